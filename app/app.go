@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -15,22 +17,54 @@ import (
 	"github.com/kochabx/kit/transport"
 )
 
-var ErrAlreadyRunning = errors.New("application is already running")
+var (
+	ErrAlreadyRunning = errors.New("application is already running")
+	ErrNotRunning     = errors.New("application is not running")
+	ErrServerNotReady = errors.New("server not ready")
+	ErrServerNotFound = errors.New("server not found")
+)
+
+const (
+	defaultServerStartTimeout = 10 * time.Second
+	readinessPollInterval     = 50 * time.Millisecond
+)
 
 // builder — 两阶段构建：先收集配置，New() 中一次性创建容器
 type builder struct {
-	ctx             context.Context
-	shutdownTimeout time.Duration
-	signals         []os.Signal
-	container       *cx.Container
-	cxOpts          []cx.Option
-	servers         []transport.Server
-	components      []component
+	ctx                context.Context
+	shutdownTimeout    time.Duration
+	serverStartTimeout time.Duration
+	signals            []os.Signal
+	container          *cx.Container
+	cxOpts             []cx.Option
+	servers            []serverEntry
+	serverDeps         map[string][]string // 具名 server → 启动前需就绪的依赖
+	serverPolicies     map[string]ServerPolicy
+	components         []component
 }
 
-type component struct {
+type serverEntry struct {
+	name  string // 为空时为匿名 server
+	srv   transport.Server
+	probe func() error
+}
+
+// anonServer 记录匿名 server 及其容器 key。
+type anonServer struct {
+	key string
+	srv transport.Server
+}
+
+// readiness 记录一个待探测的 server。
+type readiness struct {
 	key   string
-	value any
+	probe func() error
+}
+
+type component struct {
+	key     string
+	value   any
+	profile string // 为空时无条件注册
 }
 
 // Option 配置 Application。
@@ -54,6 +88,15 @@ func WithShutdownTimeout(d time.Duration) Option {
 	}
 }
 
+// WithServerStartTimeout 设置每个 server 就绪探测的最长等待时间，默认 10s。
+func WithServerStartTimeout(d time.Duration) Option {
+	return func(b *builder) {
+		if d > 0 {
+			b.serverStartTimeout = d
+		}
+	}
+}
+
 // WithSignals 设置触发优雅关闭的系统信号。
 func WithSignals(signals ...os.Signal) Option {
 	return func(b *builder) {
@@ -77,17 +120,73 @@ func WithContainer(c *cx.Container) Option {
 func WithServer(server transport.Server) Option {
 	return func(b *builder) {
 		if server != nil {
-			b.servers = append(b.servers, server)
+			b.servers = append(b.servers, serverEntry{srv: server})
+		}
+	}
+}
+
+// WithReadyServer 注册一个带就绪探测的 transport.Server。
+// Run 在容器启动后轮询 probe 直到其返回 nil；若超过 WithServerStartTimeout
+// 仍未就绪，则中止启动、关闭已启动的组件并返回 ErrServerNotReady。
+func WithReadyServer(srv transport.Server, probe func() error) Option {
+	return func(b *builder) {
+		if srv != nil {
+			b.servers = append(b.servers, serverEntry{srv: srv, probe: probe})
 		}
 	}
 }
 
+// WithNamedServer 以名称注册一个 transport.Server，运行期间可通过
+// RestartServer / ReplaceServer 单独重启，而不影响其他组件。名称重复时 New 会 panic。
+func WithNamedServer(name string, srv transport.Server) Option {
+	return func(b *builder) {
+		if name != "" && srv != nil {
+			b.servers = append(b.servers, serverEntry{name: name, srv: srv})
+		}
+	}
+}
+
+// WithNamedReadyServer 以名称注册一个带就绪探测的 transport.Server。
+// 与 WithReadyServer 不同，具名 server 的启动会阻塞到 probe 返回 nil
+// (最长 WithServerStartTimeout)，因此依赖它的 server 只会在它就绪后才开始监听。
+func WithNamedReadyServer(name string, srv transport.Server, probe func() error) Option {
+	return func(b *builder) {
+		if name != "" && srv != nil {
+			b.servers = append(b.servers, serverEntry{name: name, srv: srv, probe: probe})
+		}
+	}
+}
+
+// WithServerDependsOn 声明具名 server 的启动依赖：deps 中的每一项先于 name 启动、
+// 后于 name 关闭。deps 可以是具名 server 的名称，也可以是 WithComponent 注册的组件 key，
+// 例如让数据库迁移组件与 metrics server 就绪后，公网 HTTP server 才开始监听：
+//
+//	app.New(
+//		app.WithComponent("migrate", migrator),
+//		app.WithNamedReadyServer("metrics", metricsSrv, app.TCPProbe(":9090")),
+//		app.WithNamedServer("public", publicSrv),
+//		app.WithServerDependsOn("public", "metrics", "migrate"),
+//	)
+//
+// 依赖不存在或形成环时 Run 返回错误。
+func WithServerDependsOn(name string, deps ...string) Option {
+	return func(b *builder) {
+		if name == "" || len(deps) == 0 {
+			return
+		}
+		if b.serverDeps == nil {
+			b.serverDeps = make(map[string][]string)
+		}
+		b.serverDeps[name] = append(b.serverDeps[name], deps...)
+	}
+}
+
 // WithServers 注册多个 transport.Server。
 func WithServers(servers ...transport.Server) Option {
 	return func(b *builder) {
 		for _, s := range servers {
 			if s != nil {
-				b.servers = append(b.servers, s)
+				b.servers = append(b.servers, serverEntry{srv: s})
 			}
 		}
 	}
@@ -103,6 +202,22 @@ func WithComponent(key string, value any) Option {
 	}
 }
 
+// WithComponentWhen 注册一个仅在 profile 表达式命中时生效的组件，表达式语法见 cx.Container.ProfileActive。
+func WithComponentWhen(profile, key string, value any) Option {
+	return func(b *builder) {
+		if key != "" && value != nil {
+			b.components = append(b.components, component{key: key, value: value, profile: profile})
+		}
+	}
+}
+
+// WithProfiles 设置内部容器激活的 profile（使用 WithContainer 时由外部容器自行配置）。
+func WithProfiles(profiles ...string) Option {
+	return func(b *builder) {
+		b.cxOpts = append(b.cxOpts, cx.WithProfiles(profiles...))
+	}
+}
+
 // WithOnStart 注册一个在所有组件启动前执行的钩子。
 func WithOnStart(fn func(ctx context.Context) error) Option {
 	return func(b *builder) {
@@ -145,19 +260,93 @@ func WithOnStop(fn func(ctx context.Context) error) Option {
 
 // Application 管理应用的生命周期，包括启动、停止和健康检查。
 type Application struct {
-	container       *cx.Container
-	ctx             context.Context
-	cancel          context.CancelFunc
-	shutdownTimeout time.Duration
-	signals         []os.Signal
-	running         atomic.Bool
+	container          *cx.Container
+	ctx                context.Context
+	cancel             context.CancelFunc
+	shutdownTimeout    time.Duration
+	serverStartTimeout time.Duration
+	readiness          []readiness
+	named              map[string]*namedServer
+	anonymous          []anonServer
+	signals            []os.Signal
+	events             *eventBus
+	failMu             sync.Mutex
+	failErr            error // 导致应用退出的 server 失败
+	running            atomic.Bool
+	ready              chan struct{}
+	readyOnce          sync.Once
+}
+
+// namedServer 是注册到容器中的具名 server 包装，重启 / 替换都在其内部进行，
+// 容器关闭时停止的总是当前实例。
+type namedServer struct {
+	mu      sync.Mutex
+	name    string
+	srv     transport.Server
+	probe   func() error  // 不为 nil 时启动阻塞到就绪
+	timeout time.Duration // 就绪探测的最长等待时间
+	down    bool          // 重启时启动失败，当前实例处于停止状态
+	events  *eventBus
+	policy  ServerPolicy
+	failed  chan error    // 当前实例的运行期失败，由 supervise 消费
+	unwatch chan struct{} // 关闭以停止转发当前实例的失败
+}
+
+// Start 启动 server，失败时按监督策略退避重试。
+func (n *namedServer) Start(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		err := n.startLocked(ctx)
+		if err == nil {
+			return nil
+		}
+		n.events.emit(Event{Type: EventServerError, Server: n.name, Err: err})
+		if attempt > n.policy.MaxRestarts {
+			return err
+		}
+		delay := n.policy.backoff(attempt)
+		log.Warn().Str("server", n.name).Err(err).Int("attempt", attempt).Dur("backoff", delay).Msg("retrying server start")
+		if !sleepCtx(ctx, delay) {
+			return err
+		}
+	}
+}
+
+// startLocked 启动当前实例并等待就绪；未就绪时停止该实例，避免遗留监听。
+func (n *namedServer) startLocked(ctx context.Context) error {
+	if err := n.srv.Start(ctx); err != nil {
+		return err
+	}
+	if n.probe != nil {
+		if err := pollReady(ctx, n.probe, n.timeout); err != nil {
+			stopCtx, cancel := context.WithTimeout(context.Background(), n.timeout)
+			defer cancel()
+			_ = n.srv.Stop(stopCtx)
+			return fmt.Errorf("%w: %s: %w", ErrServerNotReady, n.name, err)
+		}
+		log.Debug().Str("server", n.name).Msg("server ready")
+	}
+	n.watchLocked()
+	return nil
+}
+
+func (n *namedServer) Stop(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.unwatchLocked()
+	if n.down {
+		return nil
+	}
+	return n.srv.Stop(ctx)
 }
 
 // New 使用给定选项创建新的应用实例。
 func New(options ...Option) *Application {
 	b := &builder{
-		shutdownTimeout: 30 * time.Second,
-		signals:         []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT},
+		shutdownTimeout:    30 * time.Second,
+		serverStartTimeout: defaultServerStartTimeout,
+		signals:            []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT},
 	}
 
 	for _, opt := range options {
@@ -172,14 +361,39 @@ func New(options ...Option) *Application {
 	}
 
 	// 将 servers 注册为 cx 组件（transport.Server 已实现 cx.Starter/cx.Stopper）
+	var probes []readiness
+	var anonymous []anonServer
+	events := &eventBus{}
+	named := make(map[string]*namedServer)
+	for _, s := range b.servers {
+		if s.name != "" {
+			named[s.name] = &namedServer{
+				name:    s.name,
+				srv:     s.srv,
+				probe:   s.probe,
+				timeout: b.serverStartTimeout,
+				events:  events,
+				policy:  b.serverPolicies[s.name],
+				failed:  make(chan error, 1),
+			}
+		}
+	}
 	for i, s := range b.servers {
+		if s.name != "" {
+			registerNamedServer(container, named, s.name, b.serverDeps[s.name])
+			continue
+		}
 		key := fmt.Sprintf("app:server:%d", i)
-		cx.MustSupply(container, key, s)
+		cx.MustSupply(container, key, s.srv)
+		anonymous = append(anonymous, anonServer{key: key, srv: s.srv})
+		if s.probe != nil {
+			probes = append(probes, readiness{key: key, probe: s.probe})
+		}
 	}
 
 	// 注册自定义组件
 	for _, comp := range b.components {
-		cx.MustSupply(container, comp.key, comp.value)
+		cx.MustSupplyWhen(container, comp.profile, comp.key, comp.value)
 	}
 
 	ctx := b.ctx
@@ -189,14 +403,47 @@ func New(options ...Option) *Application {
 	ctx, cancel := context.WithCancel(ctx)
 
 	return &Application{
-		container:       container,
-		ctx:             ctx,
-		cancel:          cancel,
-		shutdownTimeout: b.shutdownTimeout,
-		signals:         b.signals,
+		container:          container,
+		ctx:                ctx,
+		cancel:             cancel,
+		shutdownTimeout:    b.shutdownTimeout,
+		serverStartTimeout: b.serverStartTimeout,
+		readiness:          probes,
+		named:              named,
+		anonymous:          anonymous,
+		signals:            b.signals,
+		events:             events,
+		ready:              make(chan struct{}),
 	}
 }
 
+// registerNamedServer 将具名 server 注册到容器。有依赖时以构造函数注册并在其中
+// Get 每个依赖，由 cx 记录依赖边：依赖先启动、后关闭。名称重复时 panic。
+func registerNamedServer(c *cx.Container, named map[string]*namedServer, name string, deps []string) {
+	ns := named[name]
+	if len(deps) == 0 {
+		cx.MustSupply(c, serverKey(name), ns)
+		return
+	}
+	cx.MustProvide(c, serverKey(name), func(c *cx.Container) (*namedServer, error) {
+		for _, dep := range deps {
+			key := dep
+			if _, ok := named[dep]; ok {
+				key = serverKey(dep)
+			}
+			if _, err := cx.Get[any](c, key); err != nil {
+				return nil, fmt.Errorf("server %s depends on %s: %w", name, dep, err)
+			}
+		}
+		return ns, nil
+	})
+}
+
+// serverKey 返回具名 server 在容器中的 key。
+func serverKey(name string) string {
+	return "app:server:" + name
+}
+
 // Container 返回底层 cx 容器，可用于注册自定义组件。
 func (app *Application) Container() *cx.Container {
 	return app.container
@@ -218,9 +465,31 @@ func (app *Application) Run() error {
 	defer app.cancel()
 
 	// 启动 cx 容器（构建组件 → onStart 钩子 → Starter.Start 按依赖序）
+	app.events.emit(Event{Type: EventStarting})
 	if err := app.container.Start(ctx); err != nil {
+		// 具名 server 的启动失败已在 namedServer.Start 中发出
+		for _, s := range app.anonymous {
+			if state, _ := app.container.ComponentState(s.key); state == cx.ComponentFailed {
+				app.events.emit(Event{Type: EventServerError, Server: s.key, Err: err})
+			}
+		}
+		err = fmt.Errorf("app: start: %w", err)
+		app.events.emit(Event{Type: EventStopped, Err: err})
+		return err
+	}
+
+	// 等待所有带探测的 server 就绪，失败则回滚已启动的组件
+	if err := app.awaitReady(ctx); err != nil {
+		if stopErr := app.shutdown(); stopErr != nil {
+			log.Error().Err(stopErr).Msg("rollback after readiness failure")
+		}
 		return fmt.Errorf("app: start: %w", err)
 	}
+	app.readyOnce.Do(func() { close(app.ready) })
+	app.events.emit(Event{Type: EventStarted})
+
+	// 监督 server 的运行期失败，关闭前先停止监督，避免与 Stop 并发重启
+	stopSupervise := app.supervise(ctx)
 
 	// 等待关闭信号
 	quit := make(chan os.Signal, 1)
@@ -230,10 +499,127 @@ func (app *Application) Run() error {
 	select {
 	case sig := <-quit:
 		log.Info().Str("signal", sig.String()).Msg("received shutdown signal")
+		app.events.emit(Event{Type: EventSignalReceived, Signal: sig})
 	case <-ctx.Done():
 	}
 
-	return app.shutdown()
+	stopSupervise()
+	err := app.shutdown()
+	if failErr := app.failure(); failErr != nil {
+		return errors.Join(fmt.Errorf("app: %w", failErr), err)
+	}
+	return err
+}
+
+// awaitReady 依次轮询每个 server 的就绪探测，单个 server 最长等待 serverStartTimeout。
+func (app *Application) awaitReady(ctx context.Context) error {
+	for _, r := range app.readiness {
+		if err := pollReady(ctx, r.probe, app.serverStartTimeout); err != nil {
+			err = fmt.Errorf("%w: %s: %w", ErrServerNotReady, r.key, err)
+			app.events.emit(Event{Type: EventServerError, Server: r.key, Err: err})
+			return err
+		}
+		log.Debug().Str("server", r.key).Msg("server ready")
+	}
+	return nil
+}
+
+// pollReady 反复调用 probe 直到返回 nil、超时或 ctx 取消。
+// 超时时返回 probe 最后一次的错误。
+func pollReady(ctx context.Context, probe func() error, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := probe()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return fmt.Errorf("not ready within %s: %w", timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// TCPProbe 返回一个就绪探测：能与 addr 建立 TCP 连接即视为就绪。
+// addr 的 host 为空时（如 ":8080"）探测本机回环地址。
+func TCPProbe(addr string) func() error {
+	return func() error {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			host = "127.0.0.1"
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), readinessPollInterval)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// RestartServer 停止并重新启动具名 server，其他组件保持运行。
+// server 必须支持在 Stop 之后再次 Start (如 transport/http.Server)；
+// 不支持复用的 server (如 transport/grpc.Server) 请使用 ReplaceServer。
+func (app *Application) RestartServer(name string) error {
+	return app.ReplaceServer(name, nil)
+}
+
+// ReplaceServer 停止具名 server 并以 srv 替换后启动，用于配置变更后以新配置重建 server。
+// srv 为 nil 时等同于 RestartServer。停止受 WithShutdownTimeout 约束；启动失败时该 server
+// 保持停止状态并返回错误，可再次调用重试，应用关闭时不会重复停止。
+func (app *Application) ReplaceServer(name string, srv transport.Server) error {
+	ns, ok := app.named[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrServerNotFound, name)
+	}
+	if !app.running.Load() || app.container.State() != cx.StateRunning {
+		return ErrNotRunning
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	ns.unwatchLocked()
+	if !ns.down {
+		stopCtx, cancel := context.WithTimeout(context.Background(), app.shutdownTimeout)
+		defer cancel()
+		if err := ns.srv.Stop(stopCtx); err != nil {
+			app.events.emit(Event{Type: EventServerError, Server: name, Err: err})
+			return fmt.Errorf("app: restart %s: stop: %w", name, err)
+		}
+	}
+	if srv != nil {
+		ns.srv = srv
+	}
+	if err := ns.startLocked(app.ctx); err != nil {
+		ns.down = true
+		app.events.emit(Event{Type: EventServerError, Server: name, Err: err})
+		return fmt.Errorf("app: restart %s: start: %w", name, err)
+	}
+	ns.down = false
+	log.Info().Str("server", name).Msg("server restarted")
+	return nil
+}
+
+// Ready 返回一个在应用就绪后关闭的 channel：所有组件已启动、所有就绪探测已通过。
+// 启动失败时该 channel 不会关闭，调用方应同时等待 Run 的返回值。
+//
+//	go func() { errCh <- application.Run() }()
+//	select {
+//	case <-application.Ready():
+//	case err := <-errCh:
+//	}
+func (app *Application) Ready() <-chan struct{} {
+	return app.ready
 }
 
 // Shutdown 触发优雅关闭。
@@ -243,13 +629,18 @@ func (app *Application) Shutdown() {
 
 // shutdown 执行优雅关闭流程。
 func (app *Application) shutdown() error {
+	app.events.emit(Event{Type: EventShuttingDown})
+
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), app.shutdownTimeout)
 	defer shutdownCancel()
 
 	if err := app.container.Stop(shutdownCtx); err != nil {
 		log.Error().Err(err).Msg("shutdown completed with errors")
-		return fmt.Errorf("app: shutdown: %w", err)
+		err = fmt.Errorf("app: shutdown: %w", err)
+		app.events.emit(Event{Type: EventStopped, Err: err})
+		return err
 	}
 
+	app.events.emit(Event{Type: EventStopped})
 	return nil
 }
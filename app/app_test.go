@@ -2,7 +2,15 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
@@ -308,3 +316,514 @@ func TestRun_ShutdownOrder(t *testing.T) {
 		t.Fatalf("expected [db-alive], got %v", order)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// 就绪探测
+// ---------------------------------------------------------------------------
+
+type fakeServer struct {
+	stopped bool
+}
+
+func (s *fakeServer) Start(context.Context) error { return nil }
+func (s *fakeServer) Stop(context.Context) error  { s.stopped = true; return nil }
+
+func TestRun_ReadyServer(t *testing.T) {
+	srv := http.NewServer(gin.New(), http.WithAddr(":18998"))
+
+	app := New(
+		WithReadyServer(srv, TCPProbe(":18998")),
+		WithServerStartTimeout(2*time.Second),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+
+	time.Sleep(100 * time.Millisecond)
+	app.Shutdown()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+	}
+}
+
+func TestRun_ServerNotReady(t *testing.T) {
+	srv := &fakeServer{}
+	probeErr := errors.New("port not bound")
+
+	app := New(
+		WithReadyServer(srv, func() error { return probeErr }),
+		WithServerStartTimeout(150*time.Millisecond),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrServerNotReady) || !errors.Is(err, probeErr) {
+			t.Fatalf("expected ErrServerNotReady wrapping probe error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "app:server:0") {
+			t.Fatalf("expected error to name the server, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not abort on readiness timeout")
+	}
+
+	if !srv.stopped {
+		t.Fatal("expected started server to be stopped after readiness failure")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 启动顺序
+// ---------------------------------------------------------------------------
+
+type orderedServer struct {
+	name  string
+	order *[]string
+	mu    *sync.Mutex
+}
+
+func (s *orderedServer) record(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.order = append(*s.order, event+":"+s.name)
+}
+
+func (s *orderedServer) Start(context.Context) error { s.record("start"); return nil }
+func (s *orderedServer) Stop(context.Context) error  { s.record("stop"); return nil }
+
+func TestRun_ServerDependsOn(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+		ready atomic.Int32
+	)
+	newSrv := func(name string) *orderedServer { return &orderedServer{name: name, order: &order, mu: &mu} }
+
+	// metrics 的探测第三次才成功：public 必须等它就绪后才启动
+	metricsProbe := func() error {
+		if ready.Add(1) < 3 {
+			return errors.New("not listening")
+		}
+		return nil
+	}
+
+	app := New(
+		WithNamedServer("public", newSrv("public")),
+		WithNamedReadyServer("metrics", newSrv("metrics"), metricsProbe),
+		WithComponent("migrate", newSrv("migrate")),
+		WithServerDependsOn("public", "metrics", "migrate"),
+		WithServerStartTimeout(2*time.Second),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+
+	select {
+	case <-app.Ready():
+	case err := <-done:
+		t.Fatalf("Run returned before ready: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("application did not become ready")
+	}
+
+	mu.Lock()
+	started := append([]string(nil), order...)
+	mu.Unlock()
+	want := []string{"start:metrics", "start:migrate", "start:public"}
+	if !slices.Equal(started, want) {
+		t.Fatalf("start order = %v, want %v", started, want)
+	}
+	if ready.Load() < 3 {
+		t.Fatalf("public started before metrics was ready (probe calls: %d)", ready.Load())
+	}
+
+	app.Shutdown()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order[3] != "stop:public" {
+		t.Fatalf("expected public to stop first, got %v", order[3:])
+	}
+}
+
+func TestRun_ServerDependsOnMissing(t *testing.T) {
+	app := New(
+		WithNamedServer("public", &fakeServer{}),
+		WithServerDependsOn("public", "nope"),
+	)
+	if err := app.Run(); !errors.Is(err, cx.ErrComponentNotFound) {
+		t.Fatalf("expected ErrComponentNotFound, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 调试 server
+// ---------------------------------------------------------------------------
+
+func TestWithDebugServer(t *testing.T) {
+	app := New(WithDebugServer("127.0.0.1:18996"))
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	select {
+	case <-app.Ready():
+	case err := <-done:
+		t.Fatalf("Run returned early: %v", err)
+	}
+
+	get := func(path string) (int, []byte) {
+		t.Helper()
+		resp, err := nethttp.Get("http://127.0.0.1:18996" + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/vars"} {
+		if code, _ := get(path); code != nethttp.StatusOK {
+			t.Errorf("GET %s = %d", path, code)
+		}
+	}
+
+	code, body := get("/debug/runtime")
+	var info runtimeInfo
+	if err := json.Unmarshal(body, &info); err != nil || code != nethttp.StatusOK {
+		t.Fatalf("GET /debug/runtime = %d, %v: %s", code, err, body)
+	}
+	if info.GoVersion == "" || info.NumGoroutine == 0 || info.Memory.Sys == 0 {
+		t.Errorf("incomplete runtime info: %+v", info)
+	}
+
+	// 以名称注册，可单独重启
+	if err := app.RestartServer(DebugServerName); err != nil {
+		t.Fatalf("RestartServer: %v", err)
+	}
+	if err := pollReady(context.Background(), TCPProbe("127.0.0.1:18996"), 2*time.Second); err != nil {
+		t.Fatalf("debug server not ready after restart: %v", err)
+	}
+
+	app.Shutdown()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 具名 server 重启
+// ---------------------------------------------------------------------------
+
+type countingServer struct {
+	starts, stops int
+	startErr      error
+}
+
+func (s *countingServer) Start(context.Context) error { s.starts++; return s.startErr }
+func (s *countingServer) Stop(context.Context) error  { s.stops++; return nil }
+
+func TestRestartServer(t *testing.T) {
+	other := &countingServer{}
+	app := New(
+		WithNamedServer("http", http.NewServer(gin.New(), http.WithAddr(":18997"))),
+		WithNamedServer("other", other),
+	)
+
+	if err := app.RestartServer("http"); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning before Run, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	if err := pollReady(context.Background(), TCPProbe(":18997"), 2*time.Second); err != nil {
+		t.Fatalf("server not ready: %v", err)
+	}
+
+	if err := app.RestartServer("http"); err != nil {
+		t.Fatalf("RestartServer: %v", err)
+	}
+	if err := pollReady(context.Background(), TCPProbe(":18997"), 2*time.Second); err != nil {
+		t.Fatalf("server not ready after restart: %v", err)
+	}
+	if other.starts != 1 || other.stops != 0 {
+		t.Fatalf("other server should be untouched, got starts=%d stops=%d", other.starts, other.stops)
+	}
+
+	// 替换实例：旧实例停止，新实例启动
+	replacement := &countingServer{}
+	if err := app.ReplaceServer("http", replacement); err != nil {
+		t.Fatalf("ReplaceServer: %v", err)
+	}
+	if err := TCPProbe(":18997")(); err == nil {
+		t.Fatal("expected old http server to be stopped")
+	}
+
+	// 启动失败后保持停止，关闭时不重复停止
+	failing := &countingServer{startErr: errors.New("bind failed")}
+	if err := app.ReplaceServer("other", failing); err == nil {
+		t.Fatal("expected start error")
+	}
+
+	if err := app.RestartServer("missing"); !errors.Is(err, ErrServerNotFound) {
+		t.Fatalf("expected ErrServerNotFound, got %v", err)
+	}
+
+	app.Shutdown()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+	}
+	if replacement.starts != 1 || replacement.stops != 1 {
+		t.Fatalf("expected replacement to be started and stopped once, got starts=%d stops=%d", replacement.starts, replacement.stops)
+	}
+	if other.stops != 1 || failing.stops != 0 {
+		t.Fatalf("unexpected stops: other=%d failing=%d", other.stops, failing.stops)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 监督策略
+// ---------------------------------------------------------------------------
+
+// crashingServer 实现 transport.Failer，可模拟启动失败与运行期崩溃。
+type crashingServer struct {
+	mu        sync.Mutex
+	starts    int
+	startErrs int // 前 startErrs 次启动失败
+	failed    chan error
+}
+
+func (s *crashingServer) Start(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starts++
+	if s.starts <= s.startErrs {
+		return errors.New("address already in use")
+	}
+	s.failed = make(chan error, 1)
+	return nil
+}
+
+func (s *crashingServer) Stop(context.Context) error { return nil }
+
+func (s *crashingServer) Failed() <-chan error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failed
+}
+
+func (s *crashingServer) crash() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed <- errors.New("listener closed")
+}
+
+func (s *crashingServer) startCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.starts
+}
+
+func TestRun_ServerPolicy(t *testing.T) {
+	srv := &crashingServer{startErrs: 1}
+	app := New(
+		WithNamedServer("flaky", srv),
+		WithServerPolicy("flaky", ServerPolicy{MaxRestarts: 2, Backoff: 10 * time.Millisecond}),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	<-app.Ready()
+	if n := srv.startCount(); n != 2 {
+		t.Fatalf("expected start to be retried once, got %d starts", n)
+	}
+
+	// 两次运行期崩溃均被重启
+	for want := 3; want <= 4; want++ {
+		srv.crash()
+		deadline := time.Now().Add(2 * time.Second)
+		for srv.startCount() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d starts, got %d", want, srv.startCount())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// 超过重启次数后关闭应用
+	srv.crash()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "listener closed") {
+			t.Fatalf("expected server failure, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after giving up")
+	}
+}
+
+func TestRun_AnonymousServerFailure(t *testing.T) {
+	srv := &crashingServer{}
+	app := New(WithServer(srv))
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	<-app.Ready()
+
+	srv.crash()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "app:server:0") {
+			t.Fatalf("expected server failure, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after server failure")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 配置组装
+// ---------------------------------------------------------------------------
+
+func TestFromConfig(t *testing.T) {
+	cfg := Config{
+		Signals: []string{"int", "SIGTERM"},
+		HTTP:    HTTPConfig{Addr: "127.0.0.1:18994", HealthPath: "/healthz"},
+	}
+	handler := nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		_, _ = io.WriteString(w, "hello")
+	})
+	a, err := FromConfig(cfg, Handlers{HTTP: handler})
+	if err != nil {
+		t.Fatalf("FromConfig: %v", err)
+	}
+	if a.shutdownTimeout != 30*time.Second || len(a.signals) != 2 || a.signals[1] != syscall.SIGTERM {
+		t.Fatalf("unexpected defaults: timeout=%v signals=%v", a.shutdownTimeout, a.signals)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+	<-a.Ready()
+
+	for path, want := range map[string]string{"/": "hello", "/healthz": `{"status":"ok"}`} {
+		resp, err := nethttp.Get("http://127.0.0.1:18994" + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Fatalf("GET %s = %q, want %q", path, body, want)
+		}
+	}
+	if err := a.RestartServer(HTTPServerName); err != nil {
+		t.Fatalf("RestartServer: %v", err)
+	}
+
+	a.Shutdown()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFromConfig_Invalid(t *testing.T) {
+	if _, err := FromConfig(Config{HTTP: HTTPConfig{Addr: ":18993"}}, Handlers{}); err == nil {
+		t.Fatal("expected error for http server without handler")
+	}
+	if _, err := FromConfig(Config{GRPC: GRPCConfig{Addr: ":18993"}}, Handlers{}); err == nil {
+		t.Fatal("expected error for grpc server without registrar")
+	}
+	if _, err := FromConfig(Config{Signals: []string{"SIGNOPE"}}, Handlers{}); err == nil {
+		t.Fatal("expected error for unknown signal")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 生命周期事件
+// ---------------------------------------------------------------------------
+
+func TestSubscribe(t *testing.T) {
+	app := New(WithNamedServer("http", &countingServer{}))
+
+	var mu sync.Mutex
+	var got []EventType
+	app.Subscribe(func(ev Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, ev.Type)
+	})
+	unsubscribe := app.Subscribe(func(Event) { t.Error("unsubscribed callback called") })
+	unsubscribe()
+	app.Subscribe(func(Event) { panic("boom") }) // panic 不影响生命周期
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	<-app.Ready()
+	app.Shutdown()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []EventType{EventStarting, EventStarted, EventShuttingDown, EventStopped}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestSubscribe_ServerError(t *testing.T) {
+	startErr := errors.New("bind failed")
+	app := New(
+		WithNamedServer("http", &countingServer{startErr: startErr}),
+		WithServer(&countingServer{startErr: startErr}),
+	)
+
+	var events []Event
+	app.Subscribe(func(ev Event) { events = append(events, ev) })
+
+	if err := app.Run(); !errors.Is(err, startErr) {
+		t.Fatalf("expected start error, got %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %v", events)
+	}
+	if ev := events[1]; ev.Type != EventServerError || ev.Server != "http" || !errors.Is(ev.Err, startErr) {
+		t.Fatalf("unexpected server error event: %+v", ev)
+	}
+	if ev := events[2]; ev.Type != EventStopped || !errors.Is(ev.Err, startErr) {
+		t.Fatalf("unexpected stopped event: %+v", ev)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Profile 条件注册
+// ---------------------------------------------------------------------------
+
+func TestNew_WithComponentWhen(t *testing.T) {
+	app := New(
+		WithProfiles("prod"),
+		WithComponentWhen("prod", "cache", "redis"),
+		WithComponentWhen("!prod", "cache", "memory"),
+		WithComponentWhen("dev", "debug", "on"),
+	)
+
+	c := app.Container()
+	if c.Count() != 1 || !c.Has("cache") {
+		t.Fatalf("expected only cache to be registered, got %v", c.Keys())
+	}
+}
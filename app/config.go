@@ -0,0 +1,208 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/kochabx/kit/core/defaults"
+	kitgrpc "github.com/kochabx/kit/transport/grpc"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+// FromConfig 注册的具名 server 名称，可用于 WithServerDependsOn / RestartServer。
+const (
+	HTTPServerName      = "http"
+	GRPCServerName      = "grpc"
+	WebSocketServerName = "websocket"
+)
+
+// Config 声明式应用配置，可作为业务配置的一个字段由 config 包从 YAML / 环境变量加载：
+//
+//	app:
+//	  shutdownTimeout: 20s
+//	  http:
+//	    addr: ":8080"
+//	    healthPath: /health
+//	  grpc:
+//	    addr: ":9090"
+//	    health: true
+//	    restart:
+//	      maxRestarts: 3
+//
+// 各 server 的 Addr 为空时不启用。
+type Config struct {
+	ShutdownTimeout    time.Duration   `json:"shutdownTimeout" default:"30s"`
+	ServerStartTimeout time.Duration   `json:"serverStartTimeout" default:"10s"`
+	Signals            []string        `json:"signals"` // 如 ["SIGINT", "SIGTERM"]，为空时使用默认信号
+	Profiles           []string        `json:"profiles"`
+	DebugAddr          string          `json:"debugAddr"` // 调试 server 地址，见 WithDebugServer
+	HTTP               HTTPConfig      `json:"http"`
+	GRPC               GRPCConfig      `json:"grpc"`
+	WebSocket          WebSocketConfig `json:"websocket"`
+}
+
+// HTTPConfig HTTP server 配置。
+type HTTPConfig struct {
+	Addr         string        `json:"addr"`
+	ReadTimeout  time.Duration `json:"readTimeout" default:"10s"`
+	WriteTimeout time.Duration `json:"writeTimeout" default:"30s"`
+	IdleTimeout  time.Duration `json:"idleTimeout" default:"60s"`
+	TLSCertFile  string        `json:"tlsCertFile"`
+	TLSKeyFile   string        `json:"tlsKeyFile"`
+	HealthPath   string        `json:"healthPath"`  // 为空时不挂载健康检查端点
+	MetricsPath  string        `json:"metricsPath"` // 为空时不挂载指标端点
+	Restart      ServerPolicy  `json:"restart"`
+}
+
+// GRPCConfig gRPC server 配置。
+type GRPCConfig struct {
+	Addr       string       `json:"addr"`
+	Health     bool         `json:"health"`     // 注册 grpc.health.v1 健康检查服务
+	Reflection bool         `json:"reflection"` // 注册 server reflection 服务
+	Restart    ServerPolicy `json:"restart"`
+}
+
+// WebSocketConfig WebSocket server 配置，使用独立端口，在 Path 上挂载 Handlers.WebSocket。
+type WebSocketConfig struct {
+	Addr    string       `json:"addr"`
+	Path    string       `json:"path" default:"/ws"`
+	Restart ServerPolicy `json:"restart"`
+}
+
+// Handlers 提供 FromConfig 所启用 server 的业务实现。
+type Handlers struct {
+	// HTTP 启用 HTTP server 时必填。
+	HTTP http.Handler
+	// GRPC 启用 gRPC server 时必填，用于注册服务。
+	GRPC func(s *grpc.Server)
+	// WebSocket 启用 WebSocket server 时必填，处理握手升级。
+	WebSocket http.Handler
+}
+
+// FromConfig 根据声明式配置组装应用：超时、信号、profile 与各 server，
+// 每个 server 以 TCP 探测等待就绪，并按 Restart 配置监督。opts 在配置之后应用，可追加组件或覆盖配置。
+//
+//	var cfg struct {
+//		App app.Config `json:"app"`
+//	}
+//	_ = config.New(&cfg).Load()
+//	a, err := app.FromConfig(cfg.App, app.Handlers{HTTP: router}, app.WithComponent("db", db))
+//
+// 启用的 server 缺少对应 Handler、信号名称无法识别时返回错误。
+func FromConfig(cfg Config, handlers Handlers, opts ...Option) (*Application, error) {
+	if err := defaults.Apply(&cfg); err != nil {
+		return nil, fmt.Errorf("app: config defaults: %w", err)
+	}
+
+	options := []Option{
+		WithShutdownTimeout(cfg.ShutdownTimeout),
+		WithServerStartTimeout(cfg.ServerStartTimeout),
+		WithDebugServer(cfg.DebugAddr),
+	}
+	if len(cfg.Profiles) > 0 {
+		options = append(options, WithProfiles(cfg.Profiles...))
+	}
+
+	if len(cfg.Signals) > 0 {
+		signals := make([]os.Signal, 0, len(cfg.Signals))
+		for _, name := range cfg.Signals {
+			sig, err := parseSignal(name)
+			if err != nil {
+				return nil, err
+			}
+			signals = append(signals, sig)
+		}
+		options = append(options, WithSignals(signals...))
+	}
+
+	if c := cfg.HTTP; c.Addr != "" {
+		if handlers.HTTP == nil {
+			return nil, errors.New("app: http server configured without handler")
+		}
+		srvOpts := []kithttp.Option{
+			kithttp.WithAddr(c.Addr),
+			kithttp.WithName(HTTPServerName),
+			kithttp.WithTimeout(c.ReadTimeout, c.WriteTimeout, c.IdleTimeout),
+		}
+		if c.TLSCertFile != "" {
+			srvOpts = append(srvOpts, kithttp.WithTLS(c.TLSCertFile, c.TLSKeyFile))
+		}
+		if c.HealthPath != "" {
+			srvOpts = append(srvOpts, kithttp.WithHealth(kithttp.HealthOption{Path: c.HealthPath}))
+		}
+		if c.MetricsPath != "" {
+			srvOpts = append(srvOpts, kithttp.WithMetrics(kithttp.MetricsOption{Path: c.MetricsPath}))
+		}
+		options = append(options,
+			WithNamedReadyServer(HTTPServerName, kithttp.NewServer(handlers.HTTP, srvOpts...), TCPProbe(c.Addr)),
+			WithServerPolicy(HTTPServerName, c.Restart),
+		)
+	}
+
+	if c := cfg.GRPC; c.Addr != "" {
+		if handlers.GRPC == nil {
+			return nil, errors.New("app: grpc server configured without registrar")
+		}
+		srvOpts := []kitgrpc.Option{kitgrpc.WithAddr(c.Addr), kitgrpc.WithName(GRPCServerName)}
+		if c.Health {
+			srvOpts = append(srvOpts, kitgrpc.WithHealth())
+		}
+		if c.Reflection {
+			srvOpts = append(srvOpts, kitgrpc.WithReflection())
+		}
+		srv := kitgrpc.NewServer(srvOpts...)
+		handlers.GRPC(srv.Srv())
+		options = append(options,
+			WithNamedReadyServer(GRPCServerName, srv, TCPProbe(c.Addr)),
+			WithServerPolicy(GRPCServerName, c.Restart),
+		)
+	}
+
+	if c := cfg.WebSocket; c.Addr != "" {
+		if handlers.WebSocket == nil {
+			return nil, errors.New("app: websocket server configured without handler")
+		}
+		mux := http.NewServeMux()
+		mux.Handle(c.Path, handlers.WebSocket)
+		// 长连接不设读写超时，由 WebSocket 层的 ping / pong 保活
+		srv := kithttp.NewServer(mux,
+			kithttp.WithAddr(c.Addr),
+			kithttp.WithName(WebSocketServerName),
+			kithttp.WithTimeout(0, 0, 0),
+		)
+		options = append(options,
+			WithNamedReadyServer(WebSocketServerName, srv, TCPProbe(c.Addr)),
+			WithServerPolicy(WebSocketServerName, c.Restart),
+		)
+	}
+
+	return New(append(options, opts...)...), nil
+}
+
+// signalsByName 可在配置中使用的信号。
+var signalsByName = map[string]os.Signal{
+	"SIGINT":  os.Interrupt,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGHUP":  syscall.SIGHUP,
+}
+
+// parseSignal 解析信号名称，大小写不敏感，可省略 "SIG" 前缀。
+func parseSignal(name string) (os.Signal, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(key, "SIG") {
+		key = "SIG" + key
+	}
+	sig, ok := signalsByName[key]
+	if !ok {
+		return nil, fmt.Errorf("app: unknown signal %q", name)
+	}
+	return sig, nil
+}
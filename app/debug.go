@@ -0,0 +1,126 @@
+package app
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+// DebugServerName 是 WithDebugServer 注册的具名 server 名称，可用于 RestartServer。
+const DebugServerName = "debug"
+
+// WithDebugServer 在独立端口上启动调试 server，生命周期由应用管理：
+//   - /debug/pprof/   net/http/pprof 的全部 profile
+//   - /debug/vars     expvar
+//   - /debug/runtime  运行时信息 (Go 版本、goroutine 数、内存、GC、构建信息)
+//
+// 调试端口不应暴露到公网，addr 建议绑定内网或回环地址，如 "127.0.0.1:6060"。
+// server 以名称 DebugServerName 注册，不设写超时，以支持长时间的 profile / trace 采集。
+func WithDebugServer(addr string) Option {
+	return func(b *builder) {
+		if addr == "" {
+			return
+		}
+		srv := kithttp.NewServer(DebugHandler(),
+			kithttp.WithAddr(addr),
+			kithttp.WithName(DebugServerName),
+			kithttp.WithTimeout(10*time.Second, 0, 60*time.Second),
+		)
+		b.servers = append(b.servers, serverEntry{name: DebugServerName, srv: srv})
+	}
+}
+
+// DebugHandler 返回 WithDebugServer 使用的 handler，便于挂载到已有的内部 server 上。
+func DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/runtime", serveRuntimeInfo)
+	return mux
+}
+
+// processStart 进程启动时间，用于计算 uptime。
+var processStart = time.Now()
+
+// runtimeInfo 是 /debug/runtime 的响应格式。
+type runtimeInfo struct {
+	GoVersion    string    `json:"go_version"`
+	GOOS         string    `json:"goos"`
+	GOARCH       string    `json:"goarch"`
+	PID          int       `json:"pid"`
+	StartedAt    time.Time `json:"started_at"`
+	Uptime       string    `json:"uptime"`
+	NumCPU       int       `json:"num_cpu"`
+	GOMAXPROCS   int       `json:"gomaxprocs"`
+	NumGoroutine int       `json:"num_goroutine"`
+	Memory       struct {
+		Alloc       uint64 `json:"alloc"`
+		TotalAlloc  uint64 `json:"total_alloc"`
+		Sys         uint64 `json:"sys"`
+		HeapInuse   uint64 `json:"heap_inuse"`
+		HeapObjects uint64 `json:"heap_objects"`
+	} `json:"memory"`
+	GC struct {
+		NumGC        uint32    `json:"num_gc"`
+		LastGC       time.Time `json:"last_gc"`
+		PauseTotalNs uint64    `json:"pause_total_ns"`
+	} `json:"gc"`
+	Build *buildInfo `json:"build,omitempty"`
+}
+
+type buildInfo struct {
+	Path     string            `json:"path"`
+	Version  string            `json:"version"`
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+func serveRuntimeInfo(w http.ResponseWriter, _ *http.Request) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	info := runtimeInfo{
+		GoVersion:    runtime.Version(),
+		GOOS:         runtime.GOOS,
+		GOARCH:       runtime.GOARCH,
+		PID:          os.Getpid(),
+		StartedAt:    processStart,
+		Uptime:       time.Since(processStart).Round(time.Second).String(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumGoroutine: runtime.NumGoroutine(),
+	}
+	info.Memory.Alloc = ms.Alloc
+	info.Memory.TotalAlloc = ms.TotalAlloc
+	info.Memory.Sys = ms.Sys
+	info.Memory.HeapInuse = ms.HeapInuse
+	info.Memory.HeapObjects = ms.HeapObjects
+	info.GC.NumGC = ms.NumGC
+	if ms.LastGC > 0 {
+		info.GC.LastGC = time.Unix(0, int64(ms.LastGC))
+	}
+	info.GC.PauseTotalNs = ms.PauseTotalNs
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Build = &buildInfo{Path: bi.Main.Path, Version: bi.Main.Version}
+		for _, s := range bi.Settings {
+			if info.Build.Settings == nil {
+				info.Build.Settings = make(map[string]string)
+			}
+			info.Build.Settings[s.Key] = s.Value
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kochabx/kit/log"
+)
+
+// EventType 生命周期事件类型。
+type EventType int
+
+const (
+	// EventStarting 即将启动容器 (构建组件、onStart 钩子、启动 server)。
+	EventStarting EventType = iota
+	// EventStarted 所有组件已启动且就绪探测已通过，与 Ready() 关闭同时发生。
+	EventStarted
+	// EventServerError server 启动、就绪探测或重启失败。
+	EventServerError
+	// EventSignalReceived 收到关闭信号。
+	EventSignalReceived
+	// EventShuttingDown 即将停止容器，此时所有 server 仍在运行。
+	EventShuttingDown
+	// EventStopped 容器已停止；启动失败回滚后同样会发出。
+	EventStopped
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventStarting:
+		return "starting"
+	case EventStarted:
+		return "started"
+	case EventServerError:
+		return "server-error"
+	case EventSignalReceived:
+		return "signal-received"
+	case EventShuttingDown:
+		return "shutting-down"
+	case EventStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Event 生命周期事件。
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Server EventServerError 对应的 server：具名 server 为其名称，匿名 server 为容器 key。
+	Server string
+	// Signal EventSignalReceived 收到的信号。
+	Signal os.Signal
+	// Err EventServerError 的错误；EventStopped 时为启动或关闭错误，正常停止为 nil。
+	Err error
+}
+
+// eventBus 同步分发生命周期事件。
+type eventBus struct {
+	mu     sync.Mutex
+	nextID int
+	subs   []subscriber
+}
+
+type subscriber struct {
+	id int
+	fn func(Event)
+}
+
+func (b *eventBus) subscribe(fn func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, subscriber{id: id, fn: fn})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit 按订阅顺序依次调用回调；回调 panic 时记录日志并继续，不影响生命周期流程。
+func (b *eventBus) emit(ev Event) {
+	ev.Time = time.Now()
+	b.mu.Lock()
+	subs := b.subs
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().Str("event", ev.Type.String()).Interface("panic", r).Msg("lifecycle event subscriber panicked")
+				}
+			}()
+			s.fn(ev)
+		}()
+	}
+}
+
+// Subscribe 订阅生命周期事件，返回取消订阅函数。
+//
+// 回调在触发事件的 goroutine 中按订阅顺序同步执行，生命周期流程会等待其返回。
+// 因此可以在 EventShuttingDown 中完成服务注册中心的注销，确保此时 server 仍在提供服务：
+//
+//	application.Subscribe(func(ev app.Event) {
+//		switch ev.Type {
+//		case app.EventStarted:
+//			registry.Register(ctx, instance)
+//		case app.EventShuttingDown:
+//			registry.Deregister(ctx, instance)
+//		}
+//	})
+//
+// 回调不应长时间阻塞；需要在 Run 之前订阅才能收到 EventStarting。
+func (app *Application) Subscribe(fn func(Event)) (unsubscribe func()) {
+	if fn == nil {
+		return func() {}
+	}
+	return app.events.subscribe(fn)
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kochabx/kit/log"
+	"github.com/kochabx/kit/transport"
+)
+
+const (
+	defaultRestartBackoff    = time.Second
+	defaultRestartMaxBackoff = 30 * time.Second
+	defaultRestartResetAfter = time.Minute
+)
+
+// ServerPolicy 具名 server 的监督策略，作用于启动失败与运行期失败
+// (server 实现 transport.Failer 时，serve 循环在 Start 之后退出)。
+//
+// 未配置策略的 server 不重启：启动失败则启动中止，运行期失败则关闭整个应用。
+type ServerPolicy struct {
+	// MaxRestarts 放弃前最多重启次数，0 表示不重启。
+	MaxRestarts int `json:"maxRestarts"`
+	// Backoff 首次重启前的等待时间，之后每次翻倍，默认 1s。
+	Backoff time.Duration `json:"backoff"`
+	// MaxBackoff 等待时间上限，默认 30s。
+	MaxBackoff time.Duration `json:"maxBackoff"`
+	// ResetAfter 重启后稳定运行超过该时长则重新计数，默认 1m。
+	ResetAfter time.Duration `json:"resetAfter"`
+}
+
+// backoff 返回第 attempt 次 (从 1 开始) 重启前的等待时间。
+func (p ServerPolicy) backoff(attempt int) time.Duration {
+	d, limit := p.Backoff, p.MaxBackoff
+	if d <= 0 {
+		d = defaultRestartBackoff
+	}
+	if limit <= 0 {
+		limit = defaultRestartMaxBackoff
+	}
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+func (p ServerPolicy) resetAfter() time.Duration {
+	if p.ResetAfter > 0 {
+		return p.ResetAfter
+	}
+	return defaultRestartResetAfter
+}
+
+// WithServerPolicy 为具名 server 设置监督策略，使临时的监听失败不至于让进程退出：
+//
+//	app.WithNamedServer("http", srv),
+//	app.WithServerPolicy("http", app.ServerPolicy{MaxRestarts: 5, Backoff: time.Second}),
+//
+// 运行期失败后直接再次调用 Start，server 需支持在 serve 循环退出后重新启动
+// (transport/http 与 transport/grpc 的 Server 均支持)。
+func WithServerPolicy(name string, policy ServerPolicy) Option {
+	return func(b *builder) {
+		if b.serverPolicies == nil {
+			b.serverPolicies = make(map[string]ServerPolicy)
+		}
+		b.serverPolicies[name] = policy
+	}
+}
+
+// sleepCtx 等待 d 或 ctx 取消，取消时返回 false。
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// watchLocked 将当前实例的运行期失败转发到 n.failed，直到 unwatchLocked。
+func (n *namedServer) watchLocked() {
+	f, ok := n.srv.(transport.Failer)
+	if !ok {
+		return
+	}
+	ch, stop := f.Failed(), make(chan struct{})
+	n.unwatch = stop
+	go func() {
+		select {
+		case err := <-ch:
+			select {
+			case n.failed <- err:
+			default:
+			}
+		case <-stop:
+		}
+	}()
+}
+
+func (n *namedServer) unwatchLocked() {
+	if n.unwatch != nil {
+		close(n.unwatch)
+		n.unwatch = nil
+	}
+}
+
+// supervise 监督所有 server 的运行期失败，直到 ctx 取消。
+// 返回的函数取消监督并等待所有监督协程退出。
+func (app *Application) supervise(ctx context.Context) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+
+	for _, ns := range app.named {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app.superviseNamed(ctx, ns)
+		}()
+	}
+	// 匿名 server 无法单独重启，运行期失败直接关闭应用
+	for _, s := range app.anonymous {
+		f, ok := s.srv.(transport.Failer)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(ch <-chan error) {
+			defer wg.Done()
+			select {
+			case err := <-ch:
+				app.events.emit(Event{Type: EventServerError, Server: s.key, Err: err})
+				app.fail(fmt.Errorf("server %s failed: %w", s.key, err))
+			case <-ctx.Done():
+			}
+		}(f.Failed())
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// superviseNamed 按策略重启运行期失败的具名 server，超过重启次数后关闭应用。
+func (app *Application) superviseNamed(ctx context.Context, ns *namedServer) {
+	attempts := 0
+	started := time.Now()
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case err = <-ns.failed:
+		}
+
+		app.events.emit(Event{Type: EventServerError, Server: ns.name, Err: err})
+		if time.Since(started) > ns.policy.resetAfter() {
+			attempts = 0
+		}
+
+		for {
+			if attempts >= ns.policy.MaxRestarts {
+				app.fail(fmt.Errorf("server %s failed: %w", ns.name, err))
+				return
+			}
+			attempts++
+			delay := ns.policy.backoff(attempts)
+			log.Warn().Str("server", ns.name).Err(err).Int("attempt", attempts).Dur("backoff", delay).Msg("restarting failed server")
+			if !sleepCtx(ctx, delay) {
+				return
+			}
+
+			ns.mu.Lock()
+			err = ns.startLocked(ctx)
+			ns.down = err != nil
+			ns.mu.Unlock()
+			if err == nil {
+				log.Info().Str("server", ns.name).Msg("server restarted")
+				started = time.Now()
+				break
+			}
+			app.events.emit(Event{Type: EventServerError, Server: ns.name, Err: err})
+		}
+	}
+}
+
+// fail 记录导致应用退出的错误并触发关闭，只保留第一个错误。
+func (app *Application) fail(err error) {
+	app.failMu.Lock()
+	if app.failErr == nil {
+		app.failErr = err
+	}
+	app.failMu.Unlock()
+	log.Error().Err(err).Msg("shutting down after server failure")
+	app.cancel()
+}
+
+func (app *Application) failure() error {
+	app.failMu.Lock()
+	defer app.failMu.Unlock()
+	return app.failErr
+}
@@ -6,6 +6,13 @@
 //  1. Directly imports the cx package, AND
 //  2. Declares a top-level init() function.
 //
+// The wire subcommand generates static wiring code instead: it collects the
+// cx.Provide0..Provide4 registrations, orders them by their dependency keys
+// and emits a function that calls every constructor explicitly and supplies
+// the results into a container. Production builds can call the generated
+// function instead of the registrations to skip runtime graph resolution,
+// while development keeps the dynamic container.
+//
 // Install:
 //
 //	go install github.com/kochabx/kit/cmd/cxgen@latest
@@ -13,6 +20,7 @@
 // Typical go:generate directive in your main package:
 //
 //	//go:generate go run github.com/kochabx/kit/cmd/cxgen@latest gen -out cx_imports.go ./...
+//	//go:generate go run github.com/kochabx/kit/cmd/cxgen@latest wire -out cx_wire.go ./...
 package main
 
 import (
@@ -36,10 +44,11 @@ func main() {
 
 var rootCmd = &cobra.Command{
 	Use:   "cxgen",
-	Short: "Code generator for cx container blank imports",
+	Short: "Code generator for the cx container",
 	Long: `cxgen scans a Go module for packages that import the cx container
 and declare an init() function, then generates a file with blank imports
-so those init() registrations are executed at startup.`,
+so those init() registrations are executed at startup (gen), or generates
+static wiring code from the ProvideN registrations (wire).`,
 }
 
 var (
@@ -39,7 +39,7 @@ func makeModule(t *testing.T, modName string, pkgs map[string]string) string {
 			t.Fatal(err)
 		}
 		if err := os.WriteFile(filepath.Join(cxRoot, "cx.go"),
-			[]byte("package cx\n"), 0o644); err != nil {
+			[]byte(cxStub), 0o644); err != nil {
 			t.Fatal(err)
 		}
 		gomod.WriteString("\nrequire " + cxPkg + " v0.0.0\n")
@@ -213,3 +213,172 @@ func init() {}
 		t.Fatalf("cx package itself should be skipped, got %v", providers)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// wire tests
+// ---------------------------------------------------------------------------
+
+// cxStub is the subset of the cx API used by ProvideN registrations and the
+// generated wiring code.
+const cxStub = `package cx
+
+type Container struct{ Values map[string]any }
+
+type Key[T any] struct{ name string }
+
+func NewKey[T any](name string) Key[T] { return Key[T]{name: name} }
+
+func (k Key[T]) String() string { return k.name }
+
+func SupplyKey[T any](c *Container, key Key[T], value T) error {
+	c.Values[key.name] = value
+	return nil
+}
+
+func Provide0[T any](c *Container, key Key[T], ctor func() (T, error)) error { return nil }
+
+func Provide1[A, T any](c *Container, key Key[T], a Key[A], ctor func(A) (T, error)) error {
+	return nil
+}
+
+func Provide2[A, B, T any](c *Container, key Key[T], a Key[A], b Key[B], ctor func(A, B) (T, error)) error {
+	return nil
+}
+`
+
+func TestWire_GeneratesDependencyOrder(t *testing.T) {
+	root := makeModule(t, "example.com/app", map[string]string{
+		"config/config.go": `package config
+
+import "example.com/cx"
+
+type Config struct{ DSN string }
+
+var ConfigKey = cx.NewKey[*Config]("config")
+
+func New() (*Config, error) { return &Config{DSN: "mem"}, nil }
+`,
+		"db/db.go": `package db
+
+import (
+	"example.com/app/config"
+	"example.com/cx"
+)
+
+type DB struct{ DSN string }
+
+var DBKey = cx.NewKey[*DB]("db")
+
+func Open(cfg *config.Config) (*DB, error) { return &DB{DSN: cfg.DSN}, nil }
+`,
+		"main.go": `package main
+
+import (
+	"example.com/app/config"
+	"example.com/app/db"
+	"example.com/cx"
+)
+
+type Service struct{}
+
+var serviceKey = cx.NewKey[*Service]("service")
+
+func newService(d *db.DB, cfg *config.Config) (*Service, error) { return &Service{}, nil }
+
+func register(c *cx.Container) {
+	_ = cx.Provide2(c, serviceKey, db.DBKey, config.ConfigKey, newService)
+	_ = cx.Provide1(c, db.DBKey, config.ConfigKey, db.Open)
+	_ = cx.Provide0(c, config.ConfigKey, config.New)
+}
+
+func main() {}
+`,
+	})
+
+	comps, err := findComponents(root, "./...", cxPkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ordered, err := wireOrder(comps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(root, "cx_wire.go")
+	if err := writeWire(out, "main", "example.com/app", "Wire", cxPkg, ordered); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	content := string(data)
+
+	cfgAt := strings.Index(content, "config.New()")
+	dbAt := strings.Index(content, "db.Open(")
+	svcAt := strings.Index(content, "newService(")
+	if cfgAt < 0 || dbAt < 0 || svcAt < 0 || !(cfgAt < dbAt && dbAt < svcAt) {
+		t.Fatalf("constructors not called in dependency order:\n%s", content)
+	}
+	if !strings.Contains(content, "cx.SupplyKey(c, serviceKey, service)") {
+		t.Fatalf("unexported same-package key not referenced directly:\n%s", content)
+	}
+
+	// The generated file must compile against the registrations.
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go vet: %v\n%s\n%s", err, out, content)
+	}
+}
+
+func TestWire_Errors(t *testing.T) {
+	tests := map[string]struct {
+		src  string
+		want string
+	}{
+		"missing": {
+			src:  `_ = cx.Provide1(c, aKey, bKey, newA)`,
+			want: "which is not provided",
+		},
+		"cycle": {
+			src: `_ = cx.Provide1(c, aKey, bKey, newA)
+	_ = cx.Provide1(c, bKey, aKey, newB)`,
+			want: "circular dependency",
+		},
+		"closure": {
+			src:  `_ = cx.Provide0(c, bKey, func() (*B, error) { return &B{}, nil })`,
+			want: "must be a named function",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := makeModule(t, "example.com/app", map[string]string{
+				"main.go": `package main
+
+import "example.com/cx"
+
+type A struct{}
+type B struct{}
+
+var (
+	aKey = cx.NewKey[*A]("a")
+	bKey = cx.NewKey[*B]("b")
+)
+
+func newA(*B) (*A, error) { return &A{}, nil }
+func newB(*A) (*B, error) { return &B{}, nil }
+
+func register(c *cx.Container) {
+	` + tt.src + `
+}
+
+func main() {}
+`,
+			})
+			comps, err := findComponents(root, "./...", cxPkg)
+			if err == nil {
+				_, err = wireOrder(comps)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("want error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
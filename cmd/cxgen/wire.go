@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	flagWireOut  string
+	flagWireFunc string
+)
+
+var wireCmd = &cobra.Command{
+	Use:   "wire [pattern]",
+	Short: "Generate static wiring code",
+	Long: `Scan packages matching pattern (default ./...) for cx.Provide0..Provide4
+registrations and write a Go source file with a function that calls every
+constructor explicitly in dependency order and supplies the results into a
+container, so that production builds skip runtime graph resolution.
+
+Only registrations whose keys are package-level variables and whose
+constructors are named functions can be wired; anything else is reported
+as an error.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern := "./..."
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+
+		comps, err := findComponents(flagDir, pattern, flagCx)
+		if err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+		ordered, err := wireOrder(comps)
+		if err != nil {
+			return err
+		}
+
+		pkgName, pkgPath := flagPkg, ""
+		if name, path := loadOutPkg(flagWireOut); pkgName == "" {
+			pkgName, pkgPath = name, path
+		} else if name == pkgName {
+			pkgPath = path
+		}
+
+		if err := writeWire(flagWireOut, pkgName, pkgPath, flagWireFunc, flagCx, ordered); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+		cmd.Printf("wrote %d component(s) → %s\n", len(ordered), flagWireOut)
+		return nil
+	},
+}
+
+func init() {
+	wireCmd.Flags().StringVarP(&flagWireOut, "out", "o", "cx_wire.go", "output file path")
+	wireCmd.Flags().StringVarP(&flagPkg, "pkg", "p", "", "package name for the output file (default: inferred from -out directory)")
+	wireCmd.Flags().StringVarP(&flagDir, "dir", "d", ".", "directory to scan (module root)")
+	wireCmd.Flags().StringVar(&flagCx, "cx", "github.com/kochabx/kit/cx", "import path of the cx package")
+	wireCmd.Flags().StringVar(&flagWireFunc, "func", "Wire", "name of the generated function")
+
+	rootCmd.AddCommand(wireCmd)
+}
+
+// ref identifies a package-level object by import path and name. Objects are
+// compared by ref rather than by identity because a package type-checked from
+// source and the same package seen through export data yield distinct objects.
+type ref struct {
+	pkg     string
+	pkgName string
+	name    string
+}
+
+func (r ref) String() string { return r.pkg + "." + r.name }
+
+// component is one ProvideN registration found in the scanned packages.
+type component struct {
+	key  ref
+	deps []ref
+	ctor ref
+	pos  token.Position
+}
+
+// findComponents loads all packages matching pattern under dir and returns
+// the cx.ProvideN registrations in a stable order (package path, then source
+// position).
+func findComponents(dir, pattern, cxPkgPath string) ([]component, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName |
+			packages.NeedFiles |
+			packages.NeedSyntax |
+			packages.NeedTypes |
+			packages.NeedTypesInfo,
+		Dir: dir,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+
+	var comps []component
+	var errs []error
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			_, _ = fmt.Fprintf(os.Stderr, "cxgen: warning: %s\n", e.Msg)
+		}
+		if pkg.PkgPath == cxPkgPath || pkg.TypesInfo == nil {
+			continue
+		}
+		for _, f := range pkg.Syntax {
+			ast.Inspect(f, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || !isProvideN(pkg.TypesInfo, call.Fun, cxPkgPath) {
+					return true
+				}
+				comp, err := parseProvide(pkg, call)
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					comps = append(comps, comp)
+				}
+				return true
+			})
+		}
+	}
+	return comps, errors.Join(errs...)
+}
+
+// isProvideN reports whether fun refers to cx.Provide0 .. cx.ProvideN.
+func isProvideN(info *types.Info, fun ast.Expr, cxPkgPath string) bool {
+	fn, ok := info.Uses[funcIdent(fun)].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != cxPkgPath {
+		return false
+	}
+	n, ok := strings.CutPrefix(fn.Name(), "Provide")
+	if !ok || n == "" {
+		return false
+	}
+	_, err := strconv.Atoi(n)
+	return err == nil
+}
+
+// funcIdent returns the identifier naming the function in a call, looking
+// through parentheses, explicit type arguments and package qualifiers.
+func funcIdent(fun ast.Expr) *ast.Ident {
+	fun = ast.Unparen(fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f
+	case *ast.SelectorExpr:
+		return f.Sel
+	}
+	return nil
+}
+
+// parseProvide extracts the key, dependency keys and constructor from a
+// ProvideN(c, key, deps..., ctor) call.
+func parseProvide(pkg *packages.Package, call *ast.CallExpr) (component, error) {
+	pos := pkg.Fset.Position(call.Pos())
+	comp := component{pos: pos}
+	if len(call.Args) < 3 {
+		return comp, fmt.Errorf("%s: unexpected ProvideN call", pos)
+	}
+
+	args := call.Args[1:]
+	var err error
+	if comp.key, err = keyRef(pkg, args[0]); err != nil {
+		return comp, err
+	}
+	for _, a := range args[1 : len(args)-1] {
+		dep, err := keyRef(pkg, a)
+		if err != nil {
+			return comp, err
+		}
+		comp.deps = append(comp.deps, dep)
+	}
+
+	ctor := args[len(args)-1]
+	fn, ok := pkg.TypesInfo.Uses[funcIdent(ctor)].(*types.Func)
+	if !ok || fn.Type().(*types.Signature).Recv() != nil {
+		return comp, fmt.Errorf("%s: constructor for %s must be a named function", pkg.Fset.Position(ctor.Pos()), comp.key)
+	}
+	comp.ctor = ref{pkg: fn.Pkg().Path(), pkgName: fn.Pkg().Name(), name: fn.Name()}
+	return comp, nil
+}
+
+// keyRef resolves a key argument, which must name a package-level variable.
+func keyRef(pkg *packages.Package, expr ast.Expr) (ref, error) {
+	v, ok := pkg.TypesInfo.Uses[funcIdent(expr)].(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return ref{}, fmt.Errorf("%s: key must be a package-level variable", pkg.Fset.Position(expr.Pos()))
+	}
+	return ref{pkg: v.Pkg().Path(), pkgName: v.Pkg().Name(), name: v.Name()}, nil
+}
+
+// wireOrder sorts comps so that every component follows its dependencies,
+// keeping discovery order otherwise. It reports duplicate keys, dependencies
+// without a registration and cycles.
+func wireOrder(comps []component) ([]component, error) {
+	byKey := make(map[ref]int, len(comps))
+	for i, c := range comps {
+		if j, ok := byKey[c.key]; ok {
+			return nil, fmt.Errorf("%s: %s already provided at %s", c.pos, c.key, comps[j].pos)
+		}
+		byKey[c.key] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(comps))
+	ordered := make([]component, 0, len(comps))
+	var stack []ref
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		c := comps[i]
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			cycle := []string{}
+			for j, k := range stack {
+				if k == c.key {
+					for _, s := range stack[j:] {
+						cycle = append(cycle, s.String())
+					}
+					break
+				}
+			}
+			cycle = append(cycle, c.key.String())
+			return fmt.Errorf("circular dependency: %s", strings.Join(cycle, " → "))
+		}
+		state[i] = visiting
+		stack = append(stack, c.key)
+		for _, d := range c.deps {
+			j, ok := byKey[d]
+			if !ok {
+				return fmt.Errorf("%s: %s depends on %s, which is not provided", c.pos, c.key, d)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = done
+		ordered = append(ordered, c)
+		return nil
+	}
+	for i := range comps {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// loadOutPkg returns the name and import path of the package in outFile's
+// directory. Falls back to the directory's base name and an empty path.
+func loadOutPkg(outFile string) (name, path string) {
+	outAbs, err := filepath.Abs(outFile)
+	if err != nil {
+		return "main", ""
+	}
+	outDir := filepath.Dir(outAbs)
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName,
+		Dir:  outDir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil || len(pkgs) == 0 || pkgs[0].Name == "" {
+		return filepath.Base(outDir), ""
+	}
+	return pkgs[0].Name, pkgs[0].PkgPath
+}
+
+// wireWriter renders the wiring function, allocating import aliases and
+// local variable names without collisions.
+type wireWriter struct {
+	pkgPath string
+	aliases map[string]string // import path -> alias
+	names   map[string]string // import path -> package name
+	used    map[string]bool   // identifiers taken in the generated file
+}
+
+func (w *wireWriter) alias(r ref) string {
+	if a, ok := w.aliases[r.pkg]; ok {
+		return a
+	}
+	a := w.unique(r.pkgName, "pkg")
+	w.aliases[r.pkg] = a
+	w.names[r.pkg] = r.pkgName
+	return a
+}
+
+// qualify returns the expression referring to r from the output package.
+func (w *wireWriter) qualify(r ref) (string, error) {
+	if r.pkg == w.pkgPath {
+		return r.name, nil
+	}
+	if !token.IsExported(r.name) {
+		return "", fmt.Errorf("%s is not exported and cannot be referenced from the generated file", r)
+	}
+	return w.alias(r) + "." + r.name, nil
+}
+
+// varName derives a local variable name from a key variable by dropping the
+// "Key" suffix and lowercasing the leading upper-case run ("DBKey" -> "db").
+func (w *wireWriter) varName(key ref) string {
+	name := strings.TrimSuffix(key.name, "Key")
+	upper := 0
+	for upper < len(name) {
+		r, size := utf8.DecodeRuneInString(name[upper:])
+		if !unicode.IsUpper(r) {
+			break
+		}
+		upper += size
+	}
+	if upper > 1 && upper < len(name) {
+		upper-- // keep the first letter of the next word: "HTTPServer" -> "httpServer"
+	}
+	name = strings.ToLower(name[:upper]) + name[upper:]
+	return w.unique(name, "v")
+}
+
+// unique returns name, or name with a numeric suffix, not yet used in the
+// generated file; fallback replaces names that are not valid identifiers.
+func (w *wireWriter) unique(name, fallback string) string {
+	if name == "" || token.IsKeyword(name) || !token.IsIdentifier(name) {
+		name = fallback
+	}
+	candidate := name
+	for i := 2; w.used[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	w.used[candidate] = true
+	return candidate
+}
+
+// writeWire generates and writes the static wiring file.
+func writeWire(outFile, pkgName, pkgPath, funcName, cxPkgPath string, comps []component) error {
+	w := &wireWriter{
+		pkgPath: pkgPath,
+		aliases: map[string]string{},
+		names:   map[string]string{},
+		used:    map[string]bool{"c": true, "err": true, "fmt": true, "cx": true, funcName: true},
+	}
+	if cxPkgPath != pkgPath {
+		w.aliases[cxPkgPath] = "cx"
+		w.names[cxPkgPath] = filepath.Base(cxPkgPath)
+	}
+
+	var body bytes.Buffer
+	vars := make(map[ref]string, len(comps))
+	for _, c := range comps {
+		key, err := w.qualify(c.key)
+		if err != nil {
+			return err
+		}
+		ctor, err := w.qualify(c.ctor)
+		if err != nil {
+			return err
+		}
+		args := make([]string, len(c.deps))
+		for i, d := range c.deps {
+			args[i] = vars[d]
+		}
+		v := w.varName(c.key)
+		vars[c.key] = v
+
+		fmt.Fprintf(&body, "\t%s, err := %s(%s)\n", v, ctor, strings.Join(args, ", "))
+		fmt.Fprintf(&body, "\tif err != nil {\n\t\treturn fmt.Errorf(\"cx: construct %%s: %%w\", %s, err)\n\t}\n", key)
+		fmt.Fprintf(&body, "\tif err := %s(c, %s, %s); err != nil {\n\t\treturn err\n\t}\n", w.supplyFunc(cxPkgPath), key, v)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by cxgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+
+	paths := make([]string, 0, len(w.aliases)+1)
+	for p := range w.aliases {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	buf.WriteString("import (\n")
+	if len(comps) > 0 {
+		buf.WriteString("\t\"fmt\"\n\n")
+	}
+	for _, p := range paths {
+		if a := w.aliases[p]; a == w.names[p] {
+			fmt.Fprintf(&buf, "\t%q\n", p)
+		} else {
+			fmt.Fprintf(&buf, "\t%s %q\n", a, p)
+		}
+	}
+	buf.WriteString(")\n\n")
+
+	container := "*Container"
+	if cxPkgPath != pkgPath {
+		container = "*cx.Container"
+	}
+	fmt.Fprintf(&buf, "// %s constructs every component with explicit constructor calls in\n", funcName)
+	buf.WriteString("// dependency order and supplies the values into c, replacing the runtime\n")
+	buf.WriteString("// graph resolution of the equivalent ProvideN registrations.\n")
+	fmt.Fprintf(&buf, "func %s(c %s) error {\n", funcName, container)
+	buf.Write(body.Bytes())
+	buf.WriteString("\treturn nil\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		// Write raw bytes so the user can debug the generated source.
+		src = buf.Bytes()
+	}
+
+	if dir := filepath.Dir(outFile); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("mkdir %s: %w", dir, err)
+		}
+	}
+	return os.WriteFile(outFile, src, 0o644)
+}
+
+func (w *wireWriter) supplyFunc(cxPkgPath string) string {
+	if cxPkgPath == w.pkgPath {
+		return "SupplyKey"
+	}
+	return "cx.SupplyKey"
+}
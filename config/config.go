@@ -1,7 +1,10 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"os"
+	"os/signal"
 	"reflect"
 	"sync"
 
@@ -23,7 +26,10 @@ type Config struct {
 	validate validator.Validator
 	target   any
 	loader   Loader
-	onChange func()
+	onChange []func()
+
+	reloadSignals []os.Signal
+	stopSignals   chan struct{}
 }
 
 // New creates a Config for target.
@@ -67,17 +73,80 @@ func (c *Config) Load() error {
 // Watch reloads the target whenever the underlying source changes.
 func (c *Config) Watch() error {
 	return c.loader.Watch(func() {
-		if err := c.Load(); err != nil {
+		if err := c.Reload(); err != nil {
 			log.Error().Err(err).Msg("failed to reload config after change")
-			return
 		}
+	})
+}
+
+// Reload loads the configuration again and runs the OnChange hooks on success.
+// On failure the target keeps its previous value.
+func (c *Config) Reload() error {
+	if err := c.Load(); err != nil {
+		return err
+	}
+
+	log.Info().Msg("config reloaded successfully")
 
-		log.Info().Msg("config reloaded successfully")
+	c.mu.Lock()
+	hooks := append([]func(){}, c.onChange...)
+	c.mu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+	return nil
+}
 
-		if c.onChange != nil {
-			c.onChange()
+// OnChange registers fn to run after every successful reload.
+func (c *Config) OnChange(fn func()) {
+	if fn == nil {
+		return
+	}
+	c.mu.Lock()
+	c.onChange = append(c.onChange, fn)
+	c.mu.Unlock()
+}
+
+// Start reloads the target whenever one of the signals configured with
+// WithReloadSignal is received. It does not load the configuration itself;
+// call Load first. Start and Stop make Config usable as a cx component.
+func (c *Config) Start(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.reloadSignals) == 0 || c.stopSignals != nil {
+		return nil
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, c.reloadSignals...)
+	stop := make(chan struct{})
+	c.stopSignals = stop
+
+	go func() {
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-sigs:
+				if err := c.Reload(); err != nil {
+					log.Error().Err(err).Str("signal", sig.String()).Msg("failed to reload config on signal")
+				}
+			}
 		}
-	})
+	}()
+	return nil
+}
+
+// Stop stops reloading on signals.
+func (c *Config) Stop(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopSignals != nil {
+		close(c.stopSignals)
+		c.stopSignals = nil
+	}
+	return nil
 }
 
 // prepareTarget creates an empty value with the target's concrete type.
@@ -1,14 +1,17 @@
 package config
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/spf13/viper"
 
 	"github.com/kochabx/kit/core/validator"
+	"github.com/kochabx/kit/cx"
 )
 
 type api struct {
@@ -223,3 +226,78 @@ func TestNewRejectsInvalidTargetOnLoad(t *testing.T) {
 		t.Fatal("expected invalid target error")
 	}
 }
+
+func TestProvideWithContainer(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("server:\n  host: before\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := cx.New()
+	loader := NewFileLoader("config.yaml", []string{dir}, viper.New(), validator.Validate)
+	if err := Provide[mock](c, "config", WithLoader(loader), WithReloadSignal()); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := make(chan string, 1)
+	if err := cx.Provide(c, "server", func(c *cx.Container) (*server, error) {
+		cfg, err := cx.Get[*mock](c, "config")
+		if err != nil {
+			return nil, err
+		}
+		manager, err := cx.Get[*Config](c, ManagerKey("config"))
+		if err != nil {
+			return nil, err
+		}
+		manager.OnChange(func() { changed <- cfg.Server.Host })
+		return &cfg.Server, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Stop(ctx) })
+
+	srv := cx.MustGet[*server](c, "server")
+	if srv.Host != "before" || srv.Port != 80 {
+		t.Fatalf("unexpected server config: %+v", srv)
+	}
+
+	if err := os.WriteFile(configFile, []byte("server:\n  host: after\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("cannot send SIGHUP: %v", err)
+	}
+
+	select {
+	case host := <-changed:
+		if host != "after" || srv.Host != "after" {
+			t.Fatalf("expected reloaded host, hook saw %q, component sees %q", host, srv.Host)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for SIGHUP reload")
+	}
+}
+
+func TestProvideFailsOnInvalidConfig(t *testing.T) {
+	type required struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	c := cx.New()
+	if err := Provide[required](c, "config", WithLoader(NewFileLoader("missing.yaml", []string{t.TempDir()}, viper.New(), validator.Validate))); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail when configuration cannot be loaded")
+	}
+}
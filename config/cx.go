@@ -0,0 +1,45 @@
+package config
+
+import (
+	"github.com/kochabx/kit/cx"
+)
+
+// ManagerKey returns the key under which Provide registers the *Config that
+// manages the configuration registered under key.
+func ManagerKey(key string) string {
+	return key + ":manager"
+}
+
+// Provide registers a configuration struct of type T with container c, so
+// components can depend on it instead of loading files themselves:
+//
+//	config.Provide[AppConfig](cx.C, "config", config.WithReloadSignal())
+//	cx.Provide(cx.C, "db", func(c *cx.Container) (*DB, error) {
+//		cfg, err := cx.Get[*AppConfig](c, "config")
+//		...
+//	})
+//
+// Two components are registered:
+//   - key: the loaded *T. Construction fails if loading or validation fails.
+//   - ManagerKey(key): the *Config managing it. It is started before any
+//     component that depends on key and, with WithReloadSignal, reloads *T in
+//     place on SIGHUP. Dependents can use it to register OnChange hooks.
+func Provide[T any](c *cx.Container, key string, opts ...Option) error {
+	managerKey := ManagerKey(key)
+	if err := cx.Provide(c, managerKey, func(*cx.Container) (*Config, error) {
+		cfg := New(new(T), opts...)
+		if err := cfg.Load(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}); err != nil {
+		return err
+	}
+	return cx.Provide(c, key, func(c *cx.Container) (*T, error) {
+		cfg, err := cx.Get[*Config](c, managerKey)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.target.(*T), nil
+	})
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"syscall"
+
 	"github.com/kochabx/kit/core/validator"
 	"github.com/spf13/viper"
 )
@@ -29,9 +32,22 @@ func WithLoader(loader Loader) Option {
 	}
 }
 
-// WithOnChange registers fn to run after a successful watched reload.
+// WithOnChange registers fn to run after a successful reload.
 func WithOnChange(fn func()) Option {
 	return func(c *Config) {
-		c.onChange = fn
+		if fn != nil {
+			c.onChange = append(c.onChange, fn)
+		}
+	}
+}
+
+// WithReloadSignal makes Start reload the configuration when one of sigs is
+// received. Without arguments it uses SIGHUP.
+func WithReloadSignal(sigs ...os.Signal) Option {
+	return func(c *Config) {
+		if len(sigs) == 0 {
+			sigs = []os.Signal{syscall.SIGHUP}
+		}
+		c.reloadSignals = sigs
 	}
 }
@@ -13,3 +13,30 @@ type Authenticator interface {
 	// Refresh 刷新 token
 	Refresh(ctx context.Context, refreshToken string, claims Claims) (*TokenPair, error)
 }
+
+// AudienceVerifier 支持按受众验证的认证器
+type AudienceVerifier interface {
+	// VerifyForAudience 验证 token，并要求其受众包含 audience
+	VerifyForAudience(ctx context.Context, tokenString, audience string, claims Claims) error
+}
+
+// TokenExchanger 支持令牌交换（RFC 8693 on-behalf-of）的认证器
+type TokenExchanger interface {
+	// Exchange 验证 subjectToken，为代理方 actor 签发仅供 audience 使用的委托 token
+	Exchange(ctx context.Context, subjectToken string, actor Actor, audience string, opts ...ExchangeOption) (*TokenPair, error)
+}
+
+// Authenticate 返回以 auth 验证 token 并解码为 T 的函数，newClaims 创建空 claims。
+// 可直接转换为 HTTP 认证中间件的认证器，JWT 与不透明 token 模式的用法相同：
+//
+//	middleware.AuthenticatorFunc[*UserClaims](jwt.Authenticate(auth, func() *UserClaims { return new(UserClaims) }))
+func Authenticate[T Claims](auth Authenticator, newClaims func() T) func(ctx context.Context, token string) (T, error) {
+	return func(ctx context.Context, token string) (T, error) {
+		claims := newClaims()
+		if err := auth.Verify(ctx, token, claims); err != nil {
+			var zero T
+			return zero, err
+		}
+		return claims, nil
+	}
+}
@@ -54,14 +54,19 @@ func New(config *Config) (*BasicAuthenticator, error) {
 
 // Generate 生成 token 对
 func (a *BasicAuthenticator) Generate(ctx context.Context, claims Claims, opts ...GenerateOption) (*TokenPair, error) {
+	options := &GenerateOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	// 生成 Access Token（JTI 会在 generator 中自动设置）
-	accessToken, err := a.generator.Generate(claims, a.config.GetAccessTokenTTL())
+	accessToken, err := a.generator.Generate(claims, a.config.GetAccessTokenTTL(), options.Audience)
 	if err != nil {
 		return nil, fmt.Errorf("generate access token: %w", err)
 	}
 
 	// 生成 Refresh Token（JTI 会在 generator 中自动设置）
-	refreshToken, err := a.generator.Generate(claims, a.config.GetRefreshTokenTTL())
+	refreshToken, err := a.generator.Generate(claims, a.config.GetRefreshTokenTTL(), options.Audience)
 	if err != nil {
 		return nil, fmt.Errorf("generate refresh token: %w", err)
 	}
@@ -78,6 +83,14 @@ func (a *BasicAuthenticator) Verify(ctx context.Context, tokenString string, cla
 	return a.generator.Parse(tokenString, claims)
 }
 
+// VerifyForAudience 验证 token，并要求其受众包含 audience
+func (a *BasicAuthenticator) VerifyForAudience(ctx context.Context, tokenString, audience string, claims Claims) error {
+	if err := a.Verify(ctx, tokenString, claims); err != nil {
+		return err
+	}
+	return checkAudience(claims, audience)
+}
+
 // Refresh 刷新 token
 func (a *BasicAuthenticator) Refresh(ctx context.Context, refreshToken string, claims Claims) (*TokenPair, error) {
 	// 验证 refresh token
@@ -85,6 +98,14 @@ func (a *BasicAuthenticator) Refresh(ctx context.Context, refreshToken string, c
 		return nil, fmt.Errorf("verify refresh token: %w", err)
 	}
 
-	// 生成新的 token 对
-	return a.Generate(ctx, claims)
+	// 生成新的 token 对（沿用原受众）
+	return a.Generate(ctx, claims, audienceOf(claims)...)
+}
+
+// audienceOf 返回沿用 claims 受众的生成选项
+func audienceOf(claims Claims) []GenerateOption {
+	if aud, err := claims.GetAudience(); err == nil && len(aud) > 0 {
+		return []GenerateOption{WithTokenAudience(aud...)}
+	}
+	return nil
 }
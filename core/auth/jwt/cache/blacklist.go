@@ -13,3 +13,11 @@ type Blacklist interface {
 	// Contains 检查 token 是否在黑名单中
 	Contains(ctx context.Context, jti string) (bool, error)
 }
+
+// BlacklistSubscriber 可选接口，由支持跨实例变更通知的黑名单实现，
+// 本地缓存借此在其他实例撤销 token 时及时失效
+type BlacklistSubscriber interface {
+	// Subscribe 订阅黑名单新增事件，对每个新加入的 jti 调用 fn；
+	// 阻塞直到 ctx 取消（返回 nil）或订阅中断（返回错误）
+	Subscribe(ctx context.Context, fn func(jti string)) error
+}
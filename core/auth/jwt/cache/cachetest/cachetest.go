@@ -0,0 +1,208 @@
+// Package cachetest 提供 cache.SessionStore 与 cache.Blacklist 实现的通用一致性测试，
+// 自定义存储后端可在自己的测试中调用 Run 验证行为与内置实现一致
+package cachetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+)
+
+// Run 对存储后端运行一致性测试。sessions 与 blacklist 应为空，测试使用以 "cachetest-" 开头的主体与 JTI
+func Run(t *testing.T, sessions cache.SessionStore, blacklist cache.Blacklist) {
+	t.Helper()
+	t.Run("SessionStore", func(t *testing.T) { testSessionStore(t, sessions) })
+	t.Run("Blacklist", func(t *testing.T) { testBlacklist(t, blacklist) })
+}
+
+func testSessionStore(t *testing.T, store cache.SessionStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	newSession := func(jti, subject string) *cache.Session {
+		return &cache.Session{
+			JTI:             jti,
+			Subject:         subject,
+			TokenType:       "refresh",
+			CreatedAt:       now,
+			ExpiresAt:       now.Add(time.Hour),
+			DeviceID:        "device-1",
+			IP:              "203.0.113.7",
+			UserAgent:       "cachetest/1.0",
+			Location:        "Shanghai, CN",
+			Label:           "cachetest device",
+			AppVersion:      "1.2.3",
+			FamilyID:        "cachetest-family",
+			FamilyCreatedAt: now,
+			Claims:          json.RawMessage(`{"sub":"cachetest"}`),
+		}
+	}
+
+	for _, jti := range []string{"cachetest-a1", "cachetest-a2"} {
+		if err := store.SaveSession(ctx, newSession(jti, "cachetest-alice")); err != nil {
+			t.Fatalf("SaveSession(%s): %v", jti, err)
+		}
+	}
+	if err := store.SaveSession(ctx, newSession("cachetest-b1", "cachetest-bob")); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := newSession("cachetest-expired", "cachetest-alice")
+	expired.ExpiresAt = now.Add(-time.Minute)
+	if err := store.SaveSession(ctx, expired); err == nil {
+		t.Error("SaveSession accepted an expired session")
+	}
+
+	got, err := store.GetSession(ctx, "cachetest-a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject != "cachetest-alice" || got.DeviceID != "device-1" || got.FamilyID != "cachetest-family" ||
+		!got.ExpiresAt.Equal(now.Add(time.Hour)) || !got.FamilyCreatedAt.Equal(now) || !got.RotatedAt.IsZero() ||
+		got.IP != "203.0.113.7" || got.UserAgent != "cachetest/1.0" || got.Location != "Shanghai, CN" || got.Label != "cachetest device" ||
+		got.AppVersion != "1.2.3" || string(got.Claims) != `{"sub":"cachetest"}` {
+		t.Errorf("GetSession returned %+v", got)
+	}
+	if _, err := store.GetSession(ctx, "cachetest-missing"); !errors.Is(err, cache.ErrSessionNotFound) {
+		t.Errorf("missing session: expected ErrSessionNotFound, got %v", err)
+	}
+
+	// 并发标记轮换：只有一个调用成功
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		marked int
+	)
+	for range 8 {
+		wg.Go(func() {
+			ok, err := store.MarkRotated(ctx, "cachetest-a2", now)
+			if err != nil {
+				t.Errorf("MarkRotated: %v", err)
+			}
+			if ok {
+				mu.Lock()
+				marked++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if marked != 1 {
+		t.Errorf("MarkRotated succeeded %d times, want 1", marked)
+	}
+	if rotated, _ := store.GetSession(ctx, "cachetest-a2"); rotated == nil || !rotated.RotatedAt.Equal(now) || !rotated.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("rotated session: %+v", rotated)
+	}
+	if ok, err := store.MarkRotated(ctx, "cachetest-a2", now.Add(time.Second)); ok || err != nil {
+		t.Errorf("MarkRotated on a rotated session = %v, %v", ok, err)
+	}
+	if _, err := store.MarkRotated(ctx, "cachetest-missing", now); !errors.Is(err, cache.ErrSessionNotFound) {
+		t.Errorf("MarkRotated on a missing session: expected ErrSessionNotFound, got %v", err)
+	}
+
+	// 并发消费：只有一个调用删除到会话
+	if err := store.SaveSession(ctx, newSession("cachetest-once", "cachetest-bob")); err != nil {
+		t.Fatal(err)
+	}
+	consumed := 0
+	for range 8 {
+		wg.Go(func() {
+			ok, err := store.ConsumeSession(ctx, "cachetest-once")
+			if err != nil {
+				t.Errorf("ConsumeSession: %v", err)
+			}
+			if ok {
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if consumed != 1 {
+		t.Errorf("ConsumeSession succeeded %d times, want 1", consumed)
+	}
+	if _, err := store.GetSession(ctx, "cachetest-once"); !errors.Is(err, cache.ErrSessionNotFound) {
+		t.Errorf("consumed session: expected ErrSessionNotFound, got %v", err)
+	}
+	if ok, err := store.ConsumeSession(ctx, "cachetest-missing"); ok || err != nil {
+		t.Errorf("ConsumeSession on a missing session = %v, %v", ok, err)
+	}
+
+	// 覆盖保存
+	got.RotatedAt = now
+	if err := store.SaveSession(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := store.GetSession(ctx, "cachetest-a1"); again == nil || !again.RotatedAt.Equal(now) {
+		t.Errorf("overwritten session: %+v", again)
+	}
+
+	list, err := store.ListSessions(ctx, "cachetest-alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jtis := sessionJTIs(list); !slices.Equal(jtis, []string{"cachetest-a1", "cachetest-a2"}) {
+		t.Errorf("ListSessions = %v", jtis)
+	}
+
+	if err := store.DeleteSession(ctx, "cachetest-a1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteSession(ctx, "cachetest-a1"); err != nil {
+		t.Errorf("deleting a missing session: %v", err)
+	}
+	list, _ = store.ListSessions(ctx, "cachetest-alice")
+	if jtis := sessionJTIs(list); !slices.Equal(jtis, []string{"cachetest-a2"}) {
+		t.Errorf("ListSessions after delete = %v", jtis)
+	}
+
+	if err := store.DeleteAllSessions(ctx, "cachetest-alice"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := store.ListSessions(ctx, "cachetest-alice"); len(list) != 0 {
+		t.Errorf("ListSessions after DeleteAllSessions = %v", sessionJTIs(list))
+	}
+	if _, err := store.GetSession(ctx, "cachetest-b1"); err != nil {
+		t.Errorf("other subject affected: %v", err)
+	}
+	_ = store.DeleteAllSessions(ctx, "cachetest-bob")
+}
+
+func testBlacklist(t *testing.T, blacklist cache.Blacklist) {
+	ctx := context.Background()
+
+	if err := blacklist.Add(ctx, "cachetest-revoked", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := blacklist.Add(ctx, "cachetest-expired", 0); err != nil {
+		t.Fatal(err)
+	}
+	for jti, want := range map[string]bool{
+		"cachetest-revoked": true,
+		"cachetest-expired": false,
+		"cachetest-unknown": false,
+	} {
+		got, err := blacklist.Contains(ctx, jti)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Contains(%s) = %v, want %v", jti, got, want)
+		}
+	}
+}
+
+func sessionJTIs(sessions []*cache.Session) []string {
+	jtis := make([]string, len(sessions))
+	for i, s := range sessions {
+		jtis[i] = s.JTI
+	}
+	slices.Sort(jtis)
+	return jtis
+}
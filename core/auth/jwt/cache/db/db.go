@@ -0,0 +1,322 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+	kitdb "github.com/kochabx/kit/store/db"
+)
+
+// sessionRecord 会话表记录
+type sessionRecord struct {
+	JTI             string    `gorm:"column:jti;primaryKey;size:64"`
+	Subject         string    `gorm:"column:subject;size:255;index"`
+	TokenType       string    `gorm:"column:token_type;size:16"`
+	CreatedAt       time.Time `gorm:"column:created_at;autoCreateTime:false"`
+	ExpiresAt       time.Time `gorm:"column:expires_at;index"`
+	DeviceID        string    `gorm:"column:device_id;size:255"`
+	IP              string    `gorm:"column:ip;size:64"`
+	UserAgent       string    `gorm:"column:user_agent;size:512"`
+	Location        string    `gorm:"column:location;size:255"`
+	Label           string    `gorm:"column:label;size:255"`
+	AppVersion      string    `gorm:"column:app_version;size:64"`
+	FamilyID        string    `gorm:"column:family_id;size:64"`
+	FamilyCreatedAt time.Time `gorm:"column:family_created_at"`
+	RotatedAt       time.Time `gorm:"column:rotated_at"`
+	Claims          []byte    `gorm:"column:claims"`
+}
+
+func newSessionRecord(s *cache.Session) *sessionRecord {
+	return &sessionRecord{
+		JTI:             s.JTI,
+		Subject:         s.Subject,
+		TokenType:       s.TokenType,
+		CreatedAt:       s.CreatedAt,
+		ExpiresAt:       s.ExpiresAt,
+		DeviceID:        s.DeviceID,
+		IP:              s.IP,
+		UserAgent:       s.UserAgent,
+		Location:        s.Location,
+		Label:           s.Label,
+		AppVersion:      s.AppVersion,
+		FamilyID:        s.FamilyID,
+		FamilyCreatedAt: s.FamilyCreatedAt,
+		RotatedAt:       s.RotatedAt,
+		Claims:          s.Claims,
+	}
+}
+
+func (r *sessionRecord) session() *cache.Session {
+	return &cache.Session{
+		JTI:             r.JTI,
+		Subject:         r.Subject,
+		TokenType:       r.TokenType,
+		CreatedAt:       r.CreatedAt,
+		ExpiresAt:       r.ExpiresAt,
+		DeviceID:        r.DeviceID,
+		IP:              r.IP,
+		UserAgent:       r.UserAgent,
+		Location:        r.Location,
+		Label:           r.Label,
+		AppVersion:      r.AppVersion,
+		FamilyID:        r.FamilyID,
+		FamilyCreatedAt: r.FamilyCreatedAt,
+		RotatedAt:       r.RotatedAt,
+		Claims:          r.Claims,
+	}
+}
+
+// blacklistRecord 黑名单表记录
+type blacklistRecord struct {
+	JTI       string    `gorm:"column:jti;primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index"`
+}
+
+// SessionStore 关系数据库会话存储实现，支持 store/db 的 MySQL、PostgreSQL 与 SQLite。
+// 过期会话在读取时被忽略，需定期调用 Purge 清理
+type SessionStore struct {
+	client *kitdb.Client
+	table  string // "jwt_sessions"
+}
+
+// SessionStoreOption 会话存储选项
+type SessionStoreOption func(*SessionStore)
+
+// WithSessionTable 设置会话表名
+func WithSessionTable(table string) SessionStoreOption {
+	return func(s *SessionStore) {
+		s.table = table
+	}
+}
+
+// NewSessionStore 创建关系数据库会话存储
+func NewSessionStore(client *kitdb.Client, opts ...SessionStoreOption) *SessionStore {
+	store := &SessionStore{
+		client: client,
+		table:  "jwt_sessions",
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+func (s *SessionStore) db(ctx context.Context) *gorm.DB {
+	return s.client.DB().WithContext(ctx).Table(s.table)
+}
+
+// AutoMigrate 创建或更新会话表
+func (s *SessionStore) AutoMigrate(ctx context.Context) error {
+	return s.db(ctx).AutoMigrate(&sessionRecord{})
+}
+
+// SaveSession 保存会话
+func (s *SessionStore) SaveSession(ctx context.Context, session *cache.Session) error {
+	if session == nil {
+		return fmt.Errorf("session is nil")
+	}
+	if !session.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("session already expired")
+	}
+
+	return s.db(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(newSessionRecord(session)).Error
+}
+
+// GetSession 获取会话
+func (s *SessionStore) GetSession(ctx context.Context, jti string) (*cache.Session, error) {
+	var record sessionRecord
+	err := s.db(ctx).Where("jti = ? AND expires_at > ?", jti, time.Now()).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, cache.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+
+	return record.session(), nil
+}
+
+// DeleteSession 删除会话
+func (s *SessionStore) DeleteSession(ctx context.Context, jti string) error {
+	return s.db(ctx).Where("jti = ?", jti).Delete(&sessionRecord{}).Error
+}
+
+// ConsumeSession 删除未过期的会话，以删除的行数判断是否由本次调用删除
+func (s *SessionStore) ConsumeSession(ctx context.Context, jti string) (bool, error) {
+	result := s.db(ctx).Where("jti = ? AND expires_at > ?", jti, time.Now()).Delete(&sessionRecord{})
+	if result.Error != nil {
+		return false, fmt.Errorf("consume session: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkRotated 将会话标记为已轮换，仅在尚未轮换时写入。
+// 通过带条件的 UPDATE 实现比较并设置，未轮换的会话 rotated_at 为 NULL 或零值时间
+func (s *SessionStore) MarkRotated(ctx context.Context, jti string, at time.Time) (bool, error) {
+	now := time.Now()
+	result := s.db(ctx).
+		Where("jti = ? AND expires_at > ?", jti, now).
+		Where("rotated_at IS NULL OR rotated_at < ?", time.Unix(0, 0)).
+		Update("rotated_at", at)
+	if result.Error != nil {
+		return false, fmt.Errorf("mark session rotated: %w", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+	if _, err := s.GetSession(ctx, jti); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// DeleteAllSessions 删除用户所有会话
+func (s *SessionStore) DeleteAllSessions(ctx context.Context, subject string) error {
+	return s.db(ctx).Where("subject = ?", subject).Delete(&sessionRecord{}).Error
+}
+
+// ListSessions 列出用户所有会话
+func (s *SessionStore) ListSessions(ctx context.Context, subject string) ([]*cache.Session, error) {
+	var records []sessionRecord
+	if err := s.db(ctx).Where("subject = ? AND expires_at > ?", subject, time.Now()).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	sessions := make([]*cache.Session, len(records))
+	for i := range records {
+		sessions[i] = records[i].session()
+	}
+	return sessions, nil
+}
+
+// Purge 删除已过期的会话，返回删除的行数
+func (s *SessionStore) Purge(ctx context.Context) (int64, error) {
+	result := s.db(ctx).Where("expires_at <= ?", time.Now()).Delete(&sessionRecord{})
+	return result.RowsAffected, result.Error
+}
+
+// Blacklist 关系数据库黑名单实现
+type Blacklist struct {
+	client *kitdb.Client
+	table  string // "jwt_blacklist"
+}
+
+// BlacklistOption 黑名单选项
+type BlacklistOption func(*Blacklist)
+
+// WithBlacklistTable 设置黑名单表名
+func WithBlacklistTable(table string) BlacklistOption {
+	return func(b *Blacklist) {
+		b.table = table
+	}
+}
+
+// NewBlacklist 创建关系数据库黑名单
+func NewBlacklist(client *kitdb.Client, opts ...BlacklistOption) *Blacklist {
+	bl := &Blacklist{
+		client: client,
+		table:  "jwt_blacklist",
+	}
+
+	for _, opt := range opts {
+		opt(bl)
+	}
+
+	return bl
+}
+
+func (b *Blacklist) db(ctx context.Context) *gorm.DB {
+	return b.client.DB().WithContext(ctx).Table(b.table)
+}
+
+// AutoMigrate 创建或更新黑名单表
+func (b *Blacklist) AutoMigrate(ctx context.Context) error {
+	return b.db(ctx).AutoMigrate(&blacklistRecord{})
+}
+
+// Add 添加 token 到黑名单
+func (b *Blacklist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // 已过期的 token 不需要加入黑名单
+	}
+
+	record := &blacklistRecord{JTI: jti, ExpiresAt: time.Now().Add(ttl)}
+	return b.db(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(record).Error
+}
+
+// Contains 检查 token 是否在黑名单中
+func (b *Blacklist) Contains(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	if err := b.db(ctx).Where("jti = ? AND expires_at > ?", jti, time.Now()).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Purge 删除已过期的黑名单记录，返回删除的行数
+func (b *Blacklist) Purge(ctx context.Context) (int64, error) {
+	result := b.db(ctx).Where("expires_at <= ?", time.Now()).Delete(&blacklistRecord{})
+	return result.RowsAffected, result.Error
+}
+
+// Store 关系数据库存储（同时包含 SessionStore 和 Blacklist）
+type Store struct {
+	*SessionStore
+	*Blacklist
+}
+
+// StoreOption Store 选项
+type StoreOption func(*Store)
+
+// WithStoreTablePrefix 设置所有表名的统一前缀
+func WithStoreTablePrefix(prefix string) StoreOption {
+	return func(s *Store) {
+		s.SessionStore.table = prefix + "sessions"
+		s.Blacklist.table = prefix + "blacklist"
+	}
+}
+
+// NewStore 创建包含 SessionStore 和 Blacklist 的关系数据库存储
+func NewStore(client *kitdb.Client, opts ...StoreOption) *Store {
+	store := &Store{
+		SessionStore: NewSessionStore(client),
+		Blacklist:    NewBlacklist(client),
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+// AutoMigrate 创建或更新会话表与黑名单表
+func (s *Store) AutoMigrate(ctx context.Context) error {
+	if err := s.SessionStore.AutoMigrate(ctx); err != nil {
+		return err
+	}
+	return s.Blacklist.AutoMigrate(ctx)
+}
+
+// Purge 删除已过期的会话与黑名单记录
+func (s *Store) Purge(ctx context.Context) error {
+	if _, err := s.SessionStore.Purge(ctx); err != nil {
+		return err
+	}
+	_, err := s.Blacklist.Purge(ctx)
+	return err
+}
+
+// 确保实现接口
+var (
+	_ cache.SessionStore = (*SessionStore)(nil)
+	_ cache.Blacklist    = (*Blacklist)(nil)
+)
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+	"github.com/kochabx/kit/core/auth/jwt/cache/cachetest"
+	kitdb "github.com/kochabx/kit/store/db"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	client, err := kitdb.New(&kitdb.SQLiteConfig{FilePath: filepath.Join(t.TempDir(), "jwt.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := NewStore(client, WithStoreTablePrefix("test_jwt_"))
+	if err := store.AutoMigrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStore(t *testing.T) {
+	store := newTestStore(t)
+	cachetest.Run(t, store.SessionStore, store.Blacklist)
+}
+
+func TestStore_Purge(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	session := &cache.Session{JTI: "short", Subject: "alice", ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+	if err := store.SaveSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(ctx, "short", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := store.GetSession(ctx, "short"); err != cache.ErrSessionNotFound {
+		t.Errorf("expired session: expected ErrSessionNotFound, got %v", err)
+	}
+	n, err := store.SessionStore.Purge(ctx)
+	if err != nil || n != 1 {
+		t.Errorf("Purge = %d, %v; want 1 row", n, err)
+	}
+	if err := store.Purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := store.Contains(ctx, "short"); ok {
+		t.Error("expired blacklist entry still present")
+	}
+}
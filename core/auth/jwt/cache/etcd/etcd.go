@@ -0,0 +1,381 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+	kitetcd "github.com/kochabx/kit/store/etcd"
+)
+
+// maxTxnRetries 条件事务因并发修改失败时的最大尝试次数
+const maxTxnRetries = 3
+
+// SessionStore etcd 会话存储实现。
+// 每个会话使用一个与其有效期相同的租约，会话数据与主体索引随租约到期自动删除
+type SessionStore struct {
+	client       *kitetcd.Etcd
+	keyPrefix    string // "jwt/session/"
+	subjectIndex string // "jwt/subject/"
+}
+
+// SessionStoreOption 会话存储选项
+type SessionStoreOption func(*SessionStore)
+
+// WithSessionKeyPrefix 设置会话 key 前缀
+func WithSessionKeyPrefix(prefix string) SessionStoreOption {
+	return func(s *SessionStore) {
+		s.keyPrefix = prefix
+	}
+}
+
+// WithSubjectIndexPrefix 设置主体索引前缀
+func WithSubjectIndexPrefix(prefix string) SessionStoreOption {
+	return func(s *SessionStore) {
+		s.subjectIndex = prefix
+	}
+}
+
+// NewSessionStore 创建 etcd 会话存储
+func NewSessionStore(client *kitetcd.Etcd, opts ...SessionStoreOption) *SessionStore {
+	store := &SessionStore{
+		client:       client,
+		keyPrefix:    "jwt/session/",
+		subjectIndex: "jwt/subject/",
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+// subjectKey 主体索引 key：{subjectIndex}{subject}/{jti}
+func (s *SessionStore) subjectKey(subject, jti string) string {
+	return s.subjectIndex + subject + "/" + jti
+}
+
+// SaveSession 保存会话
+func (s *SessionStore) SaveSession(ctx context.Context, session *cache.Session) error {
+	if session == nil {
+		return fmt.Errorf("session is nil")
+	}
+	cli := s.client.GetClient()
+	if cli == nil {
+		return kitetcd.ErrEtcdNotInitialized
+	}
+
+	// 序列化会话数据
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+
+	// 计算 TTL
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("session already expired")
+	}
+
+	lease, err := cli.Grant(ctx, leaseSeconds(ttl))
+	if err != nil {
+		return fmt.Errorf("grant lease: %w", err)
+	}
+
+	// 会话数据与主体索引共用租约，在同一事务中写入
+	_, err = cli.Txn(ctx).Then(
+		clientv3.OpPut(s.keyPrefix+session.JTI, string(data), clientv3.WithLease(lease.ID)),
+		clientv3.OpPut(s.subjectKey(session.Subject, session.JTI), "", clientv3.WithLease(lease.ID)),
+	).Commit()
+	if err != nil {
+		_, _ = cli.Revoke(context.WithoutCancel(ctx), lease.ID)
+		return fmt.Errorf("save session: %w", err)
+	}
+	return nil
+}
+
+// GetSession 获取会话
+func (s *SessionStore) GetSession(ctx context.Context, jti string) (*cache.Session, error) {
+	cli := s.client.GetClient()
+	if cli == nil {
+		return nil, kitetcd.ErrEtcdNotInitialized
+	}
+
+	resp, err := cli.Get(ctx, s.keyPrefix+jti)
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, cache.ErrSessionNotFound
+	}
+
+	var session cache.Session
+	if err := json.Unmarshal(resp.Kvs[0].Value, &session); err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// DeleteSession 删除会话
+func (s *SessionStore) DeleteSession(ctx context.Context, jti string) error {
+	// 先获取会话以获得 subject
+	session, err := s.GetSession(ctx, jti)
+	if err != nil {
+		if err == cache.ErrSessionNotFound {
+			return nil // 已经不存在
+		}
+		return err
+	}
+
+	_, err = s.client.GetClient().Txn(ctx).Then(
+		clientv3.OpDelete(s.keyPrefix+jti),
+		clientv3.OpDelete(s.subjectKey(session.Subject, jti)),
+	).Commit()
+	return err
+}
+
+// ConsumeSession 删除会话，返回是否由本次调用删除。
+// 单个删除操作在 etcd 中是原子的，并发调用时只有一个删除到数据
+func (s *SessionStore) ConsumeSession(ctx context.Context, jti string) (bool, error) {
+	cli := s.client.GetClient()
+	if cli == nil {
+		return false, kitetcd.ErrEtcdNotInitialized
+	}
+
+	resp, err := cli.Delete(ctx, s.keyPrefix+jti, clientv3.WithPrevKV())
+	if err != nil {
+		return false, fmt.Errorf("consume session: %w", err)
+	}
+	if resp.Deleted == 0 || len(resp.PrevKvs) == 0 {
+		return false, nil
+	}
+
+	// 主体索引随租约到期删除，此处尽力清理
+	var session cache.Session
+	if err := json.Unmarshal(resp.PrevKvs[0].Value, &session); err == nil {
+		_, _ = cli.Delete(ctx, s.subjectKey(session.Subject, jti))
+	}
+	return true, nil
+}
+
+// MarkRotated 将会话标记为已轮换，仅在尚未轮换时写入。
+// 以读取时的 ModRevision 作为事务条件实现比较并设置，写入沿用会话原有的租约
+func (s *SessionStore) MarkRotated(ctx context.Context, jti string, at time.Time) (bool, error) {
+	cli := s.client.GetClient()
+	if cli == nil {
+		return false, kitetcd.ErrEtcdNotInitialized
+	}
+	key := s.keyPrefix + jti
+
+	for range maxTxnRetries {
+		resp, err := cli.Get(ctx, key)
+		if err != nil {
+			return false, fmt.Errorf("get session: %w", err)
+		}
+		if len(resp.Kvs) == 0 {
+			return false, cache.ErrSessionNotFound
+		}
+		kv := resp.Kvs[0]
+
+		var session cache.Session
+		if err := json.Unmarshal(kv.Value, &session); err != nil {
+			return false, fmt.Errorf("unmarshal session: %w", err)
+		}
+		if !session.RotatedAt.IsZero() {
+			return false, nil
+		}
+
+		session.RotatedAt = at
+		data, err := json.Marshal(&session)
+		if err != nil {
+			return false, fmt.Errorf("marshal session: %w", err)
+		}
+		txn, err := cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)).
+			Then(clientv3.OpPut(key, string(data), clientv3.WithIgnoreLease())).
+			Commit()
+		if err != nil {
+			return false, fmt.Errorf("mark session rotated: %w", err)
+		}
+		if txn.Succeeded {
+			return true, nil
+		}
+		// 会话在读取后被修改，重新比较
+	}
+	return false, fmt.Errorf("mark session rotated: concurrent modification")
+}
+
+// DeleteAllSessions 删除用户所有会话
+func (s *SessionStore) DeleteAllSessions(ctx context.Context, subject string) error {
+	jtis, err := s.subjectJTIs(ctx, subject)
+	if err != nil {
+		return err
+	}
+
+	if len(jtis) == 0 {
+		return nil
+	}
+
+	ops := make([]clientv3.Op, 0, len(jtis)+1)
+	for _, jti := range jtis {
+		ops = append(ops, clientv3.OpDelete(s.keyPrefix+jti))
+	}
+	ops = append(ops, clientv3.OpDelete(s.subjectIndex+subject+"/", clientv3.WithPrefix()))
+
+	_, err = s.client.GetClient().Txn(ctx).Then(ops...).Commit()
+	return err
+}
+
+// ListSessions 列出用户所有会话
+func (s *SessionStore) ListSessions(ctx context.Context, subject string) ([]*cache.Session, error) {
+	jtis, err := s.subjectJTIs(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*cache.Session, 0, len(jtis))
+	for _, jti := range jtis {
+		session, err := s.GetSession(ctx, jti)
+		if err != nil {
+			if err == cache.ErrSessionNotFound {
+				continue // 会话已过期或被删除，索引随租约一并清理
+			}
+			return nil, fmt.Errorf("get session %s: %w", jti, err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// subjectJTIs 获取主体索引中的全部 JTI
+func (s *SessionStore) subjectJTIs(ctx context.Context, subject string) ([]string, error) {
+	cli := s.client.GetClient()
+	if cli == nil {
+		return nil, kitetcd.ErrEtcdNotInitialized
+	}
+
+	prefix := s.subjectIndex + subject + "/"
+	resp, err := cli.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, fmt.Errorf("get subject jtis: %w", err)
+	}
+
+	jtis := make([]string, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		jtis[i] = string(kv.Key[len(prefix):])
+	}
+	return jtis, nil
+}
+
+// Blacklist etcd 黑名单实现
+type Blacklist struct {
+	client    *kitetcd.Etcd
+	keyPrefix string // "jwt/blacklist/"
+}
+
+// BlacklistOption 黑名单选项
+type BlacklistOption func(*Blacklist)
+
+// WithBlacklistKeyPrefix 设置黑名单 key 前缀
+func WithBlacklistKeyPrefix(prefix string) BlacklistOption {
+	return func(b *Blacklist) {
+		b.keyPrefix = prefix
+	}
+}
+
+// NewBlacklist 创建 etcd 黑名单
+func NewBlacklist(client *kitetcd.Etcd, opts ...BlacklistOption) *Blacklist {
+	bl := &Blacklist{
+		client:    client,
+		keyPrefix: "jwt/blacklist/",
+	}
+
+	for _, opt := range opts {
+		opt(bl)
+	}
+
+	return bl
+}
+
+// Add 添加 token 到黑名单
+func (b *Blacklist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // 已过期的 token 不需要加入黑名单
+	}
+	cli := b.client.GetClient()
+	if cli == nil {
+		return kitetcd.ErrEtcdNotInitialized
+	}
+
+	lease, err := cli.Grant(ctx, leaseSeconds(ttl))
+	if err != nil {
+		return fmt.Errorf("grant lease: %w", err)
+	}
+	_, err = cli.Put(ctx, b.keyPrefix+jti, "1", clientv3.WithLease(lease.ID))
+	return err
+}
+
+// Contains 检查 token 是否在黑名单中
+func (b *Blacklist) Contains(ctx context.Context, jti string) (bool, error) {
+	cli := b.client.GetClient()
+	if cli == nil {
+		return false, kitetcd.ErrEtcdNotInitialized
+	}
+
+	resp, err := cli.Get(ctx, b.keyPrefix+jti, clientv3.WithCountOnly())
+	if err != nil {
+		return false, err
+	}
+	return resp.Count > 0, nil
+}
+
+// Store etcd 存储（同时包含 SessionStore 和 Blacklist）
+type Store struct {
+	*SessionStore
+	*Blacklist
+}
+
+// StoreOption Store 选项
+type StoreOption func(*Store)
+
+// WithStoreKeyPrefix 设置所有 key 的统一前缀
+func WithStoreKeyPrefix(prefix string) StoreOption {
+	return func(s *Store) {
+		s.SessionStore.keyPrefix = prefix + "/session/"
+		s.SessionStore.subjectIndex = prefix + "/subject/"
+		s.Blacklist.keyPrefix = prefix + "/blacklist/"
+	}
+}
+
+// NewStore 创建包含 SessionStore 和 Blacklist 的 etcd 存储
+func NewStore(client *kitetcd.Etcd, opts ...StoreOption) *Store {
+	store := &Store{
+		SessionStore: NewSessionStore(client),
+		Blacklist:    NewBlacklist(client),
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+// leaseSeconds 将 TTL 向上取整为租约秒数
+func leaseSeconds(ttl time.Duration) int64 {
+	return int64(math.Ceil(ttl.Seconds()))
+}
+
+// 确保实现接口
+var (
+	_ cache.SessionStore = (*SessionStore)(nil)
+	_ cache.Blacklist    = (*Blacklist)(nil)
+)
@@ -0,0 +1,40 @@
+package etcd
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache/cachetest"
+	kitetcd "github.com/kochabx/kit/store/etcd"
+)
+
+func TestStore_Integration(t *testing.T) {
+	if os.Getenv("KIT_ETCD_INTEGRATION") == "" {
+		t.Skip("set KIT_ETCD_INTEGRATION=1 to run etcd integration tests")
+	}
+	endpoint := os.Getenv("ETCD_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "localhost:2379"
+	}
+
+	client, err := kitetcd.New(&kitetcd.Config{Endpoints: []string{endpoint}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := client.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Stop(ctx) })
+
+	prefix := "kit-test/jwt-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	t.Cleanup(func() { _, _ = client.Client.Delete(ctx, prefix, clientv3.WithPrefix()) })
+
+	store := NewStore(client, WithStoreKeyPrefix(prefix))
+	cachetest.Run(t, store.SessionStore, store.Blacklist)
+}
@@ -0,0 +1,373 @@
+// Package local 提供黑名单的进程内缓存层，减少每次 Verify 的网络往返
+package local
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+)
+
+const (
+	defaultSize           = 100000
+	defaultTTL            = time.Hour
+	defaultNegativeTTL    = 5 * time.Second
+	defaultResubscribeGap = time.Second
+)
+
+// Blacklist 带本地 LRU 缓存的黑名单，包装任意 cache.Blacklist
+//
+// 缓存两类结果：
+//   - 已撤销：撤销不可逆，缓存 TTL 时长（默认 1 小时）是安全的，超过 token 有效期后 token 本身已无法通过验证
+//   - 未撤销：缓存 NegativeTTL 时长（默认 5 秒），其间其他实例的撤销可能不可见
+//
+// 若底层黑名单实现了 cache.BlacklistSubscriber（如 Redis 黑名单），Start 后会订阅新增事件，
+// 其他实例的撤销立即写入本地缓存，未撤销结果的过期窗口仅在订阅中断时生效。
+// 同一 jti 的并发未命中合并为一次底层查询。
+type Blacklist struct {
+	next        cache.Blacklist
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu    sync.Mutex
+	lru   *list.List               // 队首为最近使用
+	items map[string]*list.Element // jti -> *entry
+	group singleflight.Group
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+	metrics       *metrics
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// entry 缓存条目
+type entry struct {
+	jti       string
+	revoked   bool
+	expiresAt time.Time
+}
+
+// Option 本地缓存选项
+type Option func(*Blacklist)
+
+// WithSize 设置最大缓存条目数，超出时淘汰最久未使用的条目
+func WithSize(size int) Option {
+	return func(b *Blacklist) {
+		if size > 0 {
+			b.size = size
+		}
+	}
+}
+
+// WithTTL 设置已撤销结果的缓存时长
+func WithTTL(ttl time.Duration) Option {
+	return func(b *Blacklist) {
+		if ttl > 0 {
+			b.ttl = ttl
+		}
+	}
+}
+
+// WithNegativeTTL 设置未撤销结果的缓存时长，即未收到变更通知时撤销生效的最大延迟；
+// 为 0 时不缓存未撤销结果
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(b *Blacklist) {
+		if ttl >= 0 {
+			b.negativeTTL = ttl
+		}
+	}
+}
+
+// WithMetrics 启用 Prometheus 指标：命中、未命中与失效次数，以及缓存条目数
+// registerer 为 nil 时注册到 prometheus.DefaultRegisterer
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(b *Blacklist) {
+		if registerer == nil {
+			registerer = prometheus.DefaultRegisterer
+		}
+		b.metrics = newMetrics(registerer, b)
+	}
+}
+
+// NewBlacklist 创建带本地缓存的黑名单
+func NewBlacklist(next cache.Blacklist, opts ...Option) *Blacklist {
+	b := &Blacklist{
+		next:        next,
+		size:        defaultSize,
+		ttl:         defaultTTL,
+		negativeTTL: defaultNegativeTTL,
+		lru:         list.New(),
+		items:       make(map[string]*list.Element),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Add 添加 token 到底层黑名单，并写入本地缓存
+func (b *Blacklist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	if err := b.next.Add(ctx, jti, ttl); err != nil {
+		return err
+	}
+	if ttl > 0 {
+		b.store(jti, true)
+	}
+	return nil
+}
+
+// AddBatch 批量添加 token 到底层黑名单并写入本地缓存，实现 cache.BlacklistBatchAdder；
+// 底层未实现该接口时逐个调用 Add
+func (b *Blacklist) AddBatch(ctx context.Context, ttls map[string]time.Duration) error {
+	batch, ok := b.next.(cache.BlacklistBatchAdder)
+	if !ok {
+		for jti, ttl := range ttls {
+			if err := b.Add(ctx, jti, ttl); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := batch.AddBatch(ctx, ttls); err != nil {
+		return err
+	}
+	for jti, ttl := range ttls {
+		if ttl > 0 {
+			b.store(jti, true)
+		}
+	}
+	return nil
+}
+
+// Contains 检查 token 是否在黑名单中，优先读取本地缓存
+func (b *Blacklist) Contains(ctx context.Context, jti string) (bool, error) {
+	if revoked, ok := b.lookup(jti); ok {
+		b.hits.Add(1)
+		if b.metrics != nil {
+			b.metrics.hits.Inc()
+		}
+		return revoked, nil
+	}
+
+	b.misses.Add(1)
+	if b.metrics != nil {
+		b.metrics.misses.Inc()
+	}
+
+	v, err, _ := b.group.Do(jti, func() (any, error) {
+		revoked, err := b.next.Contains(ctx, jti)
+		if err != nil {
+			return false, err
+		}
+		b.store(jti, revoked)
+		return revoked, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// Start 订阅底层黑名单的新增事件，底层未实现 cache.BlacklistSubscriber 时为空操作；
+// 订阅中断后清空未撤销结果并自动重新订阅
+func (b *Blacklist) Start(ctx context.Context) error {
+	sub, ok := b.next.(cache.BlacklistSubscriber)
+	if !ok || b.cancel != nil {
+		return nil
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		for {
+			err := sub.Subscribe(runCtx, b.invalidate)
+			if runCtx.Err() != nil {
+				return
+			}
+			// 订阅中断期间可能错过事件，未撤销结果不再可信
+			b.purgeNegative()
+			if err == nil {
+				continue
+			}
+			select {
+			case <-runCtx.Done():
+				return
+			case <-time.After(defaultResubscribeGap):
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止订阅
+func (b *Blacklist) Stop(ctx context.Context) error {
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	b.cancel, b.done = nil, nil
+	return nil
+}
+
+// Stats 本地缓存统计
+type Stats struct {
+	Hits          uint64 // 命中次数
+	Misses        uint64 // 未命中次数，即底层查询次数（合并前）
+	Invalidations uint64 // 收到的变更通知数
+	Size          int    // 当前缓存条目数
+}
+
+// HitRate 命中率，尚无查询时为 0
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Stats 返回本地缓存统计的快照
+func (b *Blacklist) Stats() Stats {
+	return Stats{
+		Hits:          b.hits.Load(),
+		Misses:        b.misses.Load(),
+		Invalidations: b.invalidations.Load(),
+		Size:          b.len(),
+	}
+}
+
+// invalidate 处理其他实例的撤销通知
+func (b *Blacklist) invalidate(jti string) {
+	b.invalidations.Add(1)
+	if b.metrics != nil {
+		b.metrics.invalidations.Inc()
+	}
+	b.store(jti, true)
+}
+
+// lookup 读取未过期的缓存条目
+func (b *Blacklist) lookup(jti string) (revoked, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	el, ok := b.items[jti]
+	if !ok {
+		return false, false
+	}
+	e := el.Value.(*entry)
+	if !time.Now().Before(e.expiresAt) {
+		b.removeLocked(el)
+		return false, false
+	}
+	b.lru.MoveToFront(el)
+	return e.revoked, true
+}
+
+// store 写入缓存条目。未撤销结果不会覆盖已撤销结果：
+// 底层查询与撤销通知并发时，查询可能读到撤销之前的状态
+func (b *Blacklist) store(jti string, revoked bool) {
+	ttl := b.ttl
+	if !revoked {
+		if b.negativeTTL == 0 {
+			return
+		}
+		ttl = b.negativeTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if el, ok := b.items[jti]; ok {
+		e := el.Value.(*entry)
+		if e.revoked && !revoked && time.Now().Before(e.expiresAt) {
+			return
+		}
+		e.revoked, e.expiresAt = revoked, expiresAt
+		b.lru.MoveToFront(el)
+		return
+	}
+
+	b.items[jti] = b.lru.PushFront(&entry{jti: jti, revoked: revoked, expiresAt: expiresAt})
+	for b.lru.Len() > b.size {
+		b.removeLocked(b.lru.Back())
+	}
+}
+
+// purgeNegative 清除所有未撤销结果
+func (b *Blacklist) purgeNegative() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for el := b.lru.Front(); el != nil; {
+		next := el.Next()
+		if !el.Value.(*entry).revoked {
+			b.removeLocked(el)
+		}
+		el = next
+	}
+}
+
+func (b *Blacklist) removeLocked(el *list.Element) {
+	b.lru.Remove(el)
+	delete(b.items, el.Value.(*entry).jti)
+}
+
+func (b *Blacklist) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lru.Len()
+}
+
+// metrics 本地缓存指标
+type metrics struct {
+	hits          prometheus.Counter
+	misses        prometheus.Counter
+	invalidations prometheus.Counter
+}
+
+func newMetrics(registerer prometheus.Registerer, b *Blacklist) *metrics {
+	factory := promauto.With(registerer)
+	opts := func(name, help string) prometheus.CounterOpts {
+		return prometheus.CounterOpts{Namespace: "jwt", Subsystem: "blacklist_cache", Name: name, Help: help}
+	}
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "jwt",
+		Subsystem: "blacklist_cache",
+		Name:      "entries",
+		Help:      "Number of entries in the local blacklist cache",
+	}, func() float64 { return float64(b.len()) })
+	return &metrics{
+		hits:          factory.NewCounter(opts("hits_total", "Total number of blacklist lookups served from the local cache")),
+		misses:        factory.NewCounter(opts("misses_total", "Total number of blacklist lookups forwarded to the backing store")),
+		invalidations: factory.NewCounter(opts("invalidations_total", "Total number of revocation events received from other instances")),
+	}
+}
+
+// 确保实现接口
+var (
+	_ cache.Blacklist           = (*Blacklist)(nil)
+	_ cache.BlacklistBatchAdder = (*Blacklist)(nil)
+)
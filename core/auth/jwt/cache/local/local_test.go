@@ -0,0 +1,179 @@
+package local
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache/memory"
+)
+
+// countingBlacklist 记录底层查询次数，并模拟跨实例的变更通知
+type countingBlacklist struct {
+	*memory.Blacklist
+	lookups atomic.Int64
+
+	mu   sync.Mutex
+	subs []func(string)
+}
+
+func newCountingBlacklist() *countingBlacklist {
+	return &countingBlacklist{Blacklist: memory.NewBlacklist()}
+}
+
+func (c *countingBlacklist) Contains(ctx context.Context, jti string) (bool, error) {
+	c.lookups.Add(1)
+	return c.Blacklist.Contains(ctx, jti)
+}
+
+func (c *countingBlacklist) Subscribe(ctx context.Context, fn func(string)) error {
+	c.mu.Lock()
+	c.subs = append(c.subs, fn)
+	c.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+// revokeRemote 模拟其他实例撤销 token：写入底层存储并广播
+func (c *countingBlacklist) revokeRemote(t *testing.T, jti string) {
+	t.Helper()
+	if err := c.Blacklist.Add(context.Background(), jti, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		subs := c.subs
+		c.mu.Unlock()
+		if len(subs) > 0 {
+			for _, fn := range subs {
+				fn(jti)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no subscriber")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func mustContains(t *testing.T, b *Blacklist, jti string, want bool) {
+	t.Helper()
+	got, err := b.Contains(context.Background(), jti)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("Contains(%q) = %v, want %v", jti, got, want)
+	}
+}
+
+func TestBlacklist_CachesResults(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBlacklist()
+	b := NewBlacklist(next)
+
+	if err := b.Add(ctx, "revoked", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		mustContains(t, b, "revoked", true)
+		mustContains(t, b, "valid", false)
+	}
+	if n := next.lookups.Load(); n != 1 {
+		t.Errorf("backing lookups %d, want 1", n)
+	}
+
+	stats := b.Stats()
+	if stats.Hits != 5 || stats.Misses != 1 || stats.Size != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if rate := stats.HitRate(); rate < 0.83 || rate > 0.84 {
+		t.Errorf("hit rate %v", rate)
+	}
+}
+
+func TestBlacklist_NegativeTTL(t *testing.T) {
+	next := newCountingBlacklist()
+	b := NewBlacklist(next, WithNegativeTTL(20*time.Millisecond))
+
+	mustContains(t, b, "jti", false)
+	// 未收到通知时，其他实例的撤销在未撤销结果过期后才可见
+	if err := next.Blacklist.Add(context.Background(), "jti", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	mustContains(t, b, "jti", false)
+	time.Sleep(30 * time.Millisecond)
+	mustContains(t, b, "jti", true)
+
+	// 不缓存未撤销结果
+	b = NewBlacklist(next, WithNegativeTTL(0))
+	mustContains(t, b, "other", false)
+	mustContains(t, b, "other", false)
+	if size := b.Stats().Size; size != 0 {
+		t.Errorf("size %d, want 0", size)
+	}
+}
+
+func TestBlacklist_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBlacklist()
+	b := NewBlacklist(next, WithNegativeTTL(time.Hour))
+	if err := b.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop(ctx)
+
+	mustContains(t, b, "jti", false)
+	next.revokeRemote(t, "jti")
+	mustContains(t, b, "jti", true)
+
+	// 迟到的未撤销结果不覆盖撤销通知
+	b.store("jti", false)
+	mustContains(t, b, "jti", true)
+
+	if stats := b.Stats(); stats.Invalidations != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestBlacklist_Eviction(t *testing.T) {
+	b := NewBlacklist(newCountingBlacklist(), WithSize(2))
+
+	mustContains(t, b, "a", false)
+	mustContains(t, b, "b", false)
+	mustContains(t, b, "a", false) // a 最近使用
+	mustContains(t, b, "c", false) // 淘汰 b
+
+	b.mu.Lock()
+	_, hasA := b.items["a"]
+	_, hasB := b.items["b"]
+	b.mu.Unlock()
+	if !hasA || hasB || b.Stats().Size != 2 {
+		t.Errorf("a cached %v, b cached %v, size %d", hasA, hasB, b.Stats().Size)
+	}
+}
+
+func TestBlacklist_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	b := NewBlacklist(newCountingBlacklist(), WithMetrics(reg))
+
+	mustContains(t, b, "jti", false)
+	mustContains(t, b, "jti", false)
+
+	if got := testutil.ToFloat64(b.metrics.hits); got != 1 {
+		t.Errorf("hits %v", got)
+	}
+	if got := testutil.ToFloat64(b.metrics.misses); got != 1 {
+		t.Errorf("misses %v", got)
+	}
+	if n, err := testutil.GatherAndCount(reg, "jwt_blacklist_cache_entries"); err != nil || n != 1 {
+		t.Errorf("entries gauge: %d, %v", n, err)
+	}
+}
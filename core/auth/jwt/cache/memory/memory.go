@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+)
+
+// sweepEvery 每写入多少次清理一次过期数据
+const sweepEvery = 1024
+
+// SessionStore 内存会话存储实现，适用于测试与单实例部署，进程重启后会话丢失
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]cache.Session
+	subjects map[string]map[string]struct{} // subject -> jti 集合
+	writes   int
+}
+
+// NewSessionStore 创建内存会话存储
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[string]cache.Session),
+		subjects: make(map[string]map[string]struct{}),
+	}
+}
+
+// SaveSession 保存会话
+func (s *SessionStore) SaveSession(ctx context.Context, session *cache.Session) error {
+	if session == nil {
+		return fmt.Errorf("session is nil")
+	}
+	now := time.Now()
+	if !session.ExpiresAt.After(now) {
+		return fmt.Errorf("session already expired")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.JTI] = *session
+	jtis, ok := s.subjects[session.Subject]
+	if !ok {
+		jtis = make(map[string]struct{})
+		s.subjects[session.Subject] = jtis
+	}
+	jtis[session.JTI] = struct{}{}
+
+	if s.writes++; s.writes%sweepEvery == 0 {
+		s.sweepLocked(now)
+	}
+	return nil
+}
+
+// GetSession 获取会话
+func (s *SessionStore) GetSession(ctx context.Context, jti string) (*cache.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[jti]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return nil, cache.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// DeleteSession 删除会话
+func (s *SessionStore) DeleteSession(ctx context.Context, jti string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteLocked(jti)
+	return nil
+}
+
+// ConsumeSession 删除未过期的会话，返回是否由本次调用删除
+func (s *SessionStore) ConsumeSession(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[jti]
+	if !ok {
+		return false, nil
+	}
+	s.deleteLocked(jti)
+	return session.ExpiresAt.After(time.Now()), nil
+}
+
+// MarkRotated 将会话标记为已轮换，仅在尚未轮换时写入
+func (s *SessionStore) MarkRotated(ctx context.Context, jti string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[jti]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return false, cache.ErrSessionNotFound
+	}
+	if !session.RotatedAt.IsZero() {
+		return false, nil
+	}
+	session.RotatedAt = at
+	s.sessions[jti] = session
+	return true, nil
+}
+
+// DeleteAllSessions 删除用户所有会话
+func (s *SessionStore) DeleteAllSessions(ctx context.Context, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for jti := range s.subjects[subject] {
+		delete(s.sessions, jti)
+	}
+	delete(s.subjects, subject)
+	return nil
+}
+
+// ListSessions 列出用户所有会话
+func (s *SessionStore) ListSessions(ctx context.Context, subject string) ([]*cache.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]*cache.Session, 0, len(s.subjects[subject]))
+	for jti := range s.subjects[subject] {
+		session, ok := s.sessions[jti]
+		if !ok || !session.ExpiresAt.After(now) {
+			s.deleteLocked(jti)
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
+
+// deleteLocked 删除会话及其索引
+func (s *SessionStore) deleteLocked(jti string) {
+	session, ok := s.sessions[jti]
+	if !ok {
+		return
+	}
+	delete(s.sessions, jti)
+	if jtis, ok := s.subjects[session.Subject]; ok {
+		delete(jtis, jti)
+		if len(jtis) == 0 {
+			delete(s.subjects, session.Subject)
+		}
+	}
+}
+
+// sweepLocked 清理过期会话
+func (s *SessionStore) sweepLocked(now time.Time) {
+	for jti, session := range s.sessions {
+		if !session.ExpiresAt.After(now) {
+			s.deleteLocked(jti)
+		}
+	}
+}
+
+// Blacklist 内存黑名单实现
+type Blacklist struct {
+	mu      sync.Mutex
+	entries map[string]time.Time // jti -> 过期时间
+	writes  int
+}
+
+// NewBlacklist 创建内存黑名单
+func NewBlacklist() *Blacklist {
+	return &Blacklist{entries: make(map[string]time.Time)}
+}
+
+// Add 添加 token 到黑名单
+func (b *Blacklist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // 已过期的 token 不需要加入黑名单
+	}
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[jti] = now.Add(ttl)
+	if b.writes++; b.writes%sweepEvery == 0 {
+		for k, exp := range b.entries {
+			if !exp.After(now) {
+				delete(b.entries, k)
+			}
+		}
+	}
+	return nil
+}
+
+// Contains 检查 token 是否在黑名单中
+func (b *Blacklist) Contains(ctx context.Context, jti string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	exp, ok := b.entries[jti]
+	return ok && exp.After(time.Now()), nil
+}
+
+// Store 内存存储（同时包含 SessionStore 和 Blacklist）
+type Store struct {
+	*SessionStore
+	*Blacklist
+}
+
+// NewStore 创建包含 SessionStore 和 Blacklist 的内存存储
+func NewStore() *Store {
+	return &Store{
+		SessionStore: NewSessionStore(),
+		Blacklist:    NewBlacklist(),
+	}
+}
+
+// 确保实现接口
+var (
+	_ cache.SessionStore = (*SessionStore)(nil)
+	_ cache.Blacklist    = (*Blacklist)(nil)
+)
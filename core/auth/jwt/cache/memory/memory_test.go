@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache/cachetest"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	cachetest.Run(t, store.SessionStore, store.Blacklist)
+}
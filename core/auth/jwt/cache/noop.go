@@ -25,6 +25,14 @@ func (n *NoopSessionStore) DeleteSession(ctx context.Context, jti string) error
 	return nil
 }
 
+func (n *NoopSessionStore) ConsumeSession(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+func (n *NoopSessionStore) MarkRotated(ctx context.Context, jti string, at time.Time) (bool, error) {
+	return false, ErrSessionNotFound
+}
+
 func (n *NoopSessionStore) DeleteAllSessions(ctx context.Context, subject string) error {
 	return nil
 }
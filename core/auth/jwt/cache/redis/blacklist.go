@@ -2,8 +2,11 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	goredis "github.com/redis/go-redis/v9"
+
 	kitredis "github.com/kochabx/kit/store/redis"
 )
 
@@ -11,6 +14,7 @@ import (
 type Blacklist struct {
 	client    *kitredis.Client
 	keyPrefix string // "jwt:blacklist:"
+	channel   string // "jwt:blacklist"，新增事件的发布频道
 }
 
 // BlacklistOption 黑名单选项
@@ -23,11 +27,19 @@ func WithBlacklistKeyPrefix(prefix string) BlacklistOption {
 	}
 }
 
+// WithBlacklistChannel 设置新增事件的 pub/sub 频道，供本地缓存订阅失效
+func WithBlacklistChannel(channel string) BlacklistOption {
+	return func(b *Blacklist) {
+		b.channel = channel
+	}
+}
+
 // NewBlacklist 创建 Redis 黑名单
 func NewBlacklist(client *kitredis.Client, opts ...BlacklistOption) *Blacklist {
 	bl := &Blacklist{
 		client:    client,
 		keyPrefix: "jwt:blacklist:",
+		channel:   "jwt:blacklist",
 	}
 
 	for _, opt := range opts {
@@ -44,7 +56,27 @@ func (b *Blacklist) Add(ctx context.Context, jti string, ttl time.Duration) erro
 	}
 
 	key := b.keyPrefix + jti
-	return b.client.UniversalClient().Set(ctx, key, "1", ttl).Err()
+	_, err := b.client.UniversalClient().Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Set(ctx, key, "1", ttl)
+		pipe.Publish(ctx, b.channel, jti)
+		return nil
+	})
+	return err
+}
+
+// AddBatch 通过一次 pipeline 批量添加 token 到黑名单，实现 cache.BlacklistBatchAdder
+func (b *Blacklist) AddBatch(ctx context.Context, ttls map[string]time.Duration) error {
+	_, err := b.client.UniversalClient().Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for jti, ttl := range ttls {
+			if ttl <= 0 {
+				continue
+			}
+			pipe.Set(ctx, b.keyPrefix+jti, "1", ttl)
+			pipe.Publish(ctx, b.channel, jti)
+		}
+		return nil
+	})
+	return err
 }
 
 // Contains 检查 token 是否在黑名单中
@@ -56,3 +88,30 @@ func (b *Blacklist) Contains(ctx context.Context, jti string) (bool, error) {
 	}
 	return exists > 0, nil
 }
+
+// Subscribe 订阅黑名单新增事件，实现 cache.BlacklistSubscriber
+func (b *Blacklist) Subscribe(ctx context.Context, fn func(jti string)) error {
+	pubsub := b.client.UniversalClient().Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	// 等待订阅确认，确保返回前的连接错误能被调用方感知
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return errors.New("redis: blacklist subscription closed")
+			}
+			fn(msg.Payload)
+		}
+	}
+}
@@ -20,6 +20,7 @@ func WithStoreKeyPrefix(prefix string) StoreOption {
 		s.SessionStore.keyPrefix = prefix + ":session:"
 		s.SessionStore.subjectIndex = prefix + ":subject:"
 		s.Blacklist.keyPrefix = prefix + ":blacklist:"
+		s.Blacklist.channel = prefix + ":blacklist"
 	}
 }
 
@@ -41,4 +42,8 @@ func NewStore(client *kitredis.Client, opts ...StoreOption) *Store {
 var (
 	_ cache.SessionStore = (*SessionStore)(nil)
 	_ cache.Blacklist    = (*Blacklist)(nil)
+
+	_ cache.BlacklistSubscriber = (*Blacklist)(nil)
+	_ cache.BlacklistBatchAdder = (*Blacklist)(nil)
+	_ cache.SessionBatchDeleter = (*SessionStore)(nil)
 )
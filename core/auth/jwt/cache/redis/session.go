@@ -6,10 +6,15 @@ import (
 	"fmt"
 	"time"
 
+	goredis "github.com/redis/go-redis/v9"
+
 	"github.com/kochabx/kit/core/auth/jwt/cache"
 	kitredis "github.com/kochabx/kit/store/redis"
 )
 
+// maxTxRetries 乐观锁事务因并发修改失败时的最大尝试次数
+const maxTxRetries = 3
+
 // SessionStore Redis 会话存储实现
 type SessionStore struct {
 	client       *kitredis.Client
@@ -127,6 +132,68 @@ func (s *SessionStore) DeleteSession(ctx context.Context, jti string) error {
 	return err
 }
 
+// ConsumeSession 通过 GETDEL 原子地删除会话，返回是否由本次调用删除
+func (s *SessionStore) ConsumeSession(ctx context.Context, jti string) (bool, error) {
+	data, err := s.client.UniversalClient().GetDel(ctx, s.keyPrefix+jti).Bytes()
+	if err == kitredis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("consume session: %w", err)
+	}
+
+	// 主体索引只用于列举与批量删除，删除失败不影响消费结果
+	var session cache.Session
+	if err := json.Unmarshal(data, &session); err == nil {
+		_ = s.client.UniversalClient().SRem(ctx, s.subjectIndex+session.Subject, jti).Err()
+	}
+	return true, nil
+}
+
+// MarkRotated 将会话标记为已轮换，仅在尚未轮换时写入。
+// 通过 WATCH 乐观锁实现比较并设置，写入保留会话原有的过期时间
+func (s *SessionStore) MarkRotated(ctx context.Context, jti string, at time.Time) (bool, error) {
+	sessionKey := s.keyPrefix + jti
+
+	for range maxTxRetries {
+		marked := false
+		err := s.client.UniversalClient().Watch(ctx, func(tx *goredis.Tx) error {
+			data, err := tx.Get(ctx, sessionKey).Bytes()
+			if err == kitredis.ErrNil {
+				return cache.ErrSessionNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("get session: %w", err)
+			}
+
+			var session cache.Session
+			if err := json.Unmarshal(data, &session); err != nil {
+				return fmt.Errorf("unmarshal session: %w", err)
+			}
+			if !session.RotatedAt.IsZero() {
+				return nil
+			}
+
+			session.RotatedAt = at
+			if data, err = json.Marshal(&session); err != nil {
+				return fmt.Errorf("marshal session: %w", err)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+				pipe.SetArgs(ctx, sessionKey, data, goredis.SetArgs{KeepTTL: true})
+				return nil
+			})
+			marked = err == nil
+			return err
+		}, sessionKey)
+		if err == goredis.TxFailedErr {
+			// 会话在读取后被修改，重新比较
+			continue
+		}
+		return marked, err
+	}
+	return false, fmt.Errorf("mark session rotated: %w", goredis.TxFailedErr)
+}
+
 // DeleteAllSessions 删除用户所有会话
 func (s *SessionStore) DeleteAllSessions(ctx context.Context, subject string) error {
 	subjectKey := s.subjectIndex + subject
@@ -156,6 +223,25 @@ func (s *SessionStore) DeleteAllSessions(ctx context.Context, subject string) er
 	return err
 }
 
+// DeleteSessions 通过一次 pipeline 批量删除 subject 下的会话，实现 cache.SessionBatchDeleter
+func (s *SessionStore) DeleteSessions(ctx context.Context, subject string, jtis []string) error {
+	if len(jtis) == 0 {
+		return nil
+	}
+
+	pipe := s.client.UniversalClient().Pipeline()
+
+	members := make([]any, len(jtis))
+	for i, jti := range jtis {
+		pipe.Del(ctx, s.keyPrefix+jti)
+		members[i] = jti
+	}
+	pipe.SRem(ctx, s.subjectIndex+subject, members...)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 // ListSessions 列出用户所有会话
 func (s *SessionStore) ListSessions(ctx context.Context, subject string) ([]*cache.Session, error) {
 	subjectKey := s.subjectIndex + subject
@@ -170,19 +256,36 @@ func (s *SessionStore) ListSessions(ctx context.Context, subject string) ([]*cac
 		return []*cache.Session{}, nil
 	}
 
-	// 批量获取会话
+	// 通过 pipeline 批量获取会话
+	pipe := s.client.UniversalClient().Pipeline()
+	cmds := make([]*goredis.StringCmd, len(jtis))
+	for i, jti := range jtis {
+		cmds[i] = pipe.Get(ctx, s.keyPrefix+jti)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != kitredis.ErrNil {
+		return nil, fmt.Errorf("get sessions: %w", err)
+	}
+
 	sessions := make([]*cache.Session, 0, len(jtis))
-	for _, jti := range jtis {
-		session, err := s.GetSession(ctx, jti)
+	var stale []any
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err == kitredis.ErrNil {
+			// 会话已过期或被删除，从索引中清理
+			stale = append(stale, jtis[i])
+			continue
+		}
 		if err != nil {
-			if err == cache.ErrSessionNotFound {
-				// 会话已过期或被删除，从索引中清理
-				s.client.UniversalClient().SRem(ctx, subjectKey, jti)
-				continue
-			}
-			return nil, fmt.Errorf("get session %s: %w", jti, err)
+			return nil, fmt.Errorf("get session %s: %w", jtis[i], err)
 		}
-		sessions = append(sessions, session)
+		var session cache.Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, fmt.Errorf("unmarshal session %s: %w", jtis[i], err)
+		}
+		sessions = append(sessions, &session)
+	}
+	if len(stale) > 0 {
+		s.client.UniversalClient().SRem(ctx, subjectKey, stale...)
 	}
 
 	return sessions, nil
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionPredicate 会话筛选条件，返回 true 表示命中，用于按条件批量撤销会话
+type SessionPredicate func(*Session) bool
+
+// CreatedBefore 命中签发时间早于 t 的会话
+func CreatedBefore(t time.Time) SessionPredicate {
+	return func(s *Session) bool {
+		return s.CreatedAt.Before(t)
+	}
+}
+
+// LoggedInBefore 命中登录时间（令牌族创建时间）早于 t 的会话。
+// 与 CreatedBefore 不同，t 之后由旧 refresh token 刷新签发的会话同样命中，适用于凭证泄露后的处置
+func LoggedInBefore(t time.Time) SessionPredicate {
+	return func(s *Session) bool {
+		loggedIn := s.FamilyCreatedAt
+		if loggedIn.IsZero() {
+			loggedIn = s.CreatedAt
+		}
+		return loggedIn.Before(t)
+	}
+}
+
+// FromIPRange 命中签发时客户端 IP 属于任一网段的会话，如 netip.MustParsePrefix("203.0.113.0/24")。
+// 未记录 IP 或 IP 无法解析的会话不命中
+func FromIPRange(prefixes ...netip.Prefix) SessionPredicate {
+	return func(s *Session) bool {
+		addr, err := netip.ParseAddr(s.IP)
+		if err != nil {
+			return false
+		}
+		addr = addr.Unmap()
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AppVersionBelow 命中客户端版本低于 version 的会话，版本号按点分数字逐段比较（如 "1.10.0" > "1.9.2"），
+// 可带 "v" 前缀，预发布版本（如 "2.0.0-beta"）低于对应正式版本。未记录版本的会话不命中
+func AppVersionBelow(version string) SessionPredicate {
+	return func(s *Session) bool {
+		return s.AppVersion != "" && compareVersions(s.AppVersion, version) < 0
+	}
+}
+
+// TokenTypeIs 命中指定类型的会话，如 "access"、"refresh"
+func TokenTypeIs(tokenTypes ...string) SessionPredicate {
+	return func(s *Session) bool {
+		for _, t := range tokenTypes {
+			if s.TokenType == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// And 命中同时满足全部条件的会话
+func And(predicates ...SessionPredicate) SessionPredicate {
+	return func(s *Session) bool {
+		for _, p := range predicates {
+			if !p(s) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or 命中满足任一条件的会话
+func Or(predicates ...SessionPredicate) SessionPredicate {
+	return func(s *Session) bool {
+		for _, p := range predicates {
+			if p(s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not 命中不满足条件的会话
+func Not(predicate SessionPredicate) SessionPredicate {
+	return func(s *Session) bool {
+		return !predicate(s)
+	}
+}
+
+// compareVersions 比较两个版本号，返回 -1、0 或 1
+func compareVersions(a, b string) int {
+	a, preA, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	b, preB, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(partsA), len(partsB)) {
+		var x, y string
+		if i < len(partsA) {
+			x = partsA[i]
+		}
+		if i < len(partsB) {
+			y = partsB[i]
+		}
+		if c := compareVersionPart(x, y); c != 0 {
+			return c
+		}
+	}
+
+	// 正式版本高于预发布版本
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return strings.Compare(preA, preB)
+}
+
+// compareVersionPart 比较版本号中的一段，数字按数值比较，缺失的段视为 0
+func compareVersionPart(x, y string) int {
+	if x == "" {
+		x = "0"
+	}
+	if y == "" {
+		y = "0"
+	}
+	nx, errX := strconv.ParseUint(x, 10, 64)
+	ny, errY := strconv.ParseUint(y, 10, 64)
+	if errX != nil || errY != nil {
+		return strings.Compare(x, y)
+	}
+	switch {
+	case nx < ny:
+		return -1
+	case nx > ny:
+		return 1
+	}
+	return 0
+}
+
+// SessionBatchDeleter 可选接口，由支持批量删除的会话存储实现（如通过 Redis pipeline），
+// 批量撤销时借此减少网络往返；未实现时逐个调用 DeleteSession
+type SessionBatchDeleter interface {
+	// DeleteSessions 删除 subject 下的多个会话，不存在的会话忽略
+	DeleteSessions(ctx context.Context, subject string, jtis []string) error
+}
+
+// BlacklistBatchAdder 可选接口，由支持批量写入的黑名单实现；未实现时逐个调用 Add
+type BlacklistBatchAdder interface {
+	// AddBatch 将多个 token 加入黑名单，ttls 为 jti -> 剩余有效期，ttl <= 0 的忽略
+	AddBatch(ctx context.Context, ttls map[string]time.Duration) error
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.9.2", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"2.0.0-beta", "2.0.0", -1},
+		{"2.0.0-alpha", "2.0.0-beta", -1},
+		{"2.0.0+build.7", "2.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSessionPredicates(t *testing.T) {
+	now := time.Now()
+	session := &Session{
+		TokenType:       "refresh",
+		CreatedAt:       now,
+		FamilyCreatedAt: now.Add(-time.Hour),
+		IP:              "::ffff:203.0.113.9",
+		AppVersion:      "1.4.0",
+	}
+
+	tests := []struct {
+		name      string
+		predicate SessionPredicate
+		want      bool
+	}{
+		{"CreatedBefore", CreatedBefore(now.Add(-time.Minute)), false},
+		{"LoggedInBefore", LoggedInBefore(now.Add(-time.Minute)), true},
+		{"FromIPRange", FromIPRange(netip.MustParsePrefix("203.0.113.0/24")), true},
+		{"FromIPRange miss", FromIPRange(netip.MustParsePrefix("198.51.100.0/24")), false},
+		{"AppVersionBelow", AppVersionBelow("1.10"), true},
+		{"AppVersionBelow equal", AppVersionBelow("1.4"), false},
+		{"TokenTypeIs", TokenTypeIs("access"), false},
+		{"And", And(TokenTypeIs("refresh"), AppVersionBelow("2.0.0")), true},
+		{"Or", Or(TokenTypeIs("access"), CreatedBefore(now)), false},
+		{"Not", Not(TokenTypeIs("access")), true},
+	}
+	for _, tt := range tests {
+		if got := tt.predicate(session); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// 未记录 IP 与版本的会话不命中
+	empty := &Session{}
+	if FromIPRange(netip.MustParsePrefix("0.0.0.0/0"))(empty) || AppVersionBelow("99")(empty) {
+		t.Error("session without IP or version should not match")
+	}
+}
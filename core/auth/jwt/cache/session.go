@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -9,10 +10,25 @@ import (
 type Session struct {
 	JTI       string    `json:"jti"`
 	Subject   string    `json:"subject"`
-	TokenType string    `json:"token_type"` // "access" or "refresh"
+	TokenType string    `json:"token_type"` // "access"、"refresh" 或 "delegation"
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 	DeviceID  string    `json:"device_id,omitempty"`
+
+	// 签发时的客户端信息，用于设备管理界面展示
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	Location   string `json:"location,omitempty"`    // 地理位置，如 "Shanghai, CN"，由调用方解析
+	Label      string `json:"label,omitempty"`       // 设备名称，如 "Alice's iPhone"
+	AppVersion string `json:"app_version,omitempty"` // 客户端版本，如 "2.3.1"
+
+	// 刷新令牌族：同一次登录及其后续刷新签发的 token 属于同一族
+	FamilyID        string    `json:"family_id,omitempty"`
+	FamilyCreatedAt time.Time `json:"family_created_at,omitzero"` // 族的创建（登录）时间
+	RotatedAt       time.Time `json:"rotated_at,omitzero"`        // refresh token 被轮换的时间，零值表示尚未使用
+
+	// 不透明 token 模式下由服务端保存的 claims（JSON），JWT 模式下为空
+	Claims json.RawMessage `json:"claims,omitempty"`
 }
 
 // SessionStore 会话存储接口
@@ -26,6 +42,15 @@ type SessionStore interface {
 	// DeleteSession 删除会话
 	DeleteSession(ctx context.Context, jti string) error
 
+	// ConsumeSession 删除未过期的会话并报告是否由本次调用删除，须为原子操作：
+	// 并发调用时只有一个返回 true，会话不存在或已过期时返回 false。用于一次性 token 的消费
+	ConsumeSession(ctx context.Context, jti string) (bool, error)
+
+	// MarkRotated 将 refresh token 会话标记为在 at 时轮换 (设置 RotatedAt)，须为原子的比较并设置：
+	// 仅当会话尚未轮换时写入并返回 true，已轮换时返回 false；会话不存在返回 ErrSessionNotFound。
+	// 并发刷新同一 refresh token 时借此保证只有一个请求能完成轮换
+	MarkRotated(ctx context.Context, jti string, at time.Time) (bool, error)
+
 	// DeleteAllSessions 删除用户所有会话
 	DeleteAllSessions(ctx context.Context, subject string) error
 
@@ -1,8 +1,10 @@
 package jwt
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/kochabx/kit/core/auth/jwt/cache"
@@ -37,8 +39,19 @@ func NewCachedAuthenticator(
 	}
 }
 
-// Generate 生成 token 对（带会话管理）
+// sessionFamily 刷新令牌族信息
+type sessionFamily struct {
+	id        string
+	createdAt time.Time
+}
+
+// Generate 生成 token 对（带会话管理），新 token 开启一个新的令牌族
 func (a *CachedAuthenticator) Generate(ctx context.Context, claims Claims, opts ...GenerateOption) (*TokenPair, error) {
+	return a.generate(ctx, claims, sessionFamily{}, opts...)
+}
+
+// generate 生成 token 对并保存会话，family 为空时开启新的令牌族
+func (a *CachedAuthenticator) generate(ctx context.Context, claims Claims, family sessionFamily, opts ...GenerateOption) (*TokenPair, error) {
 	// 解析选项
 	options := &GenerateOptions{}
 	for _, opt := range opts {
@@ -61,7 +74,7 @@ func (a *CachedAuthenticator) Generate(ctx context.Context, claims Claims, opts
 	}
 
 	// 保存会话信息
-	if err := a.saveSession(ctx, tokenPair, claims, options); err != nil {
+	if err := a.saveSession(ctx, tokenPair, claims, options, family); err != nil {
 		// 会话保存失败不影响 token 生成，记录错误即可
 		return tokenPair, nil
 	}
@@ -95,57 +108,216 @@ func (a *CachedAuthenticator) Verify(ctx context.Context, tokenString string, cl
 		return ErrTokenRevoked
 	}
 
-	// 验证会话存在
-	if _, err := a.sessionStore.GetSession(ctx, jti); err != nil {
+	// 验证会话存在且未被轮换
+	session, err := a.sessionStore.GetSession(ctx, jti)
+	if err != nil {
 		if err == cache.ErrSessionNotFound {
 			return ErrSessionNotFound
 		}
 		return fmt.Errorf("get session: %w", err)
 	}
+	if !session.RotatedAt.IsZero() {
+		return ErrTokenRevoked
+	}
 
 	return nil
 }
 
-// Refresh 刷新 token（保持会话信息）
+// VerifyForAudience 验证 token（检查黑名单和会话），并要求其受众包含 audience
+func (a *CachedAuthenticator) VerifyForAudience(ctx context.Context, tokenString, audience string, claims Claims) error {
+	if err := a.Verify(ctx, tokenString, claims); err != nil {
+		return err
+	}
+	return checkAudience(claims, audience)
+}
+
+// Refresh 刷新 token（保持会话信息）。
+//
+// 每次刷新签发新的 token 对并轮换旧的 refresh token，新旧 token 属于同一令牌族。
+// 已轮换的 refresh token 再次使用时视为被盗用：撤销整个令牌族并返回 ErrRefreshReused，
+// 合法客户端与攻击者都需重新登录。WithRefreshGracePeriod 内的重复使用除外
 func (a *CachedAuthenticator) Refresh(ctx context.Context, refreshToken string, claims Claims) (*TokenPair, error) {
-	// 验证 refresh token
-	if err := a.Verify(ctx, refreshToken, claims); err != nil {
+	return a.RefreshWithOptions(ctx, refreshToken, claims)
+}
+
+// RefreshWithOptions 刷新 token，新会话沿用原会话的设备与元数据，opts 可更新本次刷新时的
+// 客户端信息（如 WithClientIP、WithUserAgent），设备 ID 不可更改
+func (a *CachedAuthenticator) RefreshWithOptions(ctx context.Context, refreshToken string, claims Claims, opts ...GenerateOption) (*TokenPair, error) {
+	// 验证 refresh token 签名与有效期
+	if err := a.basic.Verify(ctx, refreshToken, claims); err != nil {
+		return nil, fmt.Errorf("verify refresh token: %w", err)
+	}
+	rc := &RegisteredClaims{}
+	if err := a.basic.Verify(ctx, refreshToken, rc); err != nil {
 		return nil, fmt.Errorf("verify refresh token: %w", err)
 	}
 
-	// 获取 JTI
-	var jti string
-	if rc, ok := claims.(*RegisteredClaims); ok {
-		jti = rc.ID
-	} else if rc := getRegisteredClaimsFromClaims(claims); rc != nil {
-		jti = rc.ID
+	// 检查黑名单
+	if revoked, err := a.blacklist.Contains(ctx, rc.ID); err != nil {
+		return nil, fmt.Errorf("check blacklist: %w", err)
+	} else if revoked {
+		return nil, ErrTokenRevoked
 	}
 
 	// 获取原会话信息
-	session, err := a.sessionStore.GetSession(ctx, jti)
+	session, err := a.sessionStore.GetSession(ctx, rc.ID)
 	if err != nil {
+		if err == cache.ErrSessionNotFound {
+			return nil, ErrSessionNotFound
+		}
 		return nil, fmt.Errorf("get session: %w", err)
 	}
-
 	if session.TokenType != "refresh" {
 		return nil, ErrInvalidSession
 	}
 
-	// 生成新 token（保持设备信息）
-	newPair, err := a.Generate(ctx, claims,
-		WithDeviceID(session.DeviceID),
+	now := time.Now()
+	family := sessionFamily{id: session.FamilyID, createdAt: session.FamilyCreatedAt}
+	if family.id == "" {
+		// 轮换功能之前创建的会话，以自身作为令牌族
+		family = sessionFamily{id: session.JTI, createdAt: session.CreatedAt}
+	}
+
+	// 重复使用已轮换的 refresh token
+	if !session.RotatedAt.IsZero() {
+		if err := a.checkReuse(ctx, session, family, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.config.MaxSessionLifetime > 0 && now.Sub(family.createdAt) > a.config.MaxSessionLifetime {
+		return nil, ErrSessionExpired
+	}
+
+	// 签发新 token 前原子地标记旧的 refresh token 已轮换，会话保留至其过期，用于识别重复使用。
+	// 并发刷新同一 token 时只有一个请求标记成功，其余按重复使用处理
+	if session.RotatedAt.IsZero() {
+		marked, err := a.sessionStore.MarkRotated(ctx, session.JTI, now)
+		if err != nil {
+			if err == cache.ErrSessionNotFound {
+				return nil, ErrSessionNotFound
+			}
+			return nil, fmt.Errorf("mark refresh token rotated: %w", err)
+		}
+		if !marked {
+			current, err := a.sessionStore.GetSession(ctx, session.JTI)
+			if err != nil {
+				if err == cache.ErrSessionNotFound {
+					return nil, ErrSessionNotFound
+				}
+				return nil, fmt.Errorf("get session: %w", err)
+			}
+			if err := a.checkReuse(ctx, current, family, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 生成新 token（保持设备信息、元数据、受众与令牌族）
+	genOpts := append(audienceOf(claims),
+		WithClientIP(session.IP),
+		WithUserAgent(session.UserAgent),
+		WithLocation(session.Location),
+		WithDeviceLabel(session.Label),
+		WithAppVersion(session.AppVersion),
 	)
+	genOpts = append(genOpts, opts...)
+	genOpts = append(genOpts, WithDeviceID(session.DeviceID))
+	return a.generate(ctx, claims, family, genOpts...)
+}
+
+// checkReuse 处理已轮换的 refresh token 被再次使用：宽限期外视为盗用，撤销整个令牌族
+func (a *CachedAuthenticator) checkReuse(ctx context.Context, session *cache.Session, family sessionFamily, now time.Time) error {
+	if !session.RotatedAt.IsZero() && a.config.RefreshGracePeriod > 0 && now.Sub(session.RotatedAt) <= a.config.RefreshGracePeriod {
+		return nil
+	}
+	if err := a.revokeFamily(ctx, session.Subject, family.id); err != nil {
+		return fmt.Errorf("revoke token family: %w", err)
+	}
+	return ErrRefreshReused
+}
+
+// Exchange 令牌交换，语义见 BasicAuthenticator.Exchange。
+//
+// 原 token 须为未撤销的 access token 或委托 token。委托 token 以 "delegation" 类型保存会话，
+// 与原 token 属于同一设备与令牌族：撤销该令牌族、设备或用户的全部 token 时一并撤销。
+// 单独撤销原 token 不影响已签发的委托 token
+func (a *CachedAuthenticator) Exchange(ctx context.Context, subjectToken string, actor Actor, audience string, opts ...ExchangeOption) (*TokenPair, error) {
+	exchanger, ok := a.basic.(TokenExchanger)
+	if !ok {
+		return nil, ErrExchangeUnsupported
+	}
+
+	subject := &DelegationClaims{}
+	if err := a.Verify(ctx, subjectToken, subject); err != nil {
+		return nil, fmt.Errorf("verify subject token: %w", err)
+	}
+	session, err := a.sessionStore.GetSession(ctx, subject.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	if session.TokenType == "refresh" {
+		return nil, ErrInvalidSession
+	}
+
+	pair, err := exchanger.Exchange(ctx, subjectToken, actor, audience, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	// 撤销旧的 refresh token
-	if err := a.Revoke(ctx, refreshToken); err != nil {
-		// 撤销失败不影响新 token，记录即可
-		return newPair, nil
+	// 委托 token 须有会话才能通过 Verify，保存失败时返回错误
+	delegated := &RegisteredClaims{}
+	if err := a.basic.Verify(ctx, pair.AccessToken, delegated); err != nil {
+		return nil, fmt.Errorf("verify delegation token: %w", err)
+	}
+	family := sessionFamily{id: session.FamilyID, createdAt: session.FamilyCreatedAt}
+	if family.id == "" {
+		family = sessionFamily{id: session.JTI, createdAt: session.CreatedAt}
+	}
+	if err := a.sessionStore.SaveSession(ctx, &cache.Session{
+		JTI:       delegated.ID,
+		Subject:   subject.Subject,
+		TokenType: "delegation",
+		CreatedAt: time.Now(),
+		ExpiresAt: delegated.ExpiresAt.Time,
+		DeviceID:  session.DeviceID,
+
+		FamilyID:        family.id,
+		FamilyCreatedAt: family.createdAt,
+	}); err != nil {
+		return nil, fmt.Errorf("save delegation session: %w", err)
+	}
+
+	return pair, nil
+}
+
+// revokeFamily 撤销令牌族内的全部 token
+func (a *CachedAuthenticator) revokeFamily(ctx context.Context, subject, familyID string) error {
+	sessions, err := a.sessionStore.ListSessions(ctx, subject)
+	if err != nil {
+		return fmt.Errorf("list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if session.FamilyID != familyID && session.JTI != familyID {
+			continue
+		}
+
+		ttl := time.Until(session.ExpiresAt)
+		if ttl < 0 {
+			ttl = 0
+		}
+
+		if err := a.blacklist.Add(ctx, session.JTI, ttl); err != nil {
+			return fmt.Errorf("add to blacklist: %w", err)
+		}
+
+		if err := a.sessionStore.DeleteSession(ctx, session.JTI); err != nil {
+			return fmt.Errorf("delete session: %w", err)
+		}
 	}
 
-	return newPair, nil
+	return nil
 }
 
 // Revoke 撤销单个 token
@@ -205,11 +377,132 @@ func (a *CachedAuthenticator) RevokeAll(ctx context.Context, subject string) err
 	return nil
 }
 
+// RevokeWhere 撤销用户所有满足 predicate 的会话，返回撤销的会话数，用于凭证泄露后的应急处置，例如：
+//
+//	cachedAuth.RevokeWhere(ctx, subject, cache.LoggedInBefore(leakedAt))
+//	cachedAuth.RevokeWhere(ctx, subject, cache.FromIPRange(netip.MustParsePrefix("203.0.113.0/24")))
+//	cachedAuth.RevokeWhere(ctx, subject, cache.AppVersionBelow("2.3.0"))
+//
+// 会话存储与黑名单实现 cache.SessionBatchDeleter / cache.BlacklistBatchAdder 时（如 Redis）批量写入，
+// 否则逐个处理。出错时已加入黑名单的 token 保持撤销状态，可直接重试
+func (a *CachedAuthenticator) RevokeWhere(ctx context.Context, subject string, predicate cache.SessionPredicate) (int, error) {
+	if predicate == nil {
+		return 0, fmt.Errorf("predicate is nil")
+	}
+
+	sessions, err := a.sessionStore.ListSessions(ctx, subject)
+	if err != nil {
+		return 0, fmt.Errorf("list sessions: %w", err)
+	}
+
+	now := time.Now()
+	ttls := make(map[string]time.Duration)
+	jtis := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if !predicate(session) {
+			continue
+		}
+		jtis = append(jtis, session.JTI)
+		ttls[session.JTI] = max(session.ExpiresAt.Sub(now), 0)
+	}
+	if len(jtis) == 0 {
+		return 0, nil
+	}
+
+	// 先加入黑名单再删除会话，中途失败时不会留下未撤销却已无记录的 token
+	if batch, ok := a.blacklist.(cache.BlacklistBatchAdder); ok {
+		if err := batch.AddBatch(ctx, ttls); err != nil {
+			return 0, fmt.Errorf("add to blacklist: %w", err)
+		}
+	} else {
+		for _, jti := range jtis {
+			if err := a.blacklist.Add(ctx, jti, ttls[jti]); err != nil {
+				return 0, fmt.Errorf("add to blacklist: %w", err)
+			}
+		}
+	}
+
+	if batch, ok := a.sessionStore.(cache.SessionBatchDeleter); ok {
+		if err := batch.DeleteSessions(ctx, subject, jtis); err != nil {
+			return 0, fmt.Errorf("delete sessions: %w", err)
+		}
+	} else {
+		for _, jti := range jtis {
+			if err := a.sessionStore.DeleteSession(ctx, jti); err != nil {
+				return 0, fmt.Errorf("delete session: %w", err)
+			}
+		}
+	}
+
+	return len(jtis), nil
+}
+
 // ListSessions 列出用户所有会话
 func (a *CachedAuthenticator) ListSessions(ctx context.Context, subject string) ([]*cache.Session, error) {
 	return a.sessionStore.ListSessions(ctx, subject)
 }
 
+// ListDevices 按设备聚合用户的会话，按最近活跃时间倒序返回，用于"管理我的设备"界面。
+// 只统计签发时指定了 WithDeviceID 的会话；设备元数据取自最近一次登录或刷新
+func (a *CachedAuthenticator) ListDevices(ctx context.Context, subject string) ([]*Device, error) {
+	sessions, err := a.sessionStore.ListSessions(ctx, subject)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	now := time.Now()
+	devices := make(map[string]*Device)
+	for _, session := range sessions {
+		if session.DeviceID == "" {
+			continue
+		}
+		device, ok := devices[session.DeviceID]
+		if !ok {
+			device = &Device{DeviceID: session.DeviceID}
+			devices[session.DeviceID] = device
+		}
+
+		firstSeen := session.FamilyCreatedAt
+		if firstSeen.IsZero() {
+			firstSeen = session.CreatedAt
+		}
+		if device.FirstSeen.IsZero() || firstSeen.Before(device.FirstSeen) {
+			device.FirstSeen = firstSeen
+		}
+		if !session.CreatedAt.Before(device.LastSeen) {
+			device.LastSeen = session.CreatedAt
+			device.Label = session.Label
+			device.IP = session.IP
+			device.UserAgent = session.UserAgent
+			device.Location = session.Location
+		}
+
+		if !session.ExpiresAt.After(now) {
+			continue
+		}
+		switch session.TokenType {
+		case "access":
+			device.ActiveAccessTokens++
+		case "refresh":
+			if session.RotatedAt.IsZero() {
+				device.ActiveRefreshTokens++
+			}
+		}
+	}
+
+	result := make([]*Device, 0, len(devices))
+	for _, device := range devices {
+		result = append(result, device)
+	}
+	slices.SortFunc(result, func(x, y *Device) int {
+		if c := y.LastSeen.Compare(x.LastSeen); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.DeviceID, y.DeviceID)
+	})
+	return result, nil
+}
+
 // RevokeDevice 撤销指定设备的所有会话
 func (a *CachedAuthenticator) RevokeDevice(ctx context.Context, subject, deviceID string) error {
 	sessions, err := a.sessionStore.ListSessions(ctx, subject)
@@ -238,7 +531,7 @@ func (a *CachedAuthenticator) RevokeDevice(ctx context.Context, subject, deviceI
 }
 
 // saveSession 保存会话信息
-func (a *CachedAuthenticator) saveSession(ctx context.Context, tokenPair *TokenPair, claims Claims, options *GenerateOptions) error {
+func (a *CachedAuthenticator) saveSession(ctx context.Context, tokenPair *TokenPair, claims Claims, options *GenerateOptions, family sessionFamily) error {
 	// 需要重新解析 token 以获取完整的 JTI 和过期时间
 	accessClaims := &RegisteredClaims{}
 	refreshClaims := &RegisteredClaims{}
@@ -264,15 +557,26 @@ func (a *CachedAuthenticator) saveSession(ctx context.Context, tokenPair *TokenP
 
 	now := time.Now()
 	subject, _ := claims.GetSubject()
+	if family.id == "" {
+		family = sessionFamily{id: refreshClaims.ID, createdAt: now}
+	}
 
 	// 保存 access token 会话
 	accessSession := &cache.Session{
-		JTI:       accessClaims.ID,
-		Subject:   subject,
-		TokenType: "access",
-		CreatedAt: now,
-		ExpiresAt: accessClaims.ExpiresAt.Time,
-		DeviceID:  options.DeviceID,
+		JTI:        accessClaims.ID,
+		Subject:    subject,
+		TokenType:  "access",
+		CreatedAt:  now,
+		ExpiresAt:  accessClaims.ExpiresAt.Time,
+		DeviceID:   options.DeviceID,
+		IP:         options.IP,
+		UserAgent:  options.UserAgent,
+		Location:   options.Location,
+		Label:      options.Label,
+		AppVersion: options.AppVersion,
+
+		FamilyID:        family.id,
+		FamilyCreatedAt: family.createdAt,
 	}
 
 	if err := a.sessionStore.SaveSession(ctx, accessSession); err != nil {
@@ -281,12 +585,20 @@ func (a *CachedAuthenticator) saveSession(ctx context.Context, tokenPair *TokenP
 
 	// 保存 refresh token 会话
 	refreshSession := &cache.Session{
-		JTI:       refreshClaims.ID,
-		Subject:   subject,
-		TokenType: "refresh",
-		CreatedAt: now,
-		ExpiresAt: refreshClaims.ExpiresAt.Time,
-		DeviceID:  options.DeviceID,
+		JTI:        refreshClaims.ID,
+		Subject:    subject,
+		TokenType:  "refresh",
+		CreatedAt:  now,
+		ExpiresAt:  refreshClaims.ExpiresAt.Time,
+		DeviceID:   options.DeviceID,
+		IP:         options.IP,
+		UserAgent:  options.UserAgent,
+		Location:   options.Location,
+		Label:      options.Label,
+		AppVersion: options.AppVersion,
+
+		FamilyID:        family.id,
+		FamilyCreatedAt: family.createdAt,
 	}
 
 	if err := a.sessionStore.SaveSession(ctx, refreshSession); err != nil {
@@ -345,6 +657,9 @@ func getRegisteredClaimsFromClaims(claims Claims) *RegisteredClaims {
 	if rc, ok := claims.(*RegisteredClaims); ok {
 		return rc
 	}
+	if dc, ok := claims.(*DelegationClaims); ok {
+		return &dc.RegisteredClaims
+	}
 	// 对于自定义类型，无法通过类型断言直接获取
 	// 用户应该直接访问嵌入的字段
 	return nil
@@ -0,0 +1,329 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+	"github.com/kochabx/kit/core/auth/jwt/cache/memory"
+)
+
+func newTestCachedAuthenticator(t *testing.T, opts ...CacheOption) *CachedAuthenticator {
+	t.Helper()
+	basic, err := NewBasicAuthenticator(WithSecret("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewStore()
+	return NewCachedAuthenticator(basic, store.SessionStore, store.Blacklist, opts...)
+}
+
+func TestCachedAuthenticator_RefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestCachedAuthenticator(t)
+
+	first, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := auth.Refresh(ctx, first.RefreshToken, &RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := auth.Refresh(ctx, second.RefreshToken, &RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一令牌族
+	sessions, _ := auth.ListSessions(ctx, "user123")
+	families := make(map[string]bool)
+	for _, s := range sessions {
+		families[s.FamilyID] = true
+	}
+	if len(sessions) != 6 || len(families) != 1 {
+		t.Fatalf("expected 6 sessions in 1 family, got %d sessions in %d families", len(sessions), len(families))
+	}
+
+	// 轮换后的 refresh token 不再通过验证
+	if err := auth.Verify(ctx, first.RefreshToken, &RegisteredClaims{}); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("rotated token: expected ErrTokenRevoked, got %v", err)
+	}
+
+	// 另一次登录不受影响
+	other, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复使用旧 refresh token 撤销整个令牌族
+	if _, err := auth.Refresh(ctx, first.RefreshToken, &RegisteredClaims{}); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("reuse: expected ErrRefreshReused, got %v", err)
+	}
+	if err := auth.Verify(ctx, third.AccessToken, &RegisteredClaims{}); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("family access token: expected ErrTokenRevoked, got %v", err)
+	}
+	if _, err := auth.Refresh(ctx, third.RefreshToken, &RegisteredClaims{}); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("family refresh token: expected ErrTokenRevoked, got %v", err)
+	}
+	if err := auth.Verify(ctx, other.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Errorf("other family: %v", err)
+	}
+}
+
+// barrierStore 前 n 次 GetSession 读取后相互等待，使并发刷新都读到尚未轮换的会话
+type barrierStore struct {
+	*memory.SessionStore
+	n       int32
+	calls   atomic.Int32
+	arrived sync.WaitGroup
+}
+
+func newBarrierStore(n int) *barrierStore {
+	s := &barrierStore{SessionStore: memory.NewSessionStore(), n: int32(n)}
+	s.arrived.Add(n)
+	return s
+}
+
+func (s *barrierStore) GetSession(ctx context.Context, jti string) (*cache.Session, error) {
+	session, err := s.SessionStore.GetSession(ctx, jti)
+	if s.calls.Add(1) <= s.n {
+		s.arrived.Done()
+		s.arrived.Wait()
+	}
+	return session, err
+}
+
+func TestCachedAuthenticator_ConcurrentRefresh(t *testing.T) {
+	ctx := context.Background()
+	basic, err := NewBasicAuthenticator(WithSecret("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 8
+	store := newBarrierStore(n)
+	auth := NewCachedAuthenticator(basic, store, memory.NewBlacklist())
+
+	pair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发刷新同一 refresh token：都读到未轮换的会话，但只有一个请求拿到新 token
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+		reused    atomic.Int32
+	)
+	for range n {
+		wg.Go(func() {
+			_, err := auth.Refresh(ctx, pair.RefreshToken, &RegisteredClaims{})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, ErrRefreshReused):
+				reused.Add(1)
+			case errors.Is(err, ErrSessionNotFound):
+				// 令牌族已被先发现重复使用的请求撤销
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if got, want := succeeded.Load(), int32(1); got != want {
+		t.Fatalf("%d concurrent refreshes succeeded, want %d", got, want)
+	}
+	if reused.Load() == 0 {
+		t.Error("concurrent reuse was not detected")
+	}
+}
+
+// failingRotationStore MarkRotated 总是失败的会话存储
+type failingRotationStore struct {
+	*memory.SessionStore
+}
+
+func (s failingRotationStore) MarkRotated(ctx context.Context, jti string, at time.Time) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func TestCachedAuthenticator_RefreshMarkFailure(t *testing.T) {
+	ctx := context.Background()
+	basic, err := NewBasicAuthenticator(WithSecret("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewStore()
+	auth := NewCachedAuthenticator(basic, failingRotationStore{store.SessionStore}, store.Blacklist)
+
+	pair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 无法标记轮换时不签发新 token，旧 refresh token 不会变成可无限重复使用
+	if newPair, err := auth.Refresh(ctx, pair.RefreshToken, &RegisteredClaims{}); err == nil || newPair != nil {
+		t.Fatalf("expected error, got pair=%v err=%v", newPair, err)
+	}
+}
+
+func TestCachedAuthenticator_RefreshGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestCachedAuthenticator(t, WithRefreshGracePeriod(time.Minute))
+
+	pair, _ := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if _, err := auth.Refresh(ctx, pair.RefreshToken, &RegisteredClaims{}); err != nil {
+		t.Fatal(err)
+	}
+	// 并发刷新：宽限期内重复提交同一个 refresh token
+	retry, err := auth.Refresh(ctx, pair.RefreshToken, &RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("reuse within grace period: %v", err)
+	}
+	if err := auth.Verify(ctx, retry.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Errorf("token issued within grace period: %v", err)
+	}
+}
+
+func TestCachedAuthenticator_MaxSessionLifetime(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestCachedAuthenticator(t, WithMaxSessionLifetime(time.Hour))
+
+	pair, _ := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	pair, err := auth.Refresh(ctx, pair.RefreshToken, &RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 将令牌族的登录时间提前到两小时前
+	sessions, _ := auth.ListSessions(ctx, "user123")
+	for _, s := range sessions {
+		s.FamilyCreatedAt = s.FamilyCreatedAt.Add(-2 * time.Hour)
+		if err := auth.sessionStore.SaveSession(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := auth.Refresh(ctx, pair.RefreshToken, &RegisteredClaims{}); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("expected ErrSessionExpired, got %v", err)
+	}
+}
+
+func TestCachedAuthenticator_ListDevices(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestCachedAuthenticator(t)
+
+	phone, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"},
+		WithDeviceID("phone"), WithDeviceLabel("My Phone"), WithClientIP("203.0.113.1"), WithUserAgent("app/1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"},
+		WithDeviceID("laptop"), WithClientIP("203.0.113.2"), WithLocation("Shanghai, CN")); err != nil {
+		t.Fatal(err)
+	}
+	// 无设备 ID 的会话不参与聚合
+	if _, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"}); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	// 刷新沿用设备元数据，并更新本次请求的客户端 IP
+	if _, err := auth.RefreshWithOptions(ctx, phone.RefreshToken, &RegisteredClaims{}, WithClientIP("198.51.100.9")); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := auth.ListDevices(ctx, "user123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+
+	got := devices[0]
+	if got.DeviceID != "phone" || got.Label != "My Phone" || got.IP != "198.51.100.9" || got.UserAgent != "app/1.0" {
+		t.Errorf("unexpected phone device %+v", got)
+	}
+	if !got.LastSeen.After(got.FirstSeen) {
+		t.Errorf("phone last seen %v not after first seen %v", got.LastSeen, got.FirstSeen)
+	}
+	// 轮换后的 refresh token 不计入活跃数
+	if got.ActiveAccessTokens != 2 || got.ActiveRefreshTokens != 1 {
+		t.Errorf("phone tokens: access %d refresh %d", got.ActiveAccessTokens, got.ActiveRefreshTokens)
+	}
+
+	if laptop := devices[1]; laptop.DeviceID != "laptop" || laptop.Location != "Shanghai, CN" ||
+		laptop.ActiveAccessTokens != 1 || laptop.ActiveRefreshTokens != 1 {
+		t.Errorf("unexpected laptop device %+v", laptop)
+	}
+}
+
+func TestCachedAuthenticator_RevokeWhere(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestCachedAuthenticator(t)
+
+	old, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"}, WithClientIP("203.0.113.5"), WithAppVersion("2.3.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdated, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"}, WithClientIP("198.51.100.1"), WithAppVersion("v1.9.8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"}, WithClientIP("198.51.100.2"), WithAppVersion("2.10.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user456"}, WithClientIP("203.0.113.5"), WithAppVersion("1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 刷新后的会话沿用客户端版本
+	refreshed, err := auth.Refresh(ctx, outdated.RefreshToken, &RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := auth.RevokeWhere(ctx, "user123", cache.Or(
+		cache.FromIPRange(netip.MustParsePrefix("203.0.113.0/24")),
+		cache.AppVersionBelow("2.3.0"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// old 的 2 个会话 + outdated 的 2 个会话 (refresh 已轮换) + refreshed 的 2 个会话
+	if n != 6 {
+		t.Errorf("revoked %d sessions, want 6", n)
+	}
+
+	for name, token := range map[string]string{"old": old.AccessToken, "outdated": outdated.AccessToken, "refreshed": refreshed.AccessToken} {
+		if err := auth.Verify(ctx, token, &RegisteredClaims{}); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("%s: expected ErrTokenRevoked, got %v", name, err)
+		}
+	}
+	if err := auth.Verify(ctx, current.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Errorf("current: %v", err)
+	}
+	// 其他用户不受影响
+	if err := auth.Verify(ctx, other.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Errorf("other subject: %v", err)
+	}
+	if sessions, _ := auth.ListSessions(ctx, "user123"); len(sessions) != 2 {
+		t.Errorf("expected 2 remaining sessions, got %d", len(sessions))
+	}
+
+	// 登录时间早于指定时间的会话全部撤销
+	if n, err := auth.RevokeWhere(ctx, "user123", cache.LoggedInBefore(time.Now().Add(time.Second))); err != nil || n != 2 {
+		t.Errorf("LoggedInBefore: revoked %d, err %v", n, err)
+	}
+	if _, err := auth.RevokeWhere(ctx, "user123", nil); err == nil {
+		t.Error("expected error for nil predicate")
+	}
+}
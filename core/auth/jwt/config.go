@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"crypto"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -8,9 +9,17 @@ import (
 
 // Config JWT 配置
 type Config struct {
-	// 必需配置
+	// HMAC 签名密钥，HS 系列算法必需
 	Secret string `json:"secret" validate:"required"`
 
+	// 非对称签名配置（RS、PS、ES、EdDSA 系列算法）
+	PrivateKeyFile string `json:"privateKeyFile"` // PEM 私钥文件，未通过 WithPrivateKey 指定私钥时加载
+	PublicKeyFile  string `json:"publicKeyFile"`  // PEM 公钥或证书文件，仅验证 token 的服务只需配置公钥
+	KeyID          string `json:"keyID"`          // token 头部与 JWKS 中的 kid，默认为公钥的 RFC 7638 指纹
+
+	// 验证时接受的签名算法，为空时只接受 SigningMethod
+	AllowedAlgorithms []string `json:"allowedAlgorithms"`
+
 	// Token 配置
 	SigningMethod   string `json:"signingMethod" default:"HS256"`
 	AccessTokenTTL  int64  `json:"accessTokenTTL" default:"3600" validate:"gt=0"`    // 秒，默认 1 小时
@@ -19,38 +28,61 @@ type Config struct {
 	// 标准 Claims 配置
 	Issuer   string   `json:"issuer"`
 	Audience []string `json:"audience"`
+
+	privateKey       crypto.PrivateKey           // WithPrivateKey 指定的私钥
+	privateKeyPEM    []byte                      // WithPrivateKeyPEM 指定的 PEM 私钥
+	publicKey        crypto.PublicKey            // WithPublicKey 指定的公钥
+	verificationKeys map[string]crypto.PublicKey // WithVerificationKey 指定的 kid -> 公钥
+}
+
+// 支持的签名算法
+const (
+	HS256 = "HS256" // HMAC SHA-256
+	HS384 = "HS384" // HMAC SHA-384
+	HS512 = "HS512" // HMAC SHA-512
+	RS256 = "RS256" // RSASSA-PKCS1-v1_5 SHA-256
+	RS384 = "RS384" // RSASSA-PKCS1-v1_5 SHA-384
+	RS512 = "RS512" // RSASSA-PKCS1-v1_5 SHA-512
+	PS256 = "PS256" // RSASSA-PSS SHA-256
+	PS384 = "PS384" // RSASSA-PSS SHA-384
+	PS512 = "PS512" // RSASSA-PSS SHA-512
+	ES256 = "ES256" // ECDSA P-256 SHA-256
+	ES384 = "ES384" // ECDSA P-384 SHA-384
+	ES512 = "ES512" // ECDSA P-521 SHA-512
+	EdDSA = "EdDSA" // Ed25519
+)
+
+// signingMethods 算法名称到签名方法的映射
+var signingMethods = map[string]jwt.SigningMethod{
+	HS256: jwt.SigningMethodHS256,
+	HS384: jwt.SigningMethodHS384,
+	HS512: jwt.SigningMethodHS512,
+	RS256: jwt.SigningMethodRS256,
+	RS384: jwt.SigningMethodRS384,
+	RS512: jwt.SigningMethodRS512,
+	PS256: jwt.SigningMethodPS256,
+	PS384: jwt.SigningMethodPS384,
+	PS512: jwt.SigningMethodPS512,
+	ES256: jwt.SigningMethodES256,
+	ES384: jwt.SigningMethodES384,
+	ES512: jwt.SigningMethodES512,
+	EdDSA: jwt.SigningMethodEdDSA,
 }
 
-// GetSigningMethod 获取签名方法
+// GetSigningMethod 获取签名方法，未知算法返回 HS256（创建认证器时会拒绝未知算法）
 func (c *Config) GetSigningMethod() jwt.SigningMethod {
-	switch c.SigningMethod {
-	case "HS256":
-		return jwt.SigningMethodHS256
-	case "HS384":
-		return jwt.SigningMethodHS384
-	case "HS512":
-		return jwt.SigningMethodHS512
-	case "RS256":
-		return jwt.SigningMethodRS256
-	case "RS384":
-		return jwt.SigningMethodRS384
-	case "RS512":
-		return jwt.SigningMethodRS512
-	case "ES256":
-		return jwt.SigningMethodES256
-	case "ES384":
-		return jwt.SigningMethodES384
-	case "ES512":
-		return jwt.SigningMethodES512
-	case "PS256":
-		return jwt.SigningMethodPS256
-	case "PS384":
-		return jwt.SigningMethodPS384
-	case "PS512":
-		return jwt.SigningMethodPS512
-	default:
-		return jwt.SigningMethodHS256
+	if m, ok := signingMethods[c.SigningMethod]; ok {
+		return m
+	}
+	return jwt.SigningMethodHS256
+}
+
+// GetAllowedAlgorithms 获取验证时接受的签名算法
+func (c *Config) GetAllowedAlgorithms() []string {
+	if len(c.AllowedAlgorithms) == 0 {
+		return []string{c.SigningMethod}
 	}
+	return c.AllowedAlgorithms
 }
 
 // GetAccessTokenTTL 获取 Access Token TTL
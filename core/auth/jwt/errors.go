@@ -9,13 +9,28 @@ var (
 	ErrTokenRevoked     = errors.New("jwt: token revoked")
 	ErrInvalidSignature = errors.New("jwt: invalid signature")
 	ErrInvalidClaims    = errors.New("jwt: invalid claims")
+	ErrInvalidAudience  = errors.New("jwt: invalid audience")
 
 	// 配置相关错误
 	ErrConfigInvalid = errors.New("jwt: invalid configuration")
 	ErrEmptySecret   = errors.New("jwt: secret cannot be empty")
+	ErrInvalidKey    = errors.New("jwt: invalid key")
+	ErrNoSigningKey  = errors.New("jwt: no signing key")
+
+	// JWKS 相关错误
+	ErrUnknownKeyID    = errors.New("jwt: unknown key id")
+	ErrJWKSUnavailable = errors.New("jwt: jwks unavailable")
+	ErrUnsupportedKey  = errors.New("jwt: unsupported key type")
 
 	// 会话相关错误
 	ErrSessionNotFound = errors.New("jwt: session not found")
 	ErrMaxDevicesLimit = errors.New("jwt: max devices limit reached")
 	ErrInvalidSession  = errors.New("jwt: invalid session")
+	ErrSessionExpired  = errors.New("jwt: session lifetime exceeded")
+	ErrRefreshReused   = errors.New("jwt: refresh token reused")
+
+	// 令牌交换相关错误
+	ErrInvalidScope        = errors.New("jwt: invalid scope")
+	ErrNotDelegated        = errors.New("jwt: not a delegation token")
+	ErrExchangeUnsupported = errors.New("jwt: token exchange not supported")
 )
@@ -0,0 +1,178 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Actor 代理方，对应 RFC 8693 的 act 声明。Act 为更早的代理方，构成委托链
+type Actor struct {
+	Subject string `json:"sub"`
+	Issuer  string `json:"iss,omitempty"`
+	Act     *Actor `json:"act,omitempty"`
+}
+
+// DelegationClaims 委托 token 的 claims。
+//
+// Subject 为最终用户，Act 为当前代理方（嵌套的 Act 为更早的代理方），
+// AuthorizedParty 为被授权使用该 token 的服务，Scope 为空格分隔的权限列表
+type DelegationClaims struct {
+	RegisteredClaims
+	Scope           string `json:"scope,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+	Act             *Actor `json:"act,omitempty"`
+}
+
+// SetStandardClaims 实现 StandardClaimsSetter
+func (c *DelegationClaims) SetStandardClaims(jti string, issuedAt, expiresAt time.Time, issuer string, audience []string) {
+	c.ID = jti
+	c.IssuedAt = jwt.NewNumericDate(issuedAt)
+	c.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	if issuer != "" {
+		c.Issuer = issuer
+	}
+	if len(audience) > 0 {
+		c.Audience = audience
+	}
+}
+
+// Scopes 返回权限列表
+func (c *DelegationClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope 判断是否包含权限 scope
+func (c *DelegationClaims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// Delegated 判断是否为委托 token
+func (c *DelegationClaims) Delegated() bool {
+	return c.Act != nil
+}
+
+// Chain 返回委托链，从当前代理方到最早的代理方，不含最终用户。
+// 返回的 Actor 的 Act 字段均为 nil
+func (c *DelegationClaims) Chain() []Actor {
+	var chain []Actor
+	for act := c.Act; act != nil; act = act.Act {
+		chain = append(chain, Actor{Subject: act.Subject, Issuer: act.Issuer})
+	}
+	return chain
+}
+
+// ExchangeOptions 令牌交换选项
+type ExchangeOptions struct {
+	Scopes []string      // 委托 token 的权限，须为原 token 权限的子集
+	TTL    time.Duration // 委托 token 有效期上限
+}
+
+// ExchangeOption 令牌交换选项函数
+type ExchangeOption func(*ExchangeOptions)
+
+// WithExchangeScopes 缩小委托 token 的权限。未设置时沿用原 token 的权限
+func WithExchangeScopes(scopes ...string) ExchangeOption {
+	return func(o *ExchangeOptions) {
+		o.Scopes = scopes
+	}
+}
+
+// WithExchangeTTL 设置委托 token 的有效期，默认与 Access Token TTL 相同。
+// 委托 token 不会晚于原 token 过期
+func WithExchangeTTL(ttl time.Duration) ExchangeOption {
+	return func(o *ExchangeOptions) {
+		o.TTL = ttl
+	}
+}
+
+// Exchange 令牌交换（RFC 8693 on-behalf-of）：验证最终用户的 subjectToken，
+// 为代理方 actor 签发仅供 audience 使用的委托 token。
+//
+// 委托 token 沿用原 token 的 subject，act 声明记录 actor 并嵌套原 token 已有的委托链，
+// azp 为 actor.Subject。actor.Act 会被忽略，委托链只取自已验证的原 token。
+// 委托 token 只携带标准字段与 scope，原 token 的自定义字段不会传递给下游服务。
+// 返回的 TokenPair 不含 Refresh Token
+func (a *BasicAuthenticator) Exchange(ctx context.Context, subjectToken string, actor Actor, audience string, opts ...ExchangeOption) (*TokenPair, error) {
+	subject := &DelegationClaims{}
+	if err := a.Verify(ctx, subjectToken, subject); err != nil {
+		return nil, fmt.Errorf("verify subject token: %w", err)
+	}
+	return a.exchange(subject, actor, audience, opts...)
+}
+
+// exchange 基于已验证的原 token claims 签发委托 token
+func (a *BasicAuthenticator) exchange(subject *DelegationClaims, actor Actor, audience string, opts ...ExchangeOption) (*TokenPair, error) {
+	if actor.Subject == "" {
+		return nil, fmt.Errorf("%w: actor subject is required", ErrInvalidClaims)
+	}
+	if audience == "" {
+		return nil, fmt.Errorf("%w: exchange requires an audience", ErrInvalidAudience)
+	}
+
+	options := &ExchangeOptions{TTL: a.config.GetAccessTokenTTL()}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	scope, err := reduceScope(subject.Scope, options.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := options.TTL
+	if subject.ExpiresAt != nil {
+		ttl = min(ttl, time.Until(subject.ExpiresAt.Time))
+	}
+	if ttl <= 0 {
+		return nil, ErrExpiredToken
+	}
+
+	claims := &DelegationClaims{
+		Scope:           scope,
+		AuthorizedParty: actor.Subject,
+		Act:             &Actor{Subject: actor.Subject, Issuer: actor.Issuer, Act: subject.Act},
+	}
+	claims.Subject = subject.Subject
+
+	token, err := a.generator.Generate(claims, ttl, []string{audience})
+	if err != nil {
+		return nil, fmt.Errorf("generate delegation token: %w", err)
+	}
+	return &TokenPair{
+		AccessToken: token,
+		ExpiresIn:   int64(ttl / time.Second),
+	}, nil
+}
+
+// reduceScope 校验请求的权限为原权限的子集，未请求时沿用原权限。
+// 原 token 无 scope 时不能请求任何权限，避免委托 token 获得原 token 没有的权限
+func reduceScope(granted string, requested []string) (string, error) {
+	if len(requested) == 0 {
+		return granted, nil
+	}
+	allowed := strings.Fields(granted)
+	for _, s := range requested {
+		if !slices.Contains(allowed, s) {
+			return "", fmt.Errorf("%w: %q not granted", ErrInvalidScope, s)
+		}
+	}
+	return strings.Join(requested, " "), nil
+}
+
+// VerifyDelegation 验证委托 token：受众须包含 audience，且 token 须带有委托链。
+// verifier 可为 BasicAuthenticator、CachedAuthenticator 或 RemoteVerifier
+func VerifyDelegation(ctx context.Context, verifier AudienceVerifier, tokenString, audience string) (*DelegationClaims, error) {
+	claims := &DelegationClaims{}
+	if err := verifier.VerifyForAudience(ctx, tokenString, audience, claims); err != nil {
+		return nil, err
+	}
+	if !claims.Delegated() {
+		return nil, ErrNotDelegated
+	}
+	return claims, nil
+}
@@ -0,0 +1,134 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBasicAuthenticator_Exchange(t *testing.T) {
+	ctx := context.Background()
+	auth, err := NewBasicAuthenticator(WithSecret("test-secret"), WithAccessTokenTTL(3600))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &DelegationClaims{Scope: "orders:read orders:write billing:read"}
+	user.Subject = "user123"
+	pair, err := auth.Generate(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 网关代表用户调用订单服务，只授予读权限
+	toOrders, err := auth.Exchange(ctx, pair.AccessToken, Actor{Subject: "gateway"}, "orders",
+		WithExchangeScopes("orders:read", "billing:read"), WithExchangeTTL(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toOrders.RefreshToken != "" || toOrders.ExpiresIn > 60 {
+		t.Errorf("unexpected token pair %+v", toOrders)
+	}
+
+	claims, err := VerifyDelegation(ctx, auth, toOrders.AccessToken, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user123" || claims.AuthorizedParty != "gateway" {
+		t.Errorf("sub %q azp %q", claims.Subject, claims.AuthorizedParty)
+	}
+	if !claims.HasScope("orders:read") || claims.HasScope("orders:write") {
+		t.Errorf("scopes %v", claims.Scopes())
+	}
+
+	// 订单服务继续代表用户调用账单服务，委托链向前嵌套
+	toBilling, err := auth.Exchange(ctx, toOrders.AccessToken, Actor{Subject: "orders"}, "billing",
+		WithExchangeScopes("billing:read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err = VerifyDelegation(ctx, auth, toBilling.AccessToken, "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Actor{{Subject: "orders"}, {Subject: "gateway"}}
+	if got := claims.Chain(); !slices.Equal(got, want) {
+		t.Errorf("chain %+v, want %+v", got, want)
+	}
+	if !claims.ExpiresAt.Time.Before(time.Now().Add(time.Minute + time.Second)) {
+		t.Errorf("delegation outlives subject token: %v", claims.ExpiresAt)
+	}
+
+	// 不能扩大权限
+	if _, err := auth.Exchange(ctx, toOrders.AccessToken, Actor{Subject: "orders"}, "billing",
+		WithExchangeScopes("orders:write")); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("scope escalation: expected ErrInvalidScope, got %v", err)
+	}
+	// 原 token 无 scope 时不能凭空请求权限
+	unscoped, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Exchange(ctx, unscoped.AccessToken, Actor{Subject: "gateway"}, "orders",
+		WithExchangeScopes("orders:write")); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("unscoped subject token: expected ErrInvalidScope, got %v", err)
+	}
+	if _, err := auth.Exchange(ctx, unscoped.AccessToken, Actor{Subject: "gateway"}, "orders"); err != nil {
+		t.Errorf("unscoped subject token without requested scopes: %v", err)
+	}
+
+	// 受众不匹配或非委托 token
+	if _, err := VerifyDelegation(ctx, auth, toOrders.AccessToken, "billing"); !errors.Is(err, ErrInvalidAudience) {
+		t.Errorf("wrong audience: expected ErrInvalidAudience, got %v", err)
+	}
+	direct, err := auth.Generate(ctx, user, WithTokenAudience("orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyDelegation(ctx, auth, direct.AccessToken, "orders"); !errors.Is(err, ErrNotDelegated) {
+		t.Errorf("direct token: expected ErrNotDelegated, got %v", err)
+	}
+
+	if _, err := auth.Exchange(ctx, pair.AccessToken, Actor{Subject: "gateway"}, ""); !errors.Is(err, ErrInvalidAudience) {
+		t.Errorf("empty audience: expected ErrInvalidAudience, got %v", err)
+	}
+}
+
+func TestCachedAuthenticator_Exchange(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestCachedAuthenticator(t)
+
+	pair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"}, WithDeviceID("phone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := auth.Exchange(ctx, pair.RefreshToken, Actor{Subject: "gateway"}, "orders"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("refresh token as subject: expected ErrInvalidSession, got %v", err)
+	}
+
+	delegated, err := auth.Exchange(ctx, pair.AccessToken, Actor{Subject: "gateway"}, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := VerifyDelegation(ctx, auth, delegated.AccessToken, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user123" || claims.Act.Subject != "gateway" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	// 委托 token 随设备一并撤销
+	if err := auth.RevokeDevice(ctx, "user123", "phone"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyDelegation(ctx, auth, delegated.AccessToken, "orders"); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("after device revocation: expected ErrTokenRevoked, got %v", err)
+	}
+	if _, err := auth.Exchange(ctx, pair.AccessToken, Actor{Subject: "gateway"}, "orders"); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("revoked subject token: expected ErrTokenRevoked, got %v", err)
+	}
+}
@@ -1,57 +1,193 @@
 package jwt
 
 import (
+	"crypto"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+
+	"github.com/kochabx/kit/core/crypto/jose"
 )
 
 // generator JWT 生成器
 type generator struct {
-	config *Config
+	config  *Config
+	method  jwt.SigningMethod
+	allowed []string // 验证时接受的算法
+
+	// 以下仅用于非对称签名
+	signKey crypto.PrivateKey // 为空时只能验证
+	kid     string
+	keys    map[string]crypto.PublicKey // kid -> 验证公钥
+	jwks    JWKSet
 }
 
-// newGenerator 创建生成器
+// newGenerator 创建生成器。
+//
+// HMAC 签名方法需要 Secret。非对称签名方法按 WithPrivateKey、WithPrivateKeyPEM、
+// PrivateKeyFile 的顺序取私钥；都未配置时按 WithPublicKey、PublicKeyFile 取公钥，
+// 生成的认证器只能验证 token
 func newGenerator(config *Config) (*generator, error) {
-	return &generator{config: config}, nil
+	method, ok := signingMethods[config.SigningMethod]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported signing method %q", ErrConfigInvalid, config.SigningMethod)
+	}
+	g := &generator{config: config, method: method, allowed: config.GetAllowedAlgorithms()}
+	for _, alg := range g.allowed {
+		if _, ok := signingMethods[alg]; !ok {
+			return nil, fmt.Errorf("%w: unsupported allowed algorithm %q", ErrConfigInvalid, alg)
+		}
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if config.Secret == "" {
+			return nil, ErrEmptySecret
+		}
+		return g, nil
+	}
+
+	key, err := loadPrivateKey(config)
+	if err != nil {
+		return nil, err
+	}
+	var pub crypto.PublicKey
+	if key != nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok || !methodFits(method, signer.Public()) {
+			return nil, fmt.Errorf("%w: %T cannot sign %s", ErrInvalidKey, key, method.Alg())
+		}
+		g.signKey, pub = key, signer.Public()
+	} else {
+		if pub, err = loadPublicKey(config); err != nil {
+			return nil, err
+		}
+		if pub == nil {
+			return nil, fmt.Errorf("%w: %s requires a private or public key", ErrConfigInvalid, method.Alg())
+		}
+		if !methodFits(method, pub) {
+			return nil, fmt.Errorf("%w: %T cannot verify %s", ErrInvalidKey, pub, method.Alg())
+		}
+	}
+
+	jwk, err := NewJWK(pub)
+	if err != nil {
+		return nil, err
+	}
+	g.kid = config.KeyID
+	if g.kid == "" {
+		g.kid = jwk.Thumbprint()
+	}
+	jwk.KeyID, jwk.Algorithm = g.kid, method.Alg()
+	g.keys = map[string]crypto.PublicKey{g.kid: pub}
+	g.jwks.Keys = append(g.jwks.Keys, jwk)
+
+	for _, kid := range slices.Sorted(maps.Keys(config.verificationKeys)) {
+		pub := config.verificationKeys[kid]
+		if _, exists := g.keys[kid]; exists {
+			return nil, fmt.Errorf("%w: duplicate kid %q", ErrConfigInvalid, kid)
+		}
+		jwk, err := NewJWK(pub)
+		if err != nil {
+			return nil, err
+		}
+		jwk.KeyID = kid
+		g.keys[kid] = pub
+		g.jwks.Keys = append(g.jwks.Keys, jwk)
+	}
+	return g, nil
+}
+
+// loadPrivateKey 按配置取私钥，未配置时返回 nil
+func loadPrivateKey(config *Config) (crypto.PrivateKey, error) {
+	var (
+		key crypto.PrivateKey
+		err error
+	)
+	switch {
+	case config.privateKey != nil:
+		return config.privateKey, nil
+	case config.privateKeyPEM != nil:
+		key, err = jose.ParsePrivateKey(config.privateKeyPEM)
+	case config.PrivateKeyFile != "":
+		key, err = jose.LoadPrivateKey(config.PrivateKeyFile)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return key, nil
+}
+
+// loadPublicKey 按配置取公钥，未配置时返回 nil
+func loadPublicKey(config *Config) (crypto.PublicKey, error) {
+	if config.publicKey != nil {
+		return config.publicKey, nil
+	}
+	if config.PublicKeyFile == "" {
+		return nil, nil
+	}
+	key, err := jose.LoadPublicKey(config.PublicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return key, nil
 }
 
-// Generate 生成 token
-func (g *generator) Generate(claims Claims, ttl time.Duration) (string, error) {
+// Generate 生成 token，audience 为空时使用配置中的受众
+func (g *generator) Generate(claims Claims, ttl time.Duration, audience []string) (string, error) {
 	now := time.Now()
 	jti := uuid.New().String()
+	if len(audience) == 0 {
+		audience = g.config.Audience
+	}
+
+	setStandardClaims(claims, jti, now, now.Add(ttl), g.config.Issuer, audience)
 
+	token := jwt.NewWithClaims(g.method, claims)
+	if _, ok := g.method.(*jwt.SigningMethodHMAC); ok {
+		return token.SignedString(g.config.GetSecret())
+	}
+	if g.signKey == nil {
+		return "", ErrNoSigningKey
+	}
+	token.Header["kid"] = g.kid
+	return token.SignedString(g.signKey)
+}
+
+// setStandardClaims 设置 claims 的标准字段，claims 须为 *RegisteredClaims 或实现 StandardClaimsSetter
+func setStandardClaims(claims Claims, jti string, issuedAt, expiresAt time.Time, issuer string, audience []string) {
 	if rc, ok := claims.(*jwt.RegisteredClaims); ok {
-		if rc.ID == "" {
-			rc.ID = jti
-		}
-		rc.IssuedAt = jwt.NewNumericDate(now)
-		rc.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
-		if g.config.Issuer != "" {
-			rc.Issuer = g.config.Issuer
+		// 每个 token 使用新的 JTI，刷新时传入的 claims 带有旧 token 的 JTI
+		rc.ID = jti
+		rc.IssuedAt = jwt.NewNumericDate(issuedAt)
+		rc.ExpiresAt = jwt.NewNumericDate(expiresAt)
+		if issuer != "" {
+			rc.Issuer = issuer
 		}
-		if len(g.config.Audience) > 0 {
-			rc.Audience = g.config.Audience
+		if len(audience) > 0 {
+			rc.Audience = audience
 		}
 	} else if setter, ok := claims.(StandardClaimsSetter); ok {
-		setter.SetStandardClaims(jti, now, now.Add(ttl), g.config.Issuer, g.config.Audience)
+		setter.SetStandardClaims(jti, issuedAt, expiresAt, issuer, audience)
 	}
-
-	token := jwt.NewWithClaims(g.config.GetSigningMethod(), claims)
-	return token.SignedString(g.config.GetSecret())
 }
 
 // Parse 解析 token
 func (g *generator) Parse(tokenString string, claims Claims) error {
+	// 算法白名单由 WithValidMethods 校验，keyfunc 再确认算法与密钥类型匹配
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		// 验证签名方法
-		if token.Method != g.config.GetSigningMethod() {
+		if g.keys != nil {
+			return g.publicKey(token)
+		}
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidSignature
 		}
 		return g.config.GetSecret(), nil
-	})
+	}, jwt.WithValidMethods(g.allowed))
 
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
@@ -64,6 +200,34 @@ func (g *generator) Parse(tokenString string, claims Claims) error {
 	return nil
 }
 
+// publicKey 按 kid 选择验证公钥；无 kid 时使用主公钥
+func (g *generator) publicKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = g.kid
+	}
+	pub, ok := g.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+	}
+	if !methodFits(token.Method, pub) {
+		return nil, ErrInvalidSignature
+	}
+	return pub, nil
+}
+
+// checkAudience 检查 claims 的受众是否包含 audience
+func checkAudience(claims Claims, audience string) error {
+	aud, err := claims.GetAudience()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidClaims, err)
+	}
+	if !slices.Contains(aud, audience) {
+		return fmt.Errorf("%w: %q not in %v", ErrInvalidAudience, audience, []string(aud))
+	}
+	return nil
+}
+
 // Validate 仅验证 token 有效性
 func (g *generator) Validate(tokenString string) error {
 	claims := &RegisteredClaims{}
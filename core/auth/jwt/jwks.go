@@ -0,0 +1,188 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK JSON Web Key（RFC 7517），仅包含公钥参数
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC / OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKSet JWKS 文档
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// b64 JOSE 使用的无填充 base64url 编码
+var b64 = base64.RawURLEncoding
+
+// NewJWK 由 *rsa.PublicKey、*ecdsa.PublicKey 或 ed25519.PublicKey 创建签名用途的 JWK，
+// kid 与 alg 由调用方设置
+func NewJWK(key crypto.PublicKey) (JWK, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			Use:     "sig",
+			N:       b64.EncodeToString(k.N.Bytes()),
+			E:       b64.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		data, err := k.Bytes()
+		if err != nil {
+			return JWK{}, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+		}
+		size := (len(data) - 1) / 2
+		return JWK{
+			KeyType: "EC",
+			Use:     "sig",
+			Curve:   k.Curve.Params().Name,
+			X:       b64.EncodeToString(data[1 : 1+size]),
+			Y:       b64.EncodeToString(data[1+size:]),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{KeyType: "OKP", Use: "sig", Curve: "Ed25519", X: b64.EncodeToString(k)}, nil
+	default:
+		return JWK{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+	}
+}
+
+// PublicKey 解析 JWK 中的公钥
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, errN := b64.DecodeString(k.N)
+		e, errE := b64.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%w: malformed RSA key %q", ErrInvalidKey, k.KeyID)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curve := ecdsaCurve(k.Curve)
+		if curve == nil {
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKey, k.Curve)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		x, errX := b64.DecodeString(k.X)
+		y, errY := b64.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("%w: malformed EC key %q", ErrInvalidKey, k.KeyID)
+		}
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return pub, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKey, k.Curve)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: malformed Ed25519 key %q", ErrInvalidKey, k.KeyID)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: kty %q", ErrUnsupportedKey, k.KeyType)
+	}
+}
+
+// Thumbprint 返回 JWK 的 RFC 7638 SHA-256 指纹（base64url），用作默认 kid
+func (k JWK) Thumbprint() string {
+	// 必需成员按字典序排列，字符串值经 JSON 编码
+	q := func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	var canonical string
+	switch k.KeyType {
+	case "RSA":
+		canonical = `{"e":` + q(k.E) + `,"kty":"RSA","n":` + q(k.N) + `}`
+	case "EC":
+		canonical = `{"crv":` + q(k.Curve) + `,"kty":"EC","x":` + q(k.X) + `,"y":` + q(k.Y) + `}`
+	default:
+		canonical = `{"crv":` + q(k.Curve) + `,"kty":` + q(k.KeyType) + `,"x":` + q(k.X) + `}`
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return b64.EncodeToString(sum[:])
+}
+
+// JWKS 返回签名私钥与 WithVerificationKey 公钥组成的 JWKS 文档；
+// HMAC 签名方法没有可公开的密钥，返回空文档
+func (a *BasicAuthenticator) JWKS() JWKSet {
+	return JWKSet{Keys: append([]JWK{}, a.generator.jwks.Keys...)}
+}
+
+// JWKSHandler 返回输出 set 的 HTTP handler，通常挂载在 /.well-known/jwks.json；
+// gin 中使用 gin.WrapH(jwt.JWKSHandler(auth.JWKS()))
+func JWKSHandler(set JWKSet) http.Handler {
+	if set.Keys == nil {
+		set.Keys = []JWK{}
+	}
+	body, err := json.Marshal(set)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_, _ = w.Write(body)
+	})
+}
+
+// methodFits 判断签名方法能否使用该公钥
+func methodFits(method jwt.SigningMethod, key crypto.PublicKey) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		k, ok := key.(*ecdsa.PublicKey)
+		return ok && k.Curve.Params().BitSize == m.CurveBits
+	case *jwt.SigningMethodEd25519:
+		_, ok := key.(ed25519.PublicKey)
+		return ok
+	default:
+		return false
+	}
+}
+
+// ecdsaCurve 按 JWK crv 名称返回曲线
+func ecdsaCurve(name string) elliptic.Curve {
+	switch name {
+	case "P-256":
+		return elliptic.P256()
+	case "P-384":
+		return elliptic.P384()
+	case "P-521":
+		return elliptic.P521()
+	default:
+		return nil
+	}
+}
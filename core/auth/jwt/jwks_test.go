@@ -0,0 +1,220 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJWK_Thumbprint(t *testing.T) {
+	// RFC 7638 §3.1
+	jwk := JWK{
+		KeyType: "RSA",
+		N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:       "AQAB",
+	}
+	if got, want := jwk.Thumbprint(), "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Thumbprint() = %s, want %s", got, want)
+	}
+}
+
+func TestJWK_RoundTrip(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	for _, pub := range []crypto.PublicKey{&rsaKey.PublicKey, &ecKey.PublicKey, edPub} {
+		jwk, err := NewJWK(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+			t.Errorf("%s: round trip mismatch", jwk.KeyType)
+		}
+	}
+}
+
+func TestBasicAuthenticator_Asymmetric(t *testing.T) {
+	ctx := context.Background()
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	for _, tc := range []struct {
+		method string
+		key    any
+	}{
+		{"ES256", ecKey},
+		{"EdDSA", edKey},
+	} {
+		auth, err := NewBasicAuthenticator(WithSigningMethod(tc.method), WithPrivateKey(tc.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims := &RegisteredClaims{}
+		if err := auth.Verify(ctx, pair.AccessToken, claims); err != nil || claims.Subject != "user123" {
+			t.Fatalf("%s: Verify = %v, subject %q", tc.method, err, claims.Subject)
+		}
+
+		set := auth.JWKS()
+		if len(set.Keys) != 1 || set.Keys[0].Algorithm != tc.method || set.Keys[0].KeyID != set.Keys[0].Thumbprint() {
+			t.Errorf("%s: unexpected JWKS %+v", tc.method, set)
+		}
+	}
+
+	if _, err := NewBasicAuthenticator(WithSigningMethod("RS256"), WithPrivateKey(ecKey)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("mismatched key: expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := NewBasicAuthenticator(WithSigningMethod("ES256")); !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("missing key: expected ErrConfigInvalid, got %v", err)
+	}
+	hmac, _ := NewBasicAuthenticator(WithSecret("secret"))
+	if keys := hmac.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("HMAC authenticator published %d keys", len(keys))
+	}
+}
+
+func TestRemoteVerifier(t *testing.T) {
+	ctx := context.Background()
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	issuer, err := NewBasicAuthenticator(
+		WithSigningMethod("RS256"),
+		WithPrivateKey(oldKey),
+		WithKeyID("k1"),
+		WithIssuer("auth"),
+		WithAudience("orders"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 签发方可在运行期轮换密钥，handler 每次返回当前 JWKS
+	var current atomic.Pointer[BasicAuthenticator]
+	current.Store(issuer)
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		JWKSHandler(current.Load().JWKS()).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	verifier := NewRemoteVerifier(srv.URL, WithExpectedIssuer("auth"), WithMinRefreshInterval(0))
+
+	pair, err := issuer.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &RegisteredClaims{}
+	if err := verifier.VerifyForAudience(ctx, pair.AccessToken, "orders", claims); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifyForAudience(ctx, pair.AccessToken, "billing", &RegisteredClaims{}); !errors.Is(err, ErrInvalidAudience) {
+		t.Errorf("expected ErrInvalidAudience, got %v", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected 1 fetch, got %d", n)
+	}
+
+	// 轮换：新 kid 触发刷新，旧 kid 作为验证公钥继续有效
+	rotated, err := NewBasicAuthenticator(
+		WithSigningMethod("RS256"),
+		WithPrivateKey(newKey),
+		WithKeyID("k2"),
+		WithVerificationKey("k1", &oldKey.PublicKey),
+		WithIssuer("auth"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Store(rotated)
+	newPair, err := rotated.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(ctx, newPair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if err := verifier.Verify(ctx, pair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Fatalf("old key after rotation: %v", err)
+	}
+	if err := rotated.Verify(ctx, pair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Fatalf("issuer verifying old key: %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("expected 2 fetches, got %d", n)
+	}
+
+	// 其他签发者、HMAC token 与未知 kid 均被拒绝
+	other, _ := NewBasicAuthenticator(WithSigningMethod("RS256"), WithPrivateKey(newKey), WithKeyID("k2"), WithIssuer("evil"))
+	otherPair, _ := other.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err := verifier.Verify(ctx, otherPair.AccessToken, &RegisteredClaims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("wrong issuer: expected ErrInvalidToken, got %v", err)
+	}
+	hmac, _ := NewBasicAuthenticator(WithSecret("secret"), WithIssuer("auth"))
+	hmacPair, _ := hmac.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err := verifier.Verify(ctx, hmacPair.AccessToken, &RegisteredClaims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("HMAC token: expected ErrInvalidToken, got %v", err)
+	}
+	unknownKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	unknown, _ := NewBasicAuthenticator(WithSigningMethod("ES256"), WithPrivateKey(unknownKey), WithIssuer("auth"))
+	unknownPair, _ := unknown.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err := verifier.Verify(ctx, unknownPair.AccessToken, &RegisteredClaims{}); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("unknown kid: expected ErrUnknownKeyID, got %v", err)
+	}
+}
+
+func TestRemoteVerifier_Unavailable(t *testing.T) {
+	ctx := context.Background()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	auth, _ := NewBasicAuthenticator(WithSigningMethod("ES256"), WithPrivateKey(key))
+	pair, _ := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(auth.JWKS())
+	}))
+	defer srv.Close()
+
+	verifier := NewRemoteVerifier(srv.URL, WithRefreshInterval(time.Nanosecond))
+	if err := verifier.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 刷新失败时继续使用缓存的公钥
+	down.Store(true)
+	if err := verifier.Verify(ctx, pair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Fatalf("stale keys: %v", err)
+	}
+	if err := verifier.Refresh(ctx); !errors.Is(err, ErrJWKSUnavailable) {
+		t.Errorf("expected ErrJWKSUnavailable, got %v", err)
+	}
+
+	cold := NewRemoteVerifier(srv.URL)
+	if err := cold.Verify(ctx, pair.AccessToken, &RegisteredClaims{}); !errors.Is(err, ErrJWKSUnavailable) {
+		t.Errorf("cold cache: expected ErrJWKSUnavailable, got %v", err)
+	}
+}
@@ -2,6 +2,15 @@ package jwt
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -76,3 +85,165 @@ func TestBasicAuthenticator(t *testing.T) {
 	t.Log("New Access Token:", newPair.AccessToken)
 	t.Log("New Refresh Token:", newPair.RefreshToken)
 }
+
+func TestBasicAuthenticator_MultiAudience(t *testing.T) {
+	ctx := context.Background()
+
+	auth, err := NewBasicAuthenticator(
+		WithSecret("test-secret"),
+		WithAudience("default"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := &RegisteredClaims{Subject: "user123"}
+	pair, err := auth.Generate(ctx, claims, WithTokenAudience("orders", "billing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, aud := range []string{"orders", "billing"} {
+		if err := auth.VerifyForAudience(ctx, pair.AccessToken, aud, &RegisteredClaims{}); err != nil {
+			t.Errorf("VerifyForAudience(%q): %v", aud, err)
+		}
+	}
+	for _, aud := range []string{"default", "admin"} {
+		if err := auth.VerifyForAudience(ctx, pair.AccessToken, aud, &RegisteredClaims{}); !errors.Is(err, ErrInvalidAudience) {
+			t.Errorf("VerifyForAudience(%q): expected ErrInvalidAudience, got %v", aud, err)
+		}
+	}
+
+	// 未指定时使用配置中的受众
+	defaultPair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.VerifyForAudience(ctx, defaultPair.AccessToken, "default", &RegisteredClaims{}); err != nil {
+		t.Errorf("VerifyForAudience(default): %v", err)
+	}
+
+	// 刷新沿用原受众
+	refreshClaims := &RegisteredClaims{}
+	newPair, err := auth.Refresh(ctx, pair.RefreshToken, refreshClaims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified := &RegisteredClaims{}
+	if err := auth.VerifyForAudience(ctx, newPair.AccessToken, "billing", verified); err != nil {
+		t.Fatalf("refreshed token lost audience: %v", err)
+	}
+	if got := []string(verified.Audience); len(got) != 2 || got[0] != "orders" || got[1] != "billing" {
+		t.Errorf("expected audience [orders billing], got %v", got)
+	}
+}
+
+func TestBasicAuthenticator_PEMKeys(t *testing.T) {
+	ctx := context.Background()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	privDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	pubDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	dir := t.TempDir()
+	privFile := filepath.Join(dir, "private.pem")
+	pubFile := filepath.Join(dir, "public.pem")
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	if err := os.WriteFile(privFile, privPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewBasicAuthenticator(WithSigningMethod(RS256), WithPrivateKeyFile(privFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := signer.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPEM, err := NewBasicAuthenticator(WithSigningMethod(RS256), WithPrivateKeyPEM(privPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fromPEM.Verify(ctx, pair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Errorf("PEM key: %v", err)
+	}
+
+	// 只配置公钥时只能验证
+	verifier, err := NewBasicAuthenticator(WithSigningMethod(RS256), WithPublicKeyFile(pubFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &RegisteredClaims{}
+	if err := verifier.Verify(ctx, pair.AccessToken, claims); err != nil || claims.Subject != "user123" {
+		t.Fatalf("verify-only: Verify = %v, subject %q", err, claims.Subject)
+	}
+	if _, err := verifier.Generate(ctx, &RegisteredClaims{}); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("verify-only: expected ErrNoSigningKey, got %v", err)
+	}
+
+	if _, err := NewBasicAuthenticator(WithSigningMethod(RS256), WithPrivateKeyFile(filepath.Join(dir, "missing.pem"))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("missing file: expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := NewBasicAuthenticator(WithSigningMethod(ES256), WithPublicKeyFile(pubFile)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("mismatched public key: expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestBasicAuthenticator_AllowedAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	old, err := NewBasicAuthenticator(WithSigningMethod(RS256), WithPrivateKey(rsaKey), WithKeyID("rsa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPair, _ := old.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+
+	// 迁移到 ES256：旧 RS256 token 只有在白名单中才被接受
+	strict, err := NewBasicAuthenticator(
+		WithSigningMethod(ES256),
+		WithPrivateKey(ecKey),
+		WithVerificationKey("rsa", &rsaKey.PublicKey),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := strict.Verify(ctx, oldPair.AccessToken, &RegisteredClaims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RS256 not allowed: expected ErrInvalidToken, got %v", err)
+	}
+	migrating, err := NewBasicAuthenticator(
+		WithSigningMethod(ES256),
+		WithPrivateKey(ecKey),
+		WithVerificationKey("rsa", &rsaKey.PublicKey),
+		WithAllowedAlgorithms(ES256, RS256),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrating.Verify(ctx, oldPair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Errorf("RS256 allowed: %v", err)
+	}
+	newPair, _ := migrating.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err := migrating.Verify(ctx, newPair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Errorf("ES256: %v", err)
+	}
+
+	// HMAC 认证器拒绝其他算法的 token
+	hmac, _ := NewBasicAuthenticator(WithSecret("secret"))
+	if err := hmac.Verify(ctx, newPair.AccessToken, &RegisteredClaims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("HMAC: expected ErrInvalidToken, got %v", err)
+	}
+
+	if _, err := NewBasicAuthenticator(WithSecret("secret"), WithSigningMethod("none")); !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("unknown method: expected ErrConfigInvalid, got %v", err)
+	}
+	if _, err := NewBasicAuthenticator(WithSecret("secret"), WithAllowedAlgorithms(HS256, "XS256")); !errors.Is(err, ErrConfigInvalid) {
+		t.Errorf("unknown allowed algorithm: expected ErrConfigInvalid, got %v", err)
+	}
+	if _, err := NewBasicAuthenticator(WithSigningMethod(HS512)); !errors.Is(err, ErrEmptySecret) {
+		t.Errorf("empty secret: expected ErrEmptySecret, got %v", err)
+	}
+}
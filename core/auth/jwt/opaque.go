@@ -0,0 +1,338 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache"
+	"github.com/kochabx/kit/core/defaults"
+)
+
+// OpaqueTokenPrefix 不透明 token 的前缀，用于区分 JWT 及被密钥扫描工具检出
+const OpaqueTokenPrefix = "ot_"
+
+// IsOpaqueToken 是否为 OpaqueAuthenticator 签发的 token 格式
+func IsOpaqueToken(token string) bool {
+	return strings.HasPrefix(token, OpaqueTokenPrefix) && !strings.Contains(token, ".")
+}
+
+// OpaqueAuthenticator 不透明 token 认证器。
+//
+// 签发随机的引用 token，claims 保存在服务端的会话存储中，客户端无法读取 token 内容，
+// 适用于不能向客户端暴露 JWT 内容的产品。会话以 token 的 SHA-256 哈希为键（即 claims 的 JTI），
+// 存储中不保存 token 明文。每次验证都需查询存储，撤销立即生效，无需黑名单。
+//
+// 实现 Authenticator 与 AudienceVerifier 接口，可与 BasicAuthenticator 互换使用；
+// 两种模式间的迁移见 MigratingAuthenticator
+type OpaqueAuthenticator struct {
+	store  cache.SessionStore
+	config *Config
+}
+
+// NewOpaqueAuthenticator 创建不透明 token 认证器，opts 中只有 TTL、Issuer 与 Audience 生效
+func NewOpaqueAuthenticator(store cache.SessionStore, opts ...Option) (*OpaqueAuthenticator, error) {
+	if store == nil {
+		return nil, fmt.Errorf("%w: session store is nil", ErrConfigInvalid)
+	}
+
+	config := &Config{}
+	if err := defaults.Apply(config); err != nil {
+		return nil, fmt.Errorf("apply defaults: %w", err)
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.AccessTokenTTL <= 0 || config.RefreshTokenTTL <= 0 {
+		return nil, fmt.Errorf("%w: token ttl must be positive", ErrConfigInvalid)
+	}
+
+	return &OpaqueAuthenticator{store: store, config: config}, nil
+}
+
+// Generate 生成 token 对，claims 的标准字段（JTI、时间、签发者、受众）在保存前设置，
+// claims 须为 *RegisteredClaims 或实现 StandardClaimsSetter
+func (a *OpaqueAuthenticator) Generate(ctx context.Context, claims Claims, opts ...GenerateOption) (*TokenPair, error) {
+	return a.generate(ctx, claims, sessionFamily{}, opts...)
+}
+
+// generate 生成 token 对并保存会话，family 为空时开启新的令牌族
+func (a *OpaqueAuthenticator) generate(ctx context.Context, claims Claims, family sessionFamily, opts ...GenerateOption) (*TokenPair, error) {
+	options := &GenerateOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	accessToken, accessSession, err := a.newSession(claims, "access", a.config.GetAccessTokenTTL(), options)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token: %w", err)
+	}
+	refreshToken, refreshSession, err := a.newSession(claims, "refresh", a.config.GetRefreshTokenTTL(), options)
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	if family.id == "" {
+		family = sessionFamily{id: refreshSession.JTI, createdAt: refreshSession.CreatedAt}
+	}
+	for _, session := range []*cache.Session{accessSession, refreshSession} {
+		session.FamilyID = family.id
+		session.FamilyCreatedAt = family.createdAt
+	}
+
+	// token 只能通过会话验证，保存失败时返回错误
+	if err := a.store.SaveSession(ctx, accessSession); err != nil {
+		return nil, fmt.Errorf("save access session: %w", err)
+	}
+	if err := a.store.SaveSession(ctx, refreshSession); err != nil {
+		return nil, fmt.Errorf("save refresh session: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    a.config.AccessTokenTTL,
+	}, nil
+}
+
+// newSession 生成随机 token 及保存其 claims 的会话
+func (a *OpaqueAuthenticator) newSession(claims Claims, tokenType string, ttl time.Duration, options *GenerateOptions) (string, *cache.Session, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", nil, err
+	}
+	token := OpaqueTokenPrefix + base64.RawURLEncoding.EncodeToString(b[:])
+	jti := opaqueTokenID(token)
+
+	audience := options.Audience
+	if len(audience) == 0 {
+		audience = a.config.Audience
+	}
+	now := time.Now()
+	setStandardClaims(claims, jti, now, now.Add(ttl), a.config.Issuer, audience)
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, fmt.Errorf("marshal claims: %w", err)
+	}
+	subject, _ := claims.GetSubject()
+
+	return token, &cache.Session{
+		JTI:        jti,
+		Subject:    subject,
+		TokenType:  tokenType,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+		DeviceID:   options.DeviceID,
+		IP:         options.IP,
+		UserAgent:  options.UserAgent,
+		Location:   options.Location,
+		Label:      options.Label,
+		AppVersion: options.AppVersion,
+		Claims:     data,
+	}, nil
+}
+
+// Verify 验证 access token，并将服务端保存的 claims 解码到 claims
+func (a *OpaqueAuthenticator) Verify(ctx context.Context, tokenString string, claims Claims) error {
+	session, err := a.lookup(ctx, tokenString, "access")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(session.Claims, claims); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidClaims, err)
+	}
+	return nil
+}
+
+// VerifyForAudience 验证 access token，并要求其受众包含 audience
+func (a *OpaqueAuthenticator) VerifyForAudience(ctx context.Context, tokenString, audience string, claims Claims) error {
+	if err := a.Verify(ctx, tokenString, claims); err != nil {
+		return err
+	}
+	return checkAudience(claims, audience)
+}
+
+// Refresh 刷新 token：签发新的 token 对并删除旧的 refresh token，新 token 沿用原会话的设备、
+// 元数据、受众与令牌族。refresh token 只能使用一次，再次使用返回 ErrInvalidToken；
+// 旧 token 通过 SessionStore.ConsumeSession 原子地消费，并发刷新同一 token 时只有一个成功
+func (a *OpaqueAuthenticator) Refresh(ctx context.Context, refreshToken string, claims Claims) (*TokenPair, error) {
+	session, err := a.lookup(ctx, refreshToken, "refresh")
+	if err != nil {
+		return nil, fmt.Errorf("verify refresh token: %w", err)
+	}
+	if err := json.Unmarshal(session.Claims, claims); err != nil {
+		return nil, fmt.Errorf("verify refresh token: %w: %v", ErrInvalidClaims, err)
+	}
+
+	// 先消费旧 token 再签发，签发失败时需重新登录，但旧 token 不会留下可重复使用的窗口
+	consumed, err := a.store.ConsumeSession(ctx, session.JTI)
+	if err != nil {
+		return nil, fmt.Errorf("consume session: %w", err)
+	}
+	if !consumed {
+		return nil, fmt.Errorf("verify refresh token: %w", ErrInvalidToken)
+	}
+
+	family := sessionFamily{id: session.FamilyID, createdAt: session.FamilyCreatedAt}
+	genOpts := append(audienceOf(claims),
+		WithDeviceID(session.DeviceID),
+		WithClientIP(session.IP),
+		WithUserAgent(session.UserAgent),
+		WithLocation(session.Location),
+		WithDeviceLabel(session.Label),
+		WithAppVersion(session.AppVersion),
+	)
+	return a.generate(ctx, claims, family, genOpts...)
+}
+
+// Revoke 撤销单个 token（access 或 refresh），token 不存在时不返回错误
+func (a *OpaqueAuthenticator) Revoke(ctx context.Context, tokenString string) error {
+	if !IsOpaqueToken(tokenString) {
+		return ErrInvalidToken
+	}
+	if err := a.store.DeleteSession(ctx, opaqueTokenID(tokenString)); err != nil && !errors.Is(err, cache.ErrSessionNotFound) {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll 撤销用户所有 token
+func (a *OpaqueAuthenticator) RevokeAll(ctx context.Context, subject string) error {
+	if err := a.store.DeleteAllSessions(ctx, subject); err != nil {
+		return fmt.Errorf("delete all sessions: %w", err)
+	}
+	return nil
+}
+
+// ListSessions 列出用户所有会话
+func (a *OpaqueAuthenticator) ListSessions(ctx context.Context, subject string) ([]*cache.Session, error) {
+	return a.store.ListSessions(ctx, subject)
+}
+
+// Recognizes 是否为本认证器签发的 token 格式，供 MigratingAuthenticator 选择认证器
+func (a *OpaqueAuthenticator) Recognizes(tokenString string) bool {
+	return IsOpaqueToken(tokenString)
+}
+
+// lookup 按 token 查找未过期的会话，并要求其类型为 tokenType
+func (a *OpaqueAuthenticator) lookup(ctx context.Context, tokenString, tokenType string) (*cache.Session, error) {
+	if !IsOpaqueToken(tokenString) {
+		return nil, ErrInvalidToken
+	}
+	session, err := a.store.GetSession(ctx, opaqueTokenID(tokenString))
+	if err != nil {
+		if errors.Is(err, cache.ErrSessionNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	if session.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+	if !session.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiredToken
+	}
+	if len(session.Claims) == 0 {
+		return nil, ErrInvalidSession
+	}
+	return session, nil
+}
+
+// opaqueTokenID 不透明 token 的会话键：token 的 SHA-256 十六进制哈希
+func opaqueTokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigratingAuthenticator 在 JWT 与不透明 token 两种模式间迁移的认证器。
+//
+// 新 token 由 primary 签发；验证时按 token 格式选择 primary 或 legacy，迁移期间两种 token 均可使用。
+// legacy 签发的 refresh token 刷新时换发 primary 的 token 对，客户端在下一次刷新后完成迁移，
+// 旧 token 最迟在其过期后全部失效，届时可移除 legacy：
+//
+//	opaque, _ := jwt.NewOpaqueAuthenticator(store)
+//	auth := jwt.NewMigratingAuthenticator(opaque, jwtAuth) // JWT → 不透明 token
+//
+// 反向迁移时交换两个参数即可。认证器实现 Recognizes(token) bool 时按其判断格式（如 OpaqueAuthenticator），
+// 否则视为 JWT 认证器
+type MigratingAuthenticator struct {
+	primary Authenticator
+	legacy  Authenticator
+}
+
+// NewMigratingAuthenticator 创建迁移认证器，primary 为目标模式，legacy 为原模式
+func NewMigratingAuthenticator(primary, legacy Authenticator) *MigratingAuthenticator {
+	return &MigratingAuthenticator{primary: primary, legacy: legacy}
+}
+
+// Generate 由目标模式的认证器生成 token 对
+func (a *MigratingAuthenticator) Generate(ctx context.Context, claims Claims, opts ...GenerateOption) (*TokenPair, error) {
+	return a.primary.Generate(ctx, claims, opts...)
+}
+
+// Verify 按 token 格式选择认证器验证 token
+func (a *MigratingAuthenticator) Verify(ctx context.Context, tokenString string, claims Claims) error {
+	return a.route(tokenString).Verify(ctx, tokenString, claims)
+}
+
+// VerifyForAudience 验证 token，并要求其受众包含 audience
+func (a *MigratingAuthenticator) VerifyForAudience(ctx context.Context, tokenString, audience string, claims Claims) error {
+	if err := a.Verify(ctx, tokenString, claims); err != nil {
+		return err
+	}
+	return checkAudience(claims, audience)
+}
+
+// Refresh 刷新 token。原模式的 refresh token 先由 legacy 刷新使其失效，
+// 再由 primary 以相同 claims 与受众签发新的 token 对，legacy 换发的 token 对随即撤销。
+// legacy 为无状态的 BasicAuthenticator 时无法撤销，旧 refresh token 在过期前仍可使用
+func (a *MigratingAuthenticator) Refresh(ctx context.Context, refreshToken string, claims Claims) (*TokenPair, error) {
+	if a.route(refreshToken) == a.primary {
+		return a.primary.Refresh(ctx, refreshToken, claims)
+	}
+
+	legacyPair, err := a.legacy.Refresh(ctx, refreshToken, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoker, ok := a.legacy.(interface {
+		Revoke(ctx context.Context, tokenString string) error
+	}); ok {
+		for _, token := range []string{legacyPair.AccessToken, legacyPair.RefreshToken} {
+			if err := revoker.Revoke(ctx, token); err != nil {
+				return nil, fmt.Errorf("revoke legacy token: %w", err)
+			}
+		}
+	}
+	return a.primary.Generate(ctx, claims, audienceOf(claims)...)
+}
+
+// route 按 token 格式返回负责该 token 的认证器
+func (a *MigratingAuthenticator) route(tokenString string) Authenticator {
+	if recognizes(a.primary, tokenString) || !recognizes(a.legacy, tokenString) {
+		return a.primary
+	}
+	return a.legacy
+}
+
+// recognizes 认证器是否负责该格式的 token，未实现 Recognizes 的认证器负责 JWT
+func recognizes(auth Authenticator, tokenString string) bool {
+	if r, ok := auth.(interface{ Recognizes(tokenString string) bool }); ok {
+		return r.Recognizes(tokenString)
+	}
+	return !IsOpaqueToken(tokenString) && strings.Count(tokenString, ".") == 2
+}
+
+var (
+	_ Authenticator    = (*OpaqueAuthenticator)(nil)
+	_ AudienceVerifier = (*OpaqueAuthenticator)(nil)
+	_ Authenticator    = (*MigratingAuthenticator)(nil)
+	_ AudienceVerifier = (*MigratingAuthenticator)(nil)
+)
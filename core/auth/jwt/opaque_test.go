@@ -0,0 +1,207 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kochabx/kit/core/auth/jwt/cache/memory"
+)
+
+func TestOpaqueAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewSessionStore()
+	auth, err := NewOpaqueAuthenticator(store, WithIssuer("kit"), WithAccessTokenTTL(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &DelegationClaims{Scope: "orders:read"}
+	user.Subject = "user123"
+	pair, err := auth.Generate(ctx, user, WithDeviceID("phone"), WithTokenAudience("orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsOpaqueToken(pair.AccessToken) || !IsOpaqueToken(pair.RefreshToken) || pair.ExpiresIn != 60 {
+		t.Fatalf("unexpected token pair %+v", pair)
+	}
+	// token 中不包含 claims
+	if strings.Count(pair.AccessToken, ".") != 0 || strings.Contains(pair.AccessToken, "user123") {
+		t.Errorf("token exposes content: %s", pair.AccessToken)
+	}
+
+	// 存储中只保存哈希
+	sessions, _ := auth.ListSessions(ctx, "user123")
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.JTI == pair.AccessToken || s.JTI == pair.RefreshToken || s.DeviceID != "phone" || s.FamilyID == "" {
+			t.Errorf("unexpected session %+v", s)
+		}
+	}
+
+	claims := &DelegationClaims{}
+	if err := auth.VerifyForAudience(ctx, pair.AccessToken, "orders", claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user123" || claims.Issuer != "kit" || !claims.HasScope("orders:read") || claims.ID != opaqueTokenID(pair.AccessToken) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if err := auth.VerifyForAudience(ctx, pair.AccessToken, "billing", &DelegationClaims{}); !errors.Is(err, ErrInvalidAudience) {
+		t.Errorf("expected ErrInvalidAudience, got %v", err)
+	}
+
+	// refresh token 不能作为 access token 使用，未知与 JWT 格式的 token 无效
+	for _, token := range []string{pair.RefreshToken, OpaqueTokenPrefix + "unknown", "a.b.c"} {
+		if err := auth.Verify(ctx, token, &DelegationClaims{}); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%q): expected ErrInvalidToken, got %v", token, err)
+		}
+	}
+
+	// 刷新沿用 claims、受众、设备与令牌族，旧 refresh token 只能使用一次
+	refreshed, err := auth.Refresh(ctx, pair.RefreshToken, &DelegationClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Refresh(ctx, pair.RefreshToken, &DelegationClaims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reused refresh token: expected ErrInvalidToken, got %v", err)
+	}
+	claims = &DelegationClaims{}
+	if err := auth.VerifyForAudience(ctx, refreshed.AccessToken, "orders", claims); err != nil || !claims.HasScope("orders:read") {
+		t.Fatalf("refreshed token: %v %+v", err, claims)
+	}
+	sessions, _ = auth.ListSessions(ctx, "user123")
+	for _, s := range sessions {
+		if s.DeviceID != "phone" || s.FamilyID != sessions[0].FamilyID {
+			t.Errorf("session lost device or family: %+v", s)
+		}
+	}
+
+	// 撤销立即生效
+	if err := auth.Revoke(ctx, refreshed.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Verify(ctx, refreshed.AccessToken, &DelegationClaims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("revoked token: expected ErrInvalidToken, got %v", err)
+	}
+	if err := auth.RevokeAll(ctx, "user123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Verify(ctx, pair.AccessToken, &DelegationClaims{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("after RevokeAll: expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestOpaqueAuthenticator_ConcurrentRefresh(t *testing.T) {
+	ctx := context.Background()
+	const n = 8
+	store := newBarrierStore(n)
+	auth, err := NewOpaqueAuthenticator(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发刷新同一 refresh token：都通过查找，但只有一个请求能消费旧 token
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+	for range n {
+		wg.Go(func() {
+			_, err := auth.Refresh(ctx, pair.RefreshToken, &RegisteredClaims{})
+			if err == nil {
+				succeeded.Add(1)
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if got := succeeded.Load(); got != 1 {
+		t.Fatalf("%d concurrent refreshes succeeded, want 1", got)
+	}
+}
+
+func TestMigratingAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	basic, err := NewBasicAuthenticator(WithSecret("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opaque, err := NewOpaqueAuthenticator(memory.NewSessionStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewMigratingAuthenticator(opaque, basic)
+
+	// 迁移前签发的 JWT 仍可使用
+	old, err := basic.Generate(ctx, &RegisteredClaims{Subject: "user123"}, WithTokenAudience("orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &RegisteredClaims{}
+	if err := auth.VerifyForAudience(ctx, old.AccessToken, "orders", claims); err != nil || claims.Subject != "user123" {
+		t.Fatalf("legacy token: %v %+v", err, claims)
+	}
+
+	// 新 token 为不透明 token
+	pair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsOpaqueToken(pair.AccessToken) {
+		t.Fatalf("expected opaque token, got %s", pair.AccessToken)
+	}
+	if err := auth.Verify(ctx, pair.AccessToken, &RegisteredClaims{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 用 JWT refresh token 刷新换发不透明 token，沿用受众
+	upgraded, err := auth.Refresh(ctx, old.RefreshToken, &RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsOpaqueToken(upgraded.AccessToken) || !IsOpaqueToken(upgraded.RefreshToken) {
+		t.Fatalf("expected opaque tokens, got %+v", upgraded)
+	}
+	claims = &RegisteredClaims{}
+	if err := auth.VerifyForAudience(ctx, upgraded.AccessToken, "orders", claims); err != nil || claims.Subject != "user123" {
+		t.Fatalf("upgraded token: %v %+v", err, claims)
+	}
+
+	// 不透明 refresh token 由目标模式刷新
+	if _, err := auth.Refresh(ctx, upgraded.RefreshToken, &RegisteredClaims{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	auth, err := NewOpaqueAuthenticator(memory.NewSessionStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := auth.Generate(ctx, &RegisteredClaims{Subject: "user123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticate := Authenticate(auth, func() *RegisteredClaims { return new(RegisteredClaims) })
+	claims, err := authenticate(ctx, pair.AccessToken)
+	if err != nil || claims.Subject != "user123" {
+		t.Fatalf("Authenticate: %v %+v", err, claims)
+	}
+	if claims, err := authenticate(ctx, "invalid"); err == nil || claims != nil {
+		t.Errorf("expected error and nil claims, got %v %+v", err, claims)
+	}
+}
@@ -1,5 +1,10 @@
 package jwt
 
+import (
+	"crypto"
+	"time"
+)
+
 // Option 配置选项
 type Option func(*Config)
 
@@ -45,10 +50,74 @@ func WithAudience(audience ...string) Option {
 	}
 }
 
+// WithPrivateKey 设置非对称签名私钥：*rsa.PrivateKey、*ecdsa.PrivateKey 或
+// ed25519.PrivateKey，须与签名方法匹配
+func WithPrivateKey(key crypto.PrivateKey) Option {
+	return func(c *Config) {
+		c.privateKey = key
+	}
+}
+
+// WithPrivateKeyPEM 设置 PEM 编码的非对称签名私钥（PKCS#8、PKCS#1 或 SEC 1）
+func WithPrivateKeyPEM(data []byte) Option {
+	return func(c *Config) {
+		c.privateKeyPEM = data
+	}
+}
+
+// WithPrivateKeyFile 设置 PEM 私钥文件
+func WithPrivateKeyFile(path string) Option {
+	return func(c *Config) {
+		c.PrivateKeyFile = path
+	}
+}
+
+// WithPublicKey 设置验证公钥。未设置私钥时认证器只能验证 token，Generate 返回 ErrNoSigningKey
+func WithPublicKey(key crypto.PublicKey) Option {
+	return func(c *Config) {
+		c.publicKey = key
+	}
+}
+
+// WithPublicKeyFile 设置 PEM 公钥或证书文件，见 WithPublicKey
+func WithPublicKeyFile(path string) Option {
+	return func(c *Config) {
+		c.PublicKeyFile = path
+	}
+}
+
+// WithAllowedAlgorithms 设置验证时接受的签名算法，默认只接受签名方法本身。
+// 例如从 RS256 迁移到 ES256 期间同时接受两者
+func WithAllowedAlgorithms(algs ...string) Option {
+	return func(c *Config) {
+		c.AllowedAlgorithms = algs
+	}
+}
+
+// WithKeyID 设置签名私钥的 kid
+func WithKeyID(kid string) Option {
+	return func(c *Config) {
+		c.KeyID = kid
+	}
+}
+
+// WithVerificationKey 添加仅用于验证的公钥，通常是轮换下来的旧签名公钥：
+// 旧 token 过期前仍可通过验证，并继续发布在 JWKS 中
+func WithVerificationKey(kid string, key crypto.PublicKey) Option {
+	return func(c *Config) {
+		if c.verificationKeys == nil {
+			c.verificationKeys = make(map[string]crypto.PublicKey)
+		}
+		c.verificationKeys[kid] = key
+	}
+}
+
 // CacheConfig 缓存配置
 type CacheConfig struct {
-	MultiLogin bool // 是否启用多点登录
-	MaxDevices int  // 最大设备数量，0 表示无限制
+	MultiLogin         bool          // 是否启用多点登录
+	MaxDevices         int           // 最大设备数量，0 表示无限制
+	RefreshGracePeriod time.Duration // 已轮换的 refresh token 在该时间内重复使用不视为盗用，0 表示不容忍
+	MaxSessionLifetime time.Duration // 会话自登录起的最长存续时间，超过后必须重新登录，0 表示不限制
 }
 
 // CacheOption 缓存选项
@@ -70,3 +139,20 @@ func WithMaxDevices(maxDevices int) CacheOption {
 		c.MaxDevices = max(maxDevices, 0)
 	}
 }
+
+// WithRefreshGracePeriod 设置 refresh token 轮换的宽限期。
+// 客户端并发刷新或重试时可能多次提交同一个 refresh token，宽限期内的重复使用会再签发一对 token，
+// 超过宽限期则视为 token 被盗用，撤销整个令牌族
+func WithRefreshGracePeriod(d time.Duration) CacheOption {
+	return func(c *CacheConfig) {
+		c.RefreshGracePeriod = max(d, 0)
+	}
+}
+
+// WithMaxSessionLifetime 设置会话最长存续时间。
+// 每次刷新都会签发完整 TTL 的新 refresh token（滑动会话），该选项限制自登录起的总时长
+func WithMaxSessionLifetime(d time.Duration) CacheOption {
+	return func(c *CacheConfig) {
+		c.MaxSessionLifetime = max(d, 0)
+	}
+}
@@ -0,0 +1,254 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/kochabx/kit/log"
+)
+
+const (
+	defaultJWKSRefreshInterval    = 15 * time.Minute
+	defaultJWKSMinRefreshInterval = 30 * time.Second
+	defaultJWKSTimeout            = 10 * time.Second
+	maxJWKSSize                   = 1 << 20
+)
+
+// RemoteOption 远程 JWKS 验证器选项
+type RemoteOption func(*RemoteVerifier)
+
+// WithHTTPClient 设置拉取 JWKS 使用的 HTTP 客户端，默认超时 10 秒
+func WithHTTPClient(client *http.Client) RemoteOption {
+	return func(v *RemoteVerifier) {
+		if client != nil {
+			v.client = client
+		}
+	}
+}
+
+// WithRefreshInterval 设置 JWKS 缓存有效期，过期后在下次验证时重新拉取，默认 15 分钟
+func WithRefreshInterval(d time.Duration) RemoteOption {
+	return func(v *RemoteVerifier) {
+		if d > 0 {
+			v.refreshInterval = d
+		}
+	}
+}
+
+// WithMinRefreshInterval 设置遇到未知 kid 时两次拉取的最小间隔，默认 30 秒。
+// 签发方轮换密钥后新 kid 可立即生效，同时避免伪造 kid 的请求打满 JWKS 端点
+func WithMinRefreshInterval(d time.Duration) RemoteOption {
+	return func(v *RemoteVerifier) {
+		if d >= 0 {
+			v.minRefreshInterval = d
+		}
+	}
+}
+
+// WithExpectedIssuer 要求 token 的签发者为 issuer
+func WithExpectedIssuer(issuer string) RemoteOption {
+	return func(v *RemoteVerifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithRemoteAlgorithms 设置接受的签名算法，默认接受全部非对称算法
+func WithRemoteAlgorithms(algs ...string) RemoteOption {
+	return func(v *RemoteVerifier) {
+		if len(algs) > 0 {
+			v.algorithms = algs
+		}
+	}
+}
+
+// RemoteVerifier 使用远程 JWKS 验证其他服务签发的非对称签名 token，无需共享密钥。
+// 公钥按 kid 缓存，定期刷新；刷新失败时继续使用已缓存的公钥
+type RemoteVerifier struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	issuer             string
+	algorithms         []string
+
+	mu        sync.RWMutex
+	keys      map[string]remoteKey
+	fetchedAt time.Time
+
+	fetchMu sync.Mutex // 串行化拉取，并发的缓存未命中只触发一次请求
+}
+
+// remoteKey 缓存的远程公钥
+type remoteKey struct {
+	pub crypto.PublicKey
+	alg string // JWK 声明的算法，为空时接受与公钥类型匹配的算法
+}
+
+// NewRemoteVerifier 创建远程 JWKS 验证器，首次验证时拉取 url
+func NewRemoteVerifier(url string, opts ...RemoteOption) *RemoteVerifier {
+	v := &RemoteVerifier{
+		url:                url,
+		client:             &http.Client{Timeout: defaultJWKSTimeout},
+		refreshInterval:    defaultJWKSRefreshInterval,
+		minRefreshInterval: defaultJWKSMinRefreshInterval,
+		algorithms:         []string{RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512, EdDSA},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify 验证 token
+func (v *RemoteVerifier) Verify(ctx context.Context, tokenString string, claims Claims) error {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(v.algorithms)}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if (key.alg != "" && token.Method.Alg() != key.alg) || !methodFits(token.Method, key.pub) {
+			return nil, ErrInvalidSignature
+		}
+		return key.pub, nil
+	}, opts...)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if !token.Valid {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// VerifyForAudience 验证 token，并要求其受众包含 audience
+func (v *RemoteVerifier) VerifyForAudience(ctx context.Context, tokenString, audience string, claims Claims) error {
+	if err := v.Verify(ctx, tokenString, claims); err != nil {
+		return err
+	}
+	return checkAudience(claims, audience)
+}
+
+// Refresh 立即重新拉取 JWKS，可在启动时调用以提前发现配置错误
+func (v *RemoteVerifier) Refresh(ctx context.Context) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	return v.fetchLocked(ctx)
+}
+
+// key 按 kid 返回公钥。缓存过期时刷新；kid 未知时在最小间隔允许的情况下刷新后重试。
+// kid 为空时仅在 JWKS 只有一个公钥时使用该公钥
+func (v *RemoteVerifier) key(ctx context.Context, kid string) (remoteKey, error) {
+	keys, fetchedAt := v.snapshot()
+	if time.Since(fetchedAt) >= v.refreshInterval {
+		if err := v.refresh(ctx, fetchedAt); err != nil {
+			if keys == nil {
+				return remoteKey{}, err
+			}
+			log.Warn().Err(err).Str("url", v.url).Msg("jwt: jwks refresh failed, using cached keys")
+		}
+		keys, fetchedAt = v.snapshot()
+	}
+	if key, ok := lookupKey(keys, kid); ok {
+		return key, nil
+	}
+	if time.Since(fetchedAt) >= v.minRefreshInterval {
+		if err := v.refresh(ctx, fetchedAt); err != nil {
+			return remoteKey{}, err
+		}
+		keys, _ = v.snapshot()
+		if key, ok := lookupKey(keys, kid); ok {
+			return key, nil
+		}
+	}
+	return remoteKey{}, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+}
+
+func lookupKey(keys map[string]remoteKey, kid string) (remoteKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+func (v *RemoteVerifier) snapshot() (map[string]remoteKey, time.Time) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.keys, v.fetchedAt
+}
+
+// refresh 拉取 JWKS，若等待期间其他 goroutine 已完成比 seen 更新的拉取则直接返回
+func (v *RemoteVerifier) refresh(ctx context.Context, seen time.Time) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	if _, fetchedAt := v.snapshot(); fetchedAt.After(seen) {
+		return nil
+	}
+	return v.fetchLocked(ctx)
+}
+
+func (v *RemoteVerifier) fetchLocked(ctx context.Context) error {
+	keys, err := v.fetch(ctx)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// 失败同样记录时间，使最小刷新间隔对失败的拉取也生效
+	v.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+	v.keys = keys
+	return nil
+}
+
+func (v *RemoteVerifier) fetch(ctx context.Context) (map[string]remoteKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrJWKSUnavailable, v.url, resp.Status)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("%w: decode: %v", ErrJWKSUnavailable, err)
+	}
+	keys := make(map[string]remoteKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// 跳过无法识别的公钥，不影响同一文档中的其他公钥
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			log.Warn().Err(err).Str("kid", jwk.KeyID).Msg("jwt: jwks key skipped")
+			continue
+		}
+		keys[jwk.KeyID] = remoteKey{pub: pub, alg: jwk.Algorithm}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s contains no usable signing key", ErrJWKSUnavailable, v.url)
+	}
+	return keys, nil
+}
@@ -1,5 +1,7 @@
 package jwt
 
+import "time"
+
 // TokenPair Access Token 和 Refresh Token 对
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
@@ -10,6 +12,14 @@ type TokenPair struct {
 // GenerateOptions Token 生成选项
 type GenerateOptions struct {
 	DeviceID string
+	Audience []string // 覆盖配置中的受众
+
+	// 会话元数据，仅 CachedAuthenticator 使用，保存到 cache.Session
+	IP         string
+	UserAgent  string
+	Location   string
+	Label      string
+	AppVersion string
 }
 
 // GenerateOption Token 生成选项函数
@@ -21,3 +31,61 @@ func WithDeviceID(deviceID string) GenerateOption {
 		o.DeviceID = deviceID
 	}
 }
+
+// WithTokenAudience 为本次签发的 token 指定受众，覆盖 Config.Audience。
+// 一个认证服务可借此签发同时供多个服务使用的 token。
+func WithTokenAudience(audience ...string) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.Audience = audience
+	}
+}
+
+// WithClientIP 记录签发 token 时的客户端 IP
+func WithClientIP(ip string) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.IP = ip
+	}
+}
+
+// WithUserAgent 记录签发 token 时的 User-Agent
+func WithUserAgent(userAgent string) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.UserAgent = userAgent
+	}
+}
+
+// WithLocation 记录签发 token 时的地理位置，由调用方根据 IP 解析
+func WithLocation(location string) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.Location = location
+	}
+}
+
+// WithDeviceLabel 设置设备名称，用于设备管理界面展示
+func WithDeviceLabel(label string) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.Label = label
+	}
+}
+
+// WithAppVersion 记录签发 token 时的客户端版本，可用于按版本批量撤销会话
+func WithAppVersion(version string) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.AppVersion = version
+	}
+}
+
+// Device 设备信息，由同一 DeviceID 下的会话聚合而成
+type Device struct {
+	DeviceID  string `json:"device_id"`
+	Label     string `json:"label,omitempty"`
+	IP        string `json:"ip,omitempty"`         // 最近一次签发时的客户端 IP
+	UserAgent string `json:"user_agent,omitempty"` // 最近一次签发时的 User-Agent
+	Location  string `json:"location,omitempty"`   // 最近一次签发时的地理位置
+
+	FirstSeen time.Time `json:"first_seen"` // 现存会话中最早的登录时间
+	LastSeen  time.Time `json:"last_seen"`  // 最近一次登录或刷新时间
+
+	ActiveAccessTokens  int `json:"active_access_tokens"`  // 未过期的 access token 数
+	ActiveRefreshTokens int `json:"active_refresh_tokens"` // 未过期且未轮换的 refresh token 数
+}
@@ -1,13 +1,18 @@
 package mfa
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base32"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"math"
 	"net/url"
 	"strconv"
@@ -41,6 +46,28 @@ const (
 // TOTP URI 协议
 const TOTPScheme = "otpauth://totp/"
 
+// Algorithm HMAC 摘要算法
+type Algorithm string
+
+const (
+	AlgorithmSHA1   Algorithm = "SHA1" // 默认算法，兼容性最好
+	AlgorithmSHA256 Algorithm = "SHA256"
+	AlgorithmSHA512 Algorithm = "SHA512"
+)
+
+// hash 返回算法对应的摘要函数，未知算法返回 nil
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case AlgorithmSHA1:
+		return sha1.New
+	case AlgorithmSHA256:
+		return sha256.New
+	case AlgorithmSHA512:
+		return sha512.New
+	}
+	return nil
+}
+
 // 错误常量定义
 var (
 	ErrEmptySecret       = errors.New("secret cannot be empty")
@@ -63,6 +90,7 @@ type GoogleAuthenticatorer interface {
 	GenerateCode(secret string) (string, error)
 	GenerateQRCode(label string, issuer string, secret string) (string, error)
 	ValidateCode(secret, code string) (bool, error)
+	ValidateCodeContext(ctx context.Context, secret, code string) (bool, error)
 }
 
 // 全局实例
@@ -80,8 +108,11 @@ type GoogleAuthenticator struct {
 	QrCodeSize   int `json:"qr_code_size"`  // 二维码图像尺寸（像素）
 	TimeWindow   int `json:"time_window"`   // 时间同步问题的容错值
 
+	Algorithm Algorithm `json:"algorithm"` // HMAC 摘要算法
+
 	digitsMod uint32           // 10^digits，用于模运算
 	encoder   *base32.Encoding // 复用的base32编码器
+	guard     ReplayGuard      // 重放保护与失败限流，为空时不启用
 }
 
 // 配置GoogleAuthenticator的选项函数
@@ -133,6 +164,23 @@ func WithTimeWindow(timeWindow int) Option {
 	}
 }
 
+// WithAlgorithm 设置 HMAC 摘要算法。注意部分验证器应用只支持 SHA1
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(ga *GoogleAuthenticator) {
+		if algorithm.hash() != nil {
+			ga.Algorithm = algorithm
+		}
+	}
+}
+
+// WithReplayGuard 启用重放保护与失败限流：同一密钥下已接受时间片及更早的验证码不再接受，
+// 失败次数过多时锁定。未设置时 ValidateCode 在有效期内可重复接受同一验证码
+func WithReplayGuard(guard ReplayGuard) Option {
+	return func(ga *GoogleAuthenticator) {
+		ga.guard = guard
+	}
+}
+
 // NewGoogleAuthenticator 创建一个具有默认设置的新GoogleAuthenticator
 func NewGoogleAuthenticator(opts ...Option) GoogleAuthenticatorer {
 	ga := &GoogleAuthenticator{
@@ -141,6 +189,7 @@ func NewGoogleAuthenticator(opts ...Option) GoogleAuthenticatorer {
 		Digits:       DefaultDigits,
 		QrCodeSize:   DefaultQRCodeSize,
 		TimeWindow:   DefaultTimeWindow,
+		Algorithm:    AlgorithmSHA1,
 		digitsMod:    uint32(math.Pow10(DefaultDigits)),                // 预计算模数
 		encoder:      base32.StdEncoding.WithPadding(base32.NoPadding), // 复用编码器
 	}
@@ -187,7 +236,7 @@ func (ga *GoogleAuthenticator) GenerateCode(secret string) (string, error) {
 // generateCodeAtTime 为给定密钥在特定时间戳生成TOTP验证码
 func (ga *GoogleAuthenticator) generateCodeAtTime(secret string, timestamp int64) (string, error) {
 	// 解码base32密钥
-	secretBytes, err := ga.base32decode(strings.ToUpper(secret))
+	secretBytes, err := base32decode(strings.ToUpper(secret))
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", ErrSecretDecode, err)
 	}
@@ -195,30 +244,38 @@ func (ga *GoogleAuthenticator) generateCodeAtTime(secret string, timestamp int64
 	// 计算时间计数器
 	timeCounter := uint64(timestamp) / uint64(ga.ExpireSecond)
 
-	// 生成HMAC-SHA-1哈希
-	hash := ga.generateHMAC(secretBytes, timeCounter)
+	return counterCode(secretBytes, timeCounter, ga.Algorithm, ga.Digits, ga.digitsMod), nil
+}
+
+// counterCode 按 RFC 4226 为给定密钥和计数器生成验证码，TOTP 的计数器为时间步数
+func counterCode(secret []byte, counter uint64, algorithm Algorithm, digits int, digitsMod uint32) string {
+	// 生成HMAC哈希
+	hash := generateHMAC(secret, counter, algorithm)
 
 	// 提取动态二进制码
-	code := ga.extractDynamicCode(hash)
+	code := extractDynamicCode(hash, digitsMod)
 
 	// 格式化为带前导零的字符串
-	return fmt.Sprintf(fmt.Sprintf("%%0%dd", ga.Digits), code), nil
+	return fmt.Sprintf(fmt.Sprintf("%%0%dd", digits), code)
 }
 
-// generateHMAC 为给定密钥和计数器生成HMAC-SHA-1哈希
-func (ga *GoogleAuthenticator) generateHMAC(secret []byte, counter uint64) []byte {
+// generateHMAC 为给定密钥和计数器生成HMAC哈希
+func generateHMAC(secret []byte, counter uint64, algorithm Algorithm) []byte {
 	// 将计数器转换为8字节数组
 	counterBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(counterBytes, counter)
 
-	// 生成HMAC-SHA-1
-	mac := hmac.New(sha1.New, secret)
+	newHash := algorithm.hash()
+	if newHash == nil {
+		newHash = sha1.New
+	}
+	mac := hmac.New(newHash, secret)
 	mac.Write(counterBytes)
 	return mac.Sum(nil)
 }
 
 // extractDynamicCode 从HMAC哈希中提取动态码
-func (ga *GoogleAuthenticator) extractDynamicCode(hash []byte) uint32 {
+func extractDynamicCode(hash []byte, digitsMod uint32) uint32 {
 	// 从哈希的最后4位获取偏移量
 	offset := hash[len(hash)-1] & HashOffset
 
@@ -229,7 +286,7 @@ func (ga *GoogleAuthenticator) extractDynamicCode(hash []byte) uint32 {
 	dynamicBinaryCode &= MaxUint32
 
 	// 使用预计算的模数进行模运算
-	return dynamicBinaryCode % ga.digitsMod
+	return dynamicBinaryCode % digitsMod
 }
 
 // GenerateQRCode 为TOTP设置生成二维码
@@ -261,7 +318,7 @@ func (ga *GoogleAuthenticator) generateQRData(label, issuer, secret string) stri
 	params := url.Values{}
 	params.Set("secret", secret)
 	params.Set("issuer", issuer)
-	params.Set("algorithm", "SHA1")
+	params.Set("algorithm", string(ga.Algorithm))
 	params.Set("digits", strconv.Itoa(ga.Digits))
 	params.Set("period", strconv.Itoa(ga.ExpireSecond))
 
@@ -270,6 +327,14 @@ func (ga *GoogleAuthenticator) generateQRData(label, issuer, secret string) stri
 
 // ValidateCode 验证具有时间窗口容错的TOTP验证码
 func (ga *GoogleAuthenticator) ValidateCode(secret, code string) (bool, error) {
+	return ga.ValidateCodeContext(context.Background(), secret, code)
+}
+
+// ValidateCodeContext 验证具有时间窗口容错的TOTP验证码。
+//
+// 启用 ReplayGuard 时：被锁定返回 *LockedError；验证码已被使用返回 ErrCodeReplayed，
+// 并与不匹配一样计入失败次数；验证成功后清除失败计数
+func (ga *GoogleAuthenticator) ValidateCodeContext(ctx context.Context, secret, code string) (bool, error) {
 	if secret == "" {
 		return false, ErrEmptySecret
 	}
@@ -277,26 +342,69 @@ func (ga *GoogleAuthenticator) ValidateCode(secret, code string) (bool, error) {
 		return false, ErrEmptyCode
 	}
 
-	currentTime := time.Now().Unix()
+	var key string
+	if ga.guard != nil {
+		key = guardKey(secret)
+		remaining, err := ga.guard.Locked(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		if remaining > 0 {
+			return false, &LockedError{RetryAfter: remaining}
+		}
+	}
 
-	// 检查当前时间窗口和相邻窗口以处理时钟偏移容错
+	slot, matched, err := ga.matchSlot(secret, code, time.Now().Unix())
+	if err != nil {
+		return false, err
+	}
+	if ga.guard == nil {
+		return matched, nil
+	}
+	if !matched {
+		return false, ga.guard.Fail(ctx, key)
+	}
+
+	// 记录须覆盖该时间片验证码在容错窗口内的全部有效时间
+	ttl := time.Duration(2*ga.TimeWindow+1) * time.Duration(ga.ExpireSecond) * time.Second
+	used, err := ga.guard.Use(ctx, key, slot, ttl)
+	if err != nil {
+		return false, err
+	}
+	if !used {
+		if err := ga.guard.Fail(ctx, key); err != nil {
+			return false, err
+		}
+		return false, ErrCodeReplayed
+	}
+	return true, ga.guard.Reset(ctx, key)
+}
+
+// matchSlot 检查当前时间窗口和相邻窗口以处理时钟偏移容错，返回匹配的时间片
+func (ga *GoogleAuthenticator) matchSlot(secret, code string, currentTime int64) (uint64, bool, error) {
 	for i := -ga.TimeWindow; i <= ga.TimeWindow; i++ {
 		windowTime := currentTime + int64(i*ga.ExpireSecond)
 		generatedCode, err := ga.generateCodeAtTime(secret, windowTime)
 		if err != nil {
-			return false, fmt.Errorf("%w: %v", ErrCodeGeneration, err)
+			return 0, false, fmt.Errorf("%w: %v", ErrCodeGeneration, err)
 		}
 
 		if generatedCode == code {
-			return true, nil
+			return uint64(windowTime) / uint64(ga.ExpireSecond), true, nil
 		}
 	}
 
-	return false, nil
+	return 0, false, nil
+}
+
+// guardKey 由密钥派生 ReplayGuard 的 key，避免明文密钥出现在存储中
+func guardKey(secret string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(secret)))
+	return "totp:" + hex.EncodeToString(sum[:16])
 }
 
 // base32decode 将base32字符串解码为字节数组
-func (ga *GoogleAuthenticator) base32decode(encoded string) ([]byte, error) {
+func base32decode(encoded string) ([]byte, error) {
 	// 如果需要，添加填充
 	if len(encoded)%8 != 0 {
 		encoded += strings.Repeat("=", 8-len(encoded)%8)
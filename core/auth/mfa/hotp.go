@@ -0,0 +1,228 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kochabx/kit/core/x/qrcode"
+)
+
+const (
+	// HOTP 默认配置值
+	DefaultLookAhead    = 10  // 验证时向后查找的计数器数量
+	DefaultResyncWindow = 100 // 重新同步时向后查找的计数器数量
+
+	MaxLookAhead    = 100  // 最大验证查找窗口
+	MaxResyncWindow = 1000 // 最大重新同步窗口
+)
+
+// HOTP URI 协议
+const HOTPScheme = "otpauth://hotp/"
+
+// HOTP 实现基于计数器的一次性密码（RFC 4226），适用于硬件令牌等无可靠时钟的设备。
+//
+// 服务端为每个用户保存计数器：验证成功后保存返回的新计数器，已使用的验证码随之失效。
+// 用户在设备上多次生成而未提交时，设备计数器领先于服务端，ValidateCode 在 LookAhead
+// 范围内查找；超出范围时使用 Resync 提交连续两个验证码重新同步
+type HOTP struct {
+	SecretSize   int       `json:"secret_size"`   // 生成密钥的长度
+	Digits       int       `json:"digits"`        // 生成验证码的位数
+	Algorithm    Algorithm `json:"algorithm"`     // HMAC 摘要算法
+	QrCodeSize   int       `json:"qr_code_size"`  // 二维码图像尺寸（像素）
+	LookAhead    int       `json:"look_ahead"`    // 验证时向后查找的计数器数量
+	ResyncWindow int       `json:"resync_window"` // 重新同步时向后查找的计数器数量
+
+	digitsMod uint32           // 10^digits，用于模运算
+	encoder   *base32.Encoding // 复用的base32编码器
+}
+
+// HOTPOption 配置 HOTP 的选项函数
+type HOTPOption func(*HOTP)
+
+// WithHOTPSecretSize 设置密钥大小
+func WithHOTPSecretSize(secretSize int) HOTPOption {
+	return func(h *HOTP) {
+		if secretSize >= MinSecretSize && secretSize <= MaxSecretSize {
+			h.SecretSize = secretSize
+		}
+	}
+}
+
+// WithHOTPDigits 设置验证码位数
+func WithHOTPDigits(digits int) HOTPOption {
+	return func(h *HOTP) {
+		if digits >= MinDigits && digits <= MaxDigits {
+			h.Digits = digits
+			h.digitsMod = uint32(math.Pow10(digits))
+		}
+	}
+}
+
+// WithHOTPAlgorithm 设置 HMAC 摘要算法
+func WithHOTPAlgorithm(algorithm Algorithm) HOTPOption {
+	return func(h *HOTP) {
+		if algorithm.hash() != nil {
+			h.Algorithm = algorithm
+		}
+	}
+}
+
+// WithHOTPQrCodeSize 设置二维码图像尺寸
+func WithHOTPQrCodeSize(qrCodeSize int) HOTPOption {
+	return func(h *HOTP) {
+		if qrCodeSize > 0 {
+			h.QrCodeSize = qrCodeSize
+		}
+	}
+}
+
+// WithLookAhead 设置验证时向后查找的计数器数量，0 表示只接受当前计数器
+func WithLookAhead(lookAhead int) HOTPOption {
+	return func(h *HOTP) {
+		if lookAhead >= 0 && lookAhead <= MaxLookAhead {
+			h.LookAhead = lookAhead
+		}
+	}
+}
+
+// WithResyncWindow 设置重新同步时向后查找的计数器数量
+func WithResyncWindow(window int) HOTPOption {
+	return func(h *HOTP) {
+		if window > 0 && window <= MaxResyncWindow {
+			h.ResyncWindow = window
+		}
+	}
+}
+
+// NewHOTP 创建一个具有默认设置的 HOTP
+func NewHOTP(opts ...HOTPOption) *HOTP {
+	h := &HOTP{
+		SecretSize:   DefaultSecretSize,
+		Digits:       DefaultDigits,
+		Algorithm:    AlgorithmSHA1,
+		QrCodeSize:   DefaultQRCodeSize,
+		LookAhead:    DefaultLookAhead,
+		ResyncWindow: DefaultResyncWindow,
+		digitsMod:    uint32(math.Pow10(DefaultDigits)),
+		encoder:      base32.StdEncoding.WithPadding(base32.NoPadding),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// GenerateSecret 生成加密安全的随机密钥
+func (h *HOTP) GenerateSecret() (string, error) {
+	secret := make([]byte, h.SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRandomGeneration, err)
+	}
+	return strings.ToUpper(h.encoder.EncodeToString(secret)), nil
+}
+
+// GenerateCode 为给定密钥和计数器生成验证码
+func (h *HOTP) GenerateCode(secret string, counter uint64) (string, error) {
+	key, err := h.decode(secret)
+	if err != nil {
+		return "", err
+	}
+	return counterCode(key, counter, h.Algorithm, h.Digits, h.digitsMod), nil
+}
+
+// ValidateCode 在 [counter, counter+LookAhead] 范围内验证验证码。
+// 验证成功时返回匹配计数器的下一个值，调用方须保存为新的计数器
+func (h *HOTP) ValidateCode(secret, code string, counter uint64) (uint64, bool, error) {
+	if code == "" {
+		return counter, false, ErrEmptyCode
+	}
+	key, err := h.decode(secret)
+	if err != nil {
+		return counter, false, err
+	}
+	for c := counter; c <= counter+uint64(h.LookAhead); c++ {
+		if h.match(key, code, c) {
+			return c + 1, true, nil
+		}
+	}
+	return counter, false, nil
+}
+
+// Resync 使用设备上连续生成的两个验证码重新同步计数器（RFC 4226 7.4 节），
+// 在 [counter, counter+ResyncWindow] 范围内查找 code1，且 code2 须匹配其下一个计数器。
+// 成功时返回 code2 所在计数器的下一个值，调用方须保存为新的计数器
+func (h *HOTP) Resync(secret, code1, code2 string, counter uint64) (uint64, bool, error) {
+	if code1 == "" || code2 == "" {
+		return counter, false, ErrEmptyCode
+	}
+	key, err := h.decode(secret)
+	if err != nil {
+		return counter, false, err
+	}
+	for c := counter; c <= counter+uint64(h.ResyncWindow); c++ {
+		if !h.match(key, code1, c) {
+			continue
+		}
+		if h.match(key, code2, c+1) {
+			return c + 2, true, nil
+		}
+	}
+	return counter, false, nil
+}
+
+// match 以常量时间比较验证码
+func (h *HOTP) match(key []byte, code string, counter uint64) bool {
+	expected := counterCode(key, counter, h.Algorithm, h.Digits, h.digitsMod)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1
+}
+
+// decode 解码 base32 密钥
+func (h *HOTP) decode(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, ErrEmptySecret
+	}
+	key, err := base32decode(strings.ToUpper(secret))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSecretDecode, err)
+	}
+	return key, nil
+}
+
+// GenerateQRCode 为 HOTP 设置生成二维码，counter 为设备的初始计数器
+func (h *HOTP) GenerateQRCode(label, issuer, secret string, counter uint64) (string, error) {
+	if label == "" {
+		return "", ErrEmptyLabel
+	}
+	if issuer == "" {
+		return "", ErrEmptyIssuer
+	}
+	if secret == "" {
+		return "", ErrEmptySecret
+	}
+
+	qrCode, err := qrcode.Generate(h.URI(label, issuer, secret, counter), h.QrCodeSize)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrQRCodeGeneration, err)
+	}
+	return qrCode, nil
+}
+
+// URI 生成 otpauth://hotp/ URI
+func (h *HOTP) URI(label, issuer, secret string, counter uint64) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", string(h.Algorithm))
+	params.Set("digits", strconv.Itoa(h.Digits))
+	params.Set("counter", strconv.FormatUint(counter, 10))
+
+	return fmt.Sprintf("%s%s?%s", HOTPScheme, url.QueryEscape(label), params.Encode())
+}
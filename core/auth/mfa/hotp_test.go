@@ -0,0 +1,108 @@
+package mfa
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// RFC 4226 附录 D 测试密钥 "12345678901234567890"
+var rfc4226Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestHOTP_RFC4226Vectors(t *testing.T) {
+	h := NewHOTP()
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		got, err := h.GenerateCode(rfc4226Secret, uint64(counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("counter %d: got %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestHOTP_ValidateAndResync(t *testing.T) {
+	h := NewHOTP(WithLookAhead(2), WithResyncWindow(20))
+
+	// 设备领先服务端 2 次，仍在查找窗口内
+	code, _ := h.GenerateCode(rfc4226Secret, 2)
+	next, ok, err := h.ValidateCode(rfc4226Secret, code, 0)
+	if err != nil || !ok || next != 3 {
+		t.Fatalf("ValidateCode: next=%d ok=%v err=%v", next, ok, err)
+	}
+
+	// 已使用的验证码失效
+	if _, ok, _ := h.ValidateCode(rfc4226Secret, code, next); ok {
+		t.Error("replayed code accepted")
+	}
+
+	// 超出查找窗口
+	code1, _ := h.GenerateCode(rfc4226Secret, 10)
+	code2, _ := h.GenerateCode(rfc4226Secret, 11)
+	if _, ok, _ := h.ValidateCode(rfc4226Secret, code1, next); ok {
+		t.Error("code beyond look-ahead accepted")
+	}
+
+	// 连续两个验证码重新同步
+	if _, ok, _ := h.Resync(rfc4226Secret, code2, code1, next); ok {
+		t.Error("resync accepted codes out of order")
+	}
+	next, ok, err = h.Resync(rfc4226Secret, code1, code2, next)
+	if err != nil || !ok || next != 12 {
+		t.Fatalf("Resync: next=%d ok=%v err=%v", next, ok, err)
+	}
+
+	if _, _, err := h.ValidateCode("", code, 0); err != ErrEmptySecret {
+		t.Errorf("expected ErrEmptySecret, got %v", err)
+	}
+}
+
+func TestHOTP_URI(t *testing.T) {
+	h := NewHOTP(WithHOTPAlgorithm(AlgorithmSHA512), WithHOTPDigits(8))
+	u, err := url.Parse(h.URI("alice@example.com", "kit", "SECRET", 42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if !strings.HasPrefix(u.String(), HOTPScheme) || q.Get("counter") != "42" || q.Get("algorithm") != "SHA512" || q.Get("digits") != "8" {
+		t.Errorf("unexpected uri %s", u)
+	}
+	if _, err := h.GenerateQRCode("alice@example.com", "kit", "SECRET", 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGoogleAuthenticator_Algorithms(t *testing.T) {
+	// RFC 6238 附录 B 测试向量，T = 59
+	cases := []struct {
+		alg    Algorithm
+		secret string
+		code   string
+	}{
+		{AlgorithmSHA1, "12345678901234567890", "94287082"},
+		{AlgorithmSHA256, "12345678901234567890123456789012", "46119246"},
+		{AlgorithmSHA512, "1234567890123456789012345678901234567890123456789012345678901234", "90693936"},
+	}
+	for _, c := range cases {
+		ga := NewGoogleAuthenticator(WithAlgorithm(c.alg), WithDigits(8)).(*GoogleAuthenticator)
+		secret := base32.StdEncoding.EncodeToString([]byte(c.secret))
+		code, err := ga.generateCodeAtTime(secret, 59)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != c.code {
+			t.Errorf("%s: got %s, want %s", c.alg, code, c.code)
+		}
+		if q, _ := url.ParseQuery(strings.SplitN(ga.generateQRData("a", "b", secret), "?", 2)[1]); q.Get("algorithm") != string(c.alg) {
+			t.Errorf("%s: uri algorithm %q", c.alg, q.Get("algorithm"))
+		}
+	}
+
+	// 未知算法被忽略
+	if ga := NewGoogleAuthenticator(WithAlgorithm("MD5")).(*GoogleAuthenticator); ga.Algorithm != AlgorithmSHA1 {
+		t.Errorf("unexpected algorithm %s", ga.Algorithm)
+	}
+}
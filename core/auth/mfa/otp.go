@@ -0,0 +1,338 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// OTP 默认配置值
+	DefaultOTPTTL       = 5 * time.Minute // 验证码有效期
+	DefaultSendInterval = time.Minute     // 同一目标两次发送的最小间隔
+	DefaultSendLimit    = 10              // 发送窗口内同一目标最多发送次数
+	DefaultSendWindow   = 24 * time.Hour  // 发送次数统计窗口
+	DefaultMaxAttempts  = 5               // 单个验证码最多校验次数
+	DefaultOTPKeyPrefix = "mfa:otp:"      // Redis key 前缀
+
+	defaultOTPPurpose = "default"
+
+	// Lua 脚本返回值
+	otpSendAllowed    = 1
+	otpVerifyMatched  = 1
+	otpVerifyNotFound = -1
+	otpVerifyTooMany  = -2
+)
+
+// OTP 相关错误
+var (
+	ErrEmptyDestination   = errors.New("destination cannot be empty")
+	ErrNoSender           = errors.New("no sender registered for channel")
+	ErrSendThrottled      = errors.New("otp send throttled")
+	ErrOTPNotFound        = errors.New("otp not found or expired")
+	ErrOTPInvalid         = errors.New("otp invalid")
+	ErrOTPTooManyAttempts = errors.New("otp verification attempts exceeded")
+)
+
+// Channel 验证码投递渠道
+type Channel string
+
+const (
+	ChannelSMS   Channel = "sms"
+	ChannelEmail Channel = "email"
+)
+
+// Message 一次待投递的验证码
+type Message struct {
+	Channel     Channel
+	Destination string        // 手机号或邮箱地址
+	Purpose     string        // 使用场景，如 "login"、"reset_password"
+	Code        string        // 明文验证码
+	TTL         time.Duration // 有效期，可用于渲染"5 分钟内有效"
+}
+
+// Sender 验证码投递提供方，如短信网关或邮件服务。
+// 模板渲染由实现负责：短信服务通常按模板 ID 传入 Code，邮件服务自行拼装正文。
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SenderFunc 将函数适配为 Sender
+type SenderFunc func(ctx context.Context, msg Message) error
+
+// Send 实现 Sender
+func (f SenderFunc) Send(ctx context.Context, msg Message) error { return f(ctx, msg) }
+
+// ThrottledError 发送被限流，RetryAfter 为可再次发送前需要等待的时长。
+// errors.Is(err, ErrSendThrottled) 成立。
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrSendThrottled, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottledError) Unwrap() error { return ErrSendThrottled }
+
+// OTP 基于 Redis 的短信 / 邮件一次性验证码，与 TOTP 不同，验证码由服务端生成并投递：
+//   - 数字验证码，带有效期，Redis 中只保存哈希
+//   - 按渠道注册投递提供方，登录流程可在 TOTP 之外提供备用渠道
+//   - 同一目标的发送间隔与窗口内发送次数限制
+//   - 单个验证码的校验次数限制，超过后验证码作废
+//
+// 验证码、限流计数均以 用途 + 渠道 + 目标 为维度，不同用途的验证码互不通用。
+type OTP struct {
+	client       redis.UniversalClient
+	senders      map[Channel]Sender
+	digits       int
+	ttl          time.Duration
+	sendInterval time.Duration
+	sendLimit    int
+	sendWindow   time.Duration
+	maxAttempts  int
+	keyPrefix    string
+}
+
+// OTPOption 配置 OTP 的选项函数
+type OTPOption func(*OTP)
+
+// WithSender 注册渠道的投递提供方
+func WithSender(channel Channel, sender Sender) OTPOption {
+	return func(o *OTP) {
+		if sender != nil {
+			o.senders[channel] = sender
+		}
+	}
+}
+
+// WithOTPDigits 设置验证码位数
+func WithOTPDigits(digits int) OTPOption {
+	return func(o *OTP) {
+		if digits >= MinDigits && digits <= MaxDigits {
+			o.digits = digits
+		}
+	}
+}
+
+// WithOTPTTL 设置验证码有效期
+func WithOTPTTL(ttl time.Duration) OTPOption {
+	return func(o *OTP) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithSendInterval 设置同一目标两次发送的最小间隔，0 表示不限制
+func WithSendInterval(interval time.Duration) OTPOption {
+	return func(o *OTP) {
+		if interval >= 0 {
+			o.sendInterval = interval
+		}
+	}
+}
+
+// WithSendLimit 设置 window 内同一目标最多发送 limit 次
+func WithSendLimit(limit int, window time.Duration) OTPOption {
+	return func(o *OTP) {
+		if limit > 0 && window > 0 {
+			o.sendLimit = limit
+			o.sendWindow = window
+		}
+	}
+}
+
+// WithMaxAttempts 设置单个验证码最多校验次数
+func WithMaxAttempts(attempts int) OTPOption {
+	return func(o *OTP) {
+		if attempts > 0 {
+			o.maxAttempts = attempts
+		}
+	}
+}
+
+// WithOTPKeyPrefix 设置 Redis key 前缀
+func WithOTPKeyPrefix(prefix string) OTPOption {
+	return func(o *OTP) {
+		if prefix != "" {
+			o.keyPrefix = prefix
+		}
+	}
+}
+
+// NewOTP 创建 OTP
+func NewOTP(client redis.UniversalClient, opts ...OTPOption) *OTP {
+	o := &OTP{
+		client:       client,
+		senders:      make(map[Channel]Sender),
+		digits:       DefaultDigits,
+		ttl:          DefaultOTPTTL,
+		sendInterval: DefaultSendInterval,
+		sendLimit:    DefaultSendLimit,
+		sendWindow:   DefaultSendWindow,
+		maxAttempts:  DefaultMaxAttempts,
+		keyPrefix:    DefaultOTPKeyPrefix,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// otpSendScript 检查发送间隔与窗口次数，通过后保存验证码哈希
+//
+// KEYS[1] 冷却 key  KEYS[2] 计数 key  KEYS[3] 验证码 key
+// ARGV[1] 发送间隔(ms)  ARGV[2] 统计窗口(ms)  ARGV[3] 窗口内上限  ARGV[4] 验证码哈希  ARGV[5] 有效期(ms)
+// 返回 {1, 0} 表示已保存，{0, 需等待毫秒数} 表示被限流
+var otpSendScript = redis.NewScript(`
+local cooldown = redis.call('PTTL', KEYS[1])
+if cooldown > 0 then
+	return {0, cooldown}
+end
+local count = tonumber(redis.call('GET', KEYS[2]) or '0')
+if count >= tonumber(ARGV[3]) then
+	return {0, math.max(redis.call('PTTL', KEYS[2]), 1)}
+end
+if redis.call('INCR', KEYS[2]) == 1 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+end
+if tonumber(ARGV[1]) > 0 then
+	redis.call('SET', KEYS[1], '1', 'PX', ARGV[1])
+end
+redis.call('DEL', KEYS[3])
+redis.call('HSET', KEYS[3], 'h', ARGV[4], 'a', 0)
+redis.call('PEXPIRE', KEYS[3], ARGV[5])
+return {1, 0}
+`)
+
+// otpVerifyScript 校验验证码哈希并累计校验次数
+//
+// KEYS[1] 验证码 key  ARGV[1] 验证码哈希  ARGV[2] 最多校验次数
+// 返回 1 匹配，0 不匹配，-1 不存在或已过期，-2 超过校验次数（验证码已作废）
+var otpVerifyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'a', 1)
+if redis.call('HGET', KEYS[1], 'h') == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+if attempts >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1])
+	return -2
+end
+return 0
+`)
+
+// Send 生成验证码并通过 channel 投递到 destination。
+//
+// 被限流时返回 *ThrottledError；投递失败时撤销本次验证码与发送间隔，
+// 调用方可立即重试（窗口内发送次数仍会计入，防止借失败刷接口）。
+// 新验证码会使同一目标、同一用途下之前的验证码失效。
+func (o *OTP) Send(ctx context.Context, channel Channel, destination, purpose string) error {
+	if destination == "" {
+		return ErrEmptyDestination
+	}
+	sender, ok := o.senders[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoSender, channel)
+	}
+	purpose = normalizePurpose(purpose)
+
+	code, err := generateNumericCode(o.digits)
+	if err != nil {
+		return err
+	}
+
+	base := o.baseKey(channel, destination, purpose)
+	res, err := otpSendScript.Run(ctx, o.client,
+		[]string{base + ":cooldown", base + ":count", base + ":code"},
+		o.sendInterval.Milliseconds(), o.sendWindow.Milliseconds(), o.sendLimit,
+		hashCode(base, code), o.ttl.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("otp: send script: %w", err)
+	}
+	if res[0] != otpSendAllowed {
+		return &ThrottledError{RetryAfter: time.Duration(res[1]) * time.Millisecond}
+	}
+
+	msg := Message{Channel: channel, Destination: destination, Purpose: purpose, Code: code, TTL: o.ttl}
+	if err := sender.Send(ctx, msg); err != nil {
+		// 使用独立 context，避免调用方 ctx 已取消导致撤销失败
+		o.client.Del(context.WithoutCancel(ctx), base+":code", base+":cooldown")
+		return fmt.Errorf("otp: deliver via %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Verify 校验验证码，成功后验证码立即失效（一次性）。
+//
+// 返回 ErrOTPInvalid 表示不匹配；ErrOTPTooManyAttempts 表示校验次数已用尽、验证码已作废，
+// 需要重新发送；ErrOTPNotFound 表示未发送或已过期。
+func (o *OTP) Verify(ctx context.Context, channel Channel, destination, purpose, code string) error {
+	if destination == "" {
+		return ErrEmptyDestination
+	}
+	if code == "" {
+		return ErrEmptyCode
+	}
+	purpose = normalizePurpose(purpose)
+
+	base := o.baseKey(channel, destination, purpose)
+	res, err := otpVerifyScript.Run(ctx, o.client,
+		[]string{base + ":code"}, hashCode(base, code), o.maxAttempts,
+	).Int64()
+	if err != nil {
+		return fmt.Errorf("otp: verify script: %w", err)
+	}
+
+	switch res {
+	case otpVerifyMatched:
+		return nil
+	case otpVerifyNotFound:
+		return ErrOTPNotFound
+	case otpVerifyTooMany:
+		return ErrOTPTooManyAttempts
+	default:
+		return ErrOTPInvalid
+	}
+}
+
+func (o *OTP) baseKey(channel Channel, destination, purpose string) string {
+	return o.keyPrefix + purpose + ":" + string(channel) + ":" + destination
+}
+
+func normalizePurpose(purpose string) string {
+	if purpose == "" {
+		return defaultOTPPurpose
+	}
+	return purpose
+}
+
+// hashCode 计算验证码哈希，混入 key 使相同验证码在不同目标下哈希不同
+func hashCode(base, code string) string {
+	sum := sha256.Sum256([]byte(base + "\x00" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateNumericCode 使用 crypto/rand 生成均匀分布、带前导零的数字验证码
+func generateNumericCode(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRandomGeneration, err)
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
@@ -0,0 +1,176 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	redisPassword := os.Getenv("REDIS_PASSWORD")
+	if redisPassword == "" {
+		redisPassword = "12345678"
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: redisAddr, Password: redisPassword})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		t.Skipf("redis not available at %s: %v", redisAddr, err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestGenerateNumericCode(t *testing.T) {
+	for _, digits := range []int{MinDigits, DefaultDigits, MaxDigits} {
+		for i := 0; i < 100; i++ {
+			code, err := generateNumericCode(digits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(code) != digits {
+				t.Fatalf("expected %d digits, got %q", digits, code)
+			}
+			for _, c := range code {
+				if c < '0' || c > '9' {
+					t.Fatalf("non-numeric code %q", code)
+				}
+			}
+		}
+	}
+}
+
+func TestOTP_SendAndVerify(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	var last Message
+	sms := SenderFunc(func(_ context.Context, msg Message) error {
+		last = msg
+		return nil
+	})
+	otp := NewOTP(rdb,
+		WithSender(ChannelSMS, sms),
+		WithOTPKeyPrefix("mfa-test:"+uuid.NewString()[:8]+":"),
+		WithMaxAttempts(3),
+	)
+	phone := "+8613800000000"
+
+	if err := otp.Send(ctx, ChannelEmail, "a@example.com", "login"); !errors.Is(err, ErrNoSender) {
+		t.Fatalf("expected ErrNoSender, got %v", err)
+	}
+	if err := otp.Send(ctx, ChannelSMS, phone, "login"); err != nil {
+		t.Fatal(err)
+	}
+	if last.Destination != phone || last.TTL != DefaultOTPTTL || len(last.Code) != DefaultDigits {
+		t.Fatalf("unexpected message %+v", last)
+	}
+
+	// 不同用途的验证码互不通用
+	if err := otp.Verify(ctx, ChannelSMS, phone, "reset_password", last.Code); !errors.Is(err, ErrOTPNotFound) {
+		t.Fatalf("expected ErrOTPNotFound, got %v", err)
+	}
+	if err := otp.Verify(ctx, ChannelSMS, phone, "login", last.Code); err != nil {
+		t.Fatal(err)
+	}
+	// 一次性
+	if err := otp.Verify(ctx, ChannelSMS, phone, "login", last.Code); !errors.Is(err, ErrOTPNotFound) {
+		t.Fatalf("expected ErrOTPNotFound after use, got %v", err)
+	}
+
+	// 发送间隔
+	err := otp.Send(ctx, ChannelSMS, phone, "login")
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrSendThrottled) {
+		t.Fatalf("expected ThrottledError, got %v", err)
+	}
+	if throttled.RetryAfter <= 0 || throttled.RetryAfter > DefaultSendInterval {
+		t.Fatalf("unexpected retry after %s", throttled.RetryAfter)
+	}
+}
+
+func TestOTP_MaxAttempts(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	var code string
+	otp := NewOTP(rdb,
+		WithSender(ChannelEmail, SenderFunc(func(_ context.Context, msg Message) error {
+			code = msg.Code
+			return nil
+		})),
+		WithOTPKeyPrefix("mfa-test:"+uuid.NewString()[:8]+":"),
+		WithMaxAttempts(3),
+		WithSendInterval(0),
+	)
+	email := "user@example.com"
+
+	if err := otp.Send(ctx, ChannelEmail, email, ""); err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < 2; i++ {
+		if err := otp.Verify(ctx, ChannelEmail, email, "", wrong); !errors.Is(err, ErrOTPInvalid) {
+			t.Fatalf("attempt %d: expected ErrOTPInvalid, got %v", i, err)
+		}
+	}
+	if err := otp.Verify(ctx, ChannelEmail, email, "", wrong); !errors.Is(err, ErrOTPTooManyAttempts) {
+		t.Fatalf("expected ErrOTPTooManyAttempts, got %v", err)
+	}
+	// 超过次数后正确的验证码也已作废
+	if err := otp.Verify(ctx, ChannelEmail, email, "", code); !errors.Is(err, ErrOTPNotFound) {
+		t.Fatalf("expected ErrOTPNotFound, got %v", err)
+	}
+}
+
+func TestOTP_SendLimitAndDeliveryFailure(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	fail := true
+	otp := NewOTP(rdb,
+		WithSender(ChannelSMS, SenderFunc(func(context.Context, Message) error {
+			if fail {
+				return errors.New("gateway unavailable")
+			}
+			return nil
+		})),
+		WithOTPKeyPrefix("mfa-test:"+uuid.NewString()[:8]+":"),
+		WithSendLimit(2, time.Hour),
+	)
+	phone := "+8613900000000"
+
+	// 投递失败会撤销发送间隔，可立即重试
+	if err := otp.Send(ctx, ChannelSMS, phone, "login"); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	fail = false
+	if err := otp.Send(ctx, ChannelSMS, phone, "login"); err != nil {
+		t.Fatalf("retry after delivery failure: %v", err)
+	}
+
+	// 失败的发送同样计入窗口次数
+	rdb.Del(ctx, otp.baseKey(ChannelSMS, phone, "login")+":cooldown")
+	err := otp.Send(ctx, ChannelSMS, phone, "login")
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected ThrottledError, got %v", err)
+	}
+	if throttled.RetryAfter <= DefaultSendInterval {
+		t.Fatalf("expected window retry after, got %s", throttled.RetryAfter)
+	}
+}
@@ -0,0 +1,300 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ReplayGuard 默认配置值
+	DefaultMaxFailures    = 5                // 失败窗口内最多失败次数，超过后锁定
+	DefaultFailureWindow  = 15 * time.Minute // 失败次数统计窗口
+	DefaultLockout        = 15 * time.Minute // 锁定时长
+	DefaultGuardKeyPrefix = "mfa:guard:"     // Redis key 前缀
+
+	memoryGuardSweepEvery = 1024 // 内存实现每处理多少次请求清理一次过期记录
+)
+
+// 校验防护相关错误
+var (
+	ErrCodeReplayed = errors.New("code already used")
+	ErrCodeLocked   = errors.New("code validation locked")
+)
+
+// LockedError 失败次数过多被锁定，RetryAfter 为解除锁定前需要等待的时长。
+// errors.Is(err, ErrCodeLocked) 成立。
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrCodeLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Unwrap() error { return ErrCodeLocked }
+
+// ReplayGuard 验证码校验防护，提供两项能力：
+//   - 重放保护：记录每个 key 最近一次接受的时间片，同一时间片及更早的验证码不再接受
+//   - 暴力破解限流：窗口内失败次数达到上限后锁定一段时间
+//
+// key 由调用方按用户（或密钥）区分，实现须保证并发安全，多实例部署应使用 RedisReplayGuard
+type ReplayGuard interface {
+	// Locked 返回 key 剩余的锁定时长，未锁定时返回 0
+	Locked(ctx context.Context, key string) (time.Duration, error)
+	// Fail 记录一次校验失败，达到上限时锁定 key
+	Fail(ctx context.Context, key string) error
+	// Reset 校验成功后清除失败计数
+	Reset(ctx context.Context, key string) error
+	// Use 将 key 已接受的时间片推进到 slot，slot 不大于已记录值时返回 false（重放）。
+	// 记录在 ttl 后过期，ttl 应覆盖验证码的全部有效时间
+	Use(ctx context.Context, key string, slot uint64, ttl time.Duration) (bool, error)
+}
+
+// guardConfig ReplayGuard 的公共配置
+type guardConfig struct {
+	maxFailures   int
+	failureWindow time.Duration
+	lockout       time.Duration
+	keyPrefix     string
+}
+
+// GuardOption 配置 ReplayGuard 的选项函数
+type GuardOption func(*guardConfig)
+
+// WithMaxFailures 设置 window 内最多失败 n 次，超过后锁定
+func WithMaxFailures(n int, window time.Duration) GuardOption {
+	return func(c *guardConfig) {
+		if n > 0 && window > 0 {
+			c.maxFailures = n
+			c.failureWindow = window
+		}
+	}
+}
+
+// WithLockout 设置锁定时长
+func WithLockout(lockout time.Duration) GuardOption {
+	return func(c *guardConfig) {
+		if lockout > 0 {
+			c.lockout = lockout
+		}
+	}
+}
+
+// WithGuardKeyPrefix 设置 Redis key 前缀，仅对 RedisReplayGuard 生效
+func WithGuardKeyPrefix(prefix string) GuardOption {
+	return func(c *guardConfig) {
+		if prefix != "" {
+			c.keyPrefix = prefix
+		}
+	}
+}
+
+func newGuardConfig(opts []GuardOption) guardConfig {
+	c := guardConfig{
+		maxFailures:   DefaultMaxFailures,
+		failureWindow: DefaultFailureWindow,
+		lockout:       DefaultLockout,
+		keyPrefix:     DefaultGuardKeyPrefix,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// MemoryReplayGuard 基于内存的 ReplayGuard，适用于单实例部署与测试
+type MemoryReplayGuard struct {
+	cfg guardConfig
+
+	mu       sync.Mutex
+	slots    map[string]memorySlot
+	failures map[string]*memoryFailures
+	ops      int
+}
+
+type memorySlot struct {
+	slot    uint64
+	expires time.Time
+}
+
+type memoryFailures struct {
+	count       int
+	windowEnds  time.Time
+	lockedUntil time.Time
+}
+
+var _ ReplayGuard = (*MemoryReplayGuard)(nil)
+
+// NewMemoryReplayGuard 创建基于内存的 ReplayGuard
+func NewMemoryReplayGuard(opts ...GuardOption) *MemoryReplayGuard {
+	return &MemoryReplayGuard{
+		cfg:      newGuardConfig(opts),
+		slots:    make(map[string]memorySlot),
+		failures: make(map[string]*memoryFailures),
+	}
+}
+
+// Locked 实现 ReplayGuard
+func (g *MemoryReplayGuard) Locked(_ context.Context, key string) (time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sweepLocked()
+	if f, ok := g.failures[key]; ok {
+		if remaining := time.Until(f.lockedUntil); remaining > 0 {
+			return remaining, nil
+		}
+	}
+	return 0, nil
+}
+
+// Fail 实现 ReplayGuard
+func (g *MemoryReplayGuard) Fail(_ context.Context, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	f, ok := g.failures[key]
+	if !ok {
+		f = &memoryFailures{}
+		g.failures[key] = f
+	}
+	if now.After(f.windowEnds) {
+		f.count = 0
+		f.windowEnds = now.Add(g.cfg.failureWindow)
+	}
+	f.count++
+	if f.count >= g.cfg.maxFailures {
+		f.lockedUntil = now.Add(g.cfg.lockout)
+		f.count = 0
+	}
+	return nil
+}
+
+// Reset 实现 ReplayGuard
+func (g *MemoryReplayGuard) Reset(_ context.Context, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.failures, key)
+	return nil
+}
+
+// Use 实现 ReplayGuard
+func (g *MemoryReplayGuard) Use(_ context.Context, key string, slot uint64, ttl time.Duration) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sweepLocked()
+	now := time.Now()
+	if last, ok := g.slots[key]; ok && now.Before(last.expires) && slot <= last.slot {
+		return false, nil
+	}
+	g.slots[key] = memorySlot{slot: slot, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// sweepLocked 定期清理过期记录，避免长期运行时内存增长
+func (g *MemoryReplayGuard) sweepLocked() {
+	g.ops++
+	if g.ops < memoryGuardSweepEvery {
+		return
+	}
+	g.ops = 0
+	now := time.Now()
+	for key, s := range g.slots {
+		if now.After(s.expires) {
+			delete(g.slots, key)
+		}
+	}
+	for key, f := range g.failures {
+		if now.After(f.windowEnds) && now.After(f.lockedUntil) {
+			delete(g.failures, key)
+		}
+	}
+}
+
+// RedisReplayGuard 基于 Redis 的 ReplayGuard，多实例共享重放记录与失败计数
+type RedisReplayGuard struct {
+	client redis.UniversalClient
+	cfg    guardConfig
+}
+
+var _ ReplayGuard = (*RedisReplayGuard)(nil)
+
+// NewRedisReplayGuard 创建基于 Redis 的 ReplayGuard
+func NewRedisReplayGuard(client redis.UniversalClient, opts ...GuardOption) *RedisReplayGuard {
+	return &RedisReplayGuard{client: client, cfg: newGuardConfig(opts)}
+}
+
+// guardFailScript 累计失败次数，达到上限时设置锁定并清零计数
+//
+// KEYS[1] 计数 key  KEYS[2] 锁定 key
+// ARGV[1] 统计窗口(ms)  ARGV[2] 上限  ARGV[3] 锁定时长(ms)
+var guardFailScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+if count >= tonumber(ARGV[2]) then
+	redis.call('SET', KEYS[2], '1', 'PX', ARGV[3])
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+return 0
+`)
+
+// guardUseScript 时间片严格递增时推进记录
+//
+// KEYS[1] 时间片 key  ARGV[1] 时间片  ARGV[2] 有效期(ms)
+// 返回 1 已接受，0 重放
+var guardUseScript = redis.NewScript(`
+local last = redis.call('GET', KEYS[1])
+if last and tonumber(last) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// Locked 实现 ReplayGuard
+func (g *RedisReplayGuard) Locked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := g.client.PTTL(ctx, g.cfg.keyPrefix+key+":locked").Result()
+	if err != nil {
+		return 0, fmt.Errorf("mfa: guard locked: %w", err)
+	}
+	return max(ttl, 0), nil
+}
+
+// Fail 实现 ReplayGuard
+func (g *RedisReplayGuard) Fail(ctx context.Context, key string) error {
+	base := g.cfg.keyPrefix + key
+	err := guardFailScript.Run(ctx, g.client,
+		[]string{base + ":failures", base + ":locked"},
+		g.cfg.failureWindow.Milliseconds(), g.cfg.maxFailures, g.cfg.lockout.Milliseconds(),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("mfa: guard fail script: %w", err)
+	}
+	return nil
+}
+
+// Reset 实现 ReplayGuard
+func (g *RedisReplayGuard) Reset(ctx context.Context, key string) error {
+	if err := g.client.Del(ctx, g.cfg.keyPrefix+key+":failures").Err(); err != nil {
+		return fmt.Errorf("mfa: guard reset: %w", err)
+	}
+	return nil
+}
+
+// Use 实现 ReplayGuard
+func (g *RedisReplayGuard) Use(ctx context.Context, key string, slot uint64, ttl time.Duration) (bool, error) {
+	res, err := guardUseScript.Run(ctx, g.client,
+		[]string{g.cfg.keyPrefix + key + ":slot"}, slot, ttl.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return false, fmt.Errorf("mfa: guard use script: %w", err)
+	}
+	return res == 1, nil
+}
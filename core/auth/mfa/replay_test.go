@@ -0,0 +1,118 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// wrongCode 返回与 code 位数相同但不相等的验证码
+func wrongCode(code string) string {
+	b := []byte(code)
+	b[0] = '0' + (b[0]-'0'+1)%10
+	return string(b)
+}
+
+func TestGoogleAuthenticator_ReplayGuard(t *testing.T) {
+	ga := NewGoogleAuthenticator(WithReplayGuard(NewMemoryReplayGuard(WithMaxFailures(3, time.Minute))))
+	secret := ga.GenerateSecret()
+	code, err := ga.GenerateCode(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := ga.ValidateCode(secret, code); !ok || err != nil {
+		t.Fatalf("first use = (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := ga.ValidateCode(secret, code); ok || !errors.Is(err, ErrCodeReplayed) {
+		t.Fatalf("replay = (%v, %v), want ErrCodeReplayed", ok, err)
+	}
+
+	// 重放已计 1 次失败，再失败 2 次达到上限
+	for range 2 {
+		if ok, err := ga.ValidateCode(secret, wrongCode(code)); ok || err != nil {
+			t.Fatalf("wrong code = (%v, %v), want (false, nil)", ok, err)
+		}
+	}
+	_, err = ga.ValidateCode(secret, code)
+	var locked *LockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrCodeLocked) || locked.RetryAfter <= 0 {
+		t.Fatalf("expected LockedError, got %v", err)
+	}
+
+	// 其他密钥不受影响
+	other := ga.GenerateSecret()
+	otherCode, _ := ga.GenerateCode(other)
+	if ok, err := ga.ValidateCode(other, otherCode); !ok || err != nil {
+		t.Fatalf("other secret = (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
+func TestGoogleAuthenticator_WithoutGuardAcceptsReuse(t *testing.T) {
+	ga := NewGoogleAuthenticator()
+	secret := ga.GenerateSecret()
+	code, _ := ga.GenerateCode(secret)
+	for range 2 {
+		if ok, err := ga.ValidateCode(secret, code); !ok || err != nil {
+			t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+		}
+	}
+}
+
+// testReplayGuard 对 ReplayGuard 实现的公共行为测试
+func testReplayGuard(t *testing.T, guard ReplayGuard) {
+	ctx := context.Background()
+	key := "user:" + uuid.NewString()
+
+	if ok, err := guard.Use(ctx, key, 100, time.Minute); !ok || err != nil {
+		t.Fatalf("Use(100) = (%v, %v), want (true, nil)", ok, err)
+	}
+	for _, slot := range []uint64{100, 99} {
+		if ok, err := guard.Use(ctx, key, slot, time.Minute); ok || err != nil {
+			t.Fatalf("Use(%d) = (%v, %v), want (false, nil)", slot, ok, err)
+		}
+	}
+	if ok, err := guard.Use(ctx, key, 101, time.Minute); !ok || err != nil {
+		t.Fatalf("Use(101) = (%v, %v), want (true, nil)", ok, err)
+	}
+
+	if err := guard.Fail(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.Reset(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	// Reset 后重新计数，需再失败 2 次才锁定
+	if err := guard.Fail(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, err := guard.Locked(ctx, key); remaining != 0 || err != nil {
+		t.Fatalf("Locked = (%v, %v), want (0, nil)", remaining, err)
+	}
+	if err := guard.Fail(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	remaining, err := guard.Locked(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining <= 0 || remaining > time.Minute {
+		t.Fatalf("Locked = %v, want (0, 1m]", remaining)
+	}
+}
+
+func TestMemoryReplayGuard(t *testing.T) {
+	testReplayGuard(t, NewMemoryReplayGuard(WithMaxFailures(2, time.Minute), WithLockout(time.Minute)))
+}
+
+func TestRedisReplayGuard(t *testing.T) {
+	rdb := newTestRedis(t)
+	testReplayGuard(t, NewRedisReplayGuard(rdb,
+		WithMaxFailures(2, time.Minute),
+		WithLockout(time.Minute),
+		WithGuardKeyPrefix("test:mfa:guard:"),
+	))
+}
@@ -0,0 +1,78 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// 认证器数据标志位
+const (
+	flagUserPresent    byte = 0x01 // UP
+	flagUserVerified   byte = 0x04 // UV
+	flagBackupEligible byte = 0x08 // BE
+	flagBackupState    byte = 0x10 // BS
+	flagAttestedData   byte = 0x40 // AT
+	flagExtensionData  byte = 0x80 // ED
+)
+
+// authenticatorData 认证器数据（WebAuthn 6.1 节）
+type authenticatorData struct {
+	raw       []byte
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+
+	// 仅注册时存在（AT 标志）
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte // COSE_Key 编码
+}
+
+func (a *authenticatorData) has(flag byte) bool { return a.flags&flag != 0 }
+
+// parseAuthenticatorData 解析认证器数据
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrInvalidResponse)
+	}
+	a := &authenticatorData{
+		raw:       data,
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if a.has(flagAttestedData) {
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data too short", ErrInvalidResponse)
+		}
+		a.aaguid = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+			return nil, fmt.Errorf("%w: bad credential id length", ErrInvalidResponse)
+		}
+		a.credentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: credential public key: %v", ErrInvalidResponse, err)
+		}
+		a.publicKey = rest[:n]
+		rest = rest[n:]
+	}
+
+	if a.has(flagExtensionData) {
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: extensions: %v", ErrInvalidResponse, err)
+		}
+		rest = rest[n:]
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing authenticator data", ErrInvalidResponse)
+	}
+	return a, nil
+}
@@ -0,0 +1,163 @@
+package webauthn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errCBOR CBOR 数据格式错误
+var errCBOR = errors.New("malformed cbor")
+
+// maxCBORDepth 嵌套层数上限，防止恶意数据耗尽栈
+const maxCBORDepth = 16
+
+// decodeCBOR 解码 CTAP2 规范编码的 CBOR 数据项（RFC 8949 子集），返回值与消耗的字节数。
+//
+// 整数解码为 int64，字节串为 []byte，文本为 string，数组为 []any，
+// 映射为 map[any]any（键为 int64 或 string），标签被忽略。不支持不定长编码与浮点数
+func decodeCBOR(data []byte) (any, int, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.decode(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, d.off, nil
+}
+
+// cborDecoder CBOR 解码器
+type cborDecoder struct {
+	data []byte
+	off  int
+}
+
+// read 读取 n 个字节
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, fmt.Errorf("%w: unexpected end of data", errCBOR)
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// head 读取数据项头部，返回主类型、附加信息与参数值
+func (d *cborDecoder) head() (byte, byte, uint64, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		arg, err := d.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		var v uint64
+		for _, c := range arg {
+			v = v<<8 | uint64(c)
+		}
+		return major, info, v, nil
+	}
+	return 0, 0, 0, fmt.Errorf("%w: unsupported additional info %d", errCBOR, info)
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > maxCBORDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", errCBOR)
+	}
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0: // 无符号整数
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return int64(arg), nil
+	case 1: // 负整数
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return -1 - int64(arg), nil
+	case 2: // 字节串
+		b, err := d.read(arg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 3: // 文本
+		b, err := d.read(arg)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 4: // 数组
+		if arg > uint64(len(d.data)) {
+			return nil, fmt.Errorf("%w: array too long", errCBOR)
+		}
+		arr := make([]any, 0, arg)
+		for range arg {
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case 5: // 映射
+		if arg > uint64(len(d.data)) {
+			return nil, fmt.Errorf("%w: map too long", errCBOR)
+		}
+		m := make(map[any]any, arg)
+		for range arg {
+			k, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("%w: unsupported map key %T", errCBOR, k)
+			}
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	case 6: // 标签，忽略标签号
+		return d.decode(depth + 1)
+	default: // 简单值，CTAP2 不使用浮点数
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: unsupported simple value %d", errCBOR, info)
+	}
+}
+
+// cborMap 从 CBOR 映射中按键取值的辅助类型，整数键须为 int64
+type cborMap map[any]any
+
+// bytes 取字节串
+func (m cborMap) bytes(key any) ([]byte, bool) {
+	b, ok := m[key].([]byte)
+	return b, ok
+}
+
+// int 取整数
+func (m cborMap) int(key any) (int64, bool) {
+	i, ok := m[key].(int64)
+	return i, ok
+}
@@ -0,0 +1,164 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+)
+
+// Algorithm COSE 签名算法标识（IANA COSE Algorithms 注册表）
+type Algorithm int64
+
+const (
+	AlgES256 Algorithm = -7
+	AlgES384 Algorithm = -35
+	AlgES512 Algorithm = -36
+	AlgEdDSA Algorithm = -8
+	AlgRS256 Algorithm = -257
+)
+
+// DefaultAlgorithms 注册时默认接受的算法，按偏好排序
+var DefaultAlgorithms = []Algorithm{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE 密钥参数标签（RFC 9053）
+const (
+	coseKty int64 = 1
+	coseAlg int64 = 3
+	coseCrv int64 = -1 // EC2/OKP 曲线；RSA 为模数 n
+	coseX   int64 = -2 // EC2/OKP x 坐标；RSA 为指数 e
+	coseY   int64 = -3
+
+	ktyOKP int64 = 1
+	ktyEC2 int64 = 2
+	ktyRSA int64 = 3
+
+	crvP256    int64 = 1
+	crvP384    int64 = 2
+	crvP521    int64 = 3
+	crvEd25519 int64 = 6
+)
+
+// parseCOSEKey 解析 COSE_Key 编码的凭证公钥，返回公钥与算法
+func parseCOSEKey(data []byte) (crypto.PublicKey, Algorithm, error) {
+	v, n, err := decodeCBOR(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	if n != len(data) {
+		return nil, 0, fmt.Errorf("%w: trailing data", ErrInvalidPublicKey)
+	}
+	raw, ok := v.(map[any]any)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: not a map", ErrInvalidPublicKey)
+	}
+	m := cborMap(raw)
+	kty, _ := m.int(coseKty)
+	alg, ok := m.int(coseAlg)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: missing alg", ErrInvalidPublicKey)
+	}
+
+	switch kty {
+	case ktyEC2:
+		crv, _ := m.int(coseCrv)
+		x, _ := m.bytes(coseX)
+		y, _ := m.bytes(coseY)
+		var curve elliptic.Curve
+		switch {
+		case crv == crvP256 && Algorithm(alg) == AlgES256:
+			curve = elliptic.P256()
+		case crv == crvP384 && Algorithm(alg) == AlgES384:
+			curve = elliptic.P384()
+		case crv == crvP521 && Algorithm(alg) == AlgES512:
+			curve = elliptic.P521()
+		default:
+			return nil, 0, fmt.Errorf("%w: ec2 curve %d with alg %d", ErrUnsupportedAlgorithm, crv, alg)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, 0, fmt.Errorf("%w: bad ec2 coordinates", ErrInvalidPublicKey)
+		}
+		// ParseUncompressedPublicKey 校验点在曲线上
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+		}
+		return pub, Algorithm(alg), nil
+
+	case ktyOKP:
+		crv, _ := m.int(coseCrv)
+		x, _ := m.bytes(coseX)
+		if crv != crvEd25519 || Algorithm(alg) != AlgEdDSA {
+			return nil, 0, fmt.Errorf("%w: okp curve %d with alg %d", ErrUnsupportedAlgorithm, crv, alg)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, fmt.Errorf("%w: bad ed25519 key", ErrInvalidPublicKey)
+		}
+		return ed25519.PublicKey(x), AlgEdDSA, nil
+
+	case ktyRSA:
+		if Algorithm(alg) != AlgRS256 {
+			return nil, 0, fmt.Errorf("%w: rsa with alg %d", ErrUnsupportedAlgorithm, alg)
+		}
+		nBytes, _ := m.bytes(coseCrv)
+		eBytes, _ := m.bytes(coseX)
+		if len(nBytes) < 256 || len(eBytes) == 0 || len(eBytes) > 4 {
+			return nil, 0, fmt.Errorf("%w: bad rsa key", ErrInvalidPublicKey)
+		}
+		e := new(big.Int).SetBytes(eBytes)
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(nBytes), E: int(e.Int64())}, AlgRS256, nil
+	}
+	return nil, 0, fmt.Errorf("%w: key type %d", ErrUnsupportedAlgorithm, kty)
+}
+
+// verifySignature 按算法验证签名。ECDSA 签名为 ASN.1 DER 编码
+func verifySignature(alg Algorithm, pub crypto.PublicKey, message, sig []byte) error {
+	switch alg {
+	case AlgES256, AlgES384, AlgES512:
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrInvalidSignature
+		}
+		var digest []byte
+		switch alg {
+		case AlgES256:
+			h := sha256.Sum256(message)
+			digest = h[:]
+		case AlgES384:
+			h := sha512.Sum384(message)
+			digest = h[:]
+		default:
+			h := sha512.Sum512(message)
+			digest = h[:]
+		}
+		if !ecdsa.VerifyASN1(key, digest, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case AlgEdDSA:
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(key, message, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case AlgRS256:
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidSignature
+		}
+		h := sha256.Sum256(message)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, h[:], sig) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, alg)
+}
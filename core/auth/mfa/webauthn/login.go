@@ -0,0 +1,146 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// CredentialRequestOptions navigator.credentials.get 的 publicKey 选项，
+// 前端可用 PublicKeyCredential.parseRequestOptionsFromJSON 解析
+type CredentialRequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	Timeout          int64                  `json:"timeout"` // 毫秒
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification UserVerification       `json:"userVerification"`
+}
+
+// AssertionResponse 认证器的断言响应
+type AssertionResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AuthenticatorData Base64URL `json:"authenticatorData"`
+	Signature         Base64URL `json:"signature"`
+	UserHandle        Base64URL `json:"userHandle,omitempty"`
+}
+
+// LoginResponse 浏览器 navigator.credentials.get 返回的凭证（PublicKeyCredential.toJSON()）
+type LoginResponse struct {
+	ID       string            `json:"id"`
+	RawID    Base64URL         `json:"rawId"`
+	Type     string            `json:"type"`
+	Response AssertionResponse `json:"response"`
+}
+
+// BeginLogin 开始已知用户的登录流程（如输入用户名后或作为第二因素），
+// 只允许该用户已注册的凭证。用户没有凭证时返回 ErrCredentialNotFound
+func (w *WebAuthn) BeginLogin(ctx context.Context, userID []byte) (*CredentialRequestOptions, *Session, error) {
+	creds, err := w.store.ListCredentials(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list credentials: %w", err)
+	}
+	if len(creds) == 0 {
+		return nil, nil, ErrCredentialNotFound
+	}
+	session, err := w.newSession(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range creds {
+		session.AllowCredentials = append(session.AllowCredentials, c.ID)
+	}
+	return w.requestOptions(session, descriptors(creds)), session, nil
+}
+
+// BeginDiscoverableLogin 开始通行密钥登录流程，无需事先知道用户，
+// 由认证器列出本站点的可发现凭证，FinishLogin 按返回的 userHandle 确定用户
+func (w *WebAuthn) BeginDiscoverableLogin(ctx context.Context) (*CredentialRequestOptions, *Session, error) {
+	session, err := w.newSession(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w.requestOptions(session, nil), session, nil
+}
+
+// requestOptions 构建登录选项
+func (w *WebAuthn) requestOptions(session *Session, allow []CredentialDescriptor) *CredentialRequestOptions {
+	return &CredentialRequestOptions{
+		Challenge:        session.Challenge,
+		Timeout:          w.timeout.Milliseconds(),
+		RPID:             w.rpID,
+		AllowCredentials: allow,
+		UserVerification: session.UserVerification,
+	}
+}
+
+// FinishLogin 校验登录断言（WebAuthn 7.2 节），更新凭证的签名计数并返回凭证，
+// Credential.UserID 即登录的用户。
+//
+// 签名计数未递增时视为认证器被克隆，返回 ErrCloneDetected；始终为 0 的认证器
+// （如多数同步通行密钥）不做此检查。调用方应在调用后删除 Session，避免挑战被重放
+func (w *WebAuthn) FinishLogin(ctx context.Context, session *Session, resp *LoginResponse) (*Credential, error) {
+	if err := checkSession(session); err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Type != "public-key" {
+		return nil, fmt.Errorf("%w: credential type", ErrInvalidResponse)
+	}
+	if len(session.AllowCredentials) > 0 && !slices.ContainsFunc(session.AllowCredentials, func(id Base64URL) bool {
+		return bytes.Equal(id, resp.RawID)
+	}) {
+		return nil, ErrCredentialNotAllowed
+	}
+
+	cred, err := w.store.GetCredential(ctx, resp.RawID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已知用户时凭证须属于该用户；可发现凭证登录时须由 userHandle 确认归属
+	userHandle := resp.Response.UserHandle
+	if len(session.UserID) > 0 && !bytes.Equal(cred.UserID, session.UserID) {
+		return nil, ErrCredentialNotAllowed
+	}
+	if len(session.UserID) == 0 && len(userHandle) == 0 {
+		return nil, fmt.Errorf("%w: missing user handle", ErrInvalidResponse)
+	}
+	if len(userHandle) > 0 && !bytes.Equal(cred.UserID, userHandle) {
+		return nil, ErrCredentialNotAllowed
+	}
+
+	clientDataHash, err := w.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", session)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.verifyAuthenticatorData(ad, session); err != nil {
+		return nil, err
+	}
+
+	pub, _, err := parseCOSEKey(cred.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	signed := append(slices.Clone(ad.raw), clientDataHash...)
+	if err := verifySignature(cred.Algorithm, pub, signed, resp.Response.Signature); err != nil {
+		return nil, err
+	}
+
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return nil, ErrCloneDetected
+	}
+
+	cred.SignCount = ad.signCount
+	cred.UserVerified = ad.has(flagUserVerified)
+	cred.BackupState = ad.has(flagBackupState)
+	cred.LastUsedAt = time.Now()
+	if err := w.store.UpdateCredential(ctx, cred); err != nil {
+		return nil, fmt.Errorf("update credential: %w", err)
+	}
+	return cred, nil
+}
@@ -0,0 +1,245 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RelyingPartyEntity 依赖方信息
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity 用户信息
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+// CredentialParameter 可接受的凭证类型与算法
+type CredentialParameter struct {
+	Type string    `json:"type"`
+	Alg  Algorithm `json:"alg"`
+}
+
+// AuthenticatorSelection 认证器选择条件
+type AuthenticatorSelection struct {
+	ResidentKey        ResidentKey      `json:"residentKey"`
+	RequireResidentKey bool             `json:"requireResidentKey"`
+	UserVerification   UserVerification `json:"userVerification"`
+}
+
+// CredentialCreationOptions navigator.credentials.create 的 publicKey 选项，
+// 前端可用 PublicKeyCredential.parseCreationOptionsFromJSON 解析
+type CredentialCreationOptions struct {
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              Base64URL              `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"` // 毫秒
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// AttestationResponse 认证器的注册响应
+type AttestationResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AttestationObject Base64URL `json:"attestationObject"`
+	Transports        []string  `json:"transports,omitempty"`
+}
+
+// RegistrationResponse 浏览器 navigator.credentials.create 返回的凭证（PublicKeyCredential.toJSON()）
+type RegistrationResponse struct {
+	ID       string              `json:"id"`
+	RawID    Base64URL           `json:"rawId"`
+	Type     string              `json:"type"`
+	Response AttestationResponse `json:"response"`
+}
+
+// BeginRegistration 开始注册流程，返回交给浏览器的选项与须由调用方保存的 Session。
+// 用户已注册的凭证放入 excludeCredentials，避免同一认证器重复注册
+func (w *WebAuthn) BeginRegistration(ctx context.Context, user User) (*CredentialCreationOptions, *Session, error) {
+	if len(user.ID) == 0 || len(user.ID) > 64 {
+		return nil, nil, fmt.Errorf("%w: user id must be 1-64 bytes", ErrInvalidConfig)
+	}
+	if user.Name == "" {
+		return nil, nil, fmt.Errorf("%w: user name is required", ErrInvalidConfig)
+	}
+	existing, err := w.store.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list credentials: %w", err)
+	}
+	session, err := w.newSession(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.Name
+	}
+	params := make([]CredentialParameter, 0, len(w.algorithms))
+	for _, alg := range w.algorithms {
+		params = append(params, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+
+	return &CredentialCreationOptions{
+		RP:                 RelyingPartyEntity{ID: w.rpID, Name: w.rpName},
+		User:               UserEntity{ID: user.ID, Name: user.Name, DisplayName: displayName},
+		Challenge:          session.Challenge,
+		PubKeyCredParams:   params,
+		Timeout:            w.timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(existing),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        w.residentKey,
+			RequireResidentKey: w.residentKey == ResidentKeyRequired,
+			UserVerification:   w.userVerification,
+		},
+		Attestation: "none",
+	}, session, nil
+}
+
+// FinishRegistration 校验注册响应（WebAuthn 7.1 节）并保存凭证。
+// 调用方应在调用后删除 Session，避免挑战被重放
+func (w *WebAuthn) FinishRegistration(ctx context.Context, session *Session, resp *RegistrationResponse) (*Credential, error) {
+	if err := checkSession(session); err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Type != "public-key" {
+		return nil, fmt.Errorf("%w: credential type", ErrInvalidResponse)
+	}
+
+	clientDataHash, err := w.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", session)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := decodeCBOR(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attestation object: %v", ErrInvalidResponse, err)
+	}
+	raw, ok := v.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object", ErrInvalidResponse)
+	}
+	obj := cborMap(raw)
+	format, _ := obj["fmt"].(string)
+	stmt, _ := obj["attStmt"].(map[any]any)
+	authData, ok := obj.bytes("authData")
+	if !ok || stmt == nil {
+		return nil, fmt.Errorf("%w: attestation object", ErrInvalidResponse)
+	}
+
+	ad, err := parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.verifyAuthenticatorData(ad, session); err != nil {
+		return nil, err
+	}
+	if !ad.has(flagAttestedData) {
+		return nil, fmt.Errorf("%w: missing attested credential data", ErrInvalidResponse)
+	}
+	if !bytes.Equal(ad.credentialID, resp.RawID) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+
+	pub, alg, err := parseCOSEKey(ad.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(w.algorithms, alg) {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, alg)
+	}
+	if err := verifyAttestation(format, cborMap(stmt), ad, clientDataHash, pub, alg); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.store.GetCredential(ctx, ad.credentialID); err == nil {
+		return nil, ErrCredentialExists
+	} else if !errors.Is(err, ErrCredentialNotFound) {
+		return nil, fmt.Errorf("get credential: %w", err)
+	}
+
+	now := time.Now()
+	cred := &Credential{
+		ID:                slices.Clone(ad.credentialID),
+		UserID:            session.UserID,
+		PublicKey:         slices.Clone(ad.publicKey),
+		Algorithm:         alg,
+		SignCount:         ad.signCount,
+		AAGUID:            slices.Clone(ad.aaguid),
+		Transports:        resp.Response.Transports,
+		AttestationFormat: format,
+		UserVerified:      ad.has(flagUserVerified),
+		BackupEligible:    ad.has(flagBackupEligible),
+		BackupState:       ad.has(flagBackupState),
+		CreatedAt:         now,
+		LastUsedAt:        now,
+	}
+	if err := w.store.SaveCredential(ctx, cred); err != nil {
+		return nil, fmt.Errorf("save credential: %w", err)
+	}
+	return cred, nil
+}
+
+// oidFIDOAAGUID 证明证书中的认证器 AAGUID 扩展
+var oidFIDOAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// verifyAttestation 校验证明语句（WebAuthn 8.2 / 8.7 节）
+func verifyAttestation(format string, stmt cborMap, ad *authenticatorData, clientDataHash []byte, credKey crypto.PublicKey, credAlg Algorithm) error {
+	switch format {
+	case "none":
+		if len(stmt) != 0 {
+			return fmt.Errorf("%w: none attestation with statement", ErrInvalidResponse)
+		}
+		return nil
+
+	case "packed":
+		alg, ok := stmt.int("alg")
+		sig, ok2 := stmt.bytes("sig")
+		if !ok || !ok2 {
+			return fmt.Errorf("%w: packed attestation statement", ErrInvalidResponse)
+		}
+		signed := append(slices.Clone(ad.raw), clientDataHash...)
+
+		x5c, _ := stmt["x5c"].([]any)
+		if len(x5c) == 0 {
+			// 自证明：使用凭证私钥签名
+			if Algorithm(alg) != credAlg {
+				return fmt.Errorf("%w: self attestation alg mismatch", ErrInvalidResponse)
+			}
+			return verifySignature(credAlg, credKey, signed, sig)
+		}
+
+		der, ok := x5c[0].([]byte)
+		if !ok {
+			return fmt.Errorf("%w: packed attestation certificate", ErrInvalidResponse)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("%w: attestation certificate: %v", ErrInvalidResponse, err)
+		}
+		for _, ext := range cert.Extensions {
+			if !ext.Id.Equal(oidFIDOAAGUID) {
+				continue
+			}
+			var aaguid []byte
+			if _, err := asn1.Unmarshal(ext.Value, &aaguid); err != nil || !bytes.Equal(aaguid, ad.aaguid) {
+				return fmt.Errorf("%w: attestation certificate aaguid mismatch", ErrInvalidResponse)
+			}
+		}
+		return verifySignature(Algorithm(alg), cert.PublicKey, signed, sig)
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedAttestation, format)
+}
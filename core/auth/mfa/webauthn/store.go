@@ -0,0 +1,96 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+)
+
+// CredentialStore 凭证存储。实现须保证凭证 ID 全局唯一
+type CredentialStore interface {
+	// SaveCredential 保存新注册的凭证
+	SaveCredential(ctx context.Context, cred *Credential) error
+	// UpdateCredential 更新凭证的签名计数、标志与最近使用时间
+	UpdateCredential(ctx context.Context, cred *Credential) error
+	// GetCredential 按凭证 ID 查找，不存在时返回 ErrCredentialNotFound
+	GetCredential(ctx context.Context, id []byte) (*Credential, error)
+	// ListCredentials 列出用户的全部凭证
+	ListCredentials(ctx context.Context, userID []byte) ([]*Credential, error)
+	// DeleteCredential 删除凭证
+	DeleteCredential(ctx context.Context, id []byte) error
+}
+
+// MemoryStore 基于内存的凭证存储，用于测试与单实例部署
+type MemoryStore struct {
+	mu    sync.RWMutex
+	creds map[string]*Credential // 凭证 ID -> 凭证
+}
+
+var _ CredentialStore = (*MemoryStore)(nil)
+
+// NewMemoryStore 创建内存凭证存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{creds: make(map[string]*Credential)}
+}
+
+// SaveCredential 实现 CredentialStore
+func (s *MemoryStore) SaveCredential(ctx context.Context, cred *Credential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.creds[string(cred.ID)]; ok {
+		return ErrCredentialExists
+	}
+	c := *cred
+	s.creds[string(cred.ID)] = &c
+	return nil
+}
+
+// UpdateCredential 实现 CredentialStore
+func (s *MemoryStore) UpdateCredential(ctx context.Context, cred *Credential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.creds[string(cred.ID)]; !ok {
+		return ErrCredentialNotFound
+	}
+	c := *cred
+	s.creds[string(cred.ID)] = &c
+	return nil
+}
+
+// GetCredential 实现 CredentialStore
+func (s *MemoryStore) GetCredential(ctx context.Context, id []byte) (*Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cred, ok := s.creds[string(id)]
+	if !ok {
+		return nil, ErrCredentialNotFound
+	}
+	c := *cred
+	return &c, nil
+}
+
+// ListCredentials 实现 CredentialStore，按注册时间升序返回
+func (s *MemoryStore) ListCredentials(ctx context.Context, userID []byte) ([]*Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var list []*Credential
+	for _, cred := range s.creds {
+		if bytes.Equal(cred.UserID, userID) {
+			c := *cred
+			list = append(list, &c)
+		}
+	}
+	slices.SortFunc(list, func(a, b *Credential) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return list, nil
+}
+
+// DeleteCredential 实现 CredentialStore
+func (s *MemoryStore) DeleteCredential(ctx context.Context, id []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.creds, string(id))
+	return nil
+}
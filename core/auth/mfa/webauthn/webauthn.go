@@ -0,0 +1,295 @@
+// Package webauthn 实现 WebAuthn / FIDO2 的注册与登录断言流程（WebAuthn Level 2），
+// 用于在 TOTP 之外提供通行密钥（passkey）与安全密钥登录。
+//
+// 每个流程分两步：Begin* 生成交给浏览器 navigator.credentials.create/get 的选项
+// 与服务端 Session，Finish* 校验浏览器返回的凭证。Session 由调用方保存（如服务端会话
+// 或 Redis），并在 Finish* 时原样传回；凭证通过 CredentialStore 持久化。
+//
+// 支持 ES256/ES384/ES512、EdDSA 与 RS256 凭证，证明格式支持 none 与 packed。
+// packed 证书链不做信任校验，需要认证器型号白名单的场景应按 Credential.AAGUID 自行判断
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidConfig          = errors.New("webauthn: invalid configuration")
+	ErrInvalidResponse        = errors.New("webauthn: invalid response")
+	ErrChallengeMismatch      = errors.New("webauthn: challenge mismatch")
+	ErrOriginMismatch         = errors.New("webauthn: origin not allowed")
+	ErrRPIDMismatch           = errors.New("webauthn: rp id hash mismatch")
+	ErrUserNotPresent         = errors.New("webauthn: user not present")
+	ErrUserNotVerified        = errors.New("webauthn: user verification required")
+	ErrSessionExpired         = errors.New("webauthn: session expired")
+	ErrInvalidPublicKey       = errors.New("webauthn: invalid credential public key")
+	ErrUnsupportedAlgorithm   = errors.New("webauthn: unsupported algorithm")
+	ErrUnsupportedAttestation = errors.New("webauthn: unsupported attestation format")
+	ErrInvalidSignature       = errors.New("webauthn: invalid signature")
+	ErrCredentialExists       = errors.New("webauthn: credential already registered")
+	ErrCredentialNotFound     = errors.New("webauthn: credential not found")
+	ErrCredentialNotAllowed   = errors.New("webauthn: credential not allowed")
+	ErrCloneDetected          = errors.New("webauthn: signature counter did not increase, authenticator may be cloned")
+)
+
+const (
+	DefaultTimeout       = 5 * time.Minute // 默认流程超时
+	defaultChallengeSize = 32
+)
+
+// UserVerification 用户验证要求（PIN、指纹等）
+type UserVerification string
+
+const (
+	UVRequired    UserVerification = "required"
+	UVPreferred   UserVerification = "preferred"
+	UVDiscouraged UserVerification = "discouraged"
+)
+
+// ResidentKey 可发现凭证要求。通行密钥需要可发现凭证，登录时无需先输入用户名
+type ResidentKey string
+
+const (
+	ResidentKeyRequired    ResidentKey = "required"
+	ResidentKeyPreferred   ResidentKey = "preferred"
+	ResidentKeyDiscouraged ResidentKey = "discouraged"
+)
+
+// Base64URL 以无填充 base64url 编码序列化的字节串，与浏览器 PublicKeyCredential.toJSON() 一致。
+// 反序列化时同时接受带填充的编码
+type Base64URL []byte
+
+// MarshalJSON 实现 json.Marshaler
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	*b = decoded
+	return nil
+}
+
+// User 依赖方的用户。ID 为不含个人信息的不透明标识（最长 64 字节），
+// 会保存在认证器中并在可发现凭证登录时作为 userHandle 返回
+type User struct {
+	ID          []byte
+	Name        string // 登录名，如邮箱
+	DisplayName string // 展示名称
+}
+
+// Credential 已注册的凭证
+type Credential struct {
+	ID         Base64URL `json:"id"`
+	UserID     Base64URL `json:"user_id"`
+	PublicKey  Base64URL `json:"public_key"` // COSE_Key 编码
+	Algorithm  Algorithm `json:"algorithm"`
+	SignCount  uint32    `json:"sign_count"`
+	AAGUID     Base64URL `json:"aaguid"`               // 认证器型号标识，none 证明时可能全为 0
+	Transports []string  `json:"transports,omitempty"` // 如 "usb"、"internal"、"hybrid"
+
+	AttestationFormat string `json:"attestation_format"`
+	UserVerified      bool   `json:"user_verified"`   // 最近一次使用时是否完成用户验证
+	BackupEligible    bool   `json:"backup_eligible"` // 可同步的通行密钥
+	BackupState       bool   `json:"backup_state"`    // 已同步备份
+
+	Name       string    `json:"name,omitempty"` // 用户为凭证设置的名称，用于管理界面
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// Session 一次注册或登录流程的服务端状态，须由调用方保存并在 Finish* 时传回
+type Session struct {
+	Challenge        Base64URL        `json:"challenge"`
+	UserID           Base64URL        `json:"user_id,omitempty"` // 可发现凭证登录时为空
+	AllowCredentials []Base64URL      `json:"allow_credentials,omitempty"`
+	UserVerification UserVerification `json:"user_verification"`
+	Expires          time.Time        `json:"expires"`
+}
+
+// WebAuthn 依赖方（Relying Party）
+type WebAuthn struct {
+	rpID             string
+	rpName           string
+	origins          []string
+	store            CredentialStore
+	timeout          time.Duration
+	userVerification UserVerification
+	residentKey      ResidentKey
+	algorithms       []Algorithm
+	rpIDHash         [32]byte
+}
+
+// Option 配置 WebAuthn 的选项函数
+type Option func(*WebAuthn)
+
+// WithOrigins 设置允许的来源，如 "https://example.com"。默认为 "https://" + rpID
+func WithOrigins(origins ...string) Option {
+	return func(w *WebAuthn) {
+		w.origins = origins
+	}
+}
+
+// WithTimeout 设置流程超时，超时后 Finish* 返回 ErrSessionExpired
+func WithTimeout(timeout time.Duration) Option {
+	return func(w *WebAuthn) {
+		if timeout > 0 {
+			w.timeout = timeout
+		}
+	}
+}
+
+// WithUserVerification 设置用户验证要求，默认 UVPreferred。
+// 作为唯一登录因素（无密码）时应使用 UVRequired
+func WithUserVerification(uv UserVerification) Option {
+	return func(w *WebAuthn) {
+		w.userVerification = uv
+	}
+}
+
+// WithResidentKey 设置可发现凭证要求，默认 ResidentKeyPreferred
+func WithResidentKey(rk ResidentKey) Option {
+	return func(w *WebAuthn) {
+		w.residentKey = rk
+	}
+}
+
+// WithAlgorithms 设置注册时接受的算法，默认 DefaultAlgorithms
+func WithAlgorithms(algs ...Algorithm) Option {
+	return func(w *WebAuthn) {
+		if len(algs) > 0 {
+			w.algorithms = algs
+		}
+	}
+}
+
+// New 创建依赖方。rpID 为站点的可注册域名（如 "example.com"），凭证与之绑定
+func New(rpID, rpName string, store CredentialStore, opts ...Option) (*WebAuthn, error) {
+	if rpID == "" || rpName == "" {
+		return nil, fmt.Errorf("%w: rp id and name are required", ErrInvalidConfig)
+	}
+	if store == nil {
+		return nil, fmt.Errorf("%w: credential store is required", ErrInvalidConfig)
+	}
+	w := &WebAuthn{
+		rpID:             rpID,
+		rpName:           rpName,
+		origins:          []string{"https://" + rpID},
+		store:            store,
+		timeout:          DefaultTimeout,
+		userVerification: UVPreferred,
+		residentKey:      ResidentKeyPreferred,
+		algorithms:       DefaultAlgorithms,
+		rpIDHash:         sha256.Sum256([]byte(rpID)),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if len(w.origins) == 0 {
+		return nil, fmt.Errorf("%w: at least one origin is required", ErrInvalidConfig)
+	}
+	for _, alg := range w.algorithms {
+		if !slices.Contains([]Algorithm{AlgES256, AlgES384, AlgES512, AlgEdDSA, AlgRS256}, alg) {
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, alg)
+		}
+	}
+	return w, nil
+}
+
+// newSession 生成随机挑战并创建流程状态
+func (w *WebAuthn) newSession(userID []byte) (*Session, error) {
+	challenge := make([]byte, defaultChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("generate challenge: %w", err)
+	}
+	return &Session{
+		Challenge:        challenge,
+		UserID:           userID,
+		UserVerification: w.userVerification,
+		Expires:          time.Now().Add(w.timeout),
+	}, nil
+}
+
+// clientData 客户端数据（WebAuthn 5.8.1 节）
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// verifyClientData 校验客户端数据的类型、挑战与来源，返回其 SHA-256 摘要
+func (w *WebAuthn) verifyClientData(raw []byte, typ string, session *Session) ([]byte, error) {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("%w: client data: %v", ErrInvalidResponse, err)
+	}
+	if cd.Type != typ {
+		return nil, fmt.Errorf("%w: client data type %q", ErrInvalidResponse, cd.Type)
+	}
+	if cd.Challenge != base64.RawURLEncoding.EncodeToString(session.Challenge) {
+		return nil, ErrChallengeMismatch
+	}
+	if !slices.Contains(w.origins, cd.Origin) {
+		return nil, fmt.Errorf("%w: %q", ErrOriginMismatch, cd.Origin)
+	}
+	hash := sha256.Sum256(raw)
+	return hash[:], nil
+}
+
+// verifyAuthenticatorData 校验 RP ID 摘要与用户在场 / 用户验证标志
+func (w *WebAuthn) verifyAuthenticatorData(ad *authenticatorData, session *Session) error {
+	if string(ad.rpIDHash) != string(w.rpIDHash[:]) {
+		return ErrRPIDMismatch
+	}
+	if !ad.has(flagUserPresent) {
+		return ErrUserNotPresent
+	}
+	if session.UserVerification == UVRequired && !ad.has(flagUserVerified) {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+// checkSession 校验流程状态未过期
+func checkSession(session *Session) error {
+	if session == nil || len(session.Challenge) == 0 {
+		return fmt.Errorf("%w: missing session", ErrInvalidResponse)
+	}
+	if time.Now().After(session.Expires) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// CredentialDescriptor 凭证描述符
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+// descriptors 将凭证转换为描述符列表
+func descriptors(creds []*Credential) []CredentialDescriptor {
+	list := make([]CredentialDescriptor, 0, len(creds))
+	for _, c := range creds {
+		list = append(list, CredentialDescriptor{Type: "public-key", ID: c.ID, Transports: c.Transports})
+	}
+	return list
+}
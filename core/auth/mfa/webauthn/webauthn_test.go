@@ -0,0 +1,443 @@
+package webauthn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+// cborEncode 测试用的 CBOR 编码器，映射键按编码字节排序
+func cborEncode(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		default:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+	}
+	switch v := v.(type) {
+	case int:
+		return cborEncode(int64(v))
+	case int64:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		out := head(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, cborEncode(item)...)
+		}
+		return out
+	case map[any]any:
+		var pairs [][2][]byte
+		for k, val := range v {
+			pairs = append(pairs, [2][]byte{cborEncode(k), cborEncode(val)})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return string(pairs[i][0]) < string(pairs[j][0]) })
+		out := head(5, uint64(len(v)))
+		for _, p := range pairs {
+			out = append(append(out, p[0]...), p[1]...)
+		}
+		return out
+	}
+	panic("unsupported cbor value")
+}
+
+// testAuthenticator 模拟认证器
+type testAuthenticator struct {
+	signer     crypto.Signer
+	credID     []byte
+	signCount  uint32 // 每次断言的增量为 step
+	step       uint32
+	userHandle []byte
+	flags      byte
+}
+
+func newES256Authenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{signer: key, credID: randomID(t), step: 1, flags: flagUserPresent | flagUserVerified}
+}
+
+func newEdDSAAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同步通行密钥的签名计数始终为 0
+	return &testAuthenticator{signer: key, credID: randomID(t), flags: flagUserPresent | flagUserVerified | flagBackupEligible | flagBackupState}
+}
+
+func randomID(t *testing.T) []byte {
+	t.Helper()
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	switch pub := a.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		raw, _ := pub.Bytes()
+		return cborEncode(map[any]any{1: 2, 3: -7, -1: 1, -2: raw[1:33], -3: raw[33:]})
+	case ed25519.PublicKey:
+		return cborEncode(map[any]any{1: 1, 3: -8, -1: 6, -2: []byte(pub)})
+	}
+	panic("unsupported key")
+}
+
+func (a *testAuthenticator) sign(t *testing.T, message []byte) []byte {
+	t.Helper()
+	var sig []byte
+	var err error
+	if _, ok := a.signer.(ed25519.PrivateKey); ok {
+		sig, err = a.signer.Sign(rand.Reader, message, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(message)
+		sig, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func (a *testAuthenticator) authData(flags byte, attested bool) []byte {
+	rpHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credID)))
+		data = append(data, a.credID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func clientDataJSON(typ string, challenge []byte, origin string) []byte {
+	data, _ := json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	return data
+}
+
+// create 模拟 navigator.credentials.create，format 为 "none" 或 "packed"（自证明）
+func (a *testAuthenticator) create(t *testing.T, opts *CredentialCreationOptions, origin, format string) *RegistrationResponse {
+	t.Helper()
+	a.userHandle = opts.User.ID
+	cdj := clientDataJSON("webauthn.create", opts.Challenge, origin)
+	authData := a.authData(a.flags|flagAttestedData, true)
+
+	stmt := map[any]any{}
+	if format == "packed" {
+		cdHash := sha256.Sum256(cdj)
+		alg := -7
+		if _, ok := a.signer.(ed25519.PrivateKey); ok {
+			alg = -8
+		}
+		stmt = map[any]any{"alg": alg, "sig": a.sign(t, append(append([]byte(nil), authData...), cdHash[:]...))}
+	}
+	att := cborEncode(map[any]any{"fmt": format, "attStmt": stmt, "authData": authData})
+
+	return &RegistrationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credID),
+		RawID: a.credID,
+		Type:  "public-key",
+		Response: AttestationResponse{
+			ClientDataJSON:    cdj,
+			AttestationObject: att,
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+// get 模拟 navigator.credentials.get
+func (a *testAuthenticator) get(t *testing.T, opts *CredentialRequestOptions, origin string) *LoginResponse {
+	t.Helper()
+	a.signCount += a.step
+	cdj := clientDataJSON("webauthn.get", opts.Challenge, origin)
+	authData := a.authData(a.flags, false)
+	cdHash := sha256.Sum256(cdj)
+
+	return &LoginResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credID),
+		RawID: a.credID,
+		Type:  "public-key",
+		Response: AssertionResponse{
+			ClientDataJSON:    cdj,
+			AuthenticatorData: authData,
+			Signature:         a.sign(t, append(append([]byte(nil), authData...), cdHash[:]...)),
+			UserHandle:        a.userHandle,
+		},
+	}
+}
+
+func newTestWebAuthn(t *testing.T, opts ...Option) *WebAuthn {
+	t.Helper()
+	w, err := New(testRPID, "Example", NewMemoryStore(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// roundTrip 模拟 Session 经 JSON 持久化后取回
+func roundTrip[T any](t *testing.T, v *T) *T {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(T)
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func register(t *testing.T, w *WebAuthn, a *testAuthenticator, user User) *Credential {
+	t.Helper()
+	ctx := context.Background()
+	opts, session, err := w.BeginRegistration(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, err := w.FinishRegistration(ctx, roundTrip(t, session), roundTrip(t, a.create(t, roundTrip(t, opts), testOrigin, "none")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cred
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWebAuthn(t)
+	a := newES256Authenticator(t)
+	user := User{ID: []byte("user-1"), Name: "alice@example.com"}
+
+	cred := register(t, w, a, user)
+	if cred.Algorithm != AlgES256 || string(cred.UserID) != "user-1" || cred.AttestationFormat != "none" {
+		t.Errorf("unexpected credential %+v", cred)
+	}
+
+	// 已注册的凭证被排除
+	opts, _, err := w.BeginRegistration(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.ExcludeCredentials) != 1 || opts.User.DisplayName != user.Name {
+		t.Errorf("unexpected creation options %+v", opts)
+	}
+
+	reqOpts, session, err := w.BeginLogin(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqOpts.AllowCredentials) != 1 || reqOpts.RPID != testRPID {
+		t.Errorf("unexpected request options %+v", reqOpts)
+	}
+	resp := a.get(t, reqOpts, testOrigin)
+	loggedIn, err := w.FinishLogin(ctx, session, roundTrip(t, resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loggedIn.SignCount != 1 {
+		t.Errorf("sign count %d", loggedIn.SignCount)
+	}
+
+	// 重放相同断言，签名计数未递增
+	if _, err := w.FinishLogin(ctx, session, resp); !errors.Is(err, ErrCloneDetected) {
+		t.Errorf("replay: expected ErrCloneDetected, got %v", err)
+	}
+
+	// 其他用户的流程不接受该凭证
+	other := newES256Authenticator(t)
+	register(t, w, other, User{ID: []byte("user-2"), Name: "bob@example.com"})
+	reqOpts, session, _ = w.BeginLogin(ctx, []byte("user-2"))
+	if _, err := w.FinishLogin(ctx, session, a.get(t, reqOpts, testOrigin)); !errors.Is(err, ErrCredentialNotAllowed) {
+		t.Errorf("foreign credential: expected ErrCredentialNotAllowed, got %v", err)
+	}
+
+	if _, _, err := w.BeginLogin(ctx, []byte("nobody")); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("expected ErrCredentialNotFound, got %v", err)
+	}
+}
+
+func TestDiscoverableLogin(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWebAuthn(t, WithResidentKey(ResidentKeyRequired), WithUserVerification(UVRequired))
+	a := newEdDSAAuthenticator(t)
+	cred := register(t, w, a, User{ID: []byte("user-1"), Name: "alice"})
+	if !cred.BackupEligible || cred.Algorithm != AlgEdDSA {
+		t.Errorf("unexpected credential %+v", cred)
+	}
+
+	// 计数始终为 0 的认证器可以重复登录
+	for range 2 {
+		opts, session, err := w.BeginDiscoverableLogin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(opts.AllowCredentials) != 0 {
+			t.Errorf("unexpected allow list %v", opts.AllowCredentials)
+		}
+		got, err := w.FinishLogin(ctx, session, a.get(t, opts, testOrigin))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got.UserID) != "user-1" {
+			t.Errorf("user %q", got.UserID)
+		}
+	}
+
+	// userHandle 与凭证归属不符
+	opts, session, _ := w.BeginDiscoverableLogin(ctx)
+	resp := a.get(t, opts, testOrigin)
+	resp.Response.UserHandle = []byte("user-2")
+	if _, err := w.FinishLogin(ctx, session, resp); !errors.Is(err, ErrCredentialNotAllowed) {
+		t.Errorf("expected ErrCredentialNotAllowed, got %v", err)
+	}
+
+	// 要求用户验证
+	a.flags &^= flagUserVerified
+	opts, session, _ = w.BeginDiscoverableLogin(ctx)
+	if _, err := w.FinishLogin(ctx, session, a.get(t, opts, testOrigin)); !errors.Is(err, ErrUserNotVerified) {
+		t.Errorf("expected ErrUserNotVerified, got %v", err)
+	}
+}
+
+func TestFinishRegistration_Rejects(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWebAuthn(t)
+	user := User{ID: []byte("user-1"), Name: "alice"}
+
+	begin := func() (*CredentialCreationOptions, *Session) {
+		opts, session, err := w.BeginRegistration(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return opts, session
+	}
+
+	opts, session := begin()
+	if _, err := w.FinishRegistration(ctx, session, newES256Authenticator(t).create(t, opts, "https://evil.com", "none")); !errors.Is(err, ErrOriginMismatch) {
+		t.Errorf("origin: got %v", err)
+	}
+
+	_, session = begin()
+	otherOpts, _ := begin()
+	if _, err := w.FinishRegistration(ctx, session, newES256Authenticator(t).create(t, otherOpts, testOrigin, "none")); !errors.Is(err, ErrChallengeMismatch) {
+		t.Errorf("challenge: got %v", err)
+	}
+
+	opts, session = begin()
+	session.Expires = time.Now().Add(-time.Second)
+	if _, err := w.FinishRegistration(ctx, session, newES256Authenticator(t).create(t, opts, testOrigin, "none")); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("expired: got %v", err)
+	}
+
+	opts, session = begin()
+	a := newES256Authenticator(t)
+	a.flags = 0
+	if _, err := w.FinishRegistration(ctx, session, a.create(t, opts, testOrigin, "none")); !errors.Is(err, ErrUserNotPresent) {
+		t.Errorf("user presence: got %v", err)
+	}
+
+	opts, session = begin()
+	resp := newES256Authenticator(t).create(t, opts, testOrigin, "fido-u2f")
+	if _, err := w.FinishRegistration(ctx, session, resp); !errors.Is(err, ErrUnsupportedAttestation) {
+		t.Errorf("attestation format: got %v", err)
+	}
+
+	// 同一凭证不能重复注册
+	a = newES256Authenticator(t)
+	register(t, w, a, user)
+	opts, session = begin()
+	if _, err := w.FinishRegistration(ctx, session, a.create(t, opts, testOrigin, "none")); !errors.Is(err, ErrCredentialExists) {
+		t.Errorf("duplicate: got %v", err)
+	}
+}
+
+func TestPackedSelfAttestation(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWebAuthn(t)
+	a := newES256Authenticator(t)
+
+	opts, session, _ := w.BeginRegistration(ctx, User{ID: []byte("user-1"), Name: "alice"})
+	resp := a.create(t, opts, testOrigin, "packed")
+	cred, err := w.FinishRegistration(ctx, session, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.AttestationFormat != "packed" {
+		t.Errorf("format %q", cred.AttestationFormat)
+	}
+
+	// 篡改签名
+	opts, session, _ = w.BeginRegistration(ctx, User{ID: []byte("user-2"), Name: "bob"})
+	resp = newES256Authenticator(t).create(t, opts, testOrigin, "packed")
+	v, _, _ := decodeCBOR(resp.Response.AttestationObject)
+	att := v.(map[any]any)
+	stmt := att["attStmt"].(map[any]any)
+	sig := stmt["sig"].([]byte)
+	sig[len(sig)-1] ^= 0xff
+	resp.Response.AttestationObject = cborEncode(att)
+	if _, err := w.FinishRegistration(ctx, session, resp); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered signature: got %v", err)
+	}
+}
+
+func TestDecodeCBOR_Malformed(t *testing.T) {
+	deep := make([]byte, 0, maxCBORDepth+2)
+	for range maxCBORDepth + 2 {
+		deep = append(deep, 0x81) // 单元素数组
+	}
+	cases := map[string][]byte{
+		"empty":       {},
+		"truncated":   {0x43, 1, 2}, // 3 字节的字节串只有 2 字节
+		"indefinite":  {0x5f},       // 不定长字节串
+		"huge array":  {0x9a, 0xff, 0xff, 0xff, 0xff},
+		"bad map key": {0xa1, 0x80, 0x01}, // 数组作为键
+		"deep":        deep,
+	}
+	for name, data := range cases {
+		if _, _, err := decodeCBOR(data); !errors.Is(err, errCBOR) {
+			t.Errorf("%s: expected errCBOR, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker_Transitions(t *testing.T) {
+	b := New(true, 2, 30*time.Millisecond)
+	if !b.Allow() || !b.IsClosed() {
+		t.Fatalf("new breaker should be closed, got %s", b.GetState())
+	}
+
+	b.RecordFailure()
+	if !b.IsClosed() || b.GetFailures() != 1 {
+		t.Fatalf("below threshold: state=%s failures=%d", b.GetState(), b.GetFailures())
+	}
+	b.RecordFailure()
+	if !b.IsOpen() || b.Allow() {
+		t.Fatalf("threshold reached: state=%s", b.GetState())
+	}
+
+	// 熔断超时后进入半开，失败则重新熔断
+	time.Sleep(40 * time.Millisecond)
+	if !b.Allow() || !b.IsHalfOpen() {
+		t.Fatalf("after timeout: state=%s", b.GetState())
+	}
+	b.RecordFailure()
+	if !b.IsOpen() {
+		t.Fatalf("half-open failure: state=%s", b.GetState())
+	}
+
+	// 半开状态下成功则恢复
+	time.Sleep(40 * time.Millisecond)
+	b.Allow()
+	b.RecordSuccess()
+	if !b.IsClosed() || b.GetFailures() != 0 {
+		t.Fatalf("half-open success: state=%s failures=%d", b.GetState(), b.GetFailures())
+	}
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := New(false, 1, time.Minute)
+	b.RecordFailure()
+	if !b.Allow() || b.GetState() != StateClosed {
+		t.Fatalf("disabled breaker should always allow, got %s", b.GetState())
+	}
+
+	b.Enable()
+	b.RecordFailure()
+	if b.Allow() {
+		t.Fatal("enabled breaker should open")
+	}
+	b.Reset()
+	if !b.Allow() {
+		t.Fatal("reset breaker should allow")
+	}
+}
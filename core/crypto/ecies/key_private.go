@@ -20,6 +20,12 @@ func (priv *PrivateKey) Public() *PublicKey {
 	return priv.publicKey
 }
 
+// ECDHKey returns the underlying crypto/ecdh private key, for use with other
+// ECDH-based schemes such as JWE ECDH-ES. Returns nil if the key has been destroyed.
+func (priv *PrivateKey) ECDHKey() *ecdh.PrivateKey {
+	return priv.ecdhKey
+}
+
 // Bytes returns the private key scalar as a fixed-length big-endian byte slice.
 // Returns nil if the key has been destroyed.
 func (priv *PrivateKey) Bytes() []byte {
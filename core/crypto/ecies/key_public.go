@@ -13,6 +13,15 @@ type PublicKey struct {
 	ecdhKey *ecdh.PublicKey
 }
 
+// ECDHKey returns the underlying crypto/ecdh public key, for use with other
+// ECDH-based schemes such as JWE ECDH-ES.
+func (pub *PublicKey) ECDHKey() *ecdh.PublicKey {
+	if pub == nil {
+		return nil
+	}
+	return pub.ecdhKey
+}
+
 // Bytes returns the public key in encoded format.
 // If compressed is true, returns 33 bytes (0x02/0x03 + X).
 // If compressed is false, returns 65 bytes (0x04 + X + Y).
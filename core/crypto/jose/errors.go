@@ -0,0 +1,34 @@
+package jose
+
+import "errors"
+
+// Sentinel errors. Use errors.Is to check error categories.
+var (
+	// ErrUnsupportedAlgorithm indicates an "alg" or "enc" value this package
+	// does not implement, including "none".
+	ErrUnsupportedAlgorithm = errors.New("jose: unsupported algorithm")
+
+	// ErrUnsupportedKey indicates that the key type, curve or size does not
+	// fit the requested algorithm.
+	ErrUnsupportedKey = errors.New("jose: unsupported key")
+
+	// ErrInvalidToken indicates a malformed compact serialization or header.
+	ErrInvalidToken = errors.New("jose: invalid token")
+
+	// ErrUnsupportedCritical indicates a "crit" header parameter this package
+	// does not understand (RFC 7515 §4.1.11).
+	ErrUnsupportedCritical = errors.New("jose: unsupported critical header")
+
+	// ErrDecryptionFailed indicates that a JWE could not be decrypted. The
+	// cause is deliberately not distinguished to avoid padding oracles.
+	ErrDecryptionFailed = errors.New("jose: decryption failed")
+
+	// ErrInvalidSignature indicates that a JWS signature does not verify.
+	ErrInvalidSignature = errors.New("jose: invalid signature")
+
+	// ErrInvalidPEMBlock indicates that no supported PEM block was found.
+	ErrInvalidPEMBlock = errors.New("jose: invalid PEM block")
+
+	// ErrKeyFileRead indicates a failure to read a key file.
+	ErrKeyFileRead = errors.New("jose: failed to read key file")
+)
@@ -0,0 +1,132 @@
+// Package jose implements the subset of JOSE needed to exchange encrypted
+// and signed payloads with partners: JWE compact serialization (RFC 7516)
+// with RSA-OAEP / ECDH-ES key management and A256GCM content encryption,
+// and JWS compact and detached signatures (RFC 7515, RFC 7797).
+//
+// It is deliberately thin: tokens are produced and consumed as strings,
+// keys are standard library keys (or ecies keys) loaded with LoadPrivateKey
+// / LoadPublicKey or the ecies package, and claims handling is left to the
+// caller (see the jwt package for JWT claims).
+package jose
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Header is the JOSE protected header shared by JWE and JWS.
+type Header struct {
+	// Algorithm is the key management ("alg" in JWE) or signature algorithm.
+	Algorithm string `json:"alg"`
+	// Encryption is the JWE content encryption algorithm; empty for JWS.
+	Encryption string `json:"enc,omitempty"`
+	// KeyID identifies the key, see WithKeyID.
+	KeyID string `json:"kid,omitempty"`
+	// Type is the media type of the complete object, see WithType.
+	Type string `json:"typ,omitempty"`
+	// ContentType is the media type of the payload, see WithContentType.
+	ContentType string `json:"cty,omitempty"`
+	// EphemeralKey is the sender's ephemeral public key for ECDH-ES.
+	EphemeralKey *JSONWebKey `json:"epk,omitempty"`
+	// PartyUInfo and PartyVInfo are the base64url-encoded ECDH-ES
+	// agreement party info, see WithPartyInfo.
+	PartyUInfo string `json:"apu,omitempty"`
+	PartyVInfo string `json:"apv,omitempty"`
+	// B64 is false for JWS with an unencoded payload (RFC 7797).
+	B64 *bool `json:"b64,omitempty"`
+	// Critical lists extensions that must be understood by the recipient.
+	Critical []string `json:"crit,omitempty"`
+}
+
+// JSONWebKey is the public elliptic curve JWK carried in the "epk" header.
+type JSONWebKey struct {
+	KeyType string `json:"kty"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// Option customizes the header of a produced token.
+type Option func(*options)
+
+type options struct {
+	kid       string
+	typ       string
+	cty       string
+	apu       []byte
+	apv       []byte
+	unencoded bool
+}
+
+// WithKeyID sets the "kid" header so the recipient can select the key.
+func WithKeyID(kid string) Option {
+	return func(o *options) { o.kid = kid }
+}
+
+// WithType sets the "typ" header, e.g. "JWT" or "JOSE".
+func WithType(typ string) Option {
+	return func(o *options) { o.typ = typ }
+}
+
+// WithContentType sets the "cty" header, e.g. "JWT" for a nested token.
+func WithContentType(cty string) Option {
+	return func(o *options) { o.cty = cty }
+}
+
+// WithPartyInfo sets the ECDH-ES agreement party info ("apu" / "apv"),
+// which both sides mix into the key derivation. Ignored by other algorithms.
+func WithPartyInfo(apu, apv []byte) Option {
+	return func(o *options) { o.apu, o.apv = apu, apv }
+}
+
+// WithUnencodedPayload signs the payload as-is instead of base64url-encoded
+// ("b64": false, RFC 7797), as required by some detached-signature APIs.
+// An attached unencoded payload must not contain '.'. Ignored by JWE.
+func WithUnencodedPayload() Option {
+	return func(o *options) { o.unencoded = true }
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ParseHeader decodes the protected header of a compact JWE or JWS without
+// verifying anything, e.g. to pick a key by "kid" before Decrypt or Verify.
+func ParseHeader(token string) (*Header, error) {
+	protected, _, _ := strings.Cut(token, ".")
+	return decodeHeader(protected)
+}
+
+// encodeHeader serializes and base64url-encodes h.
+func encodeHeader(h *Header) (string, error) {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return "", fmt.Errorf("jose: marshal header: %w", err)
+	}
+	return b64.EncodeToString(data), nil
+}
+
+// decodeHeader parses a base64url-encoded protected header.
+func decodeHeader(s string) (*Header, error) {
+	data, err := b64.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if h.Algorithm == "" {
+		return nil, fmt.Errorf("%w: missing alg", ErrInvalidToken)
+	}
+	return &h, nil
+}
+
+// b64 is the base64url encoding without padding used throughout JOSE.
+var b64 = base64.RawURLEncoding
@@ -0,0 +1,238 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// KeyAlgorithm is a JWE key management algorithm ("alg"): how the content
+// encryption key reaches the recipient.
+type KeyAlgorithm string
+
+const (
+	// RSAOAEP encrypts the content key with RSAES-OAEP using SHA-1 and MGF1-SHA-1.
+	RSAOAEP KeyAlgorithm = "RSA-OAEP"
+	// RSAOAEP256 encrypts the content key with RSAES-OAEP using SHA-256 and MGF1-SHA-256.
+	RSAOAEP256 KeyAlgorithm = "RSA-OAEP-256"
+	// ECDHES derives the content key directly from an ephemeral-static ECDH agreement.
+	ECDHES KeyAlgorithm = "ECDH-ES"
+	// ECDHESA256KW derives a key-encryption key with ECDH-ES and wraps a
+	// random content key with AES-256 Key Wrap.
+	ECDHESA256KW KeyAlgorithm = "ECDH-ES+A256KW"
+)
+
+// ContentEncryption is a JWE content encryption algorithm ("enc").
+type ContentEncryption string
+
+// A256GCM is AES-256-GCM, the only content encryption implemented.
+const A256GCM ContentEncryption = "A256GCM"
+
+const (
+	cekSize   = 32 // A256GCM key size
+	gcmIVSize = 12
+	gcmTagLen = 16
+)
+
+// Encrypt encrypts plaintext for the holder of key and returns a compact
+// JWE with A256GCM content encryption.
+//
+// key must match alg: an *rsa.PublicKey of at least 2048 bits for RSA-OAEP*,
+// or an *ecdsa.PublicKey, *ecdh.PublicKey or *ecies.PublicKey on P-256,
+// P-384 or P-521 for ECDH-ES*. WithKeyID, WithType, WithContentType and
+// WithPartyInfo customize the header.
+func Encrypt(plaintext []byte, key crypto.PublicKey, alg KeyAlgorithm, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	h := &Header{
+		Algorithm:   string(alg),
+		Encryption:  string(A256GCM),
+		KeyID:       o.kid,
+		Type:        o.typ,
+		ContentType: o.cty,
+	}
+
+	var cek, encryptedKey []byte
+	switch alg {
+	case RSAOAEP, RSAOAEP256:
+		pub, err := rsaPublicKey(key)
+		if err != nil {
+			return "", err
+		}
+		cek = randomBytes(cekSize)
+		if encryptedKey, err = rsa.EncryptOAEP(oaepHash(alg), rand.Reader, pub, cek, nil); err != nil {
+			return "", fmt.Errorf("jose: encrypt key: %w", err)
+		}
+
+	case ECDHES, ECDHESA256KW:
+		pub, err := ecdhPublicKey(key)
+		if err != nil {
+			return "", err
+		}
+		eph, err := pub.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", fmt.Errorf("jose: generate ephemeral key: %w", err)
+		}
+		z, err := eph.ECDH(pub)
+		if err != nil {
+			return "", fmt.Errorf("jose: key agreement: %w", err)
+		}
+		h.EphemeralKey = newEphemeralJWK(eph.PublicKey())
+		if o.apu != nil {
+			h.PartyUInfo = b64.EncodeToString(o.apu)
+		}
+		if o.apv != nil {
+			h.PartyVInfo = b64.EncodeToString(o.apv)
+		}
+		if alg == ECDHES {
+			cek = concatKDF(z, string(A256GCM), o.apu, o.apv, cekSize)
+		} else {
+			kek := concatKDF(z, string(alg), o.apu, o.apv, cekSize)
+			cek = randomBytes(cekSize)
+			if encryptedKey, err = keyWrap(kek, cek); err != nil {
+				return "", err
+			}
+		}
+
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+
+	protected, err := encodeHeader(h)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := randomBytes(gcmIVSize)
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcmTagLen], sealed[len(sealed)-gcmTagLen:]
+
+	return strings.Join([]string{
+		protected,
+		b64.EncodeToString(encryptedKey),
+		b64.EncodeToString(iv),
+		b64.EncodeToString(ciphertext),
+		b64.EncodeToString(tag),
+	}, "."), nil
+}
+
+// Decrypt decrypts a compact JWE produced by Encrypt or any JOSE library
+// using a supported algorithm, returning the plaintext and the header.
+//
+// key is the recipient's private key: *rsa.PrivateKey for RSA-OAEP*, or
+// *ecdsa.PrivateKey, *ecdh.PrivateKey or *ecies.PrivateKey for ECDH-ES*.
+// The algorithm is taken from the header but must fit the key type.
+// Any cryptographic failure is reported as ErrDecryptionFailed.
+func Decrypt(token string, key crypto.PrivateKey) ([]byte, *Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, fmt.Errorf("%w: JWE must have 5 parts", ErrInvalidToken)
+	}
+	h, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if ContentEncryption(h.Encryption) != A256GCM {
+		return nil, nil, fmt.Errorf("%w: enc %q", ErrUnsupportedAlgorithm, h.Encryption)
+	}
+	if len(h.Critical) > 0 {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnsupportedCritical, h.Critical)
+	}
+	encryptedKey, errK := b64.DecodeString(parts[1])
+	iv, errI := b64.DecodeString(parts[2])
+	ciphertext, errC := b64.DecodeString(parts[3])
+	tag, errT := b64.DecodeString(parts[4])
+	if errK != nil || errI != nil || errC != nil || errT != nil || len(iv) != gcmIVSize || len(tag) != gcmTagLen {
+		return nil, nil, fmt.Errorf("%w: malformed JWE", ErrInvalidToken)
+	}
+
+	var cek []byte
+	switch alg := KeyAlgorithm(h.Algorithm); alg {
+	case RSAOAEP, RSAOAEP256:
+		priv, err := rsaPrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		// On failure continue with a random key so that the error is
+		// indistinguishable from a bad tag (RFC 7516 §11.5).
+		cek, err = rsa.DecryptOAEP(oaepHash(alg), nil, priv, encryptedKey, nil)
+		if err != nil || len(cek) != cekSize {
+			cek = randomBytes(cekSize)
+		}
+
+	case ECDHES, ECDHESA256KW:
+		priv, err := ecdhPrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		epk, err := h.EphemeralKey.publicKey(priv.Curve())
+		if err != nil {
+			return nil, nil, err
+		}
+		apu, errU := b64.DecodeString(h.PartyUInfo)
+		apv, errV := b64.DecodeString(h.PartyVInfo)
+		if errU != nil || errV != nil {
+			return nil, nil, fmt.Errorf("%w: malformed apu/apv", ErrInvalidToken)
+		}
+		z, err := priv.ECDH(epk)
+		if err != nil {
+			return nil, nil, ErrDecryptionFailed
+		}
+		if alg == ECDHES {
+			if len(encryptedKey) != 0 {
+				return nil, nil, fmt.Errorf("%w: ECDH-ES must not carry an encrypted key", ErrInvalidToken)
+			}
+			cek = concatKDF(z, string(A256GCM), apu, apv, cekSize)
+		} else {
+			kek := concatKDF(z, string(alg), apu, apv, cekSize)
+			if cek, err = keyUnwrap(kek, encryptedKey); err != nil || len(cek) != cekSize {
+				return nil, nil, ErrDecryptionFailed
+			}
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, h.Algorithm)
+	}
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, nil, ErrDecryptionFailed
+	}
+	return plaintext, h, nil
+}
+
+// oaepHash returns the OAEP hash of an RSA-OAEP* algorithm.
+func oaepHash(alg KeyAlgorithm) hash.Hash {
+	if alg == RSAOAEP {
+		return sha1.New()
+	}
+	return sha256.New()
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// randomBytes returns n bytes from crypto/rand, which never fails.
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return b
+}
@@ -0,0 +1,227 @@
+package jose
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kochabx/kit/core/crypto/ecies"
+)
+
+var (
+	rsaKeyOnce sync.Once
+	rsaKey     *rsa.PrivateKey
+)
+
+// testRSAKey returns a shared 2048-bit key; generating one per test is slow.
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	rsaKeyOnce.Do(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+	})
+	return rsaKey
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	rsaPriv := testRSAKey(t)
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	ecdhPriv, err := ecdh.P521().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	eciesPriv, err := ecies.GenerateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		alg  KeyAlgorithm
+		pub  any
+		priv any
+	}{
+		{"RSA-OAEP", RSAOAEP, &rsaPriv.PublicKey, rsaPriv},
+		{"RSA-OAEP-256", RSAOAEP256, &rsaPriv.PublicKey, rsaPriv},
+		{"ECDH-ES ecdsa", ECDHES, &ecPriv.PublicKey, ecPriv},
+		{"ECDH-ES ecdh", ECDHES, ecdhPriv.PublicKey(), ecdhPriv},
+		{"ECDH-ES+A256KW", ECDHESA256KW, &ecPriv.PublicKey, ecPriv},
+		{"ECDH-ES+A256KW ecies", ECDHESA256KW, eciesPriv.Public(), eciesPriv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := []byte(`{"account":"6222","amount":100}`)
+			token, err := Encrypt(plaintext, tt.pub, tt.alg,
+				WithKeyID("partner-2024"), WithContentType("application/json"), WithPartyInfo([]byte("us"), []byte("them")))
+			require.NoError(t, err)
+			assert.Equal(t, 4, strings.Count(token, "."))
+
+			got, h, err := Decrypt(token, tt.priv)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, got)
+			assert.Equal(t, string(tt.alg), h.Algorithm)
+			assert.Equal(t, "A256GCM", h.Encryption)
+			assert.Equal(t, "partner-2024", h.KeyID)
+
+			parsed, err := ParseHeader(token)
+			require.NoError(t, err)
+			assert.Equal(t, h, parsed)
+
+			// Tamper with the ciphertext.
+			parts := strings.Split(token, ".")
+			ct, _ := b64.DecodeString(parts[3])
+			ct[0] ^= 1
+			parts[3] = b64.EncodeToString(ct)
+			_, _, err = Decrypt(strings.Join(parts, "."), tt.priv)
+			assert.ErrorIs(t, err, ErrDecryptionFailed)
+		})
+	}
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	rsaPriv := testRSAKey(t)
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherEC, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	token, err := Encrypt([]byte("secret"), &ecPriv.PublicKey, ECDHES)
+	require.NoError(t, err)
+
+	_, _, err = Decrypt(token, otherEC)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	_, _, err = Decrypt(token, rsaPriv)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+	_, _, err = Decrypt("a.b.c", ecPriv)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Ephemeral key on a different curve.
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	token384, err := Encrypt([]byte("secret"), &p384.PublicKey, ECDHES)
+	require.NoError(t, err)
+	_, _, err = Decrypt(token384, ecPriv)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	rsaToken, err := Encrypt([]byte("secret"), &rsaPriv.PublicKey, RSAOAEP256)
+	require.NoError(t, err)
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, _, err = Decrypt(rsaToken, otherRSA)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = Encrypt([]byte("x"), &rsaPriv.PublicKey, "RSA1_5")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	_, err = Encrypt([]byte("x"), &rsaPriv.PublicKey, ECDHES)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = Encrypt([]byte("x"), &small.PublicKey, RSAOAEP)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
+
+// RFC 3394 §4.6: wrap 256 bits of key data with a 256-bit KEK.
+func TestKeyWrap_RFC3394(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F")
+	data, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F")
+	want, _ := hex.DecodeString("28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21")
+
+	wrapped, err := keyWrap(kek, data)
+	require.NoError(t, err)
+	assert.Equal(t, want, wrapped)
+
+	unwrapped, err := keyUnwrap(kek, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, data, unwrapped)
+
+	wrapped[10] ^= 1
+	_, err = keyUnwrap(kek, wrapped)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+// RFC 7518 Appendix C: ECDH-ES key agreement between Alice and Bob.
+func TestConcatKDF_RFC7518(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := b64.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+	alice, err := ecdh.P256().NewPrivateKey(decode("0_NxaRPUMQoAJt50Gz8YiTr8gRTwyEaCumd-MToTmIo"))
+	require.NoError(t, err)
+	bob := &JSONWebKey{
+		KeyType: "EC",
+		Curve:   "P-256",
+		X:       "weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ",
+		Y:       "e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck",
+	}
+	bobPub, err := bob.publicKey(ecdh.P256())
+	require.NoError(t, err)
+
+	z, err := alice.ECDH(bobPub)
+	require.NoError(t, err)
+	key := concatKDF(z, "A128GCM", []byte("Alice"), []byte("Bob"), 16)
+	assert.Equal(t, "VqqN6vgjbSBcIijNcacQGg", b64.EncodeToString(key))
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaPriv := testRSAKey(t)
+	write := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+		return path
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaPriv)
+	require.NoError(t, err)
+	spki, err := x509.MarshalPKIXPublicKey(&rsaPriv.PublicKey)
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		write("pkcs8.pem", "PRIVATE KEY", pkcs8),
+		write("pkcs1.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPriv)),
+	} {
+		priv, err := LoadPrivateKey(path)
+		require.NoError(t, err)
+		assert.True(t, rsaPriv.Equal(priv))
+	}
+	for _, path := range []string{
+		write("spki.pem", "PUBLIC KEY", spki),
+		write("pkcs1pub.pem", "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaPriv.PublicKey)),
+	} {
+		pub, err := LoadPublicKey(path)
+		require.NoError(t, err)
+		assert.True(t, rsaPriv.PublicKey.Equal(pub))
+	}
+
+	// Key files written by ecies work for ECDH-ES.
+	eciesPriv, err := ecies.GenerateKey()
+	require.NoError(t, err)
+	require.NoError(t, ecies.SavePrivateKey(eciesPriv, filepath.Join(dir, "ec.pem")))
+	require.NoError(t, ecies.SavePublicKey(eciesPriv.Public(), filepath.Join(dir, "ec.pub.pem")))
+	ecPub, err := LoadPublicKey(filepath.Join(dir, "ec.pub.pem"))
+	require.NoError(t, err)
+	token, err := Encrypt([]byte("hello"), ecPub, ECDHESA256KW)
+	require.NoError(t, err)
+	loaded, err := ecies.LoadPrivateKey(filepath.Join(dir, "ec.pem"))
+	require.NoError(t, err)
+	got, _, err := Decrypt(token, loaded)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+
+	_, err = ParsePrivateKey([]byte("garbage"))
+	assert.ErrorIs(t, err, ErrInvalidPEMBlock)
+	_, err = LoadPublicKey(filepath.Join(dir, "missing.pem"))
+	assert.ErrorIs(t, err, ErrKeyFileRead)
+}
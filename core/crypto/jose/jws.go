@@ -0,0 +1,247 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// SignatureAlgorithm is a JWS signature algorithm ("alg").
+type SignatureAlgorithm string
+
+const (
+	RS256 SignatureAlgorithm = "RS256" // RSASSA-PKCS1-v1_5 with SHA-256
+	RS384 SignatureAlgorithm = "RS384" // RSASSA-PKCS1-v1_5 with SHA-384
+	RS512 SignatureAlgorithm = "RS512" // RSASSA-PKCS1-v1_5 with SHA-512
+	PS256 SignatureAlgorithm = "PS256" // RSASSA-PSS with SHA-256
+	PS384 SignatureAlgorithm = "PS384" // RSASSA-PSS with SHA-384
+	PS512 SignatureAlgorithm = "PS512" // RSASSA-PSS with SHA-512
+	ES256 SignatureAlgorithm = "ES256" // ECDSA P-256 with SHA-256
+	ES384 SignatureAlgorithm = "ES384" // ECDSA P-384 with SHA-384
+	ES512 SignatureAlgorithm = "ES512" // ECDSA P-521 with SHA-512
+	EdDSA SignatureAlgorithm = "EdDSA" // Ed25519
+)
+
+// signatureHashes maps each algorithm except EdDSA to its digest.
+var signatureHashes = map[SignatureAlgorithm]crypto.Hash{
+	RS256: crypto.SHA256, RS384: crypto.SHA384, RS512: crypto.SHA512,
+	PS256: crypto.SHA256, PS384: crypto.SHA384, PS512: crypto.SHA512,
+	ES256: crypto.SHA256, ES384: crypto.SHA384, ES512: crypto.SHA512,
+}
+
+// Sign signs payload and returns a compact JWS carrying it.
+//
+// key must match alg: *rsa.PrivateKey (at least 2048 bits) for RS* / PS*,
+// *ecdsa.PrivateKey on the matching curve for ES*, ed25519.PrivateKey for
+// EdDSA. WithKeyID, WithType, WithContentType and WithUnencodedPayload
+// customize the header.
+func Sign(payload []byte, key crypto.PrivateKey, alg SignatureAlgorithm, opts ...Option) (string, error) {
+	return sign(payload, key, alg, newOptions(opts), false)
+}
+
+// SignDetached signs payload and returns a compact JWS with the payload
+// part left empty ("header..signature", RFC 7515 Appendix F), for APIs that
+// send the signature next to the body, e.g. in a header.
+func SignDetached(payload []byte, key crypto.PrivateKey, alg SignatureAlgorithm, opts ...Option) (string, error) {
+	return sign(payload, key, alg, newOptions(opts), true)
+}
+
+// Verify verifies a compact JWS with an attached payload and returns the
+// payload and the header. key is the signer's *rsa.PublicKey,
+// *ecdsa.PublicKey or ed25519.PublicKey; the algorithm is taken from the
+// header but must fit the key type.
+func Verify(token string, key crypto.PublicKey) ([]byte, *Header, error) {
+	return verify(token, nil, key)
+}
+
+// VerifyDetached verifies a detached JWS ("header..signature") over payload
+// and returns the header.
+func VerifyDetached(token string, payload []byte, key crypto.PublicKey) (*Header, error) {
+	if payload == nil {
+		payload = []byte{}
+	}
+	_, h, err := verify(token, payload, key)
+	return h, err
+}
+
+func sign(payload []byte, key crypto.PrivateKey, alg SignatureAlgorithm, o *options, detached bool) (string, error) {
+	h := &Header{Algorithm: string(alg), KeyID: o.kid, Type: o.typ, ContentType: o.cty}
+	if o.unencoded {
+		b := false
+		h.B64 = &b
+		h.Critical = []string{"b64"}
+		if !detached && strings.Contains(string(payload), ".") {
+			return "", fmt.Errorf("%w: attached unencoded payload contains '.'", ErrInvalidToken)
+		}
+	}
+	protected, err := encodeHeader(h)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := string(payload)
+	if !o.unencoded {
+		encoded = b64.EncodeToString(payload)
+	}
+	sig, err := signBytes(alg, key, []byte(protected+"."+encoded))
+	if err != nil {
+		return "", err
+	}
+	if detached {
+		encoded = ""
+	}
+	return protected + "." + encoded + "." + b64.EncodeToString(sig), nil
+}
+
+// verify checks token; a non-nil detached is the payload of a detached JWS.
+func verify(token string, detached []byte, key crypto.PublicKey) ([]byte, *Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, fmt.Errorf("%w: JWS must have 3 parts", ErrInvalidToken)
+	}
+	h, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range h.Critical {
+		if name != "b64" || h.B64 == nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedCritical, name)
+		}
+	}
+	encodedPayload := h.B64 == nil || *h.B64
+	if !encodedPayload && !slices.Contains(h.Critical, "b64") {
+		return nil, nil, fmt.Errorf("%w: b64 must be listed in crit", ErrInvalidToken)
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+
+	var payload []byte
+	var signed string
+	switch {
+	case detached != nil:
+		if parts[1] != "" {
+			return nil, nil, fmt.Errorf("%w: detached JWS carries a payload", ErrInvalidToken)
+		}
+		payload = detached
+		if encodedPayload {
+			signed = b64.EncodeToString(payload)
+		} else {
+			signed = string(payload)
+		}
+	case encodedPayload:
+		if payload, err = b64.DecodeString(parts[1]); err != nil {
+			return nil, nil, fmt.Errorf("%w: payload: %v", ErrInvalidToken, err)
+		}
+		signed = parts[1]
+	default:
+		payload, signed = []byte(parts[1]), parts[1]
+	}
+
+	if err := verifyBytes(SignatureAlgorithm(h.Algorithm), key, []byte(parts[0]+"."+signed), sig); err != nil {
+		return nil, nil, err
+	}
+	return payload, h, nil
+}
+
+func signBytes(alg SignatureAlgorithm, key crypto.PrivateKey, input []byte) ([]byte, error) {
+	if alg == EdDSA {
+		priv, ok := ed25519PrivateKey(key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T is not an Ed25519 private key", ErrUnsupportedKey, key)
+		}
+		return ed25519.Sign(priv, input), nil
+	}
+	hash, ok := signatureHashes[alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+	h := hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+
+	switch alg[0] {
+	case 'R', 'P':
+		priv, err := rsaPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if alg[0] == 'R' {
+			return rsa.SignPKCS1v15(rand.Reader, priv, hash, digest)
+		}
+		return rsa.SignPSS(rand.Reader, priv, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	default:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve != ecdsaCurve(alg) {
+			return nil, fmt.Errorf("%w: %s requires an ECDSA key on %s", ErrUnsupportedKey, alg, ecdsaCurve(alg).Params().Name)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest)
+		if err != nil {
+			return nil, err
+		}
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		out := make([]byte, 2*size)
+		r.FillBytes(out[:size])
+		s.FillBytes(out[size:])
+		return out, nil
+	}
+}
+
+func verifyBytes(alg SignatureAlgorithm, key crypto.PublicKey, input, sig []byte) error {
+	if alg == EdDSA {
+		pub, ok := ed25519PublicKey(key)
+		if !ok {
+			return fmt.Errorf("%w: %T is not an Ed25519 public key", ErrUnsupportedKey, key)
+		}
+		if !ed25519.Verify(pub, input, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	hash, ok := signatureHashes[alg]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+	h := hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+
+	switch alg[0] {
+	case 'R', 'P':
+		pub, err := rsaPublicKey(key)
+		if err != nil {
+			return err
+		}
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != ecdsaCurve(alg) {
+			return fmt.Errorf("%w: %s requires an ECDSA key on %s", ErrUnsupportedKey, alg, ecdsaCurve(alg).Params().Name)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+package jose
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	rsaPriv := testRSAKey(t)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg  SignatureAlgorithm
+		priv any
+		pub  any
+	}{
+		{RS256, rsaPriv, &rsaPriv.PublicKey},
+		{RS512, rsaPriv, &rsaPriv.PublicKey},
+		{PS256, rsaPriv, &rsaPriv.PublicKey},
+		{ES256, p256, &p256.PublicKey},
+		{ES512, p521, &p521.PublicKey},
+		{EdDSA, edPriv, edPub},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.alg), func(t *testing.T) {
+			payload := []byte(`{"order":"42"}`)
+
+			token, err := Sign(payload, tt.priv, tt.alg, WithKeyID("k1"), WithType("JOSE"))
+			require.NoError(t, err)
+			got, h, err := Verify(token, tt.pub)
+			require.NoError(t, err)
+			assert.Equal(t, payload, got)
+			assert.Equal(t, "k1", h.KeyID)
+
+			detached, err := SignDetached(payload, tt.priv, tt.alg)
+			require.NoError(t, err)
+			assert.Contains(t, detached, "..")
+			_, err = VerifyDetached(detached, payload, tt.pub)
+			require.NoError(t, err)
+			_, err = VerifyDetached(detached, []byte(`{"order":"43"}`), tt.pub)
+			assert.ErrorIs(t, err, ErrInvalidSignature)
+
+			// Tamper with the signature.
+			parts := strings.Split(token, ".")
+			sig, _ := b64.DecodeString(parts[2])
+			sig[len(sig)/2] ^= 1
+			parts[2] = b64.EncodeToString(sig)
+			_, _, err = Verify(strings.Join(parts, "."), tt.pub)
+			assert.ErrorIs(t, err, ErrInvalidSignature)
+		})
+	}
+}
+
+func TestSignDetached_Unencoded(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	body := []byte(`{"amount":"10.00","currency":"GBP"}`)
+
+	token, err := SignDetached(body, key, ES256, WithUnencodedPayload())
+	require.NoError(t, err)
+	h, err := ParseHeader(token)
+	require.NoError(t, err)
+	require.NotNil(t, h.B64)
+	assert.False(t, *h.B64)
+	assert.Equal(t, []string{"b64"}, h.Critical)
+
+	_, err = VerifyDetached(token, body, &key.PublicKey)
+	require.NoError(t, err)
+	_, err = VerifyDetached(token, append(body, ' '), &key.PublicKey)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = Sign([]byte("a.b"), key, ES256, WithUnencodedPayload())
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// RFC 8037 Appendix A.4: Ed25519 signing is deterministic.
+func TestSign_RFC8037(t *testing.T) {
+	seed, err := b64.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	require.NoError(t, err)
+	priv := ed25519.NewKeyFromSeed(seed)
+
+	token, err := Sign([]byte("Example of Ed25519 signing"), priv, EdDSA)
+	require.NoError(t, err)
+	assert.Equal(t, "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc."+
+		"hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg", token)
+}
+
+func TestVerify_Errors(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	token, err := Sign([]byte("x"), p256, ES256)
+	require.NoError(t, err)
+	_, _, err = Verify(token, &p384.PublicKey)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+	_, err = VerifyDetached(token, []byte("x"), &p256.PublicKey)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = Sign([]byte("x"), p384, ES256)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+
+	none := b64.EncodeToString([]byte(`{"alg":"none"}`)) + "." + b64.EncodeToString([]byte("x")) + "."
+	_, _, err = Verify(none, &p256.PublicKey)
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	crit := b64.EncodeToString([]byte(`{"alg":"ES256","crit":["exp"],"exp":1}`)) + ".eA.c2ln"
+	_, _, err = Verify(crit, &p256.PublicKey)
+	assert.ErrorIs(t, err, ErrUnsupportedCritical)
+}
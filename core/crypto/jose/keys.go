@@ -0,0 +1,272 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/kochabx/kit/core/crypto/ecies"
+)
+
+// minRSABits is the minimum RSA modulus size required by RFC 7518.
+const minRSABits = 2048
+
+// LoadPrivateKey loads a private key from a PEM file, see ParsePrivateKey.
+func LoadPrivateKey(path string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyFileRead, err)
+	}
+	return ParsePrivateKey(data)
+}
+
+// LoadPublicKey loads a public key from a PEM file, see ParsePublicKey.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyFileRead, err)
+	}
+	return ParsePublicKey(data)
+}
+
+// ParsePrivateKey parses the first PEM block of data as a PKCS#8
+// ("PRIVATE KEY"), PKCS#1 ("RSA PRIVATE KEY") or SEC 1 ("EC PRIVATE KEY")
+// private key. The result is an *rsa.PrivateKey, *ecdsa.PrivateKey or
+// ed25519.PrivateKey, usable for both signing and decryption.
+func ParsePrivateKey(data []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEMBlock
+	}
+	var (
+		key crypto.PrivateKey
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPEMBlock, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jose: parse private key: %w", err)
+	}
+	return key, nil
+}
+
+// ParsePublicKey parses the first PEM block of data as a SubjectPublicKeyInfo
+// ("PUBLIC KEY"), PKCS#1 ("RSA PUBLIC KEY") public key or an X.509
+// certificate ("CERTIFICATE"), whose public key is returned.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEMBlock
+	}
+	var (
+		key crypto.PublicKey
+		err error
+	)
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPEMBlock, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jose: parse public key: %w", err)
+	}
+	return key, nil
+}
+
+// rsaPublicKey returns key as an RSA public key of acceptable size.
+func rsaPublicKey(key crypto.PublicKey) (*rsa.PublicKey, error) {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not an RSA public key", ErrUnsupportedKey, key)
+	}
+	if pub.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("%w: RSA key smaller than %d bits", ErrUnsupportedKey, minRSABits)
+	}
+	return pub, nil
+}
+
+// rsaPrivateKey returns key as an RSA private key of acceptable size.
+func rsaPrivateKey(key crypto.PrivateKey) (*rsa.PrivateKey, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not an RSA private key", ErrUnsupportedKey, key)
+	}
+	if priv.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("%w: RSA key smaller than %d bits", ErrUnsupportedKey, minRSABits)
+	}
+	return priv, nil
+}
+
+// ecdhPublicKey converts an ECDSA, ECDH or ecies public key on a NIST curve
+// to its crypto/ecdh form.
+func ecdhPublicKey(key crypto.PublicKey) (*ecdh.PublicKey, error) {
+	var (
+		pub *ecdh.PublicKey
+		err error
+	)
+	switch k := key.(type) {
+	case *ecdh.PublicKey:
+		pub = k
+	case *ecdsa.PublicKey:
+		pub, err = k.ECDH()
+	case *ecies.PublicKey:
+		pub = k.ECDHKey()
+	}
+	if err != nil || pub == nil {
+		return nil, fmt.Errorf("%w: %T is not an EC public key", ErrUnsupportedKey, key)
+	}
+	if curveName(pub.Curve()) == "" {
+		return nil, fmt.Errorf("%w: curve not supported", ErrUnsupportedKey)
+	}
+	return pub, nil
+}
+
+// ecdhPrivateKey converts an ECDSA, ECDH or ecies private key on a NIST
+// curve to its crypto/ecdh form.
+func ecdhPrivateKey(key crypto.PrivateKey) (*ecdh.PrivateKey, error) {
+	var (
+		priv *ecdh.PrivateKey
+		err  error
+	)
+	switch k := key.(type) {
+	case *ecdh.PrivateKey:
+		priv = k
+	case *ecdsa.PrivateKey:
+		priv, err = k.ECDH()
+	case *ecies.PrivateKey:
+		priv = k.ECDHKey()
+	}
+	if err != nil || priv == nil {
+		return nil, fmt.Errorf("%w: %T is not an EC private key", ErrUnsupportedKey, key)
+	}
+	if curveName(priv.Curve()) == "" {
+		return nil, fmt.Errorf("%w: curve not supported", ErrUnsupportedKey)
+	}
+	return priv, nil
+}
+
+// curveName returns the JWK "crv" name of a NIST curve, or "" otherwise.
+func curveName(c ecdh.Curve) string {
+	switch c {
+	case ecdh.P256():
+		return "P-256"
+	case ecdh.P384():
+		return "P-384"
+	case ecdh.P521():
+		return "P-521"
+	}
+	return ""
+}
+
+// curveByName is the inverse of curveName.
+func curveByName(name string) ecdh.Curve {
+	switch name {
+	case "P-256":
+		return ecdh.P256()
+	case "P-384":
+		return ecdh.P384()
+	case "P-521":
+		return ecdh.P521()
+	}
+	return nil
+}
+
+// newEphemeralJWK encodes an ECDH public key as the "epk" header value.
+func newEphemeralJWK(pub *ecdh.PublicKey) *JSONWebKey {
+	point := pub.Bytes() // 0x04 || X || Y
+	size := (len(point) - 1) / 2
+	return &JSONWebKey{
+		KeyType: "EC",
+		Curve:   curveName(pub.Curve()),
+		X:       b64.EncodeToString(point[1 : 1+size]),
+		Y:       b64.EncodeToString(point[1+size:]),
+	}
+}
+
+// publicKey decodes an "epk" header value on the given curve; the point is
+// validated by crypto/ecdh.
+func (k *JSONWebKey) publicKey(curve ecdh.Curve) (*ecdh.PublicKey, error) {
+	if k == nil || k.KeyType != "EC" || curveByName(k.Curve) != curve {
+		return nil, fmt.Errorf("%w: epk does not match the recipient key", ErrInvalidToken)
+	}
+	x, errX := b64.DecodeString(k.X)
+	y, errY := b64.DecodeString(k.Y)
+	size := coordinateSize(curve)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("%w: malformed epk", ErrInvalidToken)
+	}
+	pub, err := curve.NewPublicKey(append(append([]byte{4}, x...), y...))
+	if err != nil {
+		return nil, fmt.Errorf("%w: epk: %v", ErrInvalidToken, err)
+	}
+	return pub, nil
+}
+
+// coordinateSize returns the byte length of a field element on curve.
+func coordinateSize(curve ecdh.Curve) int {
+	switch curve {
+	case ecdh.P256():
+		return 32
+	case ecdh.P384():
+		return 48
+	default:
+		return 66
+	}
+}
+
+// ecdsaCurve returns the elliptic curve required by an ES* algorithm.
+func ecdsaCurve(alg SignatureAlgorithm) elliptic.Curve {
+	switch alg {
+	case ES256:
+		return elliptic.P256()
+	case ES384:
+		return elliptic.P384()
+	default:
+		return elliptic.P521()
+	}
+}
+
+// ed25519PrivateKey accepts both value and pointer forms.
+func ed25519PrivateKey(key crypto.PrivateKey) (ed25519.PrivateKey, bool) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, true
+	case *ed25519.PrivateKey:
+		return *k, true
+	}
+	return nil, false
+}
+
+// ed25519PublicKey accepts both value and pointer forms.
+func ed25519PublicKey(key crypto.PublicKey) (ed25519.PublicKey, bool) {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k, true
+	case *ed25519.PublicKey:
+		return *k, true
+	}
+	return nil, false
+}
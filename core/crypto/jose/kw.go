@@ -0,0 +1,98 @@
+package jose
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// kwIV is the default initial value of AES Key Wrap (RFC 3394 §2.2.3.1).
+var kwIV = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// keyWrap wraps cek with kek using AES Key Wrap (RFC 3394).
+func keyWrap(kek, cek []byte) ([]byte, error) {
+	if len(cek)%8 != 0 || len(cek) < 16 {
+		return nil, errors.New("jose: key wrap input must be a multiple of 8 bytes")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(cek) / 8
+	out := make([]byte, 8+len(cek))
+	copy(out, kwIV[:])
+	copy(out[8:], cek)
+
+	var buf [16]byte
+	for j := range 6 {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[i*8:i*8+8])
+			block.Encrypt(buf[:], buf[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	return out, nil
+}
+
+// keyUnwrap reverses keyWrap and checks the integrity value.
+func keyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, ErrDecryptionFailed
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrapped)/8 - 1
+	out := make([]byte, len(wrapped))
+	copy(out, wrapped)
+
+	var buf [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+			copy(buf[8:], out[i*8:i*8+8])
+			block.Decrypt(buf[:], buf[:])
+			copy(out[:8], buf[:8])
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(out[:8], kwIV[:]) != 1 {
+		return nil, ErrDecryptionFailed
+	}
+	return out[8:], nil
+}
+
+// concatKDF derives size bytes from the ECDH shared secret z with the
+// Concat KDF (NIST SP 800-56A) as profiled for ECDH-ES by RFC 7518 §4.6.2.
+// algID is "enc" for direct key agreement and "alg" for key wrapping.
+func concatKDF(z []byte, algID string, apu, apv []byte, size int) []byte {
+	var other []byte
+	other = appendLengthPrefixed(other, []byte(algID))
+	other = appendLengthPrefixed(other, apu)
+	other = appendLengthPrefixed(other, apv)
+	other = binary.BigEndian.AppendUint32(other, uint32(size*8))
+
+	var out []byte
+	for counter := uint32(1); len(out) < size; counter++ {
+		h := sha256.New()
+		_ = binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(other)
+		out = h.Sum(out)
+	}
+	return out[:size]
+}
+
+func appendLengthPrefixed(dst, data []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(data)))
+	return append(dst, data...)
+}
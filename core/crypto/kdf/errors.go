@@ -0,0 +1,28 @@
+package kdf
+
+import "errors"
+
+// Sentinel errors. Use errors.Is to check error categories.
+var (
+	// ErrUnsupportedHash indicates that an unknown Hash value was used.
+	ErrUnsupportedHash = errors.New("kdf: unsupported hash")
+
+	// ErrInvalidKeyLength indicates that the requested output length is
+	// non-positive or exceeds what the construction can produce.
+	ErrInvalidKeyLength = errors.New("kdf: invalid key length")
+
+	// ErrEmptySecret indicates that the input keying material or password is empty.
+	ErrEmptySecret = errors.New("kdf: secret is empty")
+
+	// ErrSaltTooShort indicates that a password-hashing salt is shorter than
+	// MinSaltSize.
+	ErrSaltTooShort = errors.New("kdf: salt too short")
+
+	// ErrInvalidParams indicates that PBKDF2 or scrypt cost parameters are
+	// out of range.
+	ErrInvalidParams = errors.New("kdf: invalid parameters")
+
+	// ErrInvalidLabel indicates that a context label is empty or contains a
+	// NUL byte (which is reserved as the label/context separator).
+	ErrInvalidLabel = errors.New("kdf: invalid label")
+)
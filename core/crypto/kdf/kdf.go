@@ -0,0 +1,168 @@
+// Package kdf provides key-derivation helpers shared by kit's crypto
+// packages so that every feature derives keys with the same constructions
+// and parameters.
+//
+// Two families are offered:
+//
+//   - HKDF (RFC 5869) over SHA-256 or SHA-512, for deriving symmetric keys
+//     from high-entropy input such as ECDH shared secrets. Derive binds the
+//     output to a context label so keys for different purposes never collide.
+//   - Password hashing with PBKDF2 and scrypt, for low-entropy input. Zero
+//     parameter fields fall back to DefaultPBKDF2 / DefaultScrypt.
+//
+// Example:
+//
+//	key, _ := kdf.Derive(kdf.SHA256, sharedSecret, nil, "kit/ecies/v1", ephemeralPub, 32)
+//
+//	salt, _ := kdf.NewSalt(kdf.MinSaltSize)
+//	hash, _ := kdf.PBKDF2([]byte(password), salt, kdf.PBKDF2Params{})
+package kdf
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+)
+
+// Hash selects the hash function used by HKDF and PBKDF2.
+type Hash uint8
+
+const (
+	// SHA256 selects SHA-256 (default).
+	SHA256 Hash = iota
+	// SHA512 selects SHA-512.
+	SHA512
+)
+
+// String implements fmt.Stringer.
+func (h Hash) String() string {
+	switch h {
+	case SHA256:
+		return "SHA-256"
+	case SHA512:
+		return "SHA-512"
+	default:
+		return fmt.Sprintf("Hash(%d)", uint8(h))
+	}
+}
+
+// Size returns the digest size in bytes, or 0 for an unknown hash.
+func (h Hash) Size() int {
+	switch h {
+	case SHA256:
+		return sha256.Size
+	case SHA512:
+		return sha512.Size
+	default:
+		return 0
+	}
+}
+
+func (h Hash) new() (func() hash.Hash, error) {
+	switch h {
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedHash, h)
+	}
+}
+
+// MinSaltSize is the minimum salt length accepted by PBKDF2 and Scrypt.
+const MinSaltSize = 16
+
+// NewSalt returns n cryptographically random bytes.
+func NewSalt(n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidKeyLength, n)
+	}
+	salt := make([]byte, n)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// ---------------------------------------------------------------------------
+// HKDF
+// ---------------------------------------------------------------------------
+
+// Extract runs the HKDF-Extract step and returns a pseudorandom key of
+// h.Size() bytes. A nil salt is treated as h.Size() zero bytes.
+func Extract(h Hash, secret, salt []byte) ([]byte, error) {
+	fn, err := h.new()
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	return hkdf.Extract(fn, secret, salt)
+}
+
+// Expand runs the HKDF-Expand step on a pseudorandom key produced by Extract.
+// The info argument is used verbatim; use Info to build a labelled one.
+func Expand(h Hash, prk, info []byte, length int) ([]byte, error) {
+	fn, err := h.new()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHKDFLength(h, length); err != nil {
+		return nil, err
+	}
+	return hkdf.Expand(fn, prk, string(info), length)
+}
+
+// Key runs HKDF-Extract followed by HKDF-Expand with a raw info value.
+// Prefer Derive for new code; Key exists for wire formats whose info is
+// already fixed.
+func Key(h Hash, secret, salt, info []byte, length int) ([]byte, error) {
+	fn, err := h.new()
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if err := checkHKDFLength(h, length); err != nil {
+		return nil, err
+	}
+	return hkdf.Key(fn, secret, salt, string(info), length)
+}
+
+// Derive derives length bytes from secret, bound to a purpose label and an
+// optional context (for example a public key or session ID). Distinct labels
+// always produce independent keys for the same secret.
+func Derive(h Hash, secret, salt []byte, label string, context []byte, length int) ([]byte, error) {
+	info, err := Info(label, context)
+	if err != nil {
+		return nil, err
+	}
+	return Key(h, secret, salt, info, length)
+}
+
+// Info encodes an HKDF info value as label || 0x00 || context.
+// The label must be non-empty and must not contain a NUL byte.
+func Info(label string, context []byte) ([]byte, error) {
+	if label == "" || bytes.IndexByte([]byte(label), 0) >= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+	}
+	info := make([]byte, 0, len(label)+1+len(context))
+	info = append(info, label...)
+	info = append(info, 0)
+	info = append(info, context...)
+	return info, nil
+}
+
+// checkHKDFLength enforces the RFC 5869 output limit of 255 * HashLen.
+func checkHKDFLength(h Hash, length int) error {
+	if length <= 0 || length > 255*h.Size() {
+		return fmt.Errorf("%w: %d", ErrInvalidKeyLength, length)
+	}
+	return nil
+}
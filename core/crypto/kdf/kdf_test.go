@@ -0,0 +1,155 @@
+package kdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// RFC 5869, Appendix A.1.
+func TestHKDF_RFC5869(t *testing.T) {
+	ikm := mustHex(t, "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt := mustHex(t, "000102030405060708090a0b0c")
+	info := mustHex(t, "f0f1f2f3f4f5f6f7f8f9")
+	wantPRK := mustHex(t, "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5")
+	wantOKM := mustHex(t, "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865")
+
+	prk, err := Extract(SHA256, ikm, salt)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if !bytes.Equal(prk, wantPRK) {
+		t.Fatalf("PRK = %x, want %x", prk, wantPRK)
+	}
+	okm, err := Expand(SHA256, prk, info, 42)
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if !bytes.Equal(okm, wantOKM) {
+		t.Fatalf("OKM = %x, want %x", okm, wantOKM)
+	}
+	okm, err = Key(SHA256, ikm, salt, info, 42)
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	if !bytes.Equal(okm, wantOKM) {
+		t.Fatalf("Key OKM = %x, want %x", okm, wantOKM)
+	}
+}
+
+func TestDerive_LabelSeparation(t *testing.T) {
+	secret := []byte("shared-secret")
+	a, err := Derive(SHA512, secret, nil, "kit/a", []byte("ctx"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Derive(SHA512, secret, nil, "kit/b", []byte("ctx"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Fatal("different labels derived the same key")
+	}
+	again, _ := Derive(SHA512, secret, nil, "kit/a", []byte("ctx"), 32)
+	if !bytes.Equal(a, again) {
+		t.Fatal("derivation is not deterministic")
+	}
+}
+
+func TestDerive_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		h      Hash
+		secret []byte
+		label  string
+		length int
+		want   error
+	}{
+		{"bad hash", Hash(9), []byte("s"), "l", 32, ErrUnsupportedHash},
+		{"empty secret", SHA256, nil, "l", 32, ErrEmptySecret},
+		{"empty label", SHA256, []byte("s"), "", 32, ErrInvalidLabel},
+		{"nul label", SHA256, []byte("s"), "a\x00b", 32, ErrInvalidLabel},
+		{"zero length", SHA256, []byte("s"), "l", 0, ErrInvalidKeyLength},
+		{"too long", SHA256, []byte("s"), "l", 255*32 + 1, ErrInvalidKeyLength},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Derive(tc.h, tc.secret, nil, tc.label, nil, tc.length)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	salt := []byte("saltSALTsaltSALT")
+	key, err := PBKDF2([]byte("password"), salt, PBKDF2Params{Iterations: 1000})
+	if err != nil {
+		t.Fatalf("PBKDF2: %v", err)
+	}
+	if len(key) != DefaultPBKDF2.KeyLen {
+		t.Fatalf("len = %d, want %d", len(key), DefaultPBKDF2.KeyLen)
+	}
+	again, _ := PBKDF2([]byte("password"), salt, PBKDF2Params{Iterations: 1000})
+	if !bytes.Equal(key, again) {
+		t.Fatal("PBKDF2 is not deterministic")
+	}
+	other, _ := PBKDF2([]byte("password"), salt, PBKDF2Params{Hash: SHA512, Iterations: 1000})
+	if bytes.Equal(key, other) {
+		t.Fatal("SHA-256 and SHA-512 produced the same key")
+	}
+
+	if _, err := PBKDF2([]byte("password"), []byte("short"), PBKDF2Params{}); !errors.Is(err, ErrSaltTooShort) {
+		t.Fatalf("short salt err = %v", err)
+	}
+	if _, err := PBKDF2(nil, salt, PBKDF2Params{}); !errors.Is(err, ErrEmptySecret) {
+		t.Fatalf("empty password err = %v", err)
+	}
+	if _, err := PBKDF2([]byte("p"), salt, PBKDF2Params{Iterations: -1}); !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("negative iterations err = %v", err)
+	}
+}
+
+func TestScrypt(t *testing.T) {
+	salt := []byte("NaClNaClNaClNaCl")
+	p := ScryptParams{N: 1 << 10, KeyLen: 64}
+	key, err := Scrypt([]byte("password"), salt, p)
+	if err != nil {
+		t.Fatalf("Scrypt: %v", err)
+	}
+	if len(key) != 64 {
+		t.Fatalf("len = %d, want 64", len(key))
+	}
+	again, _ := Scrypt([]byte("password"), salt, p)
+	if !bytes.Equal(key, again) {
+		t.Fatal("Scrypt is not deterministic")
+	}
+	if _, err := Scrypt([]byte("password"), salt, ScryptParams{N: 1000}); !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("non power-of-two N err = %v", err)
+	}
+}
+
+func TestNewSalt(t *testing.T) {
+	a, err := NewSalt(MinSaltSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewSalt(MinSaltSize)
+	if len(a) != MinSaltSize || bytes.Equal(a, b) {
+		t.Fatalf("unexpected salts %x %x", a, b)
+	}
+	if _, err := NewSalt(0); !errors.Is(err, ErrInvalidKeyLength) {
+		t.Fatalf("err = %v", err)
+	}
+}
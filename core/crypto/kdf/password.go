@@ -0,0 +1,112 @@
+package kdf
+
+import (
+	"crypto/pbkdf2"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PBKDF2Params configures PBKDF2. Zero fields take their value from
+// DefaultPBKDF2.
+type PBKDF2Params struct {
+	Hash       Hash
+	Iterations int
+	KeyLen     int
+}
+
+// DefaultPBKDF2 follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+var DefaultPBKDF2 = PBKDF2Params{
+	Hash:       SHA256,
+	Iterations: 600_000,
+	KeyLen:     32,
+}
+
+func (p PBKDF2Params) withDefaults() PBKDF2Params {
+	if p.Iterations == 0 {
+		p.Iterations = DefaultPBKDF2.Iterations
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = DefaultPBKDF2.KeyLen
+	}
+	return p
+}
+
+// PBKDF2 derives a key from password and salt with PBKDF2-HMAC.
+func PBKDF2(password, salt []byte, p PBKDF2Params) ([]byte, error) {
+	p = p.withDefaults()
+	fn, err := p.Hash.new()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPassword(password, salt); err != nil {
+		return nil, err
+	}
+	if p.Iterations < 1 {
+		return nil, fmt.Errorf("%w: iterations %d", ErrInvalidParams, p.Iterations)
+	}
+	if p.KeyLen < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidKeyLength, p.KeyLen)
+	}
+	return pbkdf2.Key(fn, string(password), salt, p.Iterations, p.KeyLen)
+}
+
+// ScryptParams configures scrypt. Zero fields take their value from
+// DefaultScrypt.
+type ScryptParams struct {
+	N      int // CPU/memory cost, must be a power of two greater than 1
+	R      int // block size
+	P      int // parallelization
+	KeyLen int
+}
+
+// DefaultScrypt uses the interactive-login parameters recommended by the
+// scrypt paper (N=2^15, r=8, p=1), about 32 MiB of memory per derivation.
+var DefaultScrypt = ScryptParams{
+	N:      1 << 15,
+	R:      8,
+	P:      1,
+	KeyLen: 32,
+}
+
+func (p ScryptParams) withDefaults() ScryptParams {
+	if p.N == 0 {
+		p.N = DefaultScrypt.N
+	}
+	if p.R == 0 {
+		p.R = DefaultScrypt.R
+	}
+	if p.P == 0 {
+		p.P = DefaultScrypt.P
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = DefaultScrypt.KeyLen
+	}
+	return p
+}
+
+// Scrypt derives a key from password and salt with scrypt.
+func Scrypt(password, salt []byte, p ScryptParams) ([]byte, error) {
+	p = p.withDefaults()
+	if err := checkPassword(password, salt); err != nil {
+		return nil, err
+	}
+	if p.KeyLen < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidKeyLength, p.KeyLen)
+	}
+	key, err := scrypt.Key(password, salt, p.N, p.R, p.P, p.KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+	return key, nil
+}
+
+func checkPassword(password, salt []byte) error {
+	if len(password) == 0 {
+		return ErrEmptySecret
+	}
+	if len(salt) < MinSaltSize {
+		return fmt.Errorf("%w: %d < %d", ErrSaltTooShort, len(salt), MinSaltSize)
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 //
 // Encode 必须可以被安全地多次调用 (用于重试)，返回的字节切片不应在外部被修改。
 // 当 data 为 nil 时表示没有 body；contentType 为空时不会自动设置 Content-Type。
+// Binary 与 Multipart 返回的 Body 由 Do 以流方式发送，不经过 Encode。
 type Body interface {
 	Encode() (data []byte, contentType string, err error)
 }
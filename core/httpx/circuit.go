@@ -0,0 +1,208 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kochabx/kit/core/breaker"
+)
+
+// ErrCircuitOpen host 的熔断器处于打开状态，请求未发送。
+var ErrCircuitOpen = errors.New("httpx: circuit open")
+
+// CircuitState 熔断器状态，见 breaker.State。
+type CircuitState = breaker.State
+
+const (
+	CircuitClosed   = breaker.StateClosed   // 关闭 (正常)
+	CircuitOpen     = breaker.StateOpen     // 打开 (熔断)
+	CircuitHalfOpen = breaker.StateHalfOpen // 半开 (探测恢复)
+)
+
+// CircuitConfig 按 host 熔断配置，见 WithCircuitBreaker。零值字段使用默认值。
+type CircuitConfig struct {
+	// MaxFailures 连续失败多少次后打开熔断，默认 5。
+	MaxFailures int
+	// OpenTimeout 熔断打开后经过多久进入半开状态，默认 30s。
+	OpenTimeout time.Duration
+	// SlowThreshold 单次发送 (到收到响应头) 耗时超过该值时视为失败，<=0 表示不按耗时判定。
+	// 耗时不包括限流与排队等待。
+	SlowThreshold time.Duration
+	// IsFailure 自定义失败判定，nil 时为网络错误 (context 错误除外) 与 5xx。
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange 熔断状态变化时回调，可用于日志与指标。
+	OnStateChange func(host string, from, to CircuitState)
+}
+
+func (c *CircuitConfig) setDefaults() {
+	if c.MaxFailures <= 0 {
+		c.MaxFailures = 5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 30 * time.Second
+	}
+	if c.IsFailure == nil {
+		c.IsFailure = defaultIsFailure
+	}
+}
+
+// WithCircuitBreaker 启用按 host 的熔断：
+//
+//   - 每个 host 独立的熔断器，连续 MaxFailures 次失败 (含慢响应) 后打开，
+//     打开期间发往该 host 的请求直接返回 ErrCircuitOpen，不占用连接
+//   - OpenTimeout 后进入半开状态，只放行一个探测请求：成功则关闭，失败则重新打开；
+//     探测期间的其他请求仍返回 ErrCircuitOpen
+//
+// 熔断作用于每一次实际发送 (包括重试)，检查位于限流与排队之前，
+// 默认重试策略不会重试 ErrCircuitOpen。当前熔断状态可通过 Stats 查看。
+func WithCircuitBreaker(cfg CircuitConfig) ClientOption {
+	return func(cli *Client) {
+		cfg.setDefaults()
+		cli.circuit = &circuitBreakers{cfg: cfg, hosts: make(map[string]*hostCircuit)}
+	}
+}
+
+// CircuitStats 单个 host 的熔断状态。
+type CircuitStats struct {
+	State    CircuitState // 当前状态
+	Failures int          // 当前连续失败次数
+	Rejected int64        // 累计因熔断被拒绝的请求数
+}
+
+// circuitBreakers 按 host 管理熔断器。
+type circuitBreakers struct {
+	cfg   CircuitConfig
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+// hostCircuit 单个 host 的熔断器。breaker.Breaker 在半开状态下放行所有请求，
+// 由 probing 保证同一时刻只有一个探测请求。
+type hostCircuit struct {
+	breaker  *breaker.Breaker
+	probing  atomic.Bool
+	rejected atomic.Int64
+}
+
+// circuitCall 记录一次发送的耗时，由内层中间件填写，见 circuitBreakers.timer。
+type circuitCall struct {
+	elapsed time.Duration
+}
+
+type circuitCallKey struct{}
+
+func (cb *circuitBreakers) host(host string) *hostCircuit {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	h, ok := cb.hosts[host]
+	if !ok {
+		h = &hostCircuit{breaker: breaker.New(true, cb.cfg.MaxFailures, cb.cfg.OpenTimeout)}
+		cb.hosts[host] = h
+	}
+	return h
+}
+
+// allow 判断能否向 host 发送，probe 表示本次为半开状态下的探测请求。
+func (cb *circuitBreakers) allow(host string, h *hostCircuit) (probe, ok bool) {
+	from := h.breaker.GetState()
+	if !h.breaker.Allow() {
+		h.rejected.Add(1)
+		return false, false
+	}
+	to := h.breaker.GetState()
+	cb.notify(host, from, to)
+	if to != CircuitHalfOpen {
+		return false, true
+	}
+	if !h.probing.CompareAndSwap(false, true) {
+		h.rejected.Add(1)
+		return false, false
+	}
+	return true, true
+}
+
+// observe 记录发送结果。context 错误既不计为成功也不计为失败。
+func (cb *circuitBreakers) observe(host string, h *hostCircuit, resp *http.Response, err error, elapsed time.Duration) {
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+	from := h.breaker.GetState()
+	failed := cb.cfg.IsFailure(resp, err) || (cb.cfg.SlowThreshold > 0 && elapsed > cb.cfg.SlowThreshold)
+	if failed {
+		h.breaker.RecordFailure()
+	} else {
+		h.breaker.RecordSuccess()
+	}
+	cb.notify(host, from, h.breaker.GetState())
+}
+
+func (cb *circuitBreakers) notify(host string, from, to CircuitState) {
+	if from != to && cb.cfg.OnStateChange != nil {
+		cb.cfg.OnStateChange(host, from, to)
+	}
+}
+
+func (cb *circuitBreakers) stats() map[string]CircuitStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	out := make(map[string]CircuitStats, len(cb.hosts))
+	for host, h := range cb.hosts {
+		out[host] = CircuitStats{
+			State:    h.breaker.GetState(),
+			Failures: h.breaker.GetFailures(),
+			Rejected: h.rejected.Load(),
+		}
+	}
+	return out
+}
+
+// middleware 在发送前检查熔断状态，并根据结果更新熔断器。
+func (cb *circuitBreakers) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		host := req.URL.Host
+		h := cb.host(host)
+		probe, ok := cb.allow(host, h)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+		}
+		if probe {
+			defer h.probing.Store(false)
+		}
+
+		call := &circuitCall{}
+		resp, err := next(req.WithContext(context.WithValue(req.Context(), circuitCallKey{}, call)))
+		if call.elapsed == 0 {
+			// 请求未发出 (如排队已满、并发已达上限)，不影响熔断状态
+			return resp, err
+		}
+		cb.observe(host, h, resp, err, call.elapsed)
+		return resp, err
+	}
+}
+
+// timer 位于中间件链最内层，记录实际发送的耗时，使 SlowThreshold 不受限流与排队等待影响。
+func (cb *circuitBreakers) timer(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		call, ok := req.Context().Value(circuitCallKey{}).(*circuitCall)
+		if !ok {
+			return next(req)
+		}
+		start := time.Now()
+		resp, err := next(req)
+		call.elapsed = max(time.Since(start), time.Nanosecond)
+		return resp, err
+	}
+}
+
+// defaultIsFailure 默认失败判定：网络错误或 5xx。
+func defaultIsFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp != nil && resp.StatusCode >= 500
+}
@@ -8,17 +8,29 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Client 是经过包装的 HTTP 客户端，提供：
 //   - body 类型驱动的 Content-Type
 //   - 基地址、默认请求头、超时
-//   - 中间件链 (RoundTripper)
+//   - 中间件链 (RoundTripper)，构造后可通过 Use 追加拦截器
 //   - 状态码错误化 (HTTPError)
-//   - 可选重试 + 退避
+//   - 可选重试 + 退避，可按请求覆盖策略 (WithRetryPolicy / Retry)
 //   - 链路解码 (Into / IntoJSON / IntoXML / IntoBytes / IntoString)
+//   - 流式上传与下载，不缓冲到内存 (Binary / Multipart / IntoWriter)
+//   - 按名称调用的请求模板 (WithCollection / Call)
+//   - 按 host 的自适应限流 (WithAdaptiveThrottle / Stats)
+//   - 按优先级排队的全局并发上限 (WithQueue / WithPriority)
+//   - 按 host 的熔断与在途请求上限 (WithCircuitBreaker / WithHostLimit)
+//   - 共享预算的并发请求 (All / Race / WithBudget)
+//   - 按 Link 头或游标自动翻页 (Paginate)
+//   - SSRF 防护 (WithSSRFProtection)
+//   - 请求 ID、W3C traceparent / baggage 与剩余超时自动传播 (WithPropagation 自定义，WithoutCorrelation 关闭)
 //
 // Client 在配置完成后是并发安全的。
 type Client struct {
@@ -27,15 +39,18 @@ type Client struct {
 	baseURL       string
 	defaultHeader http.Header
 	middlewares   []Middleware
+	mu            sync.Mutex                        // 串行化 Use
+	rt            atomic.Pointer[http.RoundTripper] // 当前的中间件链，Use 时整体替换
 	errorOnStatus func(int) bool
-	retry         retryConfig
-}
-
-// retryConfig 重试配置。MaxAttempts <= 1 表示不重试。
-type retryConfig struct {
-	MaxAttempts int
-	Backoff     BackoffFunc
-	RetryOn     func(resp *http.Response, err error) bool
+	retry         RetryPolicy       // 默认重试策略，见 WithRetryPolicy
+	collection    *Collection       // 请求模板，见 WithCollection / Call
+	throttle      *throttler        // 自适应限流，见 WithAdaptiveThrottle
+	queue         *requestQueue     // 优先级队列，见 WithQueue
+	circuit       *circuitBreakers  // 按 host 熔断，见 WithCircuitBreaker
+	hostLimit     *hostLimiter      // 按 host 在途上限，见 WithHostLimit
+	ssrf          *ssrfGuard        // SSRF 防护，见 WithSSRFProtection
+	propagation   PropagationConfig // 上下文传播，见 WithPropagation
+	noCorrelation bool              // 不传播上下文，见 WithoutCorrelation
 }
 
 // BackoffFunc 返回第 attempt 次失败后 (attempt 从 1 开始) 应等待的时长。
@@ -89,18 +104,17 @@ func WithErrorOnStatus(fn func(int) bool) ClientOption {
 
 // WithRetry 启用重试。maxAttempts 包含首次尝试在内 (即 3 表示最多 3 次)。
 // backoff 为 nil 时不等待。retryOn 决定何时重试，nil 时使用默认策略
-// (网络错误或 5xx / 429)。
+// (网络错误或 5xx / 429)。遵循 Retry-After。
 //
-// 重试要求请求 body 可以重放。本库提供的 Body 构造器 (JSON/XML/Form/Raw/Text/ReadAll)
-// 均会先把 body 完整缓存，因此天然支持重试。
+// 与既有行为一致，WithRetry 对所有方法重试 (即 RetryNonIdempotent)；
+// 需要避免重复执行 POST / PATCH 时使用 WithRetryPolicy，其默认只重试幂等请求。
 func WithRetry(maxAttempts int, backoff BackoffFunc, retryOn func(*http.Response, error) bool) ClientOption {
-	return func(cli *Client) {
-		cli.retry = retryConfig{
-			MaxAttempts: maxAttempts,
-			Backoff:     backoff,
-			RetryOn:     retryOn,
-		}
-	}
+	return WithRetryPolicy(RetryPolicy{
+		MaxAttempts:        maxAttempts,
+		Backoff:            backoff,
+		RetryOn:            retryOn,
+		RetryNonIdempotent: true,
+	})
 }
 
 // ExpBackoff 返回指数退避函数：base, 2*base, 4*base, ...
@@ -123,19 +137,69 @@ func New(opts ...ClientOption) *Client {
 	for _, opt := range opts {
 		opt(c)
 	}
-	// 装配 transport + 中间件
-	c.httpClient.Transport = chain(c.transport, c.middlewares)
+	if c.ssrf != nil {
+		c.transport = c.ssrf.transport(c.transport)
+	}
+	c.propagation.setDefaults()
+	c.rechain()
+	c.httpClient.Transport = RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return (*c.rt.Load()).RoundTrip(req)
+	})
 	return c
 }
 
+// Use 追加拦截器，位于已注册中间件的内层，用于构造后统一加入横切逻辑
+// (认证头注入、日志、指标、链路追踪等)，无需包装每个调用点：
+//
+//	client.Use(func(next httpx.RoundTripFunc) httpx.RoundTripFunc {
+//	    return func(req *http.Request) (*http.Response, error) {
+//	        req.Header.Set("Authorization", "Bearer "+tokens.Current())
+//	        return next(req)
+//	    }
+//	})
+//
+// 拦截器作用于每次实际发送 (重试时每次尝试都会经过)，可与请求并发调用：
+// 已发出的请求不受影响，之后的请求使用新的链。
+func (c *Client) Use(interceptors ...Interceptor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middlewares = append(slices.Clip(c.middlewares), interceptors...)
+	c.rechain()
+}
+
+// rechain 装配 transport + 中间件，SSRF 校验、熔断、限流与排队位于最内层以观察到每次实际发送。
+func (c *Client) rechain() {
+	mws := slices.Clip(c.middlewares)
+	if c.ssrf != nil {
+		mws = append(mws, c.ssrf.middleware)
+	}
+	if c.circuit != nil {
+		mws = append(mws, c.circuit.middleware)
+	}
+	if c.throttle != nil {
+		mws = append(mws, c.throttle.middleware)
+	}
+	if c.hostLimit != nil {
+		mws = append(mws, c.hostLimit.middleware)
+	}
+	if c.queue != nil {
+		mws = append(mws, c.queue.middleware)
+	}
+	if c.circuit != nil {
+		mws = append(mws, c.circuit.timer)
+	}
+	rt := chain(c.transport, mws)
+	c.rt.Store(&rt)
+}
+
 // defaultErrorOnStatus 默认错误判定：4xx / 5xx。
 func defaultErrorOnStatus(code int) bool { return code >= 400 }
 
 // defaultRetryOn 默认重试判定：网络错误，或 5xx，或 429。
 func defaultRetryOn(resp *http.Response, err error) bool {
 	if err != nil {
-		// context 错误不重试
-		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// context 错误与熔断不重试
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
 			return false
 		}
 		return true
@@ -162,28 +226,45 @@ func (c *Client) Do(ctx context.Context, method, target string, body Body, opts
 	for _, opt := range opts {
 		opt(cfg)
 	}
+	if cfg.priority != nil {
+		ctx = ContextWithPriority(ctx, *cfg.priority)
+	}
 
 	// 1. 解析 URL
+	if len(cfg.params) > 0 {
+		rendered, err := renderPath(target, cfg.params)
+		if err != nil {
+			return nil, err
+		}
+		target = rendered
+	}
 	fullURL, err := c.resolveURL(target, cfg.query)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. 编码 body
-	var bodyBytes []byte
+	// 2. 编码 body (流式请求体在每次发送时打开)
+	var payload requestBody
 	var bodyContentType string
-	if body != nil {
-		bodyBytes, bodyContentType, err = body.Encode()
+	switch b := body.(type) {
+	case nil:
+	case *streamBody:
+		payload.stream, bodyContentType = b, b.contentType
+	default:
+		payload.data, bodyContentType, err = body.Encode()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	// 3. 合并 header (默认 < body 推断 < 用户显式)
-	header := make(http.Header, len(c.defaultHeader)+len(cfg.header)+1)
+	// 3. 合并 header (默认 < 关联 ID < body 推断 < 用户显式)
+	header := make(http.Header, len(c.defaultHeader)+len(cfg.header)+3)
 	for k, vs := range c.defaultHeader {
 		header[k] = append([]string(nil), vs...)
 	}
+	if !c.noCorrelation {
+		c.propagation.injectCorrelation(ctx, header)
+	}
 	if bodyContentType != "" && header.Get("Content-Type") == "" {
 		header.Set("Content-Type", bodyContentType)
 	}
@@ -192,7 +273,11 @@ func (c *Client) Do(ctx context.Context, method, target string, body Body, opts
 	}
 
 	// 4. 执行 (含重试)
-	resp, err := c.doWithRetry(ctx, method, fullURL, header, bodyBytes)
+	policy := &c.retry
+	if cfg.retry != nil {
+		policy = cfg.retry
+	}
+	resp, err := c.doWithRetry(ctx, policy, method, fullURL, header, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -245,26 +330,46 @@ func (c *Client) resolveURL(target string, extraQuery url.Values) (string, error
 	return u.String(), nil
 }
 
-// doWithRetry 在需要时重试。每次重试都会重建 *http.Request 以便重放 body。
-func (c *Client) doWithRetry(ctx context.Context, method, fullURL string, header http.Header, bodyBytes []byte) (*http.Response, error) {
-	maxAttempts := c.retry.MaxAttempts
-	if maxAttempts < 1 {
+// requestBody 单次 Do 的请求体，data 与 stream 至多一个非空。
+type requestBody struct {
+	data   []byte
+	stream *streamBody
+}
+
+// doWithRetry 按 policy 在需要时重试。每次重试都会重建 *http.Request 以便重放 body，
+// 不可重放的流式请求体只发送一次。
+func (c *Client) doWithRetry(ctx context.Context, policy *RetryPolicy, method, fullURL string, header http.Header, body requestBody) (*http.Response, error) {
+	maxAttempts := max(policy.MaxAttempts, 1)
+	if body.stream != nil && !body.stream.replayable() {
 		maxAttempts = 1
 	}
-	retryOn := c.retry.RetryOn
-	if retryOn == nil {
-		retryOn = defaultRetryOn
-	}
 
 	var lastResp *http.Response
 	var lastErr error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		req, err := c.buildRequest(ctx, method, fullURL, header, bodyBytes)
+		req, err := c.buildRequest(ctx, method, fullURL, header, body)
 		if err != nil {
 			return nil, err
 		}
 		lastResp, lastErr = c.httpClient.Do(req)
-		if attempt == maxAttempts || !retryOn(lastResp, lastErr) {
+
+		retry := attempt < maxAttempts && policy.shouldRetry(method, header, lastResp, lastErr)
+		var wait time.Duration
+		if retry {
+			wait, retry = policy.wait(attempt, lastResp)
+		}
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(RetryAttempt{
+				Attempt:  attempt,
+				Method:   method,
+				URL:      fullURL,
+				Response: lastResp,
+				Err:      lastErr,
+				Retry:    retry,
+				Wait:     wait,
+			})
+		}
+		if !retry {
 			break
 		}
 		// 失败的响应必须先排空 + 关闭，才能重用连接
@@ -274,9 +379,9 @@ func (c *Client) doWithRetry(ctx context.Context, method, fullURL string, header
 			lastResp = nil
 		}
 		// 退避
-		if c.retry.Backoff != nil {
+		if wait > 0 {
 			select {
-			case <-time.After(c.retry.Backoff(attempt)):
+			case <-time.After(wait):
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			}
@@ -286,22 +391,48 @@ func (c *Client) doWithRetry(ctx context.Context, method, fullURL string, header
 }
 
 // buildRequest 构造单次 *http.Request。
-func (c *Client) buildRequest(ctx context.Context, method, fullURL string, header http.Header, bodyBytes []byte) (*http.Request, error) {
+func (c *Client) buildRequest(ctx context.Context, method, fullURL string, header http.Header, body requestBody) (*http.Request, error) {
 	var bodyReader io.Reader
-	if bodyBytes != nil {
-		bodyReader = bytes.NewReader(bodyBytes)
+	var size int64
+	var getBody func() (io.ReadCloser, error)
+	switch {
+	case body.stream != nil:
+		rc, n, err := body.stream.reopen()
+		if err != nil {
+			return nil, err
+		}
+		bodyReader, size = rc, n
+		if body.stream.replayable() {
+			getBody = func() (io.ReadCloser, error) {
+				rc, _, err := body.stream.reopen()
+				return rc, err
+			}
+		}
+	case body.data != nil:
+		data := body.data
+		bodyReader, size = bytes.NewReader(data), int64(len(data))
+		getBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
 	}
+
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 	if err != nil {
+		if rc, ok := bodyReader.(io.Closer); ok {
+			_ = rc.Close()
+		}
 		return nil, fmt.Errorf("httpx: build request: %w", err)
 	}
 	req.Header = header.Clone()
-	if bodyBytes != nil {
+	if !c.noCorrelation {
+		// 剩余超时按每次尝试重新计算
+		c.propagation.injectTimeout(ctx, req.Header, c.httpClient.Timeout)
+	}
+	if bodyReader != nil {
+		// 流式请求体长度未知 (-1) 时以 chunked 方式发送；
 		// 提供 GetBody 让 stdlib 在需要时也能重放 (如 307/308 重定向)
-		req.ContentLength = int64(len(bodyBytes))
-		req.GetBody = func() (io.ReadCloser, error) {
-			return io.NopCloser(bytes.NewReader(bodyBytes)), nil
-		}
+		req.ContentLength = size
+		req.GetBody = getBody
 	}
 	return req, nil
 }
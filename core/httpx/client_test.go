@@ -7,10 +7,17 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+
+	kitctx "github.com/kochabx/kit/core/x/ctx"
 )
 
 type echo struct {
@@ -284,6 +291,222 @@ func TestClient_Retry_Exhausted(t *testing.T) {
 	}
 }
 
+func TestClient_Retry_NonIdempotent(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+
+	// WithRetry 保持原有语义：POST 无 Idempotency-Key 时同样重试
+	retryOn := func(resp *http.Response, err error) bool { return resp != nil && resp.StatusCode == 503 }
+	for _, c := range []*Client{New(WithRetry(3, nil, nil)), New(WithRetry(3, nil, retryOn))} {
+		atomic.StoreInt32(&calls, 0)
+		_, _ = c.Post(context.Background(), srv.URL+"/", Text("x"))
+		if got := atomic.LoadInt32(&calls); got != 3 {
+			t.Errorf("calls = %d, want 3", got)
+		}
+	}
+}
+
+func TestClient_RetryPolicy_Idempotency(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name   string
+		policy RetryPolicy
+		opts   []RequestOption
+		want   int32
+	}{
+		// 默认不重试 POST
+		{"post", RetryPolicy{MaxAttempts: 3}, nil, 1},
+		{"idempotency key", RetryPolicy{MaxAttempts: 3}, []RequestOption{SetHeader("Idempotency-Key", "k1")}, 3},
+		{"non-idempotent allowed", RetryPolicy{MaxAttempts: 3, RetryNonIdempotent: true}, nil, 3},
+	}
+	for _, tc := range cases {
+		atomic.StoreInt32(&calls, 0)
+		c := New(WithRetryPolicy(tc.policy))
+		_, _ = c.Post(context.Background(), srv.URL+"/", Text("x"), tc.opts...)
+		if got := atomic.LoadInt32(&calls); got != tc.want {
+			t.Errorf("%s: calls = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestClient_RetryPolicy_PerRequestAndHook(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer srv.Close()
+
+	var attempts []RetryAttempt
+	c := New(WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		RetryStatus: []int{http.StatusConflict},
+		Backoff:     func(int) time.Duration { return time.Millisecond },
+		OnAttempt:   func(a RetryAttempt) { attempts = append(attempts, a) },
+	}))
+	_, err := c.Get(context.Background(), srv.URL+"/")
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 HTTPError, got %v", err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(attempts))
+	}
+	for i, a := range attempts {
+		last := i == len(attempts)-1
+		if a.Attempt != i+1 || a.Method != http.MethodGet || a.Response == nil || a.Response.StatusCode != http.StatusConflict || a.Retry == last {
+			t.Errorf("attempt %d = %+v", i, a)
+		}
+		if !last && a.Wait != time.Millisecond {
+			t.Errorf("attempt %d wait = %v", i, a.Wait)
+		}
+	}
+
+	// 单次请求覆盖：禁用重试
+	atomic.StoreInt32(&calls, 0)
+	_, _ = c.Get(context.Background(), srv.URL+"/", NoRetry())
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("NoRetry: calls = %d, want 1", got)
+	}
+
+	// 单次请求覆盖：409 不在 RetryStatus 中
+	atomic.StoreInt32(&calls, 0)
+	_, _ = c.Get(context.Background(), srv.URL+"/", Retry(RetryPolicy{MaxAttempts: 5, RetryStatus: []int{503}}))
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Retry override: calls = %d, want 1", got)
+	}
+}
+
+func TestClient_RetryPolicy_RetryAfter(t *testing.T) {
+	var calls int32
+	var second time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		second = time.Now()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := New(WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+	start := time.Now()
+	if _, err := c.Get(context.Background(), srv.URL+"/"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if d := second.Sub(start); d < 900*time.Millisecond {
+		t.Errorf("retry sent after %v, want Retry-After honored", d)
+	}
+
+	// Retry-After 超出上限时直接返回
+	atomic.StoreInt32(&calls, 0)
+	_, err := c.Get(context.Background(), srv.URL+"/", Retry(RetryPolicy{MaxAttempts: 2, MaxRetryAfter: 100 * time.Millisecond}))
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 HTTPError, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestExpJitterBackoff(t *testing.T) {
+	b := ExpJitterBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for range 100 {
+		if d := b(1); d < 0 || d > 10*time.Millisecond {
+			t.Fatalf("attempt 1: %v out of range", d)
+		}
+		if d := b(10); d < 0 || d > 50*time.Millisecond {
+			t.Fatalf("attempt 10: %v exceeds cap", d)
+		}
+	}
+	if d := ExpJitterBackoff(0, 0)(3); d != 0 {
+		t.Errorf("zero base: %v", d)
+	}
+}
+
+func TestClient_AdaptiveThrottle(t *testing.T) {
+	var calls int32
+	var second time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		second = time.Now()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := New(
+		WithRetry(2, nil, nil),
+		WithAdaptiveThrottle(ThrottleConfig{Rate: 100, Recovery: time.Hour}),
+	)
+	start := time.Now()
+	if _, err := c.Get(context.Background(), srv.URL+"/"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if d := second.Sub(start); d < 900*time.Millisecond {
+		t.Errorf("retry sent after %v, want Retry-After honored", d)
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	st, ok := c.Stats().Hosts[host]
+	if !ok {
+		t.Fatalf("no stats for %s: %+v", host, c.Stats())
+	}
+	if st.Throttled != 1 {
+		t.Errorf("Throttled = %d, want 1", st.Throttled)
+	}
+	if st.Factor < 0.49 || st.Factor > 0.51 {
+		t.Errorf("Factor = %v, want ~0.5", st.Factor)
+	}
+
+	// 暂停期间 ctx 超时应直接返回
+	atomic.StoreInt32(&calls, 0)
+	c = New(WithAdaptiveThrottle(ThrottleConfig{}))
+	_, _ = c.Get(context.Background(), srv.URL+"/")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx, srv.URL+"/"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"-1", 0, false},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{"soon", 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseRetryAfter(tc.in, now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
 func TestClient_Middleware_Order(t *testing.T) {
 	srv := newEchoServer(t)
 	defer srv.Close()
@@ -311,6 +534,66 @@ func TestClient_Middleware_Order(t *testing.T) {
 	}
 }
 
+func TestClient_Use(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var order []string
+	mw := func(name string) Interceptor {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				return next(req)
+			}
+		}
+	}
+
+	c := New(WithMiddleware(mw("A")), WithRetry(2, nil, nil))
+	c.Use(mw("B"), func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer t1")
+			return next(req)
+		}
+	})
+
+	var got string
+	if _, err := c.Get(context.Background(), srv.URL+"/", IntoString(&got)); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got != "Bearer t1" {
+		t.Errorf("Authorization = %q", got)
+	}
+	// 拦截器位于已注册中间件内层，每次尝试都会经过
+	if want := "A,B,A,B"; strings.Join(order, ",") != want {
+		t.Errorf("order = %v, want %s", order, want)
+	}
+
+	// 与请求并发调用 Use
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				c.Use(mw("C"))
+				return
+			}
+			_, _ = c.Get(context.Background(), srv.URL+"/")
+		}()
+	}
+	wg.Wait()
+}
+
 func TestClient_IntoBytesAndString(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("hello"))
@@ -356,6 +639,131 @@ func TestClient_RawBody(t *testing.T) {
 	}
 }
 
+func TestClient_MultipartBody(t *testing.T) {
+	type upload struct {
+		length int64
+		fields map[string]string
+		files  map[string]string
+	}
+	var got upload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = upload{length: r.ContentLength, fields: map[string]string{}, files: map[string]string{}}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		for k, vs := range r.MultipartForm.Value {
+			got.fields[k] = vs[0]
+		}
+		for k, fhs := range r.MultipartForm.File {
+			f, _ := fhs[0].Open()
+			data, _ := io.ReadAll(f)
+			f.Close()
+			got.files[k] = fhs[0].Filename + ":" + fhs[0].Header.Get("Content-Type") + ":" + string(data)
+		}
+	}))
+	defer srv.Close()
+
+	body := Multipart(map[string]string{"name": "report", "year": "2024"},
+		File{Field: "doc", Name: "a.txt", ContentType: "text/plain", Reader: strings.NewReader("hello")},
+		File{Field: "raw", Name: `b"c.bin`, Reader: strings.NewReader("world")},
+	)
+	resp, err := New().Post(context.Background(), srv.URL, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.fields["name"] != "report" || got.fields["year"] != "2024" {
+		t.Errorf("fields = %v", got.fields)
+	}
+	if got.files["doc"] != "a.txt:text/plain:hello" || got.files["raw"] != `b"c.bin:application/octet-stream:world` {
+		t.Errorf("files = %v", got.files)
+	}
+	// 文件长度可知时设置 Content-Length
+	if got.length <= 0 {
+		t.Errorf("ContentLength = %d, want known length", got.length)
+	}
+
+	// 长度未知时以 chunked 发送
+	body = Multipart(nil, File{Field: "doc", Name: "a.txt", Reader: struct{ io.Reader }{strings.NewReader("stream")}})
+	resp, err = New().Post(context.Background(), srv.URL, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.length != -1 || got.files["doc"] != "a.txt:application/octet-stream:stream" {
+		t.Errorf("chunked upload = %+v", got)
+	}
+}
+
+func TestClient_BinaryBody_Retry(t *testing.T) {
+	var calls int32
+	var bodies []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Header.Get("Content-Type")+":"+string(data))
+		mu.Unlock()
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(503)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(WithRetry(3, nil, nil))
+
+	// 可 Seek 的 reader 每次重试从头发送
+	r := strings.NewReader("xxpayload")
+	_, _ = r.Seek(2, io.SeekStart)
+	resp, err := c.Put(context.Background(), srv.URL, Binary("", r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	mu.Lock()
+	if len(bodies) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(bodies))
+	}
+	for _, b := range bodies {
+		if b != "application/octet-stream:payload" {
+			t.Errorf("body = %q", b)
+		}
+	}
+	bodies = nil
+	mu.Unlock()
+
+	// 不可 Seek 的 reader 只发送一次
+	atomic.StoreInt32(&calls, 0)
+	_, err = c.Put(context.Background(), srv.URL, Binary("video/mp4", struct{ io.Reader }{strings.NewReader("once")}))
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != 503 {
+		t.Fatalf("expected 503 HTTPError, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+	if len(bodies) != 1 || bodies[0] != "video/mp4:once" {
+		t.Errorf("bodies = %q", bodies)
+	}
+}
+
+func TestClient_IntoWriter(t *testing.T) {
+	payload := strings.Repeat("0123456789", 10000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, payload)
+	}))
+	defer srv.Close()
+
+	var buf strings.Builder
+	if _, err := New().Get(context.Background(), srv.URL, IntoWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != payload {
+		t.Errorf("downloaded %d bytes, want %d", buf.Len(), len(payload))
+	}
+}
+
 func TestClient_NilContext(t *testing.T) {
 	c := New()
 	var ctx context.Context // intentionally nil
@@ -364,3 +772,709 @@ func TestClient_NilContext(t *testing.T) {
 		t.Fatal("expected error for nil context")
 	}
 }
+
+func TestClient_CallTemplate(t *testing.T) {
+	srv := newEchoServer(t)
+	defer srv.Close()
+
+	col, err := LoadCollection(strings.NewReader(`
+templates:
+  - name: createUser
+    method: post
+    path: /tenants/{tenant}/users
+    query:
+      notify: "{notify}"
+      source: api
+    body:
+      fields:
+        - name: name
+          required: true
+        - name: role
+          default: member
+`))
+	if err != nil {
+		t.Fatalf("LoadCollection failed: %v", err)
+	}
+
+	c := New(WithBaseURL(srv.URL), WithCollection(col))
+	var got echo
+	_, err = c.Call(context.Background(), "createUser", Vars{"tenant": "a b", "name": "bob"}, &got)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if got.Method != "POST" || got.Path != "/tenants/a b/users" {
+		t.Errorf("method/path = %s %s", got.Method, got.Path)
+	}
+	if got.Query != "source=api" {
+		t.Errorf("query = %q", got.Query)
+	}
+	if got.CT != ContentTypeJSON {
+		t.Errorf("CT = %q", got.CT)
+	}
+	var body map[string]string
+	if err := json.Unmarshal([]byte(got.Body), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["name"] != "bob" || body["role"] != "member" {
+		t.Errorf("body = %v", body)
+	}
+
+	_, err = c.Call(context.Background(), "createUser", Vars{"tenant": "a"}, nil)
+	if !errors.Is(err, ErrMissingVar) {
+		t.Errorf("expected ErrMissingVar, got %v", err)
+	}
+	_, err = c.Call(context.Background(), "deleteUser", nil, nil)
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+}
+
+func TestNewCollection_Invalid(t *testing.T) {
+	if _, err := NewCollection(Template{Name: "a", Method: "GET", Path: "/"}, Template{Name: "a", Method: "GET", Path: "/"}); err == nil {
+		t.Error("expected duplicate template error")
+	}
+	if _, err := NewCollection(Template{Name: "a", Method: "GET"}); err == nil {
+		t.Error("expected missing path error")
+	}
+	if _, err := NewCollection(Template{Name: "a", Method: "POST", Path: "/", Body: &BodySchema{Type: "xml"}}); err == nil {
+		t.Error("expected unsupported body type error")
+	}
+}
+
+func newParallelServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"path": r.URL.Path})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_All(t *testing.T) {
+	srv := newParallelServer(t)
+	c := New(WithBaseURL(srv.URL))
+
+	var a map[string]string
+	results, err := c.WithBudget(Budget{MaxFailures: -1}).All(context.Background(),
+		NewRequest(http.MethodGet, "/a", nil, IntoJSON(&a)),
+		NewRequest(http.MethodGet, "/b", nil),
+		NewRequest(http.MethodGet, "/fail", nil),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d", len(results))
+	}
+	if results[0].Err != nil || a["path"] != "/a" {
+		t.Errorf("results[0] = %+v, decoded %v", results[0], a)
+	}
+	// 未指定解码的响应体在返回后仍然可读
+	body, _ := io.ReadAll(results[1].Response.Body)
+	if results[1].Err != nil || !strings.Contains(string(body), "/b") {
+		t.Errorf("results[1] = %+v, body %q", results[1], body)
+	}
+	if !errors.Is(results[2].Err, &HTTPError{StatusCode: 500}) {
+		t.Errorf("results[2].Err = %v, want HTTPError 500", results[2].Err)
+	}
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("results[%d].Index = %d", i, r.Index)
+		}
+	}
+}
+
+func TestClient_All_BudgetExceeded(t *testing.T) {
+	srv := newParallelServer(t)
+	c := New(WithBaseURL(srv.URL))
+
+	start := time.Now()
+	results, err := c.All(context.Background(),
+		NewRequest(http.MethodGet, "/slow", nil),
+		NewRequest(http.MethodGet, "/fail", nil),
+	)
+	if !errors.Is(err, ErrBudgetExceeded) || !errors.Is(err, &HTTPError{StatusCode: 500}) {
+		t.Fatalf("err = %v, want ErrBudgetExceeded wrapping HTTPError", err)
+	}
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("slow request err = %v, want context.Canceled", results[0].Err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("slow request not cancelled, took %v", elapsed)
+	}
+}
+
+func TestClient_All_Timeout(t *testing.T) {
+	srv := newParallelServer(t)
+	c := New(WithBaseURL(srv.URL))
+
+	results, err := c.WithBudget(Budget{Timeout: 50 * time.Millisecond, MaxFailures: 1}).All(context.Background(),
+		NewRequest(http.MethodGet, "/a", nil),
+		NewRequest(http.MethodGet, "/slow", nil),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Err != nil {
+		t.Errorf("fast request err = %v", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, context.DeadlineExceeded) {
+		t.Errorf("slow request err = %v, want context.DeadlineExceeded", results[1].Err)
+	}
+}
+
+func TestClient_Race(t *testing.T) {
+	srv := newParallelServer(t)
+	c := New(WithBaseURL(srv.URL))
+
+	start := time.Now()
+	var out map[string]string
+	r, err := c.Race(context.Background(),
+		NewRequest(http.MethodGet, "/slow", nil),
+		NewRequest(http.MethodGet, "/fail", nil),
+		NewRequest(http.MethodGet, "/fast", nil, IntoJSON(&out)),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Index != 2 || out["path"] != "/fast" {
+		t.Errorf("winner = %d, decoded %v", r.Index, out)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("losers not cancelled, took %v", elapsed)
+	}
+
+	r, err = c.Race(context.Background(),
+		NewRequest(http.MethodGet, "/fail", nil),
+		NewRequest(http.MethodGet, "/fail", nil),
+	)
+	if err == nil || r.Index != -1 || !errors.Is(err, &HTTPError{StatusCode: 500}) {
+		t.Errorf("all failed: result %+v, err %v", r, err)
+	}
+
+	if _, err := c.Race(context.Background()); !errors.Is(err, ErrNoRequests) {
+		t.Errorf("no requests: err = %v", err)
+	}
+}
+
+func TestClient_Race_Sequential(t *testing.T) {
+	var calls []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/primary" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	c := New(WithBaseURL(srv.URL))
+
+	r, err := c.WithBudget(Budget{Concurrency: 1}).Race(context.Background(),
+		NewRequest(http.MethodGet, "/primary", nil),
+		NewRequest(http.MethodGet, "/secondary", nil),
+		NewRequest(http.MethodGet, "/tertiary", nil),
+	)
+	if err != nil || r.Index != 1 {
+		t.Fatalf("result %+v, err %v", r, err)
+	}
+	if strings.Join(calls, ",") != "/primary,/secondary" {
+		t.Errorf("calls = %v, want fallback in order", calls)
+	}
+}
+
+func TestClient_PriorityQueue(t *testing.T) {
+	unblock := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			<-unblock
+		}
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var waited atomic.Int64
+	c := New(WithQueue(QueueConfig{
+		MaxConcurrent: 1,
+		MaxQueued:     2,
+		OnWait:        func(Priority, time.Duration) { waited.Add(1) },
+	}))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	send := func(path string, p Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Get(ctx, srv.URL+path, WithPriority(p), IntoBytes(new([]byte))); err != nil {
+				t.Errorf("Get %s: %v", path, err)
+			}
+		}()
+	}
+	waitQueued := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for c.Stats().Queue.Queued != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("Queued = %d, want %d", c.Stats().Queue.Queued, n)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// 占满名额后依次排入批处理与交互请求
+	send("/block", PriorityNormal)
+	for c.Stats().Queue.Running != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	send("/batch", PriorityBatch)
+	waitQueued(1)
+	send("/interactive", PriorityInteractive)
+	waitQueued(2)
+
+	// 队列已满时立即拒绝
+	if _, err := c.Get(ctx, srv.URL+"/rejected"); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("err = %v, want ErrQueueFull", err)
+	}
+
+	// 排队期间 ctx 取消
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	c2 := New(WithQueue(QueueConfig{MaxConcurrent: 1}))
+	resp, err := c2.Get(ctx, srv.URL+"/hold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c2.Get(cctx, srv.URL+"/cancelled"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+	resp.Body.Close()
+	if st := c2.Stats().Queue; st.Running != 0 || st.Queued != 0 {
+		t.Errorf("c2 queue = %+v, want idle", st)
+	}
+
+	close(unblock)
+	wg.Wait()
+
+	mu.Lock()
+	got := strings.Join(order, ",")
+	mu.Unlock()
+	if !strings.HasSuffix(got, "/block,/interactive,/batch") {
+		t.Errorf("order = %s, want interactive before batch", got)
+	}
+	st := c.Stats().Queue
+	if st.Running != 0 || st.Queued != 0 {
+		t.Errorf("queue = %+v, want idle", st)
+	}
+	if st.Waits[PriorityBatch].Count != 1 || st.Waits[PriorityBatch].Max <= 0 || st.Waits[PriorityNormal].Rejected != 1 {
+		t.Errorf("waits = %+v", st.Waits)
+	}
+	if waited.Load() != 3 {
+		t.Errorf("OnWait called %d times, want 3", waited.Load())
+	}
+}
+
+func TestClient_Correlation(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9}, SpanID: trace.SpanID{0x01}, TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(kitctx.WithRequestID(context.Background(), "req-1"), sc)
+
+	resp, err := New().Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Request-ID") != "req-1" || got.Get("traceparent") != "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01" {
+		t.Errorf("correlation headers not propagated: %v", got)
+	}
+
+	// 显式请求头优先
+	resp, err = New().Get(ctx, srv.URL, SetHeader("X-Request-ID", "explicit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Request-ID") != "explicit" {
+		t.Errorf("explicit header should win, got %q", got.Get("X-Request-ID"))
+	}
+
+	resp, err = New(WithoutCorrelation()).Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Request-ID") != "" || got.Get("traceparent") != "" {
+		t.Errorf("WithoutCorrelation should not send correlation headers: %v", got)
+	}
+}
+
+func TestClient_Propagation(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	member, _ := baggage.NewMember("tenant", "acme")
+	bag, _ := baggage.New(member)
+	ctx := baggage.ContextWithBaggage(kitctx.WithRequestID(context.Background(), "req-1"), bag)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	resp, err := New().Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("baggage") != "tenant=acme" {
+		t.Errorf("baggage = %q", got.Get("baggage"))
+	}
+	ms, err := strconv.Atoi(got.Get(HeaderRequestTimeout))
+	if err != nil || ms <= 1000 || ms > 2000 {
+		t.Errorf("%s = %q, want remaining ms of ctx deadline", HeaderRequestTimeout, got.Get(HeaderRequestTimeout))
+	}
+
+	// WithTimeout 比 ctx 更短时取较小者
+	resp, err = New(WithTimeout(500*time.Millisecond)).Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ms, _ := strconv.Atoi(got.Get(HeaderRequestTimeout)); ms > 500 {
+		t.Errorf("%s = %d, want <= 500", HeaderRequestTimeout, ms)
+	}
+
+	// 没有任何超时时不发送
+	resp, err = New().Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if v := got.Get(HeaderRequestTimeout); v != "" {
+		t.Errorf("no deadline should not send %s, got %q", HeaderRequestTimeout, v)
+	}
+
+	// 自定义请求头名称与注入钩子
+	cli := New(WithPropagation(PropagationConfig{
+		RequestIDHeader: "X-Correlation-ID",
+		TimeoutHeader:   "X-Deadline",
+		FormatTimeout:   func(d time.Duration) string { return "set" },
+		Inject: func(ctx context.Context, h http.Header) {
+			h.Set("X-Tenant", baggage.FromContext(ctx).Member("tenant").Value())
+		},
+	}))
+	resp, err = cli.Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Correlation-ID") != "req-1" || got.Get("X-Request-ID") != "" ||
+		got.Get("X-Deadline") != "set" || got.Get(HeaderRequestTimeout) != "" || got.Get("X-Tenant") != "acme" {
+		t.Errorf("custom propagation headers = %v", got)
+	}
+
+	resp, err = New(WithPropagation(PropagationConfig{DisableTimeout: true})).Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get(HeaderRequestTimeout) != "" || got.Get("baggage") == "" {
+		t.Errorf("DisableTimeout should only drop the timeout header: %v", got)
+	}
+
+	resp, err = New(WithoutCorrelation()).Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("baggage") != "" || got.Get(HeaderRequestTimeout) != "" {
+		t.Errorf("WithoutCorrelation should not send propagation headers: %v", got)
+	}
+}
+
+func TestLinkNext(t *testing.T) {
+	cases := map[string]string{
+		`<https://a.example/items?page=2>; rel="next", <https://a.example/items?page=9>; rel="last"`: "https://a.example/items?page=2",
+		`<https://a.example/items?page=1>; rel="prev"`:                                               "",
+		`</items?page=3>; rel="last next"`:                                                           "/items?page=3",
+		`<https://a.example/items?page=4>;REL=next`:                                                  "https://a.example/items?page=4",
+		`https://a.example/items?page=5; rel="next"`:                                                 "",
+	}
+	for link, want := range cases {
+		h := http.Header{}
+		h.Set("Link", link)
+		if got := linkNext(h); got != want {
+			t.Errorf("linkNext(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+type itemPage struct {
+	Items  []int  `json:"items"`
+	Cursor string `json:"cursor"`
+}
+
+func TestPaginate_Link(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		if r.Header.Get("Authorization") != "Bearer tok" || r.URL.Query()["limit"][0] != "2" || len(r.URL.Query()["limit"]) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `</items?limit=2&page=2>; rel="next"`)
+			_, _ = w.Write([]byte(`{"items":[1,2]}`))
+		case "2":
+			if calls.Load() == 2 {
+				// 首次请求第 2 页时被限流
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Link", `</items?limit=2&page=3>; rel="next"`)
+			_, _ = w.Write([]byte(`{"items":[3,4]}`))
+		default:
+			_, _ = w.Write([]byte(`{"items":[5]}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := New(WithBaseURL(srv.URL))
+	var got []int
+	err := Paginate(context.Background(), c, "/items", LinkNext[itemPage](), func(p itemPage) error {
+		got = append(got, p.Items...)
+		return nil
+	}, Bearer("tok"), Query("limit", "2"))
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+	if len(got) != 5 || got[4] != 5 {
+		t.Errorf("items = %v", got)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("calls = %d, want 4 (3 pages + 1 rate limited)", calls.Load())
+	}
+}
+
+func TestPaginate_CursorStopAndLoop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		next := map[string]string{"": "a", "a": "b", "b": "a"}[cursor]
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		_ = json.NewEncoder(w).Encode(itemPage{Items: []int{len(cursor)}, Cursor: next})
+	}))
+	defer srv.Close()
+
+	c := New()
+	next := CursorNext("cursor", func(p itemPage) string { return p.Cursor })
+
+	// 提前结束
+	pages := 0
+	err := Paginate(context.Background(), c, srv.URL, next, func(p itemPage) error {
+		pages++
+		if pages == 2 {
+			return ErrStopPagination
+		}
+		return nil
+	})
+	if err != nil || pages != 2 {
+		t.Fatalf("stop: pages=%d err=%v", pages, err)
+	}
+
+	// 游标回到已请求过的页
+	pages = 0
+	err = Paginate(context.Background(), c, srv.URL, next, func(p itemPage) error {
+		pages++
+		return nil
+	})
+	if !errors.Is(err, ErrPaginationLoop) || pages != 3 {
+		t.Fatalf("loop: pages=%d err=%v", pages, err)
+	}
+
+	// 页处理函数的错误原样返回
+	boom := errors.New("boom")
+	if err := Paginate(context.Background(), c, srv.URL, next, func(p itemPage) error { return boom }); !errors.Is(err, boom) {
+		t.Fatalf("fn error: %v", err)
+	}
+}
+
+func TestPaginate_RateLimitGivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := Paginate(context.Background(), New(), srv.URL, LinkNext[itemPage](), func(p itemPage) error { return nil })
+	if !errors.Is(err, &HTTPError{StatusCode: http.StatusServiceUnavailable}) {
+		t.Fatalf("expected 503 HTTPError, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Paginate(ctx, New(), srv.URL, LinkNext[itemPage](), func(p itemPage) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var calls atomic.Int32
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var transitions []string
+	c := New(
+		WithRetry(3, nil, nil),
+		WithCircuitBreaker(CircuitConfig{
+			MaxFailures: 2,
+			OpenTimeout: 100 * time.Millisecond,
+			OnStateChange: func(host string, from, to CircuitState) {
+				mu.Lock()
+				transitions = append(transitions, from.String()+">"+to.String())
+				mu.Unlock()
+			},
+		}),
+	)
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	// 第 2 次失败打开熔断，第 3 次尝试不再发送，且不重试 ErrCircuitOpen
+	if _, err := c.Get(ctx, srv.URL+"/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+	if _, err := c.Get(ctx, srv.URL+"/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen", err)
+	}
+	st := c.Stats().Circuits[host]
+	if st.State != CircuitOpen || st.Rejected != 2 {
+		t.Errorf("circuit = %+v, want open with 2 rejected", st)
+	}
+
+	// 半开状态只放行一个探测请求，探测成功后关闭
+	time.Sleep(150 * time.Millisecond)
+	failing.Store(false)
+	probe := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, srv.URL+"/slow")
+		probe <- err
+	}()
+	for c.Stats().Circuits[host].State != CircuitHalfOpen {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.Get(ctx, srv.URL+"/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("during probe: err = %v, want ErrCircuitOpen", err)
+	}
+	if err := <-probe; err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if _, err := c.Get(ctx, srv.URL+"/"); err != nil {
+		t.Errorf("after recovery: %v", err)
+	}
+
+	mu.Lock()
+	got := strings.Join(transitions, ",")
+	mu.Unlock()
+	if got != "closed>open,open>half-open,half-open>closed" {
+		t.Errorf("transitions = %s", got)
+	}
+
+	// 慢响应计为失败
+	c = New(WithCircuitBreaker(CircuitConfig{MaxFailures: 1, SlowThreshold: 10 * time.Millisecond}))
+	if _, err := c.Get(ctx, srv.URL+"/slow"); err != nil {
+		t.Fatalf("slow: %v", err)
+	}
+	if _, err := c.Get(ctx, srv.URL+"/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("after slow response: err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestClient_HostLimit(t *testing.T) {
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			<-unblock
+		}
+	}))
+	defer srv.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+
+	c := New(
+		WithHostLimit(HostLimitConfig{MaxInFlight: 1, MaxWait: 20 * time.Millisecond}),
+		WithCircuitBreaker(CircuitConfig{MaxFailures: 1}),
+	)
+	ctx := context.Background()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, srv.URL+"/block", IntoBytes(new([]byte)))
+		done <- err
+	}()
+	for c.Stats().HostLimits[host].InFlight != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 名额已满时等待 MaxWait 后拒绝，其他 host 不受影响
+	if _, err := c.Get(ctx, srv.URL+"/"); !errors.Is(err, ErrHostLimit) {
+		t.Errorf("err = %v, want ErrHostLimit", err)
+	}
+	if _, err := c.Get(ctx, other.URL+"/", IntoBytes(new([]byte))); err != nil {
+		t.Errorf("other host: %v", err)
+	}
+	// 未发出的请求不计入熔断
+	if st := c.Stats().Circuits[host]; st.State != CircuitClosed {
+		t.Errorf("circuit = %+v, want closed", st)
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	st := c.Stats().HostLimits[host]
+	if st.InFlight != 0 || st.Waiting != 0 || st.Rejected != 1 {
+		t.Errorf("host limit = %+v, want idle with 1 rejected", st)
+	}
+}
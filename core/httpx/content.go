@@ -6,4 +6,6 @@ const (
 	ContentTypeForm = "application/x-www-form-urlencoded"
 	ContentTypeXML  = "application/xml"
 	ContentTypeText = "text/plain"
+
+	ContentTypeOctetStream = "application/octet-stream"
 )
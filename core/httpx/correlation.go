@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+
+	kitctx "github.com/kochabx/kit/core/x/ctx"
+)
+
+const (
+	// HeaderRequestID 出站请求携带请求 ID 的请求头。
+	HeaderRequestID = "X-Request-ID"
+	// HeaderRequestTimeout 出站请求携带剩余超时 (毫秒) 的请求头。
+	HeaderRequestTimeout = "X-Request-Timeout"
+)
+
+// PropagationConfig 出站请求的上下文传播配置，见 WithPropagation。零值字段使用默认值。
+type PropagationConfig struct {
+	// RequestIDHeader 请求 ID 请求头，默认 HeaderRequestID。
+	RequestIDHeader string
+	// TimeoutHeader 剩余超时请求头，默认 HeaderRequestTimeout。
+	TimeoutHeader string
+	// DisableTimeout 不传播剩余超时。
+	DisableTimeout bool
+	// FormatTimeout 剩余超时的编码方式，默认为毫秒整数 (不足 1ms 记为 1)。
+	FormatTimeout func(time.Duration) string
+	// Propagator 链路上下文传播器，默认为 W3C traceparent / tracestate 与 baggage。
+	Propagator propagation.TextMapPropagator
+	// Inject 额外的注入钩子，在默认请求头写入后调用，可写入租户等自定义请求头。
+	Inject func(ctx context.Context, header http.Header)
+}
+
+func (p *PropagationConfig) setDefaults() {
+	if p.RequestIDHeader == "" {
+		p.RequestIDHeader = HeaderRequestID
+	}
+	if p.TimeoutHeader == "" {
+		p.TimeoutHeader = HeaderRequestTimeout
+	}
+	if p.FormatTimeout == nil {
+		p.FormatTimeout = formatTimeoutMillis
+	}
+	if p.Propagator == nil {
+		p.Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+}
+
+// WithPropagation 自定义出站请求的上下文传播，默认行为见 WithoutCorrelation。
+//
+//	httpx.New(httpx.WithPropagation(httpx.PropagationConfig{
+//	    RequestIDHeader: "X-Correlation-ID",
+//	    TimeoutHeader:   "X-Deadline-Ms",
+//	    Inject: func(ctx context.Context, h http.Header) {
+//	        h.Set("X-Tenant-ID", tenant.From(ctx))
+//	    },
+//	}))
+func WithPropagation(cfg PropagationConfig) ClientOption {
+	return func(cli *Client) { cli.propagation = cfg }
+}
+
+// WithoutCorrelation 关闭上下文传播。
+//
+// 默认情况下，Do 会把 ctx 携带的上下文写入出站请求头，使下游服务的日志、链路与超时能关联到同一请求：
+//   - 请求 ID (见 ctx.WithRequestID，通常由服务端 RequestID 中间件设置) 写入 X-Request-ID
+//   - span context 按 W3C Trace Context 写入 traceparent / tracestate，OpenTelemetry baggage 写入 baggage
+//   - ctx 的剩余超时 (与 WithTimeout 取较小者) 以毫秒写入 X-Request-Timeout，
+//     协作的内部服务可据此提前放弃注定超时的工作；每次重试按当时的剩余时间重新计算
+//
+// 请求头名称与传播器可通过 WithPropagation 自定义。调用第三方 API 不希望暴露内部 ID 时可关闭。
+// 默认请求头与 Header / SetHeader 显式设置的同名请求头优先。
+func WithoutCorrelation() ClientOption {
+	return func(cli *Client) { cli.noCorrelation = true }
+}
+
+// injectCorrelation 把 ctx 中的请求 ID、span context 与 baggage 写入 header。
+func (p *PropagationConfig) injectCorrelation(ctx context.Context, header http.Header) {
+	if id := kitctx.RequestID(ctx); id != "" && header.Get(p.RequestIDHeader) == "" {
+		header.Set(p.RequestIDHeader, id)
+	}
+	injected := make(http.Header)
+	p.Propagator.Inject(ctx, propagation.HeaderCarrier(injected))
+	if header.Get("traceparent") != "" {
+		// tracestate 从属于 traceparent，显式设置了 traceparent 时一并保留调用方的值
+		injected.Del("tracestate")
+	}
+	for k, vs := range injected {
+		if header.Get(k) == "" {
+			header[k] = vs
+		}
+	}
+	if p.Inject != nil {
+		p.Inject(ctx, header)
+	}
+}
+
+// injectTimeout 把本次发送的剩余超时写入 header，clientTimeout 为 http.Client.Timeout。
+// 已显式设置或没有任何超时时不写入。
+func (p *PropagationConfig) injectTimeout(ctx context.Context, header http.Header, clientTimeout time.Duration) {
+	if p.DisableTimeout || header.Get(p.TimeoutHeader) != "" {
+		return
+	}
+	remaining := clientTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); remaining <= 0 || left < remaining {
+			remaining = left
+		}
+	}
+	if remaining <= 0 {
+		return
+	}
+	header.Set(p.TimeoutHeader, p.FormatTimeout(remaining))
+}
+
+// formatTimeoutMillis 以毫秒整数表示超时，不足 1ms 记为 1。
+func formatTimeoutMillis(d time.Duration) string {
+	return strconv.FormatInt(max(d.Milliseconds(), 1), 10)
+}
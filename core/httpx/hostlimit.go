@@ -0,0 +1,132 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrHostLimit 发往该 host 的在途请求数已达 HostLimitConfig.MaxInFlight，且在 MaxWait 内未取得名额。
+var ErrHostLimit = errors.New("httpx: host in-flight limit reached")
+
+// HostLimitConfig 按 host 的在途请求上限配置，见 WithHostLimit。
+type HostLimitConfig struct {
+	// MaxInFlight 每个 host 同时在途的请求数上限 (从发送到响应体关闭)，<=0 时为 16。
+	MaxInFlight int
+	// MaxWait 名额不足时的最长等待时间，<=0 表示不等待，立即返回 ErrHostLimit。
+	MaxWait time.Duration
+}
+
+// WithHostLimit 限制每个 host 的在途请求数，使一个响应缓慢或挂起的上游
+// 只能占用有限的连接与协程，不会拖垮整个进程。
+//
+// 上限作用于每一次实际发送 (包括重试)，位于自适应限流之后、WithQueue 之前，
+// 因此等待某个 host 名额的请求不会占用全局并发名额。名额在响应体关闭 (或读到 EOF) 时归还，
+// 调用方须关闭 Response.Body。各 host 的在途数可通过 Stats 查看。
+func WithHostLimit(cfg HostLimitConfig) ClientOption {
+	return func(cli *Client) {
+		if cfg.MaxInFlight <= 0 {
+			cfg.MaxInFlight = 16
+		}
+		cli.hostLimit = &hostLimiter{cfg: cfg, hosts: make(map[string]*hostSlots)}
+	}
+}
+
+// HostLimitStats 单个 host 的在途请求状态。
+type HostLimitStats struct {
+	InFlight int   // 在途请求数
+	Waiting  int   // 等待名额的请求数
+	Rejected int64 // 累计因未取得名额被拒绝的请求数
+}
+
+// hostLimiter 按 host 分配在途名额。
+type hostLimiter struct {
+	cfg   HostLimitConfig
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+// hostSlots 单个 host 的名额，sem 的长度即在途请求数。
+type hostSlots struct {
+	sem      chan struct{}
+	waiting  atomic.Int64
+	rejected atomic.Int64
+}
+
+func (l *hostLimiter) host(host string) *hostSlots {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.hosts[host]
+	if !ok {
+		s = &hostSlots{sem: make(chan struct{}, l.cfg.MaxInFlight)}
+		l.hosts[host] = s
+	}
+	return s
+}
+
+// acquire 取得一个名额，最多等待 MaxWait；ctx 取消时返回 ctx.Err()。
+func (l *hostLimiter) acquire(ctx context.Context, s *hostSlots) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	default:
+	}
+	if l.cfg.MaxWait <= 0 {
+		s.rejected.Add(1)
+		return ErrHostLimit
+	}
+
+	s.waiting.Add(1)
+	defer s.waiting.Add(-1)
+	timer := time.NewTimer(l.cfg.MaxWait)
+	defer timer.Stop()
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		s.rejected.Add(1)
+		return ErrHostLimit
+	}
+}
+
+func (l *hostLimiter) stats() map[string]HostLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]HostLimitStats, len(l.hosts))
+	for host, s := range l.hosts {
+		out[host] = HostLimitStats{
+			InFlight: len(s.sem),
+			Waiting:  int(s.waiting.Load()),
+			Rejected: s.rejected.Load(),
+		}
+	}
+	return out
+}
+
+// middleware 在每次发送前取得 host 名额，响应体关闭时归还。
+func (l *hostLimiter) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		s := l.host(req.URL.Host)
+		if err := l.acquire(req.Context(), s); err != nil {
+			if errors.Is(err, ErrHostLimit) {
+				return nil, fmt.Errorf("%w: %s", err, req.URL.Host)
+			}
+			return nil, err
+		}
+		release := func() { <-s.sem }
+		resp, err := next(req)
+		// 协议升级后的连接不再计入在途名额
+		if err != nil || resp == nil || resp.Body == nil || resp.StatusCode == http.StatusSwitchingProtocols {
+			release()
+			return resp, err
+		}
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	}
+}
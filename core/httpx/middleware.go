@@ -22,6 +22,9 @@ func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { re
 //	}
 type Middleware func(next RoundTripFunc) RoundTripFunc
 
+// Interceptor 是 Middleware 的别名，用于 Client.Use。
+type Interceptor = Middleware
+
 // chain 把一组中间件按声明顺序包装到 base 之外，先声明的位于最外层。
 func chain(base http.RoundTripper, mws []Middleware) http.RoundTripper {
 	if base == nil {
@@ -16,9 +16,12 @@ type RequestOption func(*requestConfig)
 
 // requestConfig 是单次请求的可变配置，由 RequestOption 填充。
 type requestConfig struct {
-	header http.Header
-	query  url.Values
-	decode func(*http.Response) error
+	header   http.Header
+	query    url.Values
+	params   Vars // 路径参数，见 PathParam
+	decode   func(*http.Response) error
+	priority *Priority    // 见 WithPriority
+	retry    *RetryPolicy // 见 Retry / NoRetry
 }
 
 // Header 添加单个请求头。多次调用同一 key 会追加值。
@@ -72,6 +75,20 @@ func Queries(values url.Values) RequestOption {
 	}
 }
 
+// PathParam 设置路径参数，替换请求路径中的 {name} 占位符，值会做路径转义：
+//
+//	client.Get(ctx, "/users/{id}/orders", httpx.PathParam("id", 42))
+//
+// 设置了路径参数时，路径中未提供值的占位符会使请求返回 ErrMissingVar。
+func PathParam(name string, value any) RequestOption {
+	return func(c *requestConfig) {
+		if c.params == nil {
+			c.params = make(Vars)
+		}
+		c.params[name] = value
+	}
+}
+
 // Into 根据响应的 Content-Type 自动解码到 dest：
 //   - application/json => json.Unmarshal
 //   - application/xml / text/xml => xml.Unmarshal
@@ -136,6 +153,21 @@ func IntoString(dest *string) RequestOption {
 	}
 }
 
+// IntoWriter 将响应体流式写入 w，不缓冲到内存，适合下载大文件。
+// 写入完成后 Body 会被关闭；写入失败时返回的错误中包含已写入的字节数。
+func IntoWriter(w io.Writer) RequestOption {
+	return func(c *requestConfig) {
+		c.decode = func(resp *http.Response) error {
+			defer resp.Body.Close()
+			n, err := io.Copy(w, resp.Body)
+			if err != nil {
+				return fmt.Errorf("httpx: stream response body (%d bytes written): %w", n, err)
+			}
+			return nil
+		}
+	}
+}
+
 // Decode 注册一个自定义响应处理函数，调用方负责关闭 resp.Body。
 func Decode(fn func(*http.Response) error) RequestOption {
 	return func(c *requestConfig) { c.decode = fn }
@@ -0,0 +1,171 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrStopPagination 由 Paginate 的页处理函数返回，表示提前结束翻页，Paginate 返回 nil。
+	ErrStopPagination = errors.New("httpx: stop pagination")
+	// ErrPaginationLoop 下一页的 URL 已经请求过，通常是对端游标未前进，继续翻页会死循环。
+	ErrPaginationLoop = errors.New("httpx: pagination loop")
+)
+
+const (
+	// pageMaxRateLimitWaits 同一页连续被限流 (429 / 503) 时最多等待的次数，超过后返回 HTTPError。
+	pageMaxRateLimitWaits = 5
+	// pageMaxRateLimitWait 单次限流等待的上限，避免对端返回过大的 Retry-After 导致长时间阻塞。
+	pageMaxRateLimitWait = time.Minute
+	// pageRateLimitBackoff 响应未带 Retry-After 时限流等待的基准时长，按次数指数增长。
+	pageRateLimitBackoff = time.Second
+)
+
+// NextPageFunc 根据当前页返回下一页的 URL，返回空串表示没有下一页。
+//
+// current 为当前页的完整 URL，resp 为当前页的响应 (Body 已读取并关闭，Header 可用)，
+// page 为当前页解码后的内容。返回的 URL 可以是绝对 URL，也可以是相对 current 的引用。
+type NextPageFunc[T any] func(current *url.URL, resp *http.Response, page T) (string, error)
+
+// LinkNext 按 RFC 8288 Link 响应头中 rel="next" 的链接翻页，适用于 GitHub 等 API：
+//
+//	Link: <https://api.example.com/items?page=2>; rel="next", <...>; rel="last"
+func LinkNext[T any]() NextPageFunc[T] {
+	return func(_ *url.URL, resp *http.Response, _ T) (string, error) {
+		return linkNext(resp.Header), nil
+	}
+}
+
+// CursorNext 按响应体中的游标翻页：cursor 从当前页取出下一页游标，
+// 写入当前 URL 的 param 查询参数 (覆盖原值) 作为下一页；游标为空表示没有下一页。
+//
+//	httpx.CursorNext("cursor", func(p ItemPage) string { return p.NextCursor })
+func CursorNext[T any](param string, cursor func(page T) string) NextPageFunc[T] {
+	return func(current *url.URL, _ *http.Response, page T) (string, error) {
+		next := cursor(page)
+		if next == "" {
+			return "", nil
+		}
+		u := *current
+		q := u.Query()
+		q.Set(param, next)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+}
+
+// Paginate 从 firstURL 开始逐页 GET，将每页响应解码 (同 Into) 为 T 后交给 fn，
+// 再通过 next 确定下一页，直到 next 返回空串：
+//
+//   - fn 返回 ErrStopPagination 时提前结束并返回 nil，返回其他错误时原样返回
+//   - 某页返回 429 / 503 时按 Retry-After (缺失时指数退避) 等待后重新请求该页，
+//     连续限流超过 5 次返回 HTTPError；等待期间 ctx 取消时返回 ctx.Err()
+//   - 下一页 URL 已请求过时返回 ErrPaginationLoop，避免对端游标不前进导致死循环
+//
+// opts 应用于每一页的请求 (如鉴权头)；其中的 Query 参数只合并到 firstURL，
+// 后续页的 URL 完全由 next 决定，不会重复追加。
+func Paginate[T any](ctx context.Context, c *Client, firstURL string, next NextPageFunc[T], fn func(page T) error, opts ...RequestOption) error {
+	if next == nil || fn == nil {
+		return errors.New("httpx: nil pagination function")
+	}
+
+	cfg := &requestConfig{header: make(http.Header), query: make(url.Values)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	target, err := c.resolveURL(firstURL, cfg.query)
+	if err != nil {
+		return err
+	}
+	opts = append(opts[:len(opts):len(opts)], func(rc *requestConfig) { rc.query = make(url.Values) })
+
+	seen := make(map[string]struct{})
+	for target != "" {
+		current, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf("httpx: parse page url %q: %w", target, err)
+		}
+		seen[current.String()] = struct{}{}
+
+		page, resp, err := fetchPage[T](ctx, c, current.String(), opts)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			if errors.Is(err, ErrStopPagination) {
+				return nil
+			}
+			return err
+		}
+
+		ref, err := next(current, resp, page)
+		if err != nil || ref == "" {
+			return err
+		}
+		nextURL, err := current.Parse(ref)
+		if err != nil {
+			return fmt.Errorf("httpx: parse next page url %q: %w", ref, err)
+		}
+		if _, ok := seen[nextURL.String()]; ok {
+			return fmt.Errorf("%w: %s", ErrPaginationLoop, nextURL)
+		}
+		target = nextURL.String()
+	}
+	return nil
+}
+
+// fetchPage 请求并解码一页，被限流时等待后重试。
+func fetchPage[T any](ctx context.Context, c *Client, target string, opts []RequestOption) (T, *http.Response, error) {
+	for wait := 1; ; wait++ {
+		var page T
+		resp, err := c.Get(ctx, target, append(opts[:len(opts):len(opts)], Into(&page))...)
+		if err == nil {
+			return page, resp, nil
+		}
+
+		var httpErr *HTTPError
+		if wait > pageMaxRateLimitWaits || !errors.As(err, &httpErr) ||
+			(httpErr.StatusCode != http.StatusTooManyRequests && httpErr.StatusCode != http.StatusServiceUnavailable) {
+			return page, resp, err
+		}
+		d, ok := parseRetryAfter(httpErr.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			d = pageRateLimitBackoff << (wait - 1)
+		}
+		select {
+		case <-time.After(min(d, pageMaxRateLimitWait)):
+		case <-ctx.Done():
+			return page, resp, ctx.Err()
+		}
+	}
+}
+
+// linkNext 返回 Link 头中 rel="next" 的 URL，不存在时返回空串。
+func linkNext(header http.Header) string {
+	for _, v := range header.Values("Link") {
+		for link := range strings.SplitSeq(v, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			target = strings.TrimSpace(target)
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for param := range strings.SplitSeq(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for rel := range strings.FieldsSeq(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,220 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoRequests Race 未传入任何请求。
+	ErrNoRequests = errors.New("httpx: no requests")
+	// ErrBudgetExceeded All 中失败的请求数超过了 Budget.MaxFailures，其余请求已被取消。
+	ErrBudgetExceeded = errors.New("httpx: error budget exceeded")
+)
+
+// Request 描述 All / Race 中的一个请求，字段含义与 Do 的参数一致。
+type Request struct {
+	Method  string
+	Target  string
+	Body    Body
+	Options []RequestOption
+}
+
+// NewRequest 构造一个 Request。
+func NewRequest(method, target string, body Body, opts ...RequestOption) Request {
+	return Request{Method: method, Target: target, Body: body, Options: opts}
+}
+
+// Result 是单个请求的执行结果。
+//
+// Response 与 Err 的语义与 Do 的返回值一致。Err 为 nil 时 Response.Body 已被完整读入内存
+// (即使使用了 Into 等解码选项也可再次读取)，不受共享截止时间或取消的影响，调用方无需 Close。
+type Result struct {
+	Index    int            // 请求在参数列表中的下标
+	Response *http.Response // 响应，请求未发出或网络错误时为 nil
+	Err      error          // 请求错误 (含 HTTPError、解码错误、取消)
+	Duration time.Duration  // 请求耗时 (不含等待并发名额的时间)
+}
+
+// Budget 是一组并发请求共享的预算。零值表示：仅受 ctx 约束、不限并发、任一失败即取消其余请求。
+type Budget struct {
+	// Timeout 所有请求共享的截止时间，<=0 表示仅受 ctx 约束
+	Timeout time.Duration
+	// MaxFailures All 允许失败的请求数，超出后取消其余请求；<0 表示不限制 (总是等待全部完成)
+	MaxFailures int
+	// Concurrency 同时执行的最大请求数，<=0 表示不限制
+	Concurrency int
+}
+
+// Parallel 以指定预算并发执行请求，由 Client.WithBudget 创建。
+type Parallel struct {
+	client *Client
+	budget Budget
+}
+
+// WithBudget 返回一个使用预算 b 执行 All / Race 的 Parallel：
+//
+//	results, err := c.WithBudget(httpx.Budget{Timeout: time.Second, MaxFailures: 1}).All(ctx,
+//		httpx.NewRequest(http.MethodGet, "/users/1", nil, httpx.IntoJSON(&user)),
+//		httpx.NewRequest(http.MethodGet, "/orders?user=1", nil, httpx.IntoJSON(&orders)),
+//	)
+func (c *Client) WithBudget(b Budget) *Parallel {
+	return &Parallel{client: c, budget: b}
+}
+
+// All 以零值 Budget 并发执行全部请求，见 Parallel.All。
+func (c *Client) All(ctx context.Context, requests ...Request) ([]Result, error) {
+	return c.WithBudget(Budget{}).All(ctx, requests...)
+}
+
+// Race 以零值 Budget 并发执行请求并返回最先成功的一个，见 Parallel.Race。
+func (c *Client) Race(ctx context.Context, requests ...Request) (Result, error) {
+	return c.WithBudget(Budget{}).Race(ctx, requests...)
+}
+
+// All 并发执行全部请求，按参数顺序返回每个请求的结果。
+//
+// 失败的请求数超过 Budget.MaxFailures 时取消仍在执行的请求，并返回包装了首个失败原因的
+// ErrBudgetExceeded；否则返回 nil，个别失败的请求通过 Result.Err 体现。
+// 无论是否出错，返回的切片长度都与 requests 相同。
+func (p *Parallel) All(ctx context.Context, requests ...Request) ([]Result, error) {
+	if ctx == nil {
+		return nil, errors.New("httpx: nil Context")
+	}
+	ctx, cancel := p.budget.context(ctx)
+	defer cancel()
+
+	results := make([]Result, len(requests))
+	var (
+		mu       sync.Mutex
+		failures int
+		exceeded error
+	)
+	p.run(ctx, requests, func(r Result) {
+		results[r.Index] = r
+		if r.Err == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		failures++
+		if exceeded == nil && p.budget.MaxFailures >= 0 && failures > p.budget.MaxFailures {
+			exceeded = fmt.Errorf("%w: request %d: %w", ErrBudgetExceeded, r.Index, r.Err)
+			cancel()
+		}
+	})
+	return results, exceeded
+}
+
+// Race 并发执行请求，返回最先成功的结果并取消其余请求，适用于向多个副本发起对冲请求。
+// 配合 Budget.Concurrency 可以实现按顺序降级：Concurrency 为 1 时依次尝试，直到某个请求成功。
+//
+// 全部请求失败时返回的 Result.Index 为 -1，错误聚合了每个请求的失败原因。
+// Budget.MaxFailures 对 Race 无效。
+func (p *Parallel) Race(ctx context.Context, requests ...Request) (Result, error) {
+	if ctx == nil {
+		return Result{Index: -1}, errors.New("httpx: nil Context")
+	}
+	if len(requests) == 0 {
+		return Result{Index: -1}, ErrNoRequests
+	}
+	ctx, cancel := p.budget.context(ctx)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		winner = Result{Index: -1}
+		errs   = make([]error, len(requests))
+	)
+	p.run(ctx, requests, func(r Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Err == nil && winner.Index < 0 {
+			winner = r
+			cancel()
+			return
+		}
+		errs[r.Index] = r.Err
+	})
+	if winner.Index < 0 {
+		return winner, fmt.Errorf("httpx: all %d requests failed: %w", len(requests), errors.Join(errs...))
+	}
+	return winner, nil
+}
+
+// context 按 Timeout 派生共享 ctx。
+func (b Budget) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.Timeout > 0 {
+		return context.WithTimeout(ctx, b.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// run 按参数顺序启动请求 (受并发限制)，每个请求完成后调用 done，全部完成后返回。
+// ctx 结束后尚未启动的请求不再发出，直接以 ctx.Err() 完成。
+func (p *Parallel) run(ctx context.Context, requests []Request, done func(Result)) {
+	var sem chan struct{}
+	if p.budget.Concurrency > 0 {
+		sem = make(chan struct{}, p.budget.Concurrency)
+	}
+
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			for j := i; j < len(requests); j++ {
+				done(Result{Index: j, Err: err})
+			}
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			done(p.do(ctx, i, req))
+		}()
+	}
+	wg.Wait()
+}
+
+// do 执行单个请求。
+func (p *Parallel) do(ctx context.Context, index int, req Request) Result {
+	opts := append(append([]RequestOption(nil), req.Options...), buffered)
+	start := time.Now()
+	resp, err := p.client.Do(ctx, req.Method, req.Target, req.Body, opts...)
+	return Result{Index: index, Response: resp, Err: err, Duration: time.Since(start)}
+}
+
+// buffered 包装请求的解码步骤：先把 body 读入内存再交给原解码函数，
+// 之后 resp.Body 重置为内存副本，使其在共享 ctx 取消后仍然可读。
+func buffered(c *requestConfig) {
+	decode := c.decode
+	c.decode = func(resp *http.Response) error {
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if decode != nil {
+			if err := decode(resp); err != nil {
+				return err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(data))
+		}
+		return nil
+	}
+}
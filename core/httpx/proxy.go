@@ -0,0 +1,340 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoTarget TargetFunc 未选出上游地址。
+	ErrNoTarget = errors.New("httpx: no proxy target")
+	// ErrProxyTimeout 上游未在 WithProxyTimeout 时限内返回响应头。
+	ErrProxyTimeout = errors.New("httpx: proxy upstream timeout")
+)
+
+// TargetFunc 为每个请求选择上游地址，可用于负载均衡或按租户路由。
+// 返回的 URL 的 scheme、host 与 path 前缀生效，path 与请求路径拼接。
+type TargetFunc func(r *http.Request) (*url.URL, error)
+
+// RewriteFunc 改写转发地址。b 已填充请求路径与 query，可在此修改路径或参数；
+// 路径随后拼接在上游地址的路径之后。b 中设置的 scheme/host 会覆盖上游地址。
+type RewriteFunc func(r *http.Request, b *URLBuilder)
+
+// HeaderPolicy 头转发策略。Allow 非空时只保留列出的头，随后删除 Deny 中的头；
+// 名称不区分大小写。
+type HeaderPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// apply 按策略过滤 h。
+func (p HeaderPolicy) apply(h http.Header) {
+	if len(p.Allow) > 0 {
+		allowed := make(map[string]struct{}, len(p.Allow))
+		for _, k := range p.Allow {
+			allowed[http.CanonicalHeaderKey(k)] = struct{}{}
+		}
+		for k := range h {
+			if _, ok := allowed[k]; !ok {
+				delete(h, k)
+			}
+		}
+	}
+	for _, k := range p.Deny {
+		h.Del(k)
+	}
+}
+
+// Proxy 反向代理，基于 httputil.ReverseProxy，实现 http.Handler。
+//
+// 在 gin 中使用：
+//
+//	proxy := httpx.NewProxy(httpx.StaticTarget(upstream), httpx.WithProxyRewrite(httpx.StripPrefix("/api")))
+//	r.Any("/api/*path", gin.WrapH(proxy))
+type Proxy struct {
+	target        TargetFunc
+	rewrite       RewriteFunc
+	reqHeaders    HeaderPolicy
+	respHeaders   HeaderPolicy
+	timeout       time.Duration
+	retry         RetryPolicy
+	transport     http.RoundTripper
+	flushInterval time.Duration
+	errorHandler  func(http.ResponseWriter, *http.Request, error)
+
+	rp *httputil.ReverseProxy
+}
+
+// ProxyOption 配置 Proxy。
+type ProxyOption func(*Proxy)
+
+// targetKey 在请求上下文中传递 ServeHTTP 选出的上游地址。
+type targetKey struct{}
+
+// StaticTarget 返回固定上游地址的 TargetFunc。rawURL 无法解析时每个请求都返回该错误。
+func StaticTarget(rawURL string) TargetFunc {
+	u, err := url.Parse(rawURL)
+	if err == nil && (u.Scheme == "" || u.Host == "") {
+		err = errors.New("httpx: proxy target must be an absolute URL: " + rawURL)
+	}
+	return func(*http.Request) (*url.URL, error) {
+		return u, err
+	}
+}
+
+// StripPrefix 返回去掉路径前缀的 RewriteFunc，常用于把 /api/users 转发为上游的 /users。
+func StripPrefix(prefix string) RewriteFunc {
+	return func(r *http.Request, b *URLBuilder) {
+		p := strings.TrimPrefix(b.path.String(), prefix)
+		if p == "" || p[0] != '/' {
+			p = "/" + p
+		}
+		b.Path(p)
+	}
+}
+
+// WithProxyRewrite 设置转发地址改写函数。
+func WithProxyRewrite(fn RewriteFunc) ProxyOption {
+	return func(p *Proxy) { p.rewrite = fn }
+}
+
+// WithRequestHeaders 设置请求头转发策略。X-Forwarded-* 在过滤后设置，不受策略影响。
+func WithRequestHeaders(policy HeaderPolicy) ProxyOption {
+	return func(p *Proxy) { p.reqHeaders = policy }
+}
+
+// WithResponseHeaders 设置响应头转发策略。
+func WithResponseHeaders(policy HeaderPolicy) ProxyOption {
+	return func(p *Proxy) { p.respHeaders = policy }
+}
+
+// WithProxyTimeout 设置等待上游响应头的超时，超时返回 504。
+// 响应体的流式传输不受限制，适用于 SSE 等长连接。
+func WithProxyTimeout(d time.Duration) ProxyOption {
+	return func(p *Proxy) { p.timeout = d }
+}
+
+// WithProxyRetry 对幂等方法 (GET/HEAD/OPTIONS/TRACE/PUT/DELETE) 且无请求体的请求启用重试。
+// maxAttempts 包含首次尝试，backoff 为 nil 时不等待。
+// 网络错误、超时与 502/503/504 响应会触发重试，客户端断开不重试。
+func WithProxyRetry(maxAttempts int, backoff BackoffFunc) ProxyOption {
+	return func(p *Proxy) {
+		p.retry = RetryPolicy{MaxAttempts: maxAttempts, Backoff: backoff}
+	}
+}
+
+// WithProxyTransport 设置转发使用的 RoundTripper，默认 http.DefaultTransport。
+func WithProxyTransport(rt http.RoundTripper) ProxyOption {
+	return func(p *Proxy) { p.transport = rt }
+}
+
+// WithProxyFlushInterval 设置响应体刷新间隔。默认 -1，即每次写入后立即刷新，
+// 流式响应原样透传。
+func WithProxyFlushInterval(d time.Duration) ProxyOption {
+	return func(p *Proxy) { p.flushInterval = d }
+}
+
+// WithProxyErrorHandler 设置转发失败时的处理函数。默认超时返回 504，其余返回 502。
+func WithProxyErrorHandler(fn func(http.ResponseWriter, *http.Request, error)) ProxyOption {
+	return func(p *Proxy) { p.errorHandler = fn }
+}
+
+// NewProxy 创建反向代理。
+func NewProxy(target TargetFunc, opts ...ProxyOption) *Proxy {
+	p := &Proxy{
+		target:        target,
+		flushInterval: -1,
+		errorHandler:  defaultProxyErrorHandler,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.transport == nil {
+		p.transport = http.DefaultTransport
+	}
+
+	p.rp = &httputil.ReverseProxy{
+		Rewrite:        p.rewriteRequest,
+		Transport:      RoundTripFunc(p.roundTrip),
+		FlushInterval:  p.flushInterval,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler:   p.errorHandler,
+	}
+	return p
+}
+
+// ServeHTTP 实现 http.Handler。
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var u *url.URL
+	var err error
+	if p.target != nil {
+		u, err = p.target(r)
+	}
+	if err == nil && u == nil {
+		err = ErrNoTarget
+	}
+	if err != nil {
+		p.errorHandler(w, r, err)
+		return
+	}
+	p.rp.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), targetKey{}, u)))
+}
+
+// rewriteRequest 构造转发请求：应用改写函数、拼接上游地址与请求头策略。
+func (p *Proxy) rewriteRequest(pr *httputil.ProxyRequest) {
+	target := pr.In.Context().Value(targetKey{}).(*url.URL)
+
+	b := NewURLBuilder().Path(pr.In.URL.Path)
+	for k, vs := range pr.In.URL.Query() {
+		b.QuerySlice(k, vs)
+	}
+	if p.rewrite != nil {
+		p.rewrite(pr.In, b)
+	}
+
+	// 改写函数未指定 scheme/host 时使用上游地址，路径拼接在上游路径前缀之后
+	out := &url.URL{Scheme: b.scheme, Host: b.buildHost()}
+	if out.Scheme == "" {
+		out.Scheme = target.Scheme
+	}
+	if out.Host == "" {
+		out.Host = target.Host
+	}
+	out.Path = singleJoiningSlash(target.Path, b.path.String())
+	query := target.Query()
+	for k, vs := range b.query {
+		query[k] = append(query[k], vs...)
+	}
+	out.RawQuery = query.Encode()
+
+	pr.Out.URL = out
+	pr.Out.Host = ""
+
+	p.reqHeaders.apply(pr.Out.Header)
+	pr.SetXForwarded()
+}
+
+// singleJoiningSlash 拼接路径，保证连接处恰有一个斜杠，保留末尾斜杠。
+func singleJoiningSlash(a, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash && a != "" && b != "":
+		return a + "/" + b
+	}
+	return a + b
+}
+
+// modifyResponse 应用响应头策略。
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	p.respHeaders.apply(resp.Header)
+	return nil
+}
+
+// roundTrip 在 transport 之外附加响应头超时与幂等重试。
+func (p *Proxy) roundTrip(req *http.Request) (*http.Response, error) {
+	maxAttempts := p.retry.MaxAttempts
+	if maxAttempts < 1 || !replayable(req) {
+		maxAttempts = 1
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		resp, err = p.attempt(req)
+		if attempt == maxAttempts || !proxyRetryOn(req.Context(), resp, err) {
+			break
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			resp = nil
+		}
+		if p.retry.Backoff != nil {
+			select {
+			case <-time.After(p.retry.Backoff(attempt)):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+	}
+	return resp, err
+}
+
+// attempt 发送一次请求。超时只覆盖到收到响应头为止，之后由响应体的关闭释放上下文。
+func (p *Proxy) attempt(req *http.Request) (*http.Response, error) {
+	if p.timeout <= 0 {
+		return p.transport.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(p.timeout, cancel)
+	resp, err := p.transport.RoundTrip(req.WithContext(ctx))
+	if !timer.Stop() {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		cancel()
+		return nil, ErrProxyTimeout
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody 在响应体关闭时释放请求上下文。
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// replayable 判断请求能否安全重发：幂等方法且无请求体。
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0
+}
+
+// proxyRetryOn 代理重试判定：网络错误、超时或 502/503/504，客户端已断开时不重试。
+func proxyRetryOn(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// defaultProxyErrorHandler 超时返回 504，其余返回 502。
+func defaultProxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrProxyTimeout) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
@@ -0,0 +1,187 @@
+package httpx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxy_RewriteAndHeaders(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("X-Internal", "secret")
+		w.Header().Set("X-Public", "ok")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+
+	proxy := NewProxy(StaticTarget(upstream.URL+"/v1?tenant=a"),
+		WithProxyRewrite(func(r *http.Request, b *URLBuilder) {
+			StripPrefix("/api")(r, b)
+			b.SetQuery("via", "proxy")
+		}),
+		WithRequestHeaders(HeaderPolicy{Allow: []string{"authorization", "X-Trace"}, Deny: []string{"X-Trace"}}),
+		WithResponseHeaders(HeaderPolicy{Deny: []string{"X-Internal"}}),
+	)
+	front := httptest.NewServer(proxy)
+	defer front.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, front.URL+"/api/users/1?page=2", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	req.Header.Set("X-Trace", "1")
+	req.Header.Set("Cookie", "session=1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTeapot {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if got.URL.Path != "/v1/users/1" {
+		t.Errorf("upstream path %q", got.URL.Path)
+	}
+	if q := got.URL.Query(); q.Get("tenant") != "a" || q.Get("page") != "2" || q.Get("via") != "proxy" {
+		t.Errorf("upstream query %q", got.URL.RawQuery)
+	}
+	if got.Header.Get("Authorization") != "Bearer t" || got.Header.Get("X-Trace") != "" || got.Header.Get("Cookie") != "" {
+		t.Errorf("upstream headers %v", got.Header)
+	}
+	if got.Header.Get("X-Forwarded-For") == "" {
+		t.Error("X-Forwarded-For not set")
+	}
+	if resp.Header.Get("X-Internal") != "" || resp.Header.Get("X-Public") != "ok" {
+		t.Errorf("response headers %v", resp.Header)
+	}
+}
+
+func TestProxy_TargetSelection(t *testing.T) {
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "a") }))
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "b") }))
+	defer b.Close()
+
+	proxy := NewProxy(func(r *http.Request) (*url.URL, error) {
+		switch r.Header.Get("X-Tenant") {
+		case "a":
+			return url.Parse(a.URL)
+		case "b":
+			return url.Parse(b.URL)
+		}
+		return nil, nil
+	})
+
+	for tenant, want := range map[string]int{"a": http.StatusOK, "b": http.StatusOK, "": http.StatusBadGateway} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Tenant", tenant)
+		proxy.ServeHTTP(w, r)
+		if w.Code != want || (want == http.StatusOK && w.Body.String() != tenant) {
+			t.Errorf("tenant %q: status %d body %q", tenant, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestProxy_RetryIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer upstream.Close()
+
+	proxy := NewProxy(StaticTarget(upstream.URL), WithProxyRetry(3, nil))
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("GET: status %d after %d calls", w.Code, calls.Load())
+	}
+
+	// 非幂等方法不重试
+	calls.Store(0)
+	w = httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("x")))
+	if w.Code != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("POST: status %d after %d calls", w.Code, calls.Load())
+	}
+}
+
+func TestProxy_Timeout(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstream.Close()
+
+	var gotErr error
+	proxy := NewProxy(StaticTarget(upstream.URL),
+		WithProxyTimeout(20*time.Millisecond),
+		WithProxyRetry(2, nil),
+		WithProxyErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			gotErr = err
+			defaultProxyErrorHandler(w, r, err)
+		}),
+	)
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusGatewayTimeout || !errors.Is(gotErr, ErrProxyTimeout) {
+		t.Fatalf("status %d, err %v", w.Code, gotErr)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("upstream calls %d, want 2", calls.Load())
+	}
+}
+
+func TestProxy_Streaming(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, "first")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprintln(w, "second")
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	// 超时只约束响应头，不影响流式响应体
+	front := httptest.NewServer(NewProxy(StaticTarget(upstream.URL), WithProxyTimeout(time.Second)))
+	defer front.Close()
+
+	resp, err := http.Get(front.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "first\n" {
+		t.Fatalf("first chunk %q, %v", line, err)
+	}
+}
+
+func TestStaticTarget_Invalid(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewProxy(StaticTarget("/relative")).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status %d", w.Code)
+	}
+}
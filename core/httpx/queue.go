@@ -0,0 +1,259 @@
+package httpx
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull 客户端队列中等待的请求数已达 QueueConfig.MaxQueued。
+var ErrQueueFull = errors.New("httpx: request queue full")
+
+// Priority 请求优先级，数值越大越先发送。零值为 PriorityNormal。
+type Priority int
+
+const (
+	PriorityBatch       Priority = -10 // 后台同步、批处理
+	PriorityNormal      Priority = 0   // 默认
+	PriorityInteractive Priority = 10  // 面向用户的请求
+)
+
+type priorityKey struct{}
+
+// ContextWithPriority 返回携带请求优先级的 ctx，适用于无法逐个传入 WithPriority 的场景
+// (如 All / Race、ReverseProxy 的出站请求)。WithPriority 的优先级高于 ctx。
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext 返回 ctx 携带的请求优先级，未设置时为 PriorityNormal。
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// WithPriority 设置请求优先级，仅在启用 WithQueue 时生效。
+func WithPriority(p Priority) RequestOption {
+	return func(c *requestConfig) { c.priority = &p }
+}
+
+// QueueConfig 客户端请求队列配置，见 WithQueue。
+type QueueConfig struct {
+	// MaxConcurrent 同时在途的请求数上限 (从发送到响应体关闭)，<=0 时为 16。
+	MaxConcurrent int
+	// MaxQueued 排队等待的请求数上限，超出时立即返回 ErrQueueFull；<=0 表示不限制。
+	MaxQueued int
+	// OnWait 每个请求取得名额后回调，可用于上报排队时长指标。
+	OnWait func(p Priority, wait time.Duration)
+}
+
+// WithQueue 启用客户端请求队列：所有请求共享 MaxConcurrent 个并发名额，
+// 名额不足时按优先级排队，同一优先级先到先得。
+//
+// 排队作用于每一次实际发送 (包括重试)，位于自适应限流之后，
+// 因此等待 Retry-After 的请求不会占用名额。名额在响应体关闭 (或读到 EOF) 时归还，
+// 调用方须关闭 Response.Body。排队期间 ctx 取消时请求返回 ctx.Err()。
+//
+// 队列按严格优先级调度：高优先级请求持续占满名额时，低优先级请求会一直等待，
+// 批处理调用方应自行设置超时。排队状态可通过 Stats 查看。
+func WithQueue(cfg QueueConfig) ClientOption {
+	return func(cli *Client) {
+		if cfg.MaxConcurrent <= 0 {
+			cfg.MaxConcurrent = 16
+		}
+		cli.queue = &requestQueue{cfg: cfg, waits: make(map[Priority]*QueueWaitStats)}
+	}
+}
+
+// QueueStats 请求队列状态。
+type QueueStats struct {
+	Running int                         // 在途请求数
+	Queued  int                         // 排队请求数
+	Waits   map[Priority]QueueWaitStats // 各优先级的排队统计
+}
+
+// QueueWaitStats 单个优先级的累计排队统计。
+type QueueWaitStats struct {
+	Count    int64         // 取得名额的请求数
+	Rejected int64         // 因队列已满被拒绝的请求数
+	Total    time.Duration // 累计排队时长
+	Max      time.Duration // 最长排队时长
+}
+
+// requestQueue 按优先级分配并发名额。
+type requestQueue struct {
+	cfg     QueueConfig
+	mu      sync.Mutex
+	running int
+	seq     uint64
+	waiters waiterHeap
+	waits   map[Priority]*QueueWaitStats
+}
+
+// waiter 一个排队中的请求。granted 与 index 由 requestQueue.mu 保护。
+type waiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	granted  bool
+	index    int
+}
+
+// waiterHeap 按优先级降序、入队顺序升序排列。
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *waiterHeap) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+func (h *waiterHeap) Pop() any {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	w.index = -1
+	return w
+}
+
+// acquire 取得一个名额，阻塞直到取得或 ctx 取消。
+func (q *requestQueue) acquire(ctx context.Context, p Priority) error {
+	start := time.Now()
+	q.mu.Lock()
+	if q.running < q.cfg.MaxConcurrent && q.waiters.Len() == 0 {
+		q.running++
+		q.recordLocked(p, 0)
+		q.mu.Unlock()
+		q.onWait(p, 0)
+		return nil
+	}
+	if q.cfg.MaxQueued > 0 && q.waiters.Len() >= q.cfg.MaxQueued {
+		q.statsLocked(p).Rejected++
+		q.mu.Unlock()
+		return ErrQueueFull
+	}
+	q.seq++
+	w := &waiter{priority: p, seq: q.seq, ready: make(chan struct{})}
+	heap.Push(&q.waiters, w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		wait := time.Since(start)
+		q.mu.Lock()
+		q.recordLocked(p, wait)
+		q.mu.Unlock()
+		q.onWait(p, wait)
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		if w.granted {
+			// 名额已交接给本请求，转交下一个等待者
+			q.mu.Unlock()
+			q.release()
+		} else {
+			heap.Remove(&q.waiters, w.index)
+			q.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// release 归还名额，优先交接给队首等待者。
+func (q *requestQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.waiters.Len() == 0 {
+		q.running--
+		return
+	}
+	w := heap.Pop(&q.waiters).(*waiter)
+	w.granted = true
+	close(w.ready)
+}
+
+func (q *requestQueue) onWait(p Priority, wait time.Duration) {
+	if q.cfg.OnWait != nil {
+		q.cfg.OnWait(p, wait)
+	}
+}
+
+func (q *requestQueue) statsLocked(p Priority) *QueueWaitStats {
+	s, ok := q.waits[p]
+	if !ok {
+		s = &QueueWaitStats{}
+		q.waits[p] = s
+	}
+	return s
+}
+
+func (q *requestQueue) recordLocked(p Priority, wait time.Duration) {
+	s := q.statsLocked(p)
+	s.Count++
+	s.Total += wait
+	s.Max = max(s.Max, wait)
+}
+
+func (q *requestQueue) stats() *QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waits := make(map[Priority]QueueWaitStats, len(q.waits))
+	for p, s := range q.waits {
+		waits[p] = *s
+	}
+	return &QueueStats{Running: q.running, Queued: q.waiters.Len(), Waits: waits}
+}
+
+// middleware 在每次发送前取得名额，响应体关闭时归还。
+func (q *requestQueue) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if err := q.acquire(req.Context(), PriorityFromContext(req.Context())); err != nil {
+			return nil, err
+		}
+		resp, err := next(req)
+		// 协议升级后的连接不再计入并发名额
+		if err != nil || resp == nil || resp.Body == nil || resp.StatusCode == http.StatusSwitchingProtocols {
+			q.release()
+			return resp, err
+		}
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: q.release}
+		return resp, nil
+	}
+}
+
+// releaseBody 在读到 EOF 或 Close 时归还名额，只归还一次。
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,127 @@
+package httpx
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// defaultMaxRetryAfter RetryPolicy.MaxRetryAfter 的默认值。
+const defaultMaxRetryAfter = time.Minute
+
+// RetryPolicy 重试策略，通过 WithRetryPolicy 设置客户端默认值，通过 Retry / NoRetry 按请求覆盖。
+type RetryPolicy struct {
+	// MaxAttempts 包含首次尝试在内的最大尝试次数，<=1 表示不重试。
+	MaxAttempts int
+	// Backoff 第 attempt 次失败后的等待时长，nil 时不等待。见 ExpBackoff / ExpJitterBackoff。
+	Backoff BackoffFunc
+	// RetryOn 自定义重试判定，设置后忽略 RetryStatus。
+	// nil 时对网络错误 (context 错误除外) 与 RetryStatus 中的状态码重试。
+	RetryOn func(resp *http.Response, err error) bool
+	// RetryStatus 可重试的状态码，为空时为 429 与全部 5xx。
+	RetryStatus []int
+	// IgnoreRetryAfter 不遵循 429 / 503 响应的 Retry-After。
+	// 默认按 Retry-After 与退避时长中较大者等待。
+	IgnoreRetryAfter bool
+	// MaxRetryAfter Retry-After 的等待上限，超出时不再重试而直接返回该响应，<=0 时为 1 分钟。
+	MaxRetryAfter time.Duration
+	// RetryNonIdempotent 允许重试非幂等请求。默认只重试幂等方法
+	// (GET/HEAD/OPTIONS/TRACE/PUT/DELETE) 与携带 Idempotency-Key 请求头的请求，
+	// 避免 POST / PATCH 在服务端已处理、响应丢失时被重复执行。
+	RetryNonIdempotent bool
+	// OnAttempt 每次尝试结束后回调 (含最后一次)，可用于日志与指标。
+	OnAttempt func(RetryAttempt)
+}
+
+// RetryAttempt 描述一次尝试的结果，见 RetryPolicy.OnAttempt。
+type RetryAttempt struct {
+	Attempt  int            // 第几次尝试，从 1 开始
+	Method   string         // 请求方法
+	URL      string         // 请求 URL
+	Response *http.Response // 本次响应，网络错误时为 nil；回调中只应读取状态码与响应头
+	Err      error          // 本次的网络错误
+	Retry    bool           // 是否会继续重试
+	Wait     time.Duration  // 下次尝试前的等待时长，Retry 为 false 时为 0
+}
+
+// WithRetryPolicy 设置客户端默认重试策略，单次请求可通过 Retry / NoRetry 覆盖。
+//
+// 重试要求请求 body 可以重放。本库提供的 Body 构造器 (JSON/XML/Form/Raw/Text/ReadAll)
+// 均会先把 body 完整缓存，因此天然支持重试。
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(cli *Client) { cli.retry = p }
+}
+
+// Retry 为单次请求覆盖客户端的重试策略。
+func Retry(p RetryPolicy) RequestOption {
+	return func(c *requestConfig) { c.retry = &p }
+}
+
+// NoRetry 禁用单次请求的重试。
+func NoRetry() RequestOption {
+	return Retry(RetryPolicy{MaxAttempts: 1})
+}
+
+// ExpJitterBackoff 返回带全抖动 (full jitter) 的指数退避函数：
+// 在 [0, min(ceiling, base*2^(attempt-1))] 内均匀取值，避免大量客户端同时重试。ceiling <= 0 表示不设上限。
+func ExpJitterBackoff(base, ceiling time.Duration) BackoffFunc {
+	exp := ExpBackoff(base)
+	return func(attempt int) time.Duration {
+		d := exp(attempt)
+		if d <= 0 || (ceiling > 0 && d > ceiling) {
+			// 移位溢出时同样取上限
+			d = ceiling
+		}
+		if d <= 0 {
+			return 0
+		}
+		return rand.N(d + 1)
+	}
+}
+
+// shouldRetry 判断本次尝试后是否重试 (不含次数判断)。
+func (p *RetryPolicy) shouldRetry(method string, header http.Header, resp *http.Response, err error) bool {
+	if !p.RetryNonIdempotent && !idempotent(method, header) {
+		return false
+	}
+	if p.RetryOn != nil {
+		return p.RetryOn(resp, err)
+	}
+	if err != nil || len(p.RetryStatus) == 0 || resp == nil {
+		return defaultRetryOn(resp, err)
+	}
+	return slices.Contains(p.RetryStatus, resp.StatusCode)
+}
+
+// wait 返回第 attempt 次失败后的等待时长；Retry-After 超出上限时 ok 为 false。
+func (p *RetryPolicy) wait(attempt int, resp *http.Response) (d time.Duration, ok bool) {
+	if p.Backoff != nil {
+		d = p.Backoff(attempt)
+	}
+	if p.IgnoreRetryAfter || resp == nil ||
+		(resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return d, true
+	}
+	ra, found := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !found {
+		return d, true
+	}
+	limit := p.MaxRetryAfter
+	if limit <= 0 {
+		limit = defaultMaxRetryAfter
+	}
+	if ra > limit {
+		return 0, false
+	}
+	return max(d, ra), true
+}
+
+// idempotent 判断请求能否安全地重复发送：幂等方法，或携带 Idempotency-Key 请求头。
+func idempotent(method string, header http.Header) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return header.Get("Idempotency-Key") != ""
+}
@@ -0,0 +1,149 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Empty 用作 Call 的类型参数，表示没有请求体或不解码响应体。
+type Empty struct{}
+
+// Service 声明式的服务客户端：绑定基地址、认证与默认请求头，
+// 通过 Call 以类型化的请求 / 响应调用端点：
+//
+//	users, err := httpx.NewService("https://api.example.com",
+//	    httpx.WithBearerToken(tokens.Token),
+//	    httpx.WithDefaultHeaders(map[string]string{"X-Tenant": "acme"}),
+//	)
+//	user, err := httpx.Call[CreateUser, User](users, ctx, httpx.MethodPost, "/v1/users", req)
+//	user, err = httpx.Call[httpx.Empty, User](users, ctx, httpx.MethodGet, "/v1/users/{id}", httpx.Empty{},
+//	    httpx.PathParam("id", 42))
+//
+// Service 是并发安全的。
+type Service struct {
+	client *Client
+}
+
+// serviceConfig 是 NewService 的构建参数。
+type serviceConfig struct {
+	token   func(context.Context) (string, error)
+	header  map[string]string
+	options []ClientOption
+}
+
+// ServiceOption 配置 Service。
+type ServiceOption func(*serviceConfig)
+
+// WithBearerToken 为每次发送设置 Authorization: Bearer <token>，token 在发送前由 fn 获取，
+// 重试时重新获取，便于配合会自动刷新的令牌源。请求已设置 Authorization 时不覆盖。
+func WithBearerToken(fn func(ctx context.Context) (string, error)) ServiceOption {
+	return func(c *serviceConfig) { c.token = fn }
+}
+
+// WithDefaultHeaders 设置默认请求头 (调用方的 Header 选项会覆盖同名值)。
+func WithDefaultHeaders(headers map[string]string) ServiceOption {
+	return func(c *serviceConfig) {
+		if c.header == nil {
+			c.header = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.header[k] = v
+		}
+	}
+}
+
+// WithClientOptions 追加底层 Client 的选项，如 WithRetryPolicy、WithTimeout、WithMiddleware。
+func WithClientOptions(opts ...ClientOption) ServiceOption {
+	return func(c *serviceConfig) { c.options = append(c.options, opts...) }
+}
+
+// NewService 创建 baseURL 上的 Service。baseURL 必须是带 scheme 与 host 的绝对 URL，
+// 可以包含路径前缀 (如 "https://api.example.com/v2")。
+func NewService(baseURL string, opts ...ServiceOption) (*Service, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("httpx: parse base url %q: %w", baseURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("httpx: base url %q must be absolute", baseURL)
+	}
+
+	cfg := &serviceConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	clientOpts := make([]ClientOption, 0, len(cfg.header)+len(cfg.options)+2)
+	clientOpts = append(clientOpts, WithBaseURL(baseURL))
+	for k, v := range cfg.header {
+		clientOpts = append(clientOpts, WithDefaultHeader(k, v))
+	}
+	if cfg.token != nil {
+		clientOpts = append(clientOpts, WithMiddleware(bearerToken(cfg.token)))
+	}
+	clientOpts = append(clientOpts, cfg.options...)
+	return &Service{client: New(clientOpts...)}, nil
+}
+
+// Client 返回底层 Client，用于 Call 之外的调用 (如 Paginate、流式响应)。
+func (s *Service) Client() *Client { return s.client }
+
+// Call 调用 svc 上的端点：req 编码为 JSON 请求体，响应按 Content-Type 解码为 Resp。
+//
+//   - Req 为 Empty 或 req 为 nil 接口值时不发送请求体；req 实现 Body 时直接使用 (如 Form、Raw)
+//   - Resp 为 Empty、响应为 204 或 HEAD 请求时不解码，响应体被排空并关闭；
+//     Resp 为 []byte / string 时保存原始响应体
+//   - path 中的 {name} 占位符通过 PathParam 替换
+//
+// 状态码被判定为错误时返回 *HTTPError，Resp 为零值。
+func Call[Req, Resp any](svc *Service, ctx context.Context, method, path string, req Req, opts ...RequestOption) (Resp, error) {
+	var out Resp
+
+	var body Body
+	switch r := any(req).(type) {
+	case nil, Empty, *Empty:
+	case Body:
+		body = r
+	default:
+		body = JSON(r)
+	}
+
+	_, empty := any(out).(Empty)
+	decode := Decode(func(resp *http.Response) error {
+		if empty || resp.StatusCode == http.StatusNoContent || method == MethodHead {
+			defer resp.Body.Close()
+			_, err := io.Copy(io.Discard, resp.Body)
+			return err
+		}
+		return decodeAuto(resp, &out)
+	})
+	opts = append(opts[:len(opts):len(opts)], decode) // 不修改调用方的切片
+
+	if _, err := svc.client.Do(ctx, method, path, body, opts...); err != nil {
+		var zero Resp
+		return zero, err
+	}
+	return out, nil
+}
+
+// bearerToken 返回在发送前设置 Bearer 令牌的中间件。
+func bearerToken(fn func(context.Context) (string, error)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "" {
+				return next(req)
+			}
+			token, err := fn(req.Context())
+			if err != nil {
+				return nil, fmt.Errorf("httpx: bearer token: %w", err)
+			}
+			// RoundTripper 不应修改传入的请求
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+token)
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type testUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestService_Call(t *testing.T) {
+	var tokens int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t1" || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == MethodPost && r.URL.Path == "/v2/users":
+			var u testUser
+			_ = json.NewDecoder(r.Body).Decode(&u)
+			u.ID = 7
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			_ = json.NewEncoder(w).Encode(u)
+		case r.Method == MethodGet && r.URL.EscapedPath() == "/v2/users/a%2Fb":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			_ = json.NewEncoder(w).Encode(testUser{ID: 1, Name: string(body)})
+		case r.Method == MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := NewService("/relative"); err == nil {
+		t.Error("expected error for relative base url")
+	}
+	svc, err := NewService(srv.URL+"/v2",
+		WithBearerToken(func(context.Context) (string, error) {
+			atomic.AddInt32(&tokens, 1)
+			return "t1", nil
+		}),
+		WithDefaultHeaders(map[string]string{"X-Tenant": "acme"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	created, err := Call[testUser, testUser](svc, ctx, MethodPost, "/users", testUser{Name: "bob"})
+	if err != nil || created.ID != 7 || created.Name != "bob" {
+		t.Fatalf("POST: %+v %v", created, err)
+	}
+
+	// 路径参数转义，Empty 请求不发送请求体
+	got, err := Call[Empty, testUser](svc, ctx, MethodGet, "/users/{id}", Empty{}, PathParam("id", "a/b"))
+	if err != nil || got.ID != 1 || got.Name != "" {
+		t.Fatalf("GET: %+v %v", got, err)
+	}
+
+	// 204 不解码
+	if _, err := Call[Empty, testUser](svc, ctx, MethodDelete, "/users/{id}", Empty{}, PathParam("id", 1)); err != nil {
+		t.Fatalf("DELETE: %v", err)
+	}
+	if _, err := Call[Empty, Empty](svc, ctx, MethodGet, "/users/{id}", Empty{}, PathParam("uid", 1)); !errors.Is(err, ErrMissingVar) {
+		t.Errorf("expected ErrMissingVar, got %v", err)
+	}
+
+	// 错误状态码返回 HTTPError 与零值，调用方的 Authorization 优先
+	u, err := Call[Empty, testUser](svc, ctx, MethodGet, "/missing", Empty{}, SetHeader("Authorization", "Bearer other"))
+	if !errors.Is(err, &HTTPError{StatusCode: http.StatusUnauthorized}) || u != (testUser{}) {
+		t.Errorf("expected 401 and zero value, got %+v %v", u, err)
+	}
+	if n := atomic.LoadInt32(&tokens); n != 3 {
+		t.Errorf("token fetched %d times, want 3", n)
+	}
+}
+
+func TestService_BearerTokenError(t *testing.T) {
+	errNoToken := errors.New("no token")
+	svc, err := NewService("http://127.0.0.1:1", WithBearerToken(func(context.Context) (string, error) {
+		return "", errNoToken
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Call[Empty, Empty](svc, context.Background(), MethodGet, "/", Empty{}); !errors.Is(err, errNoToken) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
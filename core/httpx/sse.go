@@ -0,0 +1,329 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentTypeEventStream SSE 响应的 Content-Type。
+	ContentTypeEventStream = "text/event-stream"
+
+	defaultSSERetry       = 3 * time.Second
+	defaultSSEBufferSize  = 16
+	defaultSSEMaxLineSize = 1 << 20
+)
+
+// Event 是一条解析后的 server-sent event。
+type Event struct {
+	ID    string        // id 字段；未设置时沿用上一条事件的 ID
+	Event string        // event 字段，缺省为 "message"
+	Data  string        // data 字段，多行以 "\n" 连接
+	Retry time.Duration // retry 字段，未设置时为 0
+}
+
+// SSEOption 配置 Client.SSE。
+type SSEOption func(*sseConfig)
+
+type sseConfig struct {
+	method      string
+	body        Body
+	reqOpts     []RequestOption
+	lastEventID string
+	retry       time.Duration
+	maxRetries  int
+	bufferSize  int
+	maxLineSize int
+	onError     func(error)
+}
+
+// SSERequest 设置请求方法与 body (默认 GET、无 body)。
+// 许多流式 AI 接口要求以 POST 携带 JSON 参数。
+func SSERequest(method string, body Body) SSEOption {
+	return func(c *sseConfig) {
+		c.method = method
+		c.body = body
+	}
+}
+
+// SSERequestOptions 为每次 (重) 连接附加请求选项，如 Bearer / Header / Query。
+func SSERequestOptions(opts ...RequestOption) SSEOption {
+	return func(c *sseConfig) { c.reqOpts = append(c.reqOpts, opts...) }
+}
+
+// SSELastEventID 设置首次连接携带的 Last-Event-ID，用于从断点续传。
+func SSELastEventID(id string) SSEOption {
+	return func(c *sseConfig) { c.lastEventID = id }
+}
+
+// SSERetry 设置默认重连间隔 (默认 3s)，服务端的 retry 字段会覆盖该值。
+func SSERetry(d time.Duration) SSEOption {
+	return func(c *sseConfig) {
+		if d > 0 {
+			c.retry = d
+		}
+	}
+}
+
+// SSEMaxRetries 设置连续重连失败的最大次数，0 表示不限 (默认)。
+// 成功建立连接后计数清零。
+func SSEMaxRetries(n int) SSEOption {
+	return func(c *sseConfig) { c.maxRetries = n }
+}
+
+// SSEBufferSize 设置事件 channel 的缓冲大小 (默认 16)。
+func SSEBufferSize(n int) SSEOption {
+	return func(c *sseConfig) {
+		if n >= 0 {
+			c.bufferSize = n
+		}
+	}
+}
+
+// SSEMaxLineSize 设置单行最大字节数 (默认 1MiB)，超出时视为连接错误并重连。
+func SSEMaxLineSize(n int) SSEOption {
+	return func(c *sseConfig) {
+		if n > 0 {
+			c.maxLineSize = n
+		}
+	}
+}
+
+// SSEOnError 设置错误回调，每次连接中断或重连失败时调用。
+func SSEOnError(fn func(error)) SSEOption {
+	return func(c *sseConfig) { c.onError = fn }
+}
+
+var (
+	// ErrNotEventStream 响应的 Content-Type 不是 text/event-stream。
+	ErrNotEventStream = errors.New("httpx: response is not an event stream")
+
+	// errStopStream 表示服务端要求停止重连 (HTTP 204)。
+	errStopStream = errors.New("httpx: event stream closed by server")
+)
+
+// SSE 订阅 target 的 server-sent events，返回按顺序投递事件的 channel。
+//
+// 首次连接在返回前同步完成，连接失败 (网络错误、HTTPError、非 text/event-stream
+// 响应) 直接返回错误。此后连接断开会按 retry 间隔自动重连，并通过
+// Last-Event-ID 请求头续传。以下情况 channel 被关闭：
+//   - ctx 取消
+//   - 服务端返回 204 No Content
+//   - 重连时收到 HTTPError 等非网络错误
+//   - 连续重连失败超过 SSEMaxRetries
+//
+// 注意：WithTimeout 作用于整个请求 (包括读取 body)，长连接流应避免设置或按需放宽。
+func (c *Client) SSE(ctx context.Context, target string, opts ...SSEOption) (<-chan Event, error) {
+	cfg := &sseConfig{
+		method:      http.MethodGet,
+		retry:       defaultSSERetry,
+		bufferSize:  defaultSSEBufferSize,
+		maxLineSize: defaultSSEMaxLineSize,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	body, err := c.connectSSE(ctx, target, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Event, cfg.bufferSize)
+	go c.streamSSE(ctx, target, cfg, body, ch)
+	return ch, nil
+}
+
+// connectSSE 建立一次 SSE 连接，返回响应 body。
+func (c *Client) connectSSE(ctx context.Context, target string, cfg *sseConfig) (io.ReadCloser, error) {
+	reqOpts := make([]RequestOption, 0, len(cfg.reqOpts)+3)
+	reqOpts = append(reqOpts,
+		SetHeader("Accept", ContentTypeEventStream),
+		SetHeader("Cache-Control", "no-cache"),
+	)
+	if cfg.lastEventID != "" {
+		reqOpts = append(reqOpts, SetHeader("Last-Event-ID", cfg.lastEventID))
+	}
+	reqOpts = append(reqOpts, cfg.reqOpts...)
+
+	resp, err := c.Do(ctx, cfg.method, target, cfg.body, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return nil, errStopStream
+	}
+	if resp.StatusCode != http.StatusOK {
+		// errorOnStatus 被禁用时仍需拒绝非 200 响应
+		return nil, newHTTPError(cfg.method, target, resp)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, ContentTypeEventStream) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: content type %q", ErrNotEventStream, ct)
+	}
+	return resp.Body, nil
+}
+
+// streamSSE 读取事件并在断开后重连，直到 ctx 取消或不可恢复的错误。
+func (c *Client) streamSSE(ctx context.Context, target string, cfg *sseConfig, body io.ReadCloser, ch chan<- Event) {
+	defer close(ch)
+
+	failures := 0
+	for {
+		err := readSSE(ctx, body, cfg, ch)
+		body.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		cfg.report(err)
+
+		for {
+			if cfg.maxRetries > 0 && failures >= cfg.maxRetries {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(cfg.retry):
+			}
+
+			body, err = c.connectSSE(ctx, target, cfg)
+			if err == nil {
+				failures = 0
+				break
+			}
+			if ctx.Err() != nil || errors.Is(err, errStopStream) {
+				return
+			}
+			cfg.report(err)
+			// 服务端明确拒绝 (4xx/5xx、内容类型错误) 不再重试
+			if !isNetworkError(err) {
+				return
+			}
+			failures++
+		}
+	}
+}
+
+func (cfg *sseConfig) report(err error) {
+	if err != nil && cfg.onError != nil {
+		cfg.onError(err)
+	}
+}
+
+// isNetworkError 报告 err 是否为可重连的传输层错误 (非 HTTPError / 内容类型错误)。
+func isNetworkError(err error) bool {
+	var he *HTTPError
+	return !errors.As(err, &he) && !errors.Is(err, ErrNotEventStream)
+}
+
+// readSSE 按 WHATWG EventSource 规范解析事件流，直到 body 结束或出错。
+// 连接断开时尚未以空行结束的事件被丢弃。
+func readSSE(ctx context.Context, body io.Reader, cfg *sseConfig, ch chan<- Event) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), cfg.maxLineSize)
+	scanner.Split(scanSSELines)
+
+	var (
+		data      strings.Builder
+		eventType string
+		idBuf     = cfg.lastEventID
+		retry     time.Duration
+	)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		// 空行：派发事件
+		if len(line) == 0 {
+			cfg.lastEventID = idBuf
+			if data.Len() == 0 {
+				eventType, retry = "", 0
+				continue
+			}
+			ev := Event{
+				ID:    idBuf,
+				Event: eventType,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+				Retry: retry,
+			}
+			if ev.Event == "" {
+				ev.Event = "message"
+			}
+			data.Reset()
+			eventType, retry = "", 0
+
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+
+		// 注释行
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+
+		switch string(field) {
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "event":
+			eventType = string(value)
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				idBuf = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 63); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+				cfg.retry = retry
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// scanSSELines 是 bufio.SplitFunc，按 CRLF、LF 或 CR 切分行。
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// '\r'：需要确认是否紧跟 '\n'
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
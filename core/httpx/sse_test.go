@@ -0,0 +1,144 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func collectEvents(t *testing.T, ch <-chan Event, n int) []Event {
+	t.Helper()
+	var out []Event
+	timeout := time.After(5 * time.Second)
+	for len(out) < n {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return out
+			}
+			out = append(out, ev)
+		case <-timeout:
+			t.Fatalf("timed out after %d/%d events", len(out), n)
+		}
+	}
+	return out
+}
+
+func TestClient_SSE_Parse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != ContentTypeEventStream {
+			t.Errorf("Accept = %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", ContentTypeEventStream)
+		fmt.Fprint(w, ": comment\n\n")
+		fmt.Fprint(w, "data: hello\n\n")
+		fmt.Fprint(w, "event: delta\r\nid: 2\r\ndata: line1\r\ndata:line2\r\n\r\n")
+		fmt.Fprint(w, "data\n\n")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := New().SSE(ctx, srv.URL, SSEMaxRetries(1), SSERetry(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("SSE: %v", err)
+	}
+	events := collectEvents(t, ch, 3)
+	if len(events) != 3 {
+		t.Fatalf("got %d events: %+v", len(events), events)
+	}
+	if events[0].Event != "message" || events[0].Data != "hello" || events[0].ID != "" {
+		t.Errorf("event[0] = %+v", events[0])
+	}
+	if events[1].Event != "delta" || events[1].Data != "line1\nline2" || events[1].ID != "2" {
+		t.Errorf("event[1] = %+v", events[1])
+	}
+	// 空 data 字段仍派发一个空事件，并继承上一条的 ID
+	if events[2].Data != "" || events[2].ID != "2" {
+		t.Errorf("event[2] = %+v", events[2])
+	}
+}
+
+func TestClient_SSE_ReconnectWithLastEventID(t *testing.T) {
+	var conns atomic.Int32
+	var lastID atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeEventStream)
+		switch conns.Add(1) {
+		case 1:
+			fmt.Fprint(w, "retry: 10\nid: 1\ndata: first\n\n")
+			fmt.Fprint(w, "data: partial") // 未以空行结束，断开时丢弃
+		case 2:
+			lastID.Store(r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, "id: 2\ndata: second\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	ch, err := New().SSE(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("SSE: %v", err)
+	}
+	events := collectEvents(t, ch, 3)
+	if len(events) != 2 || events[0].Data != "first" || events[1].Data != "second" {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[0].Retry != 10*time.Millisecond {
+		t.Errorf("retry = %v", events[0].Retry)
+	}
+	if got, _ := lastID.Load().(string); got != "1" {
+		t.Errorf("Last-Event-ID = %q, want 1", got)
+	}
+}
+
+func TestClient_SSE_ConnectErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := New(WithBaseURL(srv.URL))
+	if _, err := c.SSE(context.Background(), "/auth"); !errors.Is(err, &HTTPError{StatusCode: 401}) {
+		t.Errorf("err = %v, want 401 HTTPError", err)
+	}
+	if _, err := c.SSE(context.Background(), "/json"); !errors.Is(err, ErrNotEventStream) {
+		t.Errorf("err = %v, want ErrNotEventStream", err)
+	}
+}
+
+func TestClient_SSE_ContextCancelClosesChannel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeEventStream)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := New().SSE(ctx, srv.URL)
+	if err != nil {
+		t.Fatalf("SSE: %v", err)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected channel to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
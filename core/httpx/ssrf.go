@@ -0,0 +1,208 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrSSRFBlocked 请求被 SSRF 防护策略拦截。拦截发生在发送前或建立连接时，
+// 返回的错误可通过 errors.Is(err, ErrSSRFBlocked) 判断。
+var ErrSSRFBlocked = errors.New("httpx: blocked by ssrf policy")
+
+// SSRFPolicy 服务端请求伪造 (SSRF) 防护策略，见 WithSSRFProtection。零值即为最严格的默认策略：
+// 只允许 http / https，禁止访问回环、私有、链路本地、组播及其他保留地址。
+type SSRFPolicy struct {
+	// AllowedSchemes 允许的协议，默认 http、https。
+	AllowedSchemes []string
+	// AllowedHosts host 白名单，支持 "*.example.com" 匹配子域名 (不含 example.com 本身)。
+	// 为空表示不限制 host，但解析出的 IP 仍需通过地址校验。
+	AllowedHosts []string
+	// DeniedHosts host 黑名单，规则同 AllowedHosts，优先于白名单。
+	DeniedHosts []string
+	// AllowedPorts 允许的端口，为空表示不限制。
+	AllowedPorts []int
+	// AllowedPrefixes 放行的网段，优先于内置的禁止网段，用于访问指定的内部服务。
+	AllowedPrefixes []netip.Prefix
+	// DeniedPrefixes 额外禁止的网段。
+	DeniedPrefixes []netip.Prefix
+}
+
+// reservedPrefixes 除 netip.Addr 方法可识别的地址外，需要额外禁止的保留网段。
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"),   // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF 协议分配
+	netip.MustParsePrefix("192.0.2.0/24"),    // 文档
+	netip.MustParsePrefix("198.18.0.0/15"),   // 基准测试
+	netip.MustParsePrefix("198.51.100.0/24"), // 文档
+	netip.MustParsePrefix("203.0.113.0/24"),  // 文档
+	netip.MustParsePrefix("240.0.0.0/4"),     // 保留
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64，可映射到任意 IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // 本地 NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // 文档
+	netip.MustParsePrefix("2002::/16"),       // 6to4，可映射到任意 IPv4
+}
+
+// WithSSRFProtection 启用 SSRF 防护，用于按用户提供的 URL 发起服务端请求的场景：
+//
+//   - 发送前校验协议、host、端口；重定向的每一跳同样校验，越过策略的重定向会被拦截
+//   - 建立连接时校验实际连接的 IP，DNS 解析到内网地址 (包括 DNS rebinding) 会被拦截
+//
+// 连接校验要求底层 RoundTripper 为 *http.Transport (默认即是)，启用后会使用其副本并忽略 Proxy，
+// 否则代理会代替客户端解析目标地址而绕过校验。底层为其他 RoundTripper 时退化为发送前解析校验，
+// 无法防御 DNS rebinding。
+func WithSSRFProtection(policy SSRFPolicy) ClientOption {
+	return func(cli *Client) {
+		cli.ssrf = newSSRFGuard(policy)
+	}
+}
+
+// ssrfGuard 编译后的 SSRF 防护策略。
+type ssrfGuard struct {
+	policy  SSRFPolicy
+	schemes []string
+	dialed  bool // 底层 Transport 是否已在建立连接时校验 IP
+}
+
+func newSSRFGuard(policy SSRFPolicy) *ssrfGuard {
+	g := &ssrfGuard{policy: policy, schemes: []string{"http", "https"}}
+	if len(policy.AllowedSchemes) > 0 {
+		g.schemes = make([]string, len(policy.AllowedSchemes))
+		for i, s := range policy.AllowedSchemes {
+			g.schemes[i] = strings.ToLower(s)
+		}
+	}
+	return g
+}
+
+// transport 返回在建立连接时校验 IP 的 RoundTripper。
+func (g *ssrfGuard) transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t, ok := base.(*http.Transport)
+	if !ok {
+		return base
+	}
+	t = t.Clone()
+	t.Proxy = nil
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrSSRFBlocked, err)
+			}
+			return g.checkAddr(addr.Addr())
+		},
+	}
+	t.DialContext = dialer.DialContext
+	g.dialed = true
+	return t
+}
+
+// middleware 在每次实际发送 (包括重定向的每一跳) 前校验 URL。
+func (g *ssrfGuard) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if err := g.checkRequest(req); err != nil {
+			return nil, err
+		}
+		return next(req)
+	}
+}
+
+// checkRequest 校验请求的协议、host、端口；未在连接时校验 IP 时解析并校验 host。
+func (g *ssrfGuard) checkRequest(req *http.Request) error {
+	u := req.URL
+	if !slices.Contains(g.schemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: scheme %q not allowed", ErrSSRFBlocked, u.Scheme)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrSSRFBlocked)
+	}
+	if matchHost(host, g.policy.DeniedHosts) {
+		return fmt.Errorf("%w: host %q denied", ErrSSRFBlocked, host)
+	}
+	if len(g.policy.AllowedHosts) > 0 && !matchHost(host, g.policy.AllowedHosts) {
+		return fmt.Errorf("%w: host %q not allowed", ErrSSRFBlocked, host)
+	}
+	if len(g.policy.AllowedPorts) > 0 {
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[strings.ToLower(u.Scheme)]
+		}
+		n, _ := strconv.Atoi(port)
+		if !slices.Contains(g.policy.AllowedPorts, n) {
+			return fmt.Errorf("%w: port %s not allowed", ErrSSRFBlocked, port)
+		}
+	}
+
+	// IP 字面量直接校验，尽早给出明确的错误
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.checkAddr(addr)
+	}
+	if g.dialed {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(req.Context(), "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := g.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAddr 校验目标 IP。
+func (g *ssrfGuard) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, p := range g.policy.AllowedPrefixes {
+		if p.Contains(addr) {
+			return nil
+		}
+	}
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return fmt.Errorf("%w: address %s not allowed", ErrSSRFBlocked, addr)
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return fmt.Errorf("%w: address %s not allowed", ErrSSRFBlocked, addr)
+		}
+	}
+	for _, p := range g.policy.DeniedPrefixes {
+		if p.Contains(addr) {
+			return fmt.Errorf("%w: address %s denied", ErrSSRFBlocked, addr)
+		}
+	}
+	return nil
+}
+
+// matchHost 判断 host 是否匹配 patterns 中的任一项。
+func matchHost(host string, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if suffix, ok := strings.CutPrefix(p, "*"); ok && strings.HasPrefix(suffix, ".") {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestSSRF_BlocksPrivateAddresses(t *testing.T) {
+	srv := newEchoServer(t)
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := New(WithSSRFProtection(SSRFPolicy{}))
+	if _, err := c.Get(ctx, srv.URL); !errors.Is(err, ErrSSRFBlocked) {
+		t.Fatalf("loopback literal: expected ErrSSRFBlocked, got %v", err)
+	}
+	// 主机名在建立连接时才解析到回环地址
+	port := srv.URL[strings.LastIndex(srv.URL, ":"):]
+	if _, err := c.Get(ctx, "http://localhost"+port); !errors.Is(err, ErrSSRFBlocked) {
+		t.Fatalf("resolved loopback: expected ErrSSRFBlocked, got %v", err)
+	}
+	for _, target := range []string{
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::ffff:10.0.0.1]/",
+		"http://100.64.0.1/",
+		"ftp://example.com/",
+	} {
+		if _, err := c.Get(ctx, target); !errors.Is(err, ErrSSRFBlocked) {
+			t.Errorf("%s: expected ErrSSRFBlocked, got %v", target, err)
+		}
+	}
+
+	// 非 *http.Transport 时发送前解析校验
+	var sent bool
+	custom := New(
+		WithTransport(RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			sent = true
+			return nil, errors.New("unreachable")
+		})),
+		WithSSRFProtection(SSRFPolicy{}),
+	)
+	if _, err := custom.Get(ctx, "http://localhost"+port); !errors.Is(err, ErrSSRFBlocked) || sent {
+		t.Errorf("custom transport: expected ErrSSRFBlocked before sending, got %v (sent=%v)", err, sent)
+	}
+}
+
+func TestSSRF_Policy(t *testing.T) {
+	srv := newEchoServer(t)
+	defer srv.Close()
+	ctx := context.Background()
+	loopback := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+	c := New(WithSSRFProtection(SSRFPolicy{AllowedPrefixes: loopback}))
+	if _, err := c.Get(ctx, srv.URL); err != nil {
+		t.Fatalf("allowed prefix: %v", err)
+	}
+
+	c = New(WithSSRFProtection(SSRFPolicy{AllowedPrefixes: loopback, AllowedHosts: []string{"*.example.com"}}))
+	if _, err := c.Get(ctx, srv.URL); !errors.Is(err, ErrSSRFBlocked) {
+		t.Errorf("host allowlist: expected ErrSSRFBlocked, got %v", err)
+	}
+
+	c = New(WithSSRFProtection(SSRFPolicy{AllowedPrefixes: loopback, AllowedPorts: []int{443}}))
+	if _, err := c.Get(ctx, srv.URL); !errors.Is(err, ErrSSRFBlocked) {
+		t.Errorf("port allowlist: expected ErrSSRFBlocked, got %v", err)
+	}
+
+	if !matchHost("api.example.com", []string{"*.example.com"}) || matchHost("example.com", []string{"*.example.com"}) ||
+		matchHost("evilexample.com", []string{"*.example.com"}) {
+		t.Error("unexpected wildcard host matching")
+	}
+}
+
+func TestSSRF_Redirect(t *testing.T) {
+	ctx := context.Background()
+	target := newEchoServer(t)
+	defer target.Close()
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	// 放行回环地址但拒绝 localhost：重定向到 localhost 被拦截
+	c := New(WithSSRFProtection(SSRFPolicy{
+		AllowedPrefixes: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+		DeniedHosts:     []string{"localhost"},
+	}))
+	port := target.URL[strings.LastIndex(target.URL, ":"):]
+	if _, err := c.Get(ctx, redirector.URL, Query("to", target.URL)); err != nil {
+		t.Fatalf("redirect within policy: %v", err)
+	}
+	if _, err := c.Get(ctx, redirector.URL, Query("to", "http://localhost"+port)); !errors.Is(err, ErrSSRFBlocked) {
+		t.Errorf("redirect to denied host: expected ErrSSRFBlocked, got %v", err)
+	}
+	if _, err := c.Get(ctx, redirector.URL, Query("to", "http://169.254.169.254/")); !errors.Is(err, ErrSSRFBlocked) {
+		t.Errorf("redirect to metadata: expected ErrSSRFBlocked, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// errBodyReplaced 流式请求体已被重试时的新请求接管，旧请求不能再读取。
+var errBodyReplaced = errors.New("httpx: request body replaced by retry")
+
+// File multipart/form-data 请求体中的一个文件，见 Multipart。
+type File struct {
+	Field       string    // 表单字段名
+	Name        string    // 文件名
+	ContentType string    // 默认 application/octet-stream
+	Reader      io.Reader // 文件内容，由调用方关闭
+}
+
+// streamBody 以流方式发送、不缓冲到内存的请求体，见 Binary / Multipart。
+//
+// 只有 reader 全部实现 io.Seeker 时才可重放：每次重试 (以及 307 / 308 重定向) 前
+// 回到首次发送时的位置；否则请求只发送一次，不会重试。
+type streamBody struct {
+	contentType string
+	readers     []io.Reader
+	open        func() (io.ReadCloser, error) // 打开一份新的请求体，readers 已回到起始位置
+	size        func() int64                  // 请求体长度，未知时为 -1
+
+	mu      sync.Mutex
+	starts  []int64 // 各 reader 首次发送时的位置，nil 表示尚未发送
+	release func()  // 使上一次打开的请求体失效，并等待其停止读取 readers
+}
+
+// Encode 一次性读出整个请求体，仅用于直接调用 Body.Encode 的场景；Do 会以流方式发送。
+func (b *streamBody) Encode() ([]byte, string, error) {
+	rc, _, err := b.reopen()
+	if err != nil {
+		return nil, "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, "", fmt.Errorf("httpx: read body: %w", err)
+	}
+	return data, b.contentType, nil
+}
+
+// replayable 请求体能否多次发送。
+func (b *streamBody) replayable() bool {
+	return !slices.ContainsFunc(b.readers, func(r io.Reader) bool {
+		_, ok := r.(io.Seeker)
+		return !ok
+	})
+}
+
+// reopen 打开一份新的请求体，返回请求体与长度 (未知时为 -1)。
+// 上一次打开的请求体随之失效，不可重放时第二次调用返回错误。
+func (b *streamBody) reopen() (io.ReadCloser, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.starts != nil && !b.replayable() {
+		return nil, 0, errors.New("httpx: streaming body cannot be replayed")
+	}
+	if b.release != nil {
+		b.release()
+		b.release = nil
+	}
+	if b.starts == nil {
+		b.starts = make([]int64, len(b.readers))
+		for i, r := range b.readers {
+			if s, ok := r.(io.Seeker); ok {
+				pos, err := s.Seek(0, io.SeekCurrent)
+				if err != nil {
+					return nil, 0, fmt.Errorf("httpx: seek body: %w", err)
+				}
+				b.starts[i] = pos
+			}
+		}
+	} else {
+		for i, r := range b.readers {
+			if _, err := r.(io.Seeker).Seek(b.starts[i], io.SeekStart); err != nil {
+				return nil, 0, fmt.Errorf("httpx: seek body: %w", err)
+			}
+		}
+	}
+
+	rc, err := b.open()
+	if err != nil {
+		return nil, 0, err
+	}
+	return rc, b.size(), nil
+}
+
+// Binary 以 contentType 流式发送 r 的内容，不读入内存，适合上传大文件。
+// contentType 为空时为 application/octet-stream。
+//
+// r 为 *os.File、*bytes.Reader、*strings.Reader 等可获知长度的类型时设置 Content-Length，
+// 否则以 chunked 方式发送。r 实现 io.Seeker 时可重试，否则请求只发送一次。
+// r 由调用方关闭，且在 Do 返回前不应被其他地方读取。
+func Binary(contentType string, r io.Reader) Body {
+	if contentType == "" {
+		contentType = ContentTypeOctetStream
+	}
+	if r == nil {
+		return Raw(contentType, nil)
+	}
+	b := &streamBody{contentType: contentType, readers: []io.Reader{r}}
+	b.open = func() (io.ReadCloser, error) {
+		lr := &leaseReader{r: r}
+		b.release = lr.revoke
+		return lr, nil
+	}
+	b.size = func() int64 { return readerSize(r) }
+	return b
+}
+
+// Multipart 以 multipart/form-data 流式发送表单字段与文件，文件内容边读边发，不读入内存。
+//
+// 字段按名称排序写在文件之前。所有文件长度可知时 (见 Binary) 设置 Content-Length，
+// 否则以 chunked 方式发送；所有文件实现 io.Seeker 时可重试，否则请求只发送一次。
+// 文件由调用方关闭，且在 Do 返回前不应被其他地方读取。
+func Multipart(fields map[string]string, files ...File) Body {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	b := &streamBody{contentType: "multipart/form-data; boundary=" + boundary}
+	for _, f := range files {
+		if f.Reader != nil {
+			b.readers = append(b.readers, f.Reader)
+		}
+	}
+
+	// write 写出整个请求体；content 为 false 时跳过文件内容，用于计算长度
+	write := func(w io.Writer, content bool) error {
+		mw := multipart.NewWriter(w)
+		if err := mw.SetBoundary(boundary); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := mw.WriteField(name, fields[name]); err != nil {
+				return err
+			}
+		}
+		for _, f := range files {
+			part, err := mw.CreatePart(fileHeader(f))
+			if err != nil {
+				return err
+			}
+			if content && f.Reader != nil {
+				if _, err := io.Copy(part, f.Reader); err != nil {
+					return err
+				}
+			}
+		}
+		return mw.Close()
+	}
+
+	b.open = func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			pw.CloseWithError(write(pw, true))
+		}()
+		// 上一次的请求体关闭后，写协程在下一次写入时退出，此后才能移动文件位置
+		b.release = func() {
+			_ = pr.CloseWithError(errBodyReplaced)
+			<-done
+		}
+		return pr, nil
+	}
+	b.size = func() int64 {
+		var counter countWriter
+		if err := write(&counter, false); err != nil {
+			return -1
+		}
+		total := counter.n
+		for _, f := range files {
+			if f.Reader == nil {
+				continue
+			}
+			n := readerSize(f.Reader)
+			if n < 0 {
+				return -1
+			}
+			total += n
+		}
+		return total
+	}
+	return b
+}
+
+// fileHeader 构造文件分段的头部。
+func fileHeader(f File) textproto.MIMEHeader {
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = ContentTypeOctetStream
+	}
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escape.Replace(f.Field), escape.Replace(f.Name)))
+	h.Set("Content-Type", contentType)
+	return h
+}
+
+// readerSize 返回 r 剩余的字节数，无法获知时为 -1。
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case nil:
+		return 0
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case interface {
+		Stat() (fs.FileInfo, error)
+		io.Seeker
+	}:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		pos, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - pos
+	}
+	return -1
+}
+
+// leaseReader 对 reader 的一次租用。Transport 可能在 RoundTrip 返回后仍在其他协程中读取请求体，
+// 重试前 revoke 使其后续读取失败，再移动 reader 的位置。
+type leaseReader struct {
+	mu      sync.Mutex
+	r       io.Reader
+	revoked bool
+}
+
+func (l *leaseReader) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.revoked {
+		return 0, errBodyReplaced
+	}
+	return l.r.Read(p)
+}
+
+// Close 不关闭底层 reader，由调用方负责。
+func (l *leaseReader) Close() error {
+	l.revoke()
+	return nil
+}
+
+func (l *leaseReader) revoke() {
+	l.mu.Lock()
+	l.revoked = true
+	l.mu.Unlock()
+}
+
+// countWriter 只统计写入的字节数。
+type countWriter struct{ n int64 }
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
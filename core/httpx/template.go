@@ -0,0 +1,253 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrTemplateNotFound 调用了未在集合中声明的模板。
+	ErrTemplateNotFound = errors.New("httpx: template not found")
+	// ErrMissingVar 路径占位符或必填 body 字段缺少对应变量。
+	ErrMissingVar = errors.New("httpx: missing template variable")
+)
+
+// Vars 是调用模板时传入的变量。
+type Vars map[string]any
+
+// Template 描述一个可复用的请求模板。
+//
+// Path、Headers、Query 的值可以包含 {name} 占位符，调用时由 Vars 替换：
+//   - Path 中的占位符必须提供，值会做路径转义
+//   - Headers / Query 中引用了缺失变量的条目会被跳过，其余条目作为默认值发送
+//
+// 调用方通过 RequestOption 传入的 Header / Query 会覆盖或追加到模板默认值之上。
+type Template struct {
+	Name    string            `yaml:"name"`
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Headers map[string]string `yaml:"headers"`
+	Query   map[string]string `yaml:"query"`
+	Body    *BodySchema       `yaml:"body"`
+}
+
+// BodySchema 描述模板的请求体：字段从 Vars 中取值，缺失时使用默认值。
+type BodySchema struct {
+	// Type 为 json (默认) 或 form。
+	Type   string      `yaml:"type"`
+	Fields []BodyField `yaml:"fields"`
+}
+
+// BodyField 描述请求体中的一个字段。
+type BodyField struct {
+	Name     string `yaml:"name"`
+	Required bool   `yaml:"required"`
+	Default  any    `yaml:"default"`
+}
+
+// Collection 是一组按名称索引的请求模板，构造后只读，可在多个 Client 间共享。
+type Collection struct {
+	templates map[string]*Template
+}
+
+// placeholderRe 匹配 {name} 占位符。
+var placeholderRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// NewCollection 以编程方式声明模板集合。名称为空或重复、缺少 Method/Path、
+// body 类型不支持时返回错误。
+func NewCollection(templates ...Template) (*Collection, error) {
+	col := &Collection{templates: make(map[string]*Template, len(templates))}
+	for i := range templates {
+		t := templates[i]
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := col.templates[t.Name]; exists {
+			return nil, fmt.Errorf("httpx: duplicate template %q", t.Name)
+		}
+		t.Method = strings.ToUpper(t.Method)
+		col.templates[t.Name] = &t
+	}
+	return col, nil
+}
+
+// LoadCollection 从 YAML 读取模板集合：
+//
+//	templates:
+//	  - name: createUser
+//	    method: POST
+//	    path: /users
+//	    headers:
+//	      X-Tenant: "{tenant}"
+//	    body:
+//	      fields:
+//	        - name: name
+//	          required: true
+//	        - name: role
+//	          default: member
+//	  - name: getUser
+//	    method: GET
+//	    path: /users/{id}
+//	    query:
+//	      expand: profile
+func LoadCollection(r io.Reader) (*Collection, error) {
+	var doc struct {
+		Templates []Template `yaml:"templates"`
+	}
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("httpx: decode collection: %w", err)
+	}
+	return NewCollection(doc.Templates...)
+}
+
+// LoadCollectionFile 从 YAML 文件读取模板集合。
+func LoadCollectionFile(path string) (*Collection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("httpx: open collection: %w", err)
+	}
+	defer f.Close()
+	return LoadCollection(f)
+}
+
+// Template 返回指定名称的模板。
+func (col *Collection) Template(name string) (Template, bool) {
+	t, ok := col.templates[name]
+	if !ok {
+		return Template{}, false
+	}
+	return *t, true
+}
+
+// validate 校验模板定义。
+func (t *Template) validate() error {
+	if t.Name == "" {
+		return errors.New("httpx: template name is empty")
+	}
+	if t.Method == "" || t.Path == "" {
+		return fmt.Errorf("httpx: template %q: method and path are required", t.Name)
+	}
+	if t.Body != nil {
+		switch strings.ToLower(t.Body.Type) {
+		case "", "json", "form":
+		default:
+			return fmt.Errorf("httpx: template %q: unsupported body type %q", t.Name, t.Body.Type)
+		}
+	}
+	return nil
+}
+
+// WithCollection 为 Client 挂载模板集合，之后可通过 Call 按名称发起请求。
+func WithCollection(col *Collection) ClientOption {
+	return func(cli *Client) { cli.collection = col }
+}
+
+// Call 按名称调用模板：用 vars 渲染路径、默认请求头 / query 与请求体后发送。
+// out 不为 nil 时等同于附加 Into(out)。opts 可追加或覆盖模板的默认值。
+//
+//	var user User
+//	_, err := client.Call(ctx, "createUser", httpx.Vars{"tenant": "acme", "name": "bob"}, &user)
+func (c *Client) Call(ctx context.Context, name string, vars Vars, out any, opts ...RequestOption) (*http.Response, error) {
+	if c.collection == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	t, ok := c.collection.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	path, err := renderPath(t.Path, vars)
+	if err != nil {
+		return nil, fmt.Errorf("httpx: template %q: %w", name, err)
+	}
+
+	var body Body
+	if t.Body != nil {
+		if body, err = t.Body.build(vars); err != nil {
+			return nil, fmt.Errorf("httpx: template %q: %w", name, err)
+		}
+	}
+
+	// 模板默认值在前，调用方选项在后，以便覆盖
+	all := make([]RequestOption, 0, len(t.Headers)+len(t.Query)+len(opts)+1)
+	for k, v := range t.Headers {
+		if rv, ok := render(v, vars); ok {
+			all = append(all, SetHeader(k, rv))
+		}
+	}
+	for k, v := range t.Query {
+		if rv, ok := render(v, vars); ok {
+			all = append(all, SetQuery(k, rv))
+		}
+	}
+	all = append(all, opts...)
+	if out != nil {
+		all = append(all, Into(out))
+	}
+	return c.Do(ctx, t.Method, path, body, all...)
+}
+
+// renderPath 替换路径占位符，缺失变量时返回 ErrMissingVar。
+func renderPath(path string, vars Vars) (string, error) {
+	var missing string
+	out := placeholderRe.ReplaceAllStringFunc(path, func(m string) string {
+		key := m[1 : len(m)-1]
+		v, ok := vars[key]
+		if !ok {
+			if missing == "" {
+				missing = key
+			}
+			return m
+		}
+		return url.PathEscape(fmt.Sprint(v))
+	})
+	if missing != "" {
+		return "", fmt.Errorf("%w: %s", ErrMissingVar, missing)
+	}
+	return out, nil
+}
+
+// render 替换 s 中的占位符；引用了缺失变量时返回 false。
+func render(s string, vars Vars) (string, bool) {
+	ok := true
+	out := placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+		v, found := vars[m[1:len(m)-1]]
+		if !found {
+			ok = false
+			return m
+		}
+		return fmt.Sprint(v)
+	})
+	return out, ok
+}
+
+// build 按 schema 从 vars 组装请求体。
+func (s *BodySchema) build(vars Vars) (Body, error) {
+	fields := make(map[string]any, len(s.Fields))
+	for _, f := range s.Fields {
+		if v, ok := vars[f.Name]; ok {
+			fields[f.Name] = v
+		} else if f.Default != nil {
+			fields[f.Name] = f.Default
+		} else if f.Required {
+			return nil, fmt.Errorf("%w: %s", ErrMissingVar, f.Name)
+		}
+	}
+	if strings.EqualFold(s.Type, "form") {
+		values := make(url.Values, len(fields))
+		for k, v := range fields {
+			values.Set(k, fmt.Sprint(v))
+		}
+		return Form(values), nil
+	}
+	return JSON(fields), nil
+}
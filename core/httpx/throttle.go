@@ -0,0 +1,239 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ThrottleConfig 自适应限流配置，见 WithAdaptiveThrottle。零值字段使用默认值。
+type ThrottleConfig struct {
+	// Rate 每个 host 的基准发送速率 (请求/秒)，默认 10。
+	Rate float64
+	// Burst 令牌桶容量，默认 max(1, Rate)。
+	Burst int
+	// Decrease 每次收到 429 / 503 时限流系数乘以该值，取值 (0, 1)，默认 0.5。
+	Decrease float64
+	// MinFactor 限流系数下限，默认 0.05 (即最低为基准速率的 5%)。
+	MinFactor float64
+	// Recovery 限流系数从 0 线性恢复到 1 所需的时长，默认 1m。
+	// 期间再次收到 429 / 503 会重新下调。
+	Recovery time.Duration
+	// MaxRetryAfter Retry-After 的上限，避免对端返回过大的值导致长时间停发，默认 1m。
+	MaxRetryAfter time.Duration
+}
+
+func (c *ThrottleConfig) setDefaults() {
+	if c.Rate <= 0 {
+		c.Rate = 10
+	}
+	if c.Burst <= 0 {
+		c.Burst = max(1, int(c.Rate))
+	}
+	if c.Decrease <= 0 || c.Decrease >= 1 {
+		c.Decrease = 0.5
+	}
+	if c.MinFactor <= 0 || c.MinFactor > 1 {
+		c.MinFactor = 0.05
+	}
+	if c.Recovery <= 0 {
+		c.Recovery = time.Minute
+	}
+	if c.MaxRetryAfter <= 0 {
+		c.MaxRetryAfter = time.Minute
+	}
+}
+
+// WithAdaptiveThrottle 启用按 host 的自适应限流：
+//
+//   - 每个 host 独立的令牌桶，速率为 Rate * 限流系数
+//   - 收到 429 / 503 时系数按 Decrease 下调；带 Retry-After 时在该时间之前暂停向此 host 发送
+//   - 之后系数随时间线性恢复，恢复到 1 即回到基准速率
+//
+// 限流作用于每一次实际发送 (包括重试)，位于中间件链最内层，
+// 因此与 WithRetry 配合时，重试会自动遵守对端的 Retry-After。
+// 等待期间 ctx 取消时请求返回 ctx.Err()。当前限流状态可通过 Stats 查看。
+func WithAdaptiveThrottle(cfg ThrottleConfig) ClientOption {
+	return func(cli *Client) {
+		cfg.setDefaults()
+		cli.throttle = &throttler{cfg: cfg, hosts: make(map[string]*hostBucket)}
+	}
+}
+
+// Stats 客户端运行时统计。
+type Stats struct {
+	// Hosts 各 host 的限流状态，未启用 WithAdaptiveThrottle 时为空。
+	Hosts map[string]HostStats
+	// Queue 请求队列状态，未启用 WithQueue 时为 nil。
+	Queue *QueueStats
+	// Circuits 各 host 的熔断状态，未启用 WithCircuitBreaker 时为空。
+	Circuits map[string]CircuitStats
+	// HostLimits 各 host 的在途请求状态，未启用 WithHostLimit 时为空。
+	HostLimits map[string]HostLimitStats
+}
+
+// HostStats 单个 host 的限流状态。
+type HostStats struct {
+	Factor     float64   // 当前限流系数 (0, 1]，1 表示未被限流
+	Rate       float64   // 当前有效速率 (请求/秒)
+	PauseUntil time.Time // Retry-After 要求的暂停截止时间，零值或已过期表示未暂停
+	Throttled  int64     // 累计收到的 429 / 503 次数
+}
+
+// Stats 返回客户端运行时统计的快照。
+func (c *Client) Stats() Stats {
+	var st Stats
+	if c.throttle != nil {
+		st.Hosts = c.throttle.stats(time.Now())
+	}
+	if c.queue != nil {
+		st.Queue = c.queue.stats()
+	}
+	if c.circuit != nil {
+		st.Circuits = c.circuit.stats()
+	}
+	if c.hostLimit != nil {
+		st.HostLimits = c.hostLimit.stats()
+	}
+	return st
+}
+
+// throttler 按 host 管理自适应令牌桶。
+type throttler struct {
+	cfg   ThrottleConfig
+	mu    sync.Mutex
+	hosts map[string]*hostBucket
+}
+
+// hostBucket 单个 host 的令牌桶。限流系数惰性计算：
+// factor(now) = min(1, base + (now - since) / Recovery)。
+type hostBucket struct {
+	tokens     float64
+	last       time.Time // 上次补充令牌的时间
+	base       float64   // since 时刻的限流系数
+	since      time.Time
+	pauseUntil time.Time
+	throttled  int64
+}
+
+func (t *throttler) bucket(host string, now time.Time) *hostBucket {
+	b, ok := t.hosts[host]
+	if !ok {
+		b = &hostBucket{tokens: float64(t.cfg.Burst), last: now, base: 1, since: now}
+		t.hosts[host] = b
+	}
+	return b
+}
+
+func (t *throttler) factor(b *hostBucket, now time.Time) float64 {
+	f := b.base + float64(now.Sub(b.since))/float64(t.cfg.Recovery)
+	return min(1, f)
+}
+
+// reserve 尝试取得一个令牌，返回仍需等待的时长；返回 0 表示已取得。
+func (t *throttler) reserve(host string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.bucket(host, now)
+	if now.Before(b.pauseUntil) {
+		return b.pauseUntil.Sub(now)
+	}
+	rate := t.cfg.Rate * t.factor(b, now)
+	if now.After(b.last) {
+		b.tokens = min(float64(t.cfg.Burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// wait 阻塞直到可以向 host 发送请求或 ctx 取消。
+func (t *throttler) wait(ctx context.Context, host string) error {
+	for {
+		d := t.reserve(host, time.Now())
+		if d <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// observe 根据响应调整限流状态。
+func (t *throttler) observe(host string, resp *http.Response, now time.Time) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.bucket(host, now)
+	b.throttled++
+	b.base = max(t.cfg.MinFactor, t.factor(b, now)*t.cfg.Decrease)
+	b.since = now
+	// 丢弃已积累的突发额度，避免下调后仍立即打出一批请求
+	b.tokens = min(b.tokens, 0)
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		if until := now.Add(min(d, t.cfg.MaxRetryAfter)); until.After(b.pauseUntil) {
+			b.pauseUntil = until
+		}
+	}
+}
+
+func (t *throttler) stats(now time.Time) map[string]HostStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make(map[string]HostStats, len(t.hosts))
+	for host, b := range t.hosts {
+		f := t.factor(b, now)
+		out[host] = HostStats{
+			Factor:     f,
+			Rate:       t.cfg.Rate * f,
+			PauseUntil: b.pauseUntil,
+			Throttled:  b.throttled,
+		}
+	}
+	return out
+}
+
+// middleware 在每次发送前等待令牌，并根据响应调整限流。
+func (t *throttler) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		host := req.URL.Host
+		if err := t.wait(req.Context(), host); err != nil {
+			return nil, err
+		}
+		resp, err := next(req)
+		t.observe(host, resp, time.Now())
+		return resp, err
+	}
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数与 HTTP 日期两种格式。
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(0, at.Sub(now)), true
+	}
+	return 0, false
+}
@@ -2,6 +2,7 @@ package httpx
 
 import (
 	"fmt"
+	"maps"
 	"net/url"
 	"path"
 	"slices"
@@ -16,6 +17,7 @@ type URLBuilder struct {
 	path     strings.Builder
 	query    url.Values
 	fragment string
+	params   Vars // 路径参数，见 PathParam
 }
 
 // NewURLBuilder 创建新的URL构建器实例
@@ -83,6 +85,16 @@ func (b *URLBuilder) AppendPath(segments ...string) *URLBuilder {
 	return b
 }
 
+// PathParam 设置路径参数，Build 时替换路径中的 {name} 占位符，值会做路径转义。
+// 设置了路径参数时，路径中未提供值的占位符会使 Build 返回 ErrMissingVar。
+func (b *URLBuilder) PathParam(name string, value any) *URLBuilder {
+	if b.params == nil {
+		b.params = make(Vars)
+	}
+	b.params[name] = value
+	return b
+}
+
 // Query 添加单个查询参数
 func (b *URLBuilder) Query(key, value string) *URLBuilder {
 	b.query.Add(key, value)
@@ -132,6 +144,17 @@ func (b *URLBuilder) Build() (string, error) {
 		Host:   b.buildHost(),
 		Path:   b.path.String(),
 	}
+	if len(b.params) > 0 {
+		// RawPath 保留转义后的参数值，使值中的 "/" 等字符不会被当作路径分隔符
+		raw, err := renderPath(u.Path, b.params)
+		if err != nil {
+			return "", err
+		}
+		if u.Path, err = url.PathUnescape(raw); err != nil {
+			return "", err
+		}
+		u.RawPath = raw
+	}
 
 	if len(b.query) > 0 {
 		u.RawQuery = b.query.Encode()
@@ -158,6 +181,7 @@ func (b *URLBuilder) Reset() *URLBuilder {
 	b.path.Reset()
 	b.query = make(url.Values)
 	b.fragment = ""
+	b.params = nil
 	return b
 }
 
@@ -178,6 +202,7 @@ func (b *URLBuilder) Clone() *URLBuilder {
 	for k, v := range b.query {
 		newBuilder.query[k] = slices.Clone(v)
 	}
+	newBuilder.params = maps.Clone(b.params)
 
 	return newBuilder
 }
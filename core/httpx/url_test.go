@@ -1,6 +1,7 @@
 package httpx
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"testing"
@@ -665,3 +666,25 @@ func ExampleFromURL() {
 	fmt.Println(newURL)
 	// Output: https://example.com/new/path?new=param
 }
+
+func TestURLBuilder_PathParam(t *testing.T) {
+	got, err := BuildHTTPS("api.example.com", "users", "{id}", "files", "{name}").
+		PathParam("id", 42).
+		PathParam("name", "a b/c").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://api.example.com/users/42/files/a%20b%2Fc"; got != want {
+		t.Errorf("Build() = %q, want %q", got, want)
+	}
+
+	b := NewURLBuilder().Path("/users/{id}").PathParam("other", 1)
+	if _, err := b.Build(); !errors.Is(err, ErrMissingVar) {
+		t.Errorf("expected ErrMissingVar, got %v", err)
+	}
+	// 未设置路径参数时占位符原样保留
+	if got := NewURLBuilder().Path("/users/{id}").String(); got != "/users/%7Bid%7D" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package rate
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed concurrency.lua
+	concurrencyLua       string
+	concurrencyLuaScript = redis.NewScript(concurrencyLua)
+)
+
+// ErrLeaseLost 槽位已过期被回收或已释放，持有者不应再继续占用资源。
+var ErrLeaseLost = errors.New("rate: concurrency lease lost")
+
+// ConcurrencyLimiter 基于 Redis ZSET 的分布式并发限制器 (信号量)。
+//
+// 与按时间窗口计数的 Limiter 不同，它限制同一 key 的同时持有者数量：
+// Acquire 占用一个槽位，Release 归还。每个槽位都有租期，持有者崩溃未归还时
+// 槽位在租期后自动回收；执行时间可能超过租期的持有者应定期调用 Lease.Refresh。
+type ConcurrencyLimiter struct {
+	client redis.UniversalClient
+	limit  int           // 每个 key 的最大并发持有者数
+	ttl    time.Duration // 槽位租期
+}
+
+// NewConcurrencyLimiter 创建并发限制器。
+//   - limit: 每个 key 允许的最大并发持有者数
+//   - ttl: 槽位租期，应大于持有者的正常执行时间
+func NewConcurrencyLimiter(client redis.UniversalClient, limit int, ttl time.Duration) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		client: client,
+		limit:  limit,
+		ttl:    ttl,
+	}
+}
+
+// Lease 已占用的并发槽位。
+type Lease struct {
+	limiter   *ConcurrencyLimiter
+	key       string
+	token     string
+	expiresAt time.Time
+}
+
+// Acquire 尝试为 key 占用一个槽位，不阻塞。
+// 放行时返回的 *Lease 不为 nil，调用方必须在完成后调用 Release；
+// 拒绝时 *Lease 为 nil，Result.RetryAfter 为最早一个槽位租期到期前的最长等待时间。
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, key string) (*Lease, Result, error) {
+	token := uuid.New().String()
+	nowMs := time.Now().UnixMilli()
+
+	raw, err := concurrencyLuaScript.Run(ctx, l.client, []string{key},
+		"acquire", token, l.limit, nowMs, l.ttl.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return nil, Result{}, fmt.Errorf("rate: concurrency script error: %w", err)
+	}
+
+	allowed := raw[0] == 1
+	holders := raw[1]
+
+	res := Result{
+		Allowed:   allowed,
+		Remaining: max(int64(l.limit)-holders, 0),
+		Limit:     int64(l.limit),
+	}
+	if !allowed {
+		res.ResetAt = time.UnixMilli(raw[2])
+		res.RetryAfter = max(time.Duration(raw[2]-nowMs)*time.Millisecond, 0)
+		return nil, res, nil
+	}
+
+	lease := &Lease{
+		limiter:   l,
+		key:       key,
+		token:     token,
+		expiresAt: time.UnixMilli(nowMs).Add(l.ttl),
+	}
+	res.ResetAt = lease.expiresAt
+	return lease, res, nil
+}
+
+// Key 返回槽位所属的 key。
+func (le *Lease) Key() string { return le.key }
+
+// ExpiresAt 返回槽位租期的到期时间 (本地时钟)。
+func (le *Lease) ExpiresAt() time.Time { return le.expiresAt }
+
+// Refresh 将槽位租期从当前时间起延长一个 ttl。
+// 槽位已过期被回收或已释放时返回 ErrLeaseLost。
+func (le *Lease) Refresh(ctx context.Context) error {
+	l := le.limiter
+	nowMs := time.Now().UnixMilli()
+
+	raw, err := concurrencyLuaScript.Run(ctx, l.client, []string{le.key},
+		"refresh", le.token, l.limit, nowMs, l.ttl.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("rate: concurrency script error: %w", err)
+	}
+	if raw[0] != 1 {
+		return ErrLeaseLost
+	}
+	le.expiresAt = time.UnixMilli(nowMs).Add(l.ttl)
+	return nil
+}
+
+// Release 归还槽位，可重复调用。
+func (le *Lease) Release(ctx context.Context) error {
+	if err := le.limiter.client.ZRem(ctx, le.key, le.token).Err(); err != nil {
+		return fmt.Errorf("rate: release concurrency lease: %w", err)
+	}
+	return nil
+}
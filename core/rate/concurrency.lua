@@ -0,0 +1,47 @@
+-- Concurrency Limiter (ZSET-based semaphore)
+-- KEYS[1]: sorted set key, member = slot token, score = slot expiry (ms)
+-- ARGV[1]: op ("acquire" / "refresh")
+-- ARGV[2]: token (unique slot id)
+-- ARGV[3]: limit (max concurrent holders)
+-- ARGV[4]: now_ms (current timestamp in ms)
+-- ARGV[5]: ttl_ms (slot lease time in ms)
+-- Returns:
+--   acquire: {acquired (0/1), holders, earliest_expiry_ms}
+--   refresh: {refreshed (0/1), holders, 0}
+
+local key = KEYS[1]
+local op = ARGV[1]
+local token = ARGV[2]
+local limit = tonumber(ARGV[3])
+local now_ms = tonumber(ARGV[4])
+local ttl_ms = tonumber(ARGV[5])
+
+-- Reclaim slots whose holders crashed without releasing
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now_ms)
+
+if op == "refresh" then
+    if not redis.call("ZSCORE", key, token) then
+        return {0, redis.call("ZCARD", key), 0}
+    end
+    redis.call("ZADD", key, now_ms + ttl_ms, token)
+    redis.call("PEXPIRE", key, ttl_ms)
+    return {1, redis.call("ZCARD", key), 0}
+end
+
+local count = redis.call("ZCARD", key)
+if count < limit then
+    redis.call("ZADD", key, now_ms + ttl_ms, token)
+    -- The key lives as long as its longest slot
+    if redis.call("PTTL", key) < ttl_ms then
+        redis.call("PEXPIRE", key, ttl_ms)
+    end
+    return {1, count + 1, 0}
+end
+
+-- Denied: report when the earliest slot expires at the latest
+local earliest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+local expiry = now_ms
+if earliest[2] then
+    expiry = tonumber(earliest[2])
+end
+return {0, count, expiry}
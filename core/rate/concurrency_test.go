@@ -0,0 +1,85 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyAcquireRelease(t *testing.T) {
+	client := newTestRedisClient(t)
+	ctx := context.Background()
+
+	lim := NewConcurrencyLimiter(client.UniversalClient(), 2, 5*time.Second)
+	key := fmt.Sprintf("test:concurrency:%d", time.Now().UnixNano())
+
+	a, res, err := lim.Acquire(ctx, key)
+	if err != nil || a == nil || !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("first acquire: lease=%v res=%+v err=%v", a, res, err)
+	}
+	b, _, err := lim.Acquire(ctx, key)
+	if err != nil || b == nil {
+		t.Fatalf("second acquire: lease=%v err=%v", b, err)
+	}
+
+	// 槽位已满
+	c, res, err := lim.Acquire(ctx, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c != nil || res.Allowed {
+		t.Fatal("third acquire should be denied")
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 5*time.Second {
+		t.Fatalf("RetryAfter = %v, want within lease ttl", res.RetryAfter)
+	}
+
+	// 归还后可再次占用
+	if err := a.Release(ctx); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := a.Release(ctx); err != nil {
+		t.Fatalf("second release should be a no-op: %v", err)
+	}
+	if c, _, err = lim.Acquire(ctx, key); err != nil || c == nil {
+		t.Fatalf("acquire after release: lease=%v err=%v", c, err)
+	}
+	if err := a.Refresh(ctx); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("refresh of released lease: want ErrLeaseLost, got %v", err)
+	}
+}
+
+func TestConcurrencyLeaseExpiry(t *testing.T) {
+	client := newTestRedisClient(t)
+	ctx := context.Background()
+
+	lim := NewConcurrencyLimiter(client.UniversalClient(), 1, 300*time.Millisecond)
+	key := fmt.Sprintf("test:concurrency:ttl:%d", time.Now().UnixNano())
+
+	held, _, err := lim.Acquire(ctx, key)
+	if err != nil || held == nil {
+		t.Fatalf("acquire: lease=%v err=%v", held, err)
+	}
+
+	// 续约期间槽位不会被回收
+	time.Sleep(200 * time.Millisecond)
+	if err := held.Refresh(ctx); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if l, _, _ := lim.Acquire(ctx, key); l != nil {
+		t.Fatal("refreshed slot should still be held")
+	}
+
+	// 持有者崩溃未归还：租期后自动回收
+	time.Sleep(400 * time.Millisecond)
+	l, _, err := lim.Acquire(ctx, key)
+	if err != nil || l == nil {
+		t.Fatalf("expired slot should be reclaimed: lease=%v err=%v", l, err)
+	}
+	if err := held.Refresh(ctx); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("refresh of expired lease: want ErrLeaseLost, got %v", err)
+	}
+}
@@ -53,16 +53,18 @@ func (l *FixedWindowLimiter) Allow(ctx context.Context, key string, n int) (Resu
 
 	allowed := raw[0] == 1
 	count := raw[1]
+	resetIn := time.Duration(raw[2]) * time.Millisecond
 
 	res := Result{
 		Allowed:   allowed,
 		Remaining: int64(l.limit) - count,
 		Limit:     int64(l.limit),
-		ResetAt:   time.Now().Add(l.window),
+		ResetAt:   time.Now().Add(resetIn),
 	}
 
 	if !allowed {
-		res.RetryAfter = l.window
+		// 当前窗口过期后计数清零
+		res.RetryAfter = resetIn
 	}
 
 	return res, nil
@@ -3,7 +3,7 @@
 -- ARGV[1]: window_sec (window size in seconds)
 -- ARGV[2]: limit (max requests per window)
 -- ARGV[3]: requested (number of requests)
--- Returns: {allowed (0/1), current_count (count after operation)}
+-- Returns: {allowed (0/1), current_count (count after operation), ttl_ms (time until the window resets)}
 
 local key = KEYS[1]
 local window_sec = tonumber(ARGV[1])
@@ -18,7 +18,7 @@ if current + requested <= limit then
     if redis.call("TTL", key) == -1 then
         redis.call("EXPIRE", key, window_sec)
     end
-    return {1, new_count}
+    return {1, new_count, redis.call("PTTL", key)}
 else
-    return {0, current}
+    return {0, current, math.max(redis.call("PTTL", key), 0)}
 end
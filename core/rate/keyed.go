@@ -0,0 +1,29 @@
+package rate
+
+import "context"
+
+// KeyedLimiter 按 key 选择限流器，用于不同主体配额不同的场景，
+// 如付费用户与免费用户、内网 IP 与公网 IP。
+type KeyedLimiter struct {
+	resolve  func(ctx context.Context, key string) Limiter
+	fallback Limiter
+}
+
+var _ Limiter = (*KeyedLimiter)(nil)
+
+// NewKeyedLimiter 创建按 key 选择限流器的 Limiter。
+//   - fallback: resolve 返回 nil 时使用的默认限流器
+//   - resolve: 根据 key 返回限流器，可从 ctx 读取调用方信息 (如用户等级)
+func NewKeyedLimiter(fallback Limiter, resolve func(ctx context.Context, key string) Limiter) *KeyedLimiter {
+	return &KeyedLimiter{resolve: resolve, fallback: fallback}
+}
+
+// Allow 实现 Limiter 接口。
+func (l *KeyedLimiter) Allow(ctx context.Context, key string, n int) (Result, error) {
+	if l.resolve != nil {
+		if lim := l.resolve(ctx, key); lim != nil {
+			return lim.Allow(ctx, key, n)
+		}
+	}
+	return l.fallback.Allow(ctx, key, n)
+}
@@ -2,9 +2,15 @@ package rate
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"strconv"
 	"time"
 )
 
+// ErrWaitExceedsDeadline WaitN 需要等待的时间超过了 ctx 的截止时间。
+var ErrWaitExceedsDeadline = errors.New("rate: wait would exceed context deadline")
+
 // Result 限流判定结果
 type Result struct {
 	// Allowed 是否放行
@@ -19,9 +25,58 @@ type Result struct {
 	ResetAt time.Time
 }
 
+// SetHeaders 将结果写入响应头：
+//   - X-RateLimit-Limit: 总配额上限 (Limit 为 0 时省略)
+//   - X-RateLimit-Remaining: 剩余配额 (Limit 为 0 时省略)
+//   - X-RateLimit-Reset: 配额重置的 Unix 时间戳 (秒)
+//   - Retry-After: 被拒绝时的重试等待秒数 (向上取整)
+func (r Result) SetHeaders(h http.Header) {
+	if r.Limit > 0 {
+		h.Set("X-RateLimit-Limit", strconv.FormatInt(r.Limit, 10))
+		h.Set("X-RateLimit-Remaining", strconv.FormatInt(max(r.Remaining, 0), 10))
+	}
+	if !r.ResetAt.IsZero() {
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(r.ResetAt.Unix(), 10))
+	}
+	if !r.Allowed && r.RetryAfter > 0 {
+		secs := (r.RetryAfter + time.Second - 1) / time.Second
+		h.Set("Retry-After", strconv.FormatInt(int64(secs), 10))
+	}
+}
+
 // Limiter 分布式限流器接口
 type Limiter interface {
 	// Allow 对 key 请求 n 个配额。
 	// n <= 0 等价于 n = 1。
 	Allow(ctx context.Context, key string, n int) (Result, error)
 }
+
+// minWaitInterval WaitN 两次尝试之间的最短间隔，避免 RetryAfter 为 0 时空转
+const minWaitInterval = 10 * time.Millisecond
+
+// WaitN 阻塞直到 key 取得 n 个配额，适用于后台任务等宁可等待也不应失败的调用方。
+//
+// 被拒绝时按 Result.RetryAfter 等待后重试。ctx 带截止时间且下一次重试会晚于截止时间时
+// 立即返回 ErrWaitExceedsDeadline，而不是空等到超时；ctx 取消时返回 ctx.Err()。
+// n 超过限流器的总配额时永远无法放行，调用方应自行保证 n <= Limit。
+func WaitN(ctx context.Context, l Limiter, key string, n int) (Result, error) {
+	for {
+		res, err := l.Allow(ctx, key, n)
+		if err != nil || res.Allowed {
+			return res, err
+		}
+
+		wait := max(res.RetryAfter, minWaitInterval)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return res, ErrWaitExceedsDeadline
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// staticLimiter 返回固定结果的 Limiter
+type staticLimiter struct {
+	res   Result
+	calls int
+}
+
+func (l *staticLimiter) Allow(ctx context.Context, key string, n int) (Result, error) {
+	l.calls++
+	return l.res, nil
+}
+
+func TestResultSetHeaders(t *testing.T) {
+	reset := time.Unix(1700000000, 0)
+	h := http.Header{}
+	Result{Allowed: false, Remaining: -1, Limit: 10, RetryAfter: 1500 * time.Millisecond, ResetAt: reset}.SetHeaders(h)
+
+	want := map[string]string{
+		"X-RateLimit-Limit":     "10",
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     "1700000000",
+		"Retry-After":           "2",
+	}
+	for k, v := range want {
+		if got := h.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+
+	h = http.Header{}
+	Result{Allowed: true, Remaining: 9, Limit: 10, ResetAt: reset}.SetHeaders(h)
+	if h.Get("Retry-After") != "" {
+		t.Error("Retry-After should not be set when allowed")
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	vip := &staticLimiter{res: Result{Allowed: true, Limit: 100}}
+	def := &staticLimiter{res: Result{Allowed: true, Limit: 10}}
+	lim := NewKeyedLimiter(def, func(ctx context.Context, key string) Limiter {
+		if key == "user:vip" {
+			return vip
+		}
+		return nil
+	})
+
+	if res, _ := lim.Allow(context.Background(), "user:vip", 1); res.Limit != 100 {
+		t.Errorf("vip limit = %d, want 100", res.Limit)
+	}
+	if res, _ := lim.Allow(context.Background(), "user:1", 1); res.Limit != 10 {
+		t.Errorf("default limit = %d, want 10", res.Limit)
+	}
+}
+
+func TestWaitNDeadline(t *testing.T) {
+	lim := &staticLimiter{res: Result{Allowed: false, RetryAfter: time.Minute}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := WaitN(ctx, lim, "k", 1); !errors.Is(err, ErrWaitExceedsDeadline) {
+		t.Fatalf("err = %v, want ErrWaitExceedsDeadline", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("WaitN should fail fast when the wait exceeds the deadline")
+	}
+}
+
+func TestWaitN(t *testing.T) {
+	client := newTestRedisClient(t)
+	ctx := context.Background()
+
+	lim := NewTokenBucketLimiter(client.UniversalClient(), 2, 10) // 容量2, 每秒填充10
+	key := fmt.Sprintf("test:wait:%d", time.Now().UnixNano())
+
+	if res, err := lim.Allow(ctx, key, 2); err != nil || !res.Allowed {
+		t.Fatalf("drain bucket: res=%+v err=%v", res, err)
+	}
+
+	start := time.Now()
+	res, err := WaitN(ctx, lim, key, 2)
+	if err != nil || !res.Allowed {
+		t.Fatalf("WaitN: res=%+v err=%v", res, err)
+	}
+	if d := time.Since(start); d < 150*time.Millisecond || d > time.Second {
+		t.Fatalf("WaitN waited %v, want ~200ms", d)
+	}
+}
@@ -58,12 +58,17 @@ func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, n int) (Re
 		Allowed:   allowed,
 		Remaining: int64(l.limit) - count,
 		Limit:     int64(l.limit),
-		ResetAt:   time.UnixMilli(nowMs + windowMs),
+		ResetAt:   time.UnixMilli(raw[3]),
 	}
 
 	if !allowed {
-		// 最早的记录过期后才有配额释放，保守估计为整个窗口
-		res.RetryAfter = l.window
+		if raw[2] > 0 {
+			// 足够多的最早记录移出窗口后即可放行
+			res.RetryAfter = max(time.Duration(raw[2]-nowMs)*time.Millisecond, 0)
+		} else {
+			// 请求数超过窗口上限，永远无法放行，保守返回整个窗口
+			res.RetryAfter = l.window
+		}
 	}
 
 	return res, nil
@@ -5,7 +5,9 @@
 -- ARGV[3]: now_ms (current timestamp in ms)
 -- ARGV[4]: requested (number of requests)
 -- ARGV[5]: unique_id (unique prefix to avoid ZSET member collision)
--- Returns: {allowed (0/1), current_count (requests in current window)}
+-- Returns: {allowed (0/1), current_count (requests in current window),
+--           retry_at_ms (when enough entries expire to admit the request, 0 if allowed),
+--           reset_at_ms (when the oldest entry in the window expires)}
 
 local key = KEYS[1]
 local window_ms = tonumber(ARGV[1])
@@ -22,15 +24,26 @@ redis.call("ZREMRANGEBYSCORE", key, "-inf", window_start)
 local count = redis.call("ZCARD", key)
 
 -- Check if there is enough quota
+local allowed = 0
+local retry_at = 0
 if count + requested <= limit then
     -- Add requested entries, each with a unique member to avoid collision
     for i = 1, requested do
         redis.call("ZADD", key, now_ms, unique_id .. ":" .. i)
     end
-    redis.call("PEXPIRE", key, window_ms)
-    return {1, count + requested}
-else
-    -- Only refresh TTL, do not add entries
-    redis.call("PEXPIRE", key, window_ms)
-    return {0, count}
+    count = count + requested
+    allowed = 1
+elseif requested <= limit then
+    -- The request fits once the (count + requested - limit) oldest entries expire
+    local entry = redis.call("ZRANGE", key, count + requested - limit - 1, count + requested - limit - 1, "WITHSCORES")
+    retry_at = tonumber(entry[2]) + window_ms
 end
+redis.call("PEXPIRE", key, window_ms)
+
+local reset_at = now_ms + window_ms
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+if oldest[2] then
+    reset_at = tonumber(oldest[2]) + window_ms
+end
+
+return {allowed, count, retry_at, reset_at}
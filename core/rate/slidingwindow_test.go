@@ -122,3 +122,34 @@ func TestSlidingWindowResult(t *testing.T) {
 		t.Fatal("ResetAt should not be zero")
 	}
 }
+
+func TestSlidingWindowRetryAfter(t *testing.T) {
+	client := newTestRedisClient(t)
+	ctx := context.Background()
+
+	lim := NewSlidingWindowLimiter(client.UniversalClient(), 2*time.Second, 2)
+	key := fmt.Sprintf("test:slidingwindow:retry:%d", time.Now().UnixNano())
+
+	if _, err := lim.Allow(ctx, key, 1); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	if _, err := lim.Allow(ctx, key, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一条记录移出窗口即可放行，约 1.5s 而不是整个窗口
+	res, err := lim.Allow(ctx, key, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Fatal("request should be denied")
+	}
+	if res.RetryAfter < time.Second || res.RetryAfter > 1600*time.Millisecond {
+		t.Fatalf("RetryAfter = %v, want ~1.5s", res.RetryAfter)
+	}
+	if until := time.Until(res.ResetAt); until < time.Second || until > 1600*time.Millisecond {
+		t.Fatalf("ResetAt in %v, want ~1.5s", until)
+	}
+}
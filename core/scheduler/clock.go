@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kochabx/kit/log"
+)
+
+// clock 调度器时钟
+//
+// 默认直接使用本地时间。启用 Redis 时钟后以 Redis TIME 为准：
+// 每隔 SyncInterval 测量一次本地与 Redis 的偏差 (按往返时延的一半修正)，
+// 之后的读取只是本地时间加上缓存的偏差，不产生额外的网络请求。
+// 多个实例共享同一个 Redis，因此到期判断、延迟计算与心跳时间在实例间一致。
+type clock struct {
+	client   *redis.Client
+	enabled  bool
+	interval time.Duration
+	metrics  *Metrics
+	logger   *log.Logger
+
+	offset   atomic.Int64 // Redis时间 - 本地时间（纳秒）
+	syncedAt atomic.Int64 // 上次同步的本地时间（unix纳秒），0 表示尚未同步
+	syncing  atomic.Bool  // 防止并发同步
+}
+
+func newClock(client *redis.Client, opts ClockOptions, metrics *Metrics, logger *log.Logger) *clock {
+	return &clock{
+		client:   client,
+		enabled:  opts.UseRedisTime,
+		interval: opts.SyncInterval,
+		metrics:  metrics,
+		logger:   logger,
+	}
+}
+
+// Now 返回校正后的当前时间；同步到期时由调用方顺带完成一次同步
+func (c *clock) Now(ctx context.Context) time.Time {
+	now := time.Now()
+	if !c.enabled {
+		return now
+	}
+	if now.UnixNano()-c.syncedAt.Load() >= int64(c.interval) && c.syncing.CompareAndSwap(false, true) {
+		c.sync(ctx)
+		c.syncing.Store(false)
+		now = time.Now()
+	}
+	return now.Add(time.Duration(c.offset.Load()))
+}
+
+// sync 通过 Redis TIME 测量偏差，失败时保留上次的偏差并在下个周期重试
+func (c *clock) sync(ctx context.Context) error {
+	start := time.Now()
+	server, err := c.client.Time(ctx).Result()
+	end := time.Now()
+	c.syncedAt.Store(end.UnixNano())
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("failed to sync clock with redis, keeping previous drift")
+		return err
+	}
+
+	// 假设请求与响应耗时对称，Redis 在往返中点读取时间
+	drift := server.Add(end.Sub(start) / 2).Sub(end)
+	c.offset.Store(int64(drift))
+	c.metrics.RecordClockDrift(drift.Seconds())
+	return nil
+}
+
+// Drift 返回最近一次测量的偏差（Redis时间 - 本地时间）
+func (c *clock) Drift() time.Duration {
+	return time.Duration(c.offset.Load())
+}
+
+// now 返回调度器时钟的当前时间
+func (s *Scheduler) now(ctx context.Context) time.Time {
+	return s.clock.Now(ctx)
+}
+
+// ClockDrift 返回最近一次测量的本地时钟与 Redis 时钟的偏差，正值表示本地时钟落后。
+// 未启用 WithRedisClock 时始终为 0。
+func (s *Scheduler) ClockDrift() time.Duration {
+	return s.clock.Drift()
+}
@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TenantTag 标记任务所属租户的标签，成本按 (日期, 任务类型, 租户) 汇总
+const TenantTag = "tenant"
+
+// costDayLayout 成本日汇总使用的日期格式 (UTC)
+const costDayLayout = "20060102"
+
+// 成本汇总 hash 的字段
+const (
+	costFieldExecutions = "executions"
+	costFieldFailures   = "failures"
+	costFieldRetries    = "retries"
+	costFieldDurationUS = "duration_us"
+	costFieldPayload    = "payload_bytes"
+	costFieldUnits      = "units"
+)
+
+// costMeterKey costMeter 在 context 中的键
+type costMeterKey struct{}
+
+// costMeter 累计 handler 上报的成本单位
+type costMeter struct {
+	mu    sync.Mutex
+	units float64
+}
+
+// AddCost 为当前执行累加自定义成本单位 (如调用的外部 API 次数、处理的记录数)，
+// 仅在 handler 内且启用 WithCostAccounting 时生效，其他情况下忽略
+func AddCost(ctx context.Context, units float64) {
+	if m, ok := ctx.Value(costMeterKey{}).(*costMeter); ok {
+		m.mu.Lock()
+		m.units += units
+		m.mu.Unlock()
+	}
+}
+
+// WithTenant 设置任务所属租户，用于成本分摊
+func WithTenant(tenant string) TaskOption {
+	return WithTag(TenantTag, tenant)
+}
+
+// CostRecord 某天某租户某类任务的成本汇总
+type CostRecord struct {
+	Day          time.Time     // 汇总日期 (UTC 零点)
+	Type         string        // 任务类型
+	Tenant       string        // 租户，未设置 TenantTag 时为空
+	Executions   int64         // 执行次数，含失败与重试
+	Failures     int64         // 失败次数
+	Retries      int64         // 属于重试的执行次数
+	Duration     time.Duration // 累计执行时长
+	PayloadBytes int64         // 累计 payload 字节数 (每次执行计一次)
+	Units        float64       // handler 通过 AddCost 上报的累计成本单位
+}
+
+// costExecution 一次执行的成本
+type costExecution struct {
+	taskType string
+	tenant   string
+	failed   bool
+	retry    bool
+	duration time.Duration
+	payload  int
+	units    float64
+}
+
+// buildCostIndexKey 构建某天成本索引key，成员为 "类型\n租户"
+func (s *Scheduler) buildCostIndexKey(day string) string {
+	return s.opts.Namespace + ":cost:" + day
+}
+
+// buildCostKey 构建某天某类任务某租户的成本汇总key
+func (s *Scheduler) buildCostKey(day, member string) string {
+	return s.opts.Namespace + ":cost:" + day + ":" + member
+}
+
+// recordCost 将一次执行的成本累加到当天的汇总中
+func (s *Scheduler) recordCost(ctx context.Context, e costExecution) error {
+	day := s.now(ctx).UTC().Format(costDayLayout)
+	member := e.taskType + "\n" + e.tenant
+	key := s.buildCostKey(day, member)
+	indexKey := s.buildCostIndexKey(day)
+	ttl := s.opts.Cost.TTL
+
+	pipe := s.client.TxPipeline()
+	pipe.HIncrBy(ctx, key, costFieldExecutions, 1)
+	if e.failed {
+		pipe.HIncrBy(ctx, key, costFieldFailures, 1)
+	}
+	if e.retry {
+		pipe.HIncrBy(ctx, key, costFieldRetries, 1)
+	}
+	pipe.HIncrBy(ctx, key, costFieldDurationUS, e.duration.Microseconds())
+	pipe.HIncrBy(ctx, key, costFieldPayload, int64(e.payload))
+	if e.units != 0 {
+		pipe.HIncrByFloat(ctx, key, costFieldUnits, e.units)
+	}
+	pipe.SAdd(ctx, indexKey, member)
+	pipe.Expire(ctx, key, ttl)
+	pipe.Expire(ctx, indexKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record task cost: %w", err)
+	}
+	return nil
+}
+
+// Costs 导出 [from, to] 内各天 (UTC) 按任务类型与租户汇总的执行成本，
+// 按日期、类型、租户排序。需启用 WithCostAccounting，数据保留至 Cost.TTL 到期
+func (s *Scheduler) Costs(ctx context.Context, from, to time.Time) ([]CostRecord, error) {
+	start := from.UTC().Truncate(24 * time.Hour)
+	end := to.UTC()
+	if end.Before(start) {
+		return nil, nil
+	}
+
+	var records []CostRecord
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		dayRecords, err := s.costsOfDay(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, dayRecords...)
+	}
+	return records, nil
+}
+
+// costsOfDay 读取某天的全部成本汇总
+func (s *Scheduler) costsOfDay(ctx context.Context, day time.Time) ([]CostRecord, error) {
+	dayStr := day.Format(costDayLayout)
+	members, err := s.client.SMembers(ctx, s.buildCostIndexKey(dayStr)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task costs: %w", err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	slices.Sort(members)
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(members))
+	for i, member := range members {
+		cmds[i] = pipe.HGetAll(ctx, s.buildCostKey(dayStr, member))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get task costs: %w", err)
+	}
+
+	records := make([]CostRecord, 0, len(members))
+	for i, member := range members {
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			continue // 汇总已过期而索引尚在
+		}
+		taskType, tenant, _ := strings.Cut(member, "\n")
+		intField := func(name string) int64 {
+			n, _ := strconv.ParseInt(fields[name], 10, 64)
+			return n
+		}
+		units, _ := strconv.ParseFloat(fields[costFieldUnits], 64)
+		records = append(records, CostRecord{
+			Day:          day,
+			Type:         taskType,
+			Tenant:       tenant,
+			Executions:   intField(costFieldExecutions),
+			Failures:     intField(costFieldFailures),
+			Retries:      intField(costFieldRetries),
+			Duration:     time.Duration(intField(costFieldDurationUS)) * time.Microsecond,
+			PayloadBytes: intField(costFieldPayload),
+			Units:        units,
+		})
+	}
+	return records, nil
+}
+
+// SumCosts 按 key 合并成本记录，例如按租户汇总整月成本：
+//
+//	SumCosts(records, func(r CostRecord) string { return r.Tenant })
+//
+// 合并后的记录保留每组第一条记录的 Day、Type、Tenant
+func SumCosts(records []CostRecord, key func(CostRecord) string) map[string]CostRecord {
+	sums := make(map[string]CostRecord)
+	for _, r := range records {
+		k := key(r)
+		sum, ok := sums[k]
+		if !ok {
+			sums[k] = r
+			continue
+		}
+		sum.Executions += r.Executions
+		sum.Failures += r.Failures
+		sum.Retries += r.Retries
+		sum.Duration += r.Duration
+		sum.PayloadBytes += r.PayloadBytes
+		sum.Units += r.Units
+		sums[k] = sum
+	}
+	return sums
+}
+
+// WriteCostsCSV 以 CSV 格式写出成本记录 (含表头)，时长以秒为单位，便于导入计费系统
+func WriteCostsCSV(w io.Writer, records []CostRecord) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"day", "type", "tenant", "executions", "failures", "retries", "duration_seconds", "payload_bytes", "units"})
+	for _, r := range records {
+		_ = cw.Write([]string{
+			r.Day.Format(time.DateOnly),
+			r.Type,
+			r.Tenant,
+			strconv.FormatInt(r.Executions, 10),
+			strconv.FormatInt(r.Failures, 10),
+			strconv.FormatInt(r.Retries, 10),
+			strconv.FormatFloat(r.Duration.Seconds(), 'f', 6, 64),
+			strconv.FormatInt(r.PayloadBytes, 10),
+			strconv.FormatFloat(r.Units, 'f', -1, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -13,6 +13,21 @@ var (
 	ErrTaskCancelled     = errors.New("task cancelled")
 	ErrTaskTimeout       = errors.New("task timeout")
 	ErrTaskDuplicate     = errors.New("task duplicate")
+	ErrTaskNotQueued     = errors.New("task is not queued")
+	ErrSubmitRejected    = errors.New("task submission rejected")
+	ErrTaskExpired       = errors.New("task expired")
+	ErrInvalidExpiry     = errors.New("invalid expiry")
+
+	// Cron 重叠相关错误
+	ErrInvalidOverlapPolicy = errors.New("invalid cron overlap policy")
+	ErrCronOverlapSkipped   = errors.New("cron run skipped: previous instance still running")
+	ErrCronReplaced         = errors.New("cron run replaced by a newer instance")
+
+	// 自定义状态相关错误
+	ErrUnknownState        = errors.New("unknown task state")
+	ErrStateOutsideHandler = errors.New("task state can only be set inside a handler")
+	ErrTaskNotParked       = errors.New("task is not in a custom state")
+	ErrStateTimeout        = errors.New("task state timeout")
 
 	// Handler相关错误
 	ErrHandlerNotFound = errors.New("handler not found")
@@ -30,6 +45,7 @@ var (
 	// 系统相关错误
 	ErrRedisConnection    = errors.New("redis connection error")
 	ErrShutdown           = errors.New("scheduler is shutting down")
+	ErrUnhealthy          = errors.New("scheduler is unhealthy")
 	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
 	ErrCircuitBreakerOpen = errors.New("circuit breaker is open")
 
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// expireAtScore 任务的过期时间 (Unix 秒)，即计划执行时间之后 Expiry
+func expireAtScore(task *Task) float64 {
+	return float64(task.ScheduleAt.Add(task.Expiry).Unix())
+}
+
+// expirable 任务是否受过期时间约束：设置了 Expiry 且尚未开始执行 (不含重试与自定义状态恢复)
+func expirable(taskInfo *TaskInfo) bool {
+	if taskInfo.Expiry <= 0 || taskInfo.RetryCount > 0 || taskInfo.State != "" {
+		return false
+	}
+	return taskInfo.Status == StatusPending || taskInfo.Status == StatusReady
+}
+
+// isExpired 任务是否已超过过期时间
+func (s *Scheduler) isExpired(ctx context.Context, taskInfo *TaskInfo) bool {
+	expireAt := taskInfo.ScheduleAt.Add(taskInfo.Expiry)
+	return !s.now(ctx).Before(expireAt)
+}
+
+// expireTasks 丢弃过期时间已到且仍未开始执行的任务
+func (s *Scheduler) expireTasks(ctx context.Context, now int64) {
+	taskIDs, err := s.queue.DueExpiring(ctx, now, s.opts.BatchSize)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to get expiring tasks")
+		return
+	}
+
+	for _, taskID := range taskIDs {
+		if err := s.expireDue(ctx, taskID); err != nil {
+			s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to expire task")
+		}
+	}
+}
+
+// expireDue 持有任务锁丢弃单个到期任务。任务锁被占用说明 Worker 已领取该任务，
+// 此时保留过期登记，由 Worker 在开始执行前判断并清理
+func (s *Scheduler) expireDue(ctx context.Context, taskID string) error {
+	owner := "expirer-" + uuid.New().String()[:8]
+	acquired, err := s.lock.Acquire(ctx, taskID, owner, s.opts.LockTimeout)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+	defer func() {
+		if _, err := s.lock.Release(ctx, taskID, owner); err != nil {
+			s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to release lock")
+		}
+	}()
+
+	taskInfo, err := s.GetTaskInfo(ctx, taskID)
+	if err == ErrTaskNotFound {
+		return s.queue.RemoveExpiring(ctx, taskID)
+	}
+	if err != nil {
+		return err
+	}
+	if !expirable(taskInfo) {
+		// 已开始执行、已取消或已结束
+		return s.queue.RemoveExpiring(ctx, taskID)
+	}
+
+	if err := s.queue.RemoveDelayed(ctx, taskID); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to remove task from delayed queue")
+	}
+	if err := s.queue.RemoveReady(ctx, taskID); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to remove task from ready queue")
+	}
+	if err := s.queue.RemoveExpiring(ctx, taskID); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to remove task from expiring index")
+	}
+	s.markExpired(ctx, taskInfo)
+	return nil
+}
+
+// markExpired 将任务标记为过期，按配置加入死信队列
+func (s *Scheduler) markExpired(ctx context.Context, taskInfo *TaskInfo) {
+	s.logger.Warn().
+		Str("task_id", taskInfo.ID).
+		Str("type", taskInfo.Type).
+		Time("schedule_at", taskInfo.ScheduleAt).
+		Dur("expiry", taskInfo.Expiry).
+		Msg("task expired before execution")
+
+	now := time.Now()
+	taskInfo.Status = StatusExpired
+	taskInfo.LastError = ErrTaskExpired.Error()
+	taskInfo.FinishTime = &now
+
+	if err := s.retainTaskInfo(ctx, taskInfo, s.opts.Retention.Terminal); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to save task info")
+	}
+	s.metrics.RecordTaskExpired(taskInfo.Type)
+
+	if !s.opts.DLQExpired {
+		return
+	}
+	if err := s.dlq.Add(ctx, taskInfo.ID); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to add task to DLQ")
+	}
+	if s.metrics.enabled {
+		count, _ := s.dlq.Count(ctx)
+		s.metrics.RecordDeadLetterCount(float64(count))
+	}
+}
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+)
+
+// AllTaskTypes 作为 WithSubmitInterceptor 的任务类型时，拦截器作用于所有任务类型
+const AllTaskTypes = "*"
+
+// SubmitInterceptor 提交拦截器，在任务写入队列前调用，可改写 task (Payload、Tags、Context、优先级等)，
+// 返回错误时拒绝提交。用于集中实现提交期的统一处理，如从 ctx 注入租户、标记 payload 版本、按策略否决提交
+type SubmitInterceptor func(ctx context.Context, task *Task) error
+
+// WithSubmitInterceptor 为 taskType 注册提交拦截器，taskType 为 AllTaskTypes 时作用于所有类型。
+// 拦截器在 Submit / BatchSubmit 应用 TaskOption 之后、校验任务之前按注册顺序执行，
+// 通用拦截器先于指定类型的拦截器；Cron 任务由调度器自动续期时不再执行
+func WithSubmitInterceptor(taskType string, interceptors ...SubmitInterceptor) Option {
+	return func(o *Options) {
+		if o.SubmitInterceptors == nil {
+			o.SubmitInterceptors = make(map[string][]SubmitInterceptor)
+		}
+		o.SubmitInterceptors[taskType] = append(o.SubmitInterceptors[taskType], interceptors...)
+	}
+}
+
+// TransformPayload 使用 serializer 解码 task.Payload，经 fn 改写后重新编码，供拦截器改写 payload
+func TransformPayload[T any](task *Task, serializer Serializer, fn func(*T) error) error {
+	var payload T
+	if err := serializer.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if err := fn(&payload); err != nil {
+		return err
+	}
+	data, err := serializer.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	task.Payload = data
+	return nil
+}
+
+// interceptSubmit 依次执行 task 适用的提交拦截器，任一拦截器返回错误时以 ErrSubmitRejected 包装返回
+func (s *Scheduler) interceptSubmit(ctx context.Context, task *Task) error {
+	if len(s.opts.SubmitInterceptors) == 0 {
+		return nil
+	}
+
+	taskType := task.Type
+	chain := s.opts.SubmitInterceptors[AllTaskTypes]
+	if taskType != AllTaskTypes {
+		chain = append(chain[:len(chain):len(chain)], s.opts.SubmitInterceptors[taskType]...)
+	}
+	for _, interceptor := range chain {
+		if err := interceptor(ctx, task); err != nil {
+			return fmt.Errorf("%w: %w", ErrSubmitRejected, err)
+		}
+	}
+	return nil
+}
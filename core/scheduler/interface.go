@@ -16,6 +16,9 @@ type LockProvider interface {
 	// Extend 延长锁的TTL
 	Extend(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
 
+	// Takeover 强制将锁转移给 value，原持有者之后的 Extend 与 Release 失败
+	Takeover(ctx context.Context, key string, value string, ttl time.Duration) error
+
 	// IsLocked 检查锁是否存在
 	IsLocked(ctx context.Context, key string) (bool, error)
 
@@ -43,7 +46,7 @@ type QueueStore interface {
 	// MoveDelayedToReady 移动到期任务到就绪队列
 	MoveDelayedToReady(ctx context.Context, now int64, batchSize int) (int64, error)
 
-	// ClaimStaleMessages 接管超时的Pending消息
+	// ClaimStaleMessages 接管超时的Pending消息并重新投递，返回重新投递的任务ID
 	ClaimStaleMessages(ctx context.Context, priority Priority, idleTime time.Duration) ([]string, error)
 
 	// RemoveDelayed 从延迟队列移除任务
@@ -52,6 +55,33 @@ type QueueStore interface {
 	// RemoveReady 从就绪队列移除任务
 	RemoveReady(ctx context.Context, taskID string) error
 
+	// Boost 将等待中的任务提升到更高优先级，返回 false 表示任务已不在等待状态
+	Boost(ctx context.Context, taskID string, from, to Priority) (bool, error)
+
+	// AddParked 添加处于自定义状态的任务，score 为截止时间
+	AddParked(ctx context.Context, taskID string, deadline float64) error
+
+	// RemoveParked 移除处于自定义状态的任务，返回 false 表示任务已被移除
+	RemoveParked(ctx context.Context, taskID string) (bool, error)
+
+	// DueParked 获取截止时间不晚于 now 的自定义状态任务
+	DueParked(ctx context.Context, now int64, limit int) ([]string, error)
+
+	// RemoveExpiring 移除任务的过期登记
+	RemoveExpiring(ctx context.Context, taskID string) error
+
+	// DueExpiring 获取过期时间不晚于 now 的任务
+	DueExpiring(ctx context.Context, now int64, limit int) ([]string, error)
+
+	// SetPaused 设置任务类型或优先级的暂停状态
+	SetPaused(ctx context.Context, scope PauseScope, name string, paused bool) error
+
+	// GetPaused 获取当前的暂停状态
+	GetPaused(ctx context.Context) (*PausedState, error)
+
+	// TypePaused 任务类型是否已暂停，可基于短时缓存
+	TypePaused(ctx context.Context, taskType string) bool
+
 	// GetStats 获取队列统计信息
 	GetStats(ctx context.Context) (*QueueStats, error)
 }
@@ -85,6 +85,12 @@ func (l *DistLock) Extend(ctx context.Context, key string, value string, ttl tim
 	return result == int64(1), nil
 }
 
+// Takeover 强制将锁转移给 value，无论当前由谁持有
+// 原持有者之后的 Extend 与 Release 均会失败，可据此感知锁被接管
+func (l *DistLock) Takeover(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return l.client.Set(ctx, l.buildLockKey(key), value, ttl).Err()
+}
+
 // IsLocked 检查锁是否存在
 func (l *DistLock) IsLocked(ctx context.Context, key string) (bool, error) {
 	lockKey := l.buildLockKey(key)
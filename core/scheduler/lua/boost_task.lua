@@ -0,0 +1,82 @@
+-- boost_task.lua
+-- 将等待中的任务原子地提升到更高优先级
+-- KEYS[1]: 延迟队列 ZSET key
+-- KEYS[2]: 任务元数据 Hash key
+-- KEYS[3]: 原优先级 Stream key
+-- KEYS[4]: 新优先级 Stream key
+-- ARGV[1]: 任务ID
+-- ARGV[2]: 新优先级
+-- ARGV[3]: 消费者组名
+-- ARGV[4]: 原 Stream 中的消息ID（任务不在就绪队列时为空）
+-- ARGV[5]: 入队时间（unix秒）
+-- 返回: 1表示成功, 0表示任务已不在等待状态（已被投递、执行或删除）
+
+local delayedKey = KEYS[1]
+local taskKey = KEYS[2]
+local oldStream = KEYS[3]
+local newStream = KEYS[4]
+local taskID = ARGV[1]
+local priority = ARGV[2]
+local group = ARGV[3]
+local msgID = ARGV[4]
+
+if redis.call('EXISTS', taskKey) == 0 then
+    return 0
+end
+
+-- 仍在延迟队列：优先级在出队时读取，只需更新元数据
+if redis.call('ZSCORE', delayedKey, taskID) then
+    redis.call('HSET', taskKey, 'priority', priority)
+    return 1
+end
+
+if msgID == '' then
+    return 0
+end
+
+-- 解析 Stream ID（ms-seq）用于比较
+local function parseID(id)
+    local ms, seq = string.match(id, '^(%d+)-(%d+)$')
+    return tonumber(ms or 0), tonumber(seq or 0)
+end
+
+-- 获取消费者组的 last-delivered-id，ID 不大于它的消息已投递给某个 Worker
+local lastDelivered = '0-0'
+local ok, groups = pcall(redis.call, 'XINFO', 'GROUPS', oldStream)
+if ok then
+    for _, g in ipairs(groups) do
+        local name, lid
+        for i = 1, #g, 2 do
+            if g[i] == 'name' then
+                name = g[i + 1]
+            elseif g[i] == 'last-delivered-id' then
+                lid = g[i + 1]
+            end
+        end
+        if name == group and lid then
+            lastDelivered = lid
+        end
+    end
+end
+
+local msgMs, msgSeq = parseID(msgID)
+local lastMs, lastSeq = parseID(lastDelivered)
+if msgMs < lastMs or (msgMs == lastMs and msgSeq <= lastSeq) then
+    return 0
+end
+
+-- 新旧优先级映射到同一个 Stream：只需更新元数据
+if oldStream == newStream then
+    redis.call('HSET', taskKey, 'priority', priority)
+    return 1
+end
+
+if redis.call('XDEL', oldStream, msgID) == 0 then
+    return 0
+end
+
+-- 确保消费者组存在（BUSYGROUP 错误忽略）
+pcall(redis.call, 'XGROUP', 'CREATE', newStream, group, '0', 'MKSTREAM')
+redis.call('XADD', newStream, '*', 'task_id', taskID, 'priority', priority, 'added_at', ARGV[5])
+redis.call('HSET', taskKey, 'priority', priority)
+return 1
@@ -0,0 +1,14 @@
+-- move_task.lua
+-- 将到期的延迟任务原子地移到就绪队列，多个调度器实例同时扫描时只有一个实例投递
+-- KEYS[1]: 延迟队列 ZSET key
+-- KEYS[2]: 就绪队列 Stream key
+-- ARGV[1]: 任务ID
+-- ARGV[2]: 优先级
+-- ARGV[3]: 入队时间（Unix 秒）
+-- 返回: 1表示已投递, 0表示任务已被其他实例移走
+
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+    return 0
+end
+redis.call('XADD', KEYS[2], '*', 'task_id', ARGV[1], 'priority', ARGV[2], 'added_at', ARGV[3])
+return 1
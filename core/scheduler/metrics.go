@@ -36,6 +36,12 @@ type Metrics struct {
 	// 重试指标
 	TaskRetry *prometheus.CounterVec // 任务重试次数
 
+	// 任务窃取指标
+	TaskStolen prometheus.Counter // 空闲Worker从其他Worker缓冲中窃取的任务数
+
+	// Cron 重叠指标
+	CronOverlap *prometheus.CounterVec // 上一实例仍在运行时按重叠策略处理的次数（按策略：skip/queue/replace）
+
 	// 死信队列指标
 	DeadLetterCount prometheus.Gauge // 死信队列任务数
 
@@ -44,6 +50,9 @@ type Metrics struct {
 
 	// 熔断器指标
 	CircuitBreakerState *prometheus.GaugeVec // 熔断器状态（0=closed, 1=open, 2=half-open）
+
+	// 时钟指标
+	ClockDrift prometheus.Gauge // 本地时钟与Redis时钟的偏差（秒）
 }
 
 // NewMetrics 创建指标收集器
@@ -150,6 +159,22 @@ func NewMetrics(namespace string, enabled bool, registerer prometheus.Registerer
 			[]string{"type", "retry_count"},
 		),
 
+		TaskStolen: factory.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "task_stolen_total",
+				Help:      "Total number of buffered tasks stolen by idle workers",
+			},
+		),
+		CronOverlap: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "cron_overlap_total",
+				Help:      "Total number of cron runs that overlapped a running instance, by overlap policy",
+			},
+			[]string{"type", "policy"},
+		),
+
 		DeadLetterCount: factory.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -174,6 +199,14 @@ func NewMetrics(namespace string, enabled bool, registerer prometheus.Registerer
 			},
 			[]string{"name"},
 		),
+
+		ClockDrift: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "clock_drift_seconds",
+				Help:      "Measured offset of redis time relative to local time in seconds",
+			},
+		),
 	}
 
 	return m
@@ -285,6 +318,30 @@ func (m *Metrics) RecordTaskRetry(taskType string, retryCount int) {
 	m.TaskRetry.WithLabelValues(m.sanitizeTaskType(taskType), retryStr).Inc()
 }
 
+// RecordTaskExpired 记录过期丢弃的任务，计入 task_executed_total (status=expired)，不计执行耗时
+func (m *Metrics) RecordTaskExpired(taskType string) {
+	if !m.enabled {
+		return
+	}
+	m.TaskExecuted.WithLabelValues(m.sanitizeTaskType(taskType), string(StatusExpired)).Inc()
+}
+
+// RecordTaskStolen 记录任务窃取
+func (m *Metrics) RecordTaskStolen() {
+	if !m.enabled {
+		return
+	}
+	m.TaskStolen.Inc()
+}
+
+// RecordCronOverlap 记录 Cron 重叠处理
+func (m *Metrics) RecordCronOverlap(taskType string, policy OverlapPolicy) {
+	if !m.enabled {
+		return
+	}
+	m.CronOverlap.WithLabelValues(m.sanitizeTaskType(taskType), string(policy)).Inc()
+}
+
 // RecordDeadLetterCount 记录死信队列任务数
 func (m *Metrics) RecordDeadLetterCount(count float64) {
 	if !m.enabled {
@@ -309,6 +366,14 @@ func (m *Metrics) RecordCircuitBreakerState(name string, state CircuitState) {
 	m.CircuitBreakerState.WithLabelValues(name).Set(float64(state))
 }
 
+// RecordClockDrift 记录时钟偏差
+func (m *Metrics) RecordClockDrift(seconds float64) {
+	if !m.enabled {
+		return
+	}
+	m.ClockDrift.Set(seconds)
+}
+
 // RegisterTaskType 注册任务类型到白名单（在 handler 注册时调用）
 func (m *Metrics) RegisterTaskType(taskType string) {
 	if !m.enabled {
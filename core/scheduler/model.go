@@ -35,6 +35,25 @@ const (
 	StatusFailed    TaskStatus = "failed"    // 失败
 	StatusCancelled TaskStatus = "cancelled" // 已取消
 	StatusDead      TaskStatus = "dead"      // 死信
+	StatusExpired   TaskStatus = "expired"   // 已过期（超过 Expiry 仍未开始执行）
+)
+
+// OverlapPolicy Cron 重叠策略：到达计划时间时上一实例仍在运行的处理方式
+type OverlapPolicy string
+
+const (
+	OverlapSkip    OverlapPolicy = "skip"    // 跳过本次执行
+	OverlapQueue   OverlapPolicy = "queue"   // 等待运行中的实例结束后执行
+	OverlapReplace OverlapPolicy = "replace" // 取消最早启动的运行中实例，立即执行
+)
+
+// StateTimeoutPolicy 自定义状态到期 (未收到外部回调) 时的处理方式，见 WithTaskState
+type StateTimeoutPolicy string
+
+const (
+	StateTimeoutResume StateTimeoutPolicy = "resume" // 重新执行 handler，由 handler 通过 ResumedFrom 判断后续处理
+	StateTimeoutRetry  StateTimeoutPolicy = "retry"  // 按失败处理：计入重试次数，超过上限进入死信队列
+	StateTimeoutDead   StateTimeoutPolicy = "dead"   // 直接进入死信队列
 )
 
 // Task 任务定义
@@ -45,25 +64,35 @@ type Task struct {
 	Payload          []byte            `json:"payload"`                     // 任务数据
 	ScheduleAt       time.Time         `json:"schedule_at"`                 // 计划执行时间
 	Cron             string            `json:"cron,omitempty"`              // Cron表达式
+	CronID           string            `json:"cron_id,omitempty"`           // Cron 系列ID，即首个实例的ID，各次执行共享
+	CronOverlap      OverlapPolicy     `json:"cron_overlap,omitempty"`      // Cron 重叠策略，为空时下次执行在本次成功后才调度
+	CronMaxRunning   int               `json:"cron_max_running,omitempty"`  // Cron 最大并发实例数，默认 1
 	MaxRetry         int               `json:"max_retry"`                   // 最大重试次数
 	Timeout          time.Duration     `json:"timeout"`                     // 超时时间
+	Expiry           time.Duration     `json:"expiry,omitempty"`            // 过期时间，到期后超过该时长仍未开始执行则丢弃
 	DeduplicationKey string            `json:"deduplication_key,omitempty"` // 去重键
 	DeduplicationTTL time.Duration     `json:"deduplication_ttl,omitempty"` // 去重窗口
 	Tags             map[string]string `json:"tags,omitempty"`              // 标签
 	Context          map[string]any    `json:"context,omitempty"`           // 上下文数据
+
+	delay time.Duration // WithDelay 设置的相对延迟，提交时按调度器时钟换算为 ScheduleAt
 }
 
 // TaskInfo 任务详细信息（包含执行状态）
 type TaskInfo struct {
 	Task
-	Status        TaskStatus     `json:"status"`                   // 任务状态
-	RetryCount    int            `json:"retry_count"`              // 当前重试次数
-	WorkerID      string         `json:"worker_id,omitempty"`      // 执行Worker ID
-	SubmitTime    time.Time      `json:"submit_time"`              // 提交时间
-	StartTime     *time.Time     `json:"start_time,omitempty"`     // 开始执行时间
-	FinishTime    *time.Time     `json:"finish_time,omitempty"`    // 完成时间
-	LastError     string         `json:"last_error,omitempty"`     // 最后错误信息
-	ExecutionTime *time.Duration `json:"execution_time,omitempty"` // 执行耗时
+	Status        TaskStatus     `json:"status"`                    // 任务状态
+	RetryCount    int            `json:"retry_count"`               // 当前重试次数
+	WorkerID      string         `json:"worker_id,omitempty"`       // 执行Worker ID
+	SubmitTime    time.Time      `json:"submit_time"`               // 提交时间
+	StartTime     *time.Time     `json:"start_time,omitempty"`      // 开始执行时间
+	FinishTime    *time.Time     `json:"finish_time,omitempty"`     // 完成时间
+	LastError     string         `json:"last_error,omitempty"`      // 最后错误信息
+	ExecutionTime *time.Duration `json:"execution_time,omitempty"`  // 执行耗时
+	Output        string         `json:"output,omitempty"`          // 最近一次执行捕获的输出（需启用 TaskLog）
+	State         TaskStatus     `json:"state,omitempty"`           // 最近一次进入的自定义状态（见 SetState）
+	StateDeadline *time.Time     `json:"state_deadline,omitempty"`  // 自定义状态的截止时间
+	StateTimedOut bool           `json:"state_timed_out,omitempty"` // 自定义状态是否因到期而结束
 }
 
 // Reset 重置TaskInfo（用于对象池）
@@ -74,12 +103,17 @@ func (t *TaskInfo) Reset() {
 	t.Payload = nil
 	t.ScheduleAt = time.Time{}
 	t.Cron = ""
+	t.CronID = ""
+	t.CronOverlap = ""
+	t.CronMaxRunning = 0
 	t.MaxRetry = 0
 	t.Timeout = 0
+	t.Expiry = 0
 	t.DeduplicationKey = ""
 	t.DeduplicationTTL = 0
 	t.Tags = nil
 	t.Context = nil
+	t.delay = 0
 	t.Status = ""
 	t.RetryCount = 0
 	t.WorkerID = ""
@@ -88,6 +122,10 @@ func (t *TaskInfo) Reset() {
 	t.FinishTime = nil
 	t.LastError = ""
 	t.ExecutionTime = nil
+	t.Output = ""
+	t.State = ""
+	t.StateDeadline = nil
+	t.StateTimedOut = false
 }
 
 // ToMap 将TaskInfo转换为Map（用于存储到Redis Hash）
@@ -99,8 +137,12 @@ func (t *TaskInfo) ToMap() map[string]any {
 	m["payload"] = string(t.Payload)
 	m["schedule_at"] = t.ScheduleAt.Unix()
 	m["cron"] = t.Cron
+	m["cron_id"] = t.CronID
+	m["cron_overlap"] = string(t.CronOverlap)
+	m["cron_max_running"] = t.CronMaxRunning
 	m["max_retry"] = t.MaxRetry
 	m["timeout"] = t.Timeout.Seconds()
+	m["expiry"] = t.Expiry.Seconds()
 	m["deduplication_key"] = t.DeduplicationKey
 	m["deduplication_ttl"] = t.DeduplicationTTL.Seconds()
 	m["status"] = string(t.Status)
@@ -126,6 +168,9 @@ func (t *TaskInfo) ToMap() map[string]any {
 	if t.ExecutionTime != nil {
 		m["execution_time"] = t.ExecutionTime.Seconds()
 	}
+	m["state"] = string(t.State)
+	m["state_deadline"] = stateDeadlineUnix(t.StateDeadline)
+	m["state_timed_out"] = t.StateTimedOut
 
 	return m
 }
@@ -136,10 +181,14 @@ func (t *TaskInfo) FromMap(m map[string]string) error {
 	t.Type = m["type"]
 	t.Payload = []byte(m["payload"])
 	t.Cron = m["cron"]
+	t.CronID = m["cron_id"]
+	t.CronOverlap = OverlapPolicy(m["cron_overlap"])
 	t.DeduplicationKey = m["deduplication_key"]
 	t.Status = TaskStatus(m["status"])
 	t.WorkerID = m["worker_id"]
 	t.LastError = m["last_error"]
+	t.State = TaskStatus(m["state"])
+	t.StateTimedOut = m["state_timed_out"] == "1"
 
 	// 解析整数
 	if v := m["priority"]; v != "" {
@@ -153,6 +202,9 @@ func (t *TaskInfo) FromMap(m map[string]string) error {
 	if v := m["retry_count"]; v != "" {
 		json.Unmarshal([]byte(v), &t.RetryCount)
 	}
+	if v := m["cron_max_running"]; v != "" {
+		json.Unmarshal([]byte(v), &t.CronMaxRunning)
+	}
 
 	// 解析时间戳
 	if v := m["schedule_at"]; v != "" {
@@ -177,6 +229,12 @@ func (t *TaskInfo) FromMap(m map[string]string) error {
 		ft := time.Unix(ts, 0)
 		t.FinishTime = &ft
 	}
+	if v := m["state_deadline"]; v != "" && v != "0" {
+		var ts int64
+		json.Unmarshal([]byte(v), &ts)
+		sd := time.Unix(ts, 0)
+		t.StateDeadline = &sd
+	}
 
 	// 解析时长
 	if v := m["timeout"]; v != "" {
@@ -184,6 +242,11 @@ func (t *TaskInfo) FromMap(m map[string]string) error {
 		json.Unmarshal([]byte(v), &sec)
 		t.Timeout = time.Duration(sec * float64(time.Second))
 	}
+	if v := m["expiry"]; v != "" {
+		var sec float64
+		json.Unmarshal([]byte(v), &sec)
+		t.Expiry = time.Duration(sec * float64(time.Second))
+	}
 	if v := m["deduplication_ttl"]; v != "" {
 		var sec float64
 		json.Unmarshal([]byte(v), &sec)
@@ -253,6 +316,10 @@ type QueueStats struct {
 	NormalCount  int64 `json:"normal_count"`  // 普通优先级队列任务数
 	LowCount     int64 `json:"low_count"`     // 低优先级队列任务数
 	RunningCount int64 `json:"running_count"` // 运行中任务数
+	ParkedCount  int64 `json:"parked_count"`  // 处于自定义状态、等待外部回调的任务数
 	DLQCount     int64 `json:"dlq_count"`     // 死信队列任务数
 	WorkerCount  int64 `json:"worker_count"`  // Worker数量
+
+	PausedTypes      []string `json:"paused_types,omitempty"`      // 已暂停的任务类型
+	PausedPriorities []string `json:"paused_priorities,omitempty"` // 已暂停的优先级
 }
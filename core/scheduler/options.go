@@ -27,6 +27,8 @@ type RedisOptions struct {
 type WorkerOptions struct {
 	Count               int           // Worker数量
 	Concurrency         int           // 每个Worker的并发协程数（默认：5）
+	PrefetchSize        int           // 每个Worker预取到本地缓冲的任务数（默认：10）
+	WorkStealing        bool          // 空闲Worker从同进程内其他Worker的缓冲中窃取任务（默认：开启）
 	LeaseTTL            time.Duration // Worker租约TTL
 	RenewInterval       time.Duration // Worker续约间隔
 	ShutdownGracePeriod time.Duration // 优雅关闭等待时间
@@ -55,6 +57,31 @@ type CircuitBreakerOptions struct {
 	Timeout     time.Duration // 熔断超时
 }
 
+// TaskLogOptions 任务输出捕获配置
+type TaskLogOptions struct {
+	Enabled bool          // 是否捕获 TaskLogger 的输出
+	MaxSize int           // 单次执行最多保留的字节数，超出部分丢弃
+	TTL     time.Duration // 输出在Redis中的保留时间
+}
+
+// CostOptions 执行成本统计配置
+type CostOptions struct {
+	Enabled bool          // 是否按任务类型与租户统计执行成本
+	TTL     time.Duration // 日汇总在Redis中的保留时间
+}
+
+// RetentionOptions 终态任务信息保留配置
+type RetentionOptions struct {
+	Terminal time.Duration // 已取消、过期、死信任务信息的保留时间，<=0 表示永久保留
+	Success  time.Duration // 成功任务信息的保留时间，<=0 表示立即删除
+}
+
+// ClockOptions 时钟配置
+type ClockOptions struct {
+	UseRedisTime bool          // 是否以 Redis TIME 作为权威时钟
+	SyncInterval time.Duration // 与 Redis 重新测量偏差的间隔
+}
+
 // MetricsOptions 监控配置
 type MetricsOptions struct {
 	Enabled  bool                 // 是否启用Prometheus指标
@@ -98,6 +125,7 @@ type Options struct {
 	// 死信队列配置
 	DLQEnabled bool // 是否启用死信队列
 	DLQMaxSize int  // 死信队列最大容量
+	DLQExpired bool // 过期任务是否加入死信队列 (见 WithExpiry)
 
 	// 限流配置
 	RateLimit RateLimitOptions
@@ -105,6 +133,24 @@ type Options struct {
 	// 熔断配置
 	CircuitBreaker CircuitBreakerOptions
 
+	// 任务输出捕获配置
+	TaskLog TaskLogOptions
+
+	// 终态任务保留配置
+	Retention RetentionOptions
+
+	// 执行成本统计配置
+	Cost CostOptions
+
+	// 时钟配置
+	Clock ClockOptions
+
+	// 自定义任务状态及其到期策略 (见 WithTaskState)
+	States map[TaskStatus]StateTimeoutPolicy
+
+	// 提交拦截器，键为任务类型或 AllTaskTypes (见 WithSubmitInterceptor)
+	SubmitInterceptors map[string][]SubmitInterceptor
+
 	// 监控配置
 	Metrics MetricsOptions
 
@@ -133,6 +179,8 @@ func DefaultOptions() *Options {
 		Worker: WorkerOptions{
 			Count:               10,
 			Concurrency:         5,
+			PrefetchSize:        taskBufferSize,
+			WorkStealing:        true,
 			LeaseTTL:            30 * time.Second,
 			RenewInterval:       10 * time.Second,
 			ShutdownGracePeriod: 30 * time.Second,
@@ -161,6 +209,23 @@ func DefaultOptions() *Options {
 			MaxFailures: 5,
 			Timeout:     30 * time.Second,
 		},
+		TaskLog: TaskLogOptions{
+			Enabled: false,
+			MaxSize: 64 << 10,
+			TTL:     7 * 24 * time.Hour,
+		},
+		Retention: RetentionOptions{
+			Terminal: 7 * 24 * time.Hour,
+			Success:  0,
+		},
+		Cost: CostOptions{
+			Enabled: false,
+			TTL:     90 * 24 * time.Hour,
+		},
+		Clock: ClockOptions{
+			UseRedisTime: false,
+			SyncInterval: 30 * time.Second,
+		},
 		Metrics: MetricsOptions{
 			Enabled: false,
 			Port:    9090,
@@ -226,6 +291,23 @@ func WithWorkerConcurrency(concurrency int) Option {
 	}
 }
 
+// WithPrefetch 设置每个Worker预取到本地缓冲的任务数。
+// 缓冲越大拉取越平滑，但单个Worker阻塞时积压的任务也越多，建议配合 WithWorkStealing 使用
+func WithPrefetch(size int) Option {
+	return func(o *Options) {
+		o.Worker.PrefetchSize = size
+	}
+}
+
+// WithWorkStealing 启用/禁用同进程内Worker间的任务窃取：
+// 协程池有空闲的Worker在本地缓冲为空时，从缓冲积压最多的Worker取走最早预取的任务，
+// 避免单个Worker阻塞导致其缓冲中的任务排队等待 (队头阻塞)
+func WithWorkStealing(enabled bool) Option {
+	return func(o *Options) {
+		o.Worker.WorkStealing = enabled
+	}
+}
+
 // WithLeaseTTL 设置Worker租约TTL
 func WithLeaseTTL(ttl time.Duration) Option {
 	return func(o *Options) {
@@ -280,6 +362,14 @@ func WithDLQ(enabled bool, maxSize int) Option {
 	}
 }
 
+// WithDLQExpired 设置过期任务 (见 WithExpiry) 是否加入死信队列，默认仅标记为 StatusExpired。
+// 加入死信队列的过期任务 LastError 为 ErrTaskExpired，可与执行失败的死信区分
+func WithDLQExpired(enabled bool) Option {
+	return func(o *Options) {
+		o.DLQExpired = enabled
+	}
+}
+
 // WithRateLimit 启用限流
 func WithRateLimit(enabled bool, rate, burst int) Option {
 	return func(o *Options) {
@@ -298,6 +388,66 @@ func WithCircuitBreaker(enabled bool, maxFailures int, timeout time.Duration) Op
 	}
 }
 
+// WithTaskLog 启用任务输出捕获：handler 通过 TaskLogger(ctx) 写入的日志
+// 除照常输出到调度器日志外还会被保存 (每次执行最多 maxSize 字节，保留 ttl)，可由 GetTaskInfo / GetTaskOutput 查看
+func WithTaskLog(maxSize int, ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TaskLog.Enabled = true
+		if maxSize > 0 {
+			o.TaskLog.MaxSize = maxSize
+		}
+		if ttl > 0 {
+			o.TaskLog.TTL = ttl
+		}
+	}
+}
+
+// WithRetention 设置终态任务信息的保留时间 (通过 EXPIRE 实现)：
+// terminal 作用于已取消、过期与死信任务 (<=0 表示永久保留)，success 作用于成功任务
+// (<=0 表示成功后立即删除)，保留期间可通过 GetTaskInfo 查询执行结果
+func WithRetention(terminal, success time.Duration) Option {
+	return func(o *Options) {
+		o.Retention.Terminal = terminal
+		o.Retention.Success = success
+	}
+}
+
+// WithCostAccounting 启用执行成本统计：每次执行的时长、是否重试、payload 大小以及
+// handler 通过 AddCost 上报的成本单位，按 (日期, 任务类型, 租户) 汇总到 Redis 并保留 ttl
+// (<=0 时使用默认 90 天)，可由 Costs 导出。租户取自任务的 TenantTag 标签 (见 WithTenant)
+func WithCostAccounting(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.Cost.Enabled = true
+		if ttl > 0 {
+			o.Cost.TTL = ttl
+		}
+	}
+}
+
+// WithRedisClock 以 Redis TIME 作为权威时钟，避免多实例间时钟偏差导致任务提前/延后执行。
+// 每隔 syncInterval 重新测量一次偏差 (<=0 时使用默认 30s)，测量值可通过 ClockDrift 与
+// clock_drift_seconds 指标查看
+func WithRedisClock(syncInterval time.Duration) Option {
+	return func(o *Options) {
+		o.Clock.UseRedisTime = true
+		if syncInterval > 0 {
+			o.Clock.SyncInterval = syncInterval
+		}
+	}
+}
+
+// WithTaskState 注册自定义任务状态 (如 "waiting_external")，handler 可通过 SetState 将任务挂起到该状态，
+// 等待外部回调 (ResumeTask / CompleteTask)；截止时间到达仍未回调时按 policy 处理。
+// 状态名不能与内置状态重复，所有实例须注册相同的状态
+func WithTaskState(state TaskStatus, policy StateTimeoutPolicy) Option {
+	return func(o *Options) {
+		if o.States == nil {
+			o.States = make(map[TaskStatus]StateTimeoutPolicy)
+		}
+		o.States[state] = policy
+	}
+}
+
 // WithMetrics 启用Prometheus指标
 func WithMetrics(enabled bool) Option {
 	return func(o *Options) {
@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cronQueueDelay 排队策略下实例重新检查槽位的间隔下限（延迟队列以秒为精度）
+const cronQueueDelay = time.Second
+
+// cronSlot 运行中的 Cron 实例占用的并发槽位
+type cronSlot struct {
+	key    string                  // 槽位锁的键
+	cancel context.CancelCauseFunc // 取消实例执行，被接管时以 ErrCronReplaced 为原因
+}
+
+// cronSlotKey 构建槽位锁的键，锁的值为占用槽位的任务ID
+func cronSlotKey(cronID string, slot int) string {
+	return "cron:" + cronID + ":" + strconv.Itoa(slot)
+}
+
+// cronMaxRunning 返回最大并发实例数，未设置时为 1
+func cronMaxRunning(taskInfo *TaskInfo) int {
+	if taskInfo.CronMaxRunning <= 0 {
+		return 1
+	}
+	return taskInfo.CronMaxRunning
+}
+
+// acquireCronSlot 为 Cron 实例占用并发槽位，槽位已满时按重叠策略处理。
+// 返回空键表示本实例不应执行（已跳过或重新排队）
+func (w *Worker) acquireCronSlot(ctx context.Context, taskInfo *TaskInfo) (string, error) {
+	lock := w.scheduler.lock
+	ttl := w.scheduler.opts.LockTimeout
+	maxRunning := cronMaxRunning(taskInfo)
+
+	for i := range maxRunning {
+		key := cronSlotKey(taskInfo.CronID, i)
+		acquired, err := lock.Acquire(ctx, key, taskInfo.ID, ttl)
+		if err != nil {
+			return "", fmt.Errorf("acquire cron slot: %w", err)
+		}
+		if acquired {
+			return key, nil
+		}
+	}
+
+	w.scheduler.metrics.RecordCronOverlap(taskInfo.Type, taskInfo.CronOverlap)
+
+	switch taskInfo.CronOverlap {
+	case OverlapReplace:
+		key, err := w.oldestCronSlot(ctx, taskInfo.CronID, maxRunning)
+		if err != nil {
+			return "", err
+		}
+		if err := lock.Takeover(ctx, key, taskInfo.ID, ttl); err != nil {
+			return "", fmt.Errorf("take over cron slot: %w", err)
+		}
+		w.logger.Info().Str("task_id", taskInfo.ID).Str("cron_id", taskInfo.CronID).Msg("cron run replacing running instance")
+		return key, nil
+
+	case OverlapQueue:
+		delay := max(w.scheduler.opts.ScanInterval, cronQueueDelay)
+		taskInfo.Status = StatusPending
+		taskInfo.ScheduleAt = w.scheduler.now(ctx).Add(delay)
+		if err := w.scheduler.saveTaskInfo(ctx, taskInfo); err != nil {
+			return "", fmt.Errorf("update task status: %w", err)
+		}
+		if err := w.scheduler.queue.AddDelayed(ctx, taskInfo.ID, float64(taskInfo.ScheduleAt.Unix())); err != nil {
+			return "", fmt.Errorf("requeue cron run: %w", err)
+		}
+		w.logger.Debug().Str("task_id", taskInfo.ID).Str("cron_id", taskInfo.CronID).Msg("cron run queued behind running instance")
+		return "", nil
+
+	default: // OverlapSkip
+		now := time.Now()
+		taskInfo.Status = StatusCancelled
+		taskInfo.LastError = ErrCronOverlapSkipped.Error()
+		taskInfo.FinishTime = &now
+		if err := w.scheduler.retainTaskInfo(ctx, taskInfo, w.scheduler.opts.Retention.Terminal); err != nil {
+			w.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to save task info")
+		}
+		w.logger.Info().Str("task_id", taskInfo.ID).Str("cron_id", taskInfo.CronID).Msg("cron run skipped, previous instance still running")
+		w.scheduler.scheduleNextCron(ctx, taskInfo)
+		return "", nil
+	}
+}
+
+// oldestCronSlot 返回被最早启动的实例占用的槽位。持有者的任务信息已不存在时视为最早
+func (w *Worker) oldestCronSlot(ctx context.Context, cronID string, maxRunning int) (string, error) {
+	var oldestKey string
+	var oldestStart time.Time
+	for i := range maxRunning {
+		key := cronSlotKey(cronID, i)
+		holder, err := w.scheduler.lock.GetLockValue(ctx, key)
+		if err == redis.Nil {
+			// 槽位刚被释放
+			return key, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("get cron slot holder: %w", err)
+		}
+		info, err := w.scheduler.GetTaskInfo(ctx, holder)
+		if err != nil || info.StartTime == nil {
+			return key, nil
+		}
+		if oldestKey == "" || info.StartTime.Before(oldestStart) {
+			oldestKey, oldestStart = key, *info.StartTime
+		}
+	}
+	return oldestKey, nil
+}
+
+// trackCronSlot 登记运行中实例的槽位，由续约循环延长，并在被接管时取消执行
+func (w *Worker) trackCronSlot(taskID, key string, cancel context.CancelCauseFunc) {
+	w.cronSlots.Store(taskID, &cronSlot{key: key, cancel: cancel})
+}
+
+// releaseCronSlot 释放槽位。槽位已被接管时锁的值不匹配，释放为空操作
+func (w *Worker) releaseCronSlot(ctx context.Context, taskID, key string) {
+	w.cronSlots.Delete(taskID)
+	if _, err := w.scheduler.lock.Release(ctx, key, taskID); err != nil {
+		w.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to release cron slot")
+	}
+}
+
+// renewCronSlots 延长运行中实例的槽位锁；槽位已被新实例接管的，取消其执行
+func (w *Worker) renewCronSlots(ctx context.Context) {
+	w.cronSlots.Range(func(k, v any) bool {
+		taskID, slot := k.(string), v.(*cronSlot)
+		extended, err := w.scheduler.lock.Extend(ctx, slot.key, taskID, w.scheduler.opts.LockTimeout)
+		if err != nil {
+			w.logger.Warn().Err(err).Str("task_id", taskID).Msg("failed to extend cron slot")
+			return true
+		}
+		if !extended {
+			w.logger.Info().Str("task_id", taskID).Msg("cron slot taken over, cancelling run")
+			slot.cancel(ErrCronReplaced)
+		}
+		return true
+	})
+}
+
+// handleCronReplaced 处理被新实例接管而取消的 Cron 实例：标记为已取消，不重试
+func (w *Worker) handleCronReplaced(ctx context.Context, taskInfo *TaskInfo) {
+	w.logger.Info().Str("task_id", taskInfo.ID).Str("type", taskInfo.Type).Msg("cron run cancelled by a newer instance")
+
+	now := time.Now()
+	taskInfo.Status = StatusCancelled
+	taskInfo.LastError = ErrCronReplaced.Error()
+	taskInfo.FinishTime = &now
+	if err := w.scheduler.retainTaskInfo(ctx, taskInfo, w.scheduler.opts.Retention.Terminal); err != nil {
+		w.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to save task info")
+	}
+}
@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// pauseRefreshInterval 暂停状态本地快照的刷新间隔，其他实例的暂停 / 恢复最多延迟该时长生效
+	pauseRefreshInterval = time.Second
+	// pausedRequeueDelay 已暂停类型的任务被领取后放回延迟队列的等待时长
+	pausedRequeueDelay = 5 * time.Second
+)
+
+// PauseScope 暂停范围
+type PauseScope string
+
+const (
+	PauseScopeType     PauseScope = "type"     // 按任务类型暂停
+	PauseScopePriority PauseScope = "priority" // 按优先级暂停
+)
+
+// PausedState 当前的暂停状态
+type PausedState struct {
+	Types      []string `json:"types"`      // 已暂停的任务类型
+	Priorities []string `json:"priorities"` // 已暂停的优先级 (high / normal / low)
+}
+
+// pauseSnapshot 暂停状态的本地快照
+type pauseSnapshot struct {
+	types      map[string]struct{}
+	priorities map[string]struct{}
+	loadedAt   time.Time
+}
+
+// priorityName 优先级对应的就绪队列名称
+func priorityName(priority Priority) string {
+	switch {
+	case priority >= PriorityHigh:
+		return "high"
+	case priority >= PriorityNormal:
+		return "normal"
+	default:
+		return "low"
+	}
+}
+
+// keyPaused 暂停状态 Hash key，field 为类型或优先级名称，value 为暂停时间 (Unix 秒)
+func (q *Queue) keyPaused(scope PauseScope) string {
+	return q.namespace + ":paused:" + string(scope)
+}
+
+// SetPaused 设置类型或优先级的暂停状态
+func (q *Queue) SetPaused(ctx context.Context, scope PauseScope, name string, paused bool) error {
+	var err error
+	if paused {
+		err = q.client.HSet(ctx, q.keyPaused(scope), name, strconv.FormatInt(time.Now().Unix(), 10)).Err()
+	} else {
+		err = q.client.HDel(ctx, q.keyPaused(scope), name).Err()
+	}
+	if err != nil {
+		return err
+	}
+	// 本实例立即生效
+	q.paused.Store(nil)
+	return nil
+}
+
+// GetPaused 从 Redis 读取当前的暂停状态
+func (q *Queue) GetPaused(ctx context.Context) (*PausedState, error) {
+	snap, err := q.loadPaused(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := &PausedState{Types: []string{}, Priorities: []string{}}
+	for name := range snap.types {
+		state.Types = append(state.Types, name)
+	}
+	for _, p := range q.priorities {
+		if _, ok := snap.priorities[priorityName(p)]; ok {
+			state.Priorities = append(state.Priorities, priorityName(p))
+		}
+	}
+	slices.Sort(state.Types)
+	return state, nil
+}
+
+// TypePaused 任务类型是否已暂停 (基于本地快照)
+func (q *Queue) TypePaused(ctx context.Context, taskType string) bool {
+	_, ok := q.pauseState(ctx).types[taskType]
+	return ok
+}
+
+// priorityPaused 优先级是否已暂停 (基于本地快照)
+func (q *Queue) priorityPaused(ctx context.Context, priority Priority) bool {
+	_, ok := q.pauseState(ctx).priorities[priorityName(priority)]
+	return ok
+}
+
+// pauseState 返回暂停状态的本地快照，超过刷新间隔时从 Redis 重新加载；
+// 加载失败时沿用旧快照，避免 Redis 抖动导致误恢复已暂停的任务
+func (q *Queue) pauseState(ctx context.Context) *pauseSnapshot {
+	snap := q.paused.Load()
+	if snap != nil && time.Since(snap.loadedAt) < pauseRefreshInterval {
+		return snap
+	}
+	fresh, err := q.loadPaused(ctx)
+	if err != nil {
+		if snap == nil {
+			return &pauseSnapshot{}
+		}
+		return snap
+	}
+	return fresh
+}
+
+// loadPaused 从 Redis 加载暂停状态并更新本地快照
+func (q *Queue) loadPaused(ctx context.Context) (*pauseSnapshot, error) {
+	pipe := q.client.Pipeline()
+	typesCmd := pipe.HKeys(ctx, q.keyPaused(PauseScopeType))
+	prioritiesCmd := pipe.HKeys(ctx, q.keyPaused(PauseScopePriority))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	snap := &pauseSnapshot{
+		types:      make(map[string]struct{}),
+		priorities: make(map[string]struct{}),
+		loadedAt:   time.Now(),
+	}
+	for _, name := range typesCmd.Val() {
+		snap.types[name] = struct{}{}
+	}
+	for _, name := range prioritiesCmd.Val() {
+		snap.priorities[name] = struct{}{}
+	}
+	q.paused.Store(snap)
+	return snap, nil
+}
+
+// PauseType 暂停分发指定类型的任务，其他类型不受影响。
+// Worker 领取到已暂停类型的任务时不执行，将其放回延迟队列稍后重新检查；
+// 暂停状态保存在 Redis 中，对所有实例生效 (其他实例最多延迟约 1 秒)，重启后依然保留
+func (s *Scheduler) PauseType(ctx context.Context, taskType string) error {
+	if taskType == "" {
+		return ErrInvalidTaskType
+	}
+	if err := s.queue.SetPaused(ctx, PauseScopeType, taskType, true); err != nil {
+		return err
+	}
+	s.logger.Warn().Str("type", taskType).Msg("task type paused")
+	return nil
+}
+
+// ResumeType 恢复分发指定类型的任务
+func (s *Scheduler) ResumeType(ctx context.Context, taskType string) error {
+	if taskType == "" {
+		return ErrInvalidTaskType
+	}
+	if err := s.queue.SetPaused(ctx, PauseScopeType, taskType, false); err != nil {
+		return err
+	}
+	s.logger.Info().Str("type", taskType).Msg("task type resumed")
+	return nil
+}
+
+// PausePriority 暂停分发指定优先级的任务：Worker 不再读取该优先级的就绪队列，
+// 消息保留在队列中，恢复后按原顺序执行
+func (s *Scheduler) PausePriority(ctx context.Context, priority Priority) error {
+	if priority < PriorityLow || priority > PriorityHigh {
+		return ErrInvalidPriority
+	}
+	if err := s.queue.SetPaused(ctx, PauseScopePriority, priorityName(priority), true); err != nil {
+		return err
+	}
+	s.logger.Warn().Str("priority", priorityName(priority)).Msg("priority paused")
+	return nil
+}
+
+// ResumePriority 恢复分发指定优先级的任务
+func (s *Scheduler) ResumePriority(ctx context.Context, priority Priority) error {
+	if priority < PriorityLow || priority > PriorityHigh {
+		return ErrInvalidPriority
+	}
+	if err := s.queue.SetPaused(ctx, PauseScopePriority, priorityName(priority), false); err != nil {
+		return err
+	}
+	s.logger.Info().Str("priority", priorityName(priority)).Msg("priority resumed")
+	return nil
+}
+
+// Paused 返回当前已暂停的任务类型与优先级
+func (s *Scheduler) Paused(ctx context.Context) (*PausedState, error) {
+	return s.queue.GetPaused(ctx)
+}
+
+// deferPaused 将已暂停类型的任务放回延迟队列，保持计划执行时间不变以免推迟其过期时间
+func (w *Worker) deferPaused(ctx context.Context, taskInfo *TaskInfo) error {
+	taskInfo.Status = StatusPending
+	if err := w.scheduler.saveTaskInfo(ctx, taskInfo); err != nil {
+		return fmt.Errorf("update task status: %w", err)
+	}
+	retryAt := w.scheduler.now(ctx).Add(pausedRequeueDelay)
+	if err := w.scheduler.queue.AddDelayed(ctx, taskInfo.ID, float64(retryAt.Unix())); err != nil {
+		return fmt.Errorf("requeue paused task: %w", err)
+	}
+	w.logger.Debug().Str("task_id", taskInfo.ID).Str("type", taskInfo.Type).Msg("task type paused, requeued")
+	return nil
+}
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxPlanOccurrences 单个 Cron 任务在一次 Plan 中最多推演的次数，避免高频表达式在长窗口下生成过多条目
+const maxPlanOccurrences = 10000
+
+// PlannedRun 预计的一次执行
+type PlannedRun struct {
+	TaskID    string    // 延迟队列中的任务ID；Cron 推演出的后续执行沿用当前实例的ID
+	Type      string    // 任务类型
+	Priority  Priority  // 优先级
+	Cron      string    // Cron表达式，非周期任务为空
+	RunAt     time.Time // 预计执行时间（秒级精度，与延迟队列一致）
+	Projected bool      // 是否为 Cron 推演出的后续执行（尚未进入延迟队列）
+}
+
+// Plan 推演 [from, to] 时间窗口内将要执行的任务，不会执行或修改任何任务。
+//
+// 数据来源为延迟队列：
+//   - 普通延迟任务按计划时间列出
+//   - Cron 任务从当前实例的计划时间起，按表达式推演窗口内的后续执行
+//
+// 结果按执行时间升序排列。限制：
+//   - 推演假设每次执行都能按时完成；实际下次时间按完成时刻计算，长耗时任务可能错过部分周期
+//   - 正在执行或已在就绪队列中的 Cron 实例不在延迟队列中，其后续执行不会出现在结果中
+//   - 单个 Cron 任务最多推演 10000 次
+//
+// 示例：列出周六维护窗口内会触发的任务
+//
+//	runs, err := s.Plan(ctx, windowStart, windowStart.Add(4*time.Hour))
+func (s *Scheduler) Plan(ctx context.Context, from, to time.Time) ([]PlannedRun, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid plan window: to %s is before from %s", to, from)
+	}
+
+	// Cron 任务的当前实例可能早于窗口，因此取截止到 to 的全部延迟任务
+	delayed, err := s.client.ZRangeByScoreWithScores(ctx, s.opts.Namespace+":delayed", &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(to.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delayed queue: %w", err)
+	}
+	if len(delayed) == 0 {
+		return nil, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(delayed))
+	for i, z := range delayed {
+		cmds[i] = pipe.HGetAll(ctx, s.buildTaskKey(z.Member.(string)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read task info: %w", err)
+	}
+
+	var runs []PlannedRun
+	for i, z := range delayed {
+		fields, err := cmds[i].Result()
+		if err != nil || len(fields) == 0 {
+			// 元数据已被清理的孤儿成员，调度时同样会被跳过
+			continue
+		}
+		var info TaskInfo
+		if err := info.FromMap(fields); err != nil {
+			return nil, fmt.Errorf("failed to parse task info: %w", err)
+		}
+
+		run := PlannedRun{
+			TaskID:   info.ID,
+			Type:     info.Type,
+			Priority: info.Priority,
+			Cron:     info.Cron,
+			RunAt:    time.Unix(int64(z.Score), 0),
+		}
+		if !run.RunAt.Before(from) {
+			runs = append(runs, run)
+		}
+		if info.Cron == "" {
+			continue
+		}
+
+		// 当前实例早于窗口时直接从窗口起点推演（Next 返回严格晚于参数的时间）
+		next := run.RunAt
+		if next.Before(from) {
+			next = from.Add(-time.Second)
+		}
+		for range maxPlanOccurrences {
+			if next, err = s.cronParser.Next(info.Cron, next); err != nil {
+				return nil, fmt.Errorf("task %s: %w", info.ID, err)
+			}
+			if next.IsZero() || next.After(to) {
+				break
+			}
+			run.RunAt = next
+			run.Projected = true
+			runs = append(runs, run)
+		}
+	}
+
+	slices.SortStableFunc(runs, func(a, b PlannedRun) int {
+		return a.RunAt.Compare(b.RunAt)
+	})
+	return runs, nil
+}
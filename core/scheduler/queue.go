@@ -2,25 +2,40 @@ package scheduler
 
 import (
 	"context"
+	_ "embed"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+var (
+	//go:embed lua/boost_task.lua
+	boostTaskScript string
+
+	//go:embed lua/move_task.lua
+	moveTaskScript string
+)
+
 // Queue 队列管理器
 type Queue struct {
-	client          *redis.Client
-	namespace       string
-	consumerName    string      // Worker唯一标识
-	priorities      [3]Priority // 预分配优先级数组
-	keyDelayedCache string      // 缓存延迟队列key
-	keyGroupCache   string      // 缓存消费者组key
-	keyStreamHigh   string      // 缓存高优先级stream key
-	keyStreamNormal string      // 缓存普通优先级stream key
-	keyStreamLow    string      // 缓存低优先级stream key
+	client           *redis.Client
+	namespace        string
+	consumerName     string      // Worker唯一标识
+	priorities       [3]Priority // 预分配优先级数组
+	keyDelayedCache  string      // 缓存延迟队列key
+	keyParkedCache   string      // 缓存自定义状态队列key
+	keyExpiringCache string      // 缓存过期索引key
+	keyGroupCache    string      // 缓存消费者组key
+	keyStreamHigh    string      // 缓存高优先级stream key
+	keyStreamNormal  string      // 缓存普通优先级stream key
+	keyStreamLow     string      // 缓存低优先级stream key
+	lock             *DistLock   // 任务锁，接管消息时判断原Worker是否仍在执行
+
+	paused atomic.Pointer[pauseSnapshot] // 暂停状态本地快照
 }
 
 // NewQueue 创建队列管理器
@@ -29,9 +44,12 @@ func NewQueue(client *redis.Client, namespace string) *Queue {
 		client:     client,
 		namespace:  namespace,
 		priorities: [3]Priority{PriorityHigh, PriorityNormal, PriorityLow},
+		lock:       NewDistLock(client, namespace),
 	}
 	// 预计算常用key
 	q.keyDelayedCache = fmt.Sprintf("%s:delayed", namespace)
+	q.keyParkedCache = fmt.Sprintf("%s:parked", namespace)
+	q.keyExpiringCache = fmt.Sprintf("%s:expiring", namespace)
 	q.keyGroupCache = fmt.Sprintf("%s:consumers", namespace)
 	q.keyStreamHigh = fmt.Sprintf("%s:stream:high", namespace)
 	q.keyStreamNormal = fmt.Sprintf("%s:stream:normal", namespace)
@@ -49,6 +67,16 @@ func (q *Queue) keyDelayed() string {
 	return q.keyDelayedCache
 }
 
+// keyParked 自定义状态队列key，按截止时间排序
+func (q *Queue) keyParked() string {
+	return q.keyParkedCache
+}
+
+// keyExpiring 过期索引key，按过期时间排序
+func (q *Queue) keyExpiring() string {
+	return q.keyExpiringCache
+}
+
 // keyStream 就绪队列Stream key（使用预计算缓存）
 func (q *Queue) keyStream(priority Priority) string {
 	switch {
@@ -105,9 +133,14 @@ func (q *Queue) PopReady(ctx context.Context, timeout int) (string, Priority, st
 
 	groupName := q.keyGroupCache
 
-	// 按优先级尝试读取（高优先级优先）- 使用预分配数组
+	// 按优先级尝试读取（高优先级优先）- 使用预分配数组，跳过已暂停的优先级
+	var active [3]bool
 	for i := range 3 {
 		priority := q.priorities[i]
+		if q.priorityPaused(ctx, priority) {
+			continue
+		}
+		active[i] = true
 		streamKey := q.keyStream(priority)
 
 		// 尝试读取单个 stream（不阻塞）
@@ -144,10 +177,24 @@ func (q *Queue) PopReady(ctx context.Context, timeout int) (string, Priority, st
 
 	// 所有优先级都没有任务，进行阻塞等待
 	// 构建streams参数 - 使用预分配数组
-	streams := make([]string, 6) // 3 streams + 3 ">"
+	streams := make([]string, 0, 6) // 3 streams + 3 ">"
 	for i := range 3 {
-		streams[i] = q.keyStream(q.priorities[i])
-		streams[i+3] = ">"
+		if active[i] {
+			streams = append(streams, q.keyStream(q.priorities[i]))
+		}
+	}
+	n := len(streams)
+	if n == 0 {
+		// 所有优先级均已暂停，等待后重新检查
+		select {
+		case <-time.After(time.Duration(timeout) * time.Second):
+			return "", 0, "", nil
+		case <-ctx.Done():
+			return "", 0, "", ctx.Err()
+		}
+	}
+	for range n {
+		streams = append(streams, ">")
 	}
 
 	// 使用 XREADGROUP 阻塞等待新任务（Redis 原生阻塞）
@@ -229,9 +276,11 @@ func (q *Queue) MoveDelayedToReady(ctx context.Context, now int64, limit int) (i
 		return 0, err
 	}
 
-	// 第二个 Pipeline：批量移动任务
+	// 第二个 Pipeline：批量移动任务，移出延迟队列与投递在脚本中原子完成
+	// 消息字段与 AddReady 保持一致
 	pipe = q.client.Pipeline()
-	moved := int64(0)
+	addedAt := time.Now().Unix()
+	moves := make([]*redis.Cmd, 0, len(tasks))
 
 	for i, taskID := range tasks {
 		if i >= len(results) {
@@ -254,30 +303,20 @@ func (q *Queue) MoveDelayedToReady(ctx context.Context, now int64, limit int) (i
 			continue
 		}
 
-		// 添加到 Stream
-		pipe.XAdd(ctx, &redis.XAddArgs{
-			Stream: streamKey,
-			Values: map[string]any{
-				"task_id": taskID,
-			},
-		})
-
-		// 从延迟队列移除
-		pipe.ZRem(ctx, q.keyDelayed(), taskID)
-		moved++
+		moves = append(moves, pipe.Eval(ctx, moveTaskScript, []string{q.keyDelayed(), streamKey}, taskID, priority, addedAt))
 	}
 
-	cmders, err := pipe.Exec(ctx)
-	if err != nil && err != redis.Nil {
-		// 检查各 command 结果，统计实际成功数
-		actualMoved := int64(0)
-		for _, cmd := range cmders {
-			if cmd.Err() == nil {
-				actualMoved++
-			}
+	_, err = pipe.Exec(ctx)
+
+	// 统计实际投递数，已被其他实例移走的任务不计入
+	moved := int64(0)
+	for _, cmd := range moves {
+		if n, cmdErr := cmd.Int64(); cmdErr == nil && n == 1 {
+			moved++
 		}
-		// 每个任务对应 XAdd + ZRem 两条命令，实际移动数 = 成功的 XAdd 数
-		return actualMoved / 2, err
+	}
+	if err != nil && err != redis.Nil {
+		return moved, err
 	}
 
 	return moved, nil
@@ -328,6 +367,66 @@ func (q *Queue) RemoveReady(ctx context.Context, taskID string) error {
 	return nil
 }
 
+// Boost 将等待中的任务从 from 优先级提升到 to 优先级
+// 延迟队列中的任务只更新元数据；就绪队列中尚未投递的消息会被原子地移动到新优先级的 Stream。
+// 返回 false 表示任务已不在等待状态（已投递、执行或删除）
+func (q *Queue) Boost(ctx context.Context, taskID string, from, to Priority) (bool, error) {
+	oldStream := q.keyStream(from)
+	msgID, err := q.findUndelivered(ctx, oldStream, taskID)
+	if err != nil {
+		return false, err
+	}
+
+	taskKey := fmt.Sprintf("%s:task:%s", q.namespace, taskID)
+	result, err := q.client.Eval(ctx, boostTaskScript,
+		[]string{q.keyDelayed(), taskKey, oldStream, q.keyStream(to)},
+		taskID, int(to), q.keyConsumerGroup(), msgID, time.Now().Unix(),
+	).Result()
+	if err != nil {
+		return false, err
+	}
+	return result == int64(1), nil
+}
+
+// findUndelivered 在 Stream 中查找尚未投递给消费者组的任务消息，未找到返回空字符串
+// 只扫描 last-delivered-id 之后的消息，避免命中历史重试留下的已确认消息
+func (q *Queue) findUndelivered(ctx context.Context, streamKey, taskID string) (string, error) {
+	const batchSize = 100
+
+	start := "-"
+	groups, err := q.client.XInfoGroups(ctx, streamKey).Result()
+	if err != nil {
+		if err == redis.Nil || strings.Contains(err.Error(), "no such key") {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, g := range groups {
+		if g.Name == q.keyGroupCache && g.LastDeliveredID != "" && g.LastDeliveredID != "0-0" {
+			start = "(" + g.LastDeliveredID
+		}
+	}
+
+	for {
+		messages, err := q.client.XRangeN(ctx, streamKey, start, "+", batchSize).Result()
+		if err != nil {
+			if err == redis.Nil {
+				return "", nil
+			}
+			return "", err
+		}
+		for _, msg := range messages {
+			if id, ok := msg.Values["task_id"].(string); ok && id == taskID {
+				return msg.ID, nil
+			}
+		}
+		if len(messages) < batchSize {
+			return "", nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
 // AckMessage 确认消息已处理
 func (q *Queue) AckMessage(ctx context.Context, priority Priority, msgID string) error {
 	streamKey := q.keyStream(priority)
@@ -335,6 +434,49 @@ func (q *Queue) AckMessage(ctx context.Context, priority Priority, msgID string)
 	return q.client.XAck(ctx, streamKey, groupName, msgID).Err()
 }
 
+// AddParked 添加处于自定义状态的任务，score 为截止时间
+func (q *Queue) AddParked(ctx context.Context, taskID string, deadline float64) error {
+	return q.client.ZAdd(ctx, q.keyParked(), redis.Z{
+		Score:  deadline,
+		Member: taskID,
+	}).Err()
+}
+
+// RemoveParked 移除处于自定义状态的任务，返回是否移除成功；
+// 多个实例 (超时扫描、外部回调) 以此争夺任务的处理权
+func (q *Queue) RemoveParked(ctx context.Context, taskID string) (bool, error) {
+	n, err := q.client.ZRem(ctx, q.keyParked(), taskID).Result()
+	return n == 1, err
+}
+
+// DueParked 获取截止时间不晚于 now 的自定义状态任务
+func (q *Queue) DueParked(ctx context.Context, now int64, limit int) ([]string, error) {
+	return q.client.ZRangeByScore(ctx, q.keyParked(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now, 10),
+		Count: int64(limit),
+	}).Result()
+}
+
+// RemoveExpiring 移除任务的过期登记
+func (q *Queue) RemoveExpiring(ctx context.Context, taskID string) error {
+	return q.client.ZRem(ctx, q.keyExpiring(), taskID).Err()
+}
+
+// DueExpiring 获取过期时间不晚于 now 的任务
+func (q *Queue) DueExpiring(ctx context.Context, now int64, limit int) ([]string, error) {
+	return q.client.ZRangeByScore(ctx, q.keyExpiring(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now, 10),
+		Count: int64(limit),
+	}).Result()
+}
+
+// GetParkedCount 获取处于自定义状态的任务数
+func (q *Queue) GetParkedCount(ctx context.Context) (int64, error) {
+	return q.client.ZCard(ctx, q.keyParked()).Result()
+}
+
 // GetDelayedCount 获取延迟队列任务数
 func (q *Queue) GetDelayedCount(ctx context.Context) (int64, error) {
 	return q.client.ZCard(ctx, q.keyDelayed()).Result()
@@ -368,7 +510,9 @@ func (q *Queue) GetPendingCount(ctx context.Context, priority Priority) (int64,
 	return pending.Count, nil
 }
 
-// ClaimStaleMessages 接管超时的Pending消息
+// ClaimStaleMessages 接管超时的Pending消息并重新投递
+// 消费者崩溃后其已读取未确认的消息不会再被 XREADGROUP ">" 读到，接管后以新消息重新加入就绪队列并确认原消息；
+// 任务锁仍被持有（原Worker存活并在续期，如长耗时任务）时只接管不投递，由原Worker完成后确认
 func (q *Queue) ClaimStaleMessages(ctx context.Context, priority Priority, idleTime time.Duration) ([]string, error) {
 	streamKey := q.keyStream(priority)
 	groupName := q.keyConsumerGroup()
@@ -393,22 +537,45 @@ func (q *Queue) ClaimStaleMessages(ctx context.Context, priority Priority, idleT
 	var claimedTaskIDs []string
 	for _, msg := range pending {
 		// 超过空闲时间的消息才接管
-		if msg.Idle >= idleTime {
-			// 使用XCLAIM接管消息
-			claimed, err := q.client.XClaim(ctx, &redis.XClaimArgs{
-				Stream:   streamKey,
-				Group:    groupName,
-				Consumer: q.consumerName,
-				MinIdle:  idleTime,
-				Messages: []string{msg.ID},
-			}).Result()
-
-			if err == nil && len(claimed) > 0 {
-				taskID, ok := claimed[0].Values["task_id"].(string)
-				if ok {
-					claimedTaskIDs = append(claimedTaskIDs, taskID)
-				}
-			}
+		if msg.Idle < idleTime {
+			continue
+		}
+		// 使用XCLAIM接管消息，并发接管时只有一个实例成功
+		claimed, err := q.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   streamKey,
+			Group:    groupName,
+			Consumer: q.consumerName,
+			MinIdle:  idleTime,
+			Messages: []string{msg.ID},
+		}).Result()
+		if err != nil || len(claimed) == 0 {
+			continue
+		}
+		taskID, ok := claimed[0].Values["task_id"].(string)
+		if !ok {
+			continue
+		}
+
+		locked, err := q.lock.IsLocked(ctx, taskID)
+		if err != nil || locked {
+			continue
+		}
+
+		// 重新投递并确认原消息
+		_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: streamKey,
+				Values: map[string]any{
+					"task_id":  taskID,
+					"priority": int(priority),
+					"added_at": time.Now().Unix(),
+				},
+			})
+			pipe.XAck(ctx, streamKey, groupName, msg.ID)
+			return nil
+		})
+		if err == nil {
+			claimedTaskIDs = append(claimedTaskIDs, taskID)
 		}
 	}
 
@@ -418,6 +585,7 @@ func (q *Queue) ClaimStaleMessages(ctx context.Context, priority Priority, idleT
 // GetStats 获取队列统计信息
 func (q *Queue) GetStats(ctx context.Context) (*QueueStats, error) {
 	delayedCount, _ := q.GetDelayedCount(ctx)
+	parkedCount, _ := q.GetParkedCount(ctx)
 	highCount, _ := q.GetReadyCount(ctx, PriorityHigh)
 	normalCount, _ := q.GetReadyCount(ctx, PriorityNormal)
 	lowCount, _ := q.GetReadyCount(ctx, PriorityLow)
@@ -428,19 +596,27 @@ func (q *Queue) GetStats(ctx context.Context) (*QueueStats, error) {
 	lowPending, _ := q.GetPendingCount(ctx, PriorityLow)
 	runningCount := highPending + normalPending + lowPending
 
-	return &QueueStats{
+	stats := &QueueStats{
 		DelayedCount: delayedCount,
 		HighCount:    highCount,
 		NormalCount:  normalCount,
 		LowCount:     lowCount,
 		RunningCount: runningCount,
-	}, nil
+		ParkedCount:  parkedCount,
+	}
+	if paused, err := q.GetPaused(ctx); err == nil {
+		stats.PausedTypes = paused.Types
+		stats.PausedPriorities = paused.Priorities
+	}
+	return stats, nil
 }
 
 // Clear 清空所有队列
 func (q *Queue) Clear(ctx context.Context) error {
 	pipe := q.client.Pipeline()
 	pipe.Del(ctx, q.keyDelayed())
+	pipe.Del(ctx, q.keyParked())
+	pipe.Del(ctx, q.keyExpiring())
 	pipe.Del(ctx, q.keyStream(PriorityHigh))
 	pipe.Del(ctx, q.keyStream(PriorityNormal))
 	pipe.Del(ctx, q.keyStream(PriorityLow))
@@ -50,6 +50,9 @@ type Scheduler struct {
 	rateLimiter    rate.Limiter
 	circuitBreaker *CircuitBreaker
 
+	// 时钟
+	clock *clock
+
 	// 监控组件
 	metrics       *Metrics
 	healthChecker *HealthChecker
@@ -57,6 +60,8 @@ type Scheduler struct {
 
 	// Worker管理
 	workers []*Worker
+	// 有任务进入Worker缓冲时通知空闲Worker尝试窃取，见 Worker.steal
+	stealSignal chan struct{}
 
 	// 运行状态
 	running atomic.Bool
@@ -85,6 +90,9 @@ func New(opts ...Option) (*Scheduler, error) {
 	for _, opt := range opts {
 		opt(options)
 	}
+	if err := validateStates(options.States); err != nil {
+		return nil, err
+	}
 
 	// 创建或使用Redis客户端
 	var client *redis.Client
@@ -137,6 +145,7 @@ func New(opts ...Option) (*Scheduler, error) {
 		circuitBreaker: NewCircuitBreaker(options.CircuitBreaker.Enabled, options.CircuitBreaker.MaxFailures, options.CircuitBreaker.Timeout),
 		metrics:        NewMetrics(options.Namespace, options.Metrics.Enabled, options.Metrics.Registry),
 		logger:         logger,
+		stealSignal:    make(chan struct{}, 1),
 		mapPool: &sync.Pool{
 			New: func() any {
 				return make(map[string]any, mapPoolInitialCap)
@@ -144,6 +153,9 @@ func New(opts ...Option) (*Scheduler, error) {
 		},
 	}
 
+	// 创建时钟
+	s.clock = newClock(client, options.Clock, s.metrics, logger)
+
 	// 创建健康检查器
 	s.healthChecker = NewHealthChecker(s)
 
@@ -201,6 +213,13 @@ func (s *Scheduler) Start(ctx context.Context) error {
 
 	s.logger.Info().Msg("scheduler starting")
 
+	// 启动前先测量一次时钟偏差，失败时回退为本地时间
+	if s.opts.Clock.UseRedisTime {
+		if err := s.clock.sync(ctx); err == nil {
+			s.logger.Info().Dur("drift", s.clock.Drift()).Msg("clock synced with redis")
+		}
+	}
+
 	// 启动Prometheus指标服务
 	if s.opts.Metrics.Enabled {
 		if err := s.startMetricsServer(); err != nil {
@@ -328,7 +347,7 @@ func (s *Scheduler) scheduleLoop(ctx context.Context) {
 
 // scan 扫描延迟队列，移动到期任务到就绪队列
 func (s *Scheduler) scan(ctx context.Context) {
-	now := time.Now().Unix()
+	now := s.now(ctx).Unix()
 
 	// 移动到期任务
 	moved, err := s.queue.MoveDelayedToReady(ctx, now, s.opts.BatchSize)
@@ -347,6 +366,14 @@ func (s *Scheduler) scan(ctx context.Context) {
 		}
 	}
 
+	// 处理到期的自定义状态
+	if len(s.opts.States) > 0 {
+		s.expireParkedTasks(ctx, now)
+	}
+
+	// 丢弃超过过期时间仍未开始执行的任务
+	s.expireTasks(ctx, now)
+
 	// 接管超时的Pending消息（故障恢复）
 	s.reclaimPendingMessages(ctx)
 
@@ -357,6 +384,7 @@ func (s *Scheduler) scan(ctx context.Context) {
 }
 
 // reclaimPendingMessages 接管超时的Pending消息（并发处理不同优先级）
+// 空闲时间由 Redis 计算 (XPENDING)，不受实例本地时钟影响
 func (s *Scheduler) reclaimPendingMessages(ctx context.Context) {
 	// 设置超时时间：任务超时的倍数（确保任务已经处理失败或Worker崩溃）
 	idleTime := s.opts.LockTimeout * idleTimeMultiplier
@@ -443,9 +471,25 @@ func SubmitWithSerializer[T any](s *Scheduler, ctx context.Context, taskType str
 		opt(task)
 	}
 
+	// 执行提交拦截器
+	if err := s.interceptSubmit(ctx, task); err != nil {
+		return "", err
+	}
+
 	return s.submitTask(ctx, task)
 }
 
+// resolveScheduleAt 按调度器时钟确定计划执行时间：
+// WithDelay 的延迟在提交时才换算为绝对时间，未指定时默认立即执行
+func (s *Scheduler) resolveScheduleAt(ctx context.Context, task *Task) {
+	if task.delay > 0 {
+		task.ScheduleAt = s.now(ctx).Add(task.delay)
+		task.delay = 0
+	} else if task.ScheduleAt.IsZero() {
+		task.ScheduleAt = s.now(ctx)
+	}
+}
+
 // submitTask 内部提交任务方法
 func (s *Scheduler) submitTask(ctx context.Context, task *Task) (string, error) {
 	// 验证任务
@@ -462,7 +506,7 @@ func (s *Scheduler) submitTask(ctx context.Context, task *Task) (string, error)
 
 		// 如果没有设置调度时间，计算首次执行时间
 		if task.ScheduleAt.IsZero() {
-			nextTime, err := s.cronParser.Next(task.Cron, time.Now())
+			nextTime, err := s.cronParser.Next(task.Cron, s.now(ctx))
 			if err != nil {
 				return "", fmt.Errorf("failed to calculate next execution time: %w", err)
 			}
@@ -474,12 +518,14 @@ func (s *Scheduler) submitTask(ctx context.Context, task *Task) (string, error)
 	if task.ID == "" {
 		task.ID = uuid.New().String()
 	}
-
-	// 设置默认计划时间
-	if task.ScheduleAt.IsZero() {
-		task.ScheduleAt = time.Now()
+	// Cron 系列以首个实例的ID标识
+	if task.Cron != "" && task.CronID == "" {
+		task.CronID = task.ID
 	}
 
+	// 设置计划时间
+	s.resolveScheduleAt(ctx, task)
+
 	// 限流检查
 	if s.opts.RateLimit.Enabled {
 		result, err := s.rateLimiter.Allow(ctx, s.opts.Namespace+":ratelimit", 1)
@@ -512,7 +558,7 @@ func (s *Scheduler) submitTask(ctx context.Context, task *Task) (string, error)
 			if existingTaskID == "" {
 				existingTaskID = "unknown"
 			}
-			s.logger.Debug().Str("task_id", existingTaskID).Str("dedup_key", task.DeduplicationKey).Msg("task duplicate")
+			s.logger.Debug().Str("task_id", existingTaskID).Stringer("dedup_key", log.Secret(task.DeduplicationKey)).Msg("task duplicate")
 			return existingTaskID, ErrTaskDuplicate
 		}
 	}
@@ -535,6 +581,11 @@ func (s *Scheduler) submitTask(ctx context.Context, task *Task) (string, error)
 	delayedKey := s.opts.Namespace + ":delayed"
 	pipe.ZAdd(ctx, delayedKey, redis.Z{Score: score, Member: task.ID})
 
+	// 登记过期时间
+	if task.Expiry > 0 {
+		pipe.ZAdd(ctx, s.opts.Namespace+":expiring", redis.Z{Score: expireAtScore(task), Member: task.ID})
+	}
+
 	// 执行Pipeline
 	if _, err := pipe.Exec(ctx); err != nil {
 		return "", fmt.Errorf("failed to submit task: %w", err)
@@ -598,6 +649,12 @@ func BatchSubmitWithSerializer[T any](s *Scheduler, ctx context.Context, taskTyp
 			opt(task)
 		}
 
+		// 执行提交拦截器
+		if err := s.interceptSubmit(ctx, task); err != nil {
+			s.logger.Warn().Err(err).Str("task_type", taskType).Msg("task rejected by submit interceptor in batch")
+			continue
+		}
+
 		// 验证任务
 		if err := task.Validate(); err != nil {
 			s.logger.Error().Err(err).Str("task_type", taskType).Msg("invalid task in batch")
@@ -609,10 +666,8 @@ func BatchSubmitWithSerializer[T any](s *Scheduler, ctx context.Context, taskTyp
 			task.ID = uuid.New().String()
 		}
 
-		// 设置默认计划时间
-		if task.ScheduleAt.IsZero() {
-			task.ScheduleAt = time.Now()
-		}
+		// 设置计划时间
+		s.resolveScheduleAt(ctx, task)
 
 		tasks = append(tasks, task)
 	}
@@ -629,6 +684,7 @@ func BatchSubmitWithSerializer[T any](s *Scheduler, ctx context.Context, taskTyp
 	// 使用 Pipeline 批量提交
 	pipe := s.client.Pipeline()
 	delayedKey := s.opts.Namespace + ":delayed"
+	expiringKey := s.opts.Namespace + ":expiring"
 	taskIDs := make([]string, 0, len(tasks))
 
 	for _, task := range tasks {
@@ -644,7 +700,7 @@ func BatchSubmitWithSerializer[T any](s *Scheduler, ctx context.Context, taskTyp
 				continue
 			}
 			if !set {
-				s.logger.Debug().Str("task_id", task.ID).Str("dedup_key", task.DeduplicationKey).Msg("task duplicate in batch")
+				s.logger.Debug().Str("task_id", task.ID).Stringer("dedup_key", log.Secret(task.DeduplicationKey)).Msg("task duplicate in batch")
 				continue
 			}
 		}
@@ -663,6 +719,11 @@ func BatchSubmitWithSerializer[T any](s *Scheduler, ctx context.Context, taskTyp
 		score := float64(task.ScheduleAt.Unix())
 		pipe.ZAdd(ctx, delayedKey, redis.Z{Score: score, Member: task.ID})
 
+		// 登记过期时间
+		if task.Expiry > 0 {
+			pipe.ZAdd(ctx, expiringKey, redis.Z{Score: expireAtScore(task), Member: task.ID})
+		}
+
 		taskIDs = append(taskIDs, task.ID)
 
 		// 记录指标
@@ -688,10 +749,18 @@ func (s *Scheduler) CancelTask(ctx context.Context, taskID string) error {
 		return err
 	}
 
-	// 只能取消Pending和Ready状态的任务
-	if taskInfo.Status != StatusPending && taskInfo.Status != StatusReady {
+	// 只能取消Pending、Ready与自定义状态的任务
+	parked := s.isCustomState(taskInfo.Status)
+	if taskInfo.Status != StatusPending && taskInfo.Status != StatusReady && !parked {
 		return fmt.Errorf("cannot cancel task in status: %s", taskInfo.Status)
 	}
+	if parked {
+		if claimed, err := s.queue.RemoveParked(ctx, taskID); err != nil {
+			return fmt.Errorf("failed to remove task from parked queue: %w", err)
+		} else if !claimed {
+			return ErrTaskNotParked
+		}
+	}
 
 	// 从队列移除
 	if err := s.queue.RemoveDelayed(ctx, taskID); err != nil {
@@ -700,13 +769,16 @@ func (s *Scheduler) CancelTask(ctx context.Context, taskID string) error {
 	if err := s.queue.RemoveReady(ctx, taskID); err != nil {
 		s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to remove task from ready queue")
 	}
+	if err := s.queue.RemoveExpiring(ctx, taskID); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to remove task from expiring index")
+	}
 
 	// 更新状态
 	taskInfo.Status = StatusCancelled
 	now := time.Now()
 	taskInfo.FinishTime = &now
 
-	if err := s.saveTaskInfo(ctx, taskInfo); err != nil {
+	if err := s.retainTaskInfo(ctx, taskInfo, s.opts.Retention.Terminal); err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
 
@@ -714,6 +786,42 @@ func (s *Scheduler) CancelTask(ctx context.Context, taskID string) error {
 	return nil
 }
 
+// BoostTask 将等待中（延迟或就绪但尚未被 Worker 领取）的任务提升到更高优先级
+// 元数据与队列位置在同一个 Lua 脚本中更新，无需取消后重新提交
+func (s *Scheduler) BoostTask(ctx context.Context, taskID string, newPriority Priority) error {
+	if newPriority < PriorityLow || newPriority > PriorityHigh {
+		return ErrInvalidPriority
+	}
+
+	taskInfo, err := s.GetTaskInfo(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	// 只能提升Pending和Ready状态的任务
+	if taskInfo.Status != StatusPending && taskInfo.Status != StatusReady {
+		return fmt.Errorf("cannot boost task in status: %s", taskInfo.Status)
+	}
+	if newPriority <= taskInfo.Priority {
+		return fmt.Errorf("%w: new priority %d must be higher than current %d", ErrInvalidPriority, newPriority, taskInfo.Priority)
+	}
+
+	boosted, err := s.queue.Boost(ctx, taskID, taskInfo.Priority, newPriority)
+	if err != nil {
+		return fmt.Errorf("failed to boost task: %w", err)
+	}
+	if !boosted {
+		return ErrTaskNotQueued
+	}
+
+	s.logger.Info().
+		Str("task_id", taskID).
+		Int("from", int(taskInfo.Priority)).
+		Int("to", int(newPriority)).
+		Msg("task boosted")
+	return nil
+}
+
 // buildTaskKey 构建任务key
 func (s *Scheduler) buildTaskKey(taskID string) string {
 	return s.opts.Namespace + ":task:" + taskID
@@ -738,6 +846,15 @@ func (s *Scheduler) GetTaskInfo(ctx context.Context, taskID string) (*TaskInfo,
 		return nil, fmt.Errorf("failed to parse task info: %w", err)
 	}
 
+	// 附加最近一次执行捕获的输出
+	if s.opts.TaskLog.Enabled {
+		output, err := s.GetTaskOutput(ctx, taskID)
+		if err != nil && err != ErrTaskNotFound {
+			return nil, err
+		}
+		taskInfo.Output = output
+	}
+
 	return taskInfo, nil
 }
 
@@ -760,6 +877,26 @@ func (s *Scheduler) saveTaskInfo(ctx context.Context, taskInfo *TaskInfo) error
 	return nil
 }
 
+// retainTaskInfo 保存终态任务信息并在 ttl 后过期；ttl<=0 时永久保留
+func (s *Scheduler) retainTaskInfo(ctx context.Context, taskInfo *TaskInfo, ttl time.Duration) error {
+	taskKey := s.buildTaskKey(taskInfo.ID)
+
+	m := s.getMapFromPool()
+	defer s.returnMapToPool(m)
+	s.taskInfoToMap(taskInfo, m)
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, taskKey, m)
+	if ttl > 0 {
+		pipe.Expire(ctx, taskKey, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save task info: %w", err)
+	}
+
+	return nil
+}
+
 // getMapFromPool 从对象池获取map
 func (s *Scheduler) getMapFromPool() map[string]any {
 	m := s.mapPool.Get().(map[string]any)
@@ -789,8 +926,12 @@ func (s *Scheduler) taskInfoToMap(t *TaskInfo, m map[string]any) {
 	m["payload"] = string(t.Payload)
 	m["schedule_at"] = t.ScheduleAt.Unix()
 	m["cron"] = t.Cron
+	m["cron_id"] = t.CronID
+	m["cron_overlap"] = string(t.CronOverlap)
+	m["cron_max_running"] = t.CronMaxRunning
 	m["max_retry"] = t.MaxRetry
 	m["timeout"] = t.Timeout.Seconds()
+	m["expiry"] = t.Expiry.Seconds()
 	m["deduplication_key"] = t.DeduplicationKey
 	m["deduplication_ttl"] = t.DeduplicationTTL.Seconds()
 	m["status"] = string(t.Status)
@@ -816,6 +957,10 @@ func (s *Scheduler) taskInfoToMap(t *TaskInfo, m map[string]any) {
 	if t.ExecutionTime != nil {
 		m["execution_time"] = t.ExecutionTime.Seconds()
 	}
+	// 自定义状态字段总是写入，离开自定义状态时覆盖旧值
+	m["state"] = string(t.State)
+	m["state_deadline"] = stateDeadlineUnix(t.StateDeadline)
+	m["state_timed_out"] = t.StateTimedOut
 }
 
 // deleteTaskInfo 删除任务信息
@@ -831,7 +976,7 @@ func (s *Scheduler) deleteTaskInfo(ctx context.Context, taskID string) error {
 
 // scheduleNextCron 调度Cron任务的下次执行
 func (s *Scheduler) scheduleNextCron(ctx context.Context, taskInfo *TaskInfo) {
-	nextTime, err := s.cronParser.Next(taskInfo.Cron, time.Now())
+	nextTime, err := s.cronParser.Next(taskInfo.Cron, s.now(ctx))
 	if err != nil {
 		s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to calculate next cron time")
 		return
@@ -839,16 +984,20 @@ func (s *Scheduler) scheduleNextCron(ctx context.Context, taskInfo *TaskInfo) {
 
 	// 创建新的任务实例
 	newTask := &Task{
-		ID:         uuid.New().String(),
-		Type:       taskInfo.Type,
-		Priority:   taskInfo.Priority,
-		Payload:    taskInfo.Payload,
-		ScheduleAt: nextTime,
-		Cron:       taskInfo.Cron,
-		MaxRetry:   taskInfo.MaxRetry,
-		Timeout:    taskInfo.Timeout,
-		Tags:       taskInfo.Tags,
-		Context:    taskInfo.Context,
+		ID:             uuid.New().String(),
+		Type:           taskInfo.Type,
+		Priority:       taskInfo.Priority,
+		Payload:        taskInfo.Payload,
+		ScheduleAt:     nextTime,
+		Cron:           taskInfo.Cron,
+		CronID:         taskInfo.CronID,
+		CronOverlap:    taskInfo.CronOverlap,
+		CronMaxRunning: taskInfo.CronMaxRunning,
+		MaxRetry:       taskInfo.MaxRetry,
+		Timeout:        taskInfo.Timeout,
+		Expiry:         taskInfo.Expiry,
+		Tags:           taskInfo.Tags,
+		Context:        taskInfo.Context,
 	}
 
 	if _, err := s.submitTask(ctx, newTask); err != nil {
@@ -904,6 +1053,17 @@ func (s *Scheduler) startMetricsServer() error {
 	return nil
 }
 
+// signalSteal 通知一个空闲Worker尝试窃取任务 (非阻塞，通知已挂起时合并)
+func (s *Scheduler) signalSteal() {
+	if !s.opts.Worker.WorkStealing {
+		return
+	}
+	select {
+	case s.stealSignal <- struct{}{}:
+	default:
+	}
+}
+
 // getActiveWorkerCount 获取活跃 worker 数量（基于 SCAN，利用 Redis TTL 自动过期）
 func (s *Scheduler) getActiveWorkerCount(ctx context.Context) (int, error) {
 	pattern := fmt.Sprintf("%s:worker:*", s.opts.Namespace)
@@ -1,17 +1,22 @@
 package scheduler
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/redis/go-redis/v9"
+
+	"github.com/kochabx/kit/log"
 )
 
 func TestScheduler_ProduceConsumeFlow(t *testing.T) {
@@ -652,6 +657,108 @@ func TestScheduler_CancelTask(t *testing.T) {
 	}
 }
 
+// ─── Terminal Retention ────────────────────────────────────
+
+func TestScheduler_TerminalRetention(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb, WithRetention(time.Hour, time.Minute))
+
+	if err := SchedulerRegister[testPayloadMsg](s, "retention.test", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		return nil
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	// 取消的任务按 Terminal 过期
+	cancelled, err := Submit[testPayloadMsg](s, ctx, "retention.test", testPayloadMsg{Value: "c"}, WithDelay(10*time.Second))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if err := s.CancelTask(ctx, cancelled); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	if ttl := rdb.TTL(ctx, s.buildTaskKey(cancelled)).Val(); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("expected cancelled task TTL ~1h, got %v", ttl)
+	}
+
+	// 成功的任务按 Success 保留，可事后查询
+	succeeded, err := Submit[testPayloadMsg](s, ctx, "retention.test", testPayloadMsg{Value: "s"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := s.GetTaskInfo(ctx, succeeded)
+		if err == nil && info.Status == StatusSuccess {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for success, info=%+v err=%v", info, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if ttl := rdb.TTL(ctx, s.buildTaskKey(succeeded)).Val(); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected succeeded task TTL ~1m, got %v", ttl)
+	}
+}
+
+// ─── Plan ──────────────────────────────────────────────────
+
+func TestScheduler_Plan(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb)
+	ctx := context.Background()
+
+	// 调度器不启动，任务只停留在延迟队列中
+	base := time.Now().Truncate(time.Hour).Add(24 * time.Hour)
+	oneOff, err := Submit[testPayloadMsg](s, ctx, "plan.once", testPayloadMsg{Value: "o"}, WithScheduleAt(base.Add(90*time.Minute)))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := Submit[testPayloadMsg](s, ctx, "plan.later", testPayloadMsg{Value: "l"}, WithScheduleAt(base.Add(48*time.Hour))); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	// 当前实例早于窗口，窗口内的执行全部来自推演
+	hourly, err := Submit[testPayloadMsg](s, ctx, "plan.cron", testPayloadMsg{Value: "c"}, WithCron("0 * * * *"), WithScheduleAt(base.Add(-time.Hour)))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	runs, err := s.Plan(ctx, base, base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	var got []string
+	for _, r := range runs {
+		got = append(got, fmt.Sprintf("%s@%s", r.Type, r.RunAt.Sub(base)))
+		if r.Type == "plan.cron" && (!r.Projected || r.TaskID != hourly) {
+			t.Errorf("cron run should be projected from %s: %+v", hourly, r)
+		}
+		if r.Type == "plan.once" && (r.Projected || r.TaskID != oneOff) {
+			t.Errorf("unexpected one-off run: %+v", r)
+		}
+	}
+	want := []string{"plan.cron@0s", "plan.cron@1h0m0s", "plan.once@1h30m0s", "plan.cron@2h0m0s", "plan.cron@3h0m0s"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("plan = %v, want %v", got, want)
+	}
+
+	if _, err := s.Plan(ctx, base, base.Add(-time.Second)); err == nil {
+		t.Fatal("expected error for inverted window")
+	}
+}
+
 // ─── Priority Order ────────────────────────────────────────
 
 func TestScheduler_PriorityOrder(t *testing.T) {
@@ -745,3 +852,1167 @@ func TestScheduler_ConcurrentDedup(t *testing.T) {
 		t.Fatalf("expected %d total, got success=%d dupes=%d", n, successes.Load(), dupes.Load())
 	}
 }
+
+// ─── Boost ─────────────────────────────────────────────────
+
+func TestScheduler_BoostTask(t *testing.T) {
+	rdb := testRedisClient(t)
+	// 不启动 worker，手工驱动 scan 与 pop
+	s, _ := newTestScheduler(t, rdb)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	q, ok := s.queue.(*Queue)
+	if !ok {
+		t.Skip("queue is not *Queue")
+	}
+	q.SetConsumer("test-consumer")
+
+	submit := func(delay time.Duration) string {
+		t.Helper()
+		id, err := Submit[testPayloadMsg](s, ctx, "boost.test", testPayloadMsg{Value: "x"},
+			WithDelay(delay),
+			WithPriority(PriorityLow),
+			WithTaskTimeout(2*time.Second),
+			WithTaskMaxRetry(0),
+		)
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return id
+	}
+
+	// 延迟队列中的任务：只更新元数据
+	delayedID := submit(time.Hour)
+	if err := s.BoostTask(ctx, delayedID, PriorityHigh); err != nil {
+		t.Fatalf("BoostTask(delayed): %v", err)
+	}
+	info, err := s.GetTaskInfo(ctx, delayedID)
+	if err != nil {
+		t.Fatalf("GetTaskInfo: %v", err)
+	}
+	if info.Priority != PriorityHigh {
+		t.Fatalf("expected priority %d, got %d", PriorityHigh, info.Priority)
+	}
+
+	// 不能降级
+	if err := s.BoostTask(ctx, delayedID, PriorityLow); !errors.Is(err, ErrInvalidPriority) {
+		t.Fatalf("expected ErrInvalidPriority, got %v", err)
+	}
+
+	// 就绪队列中的任务：从 low stream 移动到 high stream
+	readyID := submit(0)
+	if _, err := q.MoveDelayedToReady(ctx, time.Now().Unix(), 10); err != nil {
+		t.Fatalf("MoveDelayedToReady: %v", err)
+	}
+	if err := q.AddReady(ctx, "normal-1", PriorityNormal); err != nil {
+		t.Fatalf("AddReady: %v", err)
+	}
+	if err := s.BoostTask(ctx, readyID, PriorityHigh); err != nil {
+		t.Fatalf("BoostTask(ready): %v", err)
+	}
+
+	taskID, priority, _, err := q.PopReady(ctx, 1)
+	if err != nil {
+		t.Fatalf("PopReady: %v", err)
+	}
+	if taskID != readyID || priority != PriorityHigh {
+		t.Fatalf("expected %s from high stream, got %s (priority %d)", readyID, taskID, priority)
+	}
+	if n, _ := q.GetReadyCount(ctx, PriorityLow); n != 0 {
+		t.Fatalf("expected low stream to be empty, got %d", n)
+	}
+
+	// 已被领取的任务无法再提升
+	if err := s.BoostTask(ctx, readyID, PriorityHigh); err == nil {
+		t.Fatal("expected error boosting a delivered task")
+	}
+}
+
+// ─── Server adapter ────────────────────────────────────────
+
+func TestScheduler_ServerLifecycle(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb)
+	srv := s.Server()
+
+	// 启动上下文在 Start 返回后即取消，调度器不应随之停止
+	startCtx, cancelStart := context.WithCancel(context.Background())
+	if err := srv.Start(startCtx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	cancelStart()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := srv.HealthCheck(ctx)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !s.running.Load() {
+		t.Fatal("scheduler stopped after start context was cancelled")
+	}
+
+	if err := srv.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if s.running.Load() {
+		t.Fatal("scheduler still running after Stop")
+	}
+}
+
+// ─── Task Output Capture ───────────────────────────────────
+
+func TestScheduler_TaskOutputCapture(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb, WithTaskLog(1024, time.Minute))
+
+	var attempts atomic.Int64
+	if err := SchedulerRegister[testPayloadMsg](s, "output.test", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		n := attempts.Add(1)
+		TaskLogger(ctx).Info().Int64("n", n).Msg("processing " + p.Value)
+		// 超出上限的日志被丢弃
+		TaskLogger(ctx).Info().Msg(strings.Repeat("x", 2048))
+		return errors.New("boom")
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	taskID, err := Submit[testPayloadMsg](s, ctx, "output.test", testPayloadMsg{Value: "order-7"},
+		WithTaskMaxRetry(2),
+		WithTaskTimeout(2*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	// 第一次失败后任务等待重试，GetTaskInfo 附带输出
+	deadline := time.Now().Add(5 * time.Second)
+	var info *TaskInfo
+	for {
+		info, err = s.GetTaskInfo(ctx, taskID)
+		if err == nil && info.RetryCount == 1 && info.Output != "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for first attempt output, info=%+v err=%v", info, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	for _, want := range []string{`"message":"processing order-7"`, `"attempt":1`, `"error":"boom"`, truncatedMarker} {
+		if !strings.Contains(info.Output, want) {
+			t.Fatalf("output missing %q:\n%s", want, info.Output)
+		}
+	}
+
+	// 进入 DLQ 后输出仍可查询，且为最后一次执行的输出
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		count, _ := s.dlq.Count(ctx)
+		if count > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for DLQ, attempts=%d", attempts.Load())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	output, err := s.GetTaskOutput(ctx, taskID)
+	if err != nil {
+		t.Fatalf("GetTaskOutput: %v", err)
+	}
+	if !strings.Contains(output, `"attempt":2`) || strings.Contains(output, `"attempt":1`) {
+		t.Fatalf("expected output of the last attempt, got:\n%s", output)
+	}
+
+	if _, err := s.GetTaskOutput(ctx, "missing"); err != ErrTaskNotFound {
+		t.Fatalf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestWorker_TaskLoggerTee(t *testing.T) {
+	var base bytes.Buffer
+	w := &Worker{logger: log.NewWriter(&base)}
+	info := &TaskInfo{Task: Task{ID: "task-1"}, RetryCount: 1}
+
+	// 启用捕获时，日志同时写入调度器日志与任务输出
+	output := newTaskOutput(1024)
+	w.newTaskLogger(info, output).Info().Msg("captured line")
+	for name, got := range map[string]string{"base": base.String(), "output": output.String()} {
+		for _, want := range []string{`"message":"captured line"`, `"task_id":"task-1"`, `"attempt":2`} {
+			if !strings.Contains(got, want) {
+				t.Fatalf("%s logger missing %q:\n%s", name, want, got)
+			}
+		}
+	}
+
+	// 未启用捕获时只写入调度器日志
+	base.Reset()
+	w.newTaskLogger(info, nil).Info().Msg("plain line")
+	if got := base.String(); !strings.Contains(got, `"message":"plain line"`) || !strings.Contains(got, `"task_id":"task-1"`) {
+		t.Fatalf("base logger missing plain line:\n%s", got)
+	}
+}
+
+// ─── Clock ─────────────────────────────────────────────────
+func TestScheduler_RedisClock(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb, WithRedisClock(time.Hour))
+	ctx := context.Background()
+
+	if err := s.clock.sync(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if d := s.ClockDrift(); d > time.Second || d < -time.Second {
+		t.Fatalf("unexpected drift against local redis: %v", d)
+	}
+
+	// 模拟本地时钟比 Redis 慢 1 小时
+	s.clock.offset.Store(int64(time.Hour))
+
+	// 延迟以 Redis 时间为基准换算
+	taskID, err := Submit[testPayloadMsg](s, ctx, "clock.test", testPayloadMsg{}, WithDelay(time.Minute))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	info, err := s.GetTaskInfo(ctx, taskID)
+	if err != nil {
+		t.Fatalf("GetTaskInfo: %v", err)
+	}
+	want := time.Now().Add(time.Hour + time.Minute)
+	if diff := info.ScheduleAt.Sub(want); diff > 2*time.Second || diff < -2*time.Second {
+		t.Fatalf("schedule_at = %v, want ~%v", info.ScheduleAt, want)
+	}
+
+	// 按本地时间尚未到期、按 Redis 时间已到期的任务会被扫描到就绪队列
+	if _, err := Submit[testPayloadMsg](s, ctx, "clock.test", testPayloadMsg{}, WithScheduleAt(time.Now().Add(30*time.Minute))); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	s.scan(ctx)
+	stats, err := s.GetQueueStats(ctx)
+	if err != nil {
+		t.Fatalf("GetQueueStats: %v", err)
+	}
+	if stats.DelayedCount != 1 {
+		t.Fatalf("expected only the delayed-by-1h task to remain, delayed=%d", stats.DelayedCount)
+	}
+}
+
+func TestWorker_Steal(t *testing.T) {
+	// 不启动 Worker，无需 Redis
+	s, err := New(
+		WithRedisClient(redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})),
+		WithWorkerCount(3),
+		WithWorkerConcurrency(1),
+		WithPrefetch(4),
+		WithMetrics(true),
+		WithHealth(false),
+	)
+	if err != nil {
+		t.Fatalf("New scheduler: %v", err)
+	}
+	for _, w := range s.workers {
+		t.Cleanup(w.pool.Release)
+	}
+	busy, backlogged, idle := s.workers[0], s.workers[1], s.workers[2]
+	if cap(idle.taskBuffer) != 4 {
+		t.Fatalf("prefetch size = %d, want 4", cap(idle.taskBuffer))
+	}
+
+	busy.taskBuffer <- &taskItem{taskID: "b1"}
+	for _, id := range []string{"a1", "a2", "a3"} {
+		backlogged.taskBuffer <- &taskItem{taskID: id}
+	}
+
+	// 从积压最多的 Worker 按预取顺序窃取
+	for _, want := range []string{"a1", "a2"} {
+		item, ok := idle.nextItem()
+		if !ok || item.taskID != want {
+			t.Fatalf("nextItem = %v, %v; want %s", item, ok, want)
+		}
+	}
+	if got := testutil.ToFloat64(s.metrics.TaskStolen); got != 2 {
+		t.Errorf("task_stolen_total = %v, want 2", got)
+	}
+
+	// 协程池已满时不窃取
+	idle.inflight.Store(1)
+	if item := idle.steal(); item != nil {
+		t.Errorf("steal with full pool = %v, want nil", item)
+	}
+	idle.inflight.Store(0)
+
+	// 本地缓冲优先于窃取
+	idle.taskBuffer <- &taskItem{taskID: "own"}
+	if item, _ := idle.nextItem(); item.taskID != "own" {
+		t.Errorf("nextItem = %s, want own", item.taskID)
+	}
+
+	// 缓冲均为空时等待窃取通知
+	<-busy.taskBuffer
+	<-backlogged.taskBuffer
+	got := make(chan string, 1)
+	go func() {
+		item, _ := idle.nextItem()
+		got <- item.taskID
+	}()
+	time.Sleep(20 * time.Millisecond)
+	busy.taskBuffer <- &taskItem{taskID: "late"}
+	s.signalSteal()
+	select {
+	case id := <-got:
+		if id != "late" {
+			t.Errorf("nextItem = %s, want late", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("idle worker did not steal after signal")
+	}
+}
+
+// ─── Cost accounting ───────────────────────────────────────
+
+func TestScheduler_CostAccounting(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb, WithCostAccounting(time.Hour))
+
+	var attempts atomic.Int64
+	if err := SchedulerRegister[testPayloadMsg](s, "cost.test", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		AddCost(ctx, 1.5)
+		AddCost(ctx, 1)
+		if p.Value == "flaky" && attempts.Add(1) == 1 {
+			return errors.New("first attempt fails")
+		}
+		return nil
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	payloadSize := func(v string) int64 {
+		data, _ := DefaultSerializer.Marshal(testPayloadMsg{Value: v})
+		return int64(len(data))
+	}
+	for _, sub := range []struct{ value, tenant string }{{"ok", "acme"}, {"flaky", "acme"}, {"ok", "globex"}} {
+		if _, err := Submit[testPayloadMsg](s, ctx, "cost.test", testPayloadMsg{Value: sub.value}, WithTenant(sub.tenant)); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+
+	// 4 次执行：acme 3 次 (含 1 次失败与 1 次重试)，globex 1 次
+	var records []CostRecord
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var err error
+		records, err = s.Costs(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Costs: %v", err)
+		}
+		total := SumCosts(records, func(CostRecord) string { return "" })[""]
+		if total.Executions == 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for cost records, got %+v", records)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	byTenant := SumCosts(records, func(r CostRecord) string { return r.Tenant })
+	acme, globex := byTenant["acme"], byTenant["globex"]
+	if acme.Type != "cost.test" || acme.Executions != 3 || acme.Failures != 1 || acme.Retries != 1 || acme.Units != 7.5 {
+		t.Errorf("unexpected acme costs: %+v", acme)
+	}
+	if want := 2*payloadSize("flaky") + payloadSize("ok"); acme.PayloadBytes != want {
+		t.Errorf("acme payload bytes = %d, want %d", acme.PayloadBytes, want)
+	}
+	if globex.Executions != 1 || globex.Failures != 0 || globex.Units != 2.5 || globex.Duration <= 0 {
+		t.Errorf("unexpected globex costs: %+v", globex)
+	}
+}
+
+func TestCostHelpers(t *testing.T) {
+	// handler 之外调用 AddCost 被忽略
+	AddCost(context.Background(), 1)
+
+	meter := &costMeter{}
+	ctx := context.WithValue(context.Background(), costMeterKey{}, meter)
+	AddCost(ctx, 2)
+	AddCost(ctx, 0.5)
+	if meter.units != 2.5 {
+		t.Errorf("units = %v, want 2.5", meter.units)
+	}
+
+	day := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	records := []CostRecord{
+		{Day: day, Type: "report", Tenant: "acme", Executions: 2, Duration: 1500 * time.Millisecond, PayloadBytes: 10, Units: 1},
+		{Day: day, Type: "email", Tenant: "acme", Executions: 1, Failures: 1, Duration: time.Second, PayloadBytes: 5},
+		{Day: day.AddDate(0, 0, 1), Type: "report", Tenant: "globex", Executions: 1, Retries: 1, Duration: time.Second},
+	}
+	byTenant := SumCosts(records, func(r CostRecord) string { return r.Tenant })
+	if got := byTenant["acme"]; got.Executions != 3 || got.Failures != 1 || got.Duration != 2500*time.Millisecond || got.PayloadBytes != 15 || got.Units != 1 {
+		t.Errorf("unexpected acme sum: %+v", got)
+	}
+	if got := byTenant["globex"]; got.Retries != 1 {
+		t.Errorf("unexpected globex sum: %+v", got)
+	}
+
+	var buf strings.Builder
+	if err := WriteCostsCSV(&buf, records[:1]); err != nil {
+		t.Fatal(err)
+	}
+	want := "day,type,tenant,executions,failures,retries,duration_seconds,payload_bytes,units\n" +
+		"2026-10-15,report,acme,2,0,0,1.500000,10,1\n"
+	if buf.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// ─── Cron Overlap ──────────────────────────────────────────
+
+func TestTask_ValidateCronOverlap(t *testing.T) {
+	base := Task{Type: "t", Priority: PriorityNormal, Timeout: time.Second}
+
+	ok := base
+	ok.Cron = "@every 1m"
+	WithCronOverlap(OverlapReplace, 2)(&ok)
+	if err := ok.Validate(); err != nil {
+		t.Errorf("valid overlap policy: %v", err)
+	}
+
+	unknown := ok
+	unknown.CronOverlap = "parallel"
+	if err := unknown.Validate(); !errors.Is(err, ErrInvalidOverlapPolicy) {
+		t.Errorf("unknown policy: expected ErrInvalidOverlapPolicy, got %v", err)
+	}
+
+	noCron := base
+	WithCronOverlap(OverlapSkip, 1)(&noCron)
+	if err := noCron.Validate(); !errors.Is(err, ErrInvalidOverlapPolicy) {
+		t.Errorf("policy without cron: expected ErrInvalidOverlapPolicy, got %v", err)
+	}
+}
+
+// runOverlappingCron 提交每秒触发、每次执行 2.5 秒的 Cron 任务，返回首个实例ID与最大并发数
+func runOverlappingCron(t *testing.T, policy OverlapPolicy, wait time.Duration) (*Scheduler, string, *atomic.Int64, *atomic.Int64) {
+	t.Helper()
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb, WithWorkerConcurrency(4))
+
+	var running, peak, starts atomic.Int64
+	if err := SchedulerRegister[testPayloadMsg](s, "cron.overlap", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		starts.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			if old := peak.Load(); n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		select {
+		case <-time.After(2500 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	id, err := Submit[testPayloadMsg](s, ctx, "cron.overlap", testPayloadMsg{Value: string(policy)},
+		WithCron("@every 1s"),
+		WithCronOverlap(policy, 1),
+		WithPriority(PriorityNormal),
+		WithTaskTimeout(10*time.Second),
+		WithTaskMaxRetry(0),
+	)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	time.Sleep(wait)
+	return s, id, &peak, &starts
+}
+
+func TestScheduler_CronOverlapSkip(t *testing.T) {
+	_, _, peak, starts := runOverlappingCron(t, OverlapSkip, 4500*time.Millisecond)
+	if peak.Load() != 1 {
+		t.Errorf("peak concurrency %d, want 1", peak.Load())
+	}
+	// 跳过重叠的执行后，系列继续按表达式触发
+	if starts.Load() < 2 {
+		t.Errorf("starts %d, want at least 2", starts.Load())
+	}
+}
+
+func TestScheduler_CronOverlapReplace(t *testing.T) {
+	s, id, peak, starts := runOverlappingCron(t, OverlapReplace, 3*time.Second)
+	if starts.Load() < 2 {
+		t.Fatalf("starts %d, want at least 2", starts.Load())
+	}
+	// 接管在续约时感知，新旧实例可能短暂重叠
+	if peak.Load() > 2 {
+		t.Errorf("peak concurrency %d, want at most 2", peak.Load())
+	}
+
+	info, err := s.GetTaskInfo(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetTaskInfo: %v", err)
+	}
+	if info.Status != StatusCancelled || info.LastError != ErrCronReplaced.Error() {
+		t.Errorf("first run: status %s, last error %q", info.Status, info.LastError)
+	}
+}
+
+// ─── Custom State ──────────────────────────────────────────
+
+const stateWaitingExternal TaskStatus = "waiting_external"
+
+// waitTaskStatus 等待任务进入 status
+func waitTaskStatus(t *testing.T, s *Scheduler, taskID string, status TaskStatus) *TaskInfo {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := s.GetTaskInfo(context.Background(), taskID)
+		if err == nil && info.Status == status {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for status %s, info=%+v err=%v", status, info, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestTaskState_Validate(t *testing.T) {
+	if err := SetState(context.Background(), stateWaitingExternal, time.Minute); !errors.Is(err, ErrStateOutsideHandler) {
+		t.Errorf("SetState outside handler: %v", err)
+	}
+	if err := validateStates(map[TaskStatus]StateTimeoutPolicy{StatusRunning: StateTimeoutRetry}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("builtin state: %v", err)
+	}
+	// 内置状态在连接 Redis 前即被拒绝
+	if _, err := New(WithTaskState(StatusExpired, StateTimeoutDead)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expired state: %v", err)
+	}
+	if err := validateStates(map[TaskStatus]StateTimeoutPolicy{stateWaitingExternal: "later"}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("invalid policy: %v", err)
+	}
+	if err := validateStates(map[TaskStatus]StateTimeoutPolicy{stateWaitingExternal: StateTimeoutDead}); err != nil {
+		t.Errorf("valid state: %v", err)
+	}
+
+	req := &stateRequest{states: map[TaskStatus]StateTimeoutPolicy{stateWaitingExternal: StateTimeoutResume}}
+	ctx := context.WithValue(context.Background(), stateRequestKey{}, req)
+	if err := SetState(ctx, "unknown", time.Minute); !errors.Is(err, ErrUnknownState) {
+		t.Errorf("unknown state: %v", err)
+	}
+	if err := SetState(ctx, stateWaitingExternal, 0); !errors.Is(err, ErrInvalidTimeout) {
+		t.Errorf("zero timeout: %v", err)
+	}
+	if err := SetState(ctx, stateWaitingExternal, time.Minute); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+	if state, timeout := req.get(); state != stateWaitingExternal || timeout != time.Minute {
+		t.Errorf("request = (%s, %v)", state, timeout)
+	}
+}
+
+func TestScheduler_CustomStateResume(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb, WithTaskState(stateWaitingExternal, StateTimeoutDead))
+
+	resumedFrom := make(chan TaskStatus, 1)
+	var runs atomic.Int64
+	if err := SchedulerRegister[testPayloadMsg](s, "state.resume", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		runs.Add(1)
+		if state, _ := ResumedFrom(ctx); state != "" {
+			resumedFrom <- state
+			return nil
+		}
+		return SetState(ctx, stateWaitingExternal, time.Minute)
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	id, err := Submit[testPayloadMsg](s, ctx, "state.resume", testPayloadMsg{Value: "r"}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	info := waitTaskStatus(t, s, id, stateWaitingExternal)
+	if info.State != stateWaitingExternal || info.StateDeadline == nil {
+		t.Fatalf("parked info: %+v", info)
+	}
+	if stats, err := s.GetQueueStats(ctx); err != nil || stats.ParkedCount != 1 {
+		t.Fatalf("parked count: %+v %v", stats, err)
+	}
+
+	if err := s.ResumeTask(ctx, id); err != nil {
+		t.Fatalf("ResumeTask: %v", err)
+	}
+	select {
+	case state := <-resumedFrom:
+		if state != stateWaitingExternal {
+			t.Errorf("resumed from %s", state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for resumed run")
+	}
+	if err := s.ResumeTask(ctx, id); !errors.Is(err, ErrTaskNotParked) {
+		t.Errorf("second ResumeTask: %v", err)
+	}
+	if runs.Load() != 2 {
+		t.Errorf("runs = %d, want 2", runs.Load())
+	}
+}
+
+func TestScheduler_CustomStateCompleteAndTimeout(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb,
+		WithTaskState(stateWaitingExternal, StateTimeoutDead),
+		WithRetention(time.Hour, time.Hour),
+	)
+
+	if err := SchedulerRegister[testPayloadMsg](s, "state.complete", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		timeout := time.Minute
+		if p.Value == "expire" {
+			timeout = time.Second
+		}
+		return SetState(ctx, stateWaitingExternal, timeout)
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	// 外部回调直接完成任务
+	done, err := Submit[testPayloadMsg](s, ctx, "state.complete", testPayloadMsg{Value: "done"}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitTaskStatus(t, s, done, stateWaitingExternal)
+	if err := s.CompleteTask(ctx, done, nil); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+	waitTaskStatus(t, s, done, StatusSuccess)
+
+	// 未收到回调，到期后按 StateTimeoutDead 进入死信队列
+	expire, err := Submit[testPayloadMsg](s, ctx, "state.complete", testPayloadMsg{Value: "expire"}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second), WithTaskMaxRetry(3))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	info := waitTaskStatus(t, s, expire, StatusDead)
+	if !info.StateTimedOut || !strings.Contains(info.LastError, ErrStateTimeout.Error()) {
+		t.Errorf("expired info: %+v", info)
+	}
+	if err := s.CompleteTask(ctx, expire, nil); !errors.Is(err, ErrTaskNotParked) {
+		t.Errorf("CompleteTask after timeout: %v", err)
+	}
+}
+
+// ─── Submit Interceptor ────────────────────────────────────
+
+type tenantKey struct{}
+
+type tenantPayload struct {
+	Value  string `json:"value"`
+	Tenant string `json:"tenant"`
+	Schema int    `json:"schema"`
+}
+
+func TestScheduler_SubmitInterceptor(t *testing.T) {
+	rdb := testRedisClient(t)
+	var order []string
+	errNoTenant := errors.New("tenant required")
+	s, _ := newTestScheduler(t, rdb,
+		WithSubmitInterceptor(AllTaskTypes, func(ctx context.Context, task *Task) error {
+			order = append(order, "all")
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			if tenant == "" {
+				return errNoTenant
+			}
+			if task.Tags == nil {
+				task.Tags = make(map[string]string)
+			}
+			task.Tags["tenant"] = tenant
+			return nil
+		}),
+		WithSubmitInterceptor("intercept.enrich", func(ctx context.Context, task *Task) error {
+			order = append(order, "type")
+			return TransformPayload(task, DefaultSerializer, func(p *tenantPayload) error {
+				p.Tenant = task.Tags["tenant"]
+				p.Schema = 2
+				return nil
+			})
+		}),
+		WithSubmitInterceptor("intercept.batch", func(ctx context.Context, task *Task) error {
+			if strings.Contains(string(task.Payload), `"b"`) {
+				return errors.New("rejected")
+			}
+			return nil
+		}),
+	)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	id, err := Submit[tenantPayload](s, ctx, "intercept.enrich", tenantPayload{Value: "a"}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if strings.Join(order, ",") != "all,type" {
+		t.Errorf("interceptor order = %v", order)
+	}
+
+	info, err := s.GetTaskInfo(ctx, id)
+	if err != nil {
+		t.Fatalf("GetTaskInfo: %v", err)
+	}
+	if info.Tags["tenant"] != "acme" {
+		t.Errorf("tags = %v", info.Tags)
+	}
+	var payload tenantPayload
+	if err := DefaultSerializer.Unmarshal(info.Payload, &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload != (tenantPayload{Value: "a", Tenant: "acme", Schema: 2}) {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	// 其他类型只执行通用拦截器
+	order = nil
+	if _, err := Submit[tenantPayload](s, ctx, "intercept.other", tenantPayload{Value: "b"}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second)); err != nil {
+		t.Fatalf("Submit other: %v", err)
+	}
+	if strings.Join(order, ",") != "all" {
+		t.Errorf("interceptor order = %v", order)
+	}
+
+	// 拦截器否决提交
+	_, err = Submit[tenantPayload](s, context.Background(), "intercept.enrich", tenantPayload{Value: "c"}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second))
+	if !errors.Is(err, ErrSubmitRejected) || !errors.Is(err, errNoTenant) {
+		t.Fatalf("expected ErrSubmitRejected wrapping errNoTenant, got %v", err)
+	}
+
+	// 批量提交跳过被否决的任务
+	ids, err := BatchSubmit[tenantPayload](s, ctx, "intercept.batch", []tenantPayload{{Value: "a"}, {Value: "b"}, {Value: "c"}}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("BatchSubmit: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 task IDs, got %d", len(ids))
+	}
+}
+
+// ─── Task Expiry ───────────────────────────────────────────
+
+func TestScheduler_TaskExpiry(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb, WithDLQExpired(true))
+
+	var calls atomic.Int64
+	if err := SchedulerRegister[testPayloadMsg](s, "expiry.test", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		calls.Add(1)
+		return nil
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := Submit[testPayloadMsg](s, ctx, "expiry.test", testPayloadMsg{}, WithPriority(PriorityNormal), WithTaskTimeout(time.Second), WithExpiry(-time.Second)); !errors.Is(err, ErrInvalidExpiry) {
+		t.Fatalf("expected ErrInvalidExpiry, got %v", err)
+	}
+
+	// 积压场景：计划执行时间已过去 5 秒，过期时间 1 秒
+	expiredID, err := Submit[testPayloadMsg](s, ctx, "expiry.test", testPayloadMsg{Value: "late"},
+		WithScheduleAt(time.Now().Add(-5*time.Second)),
+		WithExpiry(time.Second),
+		WithPriority(PriorityNormal),
+		WithTaskTimeout(2*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("Submit expired: %v", err)
+	}
+	freshID, err := Submit[testPayloadMsg](s, ctx, "expiry.test", testPayloadMsg{Value: "fresh"},
+		WithExpiry(time.Hour),
+		WithPriority(PriorityNormal),
+		WithTaskTimeout(2*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("Submit fresh: %v", err)
+	}
+
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if calls.Load() != 1 {
+		t.Fatalf("expected only the fresh task to run, got %d calls", calls.Load())
+	}
+	if _, err := s.GetTaskInfo(ctx, freshID); err != ErrTaskNotFound {
+		t.Errorf("fresh task should have succeeded and been deleted, got %v", err)
+	}
+
+	info, err := s.GetTaskInfo(ctx, expiredID)
+	if err != nil {
+		t.Fatalf("GetTaskInfo: %v", err)
+	}
+	if info.Status != StatusExpired || info.LastError != ErrTaskExpired.Error() {
+		t.Fatalf("expected expired status, got %s (%q)", info.Status, info.LastError)
+	}
+	if info.Expiry != time.Second {
+		t.Errorf("expiry = %v, want 1s", info.Expiry)
+	}
+	dead, err := s.dlq.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("dlq: %v", err)
+	}
+	if len(dead) != 1 || dead[0] != expiredID {
+		t.Errorf("dlq = %v, want [%s]", dead, expiredID)
+	}
+	if n, _ := rdb.ZCard(ctx, s.opts.Namespace+":expiring").Result(); n != 0 {
+		t.Errorf("expiring index should be empty, got %d", n)
+	}
+}
+
+// ─── 暂停分发 ───────────────────────────────────────────
+
+func TestScheduler_PauseType(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb)
+
+	var pausedCalls, otherCalls atomic.Int64
+	if err := SchedulerRegister[testPayloadMsg](s, "pause.a", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		pausedCalls.Add(1)
+		return nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := SchedulerRegister[testPayloadMsg](s, "pause.b", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		otherCalls.Add(1)
+		return nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := s.PauseType(ctx, ""); !errors.Is(err, ErrInvalidTaskType) {
+		t.Fatalf("expected ErrInvalidTaskType, got %v", err)
+	}
+	if err := s.PauseType(ctx, "pause.a"); err != nil {
+		t.Fatalf("PauseType: %v", err)
+	}
+
+	pausedID, err := Submit[testPayloadMsg](s, ctx, "pause.a", testPayloadMsg{}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := Submit[testPayloadMsg](s, ctx, "pause.b", testPayloadMsg{}, WithPriority(PriorityNormal), WithTaskTimeout(2*time.Second)); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	// 其他类型照常执行，已暂停类型的任务被放回延迟队列
+	deadline := time.Now().Add(5 * time.Second)
+	for otherCalls.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if otherCalls.Load() != 1 || pausedCalls.Load() != 0 {
+		t.Fatalf("calls: paused=%d other=%d, want 0 and 1", pausedCalls.Load(), otherCalls.Load())
+	}
+	info, err := s.GetTaskInfo(ctx, pausedID)
+	if err != nil {
+		t.Fatalf("GetTaskInfo: %v", err)
+	}
+	if info.Status != StatusPending {
+		t.Errorf("paused task status = %s, want pending", info.Status)
+	}
+
+	stats, err := s.GetQueueStats(ctx)
+	if err != nil {
+		t.Fatalf("GetQueueStats: %v", err)
+	}
+	if len(stats.PausedTypes) != 1 || stats.PausedTypes[0] != "pause.a" {
+		t.Errorf("paused types = %v, want [pause.a]", stats.PausedTypes)
+	}
+
+	// 恢复后在下次重新检查时执行
+	if err := s.ResumeType(ctx, "pause.a"); err != nil {
+		t.Fatalf("ResumeType: %v", err)
+	}
+	deadline = time.Now().Add(pausedRequeueDelay + 3*time.Second)
+	for pausedCalls.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if pausedCalls.Load() != 1 {
+		t.Fatalf("resumed task did not run, calls = %d", pausedCalls.Load())
+	}
+	paused, err := s.Paused(ctx)
+	if err != nil {
+		t.Fatalf("Paused: %v", err)
+	}
+	if len(paused.Types) != 0 || len(paused.Priorities) != 0 {
+		t.Errorf("expected nothing paused, got %+v", paused)
+	}
+}
+
+func TestScheduler_PausePriority(t *testing.T) {
+	rdb := testRedisClient(t)
+	s, _ := newTestScheduler(t, rdb)
+
+	var mu sync.Mutex
+	var order []string
+	if err := SchedulerRegister[testPayloadMsg](s, "pause.priority", HandlerFunc[testPayloadMsg](func(ctx context.Context, p testPayloadMsg) error {
+		mu.Lock()
+		order = append(order, p.Value)
+		mu.Unlock()
+		return nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	ran := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), order...)
+	}
+
+	ctx := context.Background()
+	if err := s.PausePriority(ctx, Priority(0)); !errors.Is(err, ErrInvalidPriority) {
+		t.Fatalf("expected ErrInvalidPriority, got %v", err)
+	}
+	if err := s.PausePriority(ctx, PriorityLow); err != nil {
+		t.Fatalf("PausePriority: %v", err)
+	}
+	if _, err := Submit[testPayloadMsg](s, ctx, "pause.priority", testPayloadMsg{Value: "low"}, WithPriority(PriorityLow), WithTaskTimeout(2*time.Second)); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := Submit[testPayloadMsg](s, ctx, "pause.priority", testPayloadMsg{Value: "high"}, WithPriority(PriorityHigh), WithTaskTimeout(2*time.Second)); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutCtx)
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ran()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if got := ran(); len(got) != 1 || got[0] != "high" {
+		t.Fatalf("ran = %v, want [high]", got)
+	}
+
+	// 消息保留在就绪队列中
+	stats, err := s.GetQueueStats(ctx)
+	if err != nil {
+		t.Fatalf("GetQueueStats: %v", err)
+	}
+	if stats.LowCount != 1 || len(stats.PausedPriorities) != 1 || stats.PausedPriorities[0] != "low" {
+		t.Errorf("stats = %+v, want 1 low task and paused [low]", stats)
+	}
+
+	if err := s.ResumePriority(ctx, PriorityLow); err != nil {
+		t.Fatalf("ResumePriority: %v", err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for len(ran()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := ran(); len(got) != 2 || got[1] != "low" {
+		t.Fatalf("ran = %v, want [high low]", got)
+	}
+}
+
+// ─── Queue ─────────────────────────────────────────────────
+
+// newTestQueue 创建测试用队列，命名空间在测试结束后清理
+func newTestQueue(t *testing.T, rdb *redis.Client, namespace, consumer string) *Queue {
+	t.Helper()
+	q := NewQueue(rdb, namespace)
+	q.SetConsumer(consumer)
+	return q
+}
+
+func TestQueue_MoveDelayedToReadyOnce(t *testing.T) {
+	rdb := testRedisClient(t)
+	namespace := "test-" + uuid.NewString()[:8]
+	t.Cleanup(func() { cleanupNamespace(t, rdb, namespace) })
+
+	ctx := context.Background()
+	const taskID = "delayed-task"
+	if err := rdb.HSet(ctx, namespace+":task:"+taskID, "priority", int(PriorityNormal)).Err(); err != nil {
+		t.Fatalf("HSet: %v", err)
+	}
+	q1 := newTestQueue(t, rdb, namespace, "c1")
+	q2 := newTestQueue(t, rdb, namespace, "c2")
+	now := time.Now().Unix()
+	if err := q1.AddDelayed(ctx, taskID, float64(now-1)); err != nil {
+		t.Fatalf("AddDelayed: %v", err)
+	}
+
+	// 两个实例同时扫描到同一到期任务，只应投递一次
+	var (
+		wg    sync.WaitGroup
+		moved atomic.Int64
+	)
+	for _, q := range []*Queue{q1, q2} {
+		wg.Add(1)
+		go func(q *Queue) {
+			defer wg.Done()
+			n, err := q.MoveDelayedToReady(ctx, now, 10)
+			if err != nil {
+				t.Errorf("MoveDelayedToReady: %v", err)
+			}
+			moved.Add(n)
+		}(q)
+	}
+	wg.Wait()
+
+	if got := moved.Load(); got != 1 {
+		t.Fatalf("moved = %d, want 1", got)
+	}
+	msgs, err := rdb.XRange(ctx, q1.keyStream(PriorityNormal), "-", "+").Result()
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("stream messages = %v (err %v), want 1", msgs, err)
+	}
+	// 消息字段与 AddReady 投递的一致
+	for _, field := range []string{"task_id", "priority", "added_at"} {
+		if _, ok := msgs[0].Values[field]; !ok {
+			t.Errorf("message missing field %q: %v", field, msgs[0].Values)
+		}
+	}
+	if n, _ := q1.GetDelayedCount(ctx); n != 0 {
+		t.Fatalf("delayed count = %d, want 0", n)
+	}
+}
+
+func TestQueue_ClaimStaleMessages(t *testing.T) {
+	rdb := testRedisClient(t)
+	namespace := "test-" + uuid.NewString()[:8]
+	t.Cleanup(func() { cleanupNamespace(t, rdb, namespace) })
+
+	ctx := context.Background()
+	const taskID = "stale-task"
+	crashed := newTestQueue(t, rdb, namespace, "crashed")
+	alive := newTestQueue(t, rdb, namespace, "alive")
+
+	if err := crashed.AddReady(ctx, taskID, PriorityNormal); err != nil {
+		t.Fatalf("AddReady: %v", err)
+	}
+	got, _, _, err := crashed.PopReady(ctx, 0)
+	if err != nil || got != taskID {
+		t.Fatalf("PopReady = %q, %v", got, err)
+	}
+
+	// 任务锁仍被持有：原Worker存活，只接管不重新投递
+	lock := NewDistLock(rdb, namespace)
+	if ok, err := lock.Acquire(ctx, taskID, "crashed", 5*time.Second); err != nil || !ok {
+		t.Fatalf("Acquire = %v, %v", ok, err)
+	}
+	claimed, err := alive.ClaimStaleMessages(ctx, PriorityNormal, 0)
+	if err != nil {
+		t.Fatalf("ClaimStaleMessages: %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Fatalf("claimed while locked = %v, want none", claimed)
+	}
+	if n, _ := alive.GetPendingCount(ctx, PriorityNormal); n != 1 {
+		t.Fatalf("pending while locked = %d, want 1", n)
+	}
+
+	// 锁释放后重新投递并确认原消息
+	if _, err := lock.Release(ctx, taskID, "crashed"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	claimed, err = alive.ClaimStaleMessages(ctx, PriorityNormal, 0)
+	if err != nil {
+		t.Fatalf("ClaimStaleMessages: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0] != taskID {
+		t.Fatalf("claimed = %v, want [%s]", claimed, taskID)
+	}
+	if n, _ := alive.GetPendingCount(ctx, PriorityNormal); n != 0 {
+		t.Fatalf("pending after redelivery = %d, want 0", n)
+	}
+	got, _, _, err = alive.PopReady(ctx, 0)
+	if err != nil || got != taskID {
+		t.Fatalf("PopReady after redelivery = %q, %v", got, err)
+	}
+}
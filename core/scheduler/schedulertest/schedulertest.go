@@ -0,0 +1,504 @@
+// Package schedulertest 提供基于真实 Redis 的调度器集成测试工具：
+// 通过 dockertest 启动 Redis 容器，运行多个 Worker，并提供故障注入（杀死 Worker、Redis 延迟、丢弃 ACK）
+// 与任务终态断言，用于验证任务处理器在真实故障下的幂等性。
+//
+//	func TestOrderHandler(t *testing.T) {
+//		c := schedulertest.New(t, schedulertest.Config{
+//			Workers: 3,
+//			Setup: func(s *scheduler.Scheduler) error {
+//				return scheduler.SchedulerRegister[Order](s, "order.pay", handler)
+//			},
+//		})
+//		id := schedulertest.Submit(c, "order.pay", Order{ID: 1})
+//		c.KillWorker(0)
+//		c.RequireSucceeded(id)
+//	}
+package schedulertest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/kochabx/kit/core/scheduler"
+	"github.com/kochabx/kit/log"
+)
+
+const (
+	// DefaultRedisImage 默认 Redis 镜像
+	DefaultRedisImage = "redis:7-alpine"
+
+	defaultWorkers     = 3
+	defaultWaitTimeout = 30 * time.Second
+	defaultTaskTimeout = 10 * time.Second
+	pollInterval       = 50 * time.Millisecond
+	containerExpire    = 10 * 60 // 测试进程异常退出时容器的最长存活时间（秒）
+)
+
+// ErrKilled Worker 被杀死后其 Redis 命令返回的错误
+var ErrKilled = errors.New("schedulertest: worker killed")
+
+// Config 集群配置
+type Config struct {
+	Workers     int                                // Worker 数量（默认：3），每个 Worker 运行在独立的 Scheduler 中以模拟独立进程
+	RedisAddr   string                             // 使用已有的 Redis（如 CI 中的服务容器），为空时通过 dockertest 启动容器
+	RedisImage  string                             // Redis 镜像（默认：DefaultRedisImage）
+	WaitTimeout time.Duration                      // 等待任务终态的超时时间（默认：30秒）
+	Options     []scheduler.Option                 // 追加在测试默认选项之后的调度器选项
+	Setup       func(s *scheduler.Scheduler) error // 在每个 Scheduler 上注册处理器，启动前调用
+}
+
+// Cluster 测试集群：共享同一个 Redis 与命名空间的多个 Worker
+type Cluster struct {
+	t           testing.TB
+	cfg         Config
+	addr        string
+	namespace   string
+	waitTimeout time.Duration
+
+	// 不受故障注入影响，用于提交任务、查询与断言
+	client   *redis.Client
+	producer *scheduler.Scheduler
+
+	mu      sync.Mutex
+	workers []*Worker
+}
+
+// Worker 集群中的一个 Worker，拥有独立的 Scheduler 与 Redis 连接
+type Worker struct {
+	cluster   *Cluster
+	scheduler *scheduler.Scheduler
+	client    *redis.Client
+	chaos     *chaosHook
+}
+
+// New 创建并启动测试集群，测试结束时自动关闭并清理。
+// 未设置 Config.RedisAddr 且 Docker 不可用时跳过测试
+func New(t testing.TB, cfg Config) *Cluster {
+	t.Helper()
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWorkers
+	}
+	if cfg.RedisImage == "" {
+		cfg.RedisImage = DefaultRedisImage
+	}
+	c := &Cluster{
+		t:           t,
+		cfg:         cfg,
+		addr:        cfg.RedisAddr,
+		namespace:   "schedulertest-" + uuid.NewString()[:8],
+		waitTimeout: cfg.WaitTimeout,
+	}
+	if c.waitTimeout <= 0 {
+		c.waitTimeout = defaultWaitTimeout
+	}
+	if c.addr == "" {
+		c.addr = startRedis(t, cfg.RedisImage)
+	}
+
+	c.client = redis.NewClient(&redis.Options{Addr: c.addr})
+	t.Cleanup(c.close)
+
+	producer, err := c.newScheduler(c.client, 0)
+	if err != nil {
+		t.Fatalf("schedulertest: create producer: %v", err)
+	}
+	c.producer = producer
+
+	for range cfg.Workers {
+		c.AddWorker()
+	}
+	return c
+}
+
+// startRedis 通过 dockertest 启动 Redis 容器并等待就绪，返回 host:port
+func startRedis(t testing.TB, image string) string {
+	t.Helper()
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("schedulertest: docker not available: %v", err)
+	}
+	if err := pool.Client.Ping(); err != nil {
+		t.Skipf("schedulertest: docker not available: %v", err)
+	}
+
+	repository, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository, tag = image[:i], image[i+1:]
+	}
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: repository,
+		Tag:        tag,
+	}, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		t.Fatalf("schedulertest: start redis container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := pool.Purge(resource); err != nil {
+			t.Logf("schedulertest: purge redis container: %v", err)
+		}
+	})
+	_ = resource.Expire(containerExpire)
+
+	addr := resource.GetHostPort("6379/tcp")
+	pool.MaxWait = 30 * time.Second
+	if err := pool.Retry(func() error {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		defer client.Close()
+		return client.Ping(context.Background()).Err()
+	}); err != nil {
+		t.Fatalf("schedulertest: redis container not ready: %v", err)
+	}
+	return addr
+}
+
+// newScheduler 按测试默认选项创建 Scheduler：缩短锁超时与扫描间隔以便快速触发故障恢复，
+// 保留成功任务信息以便断言终态，日志只输出警告及以上级别
+func (c *Cluster) newScheduler(client *redis.Client, workers int) (*scheduler.Scheduler, error) {
+	opts := []scheduler.Option{
+		scheduler.WithScanInterval(50 * time.Millisecond),
+		scheduler.WithRetryStrategy(100*time.Millisecond, time.Second, 2, false),
+		scheduler.WithRetention(time.Hour, time.Hour),
+		scheduler.WithMetrics(false),
+		scheduler.WithHealth(false),
+		scheduler.WithCustomLogger(log.New(log.WithLevel(zerolog.WarnLevel))),
+		func(o *scheduler.Options) {
+			o.Worker.LeaseTTL = 2 * time.Second
+			o.Worker.RenewInterval = 200 * time.Millisecond
+			o.Worker.ShutdownGracePeriod = time.Second
+			o.LockTimeout = 2 * time.Second
+		},
+	}
+	opts = append(opts, c.cfg.Options...)
+	opts = append(opts,
+		scheduler.WithRedisClient(client),
+		scheduler.WithNamespace(c.namespace),
+		scheduler.WithWorkerCount(workers),
+	)
+
+	s, err := scheduler.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.cfg.Setup != nil {
+		if err := c.cfg.Setup(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// AddWorker 启动一个新的 Worker，如在杀死 Worker 后补充处理能力
+func (c *Cluster) AddWorker() *Worker {
+	c.t.Helper()
+	hook := &chaosHook{}
+	client := redis.NewClient(&redis.Options{Addr: c.addr})
+	client.AddHook(hook)
+
+	s, err := c.newScheduler(client, 1)
+	if err != nil {
+		_ = client.Close()
+		c.t.Fatalf("schedulertest: create worker: %v", err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		_ = client.Close()
+		c.t.Fatalf("schedulertest: start worker: %v", err)
+	}
+
+	w := &Worker{cluster: c, scheduler: s, client: client, chaos: hook}
+	c.mu.Lock()
+	c.workers = append(c.workers, w)
+	c.mu.Unlock()
+	return w
+}
+
+// Worker 返回第 i 个 Worker（按启动顺序，包括已杀死的）
+func (c *Cluster) Worker(i int) *Worker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.workers[i]
+}
+
+// Workers 返回所有 Worker（按启动顺序，包括已杀死的）
+func (c *Cluster) Workers() []*Worker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Worker(nil), c.workers...)
+}
+
+// Scheduler 返回不运行 Worker 的 Scheduler，用于提交与查询任务，不受故障注入影响
+func (c *Cluster) Scheduler() *scheduler.Scheduler {
+	return c.producer
+}
+
+// Client 返回不受故障注入影响的 Redis 客户端
+func (c *Cluster) Client() *redis.Client {
+	return c.client
+}
+
+// Namespace 返回集群使用的命名空间
+func (c *Cluster) Namespace() string {
+	return c.namespace
+}
+
+// KillWorker 杀死第 i 个 Worker，见 Worker.Kill
+func (c *Cluster) KillWorker(i int) {
+	c.Worker(i).Kill()
+}
+
+// DelayRedis 为所有 Worker 的每条 Redis 命令注入延迟，0 表示取消
+func (c *Cluster) DelayRedis(d time.Duration) {
+	for _, w := range c.Workers() {
+		w.DelayRedis(d)
+	}
+}
+
+// DropAcks 开启或关闭所有 Worker 的 ACK 丢弃，见 Worker.DropAcks
+func (c *Cluster) DropAcks(enabled bool) {
+	for _, w := range c.Workers() {
+		w.DropAcks(enabled)
+	}
+}
+
+// Submit 通过集群提交任务，失败时终止测试。
+// 默认普通优先级、超时 10 秒，opts 可覆盖
+func Submit[T any](c *Cluster, taskType string, payload T, opts ...scheduler.TaskOption) string {
+	c.t.Helper()
+	opts = append([]scheduler.TaskOption{
+		scheduler.WithPriority(scheduler.PriorityNormal),
+		scheduler.WithTaskTimeout(defaultTaskTimeout),
+	}, opts...)
+	id, err := scheduler.Submit(c.producer, context.Background(), taskType, payload, opts...)
+	if err != nil {
+		c.t.Fatalf("schedulertest: submit %s: %v", taskType, err)
+	}
+	return id
+}
+
+// TaskInfo 查询任务信息，失败时终止测试
+func (c *Cluster) TaskInfo(taskID string) *scheduler.TaskInfo {
+	c.t.Helper()
+	info, err := c.producer.GetTaskInfo(context.Background(), taskID)
+	if err != nil {
+		c.t.Fatalf("schedulertest: get task %s: %v", taskID, err)
+	}
+	return info
+}
+
+// WaitTerminal 等待任务全部进入终态（成功、失败、取消、死信），超时终止测试。
+// 返回的任务信息与 taskIDs 一一对应
+func (c *Cluster) WaitTerminal(taskIDs ...string) []*scheduler.TaskInfo {
+	c.t.Helper()
+	ctx := context.Background()
+	deadline := time.Now().Add(c.waitTimeout)
+	infos := make([]*scheduler.TaskInfo, len(taskIDs))
+	for i, id := range taskIDs {
+		for {
+			info, err := c.producer.GetTaskInfo(ctx, id)
+			if err == nil && isTerminal(info.Status) {
+				infos[i] = info
+				break
+			}
+			if time.Now().After(deadline) {
+				status := scheduler.TaskStatus("")
+				if info != nil {
+					status = info.Status
+				}
+				c.t.Fatalf("schedulertest: task %s not terminal after %s (status %q, err %v)", id, c.waitTimeout, status, err)
+			}
+			time.Sleep(pollInterval)
+		}
+	}
+	return infos
+}
+
+// RequireStatus 等待任务进入终态并要求终态为 want
+func (c *Cluster) RequireStatus(want scheduler.TaskStatus, taskIDs ...string) {
+	c.t.Helper()
+	for _, info := range c.WaitTerminal(taskIDs...) {
+		if info.Status != want {
+			c.t.Fatalf("schedulertest: task %s status %q, want %q (retries %d, last error %q)",
+				info.ID, info.Status, want, info.RetryCount, info.LastError)
+		}
+	}
+}
+
+// RequireSucceeded 等待任务全部执行成功
+func (c *Cluster) RequireSucceeded(taskIDs ...string) {
+	c.t.Helper()
+	c.RequireStatus(scheduler.StatusSuccess, taskIDs...)
+}
+
+// RequireDead 等待任务全部进入死信
+func (c *Cluster) RequireDead(taskIDs ...string) {
+	c.t.Helper()
+	c.RequireStatus(scheduler.StatusDead, taskIDs...)
+}
+
+// WaitIdle 等待延迟队列为空且没有已投递未确认的消息，超时终止测试。
+// 通常在 WaitTerminal 之后调用，确认故障恢复引起的重新投递都已处理完；ACK 丢弃开启时不会空闲
+func (c *Cluster) WaitIdle() {
+	c.t.Helper()
+	ctx := context.Background()
+	deadline := time.Now().Add(c.waitTimeout)
+	for {
+		stats, err := c.producer.GetQueueStats(ctx)
+		if err == nil && stats.DelayedCount == 0 && stats.RunningCount == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			c.t.Fatalf("schedulertest: queue not idle after %s (stats %+v, err %v)", c.waitTimeout, stats, err)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// close 关闭所有 Worker 并清理命名空间
+func (c *Cluster) close() {
+	ctx := context.Background()
+	for _, w := range c.Workers() {
+		_ = w.scheduler.Shutdown(ctx)
+		_ = w.client.Close()
+	}
+
+	iter := c.client.Scan(ctx, 0, c.namespace+":*", 1000).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if len(keys) > 0 {
+		_ = c.client.Del(ctx, keys...).Err()
+	}
+	_ = c.client.Close()
+}
+
+// Scheduler 返回 Worker 所属的 Scheduler
+func (w *Worker) Scheduler() *scheduler.Scheduler {
+	return w.scheduler
+}
+
+// Kill 模拟进程崩溃：立即切断 Worker 的所有 Redis 命令（包括执行中的命令），然后停止 Worker。
+// 执行中的任务不会被确认，也不会释放锁或更新状态；锁超时后由其他 Worker 接管并重新执行
+func (w *Worker) Kill() {
+	if !w.chaos.killed.CompareAndSwap(false, true) {
+		return
+	}
+	_ = w.scheduler.Shutdown(context.Background())
+}
+
+// Killed 返回 Worker 是否已被杀死
+func (w *Worker) Killed() bool {
+	return w.chaos.killed.Load()
+}
+
+// DelayRedis 为该 Worker 的每条 Redis 命令注入延迟，0 表示取消
+func (w *Worker) DelayRedis(d time.Duration) {
+	w.chaos.delay.Store(int64(d))
+}
+
+// DropAcks 开启后该 Worker 的 XACK 不发送到 Redis 但向调用方返回成功，模拟确认丢失：
+// 已成功的任务在锁超时的两倍后被重新投递并再次执行
+func (w *Worker) DropAcks(enabled bool) {
+	w.chaos.dropAcks.Store(enabled)
+}
+
+// DroppedAcks 返回该 Worker 已丢弃的 ACK 数
+func (w *Worker) DroppedAcks() int64 {
+	return w.chaos.dropped.Load()
+}
+
+// isTerminal 判断任务状态是否为终态
+func isTerminal(status scheduler.TaskStatus) bool {
+	switch status {
+	case scheduler.StatusSuccess, scheduler.StatusFailed, scheduler.StatusCancelled, scheduler.StatusDead:
+		return true
+	}
+	return false
+}
+
+// chaosHook 注入故障的 Redis hook
+type chaosHook struct {
+	killed   atomic.Bool
+	delay    atomic.Int64 // time.Duration
+	dropAcks atomic.Bool
+	dropped  atomic.Int64
+}
+
+// before 在命令发送前应用杀死与延迟
+func (h *chaosHook) before(ctx context.Context) error {
+	if h.killed.Load() {
+		return ErrKilled
+	}
+	if d := time.Duration(h.delay.Load()); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+func (h *chaosHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if h.killed.Load() {
+			return nil, ErrKilled
+		}
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *chaosHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.before(ctx); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		if cmd.Name() == "xack" && h.dropAcks.Load() {
+			h.dropped.Add(1)
+			return nil
+		}
+		err := next(ctx, cmd)
+		// 命令执行期间被杀死：结果不会被进程看到
+		if h.killed.Load() {
+			cmd.SetErr(ErrKilled)
+			return ErrKilled
+		}
+		return err
+	}
+}
+
+func (h *chaosHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.before(ctx); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err := next(ctx, cmds)
+		if h.killed.Load() {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrKilled)
+			}
+			return ErrKilled
+		}
+		return err
+	}
+}
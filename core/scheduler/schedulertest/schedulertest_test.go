@@ -0,0 +1,154 @@
+package schedulertest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kochabx/kit/core/scheduler"
+)
+
+type payload struct {
+	N int `json:"n"`
+}
+
+// recorder 按 payload 记录执行次数
+type recorder struct {
+	mu     sync.Mutex
+	counts map[int]int
+}
+
+func (r *recorder) record(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = make(map[int]int)
+	}
+	r.counts[n]++
+	return r.counts[n]
+}
+
+func (r *recorder) count(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[n]
+}
+
+func newCluster(t *testing.T, workers int, handler scheduler.HandlerFunc[payload]) *Cluster {
+	return New(t, Config{
+		Workers: workers,
+		Setup: func(s *scheduler.Scheduler) error {
+			return scheduler.SchedulerRegister[payload](s, "test", handler)
+		},
+	})
+}
+
+func TestCluster_Succeeded(t *testing.T) {
+	var rec recorder
+	c := newCluster(t, 3, func(ctx context.Context, p payload) error {
+		rec.record(p.N)
+		return nil
+	})
+
+	ids := make([]string, 10)
+	for i := range ids {
+		ids[i] = Submit(c, "test", payload{N: i})
+	}
+	c.RequireSucceeded(ids...)
+	c.WaitIdle()
+	for i := range ids {
+		if n := rec.count(i); n != 1 {
+			t.Errorf("task %d executed %d times, want 1", i, n)
+		}
+	}
+}
+
+func TestCluster_Dead(t *testing.T) {
+	c := newCluster(t, 1, func(ctx context.Context, p payload) error {
+		return errors.New("boom")
+	})
+
+	id := Submit(c, "test", payload{}, scheduler.WithTaskMaxRetry(2))
+	c.RequireDead(id)
+	if info := c.TaskInfo(id); info.RetryCount != 2 || info.LastError != "boom" {
+		t.Errorf("retries %d, last error %q", info.RetryCount, info.LastError)
+	}
+}
+
+func TestCluster_KillWorker(t *testing.T) {
+	var rec recorder
+	started := make(chan struct{}, 1)
+	c := newCluster(t, 1, func(ctx context.Context, p payload) error {
+		if rec.record(p.N) == 1 {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	id := Submit(c, "test", payload{N: 1})
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("task not started")
+	}
+	c.KillWorker(0)
+	if !c.Worker(0).Killed() {
+		t.Fatal("worker not killed")
+	}
+	// 被杀死的 Worker 未更新状态
+	if info := c.TaskInfo(id); info.Status != scheduler.StatusRunning {
+		t.Fatalf("status after kill %q, want running", info.Status)
+	}
+
+	c.AddWorker()
+	c.RequireSucceeded(id)
+	if n := rec.count(1); n != 2 {
+		t.Errorf("executed %d times, want 2", n)
+	}
+}
+
+func TestCluster_DropAcks(t *testing.T) {
+	var rec recorder
+	c := newCluster(t, 2, func(ctx context.Context, p payload) error {
+		rec.record(p.N)
+		return nil
+	})
+
+	c.DropAcks(true)
+	id := Submit(c, "test", payload{N: 1})
+	c.RequireSucceeded(id)
+
+	var dropped int64
+	for _, w := range c.Workers() {
+		dropped += w.DroppedAcks()
+	}
+	if dropped == 0 {
+		t.Fatal("no ack dropped")
+	}
+
+	// 确认丢失的消息被重新投递并再次执行
+	c.DropAcks(false)
+	deadline := time.Now().Add(10 * time.Second)
+	for rec.count(1) < 2 && time.Now().Before(deadline) {
+		time.Sleep(pollInterval)
+	}
+	if n := rec.count(1); n < 2 {
+		t.Fatalf("executed %d times, want redelivery", n)
+	}
+	c.WaitIdle()
+	c.RequireSucceeded(id)
+}
+
+func TestCluster_DelayRedis(t *testing.T) {
+	c := newCluster(t, 2, func(ctx context.Context, p payload) error {
+		return nil
+	})
+
+	c.DelayRedis(20 * time.Millisecond)
+	ids := []string{Submit(c, "test", payload{N: 1}), Submit(c, "test", payload{N: 2})}
+	c.RequireSucceeded(ids...)
+}
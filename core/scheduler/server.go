@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Server 将 Scheduler 适配为 transport.Server（Start/Stop）与 cx 组件
+// （cx.Starter / cx.Stopper / cx.HealthChecker），可直接用于
+// app.WithServer 或 cx.Supply，由容器统一管理启动与关闭顺序。
+//
+//	s, _ := scheduler.New(scheduler.WithRedisAddr("localhost:6379"))
+//	a := app.New(
+//	    app.WithServer(httpServer),
+//	    app.WithServer(s.Server()),
+//	)
+type Server struct {
+	scheduler *Scheduler
+}
+
+// NewServer 创建 Scheduler 的生命周期适配器。
+func NewServer(s *Scheduler) *Server {
+	return &Server{scheduler: s}
+}
+
+// Server 返回 Scheduler 的生命周期适配器，等价于 NewServer(s)。
+func (s *Scheduler) Server() *Server {
+	return NewServer(s)
+}
+
+// Scheduler 返回被适配的调度器。
+func (srv *Server) Scheduler() *Scheduler {
+	return srv.scheduler
+}
+
+// Start 启动调度器并立即返回。
+// 调度器的运行期与 ctx 解绑，只由 Stop 结束：容器传入的启动上下文
+// 可能在启动完成后即被取消，不应连带停止后台 Worker。
+func (srv *Server) Start(ctx context.Context) error {
+	return srv.scheduler.Start(context.WithoutCancel(ctx))
+}
+
+// Stop 优雅关闭调度器。
+func (srv *Server) Stop(ctx context.Context) error {
+	return srv.scheduler.Shutdown(ctx)
+}
+
+// HealthCheck 执行调度器健康检查，状态为 unhealthy 时返回 ErrUnhealthy。
+// degraded 仍视为健康，与健康检查 HTTP 接口的 200 语义一致。
+func (srv *Server) HealthCheck(ctx context.Context) error {
+	status := srv.scheduler.healthChecker.Check(ctx)
+	if status.Status != "unhealthy" {
+		return nil
+	}
+
+	names := make([]string, 0, len(status.Checks))
+	for name, check := range status.Checks {
+		if check.Status == "error" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	details := make([]string, 0, len(names))
+	for _, name := range names {
+		details = append(details, fmt.Sprintf("%s: %s", name, status.Checks[name].Message))
+	}
+	return fmt.Errorf("%w: %s", ErrUnhealthy, strings.Join(details, "; "))
+}
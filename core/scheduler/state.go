@@ -0,0 +1,250 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// builtinStatuses 内置任务状态，不能注册为自定义状态
+var builtinStatuses = []TaskStatus{
+	StatusPending, StatusReady, StatusRunning, StatusSuccess, StatusFailed, StatusCancelled, StatusDead, StatusExpired,
+}
+
+// stateRequestKey stateRequest 在 context 中的键
+type stateRequestKey struct{}
+
+// resumedStateKey resumedState 在 context 中的键
+type resumedStateKey struct{}
+
+// stateRequest 记录 handler 通过 SetState 请求进入的自定义状态
+type stateRequest struct {
+	states map[TaskStatus]StateTimeoutPolicy
+
+	mu      sync.Mutex
+	state   TaskStatus
+	timeout time.Duration
+}
+
+// get 返回请求的状态，未请求时 state 为空
+func (r *stateRequest) get() (TaskStatus, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state, r.timeout
+}
+
+// resumedState 从自定义状态恢复执行时注入 handler 的信息
+type resumedState struct {
+	state    TaskStatus
+	timedOut bool
+}
+
+// SetState 请求在 handler 返回 nil 后将任务挂起到自定义状态 state (须先通过 WithTaskState 注册)，
+// 例如调用外部系统后等待其异步回调。挂起期间任务不占用 Worker，外部回调通过 ResumeTask / CompleteTask
+// 结束该状态；timeout 后仍未回调时按注册的 StateTimeoutPolicy 处理。
+//
+// 多次调用以最后一次为准；handler 返回错误 (或 panic) 时请求被忽略，按失败处理
+func SetState(ctx context.Context, state TaskStatus, timeout time.Duration) error {
+	req, ok := ctx.Value(stateRequestKey{}).(*stateRequest)
+	if !ok {
+		return ErrStateOutsideHandler
+	}
+	if _, ok := req.states[state]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownState, state)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("%w: state timeout must be positive", ErrInvalidTimeout)
+	}
+	req.mu.Lock()
+	req.state = state
+	req.timeout = timeout
+	req.mu.Unlock()
+	return nil
+}
+
+// ResumedFrom 返回本次执行是从哪个自定义状态恢复的，timedOut 表示该状态因到期而结束
+// (StateTimeoutResume / StateTimeoutRetry)，否则由 ResumeTask 结束。非恢复执行时 state 为空
+func ResumedFrom(ctx context.Context) (state TaskStatus, timedOut bool) {
+	if r, ok := ctx.Value(resumedStateKey{}).(resumedState); ok {
+		return r.state, r.timedOut
+	}
+	return "", false
+}
+
+// validateStates 校验自定义状态配置
+func validateStates(states map[TaskStatus]StateTimeoutPolicy) error {
+	for state, policy := range states {
+		if state == "" || slices.Contains(builtinStatuses, state) {
+			return fmt.Errorf("%w: task state %q is reserved", ErrInvalidConfig, state)
+		}
+		switch policy {
+		case StateTimeoutResume, StateTimeoutRetry, StateTimeoutDead:
+		default:
+			return fmt.Errorf("%w: task state %q has invalid timeout policy %q", ErrInvalidConfig, state, policy)
+		}
+	}
+	return nil
+}
+
+// isCustomState 判断 status 是否为已注册的自定义状态
+func (s *Scheduler) isCustomState(status TaskStatus) bool {
+	_, ok := s.opts.States[status]
+	return ok
+}
+
+// stateDeadlineUnix 将截止时间转换为 Unix 秒，未设置时为 0
+func stateDeadlineUnix(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// parkTask 将任务挂起到自定义状态，等待外部回调或到期
+func (s *Scheduler) parkTask(ctx context.Context, taskInfo *TaskInfo, state TaskStatus, timeout time.Duration) error {
+	deadline := s.now(ctx).Add(timeout)
+	taskInfo.Status = state
+	taskInfo.State = state
+	taskInfo.StateDeadline = &deadline
+	taskInfo.StateTimedOut = false
+
+	if err := s.saveTaskInfo(ctx, taskInfo); err != nil {
+		return err
+	}
+	if err := s.queue.AddParked(ctx, taskInfo.ID, float64(deadline.Unix())); err != nil {
+		return fmt.Errorf("failed to park task: %w", err)
+	}
+
+	s.logger.Info().
+		Str("task_id", taskInfo.ID).
+		Str("type", taskInfo.Type).
+		Str("state", string(state)).
+		Time("deadline", deadline).
+		Msg("task parked")
+	return nil
+}
+
+// claimParked 取得处于自定义状态的任务的处理权，多个实例中只有一个成功
+func (s *Scheduler) claimParked(ctx context.Context, taskID string) (*TaskInfo, error) {
+	claimed, err := s.queue.RemoveParked(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim parked task: %w", err)
+	}
+	if !claimed {
+		return nil, ErrTaskNotParked
+	}
+	taskInfo, err := s.GetTaskInfo(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if taskInfo.ExecutionTime == nil {
+		taskInfo.ExecutionTime = new(time.Duration)
+	}
+	return taskInfo, nil
+}
+
+// requeueParked 将离开自定义状态的任务放回就绪队列，重新执行 handler；
+// 失败时尽量恢复挂起，以便回调方重试或下次扫描重新处理
+func (s *Scheduler) requeueParked(ctx context.Context, taskInfo *TaskInfo) error {
+	taskInfo.Status = StatusReady
+	err := s.saveTaskInfo(ctx, taskInfo)
+	if err == nil {
+		if err = s.queue.AddReady(ctx, taskInfo.ID, taskInfo.Priority); err == nil {
+			return nil
+		}
+		err = fmt.Errorf("failed to add task to ready queue: %w", err)
+	}
+
+	taskInfo.Status = taskInfo.State
+	if saveErr := s.saveTaskInfo(ctx, taskInfo); saveErr != nil {
+		s.logger.Error().Err(saveErr).Str("task_id", taskInfo.ID).Msg("failed to restore parked task")
+	}
+	if parkErr := s.queue.AddParked(ctx, taskInfo.ID, float64(stateDeadlineUnix(taskInfo.StateDeadline))); parkErr != nil {
+		s.logger.Error().Err(parkErr).Str("task_id", taskInfo.ID).Msg("failed to restore parked task")
+	}
+	return err
+}
+
+// ResumeTask 外部回调：结束任务的自定义状态并重新执行 handler，handler 可通过 ResumedFrom 获知所处状态。
+// 任务不处于自定义状态 (包括 handler 尚未返回、已到期或已被其他回调处理) 时返回 ErrTaskNotParked，
+// 回调可能早于 handler 返回时调用方应稍后重试
+func (s *Scheduler) ResumeTask(ctx context.Context, taskID string) error {
+	taskInfo, err := s.claimParked(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if err := s.requeueParked(ctx, taskInfo); err != nil {
+		return err
+	}
+
+	s.logger.Info().Str("task_id", taskID).Str("state", string(taskInfo.State)).Msg("task resumed")
+	return nil
+}
+
+// CompleteTask 外部回调：直接结束处于自定义状态的任务，不再执行 handler。
+// result 为 nil 时按成功处理，否则按失败处理 (重试或进入死信队列)；错误返回同 ResumeTask
+func (s *Scheduler) CompleteTask(ctx context.Context, taskID string, result error) error {
+	taskInfo, err := s.claimParked(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	if result != nil {
+		s.handleTaskFailure(ctx, taskInfo, result)
+	} else {
+		s.handleTaskSuccess(ctx, taskInfo)
+	}
+	return nil
+}
+
+// expireParkedTasks 处理截止时间已到的自定义状态任务
+func (s *Scheduler) expireParkedTasks(ctx context.Context, now int64) {
+	taskIDs, err := s.queue.DueParked(ctx, now, s.opts.BatchSize)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to get expired parked tasks")
+		return
+	}
+
+	for _, taskID := range taskIDs {
+		taskInfo, err := s.claimParked(ctx, taskID)
+		if err == ErrTaskNotParked {
+			continue // 已被回调或其他实例处理
+		}
+		if err != nil {
+			s.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to claim expired parked task")
+			continue
+		}
+		s.handleStateTimeout(ctx, taskInfo)
+	}
+}
+
+// handleStateTimeout 按注册的策略处理到期的自定义状态，未注册的状态 (如实例间配置不一致) 按重试处理
+func (s *Scheduler) handleStateTimeout(ctx context.Context, taskInfo *TaskInfo) {
+	policy, ok := s.opts.States[taskInfo.State]
+	if !ok {
+		policy = StateTimeoutRetry
+	}
+	taskInfo.StateTimedOut = true
+	timeoutErr := fmt.Errorf("%w: %s", ErrStateTimeout, taskInfo.State)
+
+	s.logger.Warn().
+		Str("task_id", taskInfo.ID).
+		Str("type", taskInfo.Type).
+		Str("state", string(taskInfo.State)).
+		Str("policy", string(policy)).
+		Msg("task state timeout")
+
+	switch policy {
+	case StateTimeoutResume:
+		if err := s.requeueParked(ctx, taskInfo); err != nil {
+			s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to resume timed out task")
+		}
+	case StateTimeoutDead:
+		taskInfo.LastError = timeoutErr.Error()
+		s.deadLetter(ctx, taskInfo)
+	default:
+		s.handleTaskFailure(ctx, taskInfo, timeoutErr)
+	}
+}
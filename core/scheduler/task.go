@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -97,13 +98,15 @@ func WithPriority(priority Priority) TaskOption {
 func WithScheduleAt(scheduleAt time.Time) TaskOption {
 	return func(t *Task) {
 		t.ScheduleAt = scheduleAt
+		t.delay = 0
 	}
 }
 
-// WithDelay 设置延迟时间
+// WithDelay 设置延迟时间，提交时以调度器时钟为基准计算计划执行时间
 func WithDelay(delay time.Duration) TaskOption {
 	return func(t *Task) {
 		t.ScheduleAt = time.Now().Add(delay)
+		t.delay = delay
 	}
 }
 
@@ -114,6 +117,18 @@ func WithCron(cron string) TaskOption {
 	}
 }
 
+// WithCronOverlap 设置 Cron 重叠策略与最大并发实例数（<=0 时为 1）
+//
+// 启用后，下次执行在本次开始时即调度，保证按表达式准时触发；每个实例运行期间占用一个
+// 并发槽位（分布式锁），槽位已满时按策略跳过、排队或取消最早启动的实例。
+// 未启用时保持原有行为：下次执行在本次成功后才调度。
+func WithCronOverlap(policy OverlapPolicy, maxRunning int) TaskOption {
+	return func(t *Task) {
+		t.CronOverlap = policy
+		t.CronMaxRunning = maxRunning
+	}
+}
+
 // WithTaskTimeout 设置超时时间
 func WithTaskTimeout(timeout time.Duration) TaskOption {
 	return func(t *Task) {
@@ -121,6 +136,16 @@ func WithTaskTimeout(timeout time.Duration) TaskOption {
 	}
 }
 
+// WithExpiry 设置任务过期时间：任务到达计划执行时间 (立即执行的任务即提交时) 后超过 d 仍未开始执行时，
+// 自动从延迟/就绪队列移除并标记为 StatusExpired，不再执行；启用 WithDLQExpired 时同时加入死信队列。
+// 适用于时效性强的任务 (如通知)，避免积压恢复后延迟数小时才执行。
+// 仅约束首次执行，已开始执行的任务 (包括重试与自定义状态恢复) 不会过期
+func WithExpiry(d time.Duration) TaskOption {
+	return func(t *Task) {
+		t.Expiry = d
+	}
+}
+
 // WithTaskMaxRetry 设置最大重试次数
 func WithTaskMaxRetry(maxRetry int) TaskOption {
 	return func(t *Task) {
@@ -192,8 +217,20 @@ func (t *Task) Validate() error {
 	if t.MaxRetry < 0 {
 		return ErrInvalidMaxRetry
 	}
+	if t.Expiry < 0 {
+		return ErrInvalidExpiry
+	}
 	if t.Priority < PriorityLow || t.Priority > PriorityHigh {
 		return ErrInvalidPriority
 	}
+	switch t.CronOverlap {
+	case "":
+	case OverlapSkip, OverlapQueue, OverlapReplace:
+		if t.Cron == "" {
+			return fmt.Errorf("%w: requires a cron expression", ErrInvalidOverlapPolicy)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidOverlapPolicy, t.CronOverlap)
+	}
 	return nil
 }
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/kochabx/kit/log"
+)
+
+// truncatedMarker 输出超出上限时追加的标记行
+const truncatedMarker = "... output truncated\n"
+
+// taskLoggerKey TaskLogger 在 context 中的键
+type taskLoggerKey struct{}
+
+// TaskLogger 返回当前任务的日志记录器，仅在 handler 内有效。
+//
+// 启用 WithTaskLog 时，写入的日志照常输出到调度器日志记录器，同时在本次执行期间被捕获并保存，
+// 可通过 GetTaskInfo (任务仍在重试中) 或 GetTaskOutput (任务已成功/进入死信) 查看；
+// 未启用时等同于带 task_id 字段的调度器日志记录器。
+// 在 handler 之外调用时返回全局日志记录器。
+func TaskLogger(ctx context.Context) *log.Logger {
+	if l, ok := ctx.Value(taskLoggerKey{}).(*log.Logger); ok {
+		return l
+	}
+	return log.Global()
+}
+
+// taskOutput 有界的输出缓冲，超出上限后丢弃整条日志，避免截断出半行 JSON
+type taskOutput struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func newTaskOutput(max int) *taskOutput {
+	return &taskOutput{max: max}
+}
+
+// Write 实现 io.Writer，始终返回成功以免影响 handler
+func (o *taskOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.buf.Len()+len(p) > o.max {
+		o.truncated = true
+		return len(p), nil
+	}
+	o.buf.Write(p)
+	return len(p), nil
+}
+
+// String 返回捕获的输出
+func (o *taskOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.truncated {
+		return o.buf.String() + truncatedMarker
+	}
+	return o.buf.String()
+}
+
+// newTaskLogger 创建任务日志记录器；output 不为 nil 时在写入调度器日志的同时捕获一份副本
+func (w *Worker) newTaskLogger(taskInfo *TaskInfo, output *taskOutput) *log.Logger {
+	if output == nil {
+		return w.logger.Child(func(c zerolog.Context) zerolog.Context {
+			return c.Str("task_id", taskInfo.ID)
+		})
+	}
+	return w.logger.Child(func(c zerolog.Context) zerolog.Context {
+		return c.Str("task_id", taskInfo.ID).Int("attempt", taskInfo.RetryCount+1)
+	}).Tee(output)
+}
+
+// buildTaskOutputKey 构建任务输出key
+func (s *Scheduler) buildTaskOutputKey(taskID string) string {
+	return s.opts.Namespace + ":task:" + taskID + ":output"
+}
+
+// saveTaskOutput 保存任务输出，覆盖上一次执行的输出
+func (s *Scheduler) saveTaskOutput(ctx context.Context, taskID, output string) error {
+	if err := s.client.Set(ctx, s.buildTaskOutputKey(taskID), output, s.opts.TaskLog.TTL).Err(); err != nil {
+		return fmt.Errorf("failed to save task output: %w", err)
+	}
+	return nil
+}
+
+// GetTaskOutput 获取任务最近一次执行捕获的输出。
+// 任务信息按 Retention 配置删除或过期后，输出仍保留至 TaskLog.TTL 到期。
+func (s *Scheduler) GetTaskOutput(ctx context.Context, taskID string) (string, error) {
+	out, err := s.client.Get(ctx, s.buildTaskOutputKey(taskID)).Result()
+	if err == redis.Nil {
+		return "", ErrTaskNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get task output: %w", err)
+	}
+	return out, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kochabx/kit/log"
 	"github.com/panjf2000/ants/v2"
+	"github.com/rs/zerolog"
 )
 
 // Worker 工作节点
@@ -29,7 +30,13 @@ type Worker struct {
 	currentTaskID atomic.Value // string
 
 	// 协程池
-	pool *ants.Pool
+	pool        *ants.Pool
+	concurrency int
+	// 已取出尚未处理完的任务数，用于判断是否有空闲处理能力
+	inflight atomic.Int64
+
+	// 运行中 Cron 实例占用的并发槽位 (taskID -> *cronSlot)
+	cronSlots sync.Map
 }
 
 // taskItem 任务项
@@ -42,12 +49,16 @@ type taskItem struct {
 // NewWorker 创建Worker
 func NewWorker(scheduler *Scheduler) *Worker {
 	w := &Worker{
-		id:         "worker-" + uuid.New().String()[:8],
-		scheduler:  scheduler,
-		startTime:  time.Now(),
-		logger:     scheduler.logger,
-		taskBuffer: make(chan *taskItem, taskBufferSize),
+		id:        "worker-" + uuid.New().String()[:8],
+		scheduler: scheduler,
+		startTime: time.Now(),
+		logger:    scheduler.logger,
+	}
+	prefetch := scheduler.opts.Worker.PrefetchSize
+	if prefetch <= 0 {
+		prefetch = taskBufferSize
 	}
+	w.taskBuffer = make(chan *taskItem, prefetch)
 	w.currentTaskID.Store("")
 
 	// 创建协程池（非阻塞模式，满时返回错误由 processLoop fallback 同步处理）
@@ -61,6 +72,7 @@ func NewWorker(scheduler *Scheduler) *Worker {
 		pool, _ = ants.NewPool(concurrency, ants.WithNonblocking(true))
 	}
 	w.pool = pool
+	w.concurrency = concurrency
 
 	return w
 }
@@ -72,9 +84,9 @@ func (w *Worker) Start(ctx context.Context) error {
 	}
 
 	// 创建带worker_id的logger
-	w.logger = &log.Logger{
-		Logger: w.scheduler.logger.Logger.With().Str("worker_id", w.id).Logger(),
-	}
+	w.logger = w.scheduler.logger.Child(func(c zerolog.Context) zerolog.Context {
+		return c.Str("worker_id", w.id)
+	})
 	w.logger.Info().Msg("worker starting")
 
 	// 设置队列的消费者名称
@@ -163,7 +175,7 @@ func (w *Worker) register(ctx context.Context) error {
 		ID:            w.id,
 		StartTime:     w.startTime,
 		TaskCount:     0,
-		LastHeartbeat: time.Now(),
+		LastHeartbeat: w.scheduler.now(ctx),
 	}
 
 	// 使用对象池构建key
@@ -216,7 +228,7 @@ func (w *Worker) renew(ctx context.Context) error {
 	// 使用Pipeline批量更新
 	pipe := w.scheduler.client.Pipeline()
 	pipe.HSet(ctx, workerKey,
-		"last_heartbeat", time.Now().Unix(),
+		"last_heartbeat", w.scheduler.now(ctx).Unix(),
 		"task_count", w.taskCount.Load(),
 	)
 	pipe.Expire(ctx, workerKey, w.scheduler.opts.Worker.LeaseTTL)
@@ -232,6 +244,7 @@ func (w *Worker) renew(ctx context.Context) error {
 			w.logger.Warn().Err(extErr).Str("task_id", taskID).Msg("failed to extend task lock")
 		}
 	}
+	w.renewCronSlots(ctx)
 
 	return nil
 }
@@ -266,6 +279,7 @@ func (w *Worker) fetchLoop(ctx context.Context) {
 			// 发送到缓冲
 			select {
 			case w.taskBuffer <- &taskItem{taskID: taskID, priority: priority, msgID: msgID}:
+				w.scheduler.signalSteal()
 			case <-ctx.Done():
 				return
 			}
@@ -279,21 +293,88 @@ func (w *Worker) processLoop(ctx context.Context) {
 
 	w.logger.Info().Msg("worker process loop started")
 
-	for item := range w.taskBuffer {
+	for {
+		item, ok := w.nextItem()
+		if !ok {
+			break
+		}
 		// 使用协程池并发处理任务
+		w.inflight.Add(1)
 		err := w.pool.Submit(func() {
+			defer w.inflight.Add(-1)
 			w.handleTask(ctx, item)
 		})
 		if err != nil {
 			w.logger.Error().Err(err).Str("task_id", item.taskID).Msg("failed to submit task to pool")
 			// 如果提交失败，同步处理
 			w.handleTask(ctx, item)
+			w.inflight.Add(-1)
 		}
 	}
 
 	w.logger.Info().Msg("task buffer closed, exiting process loop")
 }
 
+// nextItem 获取下一个待处理任务：优先取本地缓冲，启用任务窃取时本地缓冲为空则尝试窃取，
+// 都没有时等待本地缓冲或窃取通知。本地缓冲关闭 (fetchLoop 退出) 后返回 false
+func (w *Worker) nextItem() (*taskItem, bool) {
+	if !w.scheduler.opts.Worker.WorkStealing {
+		item, ok := <-w.taskBuffer
+		return item, ok
+	}
+	for {
+		select {
+		case item, ok := <-w.taskBuffer:
+			return item, ok
+		default:
+		}
+		if item := w.steal(); item != nil {
+			return item, true
+		}
+		select {
+		case item, ok := <-w.taskBuffer:
+			return item, ok
+		case <-w.scheduler.stealSignal:
+		}
+	}
+}
+
+// steal 从同进程内缓冲积压最多的Worker取走最早预取的一个任务。
+// 仅在本Worker协程池有空闲时窃取，被窃取方缓冲内的任务仍按预取顺序出队；
+// 窃取后对方仍有积压时继续通知其他空闲Worker
+func (w *Worker) steal() *taskItem {
+	if w.inflight.Load() >= int64(w.concurrency) {
+		return nil
+	}
+	var victim *Worker
+	backlog := 0
+	for _, other := range w.scheduler.workers {
+		if other == w {
+			continue
+		}
+		if n := len(other.taskBuffer); n > backlog {
+			victim, backlog = other, n
+		}
+	}
+	if victim == nil {
+		return nil
+	}
+
+	select {
+	case item, ok := <-victim.taskBuffer:
+		if !ok {
+			return nil
+		}
+		w.scheduler.metrics.RecordTaskStolen()
+		if backlog > 1 {
+			w.scheduler.signalSteal()
+		}
+		return item
+	default:
+		return nil
+	}
+}
+
 // handleTask 处理单个任务（在协程池中执行）
 func (w *Worker) handleTask(ctx context.Context, item *taskItem) {
 	// 处理任务
@@ -356,11 +437,54 @@ func (w *Worker) processTask(ctx context.Context, taskID string) error {
 		return fmt.Errorf("get task info: %w", err)
 	}
 
+	// 过期检查：已被扫描丢弃的任务直接确认
+	if taskInfo.Status == StatusExpired {
+		return nil
+	}
+
+	// 类型已暂停：不执行，放回延迟队列稍后重新检查 (过期登记保留，暂停期间仍可过期)
+	if w.scheduler.queue.TypePaused(ctx, taskInfo.Type) {
+		return w.deferPaused(ctx, taskInfo)
+	}
+
+	// 超过过期时间仍未开始执行的任务不再执行
+	if expirable(taskInfo) {
+		if err := w.scheduler.queue.RemoveExpiring(ctx, taskID); err != nil {
+			w.logger.Warn().Err(err).Str("task_id", taskID).Msg("failed to remove task from expiring index")
+		}
+		if w.scheduler.isExpired(ctx, taskInfo) {
+			w.scheduler.markExpired(ctx, taskInfo)
+			return nil
+		}
+	}
+
+	// 从自定义状态恢复的执行，handler 可通过 ResumedFrom 获知
+	resumed := resumedState{state: taskInfo.State, timedOut: taskInfo.StateTimedOut}
+
+	// Cron 重叠控制：占用并发槽位，并在开始时即调度下次执行（重试与恢复的实例已调度过）
+	var slotKey string
+	if taskInfo.CronOverlap != "" {
+		if slotKey, err = w.acquireCronSlot(ctx, taskInfo); err != nil {
+			w.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to acquire cron slot")
+			return err
+		}
+		if slotKey == "" {
+			return nil
+		}
+		defer w.releaseCronSlot(ctx, taskID, slotKey)
+		if taskInfo.RetryCount == 0 && resumed.state == "" {
+			w.scheduler.scheduleNextCron(ctx, taskInfo)
+		}
+	}
+
 	// 更新任务状态为running
 	now := time.Now()
 	taskInfo.Status = StatusRunning
 	taskInfo.WorkerID = w.id
 	taskInfo.StartTime = &now
+	taskInfo.State = ""
+	taskInfo.StateDeadline = nil
+	taskInfo.StateTimedOut = false
 	if err := w.scheduler.saveTaskInfo(ctx, taskInfo); err != nil {
 		w.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to update task status")
 		return fmt.Errorf("update task status: %w", err)
@@ -370,13 +494,42 @@ func (w *Worker) processTask(ctx context.Context, taskID string) error {
 	handler, err := w.scheduler.registry.Get(taskInfo.Type)
 	if err != nil {
 		w.logger.Error().Err(err).Str("task_id", taskID).Str("type", taskInfo.Type).Msg("handler not found")
-		w.handleTaskFailure(ctx, taskInfo, ErrHandlerNotFound)
+		w.scheduler.handleTaskFailure(ctx, taskInfo, ErrHandlerNotFound)
 		return ErrHandlerNotFound
 	}
 
 	// 创建带超时的context
 	taskCtx, cancel := context.WithTimeout(ctx, taskInfo.Timeout)
 	defer cancel()
+	if slotKey != "" {
+		var cancelCause context.CancelCauseFunc
+		taskCtx, cancelCause = context.WithCancelCause(taskCtx)
+		defer cancelCause(nil)
+		w.trackCronSlot(taskID, slotKey, cancelCause)
+	}
+
+	// 注入任务日志记录器（按需捕获输出）
+	var output *taskOutput
+	if w.scheduler.opts.TaskLog.Enabled {
+		output = newTaskOutput(w.scheduler.opts.TaskLog.MaxSize)
+	}
+	taskLogger := w.newTaskLogger(taskInfo, output)
+	taskCtx = context.WithValue(taskCtx, taskLoggerKey{}, taskLogger)
+
+	// 注入成本计量（按需）
+	var meter *costMeter
+	if w.scheduler.opts.Cost.Enabled {
+		meter = &costMeter{}
+		taskCtx = context.WithValue(taskCtx, costMeterKey{}, meter)
+	}
+
+	// 注入自定义状态请求与恢复信息
+	stateReq := &stateRequest{states: w.scheduler.opts.States}
+	taskCtx = context.WithValue(taskCtx, stateRequestKey{}, stateReq)
+	if resumed.state != "" {
+		taskCtx = context.WithValue(taskCtx, resumedStateKey{}, resumed)
+	}
+	handlerStart := time.Now()
 
 	// 执行任务（带panic恢复）
 	var execErr error
@@ -391,20 +544,57 @@ func (w *Worker) processTask(ctx context.Context, taskID string) error {
 		// 调用 handler.handle (闭包函数)
 		execErr = handler.handle(taskCtx, taskInfo.Payload)
 	}()
+	handlerDuration := time.Since(handlerStart)
 
 	// 计算执行时长
 	executionTime := time.Since(startTime)
 	taskInfo.ExecutionTime = &executionTime
 
+	// 记录执行成本，时长仅计 handler 本身，不含加锁与读写任务信息
+	if meter != nil {
+		meter.mu.Lock()
+		units := meter.units
+		meter.mu.Unlock()
+		if err := w.scheduler.recordCost(ctx, costExecution{
+			taskType: taskInfo.Type,
+			tenant:   taskInfo.Tags[TenantTag],
+			failed:   execErr != nil,
+			retry:    taskInfo.RetryCount > 0,
+			duration: handlerDuration,
+			payload:  len(taskInfo.Payload),
+			units:    units,
+		}); err != nil {
+			w.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to record task cost")
+		}
+	}
+
+	// 保存捕获的输出，失败原因一并记录
+	if output != nil {
+		if execErr != nil {
+			taskLogger.Error().Err(execErr).Msg("task failed")
+		}
+		taskInfo.Output = output.String()
+		if err := w.scheduler.saveTaskOutput(ctx, taskID, taskInfo.Output); err != nil {
+			w.logger.Error().Err(err).Str("task_id", taskID).Msg("failed to save task output")
+		}
+	}
+
 	// 处理执行结果
-	if execErr != nil {
+	if execErr != nil && context.Cause(taskCtx) == ErrCronReplaced {
+		w.handleCronReplaced(ctx, taskInfo)
+	} else if execErr != nil {
 		if execErr == context.DeadlineExceeded {
 			w.logger.Warn().Str("task_id", taskID).Str("type", taskInfo.Type).Dur("timeout", taskInfo.Timeout).Msg("task timeout")
 			execErr = ErrTaskTimeout
 		}
-		w.handleTaskFailure(ctx, taskInfo, execErr)
+		w.scheduler.handleTaskFailure(ctx, taskInfo, execErr)
+	} else if state, timeout := stateReq.get(); state != "" {
+		if err := w.scheduler.parkTask(ctx, taskInfo, state, timeout); err != nil {
+			w.logger.Error().Err(err).Str("task_id", taskID).Str("state", string(state)).Msg("failed to park task")
+			w.scheduler.handleTaskFailure(ctx, taskInfo, err)
+		}
 	} else {
-		w.handleTaskSuccess(ctx, taskInfo)
+		w.scheduler.handleTaskSuccess(ctx, taskInfo)
 	}
 
 	// 增加任务计数
@@ -419,8 +609,8 @@ func (w *Worker) processTask(ctx context.Context, taskID string) error {
 }
 
 // handleTaskSuccess 处理任务成功
-func (w *Worker) handleTaskSuccess(ctx context.Context, taskInfo *TaskInfo) {
-	w.logger.Info().
+func (s *Scheduler) handleTaskSuccess(ctx context.Context, taskInfo *TaskInfo) {
+	s.logger.Info().
 		Str("task_id", taskInfo.ID).
 		Str("type", taskInfo.Type).
 		Str("duration", taskInfo.ExecutionTime.String()).
@@ -430,29 +620,33 @@ func (w *Worker) handleTaskSuccess(ctx context.Context, taskInfo *TaskInfo) {
 	taskInfo.Status = StatusSuccess
 	taskInfo.FinishTime = &now
 
-	// 删除任务信息
-	if err := w.scheduler.deleteTaskInfo(ctx, taskInfo.ID); err != nil {
-		w.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to delete task info")
+	// 按配置保留或删除任务信息
+	if ttl := s.opts.Retention.Success; ttl > 0 {
+		if err := s.retainTaskInfo(ctx, taskInfo, ttl); err != nil {
+			s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to save task info")
+		}
+	} else if err := s.deleteTaskInfo(ctx, taskInfo.ID); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to delete task info")
 	}
 
 	// 记录指标
-	if w.scheduler.metrics.enabled {
-		w.scheduler.metrics.RecordTaskExecuted(
+	if s.metrics.enabled {
+		s.metrics.RecordTaskExecuted(
 			taskInfo.Type,
 			StatusSuccess,
 			taskInfo.ExecutionTime.Seconds(),
 		)
 	}
 
-	// 如果是Cron任务，计算下次执行时间
-	if taskInfo.Cron != "" {
-		w.scheduler.scheduleNextCron(ctx, taskInfo)
+	// 如果是Cron任务，计算下次执行时间（启用重叠策略的在开始时已调度）
+	if taskInfo.Cron != "" && taskInfo.CronOverlap == "" {
+		s.scheduleNextCron(ctx, taskInfo)
 	}
 }
 
 // handleTaskFailure 处理任务失败
-func (w *Worker) handleTaskFailure(ctx context.Context, taskInfo *TaskInfo, err error) {
-	w.logger.Warn().
+func (s *Scheduler) handleTaskFailure(ctx context.Context, taskInfo *TaskInfo, err error) {
+	s.logger.Warn().
 		Str("task_id", taskInfo.ID).
 		Str("type", taskInfo.Type).
 		Int("retry_count", taskInfo.RetryCount).
@@ -463,21 +657,21 @@ func (w *Worker) handleTaskFailure(ctx context.Context, taskInfo *TaskInfo, err
 	taskInfo.LastError = err.Error()
 
 	// 记录指标
-	if w.scheduler.metrics.enabled {
-		w.scheduler.metrics.RecordTaskExecuted(
+	if s.metrics.enabled {
+		s.metrics.RecordTaskExecuted(
 			taskInfo.Type,
 			StatusFailed,
 			taskInfo.ExecutionTime.Seconds(),
 		)
-		w.scheduler.metrics.RecordTaskRetry(taskInfo.Type, taskInfo.RetryCount)
+		s.metrics.RecordTaskRetry(taskInfo.Type, taskInfo.RetryCount)
 	}
 
 	// 检查是否需要重试
 	if taskInfo.RetryCount < taskInfo.MaxRetry {
 		// 计算重试延迟
-		retryDelay := w.scheduler.retryStrategy.NextRetry(taskInfo.RetryCount)
+		retryDelay := s.retryStrategy.NextRetry(taskInfo.RetryCount)
 
-		w.logger.Info().
+		s.logger.Info().
 			Str("task_id", taskInfo.ID).
 			Int("retry_count", taskInfo.RetryCount).
 			Dur("delay", retryDelay).
@@ -485,44 +679,49 @@ func (w *Worker) handleTaskFailure(ctx context.Context, taskInfo *TaskInfo, err
 
 		// 重新加入延迟队列
 		taskInfo.Status = StatusPending
-		taskInfo.ScheduleAt = time.Now().Add(retryDelay)
+		taskInfo.ScheduleAt = s.now(ctx).Add(retryDelay)
 		taskInfo.StartTime = nil
 		taskInfo.FinishTime = nil
 
-		if err := w.scheduler.saveTaskInfo(ctx, taskInfo); err != nil {
-			w.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to save task info")
+		if err := s.saveTaskInfo(ctx, taskInfo); err != nil {
+			s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to save task info")
 		}
 
 		// 加入延迟队列
-		if err := w.scheduler.queue.AddDelayed(ctx, taskInfo.ID, float64(taskInfo.ScheduleAt.Unix())); err != nil {
-			w.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to add task to delayed queue")
+		if err := s.queue.AddDelayed(ctx, taskInfo.ID, float64(taskInfo.ScheduleAt.Unix())); err != nil {
+			s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to add task to delayed queue")
 		}
 	} else {
 		// 超过最大重试次数，加入死信队列
-		w.logger.Error().
+		s.logger.Error().
 			Str("task_id", taskInfo.ID).
 			Str("type", taskInfo.Type).
 			Int("retry_count", taskInfo.RetryCount).
 			Msg("task exceeded max retries, moving to DLQ")
 
-		now := time.Now()
-		taskInfo.Status = StatusDead
-		taskInfo.FinishTime = &now
+		s.deadLetter(ctx, taskInfo)
+	}
+}
+
+// deadLetter 将任务标记为死信并加入死信队列
+func (s *Scheduler) deadLetter(ctx context.Context, taskInfo *TaskInfo) {
+	now := time.Now()
+	taskInfo.Status = StatusDead
+	taskInfo.FinishTime = &now
 
-		// 删除任务信息
-		if err := w.scheduler.deleteTaskInfo(ctx, taskInfo.ID); err != nil {
-			w.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to delete task info")
-		}
+	// 保留任务信息供死信排查，到期后自动清理
+	if err := s.retainTaskInfo(ctx, taskInfo, s.opts.Retention.Terminal); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to save task info")
+	}
 
-		// 加入死信队列
-		if err := w.scheduler.dlq.Add(ctx, taskInfo.ID); err != nil {
-			w.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to add task to DLQ")
-		}
+	// 加入死信队列
+	if err := s.dlq.Add(ctx, taskInfo.ID); err != nil {
+		s.logger.Error().Err(err).Str("task_id", taskInfo.ID).Msg("failed to add task to DLQ")
+	}
 
-		// 更新死信队列指标
-		if w.scheduler.metrics.enabled {
-			count, _ := w.scheduler.dlq.Count(ctx)
-			w.scheduler.metrics.RecordDeadLetterCount(float64(count))
-		}
+	// 更新死信队列指标
+	if s.metrics.enabled {
+		count, _ := s.dlq.Count(ctx)
+		s.metrics.RecordDeadLetterCount(float64(count))
 	}
 }
@@ -0,0 +1,125 @@
+package wsx
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// VerifyPeerCertificateFunc 自定义服务端证书校验，签名与 tls.Config.VerifyPeerCertificate 一致：
+// rawCerts 为对端发送的证书链 (DER)，verifiedChains 为标准校验通过的证书链
+// (InsecureSkipVerify 时为 nil)。返回非 nil 错误将中止握手。
+type VerifyPeerCertificateFunc func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+
+// CertificateError 服务端证书校验失败的详情：Connect 返回的错误可用 errors.As 取得，
+// 同时作为 EventCertificateError 的 event.Data，供安全监控记录。
+type CertificateError struct {
+	// Host 连接的主机名
+	Host string
+	// Fingerprints 对端证书链的 SHA-256 指纹 (小写十六进制)，叶子证书在前；无法获取时为空
+	Fingerprints []string
+	// Err 校验失败的原因，证书固定或自定义校验拒绝时包装 ErrCertificateRejected
+	Err error
+}
+
+func (e *CertificateError) Error() string {
+	return fmt.Sprintf("wsx: certificate verification failed for %s: %v", e.Host, e.Err)
+}
+
+func (e *CertificateError) Unwrap() error { return e.Err }
+
+// Fingerprint 返回证书 (DER) 的 SHA-256 指纹，格式与 WithPinnedCertificates 一致。
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// parsePin 规范化证书指纹：忽略大小写与冒号分隔。
+func parsePin(pin string) (string, error) {
+	s := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(pin), ":", ""))
+	if b, err := hex.DecodeString(s); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("wsx: invalid certificate pin %q", pin)
+	}
+	return s, nil
+}
+
+// verifyPeer 组合证书固定、自定义校验与 tls.Config 原有的 VerifyPeerCertificate。
+//
+// 证书固定要求对端证书链中至少一张证书的指纹在 pins 中：标准校验通过时检查各条已校验的链
+// (可固定根或中间证书)；InsecureSkipVerify 时没有已校验的链，只检查叶子证书 (自签名证书场景)。
+func verifyPeer(pins map[string]struct{}, custom, prev VerifyPeerCertificateFunc) VerifyPeerCertificateFunc {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		reject := func(err error) error {
+			fps := make([]string, len(rawCerts))
+			for i, der := range rawCerts {
+				fps[i] = Fingerprint(der)
+			}
+			return &CertificateError{Fingerprints: fps, Err: fmt.Errorf("%w: %w", ErrCertificateRejected, err)}
+		}
+
+		if len(pins) > 0 && !pinned(pins, rawCerts, verifiedChains) {
+			return reject(errors.New("no certificate in chain matches a pin"))
+		}
+		if custom != nil {
+			if err := custom(rawCerts, verifiedChains); err != nil {
+				return reject(err)
+			}
+		}
+		if prev != nil {
+			return prev(rawCerts, verifiedChains)
+		}
+		return nil
+	}
+}
+
+// pinned 判断证书链是否命中 pins。
+func pinned(pins map[string]struct{}, rawCerts [][]byte, verifiedChains [][]*x509.Certificate) bool {
+	if len(verifiedChains) == 0 {
+		if len(rawCerts) == 0 {
+			return false
+		}
+		_, ok := pins[Fingerprint(rawCerts[0])]
+		return ok
+	}
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			if _, ok := pins[Fingerprint(cert.Raw)]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// certificateError 从握手错误中提取证书校验失败的详情，非证书错误返回 nil。
+func certificateError(err error, host string) *CertificateError {
+	var ce *CertificateError
+	if errors.As(err, &ce) {
+		out := *ce
+		out.Host = host
+		return &out
+	}
+	var ve *tls.CertificateVerificationError
+	if errors.As(err, &ve) {
+		fps := make([]string, len(ve.UnverifiedCertificates))
+		for i, cert := range ve.UnverifiedCertificates {
+			fps[i] = Fingerprint(cert.Raw)
+		}
+		return &CertificateError{Host: host, Fingerprints: fps, Err: ve.Err}
+	}
+	return nil
+}
+
+// hostname 返回 URL 中的主机名，解析失败时返回原串。
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Hostname()
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -20,6 +23,15 @@ type Client struct {
 	dialer    *websocket.Dialer
 	tlsConfig *tls.Config
 
+	// 握手相关选项，New 中应用到 dialer
+	proxy        func(*http.Request) (*url.URL, error)
+	netDial      func(ctx context.Context, network, addr string) (net.Conn, error)
+	subprotocols []string
+	pins         []string
+	verifyPeer   VerifyPeerCertificateFunc
+	// pins 格式错误时由 Connect 返回
+	pinErr error
+
 	// 生命周期
 	ctx       context.Context
 	cancel    context.CancelFunc
@@ -29,16 +41,45 @@ type Client struct {
 	mu                    sync.RWMutex
 	url                   string
 	conn                  *websocket.Conn
+	subprotocol           string
 	connCancel            context.CancelFunc
 	handlers              map[EventType][]EventHandler
+	events                *eventDispatcher
 	connected             bool
 	closed                bool
 	reconnecting          bool
 	intentionalDisconnect bool
 	retryCount            int
+	// 由空闲 / 最大时长策略关闭连接时设置，供 onConnectionLost 使用
+	disconnectReason string
+	recycling        bool
+
+	// 最近一次收发业务消息的时间 (UnixNano)
+	lastActivity atomic.Int64
+
+	// 连接健康统计，见 Stats
+	stats clientStats
 
 	// 写队列；New 中按 WriteQueueSize 创建
 	writeChan chan Message
+
+	// 重连期间的发送缓冲 (受 mu 保护)，见 WithSendBuffer
+	buffering bool
+	pending   []Message
+
+	resumeToken func() string
+
+	// 信封编解码器及与当前连接对端协商后的实例
+	codec   *Codec
+	session atomic.Pointer[Codec]
+
+	// 请求 / 响应关联，见 SendRequest
+	rpc            *Correlator
+	requestHandler RequestHandler
+
+	// 主题消息编解码器及各 topic 的处理函数 (受 mu 保护)，见 OnTopic
+	topicCodec TopicCodec
+	topics     map[string]topicHandler
 }
 
 // 编译期保证 *Client 满足 Clienter
@@ -48,10 +89,12 @@ var _ Clienter = (*Client)(nil)
 func New(opts ...Option) *Client {
 	ctx, cancel := context.WithCancel(context.Background())
 	c := &Client{
-		config:   DefaultConfig(),
-		handlers: make(map[EventType][]EventHandler),
-		ctx:      ctx,
-		cancel:   cancel,
+		config:     DefaultConfig(),
+		handlers:   make(map[EventType][]EventHandler),
+		topicCodec: JSONTopicCodec(),
+		topics:     make(map[string]topicHandler),
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 
 	for _, opt := range opts {
@@ -61,6 +104,8 @@ func New(opts ...Option) *Client {
 	if c.writeChan == nil {
 		c.writeChan = make(chan Message, c.config.WriteQueueSize)
 	}
+	c.rpc = NewCorrelator(c.config.RequestTimeout)
+	c.events = newEventDispatcher(c.config.EventWorkers, c.config.EventQueueSize, c.config.EventOverflow)
 
 	if c.dialer == nil {
 		c.dialer = &websocket.Dialer{
@@ -73,10 +118,44 @@ func New(opts ...Option) *Client {
 	if c.tlsConfig != nil {
 		c.dialer.TLSClientConfig = c.tlsConfig
 	}
+	if len(c.pins) > 0 || c.verifyPeer != nil {
+		c.applyVerifyPeer()
+	}
+	if c.proxy != nil {
+		c.dialer.Proxy = c.proxy
+	}
+	if c.netDial != nil {
+		c.dialer.NetDialContext = c.netDial
+	}
+	if c.subprotocols != nil {
+		c.dialer.Subprotocols = c.subprotocols
+	}
 
 	return c
 }
 
+// applyVerifyPeer 在 dialer 的 TLS 配置副本上安装证书固定与自定义校验。
+func (c *Client) applyVerifyPeer() {
+	pins := make(map[string]struct{}, len(c.pins))
+	for _, p := range c.pins {
+		pin, err := parsePin(p)
+		if err != nil {
+			c.pinErr = err
+			return
+		}
+		pins[pin] = struct{}{}
+	}
+
+	var cfg *tls.Config
+	if c.dialer.TLSClientConfig != nil {
+		cfg = c.dialer.TLSClientConfig.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+	cfg.VerifyPeerCertificate = verifyPeer(pins, c.verifyPeer, cfg.VerifyPeerCertificate)
+	c.dialer.TLSClientConfig = cfg
+}
+
 // Connect 见 Clienter.Connect。
 func (c *Client) Connect(ctx context.Context, wsURL string) error {
 	c.mu.Lock()
@@ -88,6 +167,10 @@ func (c *Client) Connect(ctx context.Context, wsURL string) error {
 		c.mu.Unlock()
 		return ErrAlreadyConnected
 	}
+	if c.pinErr != nil {
+		c.mu.Unlock()
+		return c.pinErr
+	}
 
 	u, err := url.Parse(wsURL)
 	if err != nil {
@@ -113,8 +196,22 @@ func (c *Client) dial(ctx context.Context) error {
 		ctx = c.ctx
 	}
 
-	conn, _, err := c.dialer.DialContext(ctx, c.url, c.headers)
+	headers := c.headers
+	if c.resumeToken != nil {
+		if token := c.resumeToken(); token != "" {
+			if headers = headers.Clone(); headers == nil {
+				headers = http.Header{}
+			}
+			headers.Set(ResumeTokenHeader, token)
+		}
+	}
+
+	conn, _, err := c.dialer.DialContext(ctx, c.url, headers)
 	if err != nil {
+		if certErr := certificateError(err, hostname(c.url)); certErr != nil {
+			err = certErr
+			c.emitEvent(Event{Type: EventCertificateError, Data: certErr, Error: certErr, Timestamp: time.Now()})
+		}
 		c.emitEvent(Event{
 			Type:      EventError,
 			Error:     fmt.Errorf("wsx: dial failed: %w", err),
@@ -133,14 +230,22 @@ func (c *Client) dial(ctx context.Context) error {
 		return ErrClientClosed
 	}
 	c.conn = conn
+	c.subprotocol = conn.Subprotocol()
 	c.connected = true
 	c.reconnecting = false
 	c.connCancel = connCancel
 	c.mu.Unlock()
+	c.lastActivity.Store(time.Now().UnixNano())
+	c.stats.connectedAt.Store(time.Now().UnixNano())
+	// 新连接需重新协商，先于读循环重置
+	if c.codec != nil {
+		c.session.Store(c.codec)
+	}
 
 	conn.SetReadLimit(c.config.MaxMessageSize)
 	_ = conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
-	conn.SetPongHandler(func(string) error {
+	conn.SetPongHandler(func(appData string) error {
+		c.stats.observePong([]byte(appData), time.Now())
 		return conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
 	})
 
@@ -149,6 +254,17 @@ func (c *Client) dial(ctx context.Context) error {
 	if c.config.PingInterval > 0 {
 		go c.pingLoop(conn, connCtx)
 	}
+	if c.config.IdleTimeout > 0 || c.config.MaxConnectionAge > 0 {
+		go c.watchLoop(conn, connCtx)
+	}
+
+	// Hello 先于重连期间缓冲的消息发送
+	if c.codec != nil {
+		if hello, err := c.codec.Hello(); err == nil {
+			_ = c.enqueue(connCtx, Message{Type: TextMessage, Data: hello})
+		}
+	}
+	c.flushPending(connCtx)
 
 	c.emitEvent(Event{Type: EventConnected, Timestamp: time.Now()})
 	return nil
@@ -175,15 +291,25 @@ func (c *Client) readLoop(conn *websocket.Conn, ctx context.Context, cancel cont
 		if messageType == websocket.CloseMessage {
 			return
 		}
+		now := time.Now().UnixNano()
+		c.lastActivity.Store(now)
+		c.stats.lastMessage.Store(now)
+		c.stats.messagesIn.Add(1)
+		c.stats.bytesIn.Add(uint64(len(data)))
+
+		if messageType == websocket.TextMessage && c.rpc.Handle(ctx, data, c.requestHandler, c.sendText) {
+			continue
+		}
+		msg := Message{Type: MessageType(messageType), Data: data}
+		if c.handleTopic(msg) {
+			continue
+		}
+		if c.codec != nil && messageType == websocket.TextMessage {
+			c.handleEnvelope(data)
+			continue
+		}
 
-		c.emitEvent(Event{
-			Type: EventMessage,
-			Data: Message{
-				Type: MessageType(messageType),
-				Data: data,
-			},
-			Timestamp: time.Now(),
-		})
+		c.emitEvent(Event{Type: EventMessage, Data: msg, Timestamp: time.Now()})
 	}
 }
 
@@ -208,6 +334,9 @@ func (c *Client) writeLoop(conn *websocket.Conn, ctx context.Context) {
 				}
 				return
 			}
+			c.lastActivity.Store(time.Now().UnixNano())
+			c.stats.messagesOut.Add(1)
+			c.stats.bytesOut.Add(uint64(len(msg.Data)))
 		}
 	}
 }
@@ -221,8 +350,9 @@ func (c *Client) pingLoop(conn *websocket.Conn, ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-t.C:
-			deadline := time.Now().Add(c.config.WriteTimeout)
-			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+			now := time.Now()
+			deadline := now.Add(c.config.WriteTimeout)
+			if err := conn.WriteControl(websocket.PingMessage, c.stats.pingPayload(now), deadline); err != nil {
 				if ctx.Err() == nil {
 					c.emitEvent(Event{
 						Type:      EventError,
@@ -236,6 +366,83 @@ func (c *Client) pingLoop(conn *websocket.Conn, ctx context.Context) {
 	}
 }
 
+// watchLoop 执行空闲检测与最大连接时长策略，随连接一同退出。
+func (c *Client) watchLoop(conn *websocket.Conn, ctx context.Context) {
+	var ageC, idleC <-chan time.Time
+	if age := c.config.MaxConnectionAge; age > 0 {
+		// ±10% 抖动，避免同一时刻建立的连接同时回收
+		jitter := time.Duration((rand.Float64()*0.2 - 0.1) * float64(age))
+		t := time.NewTimer(age + jitter)
+		defer t.Stop()
+		ageC = t.C
+	}
+	var idleTimer *time.Timer
+	idleTimeout := c.config.IdleTimeout
+	if idleTimeout > 0 {
+		idleTimer = time.NewTimer(idleTimeout)
+		defer idleTimer.Stop()
+		idleC = idleTimer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ageC:
+			c.closeByPolicy(conn, DisconnectReasonMaxAge)
+			return
+		case <-idleC:
+			idle := time.Since(time.Unix(0, c.lastActivity.Load()))
+			if idle < idleTimeout {
+				idleTimer.Reset(idleTimeout - idle)
+				continue
+			}
+			if c.config.IdleAction == IdleKeepAlive {
+				c.emitEvent(Event{
+					Type:      EventIdle,
+					Data:      map[string]any{"idle": idle},
+					Timestamp: time.Now(),
+				})
+				idleTimer.Reset(idleTimeout)
+				continue
+			}
+			c.closeByPolicy(conn, DisconnectReasonIdle)
+			return
+		}
+	}
+}
+
+// closeByPolicy 按策略关闭 conn：空闲关闭不触发重连，最大时长回收则立即重连。
+// 若 conn 已不是当前连接则忽略。
+func (c *Client) closeByPolicy(conn *websocket.Conn, reason string) {
+	c.mu.Lock()
+	if c.conn != conn || !c.connected {
+		c.mu.Unlock()
+		return
+	}
+	c.disconnectReason = reason
+	code := websocket.CloseNormalClosure
+	if reason == DisconnectReasonMaxAge {
+		c.recycling = true
+		code = websocket.CloseGoingAway
+	} else {
+		c.intentionalDisconnect = true
+	}
+	cancel := c.connCancel
+	c.mu.Unlock()
+
+	_ = conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(time.Second),
+	)
+	// 先取消 ctx，使 readLoop 不把本次关闭报告为读错误
+	if cancel != nil {
+		cancel()
+	}
+	_ = conn.Close()
+}
+
 // onConnectionLost 在 readLoop 退出后被调用，负责状态清理及触发重连。
 func (c *Client) onConnectionLost() {
 	c.mu.Lock()
@@ -244,6 +451,7 @@ func (c *Client) onConnectionLost() {
 		return
 	}
 	c.connected = false
+	c.stats.connectedAt.Store(0)
 	conn := c.conn
 	c.conn = nil
 	if c.connCancel != nil {
@@ -252,21 +460,59 @@ func (c *Client) onConnectionLost() {
 	}
 	intentional := c.intentionalDisconnect
 	c.intentionalDisconnect = false
+	reason := c.disconnectReason
+	c.disconnectReason = ""
+	recycle := c.recycling && !intentional
+	c.recycling = false
+	if recycle {
+		c.reconnecting = true
+	}
 	closed := c.closed
 	enableReconnect := c.config.Reconnect.Enable
+	// 即将自动重连时开始缓冲 Send，否则丢弃未发出的缓冲
+	c.buffering = c.config.SendBufferSize > 0 && !closed && (recycle || (!intentional && enableReconnect))
+	if !c.buffering {
+		c.pending = nil
+	}
 	c.mu.Unlock()
 
 	if conn != nil {
 		_ = conn.Close()
 	}
+	// 响应不会跨连接到达，等待中的请求立即失败
+	c.rpc.Fail()
 
-	c.emitEvent(Event{Type: EventDisconnected, Timestamp: time.Now()})
+	event := Event{Type: EventDisconnected, Timestamp: time.Now()}
+	if reason != "" {
+		event.Data = map[string]any{"reason": reason}
+	}
+	c.emitEvent(event)
 
-	if !intentional && !closed && enableReconnect {
+	switch {
+	case closed:
+	case recycle:
+		go c.recycle(enableReconnect)
+	case !intentional && enableReconnect:
 		go c.runReconnect()
 	}
 }
 
+// recycle 在连接达到最大时长后立即重建连接，失败时回退到常规重连流程。
+func (c *Client) recycle(enableReconnect bool) {
+	if err := c.dial(c.ctx); err == nil {
+		c.stats.reconnects.Add(1)
+		return
+	}
+	if enableReconnect {
+		c.runReconnect()
+		return
+	}
+	c.mu.Lock()
+	c.reconnecting = false
+	c.dropPendingLocked()
+	c.mu.Unlock()
+}
+
 // runReconnect 在独立 goroutine 中实现带退避的自动重连。
 func (c *Client) runReconnect() {
 	for {
@@ -277,6 +523,8 @@ func (c *Client) runReconnect() {
 		}
 		rc := c.config.Reconnect
 		if rc.MaxRetries > 0 && c.retryCount >= rc.MaxRetries {
+			c.reconnecting = false
+			c.dropPendingLocked()
 			c.mu.Unlock()
 			c.emitEvent(Event{
 				Type:      EventError,
@@ -308,7 +556,9 @@ func (c *Client) runReconnect() {
 			return
 		}
 
+		c.stats.attempts.Add(1)
 		if err := c.dial(c.ctx); err == nil {
+			c.stats.reconnects.Add(1)
 			c.mu.Lock()
 			c.retryCount = 0
 			c.mu.Unlock()
@@ -337,11 +587,18 @@ func (c *Client) Send(messageType MessageType, data []byte) error {
 	c.mu.RLock()
 	closed := c.closed
 	connected := c.connected
+	buffering := c.buffering
 	c.mu.RUnlock()
 
 	if closed {
 		return ErrClientClosed
 	}
+	if buffering {
+		if handled, err := c.buffer(Message{Type: messageType, Data: data}); handled {
+			return err
+		}
+		connected = c.IsConnected()
+	}
 	if !connected {
 		return ErrNotConnected
 	}
@@ -359,6 +616,115 @@ func (c *Client) Send(messageType MessageType, data []byte) error {
 	}
 }
 
+// buffer 在重连期间缓冲消息；缓冲已结束 (连接恢复且已清空) 时返回 handled=false。
+func (c *Client) buffer(msg Message) (handled bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.buffering {
+		return false, nil
+	}
+	if len(c.pending) >= c.config.SendBufferSize {
+		if c.config.SendBufferOverflow != OverflowDropOldest {
+			return true, ErrSendBufferFull
+		}
+		c.pending = c.pending[1:]
+	}
+	c.pending = append(c.pending, msg)
+	return true, nil
+}
+
+// flushPending 将缓冲的消息按序写入写队列，期间新的 Send 继续进入缓冲以保证顺序，
+// 缓冲清空后结束缓冲状态。连接在此期间再次断开时，未写入的消息放回缓冲。
+func (c *Client) flushPending(ctx context.Context) {
+	for {
+		c.mu.Lock()
+		batch := c.pending
+		c.pending = nil
+		if len(batch) == 0 {
+			c.buffering = false
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+
+		for i, msg := range batch {
+			if err := c.enqueue(ctx, msg); err != nil {
+				c.mu.Lock()
+				if c.buffering {
+					c.pending = append(batch[i:], c.pending...)
+				}
+				c.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// dropPendingLocked 放弃重连时结束缓冲，调用方需持有 mu。
+func (c *Client) dropPendingLocked() {
+	c.buffering = false
+	c.pending = nil
+}
+
+// enqueue 将消息放入写队列，阻塞直到成功或 ctx 结束。
+func (c *Client) enqueue(ctx context.Context, msg Message) error {
+	select {
+	case c.writeChan <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SendEnvelope 以 typ 类型的信封发送 payload，需配置 WithCodec。
+func (c *Client) SendEnvelope(typ string, payload any) error {
+	codec := c.session.Load()
+	if codec == nil {
+		if codec = c.codec; codec == nil {
+			return ErrNoCodec
+		}
+	}
+	data, err := codec.Encode(typ, payload)
+	if err != nil {
+		return err
+	}
+	return c.Send(TextMessage, data)
+}
+
+// SendRequest 发送一个请求并等待对端的响应，可并发调用：
+//
+//	resp, err := client.SendRequest(ctx, []byte(`{"op":"offer","sdp":"…"}`))
+//
+// 请求附带关联 ID，对端通过 WithRequestHandler (服务端为 transport/websocket 的 WithOnRequest)
+// 处理后回复；请求与响应帧不会触发 EventMessage / EventEnvelope。
+// ctx 未设置截止时间时使用 Config.RequestTimeout。连接断开时返回 ErrNotConnected，
+// 对端处理失败时返回 *RemoteError。
+func (c *Client) SendRequest(ctx context.Context, payload []byte) ([]byte, error) {
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+	return c.rpc.Request(ctx, payload, c.sendText)
+}
+
+func (c *Client) sendText(data []byte) error { return c.Send(TextMessage, data) }
+
+// handleEnvelope 解码信封消息；对端的 Hello 用于协商，不对外派发。
+func (c *Client) handleEnvelope(data []byte) {
+	env, err := c.session.Load().Decode(data)
+	if err == nil && env.Type == HelloType {
+		var session *Codec
+		if session, err = c.codec.WithPeer(env); err == nil {
+			c.session.Store(session)
+			return
+		}
+	}
+	if err != nil {
+		c.emitEvent(Event{Type: EventError, Error: err, Timestamp: time.Now()})
+		return
+	}
+	c.emitEvent(Event{Type: EventEnvelope, Data: env, Timestamp: time.Now()})
+}
+
 // SendText 见 Clienter.SendText。
 func (c *Client) SendText(text string) error {
 	return c.Send(TextMessage, []byte(text))
@@ -402,6 +768,7 @@ func (c *Client) Close() error {
 		c.mu.Lock()
 		c.closed = true
 		c.intentionalDisconnect = true
+		c.dropPendingLocked()
 		conn := c.conn
 		cancel := c.connCancel
 		c.mu.Unlock()
@@ -419,6 +786,7 @@ func (c *Client) Close() error {
 		if cancel != nil {
 			cancel()
 		}
+		c.events.release()
 	})
 	return nil
 }
@@ -447,6 +815,13 @@ func (c *Client) IsConnected() bool {
 	return c.connected
 }
 
+// Subprotocol 返回最近一次握手时服务端选中的子协议，见 WithSubprotocols。
+func (c *Client) Subprotocol() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.subprotocol
+}
+
 // Config 见 Clienter.Config。
 func (c *Client) Config() Config {
 	c.mu.RLock()
@@ -454,7 +829,7 @@ func (c *Client) Config() Config {
 	return c.config
 }
 
-// emitEvent 异步派发事件，避免阻塞 read/write 循环。
+// emitEvent 异步派发事件，避免阻塞 read/write 循环；处理函数在有界协程池上执行，见 WithEventDispatch。
 // 在 RLock 内复制处理器切片，避免在调用期间被并发修改。
 func (c *Client) emitEvent(event Event) {
 	c.mu.RLock()
@@ -467,9 +842,5 @@ func (c *Client) emitEvent(event Event) {
 	copy(cp, handlers)
 	c.mu.RUnlock()
 
-	go func() {
-		for _, h := range cp {
-			h(event)
-		}
-	}()
+	c.events.dispatch(event, cp)
 }
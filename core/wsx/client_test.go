@@ -1,14 +1,25 @@
 package wsx
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -449,3 +460,931 @@ func TestPostmanEcho_LongRunningMessageReceiving(t *testing.T) {
 	assert.True(t, finalCount > 0, "应该至少收到一条消息")
 	t.Log("🎉 长时间消息接收测试完成！")
 }
+
+// newLocalEchoServer 启动本地 echo 服务端，返回 ws:// 地址及已接受的连接数。
+func newLocalEchoServer(t *testing.T) (string, *atomic.Int64) {
+	t.Helper()
+	var accepted atomic.Int64
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		accepted.Add(1)
+		defer conn.Close()
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(mt, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), &accepted
+}
+
+// waitEvent 等待事件或超时。
+func waitEvent(t *testing.T, ch <-chan Event, what string) Event {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout waiting for %s", what)
+		return Event{}
+	}
+}
+
+func TestIdleTimeout_Close(t *testing.T) {
+	url, accepted := newLocalEchoServer(t)
+	client := New(WithIdleTimeout(150*time.Millisecond, IdleClose), WithPingInterval(0))
+	defer client.Close()
+
+	disconnected := make(chan Event, 1)
+	client.OnEvent(EventDisconnected, func(e Event) { disconnected <- e })
+	var reconnecting atomic.Bool
+	client.OnEvent(EventReconnecting, func(Event) { reconnecting.Store(true) })
+
+	require.NoError(t, client.Connect(context.Background(), url))
+
+	// 业务消息会推迟空闲判定
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, client.SendText("ping"))
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, client.IsConnected())
+
+	e := waitEvent(t, disconnected, "idle disconnect")
+	assert.Equal(t, map[string]any{"reason": DisconnectReasonIdle}, e.Data)
+	assert.False(t, client.IsConnected())
+
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, reconnecting.Load(), "idle close must not trigger reconnect")
+	assert.EqualValues(t, 1, accepted.Load())
+
+	// 可再次手动连接
+	require.NoError(t, client.Connect(context.Background(), url))
+	assert.True(t, client.IsConnected())
+}
+
+func TestIdleTimeout_KeepAlive(t *testing.T) {
+	url, _ := newLocalEchoServer(t)
+	client := New(WithIdleTimeout(100*time.Millisecond, IdleKeepAlive), WithPingInterval(30*time.Millisecond))
+	defer client.Close()
+
+	idle := make(chan Event, 4)
+	client.OnEvent(EventIdle, func(e Event) {
+		select {
+		case idle <- e:
+		default:
+		}
+	})
+
+	require.NoError(t, client.Connect(context.Background(), url))
+
+	e := waitEvent(t, idle, "idle event")
+	assert.GreaterOrEqual(t, e.Data.(map[string]any)["idle"].(time.Duration), 100*time.Millisecond)
+	waitEvent(t, idle, "second idle event")
+	assert.True(t, client.IsConnected())
+}
+
+func TestMaxConnectionAge_Recycle(t *testing.T) {
+	url, accepted := newLocalEchoServer(t)
+	client := New(
+		WithMaxConnectionAge(150*time.Millisecond),
+		WithReconnect(ReconnectConfig{Enable: false}),
+	)
+	defer client.Close()
+
+	connected := make(chan Event, 4)
+	client.OnEvent(EventConnected, func(e Event) { connected <- e })
+	disconnected := make(chan Event, 4)
+	client.OnEvent(EventDisconnected, func(e Event) { disconnected <- e })
+
+	require.NoError(t, client.Connect(context.Background(), url))
+	waitEvent(t, connected, "initial connect")
+
+	// 回收不依赖 Reconnect.Enable，并立即重建连接
+	e := waitEvent(t, disconnected, "max age disconnect")
+	assert.Equal(t, map[string]any{"reason": DisconnectReasonMaxAge}, e.Data)
+	waitEvent(t, connected, "reconnect after recycle")
+	assert.True(t, client.IsConnected())
+	assert.GreaterOrEqual(t, accepted.Load(), int64(2))
+
+	require.NoError(t, client.SendText("hello"))
+}
+
+// ---------------------------------------------------------------------------
+// 信封
+// ---------------------------------------------------------------------------
+
+// 用户消息 v1: {"name": "..."}；v2: {"first": "...", "last": "..."}
+func userCodec(version int) *Codec {
+	opts := []CodecOption{WithSchema("user", version)}
+	if version >= 2 {
+		opts = append(opts,
+			WithUpgrade("user", 1, func(p json.RawMessage) (json.RawMessage, error) {
+				var v1 struct{ Name string }
+				if err := json.Unmarshal(p, &v1); err != nil {
+					return nil, err
+				}
+				first, last, _ := strings.Cut(v1.Name, " ")
+				return json.Marshal(map[string]string{"first": first, "last": last})
+			}),
+			WithDowngrade("user", 2, func(p json.RawMessage) (json.RawMessage, error) {
+				var v2 struct{ First, Last string }
+				if err := json.Unmarshal(p, &v2); err != nil {
+					return nil, err
+				}
+				return json.Marshal(map[string]string{"name": v2.First + " " + v2.Last})
+			}),
+		)
+	}
+	return NewCodec(opts...)
+}
+
+func TestCodec_Versions(t *testing.T) {
+	v1, v2 := userCodec(1), userCodec(2)
+
+	// 旧版本消息在接收端升级
+	data, err := v1.Encode("user", map[string]string{"name": "Ada Lovelace"})
+	require.NoError(t, err)
+	env, err := v2.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, 2, env.Version)
+	assert.NotEmpty(t, env.ID)
+	assert.WithinDuration(t, time.Now(), env.Time(), time.Second)
+	assert.JSONEq(t, `{"first":"Ada","last":"Lovelace"}`, string(env.Payload))
+
+	// 新版本消息无法被旧版本解码
+	data, err = v2.Encode("user", map[string]string{"first": "Ada", "last": "Lovelace"})
+	require.NoError(t, err)
+	_, err = v1.Decode(data)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	// 协商后按对端版本降级发送
+	hello, err := v1.Hello()
+	require.NoError(t, err)
+	helloEnv, err := v2.Decode(hello)
+	require.NoError(t, err)
+	session, err := v2.WithPeer(helloEnv)
+	require.NoError(t, err)
+	data, err = session.Encode("user", map[string]string{"first": "Ada", "last": "Lovelace"})
+	require.NoError(t, err)
+	env, err = v1.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, 1, env.Version)
+	var user struct{ Name string }
+	require.NoError(t, env.UnmarshalPayload(&user))
+	assert.Equal(t, "Ada Lovelace", user.Name)
+}
+
+func TestCodec_Middleware(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	aesMW, err := AESGCMPayload(key)
+	require.NoError(t, err)
+	validate := ValidateEnvelope(func(env *Envelope) error {
+		if env.Type == "chat" && !json.Valid(env.Payload) {
+			return fmt.Errorf("payload is not json")
+		}
+		return nil
+	})
+	codec := NewCodec(WithEnvelopeMiddleware(validate, GzipPayload(0), aesMW))
+
+	data, err := codec.Encode("chat", map[string]string{"text": strings.Repeat("secret ", 20)})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.Contains(t, string(data), `"enc":["gzip","aes-gcm"]`)
+
+	env, err := codec.Decode(data)
+	require.NoError(t, err)
+	assert.Empty(t, env.Encoding)
+	var msg struct{ Text string }
+	require.NoError(t, env.UnmarshalPayload(&msg))
+	assert.Equal(t, strings.Repeat("secret ", 20), msg.Text)
+
+	// 未注册编码的接收端
+	_, err = NewCodec().Decode(data)
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+
+	// 密钥不匹配
+	other, err := AESGCMPayload([]byte("fedcba9876543210"))
+	require.NoError(t, err)
+	_, err = NewCodec(WithEnvelopeMiddleware(GzipPayload(0), other)).Decode(data)
+	assert.Error(t, err)
+
+	_, err = codec.Decode([]byte(`{"version":1}`))
+	assert.ErrorIs(t, err, ErrInvalidEnvelope)
+}
+
+func TestClient_SendEnvelope(t *testing.T) {
+	url, _ := newLocalEchoServer(t)
+	client := New(WithCodec(userCodec(2)), WithPingInterval(0))
+	defer client.Close()
+
+	assert.ErrorIs(t, New().SendEnvelope("user", nil), ErrNoCodec)
+
+	envelopes := make(chan Event, 1)
+	client.OnEvent(EventEnvelope, func(e Event) { envelopes <- e })
+	require.NoError(t, client.Connect(context.Background(), url))
+
+	// echo 服务端回送的 Hello 用于协商，不会派发
+	require.NoError(t, client.SendEnvelope("user", map[string]string{"first": "Ada", "last": "Lovelace"}))
+	env := waitEvent(t, envelopes, "envelope").Data.(*Envelope)
+	assert.Equal(t, "user", env.Type)
+	assert.Equal(t, 2, env.Version)
+	assert.JSONEq(t, `{"first":"Ada","last":"Lovelace"}`, string(env.Payload))
+}
+
+func TestClient_SendRequest(t *testing.T) {
+	url, _ := newLocalEchoServer(t)
+	// echo 服务端把请求帧原样回送，客户端自身的 handler 处理后再经回送得到响应
+	client := New(
+		WithPingInterval(0),
+		WithRequestTimeout(200*time.Millisecond),
+		WithRequestHandler(func(ctx context.Context, payload []byte) ([]byte, error) {
+			switch string(payload) {
+			case "fail":
+				return nil, errors.New("boom")
+			case "slow":
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return bytes.ToUpper(payload), nil
+		}),
+	)
+	defer client.Close()
+
+	_, err := client.SendRequest(context.Background(), []byte("hi"))
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	messages := make(chan Event, 1)
+	client.OnEvent(EventMessage, func(e Event) { messages <- e })
+	require.NoError(t, client.Connect(context.Background(), url))
+
+	resp, err := client.SendRequest(context.Background(), []byte("hi"))
+	require.NoError(t, err)
+	assert.Equal(t, "HI", string(resp))
+
+	_, err = client.SendRequest(context.Background(), []byte("fail"))
+	var remote *RemoteError
+	require.ErrorAs(t, err, &remote)
+	assert.Equal(t, "boom", remote.Message)
+
+	_, err = client.SendRequest(context.Background(), []byte("slow"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 请求 / 响应帧不作为普通消息派发
+	select {
+	case e := <-messages:
+		t.Fatalf("unexpected message event: %s", e.Data.(Message).Data)
+	default:
+	}
+}
+
+func TestClient_Topics(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	for name, codec := range map[string]TopicCodec{
+		"json":   JSONTopicCodec(),
+		"binary": BinaryTopicCodec(json.Marshal, json.Unmarshal), // 代替 msgpack / protobuf
+	} {
+		t.Run(name, func(t *testing.T) {
+			url, _ := newLocalEchoServer(t)
+			client := New(WithPingInterval(0), WithTopicCodec(codec))
+			defer client.Close()
+
+			users := make(chan user, 1)
+			OnTopic(client, "user", func(u user) { users <- u })
+			messages := make(chan Event, 1)
+			client.OnEvent(EventMessage, func(e Event) { messages <- e })
+			errs := make(chan Event, 1)
+			client.OnEvent(EventError, func(e Event) { errs <- e })
+			require.NoError(t, client.Connect(context.Background(), url))
+
+			// echo 服务端原样回送，由 OnTopic 注册的处理函数接收
+			require.NoError(t, client.SendTopic("user", user{Name: "ada"}))
+			select {
+			case u := <-users:
+				assert.Equal(t, "ada", u.Name)
+			case <-time.After(2 * time.Second):
+				t.Fatal("topic handler not called")
+			}
+
+			// 未注册的 topic 仍作为普通消息派发
+			require.NoError(t, client.SendTopic("other", 1))
+			e := waitEvent(t, messages, "message")
+			topic, _, ok := codec.Decode(e.Data.(Message))
+			assert.True(t, ok)
+			assert.Equal(t, "other", topic)
+
+			// payload 无法反序列化为 T 时触发 EventError
+			require.NoError(t, client.SendTopic("user", []int{1}))
+			e = waitEvent(t, errs, "error")
+			assert.ErrorContains(t, e.Error, "unmarshal topic user")
+
+			client.RemoveTopic("user")
+			require.NoError(t, client.SendTopic("user", user{Name: "bob"}))
+			waitEvent(t, messages, "message")
+		})
+	}
+}
+
+func TestTopicCodec_Decode(t *testing.T) {
+	_, _, ok := JSONTopicCodec().Decode(Message{Type: TextMessage, Data: []byte(`{"type":"x"}`)})
+	assert.False(t, ok)
+	_, err := JSONTopicCodec().Encode("", 1)
+	assert.Error(t, err)
+
+	codec := BinaryTopicCodec(json.Marshal, json.Unmarshal)
+	for _, data := range [][]byte{nil, {0}, {5, 'a'}, {0xff, 0xff, 0xff}} {
+		_, _, ok := codec.Decode(Message{Type: BinaryMessage, Data: data})
+		assert.False(t, ok, "%v", data)
+	}
+	msg, err := codec.Encode("t", "v")
+	require.NoError(t, err)
+	topic, payload, ok := codec.Decode(msg)
+	assert.True(t, ok)
+	assert.Equal(t, "t", topic)
+	assert.Equal(t, `"v"`, string(payload))
+}
+
+func TestClient_SendBufferDuringReconnect(t *testing.T) {
+	for _, tt := range []struct {
+		policy OverflowPolicy
+		want   []string
+	}{
+		{OverflowReject, []string{"a", "b"}},
+		{OverflowDropOldest, []string{"b", "c"}},
+	} {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			var conns atomic.Int64
+			received := make(chan string, 10)
+			tokens := make(chan string, 2)
+			upgrader := websocket.Upgrader{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				tokens <- r.Header.Get(ResumeTokenHeader)
+				if conns.Add(1) == 1 {
+					// 第一条连接立即关闭，触发重连
+					_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+					return
+				}
+				for {
+					_, data, err := conn.ReadMessage()
+					if err != nil {
+						return
+					}
+					received <- string(data)
+				}
+			}))
+			defer srv.Close()
+
+			var session atomic.Value
+			session.Store("")
+			client := New(
+				WithPingInterval(0),
+				WithReconnect(ReconnectConfig{Enable: true, Interval: 300 * time.Millisecond}),
+				WithSendBuffer(2, tt.policy),
+				WithResumeToken(func() string { return session.Load().(string) }),
+			)
+			defer client.Close()
+			disconnected := make(chan Event, 1)
+			client.OnEvent(EventDisconnected, func(e Event) { disconnected <- e })
+			connected := make(chan Event, 2)
+			client.OnEvent(EventConnected, func(e Event) { connected <- e })
+
+			require.NoError(t, client.Connect(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")))
+			waitEvent(t, connected, "connected")
+			assert.Equal(t, "", <-tokens)
+			session.Store("session-1")
+			waitEvent(t, disconnected, "disconnected")
+
+			require.NoError(t, client.SendText("a"))
+			require.NoError(t, client.SendText("b"))
+			err := client.SendText("c")
+			if tt.policy == OverflowReject {
+				assert.ErrorIs(t, err, ErrSendBufferFull)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			waitEvent(t, connected, "reconnected")
+			assert.Equal(t, "session-1", <-tokens)
+			require.NoError(t, client.SendText("d"))
+			for _, want := range append(tt.want, "d") {
+				select {
+				case got := <-received:
+					assert.Equal(t, want, got)
+				case <-time.After(2 * time.Second):
+					t.Fatalf("timeout waiting for %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_SendBufferDisabled(t *testing.T) {
+	url, _ := newLocalEchoServer(t)
+	client := New(WithPingInterval(0))
+	defer client.Close()
+	assert.ErrorIs(t, client.SendText("x"), ErrNotConnected)
+
+	// 主动断开不进入缓冲
+	buffered := New(WithPingInterval(0), WithSendBuffer(10, OverflowReject))
+	defer buffered.Close()
+	require.NoError(t, buffered.Connect(context.Background(), url))
+	require.NoError(t, buffered.Disconnect())
+	require.Eventually(t, func() bool { return !buffered.IsConnected() }, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, buffered.SendText("x"), ErrNotConnected)
+}
+
+func TestClient_DialOptions(t *testing.T) {
+	upgrader := websocket.Upgrader{Subprotocols: []string{"chat.v2"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer srv.Close()
+
+	// 最简 CONNECT 代理
+	var proxied atomic.Int64
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		proxied.Add(1)
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		_, _ = io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	var dialed atomic.Int64
+	client := New(
+		WithPingInterval(0),
+		WithProxy(proxyURL),
+		WithNetDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed.Add(1)
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}),
+		WithSubprotocols("chat.v1", "chat.v2"),
+	)
+	defer client.Close()
+
+	require.NoError(t, client.Connect(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")))
+	assert.Equal(t, "chat.v2", client.Subprotocol())
+	assert.Equal(t, int64(1), proxied.Load())
+	assert.Equal(t, int64(1), dialed.Load())
+}
+
+func TestClient_Stats(t *testing.T) {
+	url, _ := newLocalEchoServer(t)
+	client := New(
+		WithPingInterval(30*time.Millisecond),
+		WithMaxConnectionAge(300*time.Millisecond),
+	)
+	defer client.Close()
+
+	messages := make(chan Event, 4)
+	client.OnEvent(EventMessage, func(e Event) { messages <- e })
+
+	assert.Equal(t, Stats{}, client.Stats())
+	start := time.Now()
+	require.NoError(t, client.Connect(context.Background(), url))
+	require.NoError(t, client.SendText("hello"))
+	waitEvent(t, messages, "echo")
+
+	s := client.Stats()
+	assert.True(t, s.Connected)
+	assert.False(t, s.ConnectedAt.Before(start))
+	assert.Equal(t, uint64(1), s.MessagesOut)
+	assert.Equal(t, uint64(1), s.MessagesIn)
+	assert.Equal(t, uint64(5), s.BytesOut)
+	assert.Equal(t, uint64(5), s.BytesIn)
+	assert.False(t, s.LastMessageAt.Before(start))
+
+	// RTT 由 ping/pong 测得
+	require.Eventually(t, func() bool { return client.Stats().RTT > 0 }, 2*time.Second, 10*time.Millisecond)
+	s = client.Stats()
+	assert.Greater(t, s.SmoothedRTT, time.Duration(0))
+	assert.Less(t, s.RTT, time.Second)
+	assert.False(t, s.LastPingAt.IsZero())
+	assert.False(t, s.LastPongAt.IsZero())
+
+	// 最大时长回收计入重连次数，计数器跨重连累计
+	require.Eventually(t, func() bool { return client.Stats().Reconnects >= 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), client.Stats().MessagesIn)
+}
+
+func TestPool(t *testing.T) {
+	url1, accepted1 := newLocalEchoServer(t)
+	url2, accepted2 := newLocalEchoServer(t)
+	pool := NewPool([]string{url1, url2}, WithPoolSize(2), WithPoolCheckInterval(0))
+	defer pool.Close()
+
+	messages := make(chan PoolEvent, 8)
+	pool.OnEvent(EventMessage, func(e PoolEvent) { messages <- e })
+
+	require.NoError(t, pool.Connect(context.Background()))
+	assert.Equal(t, int64(2), accepted1.Load())
+	assert.Equal(t, int64(2), accepted2.Load())
+	assert.Equal(t, 4, pool.Healthy())
+
+	// 轮询依次经过每个成员
+	seen := make(map[*Client]bool)
+	for range 4 {
+		c, err := pool.Next()
+		require.NoError(t, err)
+		seen[c] = true
+	}
+	assert.Len(t, seen, 4)
+
+	// 聚合事件携带来源
+	assert.Equal(t, 4, pool.Broadcast(TextMessage, []byte("hi")))
+	urls := make(map[string]int)
+	for range 4 {
+		select {
+		case e := <-messages:
+			assert.Equal(t, "hi", string(e.Data.(Message).Data))
+			urls[e.URL]++
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for pool message")
+		}
+	}
+	assert.Equal(t, map[string]int{url1: 2, url2: 2}, urls)
+
+	// 粘滞选择稳定，成员不可用时迁移
+	sticky, err := pool.Sticky("user-42")
+	require.NoError(t, err)
+	again, _ := pool.Sticky("user-42")
+	assert.Same(t, sticky, again)
+
+	require.NoError(t, sticky.Close())
+	assert.Equal(t, 3, pool.Healthy())
+	moved, err := pool.Sticky("user-42")
+	require.NoError(t, err)
+	assert.NotSame(t, sticky, moved)
+	for range 6 {
+		c, err := pool.Next()
+		require.NoError(t, err)
+		assert.NotSame(t, sticky, c)
+	}
+	unhealthy := 0
+	for _, m := range pool.Members() {
+		if !m.Healthy {
+			unhealthy++
+			assert.False(t, m.Stats.Connected)
+		}
+	}
+	assert.Equal(t, 1, unhealthy)
+}
+
+func TestPool_Supervise(t *testing.T) {
+	var ready atomic.Bool
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	down := "ws" + strings.TrimPrefix(srv.URL, "http")
+	up, _ := newLocalEchoServer(t)
+
+	// 全部失败时返回错误
+	failing := NewPool([]string{down}, WithPoolCheckInterval(0))
+	assert.Error(t, failing.Connect(context.Background()))
+	_, err := failing.Next()
+	assert.ErrorIs(t, err, ErrNoHealthyMember)
+	require.NoError(t, failing.Close())
+
+	// 部分失败时返回 nil，失败成员由巡检恢复
+	pool := NewPool([]string{up, down}, WithPoolCheckInterval(20*time.Millisecond))
+	defer pool.Close()
+	connected := make(chan PoolEvent, 4)
+	pool.OnEvent(EventConnected, func(e PoolEvent) { connected <- e })
+
+	require.NoError(t, pool.Connect(context.Background()))
+	<-connected
+	assert.Equal(t, 1, pool.Healthy())
+
+	ready.Store(true)
+	select {
+	case e := <-connected:
+		assert.Equal(t, down, e.URL)
+		assert.Equal(t, 1, e.Member)
+	case <-time.After(2 * time.Second):
+		t.Fatal("member was not reconnected")
+	}
+	assert.Equal(t, 2, pool.Healthy())
+}
+
+func TestPool_HealthCheck(t *testing.T) {
+	url, _ := newLocalEchoServer(t)
+	var degraded atomic.Bool
+	pool := NewPool([]string{url},
+		WithPoolCheckInterval(0),
+		WithPoolHealthCheck(func(Stats) bool { return !degraded.Load() }),
+	)
+	defer pool.Close()
+	require.NoError(t, pool.Connect(context.Background()))
+	require.NoError(t, pool.Send(TextMessage, []byte("ok")))
+
+	degraded.Store(true)
+	assert.ErrorIs(t, pool.SendSticky("k", TextMessage, []byte("x")), ErrNoHealthyMember)
+	assert.Equal(t, 0, pool.Broadcast(TextMessage, []byte("x")))
+}
+
+func TestClient_CertificatePinning(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	url := "wss" + strings.TrimPrefix(srv.URL, "https")
+	leaf := Fingerprint(srv.Certificate().Raw)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	connect := func(opts ...Option) (error, chan Event) {
+		events := make(chan Event, 4)
+		client := New(opts...)
+		t.Cleanup(func() { client.Close() })
+		client.OnEvent(EventCertificateError, func(e Event) { events <- e })
+		return client.Connect(context.Background(), url), events
+	}
+
+	t.Run("pinned", func(t *testing.T) {
+		err, events := connect(WithTLSConfig(&tls.Config{RootCAs: roots}), WithPinnedCertificates("00:"+strings.Repeat("11", 31), strings.ToUpper(leaf)))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		// 自签名场景：跳过标准校验，只比对叶子证书
+		err, _ = connect(WithTLSConfig(&tls.Config{InsecureSkipVerify: true}), WithPinnedCertificates(leaf))
+		require.NoError(t, err)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		err, events := connect(WithTLSConfig(&tls.Config{RootCAs: roots}), WithPinnedCertificates(strings.Repeat("ab", 32)))
+		require.ErrorIs(t, err, ErrCertificateRejected)
+		var certErr *CertificateError
+		require.ErrorAs(t, err, &certErr)
+		assert.Equal(t, "127.0.0.1", certErr.Host)
+		assert.Equal(t, leaf, certErr.Fingerprints[0])
+		assert.Equal(t, certErr, waitEvent(t, events, "certificate error").Data)
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		err, events := connect()
+		var certErr *CertificateError
+		require.ErrorAs(t, err, &certErr)
+		assert.NotErrorIs(t, err, ErrCertificateRejected)
+		assert.Equal(t, []string{leaf}, certErr.Fingerprints)
+		waitEvent(t, events, "certificate error")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		errRevoked := errors.New("revoked")
+		err, events := connect(
+			WithTLSConfig(&tls.Config{RootCAs: roots}),
+			WithVerifyPeerCertificate(func(_ [][]byte, chains [][]*x509.Certificate) error {
+				assert.NotEmpty(t, chains)
+				return errRevoked
+			}),
+		)
+		require.ErrorIs(t, err, ErrCertificateRejected)
+		require.ErrorIs(t, err, errRevoked)
+		waitEvent(t, events, "certificate error")
+	})
+
+	t.Run("invalid pin", func(t *testing.T) {
+		err, events := connect(WithPinnedCertificates("not-a-fingerprint"))
+		require.ErrorContains(t, err, "invalid certificate pin")
+		assert.Empty(t, events)
+	})
+}
+
+func TestEventDispatcher_OrderingAndBound(t *testing.T) {
+	d := newEventDispatcher(2, 1000, OverflowReject)
+	defer d.release()
+
+	var (
+		mu      sync.Mutex
+		got     = make(map[EventType][]int)
+		active  atomic.Int32
+		peak    atomic.Int32
+		pending sync.WaitGroup
+	)
+	handler := func(e Event) {
+		defer pending.Done()
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		got[e.Type] = append(got[e.Type], e.Data.(int))
+		mu.Unlock()
+		active.Add(-1)
+	}
+
+	types := []EventType{EventMessage, EventError, EventEnvelope, EventIdle}
+	for i := range 50 {
+		for _, typ := range types {
+			pending.Add(1)
+			d.dispatch(Event{Type: typ, Data: i}, []EventHandler{handler})
+		}
+	}
+	pending.Wait()
+
+	assert.LessOrEqual(t, peak.Load(), int32(2), "concurrency must not exceed workers")
+	for _, typ := range types {
+		require.Len(t, got[typ], 50)
+		for i, v := range got[typ] {
+			assert.Equal(t, i, v, "events of type %s must keep dispatch order", typ)
+		}
+	}
+	assert.Eventually(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.running == 0 && d.queued == 0
+	}, time.Second, 10*time.Millisecond, "workers should exit when idle")
+}
+
+func TestEventDispatcher_Overflow(t *testing.T) {
+	for _, tt := range []struct {
+		policy OverflowPolicy
+		want   []int
+	}{
+		{OverflowDropOldest, []int{0, 3, 4}},
+		{OverflowReject, []int{0, 1, 2}},
+	} {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			d := newEventDispatcher(1, 2, tt.policy)
+			defer d.release()
+
+			block := make(chan struct{})
+			var (
+				mu  sync.Mutex
+				got []int
+			)
+			handler := func(e Event) {
+				if e.Data.(int) == 0 {
+					<-block
+				}
+				mu.Lock()
+				got = append(got, e.Data.(int))
+				mu.Unlock()
+			}
+
+			// 事件 0 占住唯一的协程，1..4 进入容量为 2 的队列
+			d.dispatch(Event{Type: EventMessage, Data: 0}, []EventHandler{handler})
+			require.Eventually(t, func() bool { return d.busy.Load() == 1 }, time.Second, time.Millisecond)
+			for i := 1; i <= 4; i++ {
+				d.dispatch(Event{Type: EventMessage, Data: i}, []EventHandler{handler})
+			}
+			assert.Equal(t, 2, d.queuedCount())
+			assert.Equal(t, uint64(2), d.dropped.Load())
+
+			close(block)
+			require.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(got) == 3
+			}, time.Second, time.Millisecond)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEventDispatcher_HandlerPanic(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		released bool // 协程池已释放，回退为普通 goroutine
+	}{
+		{"pool", false},
+		{"goroutine", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newEventDispatcher(1, 16, OverflowReject)
+			defer d.release()
+			if tt.released {
+				d.release()
+			}
+
+			var (
+				mu  sync.Mutex
+				got []int
+			)
+			panicky := func(e Event) {
+				if e.Data.(int) == 0 {
+					panic("boom")
+				}
+			}
+			record := func(e Event) {
+				mu.Lock()
+				got = append(got, e.Data.(int))
+				mu.Unlock()
+			}
+
+			// 事件 0 的第一个处理函数 panic，不影响其后的处理函数与后续事件
+			for i := range 3 {
+				d.dispatch(Event{Type: EventMessage, Data: i}, []EventHandler{panicky, record})
+			}
+			require.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(got) == 3
+			}, time.Second, time.Millisecond)
+			assert.Equal(t, []int{0, 1, 2}, got)
+			assert.Equal(t, uint64(1), d.panics.Load())
+
+			// 计数与调度状态已复位，之后的事件照常处理
+			require.Eventually(t, func() bool {
+				d.mu.Lock()
+				defer d.mu.Unlock()
+				return d.running == 0 && !d.queues[EventMessage].scheduled
+			}, time.Second, time.Millisecond)
+			assert.Equal(t, int64(0), d.busy.Load())
+
+			d.dispatch(Event{Type: EventMessage, Data: 3}, []EventHandler{record})
+			require.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(got) == 4
+			}, time.Second, time.Millisecond)
+		})
+	}
+}
+
+func TestClient_EventDispatchStats(t *testing.T) {
+	c := New(WithEventDispatch(1, 1, OverflowReject))
+	defer c.Close()
+
+	block := make(chan struct{})
+	c.OnEvent(EventMessage, func(Event) { <-block })
+	for range 3 {
+		c.emitEvent(Event{Type: EventMessage})
+	}
+	require.Eventually(t, func() bool { return c.Stats().EventWorkersBusy == 1 }, time.Second, time.Millisecond)
+	s := c.Stats()
+	assert.Equal(t, 1, s.EventsQueued)
+	assert.Equal(t, uint64(1), s.EventsDropped)
+	close(block)
+	assert.Eventually(t, func() bool { return c.Stats().EventsQueued == 0 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,178 @@
+package wsx
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/panjf2000/ants/v2"
+
+	"github.com/kochabx/kit/log"
+)
+
+const (
+	// DefaultEventWorkers 事件处理协程数上限的默认值
+	DefaultEventWorkers = 8
+	// DefaultEventQueueSize 每种事件类型待处理事件上限的默认值
+	DefaultEventQueueSize = 1024
+)
+
+// eventDispatcher 在有界协程池上执行事件处理函数：
+//   - 同一事件类型的事件按派发顺序串行处理，不同类型之间并发
+//   - 每种类型的待处理事件有上限，满时按 OverflowPolicy 丢弃，消息风暴不会拖垮生命周期事件
+//   - 处理协程数不超过 workers，空闲时退出
+//   - 处理函数 panic 时记录日志并计数，不影响同一事件的其他处理函数与该类型的后续事件
+type eventDispatcher struct {
+	workers   int
+	queueSize int
+	overflow  OverflowPolicy
+	pool      *ants.Pool
+
+	mu      sync.Mutex
+	queues  map[EventType]*eventQueue
+	ready   []EventType // 有待处理事件且未被协程领取的类型，按先后轮转
+	running int         // 已启动的处理协程数
+	queued  int         // 待处理事件总数
+
+	busy    atomic.Int64
+	dropped atomic.Uint64
+	panics  atomic.Uint64
+}
+
+// eventQueue 单个事件类型的待处理事件。
+type eventQueue struct {
+	items []eventItem
+	// scheduled 类型已在 ready 中或正被处理，保证同一类型同时只有一个协程处理
+	scheduled bool
+}
+
+// eventItem 待处理事件及派发时的处理函数快照。
+type eventItem struct {
+	event    Event
+	handlers []EventHandler
+}
+
+func newEventDispatcher(workers, queueSize int, overflow OverflowPolicy) *eventDispatcher {
+	if workers <= 0 {
+		workers = DefaultEventWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultEventQueueSize
+	}
+	if overflow == "" {
+		overflow = OverflowDropOldest
+	}
+	d := &eventDispatcher{
+		workers:   workers,
+		queueSize: queueSize,
+		overflow:  overflow,
+		queues:    make(map[EventType]*eventQueue),
+	}
+	// 创建失败时 pool 为 nil，由 spawn 回退为普通 goroutine，数量同样受 workers 限制
+	d.pool, _ = ants.NewPool(workers, ants.WithNonblocking(true))
+	return d
+}
+
+// dispatch 将事件加入其类型的队列，必要时启动处理协程；不会阻塞调用方。
+func (d *eventDispatcher) dispatch(event Event, handlers []EventHandler) {
+	d.mu.Lock()
+	q, ok := d.queues[event.Type]
+	if !ok {
+		q = &eventQueue{}
+		d.queues[event.Type] = q
+	}
+	if len(q.items) >= d.queueSize {
+		d.dropped.Add(1)
+		if d.overflow != OverflowDropOldest {
+			d.mu.Unlock()
+			return
+		}
+		q.items[0] = eventItem{}
+		q.items = q.items[1:]
+		d.queued--
+	}
+	q.items = append(q.items, eventItem{event: event, handlers: handlers})
+	d.queued++
+
+	spawn := false
+	if !q.scheduled {
+		q.scheduled = true
+		d.ready = append(d.ready, event.Type)
+		if d.running < d.workers {
+			d.running++
+			spawn = true
+		}
+	}
+	d.mu.Unlock()
+
+	if spawn {
+		d.spawn()
+	}
+}
+
+// spawn 启动一个处理协程；协程池已释放 (Close 之后仍有事件) 时使用普通 goroutine。
+func (d *eventDispatcher) spawn() {
+	if d.pool != nil && d.pool.Submit(d.work) == nil {
+		return
+	}
+	go d.work()
+}
+
+// work 轮流处理就绪的事件类型：每处理一个事件后，将仍有事件的类型移到末尾，
+// 避免单一类型的事件风暴饿死其他类型。没有就绪类型时退出。
+func (d *eventDispatcher) work() {
+	for {
+		d.mu.Lock()
+		if len(d.ready) == 0 {
+			d.running--
+			d.mu.Unlock()
+			return
+		}
+		typ := d.ready[0]
+		d.ready = d.ready[1:]
+		q := d.queues[typ]
+		item := q.items[0]
+		q.items[0] = eventItem{}
+		q.items = q.items[1:]
+		d.queued--
+		d.mu.Unlock()
+
+		d.busy.Add(1)
+		for _, h := range item.handlers {
+			d.handle(h, item.event)
+		}
+		d.busy.Add(-1)
+
+		d.mu.Lock()
+		if len(q.items) > 0 {
+			d.ready = append(d.ready, typ)
+		} else {
+			q.scheduled = false
+		}
+		d.mu.Unlock()
+	}
+}
+
+// handle 执行单个处理函数并恢复其 panic，保证 work 的计数与调度状态总能复位。
+func (d *eventDispatcher) handle(h EventHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.panics.Add(1)
+			log.Error().Str("event", string(event.Type)).Interface("panic", r).Msg("wsx: event handler panic")
+		}
+	}()
+	h(event)
+}
+
+// queuedCount 返回待处理事件总数。
+func (d *eventDispatcher) queuedCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.queued
+}
+
+// release 释放协程池中的空闲协程；已入队的事件仍会被处理。
+func (d *eventDispatcher) release() {
+	if d.pool != nil {
+		d.pool.Release()
+	}
+}
@@ -0,0 +1,386 @@
+package wsx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HelloType 版本协商信封的类型，payload 为 {"schemas": {type: version}}。
+const HelloType = "wsx.hello"
+
+// Envelope 客户端与服务端共享的消息信封，序列化为 JSON：
+//
+//	{"type":"chat.message","version":2,"id":"…","ts":1700000000000,"payload":{…}}
+//
+// 经过压缩、加密等编码的 payload 以 base64 字符串传输，已应用的编码按顺序记录在 enc 中。
+type Envelope struct {
+	Type     string          `json:"type"`
+	Version  int             `json:"version"`
+	ID       string          `json:"id"`
+	TS       int64           `json:"ts"` // 发送时间，Unix 毫秒
+	Encoding []string        `json:"enc,omitempty"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+}
+
+// Time 返回发送时间。
+func (e *Envelope) Time() time.Time {
+	return time.UnixMilli(e.TS)
+}
+
+// UnmarshalPayload 将 payload 反序列化到 v。
+func (e *Envelope) UnmarshalPayload(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// EnvelopeMiddleware 信封编解码钩子，用于校验、压缩、加密等。
+// Encode 在序列化前按注册顺序执行，Decode 在反序列化后按逆序执行。
+type EnvelopeMiddleware interface {
+	Encode(env *Envelope) error
+	Decode(env *Envelope) error
+}
+
+// PayloadConverter 在相邻两个 schema 版本之间转换 payload。
+type PayloadConverter func(payload json.RawMessage) (json.RawMessage, error)
+
+// schemaStep 标识某类型从 from 版本出发的一次转换。
+type schemaStep struct {
+	typ  string
+	from int
+}
+
+// Codec 信封编解码器，构造后只读，可在多个连接间共享。
+//
+// 每个消息类型有一个当前 schema 版本 (默认 1)：
+//   - 编码时以当前版本发送；通过 WithPeer 与对端协商后，对端版本较低时逐级降级
+//   - 解码时将低版本 payload 逐级升级到当前版本，高于当前版本的消息返回 ErrUnsupportedVersion
+//
+// 未声明版本的类型不做版本检查。
+type Codec struct {
+	schemas     map[string]int
+	upgrades    map[schemaStep]PayloadConverter
+	downgrades  map[schemaStep]PayloadConverter
+	middlewares []EnvelopeMiddleware
+	peer        map[string]int // WithPeer 协商得到的对端版本
+}
+
+// CodecOption 配置 Codec。
+type CodecOption func(*Codec)
+
+// WithSchema 声明消息类型的当前 schema 版本。
+func WithSchema(typ string, version int) CodecOption {
+	return func(c *Codec) { c.schemas[typ] = version }
+}
+
+// WithUpgrade 注册 typ 从 from 版本升级到 from+1 的转换。
+func WithUpgrade(typ string, from int, fn PayloadConverter) CodecOption {
+	return func(c *Codec) { c.upgrades[schemaStep{typ, from}] = fn }
+}
+
+// WithDowngrade 注册 typ 从 from 版本降级到 from-1 的转换，用于向旧版本对端发送。
+func WithDowngrade(typ string, from int, fn PayloadConverter) CodecOption {
+	return func(c *Codec) { c.downgrades[schemaStep{typ, from}] = fn }
+}
+
+// WithEnvelopeMiddleware 追加编解码钩子。
+func WithEnvelopeMiddleware(mws ...EnvelopeMiddleware) CodecOption {
+	return func(c *Codec) { c.middlewares = append(c.middlewares, mws...) }
+}
+
+// NewCodec 创建信封编解码器。
+func NewCodec(opts ...CodecOption) *Codec {
+	c := &Codec{
+		schemas:    make(map[string]int),
+		upgrades:   make(map[schemaStep]PayloadConverter),
+		downgrades: make(map[schemaStep]PayloadConverter),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Encode 将 payload 包装为 typ 类型的信封并序列化。
+func (c *Codec) Encode(typ string, payload any) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("wsx: marshal payload: %w", err)
+	}
+	return c.EncodeEnvelope(&Envelope{Type: typ, Payload: raw})
+}
+
+// EncodeEnvelope 补全信封的版本、ID 与时间戳，执行降级与编码钩子后序列化。
+// Version 为 0 时使用当前 schema 版本。
+func (c *Codec) EncodeEnvelope(env *Envelope) ([]byte, error) {
+	if env.Type == "" {
+		return nil, fmt.Errorf("%w: empty type", ErrInvalidEnvelope)
+	}
+	if env.Version == 0 {
+		env.Version = c.version(env.Type)
+	}
+	if env.ID == "" {
+		env.ID = uuid.NewString()
+	}
+	if env.TS == 0 {
+		env.TS = time.Now().UnixMilli()
+	}
+
+	if target, ok := c.peer[env.Type]; ok {
+		for env.Version > target {
+			fn, ok := c.downgrades[schemaStep{env.Type, env.Version}]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s v%d for peer v%d", ErrUnsupportedVersion, env.Type, env.Version, target)
+			}
+			payload, err := fn(env.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("wsx: downgrade %s v%d: %w", env.Type, env.Version, err)
+			}
+			env.Payload = payload
+			env.Version--
+		}
+	}
+
+	for _, mw := range c.middlewares {
+		if err := mw.Encode(env); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(env)
+}
+
+// Decode 反序列化信封，按逆序执行解码钩子，并将 payload 升级到当前 schema 版本。
+func (c *Codec) Decode(data []byte) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+	if env.Type == "" {
+		return nil, fmt.Errorf("%w: empty type", ErrInvalidEnvelope)
+	}
+	if env.Version == 0 {
+		env.Version = 1
+	}
+
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		if err := c.middlewares[i].Decode(&env); err != nil {
+			return nil, err
+		}
+	}
+	if len(env.Encoding) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedEncoding, env.Encoding)
+	}
+
+	current, ok := c.schemas[env.Type]
+	if !ok {
+		return &env, nil
+	}
+	if env.Version > current {
+		return nil, fmt.Errorf("%w: %s v%d, supported up to v%d", ErrUnsupportedVersion, env.Type, env.Version, current)
+	}
+	for env.Version < current {
+		fn, ok := c.upgrades[schemaStep{env.Type, env.Version}]
+		if !ok {
+			return nil, fmt.Errorf("%w: no upgrade for %s v%d", ErrUnsupportedVersion, env.Type, env.Version)
+		}
+		payload, err := fn(env.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("wsx: upgrade %s v%d: %w", env.Type, env.Version, err)
+		}
+		env.Payload = payload
+		env.Version++
+	}
+	return &env, nil
+}
+
+// version 返回 typ 的当前 schema 版本，未声明时为 1。
+func (c *Codec) version(typ string) int {
+	if v, ok := c.schemas[typ]; ok {
+		return v
+	}
+	return 1
+}
+
+// helloPayload 是 HelloType 信封的 payload。
+type helloPayload struct {
+	Schemas map[string]int `json:"schemas"`
+}
+
+// Hello 返回声明本端 schema 版本的协商信封，连接建立后发送给对端。
+func (c *Codec) Hello() ([]byte, error) {
+	return c.Encode(HelloType, helloPayload{Schemas: c.schemas})
+}
+
+// WithPeer 根据对端的 Hello 信封返回协商后的 Codec：之后编码的消息不会高于对端声明的版本。
+// 协商状态属于单个连接，服务端应为每个连接分别调用。
+func (c *Codec) WithPeer(hello *Envelope) (*Codec, error) {
+	if hello.Type != HelloType {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrInvalidEnvelope, HelloType, hello.Type)
+	}
+	var p helloPayload
+	if err := hello.UnmarshalPayload(&p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+	peer := make(map[string]int)
+	for typ, v := range p.Schemas {
+		if local, ok := c.schemas[typ]; ok && v < local {
+			peer[typ] = v
+		}
+	}
+	cp := *c
+	cp.peer = peer
+	return &cp, nil
+}
+
+// ---------------------------------------------------------------------------
+// 内置钩子
+// ---------------------------------------------------------------------------
+
+// EnvelopeHooks 以函数实现 EnvelopeMiddleware，未设置的方向直接放行。
+type EnvelopeHooks struct {
+	OnEncode func(env *Envelope) error
+	OnDecode func(env *Envelope) error
+}
+
+func (h EnvelopeHooks) Encode(env *Envelope) error {
+	if h.OnEncode == nil {
+		return nil
+	}
+	return h.OnEncode(env)
+}
+
+func (h EnvelopeHooks) Decode(env *Envelope) error {
+	if h.OnDecode == nil {
+		return nil
+	}
+	return h.OnDecode(env)
+}
+
+// ValidateEnvelope 在发送前与接收后校验信封，应注册在压缩、加密之前，以便校验明文 payload。
+func ValidateEnvelope(fn func(env *Envelope) error) EnvelopeMiddleware {
+	wrap := func(env *Envelope) error {
+		if err := fn(env); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+		}
+		return nil
+	}
+	return EnvelopeHooks{OnEncode: wrap, OnDecode: wrap}
+}
+
+// payloadEncoding 以 name 标记的 payload 字节变换。
+type payloadEncoding struct {
+	name    string
+	minSize int // 小于该大小的 payload 不编码
+	encode  func([]byte) ([]byte, error)
+	decode  func([]byte) ([]byte, error)
+}
+
+func (p payloadEncoding) Encode(env *Envelope) error {
+	if len(env.Payload) == 0 || len(env.Payload) < p.minSize {
+		return nil
+	}
+	data, err := p.encode(env.Payload)
+	if err != nil {
+		return fmt.Errorf("wsx: %s encode: %w", p.name, err)
+	}
+	// []byte 序列化为 base64 字符串
+	if env.Payload, err = json.Marshal(data); err != nil {
+		return err
+	}
+	env.Encoding = append(env.Encoding, p.name)
+	return nil
+}
+
+func (p payloadEncoding) Decode(env *Envelope) error {
+	n := len(env.Encoding)
+	if n == 0 || env.Encoding[n-1] != p.name {
+		return nil
+	}
+	var data []byte
+	if err := json.Unmarshal(env.Payload, &data); err != nil {
+		return fmt.Errorf("%w: %s payload: %w", ErrInvalidEnvelope, p.name, err)
+	}
+	data, err := p.decode(data)
+	if err != nil {
+		return fmt.Errorf("wsx: %s decode: %w", p.name, err)
+	}
+	env.Payload = data
+	env.Encoding = env.Encoding[:n-1]
+	return nil
+}
+
+// GzipPayload 压缩不小于 minSize 字节的 payload，编码名为 "gzip"。
+func GzipPayload(minSize int) EnvelopeMiddleware {
+	return payloadEncoding{
+		name:    "gzip",
+		minSize: minSize,
+		encode: func(b []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, err := zw.Write(b); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		decode: func(b []byte) ([]byte, error) {
+			zr, err := gzip.NewReader(bytes.NewReader(b))
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+			return io.ReadAll(zr)
+		},
+	}
+}
+
+// AESGCMPayload 使用 AES-GCM 加密 payload，key 长度须为 16、24 或 32 字节，编码名为 "aes-gcm"。
+// 信封的 type、id 作为附加认证数据，防止 payload 被挪用到其它消息。
+func AESGCMPayload(key []byte) (EnvelopeMiddleware, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aesGCM{aead: aead}, nil
+}
+
+type aesGCM struct {
+	aead cipher.AEAD
+}
+
+func (a aesGCM) encoding(env *Envelope) payloadEncoding {
+	ad := []byte(env.Type + "\x00" + env.ID)
+	return payloadEncoding{
+		name: "aes-gcm",
+		encode: func(b []byte) ([]byte, error) {
+			nonce := make([]byte, a.aead.NonceSize())
+			if _, err := rand.Read(nonce); err != nil {
+				return nil, err
+			}
+			return a.aead.Seal(nonce, nonce, b, ad), nil
+		},
+		decode: func(b []byte) ([]byte, error) {
+			size := a.aead.NonceSize()
+			if len(b) < size {
+				return nil, fmt.Errorf("ciphertext too short")
+			}
+			return a.aead.Open(nil, b[:size], b[size:], ad)
+		},
+	}
+}
+
+func (a aesGCM) Encode(env *Envelope) error { return a.encoding(env).Encode(env) }
+func (a aesGCM) Decode(env *Envelope) error { return a.encoding(env).Decode(env) }
@@ -16,6 +16,20 @@ var (
 	ErrInvalidURL = errors.New("wsx: invalid websocket url")
 	// ErrSendTimeout 发送队列阻塞超时。
 	ErrSendTimeout = errors.New("wsx: send timeout")
+	// ErrSendBufferFull 重连缓冲区已满 (OverflowReject 策略)。
+	ErrSendBufferFull = errors.New("wsx: send buffer full")
 	// ErrMaxRetriesExceeded 已达到最大重连次数。
 	ErrMaxRetriesExceeded = errors.New("wsx: max reconnection attempts reached")
+	// ErrInvalidEnvelope 信封格式错误或未通过校验。
+	ErrInvalidEnvelope = errors.New("wsx: invalid envelope")
+	// ErrUnsupportedVersion 无法在 schema 版本之间转换。
+	ErrUnsupportedVersion = errors.New("wsx: unsupported schema version")
+	// ErrUnsupportedEncoding payload 使用了未注册的编码。
+	ErrUnsupportedEncoding = errors.New("wsx: unsupported payload encoding")
+	// ErrCertificateRejected 服务端证书未通过证书固定或自定义校验。
+	ErrCertificateRejected = errors.New("wsx: server certificate rejected")
+	// ErrNoHealthyMember 连接池中没有可用的连接。
+	ErrNoHealthyMember = errors.New("wsx: no healthy pool member")
+	// ErrNoCodec 客户端未配置 Codec。
+	ErrNoCodec = errors.New("wsx: no codec configured")
 )
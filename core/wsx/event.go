@@ -16,6 +16,21 @@ const (
 	EventError EventType = "error"
 	// EventReconnecting 正在重连
 	EventReconnecting EventType = "reconnecting"
+	// EventIdle 连接空闲超时 (IdleKeepAlive 策略)，event.Data 含 idle 时长
+	EventIdle EventType = "idle"
+	// EventEnvelope 收到信封消息 (配置 WithCodec 时)，event.Data 为 *Envelope
+	EventEnvelope EventType = "envelope"
+	// EventCertificateError 服务端证书校验失败 (含证书固定与自定义校验拒绝)，
+	// event.Data 为 *CertificateError；随后仍会触发 EventError
+	EventCertificateError EventType = "certificate_error"
+)
+
+// 连接被客户端策略关闭时，EventDisconnected 的 event.Data 为 map[string]any{"reason": ...}。
+const (
+	// DisconnectReasonIdle 空闲超时关闭 (IdleClose 策略)
+	DisconnectReasonIdle = "idle"
+	// DisconnectReasonMaxAge 达到最大连接时长，随后立即重建连接
+	DisconnectReasonMaxAge = "max_age"
 )
 
 // Event WebSocket 事件结构。
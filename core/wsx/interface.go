@@ -28,4 +28,6 @@ type Clienter interface {
 	IsConnected() bool
 	// Config 返回当前配置的副本。
 	Config() Config
+	// Stats 返回连接健康统计 (RTT、收发计数、重连次数等) 的快照。
+	Stats() Stats
 }
@@ -1,8 +1,11 @@
 package wsx
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -32,8 +35,50 @@ type Config struct {
 	EnableCompression bool `json:"enable_compression" yaml:"enable_compression"`
 	// Reconnect 自动重连配置
 	Reconnect ReconnectConfig `json:"reconnect" yaml:"reconnect"`
+	// IdleTimeout 无业务消息 (双向，不含 ping/pong) 的最长时长，<=0 关闭空闲检测
+	IdleTimeout time.Duration `json:"idle_timeout" yaml:"idle_timeout"`
+	// IdleAction 空闲超时后的处理策略，默认 IdleClose
+	IdleAction IdleAction `json:"idle_action" yaml:"idle_action"`
+	// MaxConnectionAge 单条连接的最长存活时长，到期后主动关闭并立即重连；
+	// 实际时长带 ±10% 抖动以避免大量客户端同时重连。<=0 表示不限制
+	MaxConnectionAge time.Duration `json:"max_connection_age" yaml:"max_connection_age"`
+	// RequestTimeout SendRequest 在 ctx 未设置截止时间时的超时，<=0 时使用 DefaultRequestTimeout
+	RequestTimeout time.Duration `json:"request_timeout" yaml:"request_timeout"`
+	// SendBufferSize 重连期间缓冲的待发送消息条数，连接恢复后按序发送；<=0 关闭缓冲
+	SendBufferSize int `json:"send_buffer_size" yaml:"send_buffer_size"`
+	// SendBufferOverflow 缓冲区满时的处理策略，默认 OverflowReject
+	SendBufferOverflow OverflowPolicy `json:"send_buffer_overflow" yaml:"send_buffer_overflow"`
+	// EventWorkers 执行事件处理函数的协程数上限，<=0 时使用 DefaultEventWorkers
+	EventWorkers int `json:"event_workers" yaml:"event_workers"`
+	// EventQueueSize 每种事件类型待处理事件的上限，<=0 时使用 DefaultEventQueueSize
+	EventQueueSize int `json:"event_queue_size" yaml:"event_queue_size"`
+	// EventOverflow 事件队列满时的处理策略，默认 OverflowDropOldest；OverflowReject 丢弃新事件
+	EventOverflow OverflowPolicy `json:"event_overflow" yaml:"event_overflow"`
 }
 
+// IdleAction 空闲超时后的处理策略。
+type IdleAction string
+
+const (
+	// IdleClose 关闭连接且不自动重连，可再次调用 Connect 恢复
+	IdleClose IdleAction = "close"
+	// IdleKeepAlive 保持连接 (依赖 ping 保活)，每个空闲周期触发一次 EventIdle
+	IdleKeepAlive IdleAction = "keepalive"
+)
+
+// OverflowPolicy 重连缓冲区满时的处理策略。
+type OverflowPolicy string
+
+const (
+	// OverflowReject 拒绝新消息，Send 返回 ErrSendBufferFull
+	OverflowReject OverflowPolicy = "reject"
+	// OverflowDropOldest 丢弃最早缓冲的消息，为新消息腾出空间
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+)
+
+// ResumeTokenHeader 握手时携带恢复令牌的请求头，见 WithResumeToken。
+const ResumeTokenHeader = "X-Resume-Token"
+
 // ReconnectConfig 自动重连配置。
 type ReconnectConfig struct {
 	// Enable 是否启用自动重连
@@ -61,6 +106,10 @@ func DefaultConfig() Config {
 		WriteBufferSize:   4096,
 		WriteQueueSize:    128,
 		EnableCompression: false,
+		RequestTimeout:    DefaultRequestTimeout,
+		EventWorkers:      DefaultEventWorkers,
+		EventQueueSize:    DefaultEventQueueSize,
+		EventOverflow:     OverflowDropOldest,
 		Reconnect: ReconnectConfig{
 			Enable:            true,
 			MaxRetries:        5,
@@ -84,7 +133,9 @@ func WithHeaders(headers http.Header) Option {
 	return func(c *Client) { c.headers = headers }
 }
 
-// WithDialer 直接注入自定义的 websocket.Dialer，将覆盖默认 Dialer。
+// WithDialer 直接注入自定义的 websocket.Dialer，将覆盖默认 Dialer；
+// WithTLSConfig / WithPinnedCertificates / WithVerifyPeerCertificate / WithProxy /
+// WithNetDialer / WithSubprotocols 仍会应用到该 Dialer 上。
 func WithDialer(d *websocket.Dialer) Option {
 	return func(c *Client) { c.dialer = d }
 }
@@ -94,6 +145,45 @@ func WithTLSConfig(t *tls.Config) Option {
 	return func(c *Client) { c.tlsConfig = t }
 }
 
+// WithPinnedCertificates 固定服务端证书：fingerprints 为证书 (DER) 的 SHA-256 指纹，
+// 十六进制，忽略大小写与冒号分隔，可由 Fingerprint 或
+// openssl x509 -noout -fingerprint -sha256 得到。对端证书链中至少一张证书命中时握手才会成功：
+// 标准校验通过时可固定叶子、中间或根证书；配合 InsecureSkipVerify 时只比对叶子证书，适用于自签名证书。
+// 固定叶子证书时需在证书轮换前同时配置新旧指纹。指纹格式错误时 Connect 返回错误。
+func WithPinnedCertificates(fingerprints ...string) Option {
+	return func(c *Client) { c.pins = append(c.pins, fingerprints...) }
+}
+
+// WithVerifyPeerCertificate 设置自定义服务端证书校验，在标准校验与证书固定之后执行，
+// 返回错误时握手失败。与 WithTLSConfig 中的 VerifyPeerCertificate 同时设置时两者都会执行。
+func WithVerifyPeerCertificate(fn VerifyPeerCertificateFunc) Option {
+	return func(c *Client) { c.verifyPeer = fn }
+}
+
+// WithProxy 通过 HTTP 代理 (CONNECT) 建立连接；proxyURL 为 nil 时按环境变量
+// (HTTP_PROXY / HTTPS_PROXY / NO_PROXY) 选择代理。未设置时直连。
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		if proxyURL == nil {
+			c.proxy = http.ProxyFromEnvironment
+			return
+		}
+		c.proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithNetDialer 设置建立底层 TCP 连接的函数，例如 (&net.Dialer{Timeout: 5 * time.Second}).DialContext，
+// 也可用于 Unix socket 或测试中的内存连接。使用代理时用于连接代理服务器。
+func WithNetDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(c *Client) { c.netDial = dial }
+}
+
+// WithSubprotocols 设置握手时请求的子协议 (Sec-WebSocket-Protocol)，按优先级排列；
+// 服务端选中的子协议可通过 Client.Subprotocol 获取。
+func WithSubprotocols(protocols ...string) Option {
+	return func(c *Client) { c.subprotocols = protocols }
+}
+
 // WithHandshakeTimeout 设置握手超时。
 func WithHandshakeTimeout(d time.Duration) Option {
 	return func(c *Client) { c.config.HandshakeTimeout = d }
@@ -144,6 +234,73 @@ func WithEnableCompression(enable bool) Option {
 	return func(c *Client) { c.config.EnableCompression = enable }
 }
 
+// WithIdleTimeout 设置空闲超时及超时后的处理策略；d<=0 关闭空闲检测。
+func WithIdleTimeout(d time.Duration, action IdleAction) Option {
+	return func(c *Client) {
+		c.config.IdleTimeout = d
+		c.config.IdleAction = action
+	}
+}
+
+// WithMaxConnectionAge 设置单条连接的最长存活时长，用于配合会静默丢弃长连接的负载均衡器。
+func WithMaxConnectionAge(d time.Duration) Option {
+	return func(c *Client) { c.config.MaxConnectionAge = d }
+}
+
+// WithCodec 启用信封编解码：连接建立后自动发送 Hello 协商 schema 版本，
+// 收到的文本消息解码为 EventEnvelope (解码失败时为 EventError)，二进制消息仍为 EventMessage。
+func WithCodec(codec *Codec) Option {
+	return func(c *Client) { c.codec = codec }
+}
+
+// WithTopicCodec 设置主题消息 (OnTopic / SendTopic) 的编解码器，默认 JSONTopicCodec。
+func WithTopicCodec(codec TopicCodec) Option {
+	return func(c *Client) {
+		if codec != nil {
+			c.topicCodec = codec
+		}
+	}
+}
+
+// WithRequestHandler 设置对端请求 (对端调用 SendRequest) 的处理函数，
+// 每个请求在独立的 goroutine 中处理。未设置时对端收到 *RemoteError。
+func WithRequestHandler(h RequestHandler) Option {
+	return func(c *Client) { c.requestHandler = h }
+}
+
+// WithRequestTimeout 设置 SendRequest 的默认超时。
+func WithRequestTimeout(d time.Duration) Option {
+	return func(c *Client) { c.config.RequestTimeout = d }
+}
+
+// WithSendBuffer 启用重连期间的发送缓冲：连接断开且即将自动重连时，Send 不再返回
+// ErrNotConnected，而是将消息缓冲 (最多 size 条)，重连成功后先于新消息按序发送。
+// 缓冲区满时按 policy 处理；放弃重连 (达到最大次数、Close) 时缓冲的消息被丢弃。
+func WithSendBuffer(size int, policy OverflowPolicy) Option {
+	return func(c *Client) {
+		c.config.SendBufferSize = size
+		c.config.SendBufferOverflow = policy
+	}
+}
+
+// WithEventDispatch 设置事件处理函数的执行方式：最多 workers 个协程并发执行，
+// 同一事件类型按派发顺序串行处理；每种类型最多积压 queueSize 个事件，满时按 policy 丢弃
+// (OverflowDropOldest 丢弃最早的事件，OverflowReject 丢弃新事件)，丢弃数见 Stats.EventsDropped。
+func WithEventDispatch(workers, queueSize int, policy OverflowPolicy) Option {
+	return func(c *Client) {
+		c.config.EventWorkers = workers
+		c.config.EventQueueSize = queueSize
+		c.config.EventOverflow = policy
+	}
+}
+
+// WithResumeToken 设置恢复令牌的来源：每次握手前调用 fn，返回值非空时通过 ResumeTokenHeader
+// 发送给服务端，便于服务端恢复会话状态 (transport/websocket 的 WithOnResume)。
+// 令牌通常由服务端在连接建立后下发，客户端保存最新值。
+func WithResumeToken(fn func() string) Option {
+	return func(c *Client) { c.resumeToken = fn }
+}
+
 // WithReconnect 设置自动重连策略。
 func WithReconnect(rc ReconnectConfig) Option {
 	return func(c *Client) { c.config.Reconnect = rc }
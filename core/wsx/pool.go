@@ -0,0 +1,331 @@
+package wsx
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPoolCheckInterval 连接池巡检断开成员的默认周期。
+const DefaultPoolCheckInterval = 5 * time.Second
+
+// poolEventTypes 连接池转发的成员事件类型。
+var poolEventTypes = []EventType{
+	EventConnected, EventDisconnected, EventMessage, EventError,
+	EventReconnecting, EventIdle, EventEnvelope, EventCertificateError,
+}
+
+// PoolEvent 连接池聚合事件：成员客户端的事件附带其来源。
+type PoolEvent struct {
+	Event
+	// URL 成员连接的地址
+	URL string
+	// Member 成员序号，与 Pool.Members 的下标一致
+	Member int
+}
+
+// PoolEventHandler 连接池事件回调。
+type PoolEventHandler func(event PoolEvent)
+
+// MemberStatus 连接池成员的状态快照。
+type MemberStatus struct {
+	Member  int
+	URL     string
+	Healthy bool
+	Stats   Stats
+}
+
+// PoolOption 配置 Pool 的可选项。
+type PoolOption func(*Pool)
+
+// WithPoolSize 设置每个 URL 建立的连接数，默认 1。
+func WithPoolSize(n int) PoolOption {
+	return func(p *Pool) {
+		if n > 0 {
+			p.size = n
+		}
+	}
+}
+
+// WithPoolClientOptions 设置每个成员客户端的选项 (重连、心跳、编解码等)。
+func WithPoolClientOptions(opts ...Option) PoolOption {
+	return func(p *Pool) { p.clientOpts = append(p.clientOpts, opts...) }
+}
+
+// WithPoolHealthCheck 设置额外的健康判定，例如 RTT 阈值：
+//
+//	wsx.WithPoolHealthCheck(func(s wsx.Stats) bool { return s.SmoothedRTT < 500*time.Millisecond })
+//
+// 成员只有在已连接且 fn 返回 true 时才会被选中。
+func WithPoolHealthCheck(fn func(Stats) bool) PoolOption {
+	return func(p *Pool) { p.healthCheck = fn }
+}
+
+// WithPoolCheckInterval 设置巡检周期：每个周期对未连接且不在自动重连中的成员
+// (首次连接失败、达到最大重连次数) 重新发起连接。<=0 关闭巡检。
+func WithPoolCheckInterval(d time.Duration) PoolOption {
+	return func(p *Pool) { p.checkInterval = d }
+}
+
+// Pool 维护到一组 URL 的多条 WebSocket 连接，按健康状态轮询或按 key 粘滞选择连接，
+// 并把所有成员的事件聚合为一个事件流，适用于消费大量上游推送的网关。
+//
+// 成员按 URL 顺序排列，每个 URL 连续 size 个成员。Pool 的公开方法并发安全。
+type Pool struct {
+	urls          []string
+	size          int
+	clientOpts    []Option
+	healthCheck   func(Stats) bool
+	checkInterval time.Duration
+
+	members []*poolMember
+	next    atomic.Uint64
+
+	mu       sync.RWMutex
+	handlers map[EventType][]PoolEventHandler
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	superOnce sync.Once
+	closeOnce sync.Once
+}
+
+// poolMember 连接池中的一条连接。
+type poolMember struct {
+	index  int
+	url    string
+	client *Client
+}
+
+// NewPool 为 urls 创建连接池，需调用 Connect 建立连接。
+func NewPool(urls []string, opts ...PoolOption) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		urls:          urls,
+		size:          1,
+		checkInterval: DefaultPoolCheckInterval,
+		handlers:      make(map[EventType][]PoolEventHandler),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for _, u := range urls {
+		for range p.size {
+			m := &poolMember{index: len(p.members), url: u, client: New(p.clientOpts...)}
+			for _, t := range poolEventTypes {
+				m.client.OnEvent(t, func(e Event) {
+					p.emit(PoolEvent{Event: e, URL: m.url, Member: m.index})
+				})
+			}
+			p.members = append(p.members, m)
+		}
+	}
+	return p
+}
+
+// Connect 并发建立所有成员的连接。只要有一个成员连接成功即返回 nil，
+// 连接失败的成员由巡检重试；全部失败时返回聚合的错误。
+func (p *Pool) Connect(ctx context.Context) error {
+	if p.ctx.Err() != nil {
+		return ErrClientClosed
+	}
+	if len(p.members) == 0 {
+		return ErrNoHealthyMember
+	}
+
+	errs := make([]error, len(p.members))
+	var wg sync.WaitGroup
+	for i, m := range p.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.client.Connect(ctx, m.url); err != nil && !errors.Is(err, ErrAlreadyConnected) {
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p.checkInterval > 0 {
+		p.superOnce.Do(func() {
+			p.wg.Add(1)
+			go p.supervise()
+		})
+	}
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭所有成员连接并停止巡检，之后 Pool 不可再用。
+func (p *Pool) Close() error {
+	var errs []error
+	p.closeOnce.Do(func() {
+		p.cancel()
+		p.wg.Wait()
+		for _, m := range p.members {
+			if err := m.client.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Next 按轮询返回下一个健康的成员客户端。
+func (p *Pool) Next() (*Client, error) {
+	n := uint64(len(p.members))
+	if n == 0 {
+		return nil, ErrNoHealthyMember
+	}
+	start := p.next.Add(1) - 1
+	for i := range n {
+		if m := p.members[(start+i)%n]; p.healthy(m) {
+			return m.client, nil
+		}
+	}
+	return nil, ErrNoHealthyMember
+}
+
+// Sticky 返回 key 对应的健康成员客户端：同一 key 在成员健康状态不变时总是映射到同一连接，
+// 某个成员不可用时只有映射到它的 key 会迁移 (rendezvous 哈希)。
+func (p *Pool) Sticky(key string) (*Client, error) {
+	var (
+		best  *poolMember
+		score uint64
+	)
+	for _, m := range p.members {
+		if !p.healthy(m) {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(m.url))
+		h.Write([]byte(strconv.Itoa(m.index)))
+		if s := h.Sum64(); best == nil || s > score {
+			best, score = m, s
+		}
+	}
+	if best == nil {
+		return nil, ErrNoHealthyMember
+	}
+	return best.client, nil
+}
+
+// Send 通过轮询选出的成员发送消息。
+func (p *Pool) Send(messageType MessageType, data []byte) error {
+	c, err := p.Next()
+	if err != nil {
+		return err
+	}
+	return c.Send(messageType, data)
+}
+
+// SendSticky 通过 key 对应的成员发送消息，见 Sticky。
+func (p *Pool) SendSticky(key string, messageType MessageType, data []byte) error {
+	c, err := p.Sticky(key)
+	if err != nil {
+		return err
+	}
+	return c.Send(messageType, data)
+}
+
+// Broadcast 向所有健康成员发送消息，返回发送成功的成员数。
+func (p *Pool) Broadcast(messageType MessageType, data []byte) int {
+	sent := 0
+	for _, m := range p.members {
+		if p.healthy(m) && m.client.Send(messageType, data) == nil {
+			sent++
+		}
+	}
+	return sent
+}
+
+// OnEvent 注册聚合事件回调，所有成员的 eventType 事件都会送达；回调在成员客户端的
+// goroutine 中执行，不同成员的事件可能并发到达。
+func (p *Pool) OnEvent(eventType EventType, handler PoolEventHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[eventType] = append(p.handlers[eventType], handler)
+}
+
+// RemoveEventHandler 移除指定事件类型下的所有聚合事件回调。
+func (p *Pool) RemoveEventHandler(eventType EventType) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.handlers, eventType)
+}
+
+// Members 返回所有成员的状态快照。
+func (p *Pool) Members() []MemberStatus {
+	out := make([]MemberStatus, len(p.members))
+	for i, m := range p.members {
+		out[i] = MemberStatus{Member: m.index, URL: m.url, Healthy: p.healthy(m), Stats: m.client.Stats()}
+	}
+	return out
+}
+
+// Healthy 返回健康成员数。
+func (p *Pool) Healthy() int {
+	n := 0
+	for _, m := range p.members {
+		if p.healthy(m) {
+			n++
+		}
+	}
+	return n
+}
+
+// healthy 判断成员是否可被选中。
+func (p *Pool) healthy(m *poolMember) bool {
+	if !m.client.IsConnected() {
+		return false
+	}
+	return p.healthCheck == nil || p.healthCheck(m.client.Stats())
+}
+
+// emit 将成员事件分发给聚合事件回调。
+func (p *Pool) emit(e PoolEvent) {
+	p.mu.RLock()
+	handlers := append([]PoolEventHandler(nil), p.handlers[e.Type]...)
+	p.mu.RUnlock()
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+// supervise 周期性地重新连接未连接且不在自动重连中的成员。
+func (p *Pool) supervise() {
+	defer p.wg.Done()
+	t := time.NewTicker(p.checkInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-t.C:
+			for _, m := range p.members {
+				if p.ctx.Err() != nil {
+					return
+				}
+				if !m.client.IsConnected() {
+					// 自动重连中的成员返回 ErrAlreadyConnected，交由其自身处理
+					_ = m.client.Connect(p.ctx, m.url)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package wsx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRequestTimeout SendRequest 在 ctx 未设置截止时间时的默认超时。
+const DefaultRequestTimeout = 30 * time.Second
+
+// rpcFrame 请求 / 响应帧，以文本消息发送：
+//
+//	{"rpc":"req","id":"…","payload":"<base64>"}
+//	{"rpc":"res","id":"…","payload":"<base64>"}
+//	{"rpc":"err","id":"…","error":"…"}
+//
+// 字段顺序固定，接收方据 `{"rpc":` 前缀识别，普通消息不做 JSON 解析。
+type rpcFrame struct {
+	Kind    string `json:"rpc"`
+	ID      string `json:"id"`
+	Payload []byte `json:"payload,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+const (
+	rpcRequest  = "req"
+	rpcResponse = "res"
+	rpcError    = "err"
+)
+
+var rpcPrefix = []byte(`{"rpc":`)
+
+// RemoteError 对端处理请求失败时 SendRequest 返回的错误。
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string { return "wsx: remote error: " + e.Message }
+
+// RequestHandler 处理对端通过 SendRequest 发来的请求，返回值作为响应 payload；
+// 返回的 error 以 *RemoteError 的形式交给请求方。
+// ctx 在连接断开时取消。
+type RequestHandler func(ctx context.Context, payload []byte) ([]byte, error)
+
+// Correlator 在一条消息流上实现请求 / 响应关联：为请求分配关联 ID，
+// 把响应路由回等待中的调用方，支持任意数量的并发请求。
+//
+// Client 内置一个 Correlator；服务端 (transport/websocket) 复用它实现同样的协议，
+// 因此双方都可以发起请求。
+type Correlator struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]chan rpcFrame
+}
+
+// NewCorrelator 创建 Correlator，timeout 为 ctx 无截止时间时的默认超时，<=0 时使用 DefaultRequestTimeout。
+func NewCorrelator(timeout time.Duration) *Correlator {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	return &Correlator{timeout: timeout, pending: make(map[string]chan rpcFrame)}
+}
+
+// Request 通过 send 发送请求帧并等待对应的响应。
+// ctx 取消、超时或 Fail 时返回相应错误；对端处理失败时返回 *RemoteError。
+func (c *Correlator) Request(ctx context.Context, payload []byte, send func([]byte) error) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	id := uuid.NewString()
+	data, err := json.Marshal(rpcFrame{Kind: rpcRequest, ID: id, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan rpcFrame, 1)
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := send(data); err != nil {
+		return nil, err
+	}
+	select {
+	case f, ok := <-ch:
+		if !ok {
+			return nil, ErrNotConnected
+		}
+		if f.Kind == rpcError {
+			return nil, &RemoteError{Message: f.Error}
+		}
+		return f.Payload, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("wsx: request %s: %w", id, ctx.Err())
+	}
+}
+
+// Handle 处理一条收到的文本消息。非请求 / 响应帧返回 false，由调用方按普通消息处理。
+//
+// 响应帧交给等待中的 Request；请求帧在新的 goroutine 中交给 handler，
+// 结果通过 send 回复。handler 为 nil 时回复错误帧，避免请求方一直等到超时。
+func (c *Correlator) Handle(ctx context.Context, data []byte, handler RequestHandler, send func([]byte) error) bool {
+	if !bytes.HasPrefix(data, rpcPrefix) {
+		return false
+	}
+	var f rpcFrame
+	if err := json.Unmarshal(data, &f); err != nil || f.ID == "" {
+		return false
+	}
+
+	switch f.Kind {
+	case rpcResponse, rpcError:
+		c.mu.Lock()
+		ch, ok := c.pending[f.ID]
+		delete(c.pending, f.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- f
+		}
+	case rpcRequest:
+		go func() {
+			reply := rpcFrame{Kind: rpcResponse, ID: f.ID}
+			if handler == nil {
+				reply.Kind, reply.Error = rpcError, "no request handler"
+			} else if out, err := handler(ctx, f.Payload); err != nil {
+				reply.Kind, reply.Error = rpcError, err.Error()
+			} else {
+				reply.Payload = out
+			}
+			if data, err := json.Marshal(reply); err == nil {
+				_ = send(data)
+			}
+		}()
+	default:
+		return false
+	}
+	return true
+}
+
+// Fail 使所有等待中的请求立即返回 ErrNotConnected，在连接断开时调用。
+func (c *Correlator) Fail() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
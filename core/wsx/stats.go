@@ -0,0 +1,123 @@
+package wsx
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+)
+
+// srttWeight SmoothedRTT 的平滑系数分母，与 TCP SRTT 一致 (新样本权重 1/8)。
+const srttWeight = 8
+
+// Stats 客户端连接健康统计，由 Client.Stats 返回，可用于对劣化的链路告警。
+//
+// RTT 通过 ping/pong 测量：ping 携带发送时间戳，对端按协议原样回显于 pong。
+// 仅在启用 PingInterval 时更新。计数器自客户端创建起累计，跨重连不清零。
+type Stats struct {
+	// Connected 当前是否已连接
+	Connected bool
+	// ConnectedAt 当前连接的建立时间，未连接时为零值
+	ConnectedAt time.Time
+	// RTT 最近一次 ping/pong 往返时延，尚未测得时为 0
+	RTT time.Duration
+	// SmoothedRTT RTT 的指数加权移动平均，对单次抖动不敏感，适合作为告警指标
+	SmoothedRTT time.Duration
+	// LastPingAt 最近一次发送 ping 的时间
+	LastPingAt time.Time
+	// LastPongAt 最近一次收到 pong 的时间
+	LastPongAt time.Time
+	// LastMessageAt 最近一次收到业务消息的时间
+	LastMessageAt time.Time
+	// ReconnectAttempts 自动重连的尝试次数
+	ReconnectAttempts uint64
+	// Reconnects 自动重连 (含最大时长回收) 成功的次数
+	Reconnects uint64
+	// MessagesIn / MessagesOut 收到 / 发出的业务消息条数
+	MessagesIn  uint64
+	MessagesOut uint64
+	// BytesIn / BytesOut 收到 / 发出的业务消息载荷字节数，不含帧头与控制帧
+	BytesIn  uint64
+	BytesOut uint64
+	// EventWorkersBusy 正在执行事件处理函数的协程数，持续等于 EventWorkers 说明处理能力饱和
+	EventWorkersBusy int
+	// EventsQueued 等待处理的事件数
+	EventsQueued int
+	// EventsDropped 因事件队列已满被丢弃的事件数
+	EventsDropped uint64
+	// EventHandlerPanics 事件处理函数 panic 的次数，panic 已被恢复并记录日志
+	EventHandlerPanics uint64
+}
+
+// clientStats Client 内部的统计计数，全部为原子操作，收发路径不加锁。
+// 时间字段保存 UnixNano，0 表示尚未发生。
+type clientStats struct {
+	connectedAt atomic.Int64
+	rtt         atomic.Int64
+	srtt        atomic.Int64
+	lastPing    atomic.Int64
+	lastPong    atomic.Int64
+	lastMessage atomic.Int64
+	attempts    atomic.Uint64
+	reconnects  atomic.Uint64
+	messagesIn  atomic.Uint64
+	messagesOut atomic.Uint64
+	bytesIn     atomic.Uint64
+	bytesOut    atomic.Uint64
+}
+
+// Stats 返回连接健康统计的快照。
+func (c *Client) Stats() Stats {
+	s := &c.stats
+	return Stats{
+		Connected:          c.IsConnected(),
+		ConnectedAt:        unixTime(s.connectedAt.Load()),
+		RTT:                time.Duration(s.rtt.Load()),
+		SmoothedRTT:        time.Duration(s.srtt.Load()),
+		LastPingAt:         unixTime(s.lastPing.Load()),
+		LastPongAt:         unixTime(s.lastPong.Load()),
+		LastMessageAt:      unixTime(s.lastMessage.Load()),
+		ReconnectAttempts:  s.attempts.Load(),
+		Reconnects:         s.reconnects.Load(),
+		MessagesIn:         s.messagesIn.Load(),
+		MessagesOut:        s.messagesOut.Load(),
+		BytesIn:            s.bytesIn.Load(),
+		BytesOut:           s.bytesOut.Load(),
+		EventWorkersBusy:   int(c.events.busy.Load()),
+		EventsQueued:       c.events.queuedCount(),
+		EventsDropped:      c.events.dropped.Load(),
+		EventHandlerPanics: c.events.panics.Load(),
+	}
+}
+
+// pingPayload 返回携带发送时间戳的 ping 载荷并记录发送时间。
+func (s *clientStats) pingPayload(now time.Time) []byte {
+	s.lastPing.Store(now.UnixNano())
+	return binary.BigEndian.AppendUint64(nil, uint64(now.UnixNano()))
+}
+
+// observePong 根据 pong 回显的时间戳更新 RTT；非本客户端格式的载荷
+// (如对端主动发送的 pong) 只更新 LastPongAt。
+func (s *clientStats) observePong(data []byte, now time.Time) {
+	s.lastPong.Store(now.UnixNano())
+	if len(data) != 8 {
+		return
+	}
+	rtt := now.UnixNano() - int64(binary.BigEndian.Uint64(data))
+	if rtt < 0 || rtt > now.UnixNano()-s.connectedAt.Load() {
+		return
+	}
+	s.rtt.Store(rtt)
+	if prev := s.srtt.Load(); prev == 0 {
+		s.srtt.Store(rtt)
+	} else {
+		s.srtt.Store(prev + (rtt-prev)/srttWeight)
+	}
+}
+
+// unixTime 将 UnixNano 转为 time.Time，0 对应零值。
+func unixTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
@@ -0,0 +1,178 @@
+package wsx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// TopicCodec 主题消息的编解码方式：把 topic 与 payload 打包为一条消息，
+// 并负责 payload 的序列化。内置 JSONTopicCodec 与 BinaryTopicCodec，
+// 后者可接入 msgpack、protobuf 等二进制序列化。
+type TopicCodec interface {
+	// Encode 将 v 序列化并与 topic 打包为一条消息。
+	Encode(topic string, v any) (Message, error)
+	// Decode 从消息中拆出 topic 与 payload，不是本编解码器产生的消息时返回 ok=false。
+	Decode(msg Message) (topic string, payload []byte, ok bool)
+	// Unmarshal 将 payload 反序列化到 v。
+	Unmarshal(payload []byte, v any) error
+}
+
+// JSONTopicCodec 返回默认的 JSON 主题编解码器，消息为文本帧：
+//
+//	{"topic":"chat.message","data":{…}}
+//
+// 字段顺序固定，接收方据 `{"topic":` 前缀识别，其它文本消息不做 JSON 解析。
+func JSONTopicCodec() TopicCodec { return jsonTopicCodec{} }
+
+type jsonTopicFrame struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+var jsonTopicPrefix = []byte(`{"topic":`)
+
+type jsonTopicCodec struct{}
+
+func (jsonTopicCodec) Encode(topic string, v any) (Message, error) {
+	if topic == "" {
+		return Message{}, fmt.Errorf("wsx: invalid topic %q", topic)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Message{}, fmt.Errorf("wsx: marshal topic %s: %w", topic, err)
+	}
+	frame, err := json.Marshal(jsonTopicFrame{Topic: topic, Data: data})
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Type: TextMessage, Data: frame}, nil
+}
+
+func (jsonTopicCodec) Decode(msg Message) (string, []byte, bool) {
+	if msg.Type != TextMessage || !bytes.HasPrefix(msg.Data, jsonTopicPrefix) {
+		return "", nil, false
+	}
+	var f jsonTopicFrame
+	if err := json.Unmarshal(msg.Data, &f); err != nil || f.Topic == "" {
+		return "", nil, false
+	}
+	return f.Topic, f.Data, true
+}
+
+func (jsonTopicCodec) Unmarshal(payload []byte, v any) error {
+	return json.Unmarshal(payload, v)
+}
+
+// BinaryTopicCodec 以 marshal / unmarshal 序列化 payload，消息为二进制帧：
+// uvarint(len(topic)) | topic | payload。例如接入 msgpack：
+//
+//	codec := wsx.BinaryTopicCodec(msgpack.Marshal, msgpack.Unmarshal)
+func BinaryTopicCodec(marshal func(v any) ([]byte, error), unmarshal func(data []byte, v any) error) TopicCodec {
+	return binaryTopicCodec{marshal: marshal, unmarshal: unmarshal}
+}
+
+// maxTopicLen 二进制帧中 topic 的最大长度，超出视为非主题消息。
+const maxTopicLen = 256
+
+type binaryTopicCodec struct {
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(data []byte, v any) error
+}
+
+func (c binaryTopicCodec) Encode(topic string, v any) (Message, error) {
+	if topic == "" || len(topic) > maxTopicLen {
+		return Message{}, fmt.Errorf("wsx: invalid topic %q", topic)
+	}
+	data, err := c.marshal(v)
+	if err != nil {
+		return Message{}, fmt.Errorf("wsx: marshal topic %s: %w", topic, err)
+	}
+	frame := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen16+len(topic)+len(data)), uint64(len(topic)))
+	frame = append(frame, topic...)
+	frame = append(frame, data...)
+	return Message{Type: BinaryMessage, Data: frame}, nil
+}
+
+func (c binaryTopicCodec) Decode(msg Message) (string, []byte, bool) {
+	if msg.Type != BinaryMessage {
+		return "", nil, false
+	}
+	n, size := binary.Uvarint(msg.Data)
+	if size <= 0 || n == 0 || n > maxTopicLen || uint64(len(msg.Data)-size) < n {
+		return "", nil, false
+	}
+	topic := msg.Data[size : size+int(n)]
+	if !utf8.Valid(topic) {
+		return "", nil, false
+	}
+	return string(topic), msg.Data[size+int(n):], true
+}
+
+func (c binaryTopicCodec) Unmarshal(payload []byte, v any) error {
+	return c.unmarshal(payload, v)
+}
+
+// topicHandler 反序列化 payload 并调用类型化的处理函数，由 OnTopic 生成。
+type topicHandler func(payload []byte) error
+
+// OnTopic 为 topic 注册类型化的处理函数，payload 由客户端的 TopicCodec
+// (WithTopicCodec，默认 JSON) 反序列化为 T 后交给 fn：
+//
+//	wsx.OnTopic(client, "chat.message", func(m ChatMessage) { … })
+//
+// 同一 topic 重复注册时覆盖之前的处理函数。已注册 topic 的消息不再触发 EventMessage，
+// 反序列化失败时触发 EventError；未注册的 topic 仍按普通消息派发。
+func OnTopic[T any](c *Client, topic string, fn func(T)) {
+	codec := c.topicCodec
+	c.mu.Lock()
+	c.topics[topic] = func(payload []byte) error {
+		var v T
+		if err := codec.Unmarshal(payload, &v); err != nil {
+			return fmt.Errorf("wsx: unmarshal topic %s: %w", topic, err)
+		}
+		fn(v)
+		return nil
+	}
+	c.mu.Unlock()
+}
+
+// RemoveTopic 移除 topic 的处理函数。
+func (c *Client) RemoveTopic(topic string) {
+	c.mu.Lock()
+	delete(c.topics, topic)
+	c.mu.Unlock()
+}
+
+// SendTopic 以客户端的 TopicCodec 序列化 v，作为 topic 主题消息发送。
+func (c *Client) SendTopic(topic string, v any) error {
+	msg, err := c.topicCodec.Encode(topic, v)
+	if err != nil {
+		return err
+	}
+	return c.Send(msg.Type, msg.Data)
+}
+
+// handleTopic 派发已注册 topic 的消息，未注册时返回 false。
+// 与事件回调一致，处理函数在独立的 goroutine 中执行。
+func (c *Client) handleTopic(msg Message) bool {
+	topic, payload, ok := c.topicCodec.Decode(msg)
+	if !ok {
+		return false
+	}
+	c.mu.RLock()
+	h, ok := c.topics[topic]
+	c.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	go func() {
+		if err := h(payload); err != nil {
+			c.emitEvent(Event{Type: EventError, Error: err, Timestamp: time.Now()})
+		}
+	}()
+	return true
+}
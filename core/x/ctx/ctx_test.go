@@ -45,3 +45,9 @@ func TestValue_Pointer(t *testing.T) {
 	require.NoError(t, err)
 	assert.Same(t, u, got)
 }
+
+func TestRequestID(t *testing.T) {
+	assert.Equal(t, "", RequestID(context.Background()))
+	ctx := WithRequestID(context.Background(), "req-1")
+	assert.Equal(t, "req-1", RequestID(ctx))
+}
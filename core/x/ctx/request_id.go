@@ -0,0 +1,20 @@
+package ctx
+
+import "context"
+
+// requestIDKey 请求 ID 在 context 中的键
+type requestIDKey struct{}
+
+// WithRequestID 返回携带请求 ID 的 context，供日志与出站调用关联同一请求。
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 context 携带的请求 ID，未设置时返回空字符串。
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
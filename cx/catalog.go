@@ -0,0 +1,225 @@
+package cx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Component catalog
+// ---------------------------------------------------------------------------
+//
+// The catalog is a machine-readable description of what a service wires:
+// every component grouped by namespace, the lifecycle interfaces it
+// implements, its position in the start order and its dependencies. It is
+// meant to be ingested by documentation tooling such as a developer portal.
+//
+// Unlike Graph, the catalog is useful before Start: the component type and
+// its interfaces are derived from the registered type T, and ProvideN
+// registrations declare their dependency keys up front. Once a component is
+// built, the built value and the recorded dependency edges take precedence.
+// Components registered with an interface type T report their type and
+// interfaces only after they are built.
+
+// Catalog is a serialisable description of the registered components.
+type Catalog struct {
+	// State is the container state at the time of the snapshot.
+	State string `json:"state"`
+	// Profiles lists the active profiles.
+	Profiles []string `json:"profiles,omitempty"`
+	// Namespaces groups the components by namespace, sorted by name.
+	Namespaces []CatalogNamespace `json:"namespaces"`
+}
+
+// CatalogNamespace groups the components whose keys share a namespace. The
+// namespace of a key is the part before its first "."; keys without a "."
+// belong to the unnamed namespace "".
+type CatalogNamespace struct {
+	Name string `json:"name"`
+	// Components lists the namespace's components in registration order.
+	Components []CatalogComponent `json:"components"`
+}
+
+// CatalogComponent describes one registered component.
+type CatalogComponent struct {
+	Key   string `json:"key"`
+	State string `json:"state"`
+	// Type is the dynamic type of the built value, or the registered type
+	// before the component is built. Empty if unknown.
+	Type string `json:"type,omitempty"`
+	// Interfaces lists the lifecycle interfaces the component implements,
+	// e.g. "cx.Starter"; see [RegisterCatalogInterface].
+	Interfaces []string `json:"interfaces,omitempty"`
+	// Order is the startup order override (see [OrderEnvPrefix]).
+	Order int `json:"order,omitempty"`
+	// StartIndex is the 1-based position in the most recent start order,
+	// zero if the component has not been built.
+	StartIndex int `json:"start_index,omitempty"`
+	// Deps are the keys this component depends on: the recorded edges once
+	// built, otherwise the keys declared by ProvideN.
+	Deps []string `json:"deps,omitempty"`
+	// Dependents are the keys of the components that depend on this one.
+	Dependents []string `json:"dependents,omitempty"`
+}
+
+// catalogInterface is an interface reported in CatalogComponent.Interfaces.
+type catalogInterface struct {
+	name       string
+	implements func(v any) bool
+}
+
+var (
+	catalogMu         sync.RWMutex
+	catalogInterfaces = []catalogInterface{
+		{"cx.Starter", implements[Starter]},
+		{"cx.Warmable", implements[Warmable]},
+		{"cx.Stopper", implements[Stopper]},
+		{"cx.HealthChecker", implements[HealthChecker]},
+		{"cx.HealthScorer", implements[HealthScorer]},
+	}
+)
+
+// implements reports whether v implements the interface I.
+func implements[I any](v any) bool {
+	_, ok := v.(I)
+	return ok
+}
+
+// RegisterCatalogInterface adds an interface to the ones reported in
+// [CatalogComponent.Interfaces], so that packages built on cx can surface
+// their own extension points without cx importing them:
+//
+//	cx.RegisterCatalogInterface("ginx.Routes", func(v any) bool {
+//		_, ok := v.(Routes)
+//		return ok
+//	})
+//
+// Registering an existing name replaces its check. It is usually called
+// from an init function.
+func RegisterCatalogInterface(name string, implements func(v any) bool) {
+	if name == "" || implements == nil {
+		return
+	}
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	for i, ci := range catalogInterfaces {
+		if ci.name == name {
+			catalogInterfaces[i].implements = implements
+			return
+		}
+	}
+	catalogInterfaces = append(catalogInterfaces, catalogInterface{name, implements})
+}
+
+// catalogInterfacesOf returns the names of the catalog interfaces v implements.
+func catalogInterfacesOf(v any) []string {
+	if v == nil {
+		return nil
+	}
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	var names []string
+	for _, ci := range catalogInterfaces {
+		if ci.implements(v) {
+			names = append(names, ci.name)
+		}
+	}
+	return names
+}
+
+// namespaceOf returns the namespace of key, the part before its first ".".
+func namespaceOf(key string) string {
+	ns, _, ok := strings.Cut(key, ".")
+	if !ok {
+		return ""
+	}
+	return ns
+}
+
+// Catalog returns a snapshot of the component catalog.
+func (c *Container) Catalog() Catalog {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	pos := make(map[string]int, len(c.buildOrder))
+	for i, k := range c.buildOrder {
+		pos[k] = i + 1
+	}
+
+	components := make([]CatalogComponent, 0, len(c.keys))
+	index := make(map[string]int, len(c.keys))
+	for _, k := range c.keys {
+		p := c.providers[k]
+		v := p.probe
+		if p.built {
+			v = p.value
+		}
+		deps := p.deps
+		if len(deps) == 0 {
+			deps = p.declared
+		}
+		comp := CatalogComponent{
+			Key:        k,
+			State:      p.status.String(),
+			Interfaces: catalogInterfacesOf(v),
+			Order:      p.order,
+			StartIndex: pos[k],
+			Deps:       slices.Clone(deps),
+		}
+		if v != nil {
+			comp.Type = fmt.Sprintf("%T", v)
+		}
+		index[k] = len(components)
+		components = append(components, comp)
+	}
+	for _, comp := range components {
+		for _, d := range comp.Deps {
+			if i, ok := index[d]; ok {
+				components[i].Dependents = append(components[i].Dependents, comp.Key)
+			}
+		}
+	}
+
+	cat := Catalog{
+		State:      c.state.String(),
+		Profiles:   slices.Clone(c.profiles),
+		Namespaces: []CatalogNamespace{},
+	}
+	byName := make(map[string]int)
+	for _, comp := range components {
+		ns := namespaceOf(comp.Key)
+		i, ok := byName[ns]
+		if !ok {
+			i = len(cat.Namespaces)
+			byName[ns] = i
+			cat.Namespaces = append(cat.Namespaces, CatalogNamespace{Name: ns})
+		}
+		cat.Namespaces[i].Components = append(cat.Namespaces[i].Components, comp)
+	}
+	slices.SortStableFunc(cat.Namespaces, func(a, b CatalogNamespace) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cat
+}
+
+// WriteJSON writes the catalog as indented JSON.
+func (cat Catalog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cat)
+}
+
+// CatalogHandler serves the container's component catalog as JSON. Like
+// [GraphHandler] it exposes component names and types, so mount it on an
+// internal admin router only.
+func CatalogHandler(c *Container) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		c.Catalog().WriteJSON(w)
+	})
+}
@@ -8,6 +8,15 @@ type Starter interface {
 	Start(ctx context.Context) error
 }
 
+// Warmable is implemented by values that need to be warmed up before the
+// container reports ready, e.g. to pre-fill a cache or open pooled
+// connections. Warmup runs after every Starter has started and before the
+// container enters StateRunning. Warm-ups run in parallel and do not follow
+// dependency order; see [WithWarmupTimeout] and [WithWarmupConcurrency].
+type Warmable interface {
+	Warmup(ctx context.Context) error
+}
+
 // Stopper is implemented by values that require graceful cleanup.
 // Stop is called during Container.Stop in reverse dependency order.
 type Stopper interface {
@@ -18,3 +27,11 @@ type Stopper interface {
 type HealthChecker interface {
 	HealthCheck(ctx context.Context) error
 }
+
+// HealthScorer is implemented by values that can report a degree of health
+// rather than a yes/no answer, e.g. a connection pool that is 80% exhausted.
+// The score is clamped to [0, 1]; an error marks the component unhealthy with
+// score 0. Values implementing HealthScorer are not asked for HealthCheck.
+type HealthScorer interface {
+	HealthScore(ctx context.Context) (float64, error)
+}
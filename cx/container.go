@@ -7,6 +7,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -45,6 +46,40 @@ func (s State) String() string {
 	}
 }
 
+// ComponentState represents the lifecycle phase of a single component.
+type ComponentState int32
+
+const (
+	ComponentNew      ComponentState = iota // Registered, not built in the current run
+	ComponentStarting                       // Being constructed or started
+	ComponentRunning                        // Built and started
+	ComponentFailed                         // Construction or Start failed
+	ComponentStopped                        // Stopped (or rolled back)
+	ComponentDisabled                       // Disabled by an environment override
+	ComponentWarming                        // Started, Warmable.Warmup in progress
+)
+
+func (s ComponentState) String() string {
+	switch s {
+	case ComponentNew:
+		return "new"
+	case ComponentStarting:
+		return "starting"
+	case ComponentRunning:
+		return "running"
+	case ComponentFailed:
+		return "failed"
+	case ComponentStopped:
+		return "stopped"
+	case ComponentDisabled:
+		return "disabled"
+	case ComponentWarming:
+		return "warming"
+	default:
+		return "unknown"
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Health / Metrics
 // ---------------------------------------------------------------------------
@@ -54,18 +89,58 @@ type ComponentHealth struct {
 	Key     string
 	Healthy bool
 	Error   error
+	// Score is the component's health in [0, 1]: the HealthScorer result, or
+	// 1/0 for healthy/unhealthy HealthCheckers. Unchecked components score 1.
+	Score float64
+	// Weight is the component's weight in HealthReport.Score (see
+	// [WithHealthWeight]); zero for components without health checks.
+	Weight float64
 }
 
 // HealthReport aggregates health-check results.
 type HealthReport struct {
 	Components []ComponentHealth
 	Healthy    bool
+	// Score is the weighted average of the checked components' scores in
+	// [0, 1], or 1 when no component is checked.
+	Score float64
+	// CheckedAt is the time the checks were started.
+	CheckedAt time.Time
 }
 
 // ContainerMetrics holds basic counts about the container.
 type ContainerMetrics struct {
 	ComponentCount int
 	State          State
+	// Components holds per-component lifecycle timings in registration order.
+	Components []ComponentMetrics
+	// StartOrder is the computed start order of the most recent Start
+	// (dependencies first, ties broken by registration order). Stop runs in
+	// the reverse order. Empty before Start.
+	StartOrder []string
+}
+
+// ComponentMetrics holds lifecycle timings and failure counts for a single
+// component. Durations describe the most recent Start/Stop; failure counts
+// accumulate over the lifetime of the container.
+type ComponentMetrics struct {
+	Key string
+	// BuildDuration is the time spent in the constructor, excluding the time
+	// spent building dependencies it pulled in via Get.
+	BuildDuration time.Duration
+	// StartDuration is the time spent in Starter.Start (zero if not a Starter).
+	StartDuration time.Duration
+	// WarmupDuration is the time spent in Warmable.Warmup (zero if not
+	// Warmable).
+	WarmupDuration time.Duration
+	// StopDuration is the time spent in Stopper.Stop (zero if not a Stopper).
+	StopDuration time.Duration
+	// StartFailures counts constructor and Starter.Start failures.
+	StartFailures int
+	// WarmupFailures counts Warmable.Warmup failures, including timeouts.
+	WarmupFailures int
+	// StopFailures counts Stopper.Stop failures.
+	StopFailures int
 }
 
 // ---------------------------------------------------------------------------
@@ -80,11 +155,42 @@ func WithStopTimeout(d time.Duration) Option {
 	return func(c *Container) { c.stopTimeout = d }
 }
 
+// WithComponentStopTimeout overrides the stop timeout for the component
+// registered under key, e.g. to give a message consumer longer to drain
+// than the default.
+func WithComponentStopTimeout(key string, d time.Duration) Option {
+	return func(c *Container) {
+		if c.componentStopTimeouts == nil {
+			c.componentStopTimeouts = make(map[string]time.Duration)
+		}
+		c.componentStopTimeouts[key] = d
+	}
+}
+
 // WithHealthTimeout sets the per-component timeout used during HealthCheck.
 func WithHealthTimeout(d time.Duration) Option {
 	return func(c *Container) { c.healthTimeout = d }
 }
 
+// WithWarmupTimeout sets the per-component timeout used during the warm-up
+// phase (see [Warmable]).
+func WithWarmupTimeout(d time.Duration) Option {
+	return func(c *Container) { c.warmupTimeout = d }
+}
+
+// WithWarmupConcurrency sets how many Warmable components are warmed up at
+// the same time. n <= 0 warms them all at once.
+func WithWarmupConcurrency(n int) Option {
+	return func(c *Container) { c.warmupConcurrency = n }
+}
+
+// WithPartialStart makes a failing Start leave already-started components
+// running instead of rolling them back, so that [Container.RetryFailed] can
+// re-attempt only the failed ones.
+func WithPartialStart() Option {
+	return func(c *Container) { c.partialStart = true }
+}
+
 // WithOnStart registers a hook that runs before the first component is started.
 func WithOnStart(fn func(ctx context.Context) error) Option {
 	return func(c *Container) { c.onStart = append(c.onStart, fn) }
@@ -115,9 +221,32 @@ type provider struct {
 	value       any
 	built       bool
 	started     bool
+	running     bool     // Start succeeded in the current run
+	warmed      bool     // Warmup succeeded in the current run
 	deps        []string // keys this provider depends on (recorded during build)
+	declared    []string // dependency keys declared by ProvideN, see Catalog
+	probe       any      // zero value of the registered type, see Catalog
+	missing     []string // unregistered keys the constructor asked for
+	order       int      // build order override, see OrderEnvPrefix
+	disabled    bool     // disabled by an override, see DisableEnvPrefix
+
+	status  ComponentState
+	metrics ComponentMetrics // survives Stop so failures accumulate across restarts
+	// nestedBuild accumulates the build time of dependencies constructed
+	// while this provider's constructor was running.
+	nestedBuild time.Duration
 }
 
+// buildError marks an error as originating from a constructor, so that a
+// failure bubbling up through dependents is only counted once.
+type buildError struct {
+	key string
+	err error
+}
+
+func (e *buildError) Error() string { return "construct " + e.key + ": " + e.err.Error() }
+func (e *buildError) Unwrap() error { return e.err }
+
 // ---------------------------------------------------------------------------
 // Container
 // ---------------------------------------------------------------------------
@@ -125,7 +254,7 @@ type provider struct {
 // C is the package-level default Container, ready to use immediately.
 var C *Container
 
-func init() { C = New() }
+func init() { C = New(WithProfiles(profilesFromEnv()...), WithEnvOverrides()) }
 
 // Container is a lightweight dependency-injection container that manages
 // component registration, lazy construction, and a Start/Stop lifecycle.
@@ -138,7 +267,8 @@ func init() { C = New() }
 //
 // Goroutine safety:
 //   - Registration (Provide/Supply) and retrieval (Get) are safe for
-//     concurrent use after Start has returned.
+//     concurrent use after Start has returned. While running, Get reads an
+//     immutable snapshot of the built values and takes no lock.
 //   - During Start, only the goroutine that called Start may interact with
 //     the container (constructors call Get on the same goroutine). Spawning
 //     goroutines that call Get inside a constructor is not supported.
@@ -150,6 +280,10 @@ type Container struct {
 	// buildOrder records the order in which providers were actually
 	// constructed. Filled during Start, used for Start/Stop ordering.
 	buildOrder []string
+	// resolved is a copy-on-write snapshot of key -> built value, published
+	// when the container reaches StateRunning and cleared when Stop begins.
+	// It lets Get serve the hot path without touching mu.
+	resolved atomic.Pointer[map[string]any]
 	// buildStack is the cycle-detection stack used during the build phase.
 	// Only the Start goroutine writes to it. The top of the stack also acts
 	// as the "current caller" used to record dependency edges.
@@ -158,6 +292,27 @@ type Container struct {
 	state         State
 	stopTimeout   time.Duration
 	healthTimeout time.Duration
+	warmupTimeout time.Duration
+	// warmupConcurrency bounds parallel Warmup calls, <= 0 = unbounded.
+	warmupConcurrency int
+	profiles          []string   // active profiles, see ProvideWhen
+	partialStart      bool       // keep started components running when Start fails
+	envOverrides      bool       // apply environment overrides at Start, see WithEnvOverrides
+	overrides         []Override // applied by the most recent Start
+	onStartDone       bool       // onStart hooks ran in the current Start/RetryFailed cycle
+	frozen            bool       // Provide/Supply rejected, see Freeze
+	noAutoFreeze      bool       // successful Start does not freeze, see WithoutAutoFreeze
+
+	// componentStopTimeouts overrides stopTimeout per key.
+	componentStopTimeouts map[string]time.Duration
+
+	healthWeights  map[string]float64 // per-key weight in HealthReport.Score, default 1
+	healthInterval time.Duration      // background health monitor period, 0 = disabled
+	healthCache    *HealthReport      // latest background report, nil before the first check
+	healthStop     func()             // stops the running monitor, nil if not running
+	onHealthChange []func(ComponentHealth)
+
+	events *eventLog // operation history, nil if disabled, see WithEventLog
 
 	onStart    []func(ctx context.Context) error
 	onStarted  []func(ctx context.Context) error
@@ -168,9 +323,11 @@ type Container struct {
 // New creates an empty Container.
 func New(opts ...Option) *Container {
 	c := &Container{
-		providers:     make(map[string]*provider),
-		stopTimeout:   30 * time.Second,
-		healthTimeout: 10 * time.Second,
+		providers:         make(map[string]*provider),
+		stopTimeout:       30 * time.Second,
+		healthTimeout:     10 * time.Second,
+		warmupTimeout:     30 * time.Second,
+		warmupConcurrency: 4,
 	}
 	for _, o := range opts {
 		o(c)
@@ -185,7 +342,15 @@ func New(opts ...Option) *Container {
 // Provide registers a lazily-invoked constructor under key.
 // The constructor is called during [Container.Start]; its dependencies are
 // resolved automatically when it calls [Get] on other keys.
+//
+// Provide fails with [ErrContainerFrozen] once the container is frozen (see
+// [Container.Freeze]); use [ProvideLate] or [Swap] to opt in explicitly.
 func Provide[T any](c *Container, key string, ctor func(*Container) (T, error)) error {
+	return provide(c, key, ctor, false)
+}
+
+// provide implements Provide and ProvideLate; late skips the freeze check.
+func provide[T any](c *Container, key string, ctor func(*Container) (T, error), late bool) error {
 	if key == "" {
 		return fmt.Errorf("%w: empty key", ErrInvalidKey)
 	}
@@ -199,17 +364,24 @@ func Provide[T any](c *Container, key string, ctor func(*Container) (T, error))
 	if c.state != StateNew && c.state != StateStopped {
 		return fmt.Errorf("%w: current state is %s", ErrContainerNotIdle, c.state)
 	}
+	if c.frozen && !late {
+		return fmt.Errorf("%w: cannot register %s", ErrContainerFrozen, key)
+	}
 	if _, exists := c.providers[key]; exists {
 		return fmt.Errorf("%w: %s", ErrComponentExists, key)
 	}
 
+	var zero T
 	c.providers[key] = &provider{
-		key: key,
+		key:     key,
+		metrics: ComponentMetrics{Key: key},
+		probe:   zero,
 		constructor: func(cont *Container) (any, error) {
 			return ctor(cont)
 		},
 	}
 	c.keys = append(c.keys, key)
+	c.record(Event{Kind: EventRegister, Key: key, Detail: typeName[T]()})
 	return nil
 }
 
@@ -251,21 +423,58 @@ func MustSupply[T any](c *Container, key string, value T) {
 // Errors:
 //   - ErrComponentNotFound: key is not registered, or is registered but not
 //     yet built (Get called outside Start).
+//   - ErrComponentDisabled: the component is disabled by an environment
+//     override (see [DisableEnvPrefix]).
 //   - ErrTypeMismatch: stored value cannot be cast to T.
 //   - ErrCircularDependency / constructor error: bubbled from lazy build.
 func Get[T any](c *Container, key string) (T, error) {
+	// Fast path: running container, lock-free snapshot lookup.
+	if m := c.resolved.Load(); m != nil {
+		if val, ok := (*m)[key]; ok {
+			if t, ok := val.(T); ok {
+				return t, nil
+			}
+		}
+	}
+
+	t, err := get[T](c, key)
+	c.record(Event{Kind: EventGet, Key: key, Err: err})
+	return t, err
+}
+
+// get is the locked path of Get, which the event log records.
+func get[T any](c *Container, key string) (T, error) {
 	var zero T
 
+	if m := c.resolved.Load(); m != nil {
+		if val, ok := (*m)[key]; ok {
+			t, ok := val.(T)
+			if !ok {
+				return zero, fmt.Errorf("%w: key %q stores %T, requested %T", ErrTypeMismatch, key, val, zero)
+			}
+			return t, nil
+		}
+	}
+
 	c.mu.RLock()
 	p, exists := c.providers[key]
 	state := c.state
+	disabled := exists && p.disabled
 	c.mu.RUnlock()
 
 	if !exists {
+		if state == StateStarting {
+			c.mu.Lock()
+			c.recordMissingLocked(key)
+			c.mu.Unlock()
+		}
 		return zero, fmt.Errorf("%w: %s", ErrComponentNotFound, key)
 	}
+	if disabled {
+		return zero, fmt.Errorf("%w: %s", ErrComponentDisabled, key)
+	}
 
-	// Fast path: already built. Read under RLock to be safe vs. concurrent Stop.
+	// Already built. Read under RLock to be safe vs. concurrent Stop.
 	c.mu.RLock()
 	built := p.built
 	val := p.value
@@ -322,6 +531,10 @@ func (c *Container) build(key string) (retErr error) {
 		c.mu.Unlock()
 		return fmt.Errorf("%w: %s", ErrComponentNotFound, key)
 	}
+	if p.disabled {
+		c.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrComponentDisabled, key)
+	}
 	if p.built {
 		// Already built – just record the dep edge from caller (if any).
 		c.recordDepEdgeLocked(key)
@@ -345,11 +558,15 @@ func (c *Container) build(key string) (retErr error) {
 
 	c.buildStack = append(c.buildStack, key)
 	ctor := p.constructor
+	p.nestedBuild = 0
+	p.status = ComponentStarting
 	c.mu.Unlock()
+	c.record(Event{Kind: EventBuildStart, Key: key})
 
 	// Run the constructor without holding the lock so it can recursively Get.
 	var val any
 	var err error
+	t0 := time.Now()
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -358,15 +575,30 @@ func (c *Container) build(key string) (retErr error) {
 		}()
 		val, err = ctor(c)
 	}()
+	elapsed := time.Since(t0)
+	c.record(Event{Kind: EventBuildEnd, Key: key, Duration: elapsed, Err: err})
 
 	c.mu.Lock()
 	// Pop our entry off the build stack (regardless of error).
 	if n := len(c.buildStack); n > 0 && c.buildStack[n-1] == key {
 		c.buildStack = c.buildStack[:n-1]
 	}
+	// Charge our inclusive build time to the caller so its own duration
+	// excludes the dependencies it pulled in.
+	if n := len(c.buildStack); n > 0 {
+		if caller := c.providers[c.buildStack[n-1]]; caller != nil {
+			caller.nestedBuild += elapsed
+		}
+	}
+	p.metrics.BuildDuration = elapsed - p.nestedBuild
 	if err != nil {
+		var be *buildError
+		if !errors.As(err, &be) {
+			p.metrics.StartFailures++
+		}
+		p.status = ComponentFailed
 		c.mu.Unlock()
-		return fmt.Errorf("construct %s: %w", key, err)
+		return &buildError{key: key, err: err}
 	}
 	p.value = val
 	p.built = true
@@ -394,15 +626,52 @@ func (c *Container) recordDepEdgeLocked(key string) {
 	caller.deps = append(caller.deps, key)
 }
 
+// publishResolvedLocked publishes a fresh snapshot of all built values for
+// the lock-free Get path. Caller holds c.mu.
+func (c *Container) publishResolvedLocked() {
+	m := make(map[string]any, len(c.buildOrder))
+	for _, k := range c.buildOrder {
+		if p := c.providers[k]; p.built {
+			m[k] = p.value
+		}
+	}
+	c.resolved.Store(&m)
+}
+
+// recordMissingLocked notes that the top-of-stack provider asked for an
+// unregistered key. mu must be held by the caller.
+func (c *Container) recordMissingLocked(key string) {
+	n := len(c.buildStack)
+	if n == 0 {
+		return
+	}
+	caller := c.providers[c.buildStack[n-1]]
+	if caller == nil || slices.Contains(caller.missing, key) {
+		return
+	}
+	caller.missing = append(caller.missing, key)
+}
+
 // ---------------------------------------------------------------------------
 // Lifecycle
 // ---------------------------------------------------------------------------
 
+// setStateLocked moves the container to state. Caller holds c.mu.
+func (c *Container) setStateLocked(state State) {
+	c.state = state
+	c.record(Event{Kind: EventState, Detail: state.String()})
+}
+
 // Start constructs all registered components and starts them in dependency
-// order. Hooks: onStart → Starter.Start (dependency order) → onStarted.
+// order, then warms up the [Warmable] ones in parallel.
+// Hooks: onStart → Starter.Start (dependency order) → Warmable.Warmup →
+// onStarted. The container only enters StateRunning once every warm-up has
+// succeeded, so readiness checks based on [Container.State] do not pass early.
 //
 // If any component fails to start, already-started components are stopped
-// in reverse order (best-effort) before returning the error.
+// in reverse order (best-effort) before returning the error, unless the
+// container was created with [WithPartialStart]. Either way the container
+// ends up in StateFailed and [Container.RetryFailed] can resume it.
 //
 // Start respects ctx cancellation: if ctx is cancelled during the build
 // phase, the next build step returns ctx.Err.
@@ -413,20 +682,58 @@ func (c *Container) Start(ctx context.Context) error {
 		c.mu.Unlock()
 		return fmt.Errorf("cx: cannot start in state %s", state)
 	}
-	c.state = StateStarting
-	c.buildOrder = c.buildOrder[:0]
-	c.buildStack = c.buildStack[:0]
 	// Reset deps from any previous run.
 	for _, p := range c.providers {
 		p.deps = nil
+		p.missing = nil
+		p.status = ComponentNew
 	}
-	keys := make([]string, len(c.keys))
-	copy(keys, c.keys)
+	if err := c.applyOverridesLocked(); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.setStateLocked(StateStarting)
+	c.buildOrder = c.buildOrder[:0]
+	c.buildStack = c.buildStack[:0]
+	c.onStartDone = false
 	c.mu.Unlock()
 
+	return c.run(ctx)
+}
+
+// RetryFailed resumes a container left in StateFailed by Start: it builds
+// the components that failed to construct, starts every component that
+// is not running yet and warms up those not warmed up yet, skipping the
+// rest. Hooks already run by the
+// failed Start (onStart) are not repeated.
+//
+// Combined with [WithPartialStart] this re-attempts only the failed
+// components while the healthy ones keep serving.
+func (c *Container) RetryFailed(ctx context.Context) error {
+	c.mu.Lock()
+	if c.state != StateFailed {
+		state := c.state
+		c.mu.Unlock()
+		return fmt.Errorf("cx: cannot retry in state %s", state)
+	}
+	c.setStateLocked(StateStarting)
+	c.buildStack = c.buildStack[:0]
+	c.mu.Unlock()
+
+	return c.run(ctx)
+}
+
+// run drives the build and start phases for Start and RetryFailed.
+// Components that are already built or running are skipped.
+func (c *Container) run(ctx context.Context) error {
+	c.mu.RLock()
+	keys := c.buildKeysLocked()
+	partial := c.partialStart
+	c.mu.RUnlock()
+
 	setFailed := func() {
 		c.mu.Lock()
-		c.state = StateFailed
+		c.setStateLocked(StateFailed)
 		c.mu.Unlock()
 	}
 
@@ -443,11 +750,19 @@ func (c *Container) Start(ctx context.Context) error {
 	}
 
 	// ---- onStart hooks ----
-	for _, fn := range c.onStart {
-		if err := fn(ctx); err != nil {
-			setFailed()
-			return fmt.Errorf("cx: onStart hook: %w", err)
+	c.mu.RLock()
+	onStartDone := c.onStartDone
+	c.mu.RUnlock()
+	if !onStartDone {
+		for _, fn := range c.onStart {
+			if err := fn(ctx); err != nil {
+				setFailed()
+				return fmt.Errorf("cx: onStart hook: %w", err)
+			}
 		}
+		c.mu.Lock()
+		c.onStartDone = true
+		c.mu.Unlock()
 	}
 
 	// ---- Start phase (dependency order) ----
@@ -457,14 +772,21 @@ func (c *Container) Start(ctx context.Context) error {
 	}
 	var startedComps []started
 
+	// rollback stops the components started by this run. In partial mode
+	// it is a no-op: started components keep running for RetryFailed.
 	rollback := func() {
+		if partial {
+			return
+		}
 		for _, s := range slices.Backward(startedComps) {
-			stopCtx, cancel := context.WithTimeout(context.Background(), c.stopTimeout)
-			_ = s.stop(stopCtx) // best-effort
-			cancel()
+			_ = c.stopComponent(context.Background(), s.key, s.stop) // best-effort
 
 			c.mu.Lock()
-			c.providers[s.key].started = false
+			p := c.providers[s.key]
+			p.started = false
+			p.running = false
+			p.warmed = false
+			p.status = ComponentStopped
 			c.mu.Unlock()
 		}
 	}
@@ -475,24 +797,49 @@ func (c *Container) Start(ctx context.Context) error {
 	c.mu.RUnlock()
 
 	for _, key := range order {
-		c.mu.RLock()
+		c.mu.Lock()
 		p := c.providers[key]
+		if p.running {
+			c.mu.Unlock()
+			continue
+		}
+		p.status = ComponentStarting
 		val := p.value
-		c.mu.RUnlock()
+		c.mu.Unlock()
 
 		if s, ok := val.(Starter); ok {
-			if err := s.Start(ctx); err != nil {
+			t0 := time.Now()
+			err := s.Start(ctx)
+			elapsed := time.Since(t0)
+			c.record(Event{Kind: EventStart, Key: key, Duration: elapsed, Err: err})
+			c.mu.Lock()
+			p.metrics.StartDuration = elapsed
+			if err != nil {
+				p.metrics.StartFailures++
+				p.status = ComponentFailed
+			}
+			c.mu.Unlock()
+			if err != nil {
 				rollback()
 				setFailed()
 				return fmt.Errorf("cx: start %s: %w", key, err)
 			}
 		}
+		c.mu.Lock()
 		if s, ok := val.(Stopper); ok {
 			startedComps = append(startedComps, started{key: key, stop: s.Stop})
-			c.mu.Lock()
 			p.started = true
-			c.mu.Unlock()
 		}
+		p.running = true
+		p.status = ComponentRunning
+		c.mu.Unlock()
+	}
+
+	// ---- Warm-up phase ----
+	if err := c.warmup(ctx, order); err != nil {
+		rollback()
+		setFailed()
+		return err
 	}
 
 	// ---- onStarted hooks ----
@@ -505,12 +852,20 @@ func (c *Container) Start(ctx context.Context) error {
 	}
 
 	c.mu.Lock()
-	c.state = StateRunning
+	c.setStateLocked(StateRunning)
+	if !c.noAutoFreeze {
+		c.frozen = true
+	}
+	c.publishResolvedLocked()
+	c.startHealthMonitorLocked()
 	c.mu.Unlock()
 	return nil
 }
 
-// Stop stops all components in reverse dependency order.
+// Stop stops all components in reverse dependency order: every component is
+// stopped before the components it pulled in via Get, regardless of
+// registration order. Each Stopper is bounded by its stop timeout (see
+// [WithStopTimeout] and [WithComponentStopTimeout]).
 // Hooks: onStopping → Stopper.Stop (reverse) → onStop.
 // Errors are collected and returned as a joined error.
 func (c *Container) Stop(ctx context.Context) error {
@@ -520,11 +875,14 @@ func (c *Container) Stop(ctx context.Context) error {
 		c.mu.Unlock()
 		return fmt.Errorf("cx: cannot stop in state %s", state)
 	}
-	c.state = StateStopping
+	c.setStateLocked(StateStopping)
+	c.resolved.Store(nil)
 	order := make([]string, len(c.buildOrder))
 	copy(order, c.buildOrder)
 	c.mu.Unlock()
 
+	c.stopHealthMonitor()
+
 	var errs []error
 
 	// onStopping hooks
@@ -542,11 +900,17 @@ func (c *Container) Stop(ctx context.Context) error {
 		started := p.started
 		c.mu.RUnlock()
 		if s, ok := val.(Stopper); ok && started {
-			stopCtx, cancel := context.WithTimeout(ctx, c.stopTimeout)
-			if err := s.Stop(stopCtx); err != nil {
+			t0 := time.Now()
+			err := c.stopComponent(ctx, key, s.Stop)
+			c.mu.Lock()
+			p.metrics.StopDuration = time.Since(t0)
+			if err != nil {
+				p.metrics.StopFailures++
+			}
+			c.mu.Unlock()
+			if err != nil {
 				errs = append(errs, fmt.Errorf("cx: stop %s: %w", key, err))
 			}
-			cancel()
 		}
 	}
 
@@ -559,12 +923,18 @@ func (c *Container) Stop(ctx context.Context) error {
 
 	// Reset build state for potential Restart.
 	c.mu.Lock()
-	c.state = StateStopped
+	c.setStateLocked(StateStopped)
 	for _, p := range c.providers {
 		p.built = false
 		p.started = false
+		p.running = false
+		p.warmed = false
 		p.value = nil
 		p.deps = nil
+		p.missing = nil
+		if p.status != ComponentNew && p.status != ComponentDisabled {
+			p.status = ComponentStopped
+		}
 	}
 	c.buildOrder = c.buildOrder[:0]
 	c.mu.Unlock()
@@ -572,6 +942,100 @@ func (c *Container) Stop(ctx context.Context) error {
 	return errors.Join(errs...)
 }
 
+// stopComponent runs stop bounded by the component's stop timeout. A Stopper
+// that ignores its context is abandoned once the timeout elapses so a single
+// hanging component cannot block the rest of the shutdown; its goroutine is
+// left running.
+func (c *Container) stopComponent(ctx context.Context, key string, stop func(context.Context) error) error {
+	timeout := c.stopTimeout
+	if d, ok := c.componentStopTimeouts[key]; ok {
+		timeout = d
+	}
+	t0 := time.Now()
+	err := runBounded(ctx, timeout, stop)
+	c.record(Event{Kind: EventStop, Key: key, Duration: time.Since(t0), Err: err})
+	return err
+}
+
+// warmup runs Warmable.Warmup for every started component in order that has
+// not been warmed up yet, at most warmupConcurrency at a time. Each call is
+// bounded by the warm-up timeout; a Warmable that ignores its context is
+// abandoned once the timeout elapses. All warm-ups run to completion and
+// their errors are joined.
+func (c *Container) warmup(ctx context.Context, order []string) error {
+	type warming struct {
+		p *provider
+		w Warmable
+	}
+	var pending []warming
+	c.mu.Lock()
+	for _, key := range order {
+		p := c.providers[key]
+		if w, ok := p.value.(Warmable); ok && p.running && !p.warmed {
+			p.status = ComponentWarming
+			pending = append(pending, warming{p: p, w: w})
+		}
+	}
+	timeout := c.warmupTimeout
+	limit := c.warmupConcurrency
+	c.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	if limit <= 0 || limit > len(pending) {
+		limit = len(pending)
+	}
+
+	errs := make([]error, len(pending))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, w := range pending {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			t0 := time.Now()
+			err := runBounded(ctx, timeout, w.w.Warmup)
+			elapsed := time.Since(t0)
+			c.record(Event{Kind: EventWarmup, Key: w.p.key, Duration: elapsed, Err: err})
+			c.mu.Lock()
+			w.p.metrics.WarmupDuration = elapsed
+			if err != nil {
+				w.p.metrics.WarmupFailures++
+				w.p.status = ComponentFailed
+			} else {
+				w.p.warmed = true
+				w.p.status = ComponentRunning
+			}
+			c.mu.Unlock()
+			if err != nil {
+				errs[i] = fmt.Errorf("cx: warmup %s: %w", w.p.key, err)
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runBounded runs fn bounded by timeout. If fn ignores its context it is
+// abandoned once the timeout elapses or ctx ends, whichever comes first; its
+// goroutine is left running.
+func runBounded(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+	bounded, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(bounded) }()
+	select {
+	case err := <-done:
+		return err
+	case <-bounded.Done():
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("abandoned: %w", err)
+		}
+		return fmt.Errorf("abandoned after %s: %w", timeout, bounded.Err())
+	}
+}
+
 // Restart shuts down then starts the container. Constructors are re-invoked.
 func (c *Container) Restart(ctx context.Context) error {
 	if err := c.Stop(ctx); err != nil {
@@ -588,6 +1052,7 @@ func (c *Container) Restart(ctx context.Context) error {
 // an aggregated report. Checks run concurrently; each check is bounded by the
 // configured health timeout (see [WithHealthTimeout], default 10s).
 func (c *Container) HealthCheck(ctx context.Context) HealthReport {
+	checkedAt := time.Now()
 	c.mu.RLock()
 	order := make([]string, len(c.buildOrder))
 	copy(order, c.buildOrder)
@@ -601,44 +1066,58 @@ func (c *Container) HealthCheck(ctx context.Context) HealthReport {
 	results := make([]ComponentHealth, len(order))
 	var wg sync.WaitGroup
 	for i := range order {
-		ch := ComponentHealth{Key: order[i], Healthy: true}
-		checker, ok := values[i].(HealthChecker)
-		if !ok {
+		ch := ComponentHealth{Key: order[i], Healthy: true, Score: 1}
+		if !isHealthChecked(values[i]) {
 			results[i] = ch
 			continue
 		}
+		ch.Weight = c.healthWeight(order[i])
 		wg.Add(1)
-		go func(idx int, key string, ck HealthChecker) {
+		go func(idx int, h ComponentHealth, v any) {
 			defer wg.Done()
 			cctx, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
-			h := ComponentHealth{Key: key, Healthy: true}
-			if err := ck.HealthCheck(cctx); err != nil {
-				h.Healthy = false
-				h.Error = err
-			}
+			h.Score, h.Error = checkHealth(cctx, v)
+			h.Healthy = h.Error == nil
 			results[idx] = h
-		}(i, order[i], checker)
+		}(i, ch, values[i])
 	}
 	wg.Wait()
+	for i, r := range results {
+		if isHealthChecked(values[i]) {
+			c.recordHealth(r)
+		}
+	}
 
-	report := HealthReport{Components: results, Healthy: true}
+	report := HealthReport{Components: results, Healthy: true, CheckedAt: checkedAt}
+	var weighted, total float64
 	for _, r := range results {
 		if !r.Healthy {
 			report.Healthy = false
-			break
 		}
+		weighted += r.Score * r.Weight
+		total += r.Weight
+	}
+	report.Score = 1
+	if total > 0 {
+		report.Score = weighted / total
 	}
 	return report
 }
 
-// Metrics returns basic container metrics.
+// Metrics returns container counts and per-component lifecycle timings.
 func (c *Container) Metrics() ContainerMetrics {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	comps := make([]ComponentMetrics, len(c.keys))
+	for i, k := range c.keys {
+		comps[i] = c.providers[k].metrics
+	}
 	return ContainerMetrics{
 		ComponentCount: len(c.providers),
 		State:          c.state,
+		Components:     comps,
+		StartOrder:     slices.Clone(c.buildOrder),
 	}
 }
 
@@ -689,6 +1168,29 @@ func (c *Container) Count() int {
 	return len(c.providers)
 }
 
+// ComponentState returns the lifecycle state of the component registered
+// under key.
+func (c *Container) ComponentState(key string) (ComponentState, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.providers[key]
+	if !ok {
+		return ComponentNew, false
+	}
+	return p.status, true
+}
+
+// ComponentStates returns the lifecycle state of every registered component.
+func (c *Container) ComponentStates() map[string]ComponentState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[string]ComponentState, len(c.providers))
+	for k, p := range c.providers {
+		out[k] = p.status
+	}
+	return out
+}
+
 // State returns the current lifecycle state.
 func (c *Container) State() State {
 	c.mu.RLock()
@@ -1,10 +1,16 @@
 package cx
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -413,6 +419,73 @@ func (s *slowStopper) Stop(ctx context.Context) error {
 	}
 }
 
+// hangingStopper ignores its context and never returns until released.
+type hangingStopper struct{ release chan struct{} }
+
+func (h *hangingStopper) Stop(context.Context) error {
+	<-h.release
+	return nil
+}
+
+func TestStop_ConsumersBeforeProviders(t *testing.T) {
+	var record []string
+	c := New()
+	// Consumer registered first; it must still be stopped before its dependency.
+	Provide(c, "api", func(c *Container) (*orderRecorder, error) {
+		if _, err := Get[*orderRecorder](c, "db"); err != nil {
+			return nil, err
+		}
+		return &orderRecorder{key: "api", record: &record}, nil
+	})
+	Provide(c, "db", func(_ *Container) (*orderRecorder, error) {
+		return &orderRecorder{key: "db", record: &record}, nil
+	})
+	require.NoError(t, c.Start(context.Background()))
+	require.NoError(t, c.Stop(context.Background()))
+
+	assert.Equal(t, []string{"start:db", "start:api", "stop:api", "stop:db"}, record)
+}
+
+func TestStop_HangingComponentIsAbandoned(t *testing.T) {
+	hang := &hangingStopper{release: make(chan struct{})}
+	defer close(hang.release)
+	db := &testDB{}
+
+	c := New(WithComponentStopTimeout("hang", 50*time.Millisecond))
+	Supply(c, "db", db)
+	Provide(c, "hang", func(c *Container) (*hangingStopper, error) {
+		_, err := Get[*testDB](c, "db")
+		return hang, err
+	})
+	require.NoError(t, c.Start(context.Background()))
+
+	t0 := time.Now()
+	err := c.Stop(context.Background())
+	assert.Less(t, time.Since(t0), time.Second)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "cx: stop hang: abandoned after 50ms")
+	assert.True(t, db.stopped, "providers are still stopped after a hanging consumer")
+
+	// The caller's context ends before the per-component timeout: the error
+	// reports the context, not the timeout that never elapsed.
+	hang2 := &hangingStopper{release: make(chan struct{})}
+	defer close(hang2.release)
+	c = New(WithComponentStopTimeout("hang", time.Minute))
+	Supply(c, "hang", hang2)
+	require.NoError(t, c.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	t0 = time.Now()
+	err = c.Stop(ctx)
+	assert.Less(t, time.Since(t0), time.Second)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "cx: stop hang: abandoned: context deadline exceeded")
+	assert.NotContains(t, err.Error(), "abandoned after")
+}
+
 // ---------------------------------------------------------------------------
 // Restart
 // ---------------------------------------------------------------------------
@@ -436,7 +509,7 @@ func TestRestart(t *testing.T) {
 }
 
 func TestProvide_AfterStop(t *testing.T) {
-	c := New()
+	c := New(WithoutAutoFreeze())
 	require.NoError(t, Supply(c, "x", 1))
 	require.NoError(t, c.Start(context.Background()))
 	require.NoError(t, c.Stop(context.Background()))
@@ -450,6 +523,49 @@ func TestProvide_AfterStop(t *testing.T) {
 	assert.Equal(t, 2, v)
 }
 
+func TestFreeze_AfterStart(t *testing.T) {
+	ctx := context.Background()
+	c := New()
+	require.NoError(t, Supply(c, "x", 1))
+	assert.False(t, c.Frozen())
+	require.NoError(t, c.Start(ctx))
+	assert.True(t, c.Frozen())
+	require.NoError(t, c.Stop(ctx))
+
+	err := Supply(c, "y", 2)
+	assert.ErrorIs(t, err, ErrContainerFrozen)
+	_, err = ProvideWhen(c, "", "y", func(*Container) (int, error) { return 2, nil })
+	assert.ErrorIs(t, err, ErrContainerFrozen)
+	assert.False(t, c.Has("y"))
+
+	// Explicit opt-ins still work while idle.
+	require.NoError(t, SupplyLate(c, "y", 2))
+	require.NoError(t, SwapValue(c, "x", 10))
+	require.NoError(t, c.Start(ctx))
+	assert.Equal(t, 10, MustGet[int](c, "x"))
+	assert.Equal(t, 2, MustGet[int](c, "y"))
+	assert.ErrorIs(t, SupplyLate(c, "z", 3), ErrContainerNotIdle)
+	assert.ErrorIs(t, SwapValue(c, "x", 11), ErrContainerNotIdle)
+}
+
+func TestFreeze_FailedStartDoesNotFreeze(t *testing.T) {
+	c := New()
+	require.NoError(t, Provide(c, "x", func(*Container) (int, error) { return 0, errors.New("boom") }))
+	require.Error(t, c.Start(context.Background()))
+	assert.False(t, c.Frozen())
+}
+
+func TestFreeze_Manual(t *testing.T) {
+	c := New(WithoutAutoFreeze())
+	require.NoError(t, Supply(c, "x", 1))
+	c.Freeze()
+	assert.ErrorIs(t, Supply(c, "y", 2), ErrContainerFrozen)
+	assert.ErrorIs(t, SupplyKey(c, NewKey[int]("y"), 2), ErrContainerFrozen)
+
+	assert.ErrorIs(t, SwapValue(c, "missing", 1), ErrComponentNotFound)
+	assert.ErrorIs(t, SupplyLate(c, "x", 2), ErrComponentExists)
+}
+
 // ---------------------------------------------------------------------------
 // Health check
 // ---------------------------------------------------------------------------
@@ -487,6 +603,132 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+// scoredService is a HealthScorer with a fixed score.
+type scoredService struct {
+	score float64
+	err   error
+}
+
+func (s *scoredService) HealthScore(context.Context) (float64, error) { return s.score, s.err }
+
+func TestHealthScore(t *testing.T) {
+	c := New(WithHealthWeight("db", 3), WithHealthWeight("ignored", 0))
+	Supply(c, "db", &scoredService{score: 0.5})
+	Supply(c, "cache", &testService{healthy: true})
+	Supply(c, "clamped", &scoredService{score: 7})
+	Supply(c, "ignored", &scoredService{err: errors.New("down")})
+	Supply(c, "plain", 42)
+
+	require.NoError(t, c.Start(context.Background()))
+	report := c.HealthCheck(context.Background())
+
+	scores := make(map[string]ComponentHealth)
+	for _, ch := range report.Components {
+		scores[ch.Key] = ch
+	}
+	assert.Equal(t, 0.5, scores["db"].Score)
+	assert.Equal(t, 3.0, scores["db"].Weight)
+	assert.Equal(t, 1.0, scores["clamped"].Score)
+	assert.False(t, scores["ignored"].Healthy)
+	assert.Zero(t, scores["plain"].Weight)
+
+	// (0.5*3 + 1 + 1) / 5; "ignored" has weight 0
+	assert.InDelta(t, 0.7, report.Score, 1e-9)
+	assert.False(t, report.Healthy)
+
+	rec := httptest.NewRecorder()
+	ScoreHandler(c, 0.5).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/lb", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code) // "ignored" is unhealthy
+	assert.Equal(t, "0.70", rec.Header().Get("X-Health-Score"))
+	assert.Equal(t, "70", rec.Header().Get("X-Weight"))
+	assert.Equal(t, "70%\n", rec.Body.String())
+}
+
+func TestScoreHandler_MinScore(t *testing.T) {
+	c := New()
+	Supply(c, "db", &scoredService{score: 0.4})
+	require.NoError(t, c.Start(context.Background()))
+
+	rec := httptest.NewRecorder()
+	ScoreHandler(c, 0.3).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/lb", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	ScoreHandler(c, 0.5).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/lb", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "40", rec.Header().Get("X-Weight"))
+}
+
+// flakyChecker is a HealthChecker whose result can be flipped concurrently.
+type flakyChecker struct{ down atomic.Bool }
+
+func (f *flakyChecker) HealthCheck(context.Context) error {
+	if f.down.Load() {
+		return errors.New("down")
+	}
+	return nil
+}
+
+func TestHealthMonitor(t *testing.T) {
+	chk := &flakyChecker{}
+	changes := make(chan ComponentHealth, 10)
+	c := New(
+		WithHealthInterval(10*time.Millisecond),
+		WithOnHealthChange(func(h ComponentHealth) { changes <- h }),
+	)
+	Supply(c, "db", chk)
+
+	_, ok := c.CachedHealth()
+	assert.False(t, ok)
+
+	require.NoError(t, c.Start(context.Background()))
+	require.Eventually(t, func() bool {
+		_, ok := c.CachedHealth()
+		return ok
+	}, time.Second, 5*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	HealthHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	chk.down.Store(true)
+	select {
+	case h := <-changes:
+		assert.Equal(t, "db", h.Key)
+		assert.False(t, h.Healthy)
+		assert.EqualError(t, h.Error, "down")
+	case <-time.After(time.Second):
+		t.Fatal("expected unhealthy transition")
+	}
+
+	rec = httptest.NewRecorder()
+	HealthHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var body struct {
+		Healthy    bool `json:"healthy"`
+		Components []struct {
+			Key   string `json:"key"`
+			Error string `json:"error"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.False(t, body.Healthy)
+	require.Len(t, body.Components, 1)
+	assert.Equal(t, "down", body.Components[0].Error)
+
+	chk.down.Store(false)
+	select {
+	case h := <-changes:
+		assert.True(t, h.Healthy)
+	case <-time.After(time.Second):
+		t.Fatal("expected healthy transition")
+	}
+
+	require.NoError(t, c.Stop(context.Background()))
+	_, ok = c.CachedHealth()
+	assert.False(t, ok)
+}
+
 // ---------------------------------------------------------------------------
 // Hooks
 // ---------------------------------------------------------------------------
@@ -570,6 +812,223 @@ func TestStart_RollbackDoesNotStopComponentTwice(t *testing.T) {
 	assert.Equal(t, 1, svc.stops)
 }
 
+// ---------------------------------------------------------------------------
+// Component states / partial start
+// ---------------------------------------------------------------------------
+
+// flakyStarter fails its first n Start calls.
+type flakyStarter struct {
+	failures int
+	starts   int
+	stops    int
+}
+
+func (f *flakyStarter) Start(context.Context) error {
+	f.starts++
+	if f.starts <= f.failures {
+		return errors.New("not ready")
+	}
+	return nil
+}
+func (f *flakyStarter) Stop(context.Context) error { f.stops++; return nil }
+
+func TestPartialStart_RetryFailed(t *testing.T) {
+	c := New(WithPartialStart())
+	db := &countingComponent{}
+	cache := &flakyStarter{failures: 1}
+	svc := &countingComponent{}
+	require.NoError(t, Supply(c, "db", db))
+	require.NoError(t, Supply(c, "cache", cache))
+	require.NoError(t, Supply(c, "svc", svc))
+
+	err := c.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "start cache")
+	assert.Equal(t, StateFailed, c.State())
+
+	// db keeps running, cache failed, svc was never started
+	assert.Equal(t, map[string]ComponentState{
+		"db":    ComponentRunning,
+		"cache": ComponentFailed,
+		"svc":   ComponentStarting,
+	}, c.ComponentStates())
+	assert.Equal(t, 0, db.stops)
+
+	require.NoError(t, c.RetryFailed(context.Background()))
+	assert.Equal(t, StateRunning, c.State())
+	assert.Equal(t, 1, db.starts, "running components are not restarted")
+	assert.Equal(t, 2, cache.starts)
+	assert.Equal(t, 1, svc.starts)
+
+	st, ok := c.ComponentState("cache")
+	assert.True(t, ok)
+	assert.Equal(t, ComponentRunning, st)
+
+	require.NoError(t, c.Stop(context.Background()))
+	assert.Equal(t, ComponentStopped, c.ComponentStates()["svc"])
+}
+
+func TestRetryFailed_AfterRollback(t *testing.T) {
+	c := New()
+	db := &countingComponent{}
+	cache := &flakyStarter{failures: 1}
+	require.NoError(t, Supply(c, "db", db))
+	require.NoError(t, Supply(c, "cache", cache))
+
+	require.Error(t, c.Start(context.Background()))
+	assert.Equal(t, 1, db.stops, "default mode rolls back")
+	assert.Equal(t, ComponentStopped, c.ComponentStates()["db"])
+
+	require.NoError(t, c.RetryFailed(context.Background()))
+	assert.Equal(t, 2, db.starts)
+	assert.Equal(t, StateRunning, c.State())
+}
+
+func TestRetryFailed_BuildFailure(t *testing.T) {
+	c := New(WithPartialStart())
+	attempts := 0
+	Provide(c, "cfg", func(_ *Container) (string, error) {
+		attempts++
+		if attempts == 1 {
+			return "", errors.New("config unavailable")
+		}
+		return "ok", nil
+	})
+
+	require.Error(t, c.Start(context.Background()))
+	assert.Equal(t, ComponentFailed, c.ComponentStates()["cfg"])
+
+	require.NoError(t, c.RetryFailed(context.Background()))
+	assert.Equal(t, "ok", mustGet[string](t, c, "cfg"))
+}
+
+func TestRetryFailed_NotFailed(t *testing.T) {
+	c := New()
+	err := c.RetryFailed(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot retry in state new")
+}
+
+// ---------------------------------------------------------------------------
+// Warm-up
+// ---------------------------------------------------------------------------
+
+// warmComponent records its warm-ups and the peak number of concurrent ones.
+type warmComponent struct {
+	countingComponent
+	warmups  int
+	failures int // fail this many warm-ups before succeeding
+	delay    time.Duration
+	active   *atomic.Int32
+	peak     *atomic.Int32
+	state    func() State // container state observed during Warmup
+	observed State
+}
+
+func (w *warmComponent) Warmup(ctx context.Context) error {
+	w.warmups++
+	if w.state != nil {
+		w.observed = w.state()
+	}
+	if w.active != nil {
+		n := w.active.Add(1)
+		defer w.active.Add(-1)
+		for {
+			p := w.peak.Load()
+			if n <= p || w.peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+	}
+	select {
+	case <-time.After(w.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if w.warmups <= w.failures {
+		return errors.New("cache not ready")
+	}
+	return nil
+}
+
+func TestWarmup_BeforeRunning(t *testing.T) {
+	var record []string
+	c := New(WithOnStarted(func(context.Context) error {
+		record = append(record, "onStarted")
+		return nil
+	}))
+	cache := &warmComponent{}
+	cache.state = c.State
+	require.NoError(t, Supply(c, "cache", cache))
+
+	require.NoError(t, c.Start(context.Background()))
+	assert.Equal(t, 1, cache.starts)
+	assert.Equal(t, 1, cache.warmups)
+	assert.Equal(t, StateStarting, cache.observed, "warm-up runs before the container is running")
+	assert.Equal(t, ComponentRunning, c.ComponentStates()["cache"])
+	assert.Equal(t, StateRunning, c.State())
+
+	require.NoError(t, c.Restart(context.Background()))
+	assert.Equal(t, 2, cache.warmups, "warm-up runs again after restart")
+}
+
+func TestWarmup_Concurrency(t *testing.T) {
+	var active, peak atomic.Int32
+	c := New(WithWarmupConcurrency(2))
+	for i := range 5 {
+		w := &warmComponent{delay: 20 * time.Millisecond, active: &active, peak: &peak}
+		require.NoError(t, Supply(c, fmt.Sprintf("w%d", i), w))
+	}
+
+	require.NoError(t, c.Start(context.Background()))
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestWarmup_FailureRollsBack(t *testing.T) {
+	c := New(WithWarmupTimeout(20 * time.Millisecond))
+	db := &countingComponent{}
+	cache := &warmComponent{failures: 1}
+	slow := &warmComponent{delay: time.Second}
+	require.NoError(t, Supply(c, "db", db))
+	require.NoError(t, Supply(c, "cache", cache))
+	require.NoError(t, Supply(c, "slow", slow))
+
+	err := c.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cx: warmup cache: cache not ready")
+	assert.Contains(t, err.Error(), "cx: warmup slow: abandoned after 20ms")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, StateFailed, c.State())
+	assert.Equal(t, 1, db.stops, "started components are rolled back")
+	assert.Equal(t, 1, cache.stops)
+
+	for _, m := range c.Metrics().Components {
+		if m.Key == "cache" || m.Key == "slow" {
+			assert.Equal(t, 1, m.WarmupFailures, m.Key)
+		}
+	}
+}
+
+func TestWarmup_PartialStartRetry(t *testing.T) {
+	c := New(WithPartialStart())
+	ok := &warmComponent{}
+	cache := &warmComponent{failures: 1}
+	require.NoError(t, Supply(c, "ok", ok))
+	require.NoError(t, Supply(c, "cache", cache))
+
+	require.Error(t, c.Start(context.Background()))
+	assert.Equal(t, map[string]ComponentState{
+		"ok":    ComponentRunning,
+		"cache": ComponentFailed,
+	}, c.ComponentStates())
+
+	require.NoError(t, c.RetryFailed(context.Background()))
+	assert.Equal(t, StateRunning, c.State())
+	assert.Equal(t, 1, cache.starts, "failed warm-up does not restart the component")
+	assert.Equal(t, 2, cache.warmups)
+	assert.Equal(t, 1, ok.warmups, "warmed components are not warmed again")
+}
+
 // ---------------------------------------------------------------------------
 // Query helpers
 // ---------------------------------------------------------------------------
@@ -586,6 +1045,45 @@ func TestKeys_Has_Count(t *testing.T) {
 	assert.Equal(t, 3, c.Count())
 }
 
+func TestGraph_ExportJSONAndDOT(t *testing.T) {
+	c := New()
+	Provide(c, "svc", func(c *Container) (int, error) {
+		_, _ = Get[string](c, "cache") // optional dependency, tolerated when absent
+		return Get[int](c, "db")
+	})
+	Supply(c, "db", 1)
+	require.NoError(t, c.Start(context.Background()))
+
+	g := c.Graph()
+	assert.Equal(t, []string{"db", "svc"}, g.Order)
+	assert.Equal(t, []string{"cache"}, g.Missing)
+	require.Len(t, g.Nodes, 2)
+	assert.Equal(t, GraphNode{Key: "svc", State: "running", Type: "int", Deps: []string{"db"}, Missing: []string{"cache"}}, g.Nodes[0])
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteJSON(&buf))
+	var decoded Graph
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, g, decoded)
+
+	buf.Reset()
+	require.NoError(t, g.WriteDOT(&buf))
+	dot := buf.String()
+	assert.Contains(t, dot, `"svc" [label="#2 svc\nint"];`)
+	assert.Contains(t, dot, `"svc" -> "db";`)
+	assert.Contains(t, dot, `"svc" -> "cache" [style=dashed, color=red];`)
+
+	rec := httptest.NewRecorder()
+	GraphHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/cx?format=dot", nil))
+	assert.Equal(t, dot, rec.Body.String())
+
+	// Edges are reset by Stop.
+	require.NoError(t, c.Stop(context.Background()))
+	g = c.Graph()
+	assert.Empty(t, g.Order)
+	assert.Empty(t, g.Missing)
+}
+
 func TestMetrics(t *testing.T) {
 	c := New()
 	Supply(c, "x", 1)
@@ -596,6 +1094,260 @@ func TestMetrics(t *testing.T) {
 	assert.Equal(t, StateNew, m.State)
 }
 
+func TestMetrics_StartOrder(t *testing.T) {
+	c := New()
+	Provide(c, "svc", func(c *Container) (int, error) {
+		return Get[int](c, "db")
+	})
+	Supply(c, "b", 2)
+	Supply(c, "a", 1)
+	Supply(c, "db", 0)
+
+	assert.Empty(t, c.Metrics().StartOrder)
+	require.NoError(t, c.Start(context.Background()))
+	// db is pulled ahead of svc; independent components keep registration order
+	assert.Equal(t, []string{"db", "svc", "b", "a"}, c.Metrics().StartOrder)
+}
+
+func TestMetrics_ComponentTimings(t *testing.T) {
+	c := New()
+	Provide(c, "dep", func(_ *Container) (int, error) {
+		time.Sleep(30 * time.Millisecond)
+		return 1, nil
+	})
+	Provide(c, "svc", func(c *Container) (*badStopper, error) {
+		if _, err := Get[int](c, "dep"); err != nil {
+			return nil, err
+		}
+		return &badStopper{name: "svc"}, nil
+	})
+
+	require.NoError(t, c.Start(context.Background()))
+	require.Error(t, c.Stop(context.Background()))
+
+	m := c.Metrics()
+	require.Len(t, m.Components, 2)
+	dep, svc := m.Components[0], m.Components[1]
+	assert.Equal(t, "dep", dep.Key)
+	assert.GreaterOrEqual(t, dep.BuildDuration, 30*time.Millisecond)
+	// svc's own build time excludes the time spent building dep
+	assert.Less(t, svc.BuildDuration, 30*time.Millisecond)
+	assert.Equal(t, 1, svc.StopFailures)
+	assert.Equal(t, 0, dep.StopFailures)
+}
+
+func TestMetrics_StartFailuresCountedOnce(t *testing.T) {
+	c := New()
+	// svc is built first and pulls in bad, whose error bubbles through svc
+	Provide(c, "svc", func(c *Container) (int, error) {
+		return Get[int](c, "bad")
+	})
+	Provide(c, "bad", func(_ *Container) (int, error) {
+		return 0, errors.New("init failed")
+	})
+
+	require.Error(t, c.Start(context.Background()))
+
+	m := c.Metrics()
+	assert.Equal(t, 0, m.Components[0].StartFailures)
+	assert.Equal(t, 1, m.Components[1].StartFailures)
+}
+
+// ---------------------------------------------------------------------------
+// Profiles
+// ---------------------------------------------------------------------------
+
+func TestProfileActive(t *testing.T) {
+	c := New(WithProfiles("prod", " cache ", ""))
+	assert.Equal(t, []string{"prod", "cache"}, c.Profiles())
+
+	assert.True(t, c.ProfileActive(""))
+	assert.True(t, c.ProfileActive("prod"))
+	assert.True(t, c.ProfileActive("dev, cache"))
+	assert.False(t, c.ProfileActive("dev"))
+	assert.False(t, c.ProfileActive("!prod"))
+	assert.True(t, c.ProfileActive("!dev"))
+}
+
+func TestProvideWhen(t *testing.T) {
+	c := New(WithProfiles("prod"))
+
+	ok, err := SupplyWhen(c, "prod", "cache", "redis")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Mutually exclusive profiles may share a key.
+	ok, err = SupplyWhen(c, "!prod", "cache", "memory")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, MustProvideWhen(c, "dev", "debug", func(_ *Container) (int, error) { return 1, nil }))
+	assert.False(t, c.Has("debug"))
+
+	require.NoError(t, c.Start(context.Background()))
+	assert.Equal(t, "redis", mustGet[string](t, c, "cache"))
+}
+
+func TestSetProfiles_NotIdle(t *testing.T) {
+	c := New()
+	require.NoError(t, c.SetProfiles("dev"))
+	assert.True(t, c.ProfileActive("dev"))
+
+	require.NoError(t, c.Start(context.Background()))
+	assert.ErrorIs(t, c.SetProfiles("prod"), ErrContainerNotIdle)
+}
+
+// ---------------------------------------------------------------------------
+// Environment overrides
+// ---------------------------------------------------------------------------
+
+func TestEnvOverrides_OrderAndDisable(t *testing.T) {
+	t.Setenv("CX_ORDER_controller__payment", "-1")
+	t.Setenv("CX_DISABLE_handler__legacy", "true")
+	t.Setenv("CX_ORDER_unknown", "3")
+
+	var record []string
+	c := New(WithEnvOverrides())
+	for _, k := range []string{"db", "handler.legacy", "controller.payment"} {
+		require.NoError(t, Supply(c, k, &orderRecorder{key: k, record: &record}))
+	}
+
+	require.NoError(t, c.Start(context.Background()))
+	assert.Equal(t, []string{"start:controller.payment", "start:db"}, record)
+
+	_, err := Get[*orderRecorder](c, "handler.legacy")
+	assert.ErrorIs(t, err, ErrComponentDisabled)
+	state, _ := c.ComponentState("handler.legacy")
+	assert.Equal(t, ComponentDisabled, state)
+	assert.Equal(t, []Override{
+		{Env: "CX_DISABLE_handler__legacy", Key: "handler.legacy", Value: "true"},
+		{Env: "CX_ORDER_controller__payment", Key: "controller.payment", Value: "-1"},
+	}, c.Overrides())
+
+	// 覆盖项在每次 Start 时重新读取
+	require.NoError(t, c.Stop(context.Background()))
+	os.Unsetenv("CX_DISABLE_handler__legacy")
+	record = nil
+	require.NoError(t, c.Start(context.Background()))
+	assert.Equal(t, []string{"start:controller.payment", "start:db", "start:handler.legacy"}, record)
+}
+
+func TestEnvOverrides_DependencyFirst(t *testing.T) {
+	t.Setenv("CX_ORDER_SVC", "-1")
+
+	c := New(WithEnvOverrides())
+	Supply(c, "db", 0)
+	Supply(c, "cache", 0)
+	Provide(c, "svc", func(c *Container) (int, error) {
+		return Get[int](c, "db")
+	})
+
+	require.NoError(t, c.Start(context.Background()))
+	// svc 提前，但其依赖仍先于它构造
+	assert.Equal(t, []string{"db", "svc", "cache"}, c.Metrics().StartOrder)
+}
+
+func TestEnvOverrides_Errors(t *testing.T) {
+	t.Run("dependency disabled", func(t *testing.T) {
+		t.Setenv("CX_DISABLE_db", "1")
+		c := New(WithEnvOverrides())
+		Supply(c, "db", 0)
+		Provide(c, "svc", func(c *Container) (int, error) {
+			return Get[int](c, "db")
+		})
+		assert.ErrorIs(t, c.Start(context.Background()), ErrComponentDisabled)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("CX_ORDER_db", "first")
+		c := New(WithEnvOverrides())
+		Supply(c, "db", 0)
+		assert.ErrorContains(t, c.Start(context.Background()), "CX_ORDER_db")
+		assert.Equal(t, StateNew, c.State())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Setenv("CX_DISABLE_db", "true")
+		c := New()
+		Supply(c, "db", 0)
+		require.NoError(t, c.Start(context.Background()))
+		assert.Equal(t, 0, mustGet[int](t, c, "db"))
+		assert.Empty(t, c.Overrides())
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Typed keys / constructor providers
+// ---------------------------------------------------------------------------
+
+func TestProvideN_WiresByKey(t *testing.T) {
+	var (
+		cfgKey = NewKey[*testConfig]("config")
+		dbKey  = NewKey[*testDB]("db")
+		svcKey = NewKey[*testService]("service")
+	)
+
+	c := New()
+	// Register consumers before providers: order must come from the deps.
+	require.NoError(t, Provide1(c, svcKey, dbKey, func(db *testDB) (*testService, error) {
+		return &testService{db: db}, nil
+	}))
+	require.NoError(t, Provide1(c, dbKey, cfgKey, func(cfg *testConfig) (*testDB, error) {
+		return &testDB{cfg: cfg}, nil
+	}))
+	require.NoError(t, SupplyKey(c, cfgKey, &testConfig{DSN: "pg"}))
+	require.NoError(t, c.Start(context.Background()))
+
+	svc := MustGetKey(c, svcKey)
+	assert.Equal(t, "pg", svc.db.cfg.DSN)
+	assert.True(t, svc.db.started)
+	assert.Equal(t, []string{"db"}, c.DependencyGraph()["service"])
+	assert.Equal(t, []string{"config"}, c.DependencyGraph()["db"])
+}
+
+func TestProvideN_MultipleDeps(t *testing.T) {
+	var (
+		aKey   = NewKey[int]("a")
+		bKey   = NewKey[string]("b")
+		dKey   = NewKey[bool]("d")
+		eKey   = NewKey[float64]("e")
+		sumKey = NewKey[string]("sum")
+	)
+
+	c := New()
+	require.NoError(t, Provide0(c, aKey, func() (int, error) { return 1, nil }))
+	require.NoError(t, SupplyKey(c, bKey, "b"))
+	require.NoError(t, SupplyKey(c, dKey, true))
+	require.NoError(t, SupplyKey(c, eKey, 2.5))
+	require.NoError(t, Provide4(c, sumKey, aKey, bKey, dKey, eKey,
+		func(a int, b string, d bool, e float64) (string, error) {
+			return fmt.Sprintf("%d-%s-%t-%.1f", a, b, d, e), nil
+		}))
+	require.NoError(t, c.Start(context.Background()))
+
+	got, err := GetKey(c, sumKey)
+	require.NoError(t, err)
+	assert.Equal(t, "1-b-true-2.5", got)
+}
+
+func TestProvideN_MissingDependency(t *testing.T) {
+	c := New()
+	err := Provide1(c, NewKey[int]("x"), NewKey[string]("missing"), func(string) (int, error) {
+		return 0, nil
+	})
+	require.NoError(t, err)
+
+	err = c.Start(context.Background())
+	assert.ErrorIs(t, err, ErrComponentNotFound)
+}
+
+func TestProvideN_NilConstructor(t *testing.T) {
+	c := New()
+	err := Provide1[string, int](c, NewKey[int]("x"), NewKey[string]("y"), nil)
+	assert.Error(t, err)
+	assert.False(t, c.Has("x"))
+}
+
 // ---------------------------------------------------------------------------
 // Concurrency
 // ---------------------------------------------------------------------------
@@ -618,6 +1370,51 @@ func TestConcurrentGet(t *testing.T) {
 	wg.Wait()
 }
 
+func TestGet_LockFreeWhileRunning(t *testing.T) {
+	c := New()
+	Supply(c, "x", 42)
+	require.NoError(t, c.Start(context.Background()))
+
+	// Get must not touch mu once the container is running.
+	c.mu.Lock()
+	done := make(chan int)
+	go func() {
+		v, _ := Get[int](c, "x")
+		done <- v
+	}()
+	select {
+	case v := <-done:
+		assert.Equal(t, 42, v)
+	case <-time.After(time.Second):
+		t.Fatal("Get blocked on the container lock")
+	}
+	c.mu.Unlock()
+
+	_, err := Get[string](c, "x")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+
+	require.NoError(t, c.Stop(context.Background()))
+	_, err = Get[int](c, "x")
+	assert.ErrorIs(t, err, ErrComponentNotFound)
+}
+
+func BenchmarkGet_Running(b *testing.B) {
+	c := New()
+	for i := range 64 {
+		Supply(c, fmt.Sprintf("svc-%d", i), i)
+	}
+	require.NoError(b, c.Start(context.Background()))
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := Get[int](c, "svc-42"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 // ---------------------------------------------------------------------------
 // Global container
 // ---------------------------------------------------------------------------
@@ -626,3 +1423,150 @@ func TestGlobalC(t *testing.T) {
 	assert.NotNil(t, C)
 	assert.Equal(t, StateNew, C.State())
 }
+
+func TestCatalog(t *testing.T) {
+	var (
+		cfgKey = NewKey[*testConfig]("config")
+		dbKey  = NewKey[*testDB]("store.db")
+		svcKey = NewKey[*testService]("app.service")
+	)
+	c := New(WithProfiles("prod"))
+	require.NoError(t, SupplyKey(c, cfgKey, &testConfig{DSN: "x"}))
+	require.NoError(t, Provide1(c, dbKey, cfgKey, func(cfg *testConfig) (*testDB, error) {
+		return &testDB{cfg: cfg}, nil
+	}))
+	require.NoError(t, Provide1(c, svcKey, dbKey, func(db *testDB) (*testService, error) {
+		return &testService{db: db, healthy: true}, nil
+	}))
+	require.NoError(t, Provide(c, "store.cache", func(c *Container) (Stopper, error) {
+		return &countingComponent{}, nil
+	}))
+
+	// Before Start: types and interfaces come from the registered type,
+	// dependencies from the ProvideN declarations.
+	cat := c.Catalog()
+	assert.Equal(t, "new", cat.State)
+	assert.Equal(t, []string{"prod"}, cat.Profiles)
+	require.Len(t, cat.Namespaces, 3)
+	assert.Equal(t, []string{"", "app", "store"}, []string{cat.Namespaces[0].Name, cat.Namespaces[1].Name, cat.Namespaces[2].Name})
+	assert.Equal(t, CatalogComponent{
+		Key:        "app.service",
+		State:      "new",
+		Type:       "*cx.testService",
+		Interfaces: []string{"cx.Starter", "cx.Stopper", "cx.HealthChecker"},
+		Deps:       []string{"store.db"},
+	}, cat.Namespaces[1].Components[0])
+	db := cat.Namespaces[2].Components[0]
+	assert.Equal(t, []string{"config"}, db.Deps)
+	assert.Equal(t, []string{"app.service"}, db.Dependents)
+	cache := cat.Namespaces[2].Components[1]
+	assert.Empty(t, cache.Type, "interface-typed registration is unknown before build")
+	assert.Empty(t, cache.Interfaces)
+
+	// After Start: built values and the start order.
+	require.NoError(t, c.Start(context.Background()))
+	t.Cleanup(func() { _ = c.Stop(context.Background()) })
+	cat = c.Catalog()
+	assert.Equal(t, "running", cat.State)
+	cache = cat.Namespaces[2].Components[1]
+	assert.Equal(t, "*cx.countingComponent", cache.Type)
+	assert.Equal(t, []string{"cx.Starter", "cx.Stopper"}, cache.Interfaces)
+	assert.Equal(t, 1, cat.Namespaces[0].Components[0].StartIndex)
+	assert.Equal(t, 3, cat.Namespaces[1].Components[0].StartIndex)
+
+	var buf bytes.Buffer
+	require.NoError(t, cat.WriteJSON(&buf))
+	var decoded Catalog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, cat, decoded)
+
+	rec := httptest.NewRecorder()
+	CatalogHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/cx/catalog", nil))
+	assert.Equal(t, buf.String(), rec.Body.String())
+}
+
+func TestEventLog(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, New().Events())
+
+	chk := &flakyChecker{}
+	c := New(WithEventLog(64))
+	require.NoError(t, Supply(c, "db", &testDB{}))
+	require.NoError(t, Provide(c, "svc", func(c *Container) (*flakyChecker, error) {
+		if _, err := Get[*testDB](c, "db"); err != nil {
+			return nil, err
+		}
+		return chk, nil
+	}))
+	require.NoError(t, c.Start(ctx))
+
+	// Lock-free Gets are not recorded, failed ones are.
+	_ = mustGet[*testDB](t, c, "db")
+	_, err := Get[*testDB](c, "missing")
+	require.Error(t, err)
+
+	// Health results are recorded when they change.
+	c.HealthCheck(ctx)
+	c.HealthCheck(ctx)
+	chk.down.Store(true)
+	c.HealthCheck(ctx)
+
+	require.NoError(t, c.Stop(ctx))
+	require.NoError(t, SwapValue(c, "db", &testDB{}))
+
+	var got []string
+	for i, e := range c.Events() {
+		assert.Equal(t, uint64(i+1), e.Seq)
+		assert.False(t, e.Time.IsZero())
+		got = append(got, e.Kind.String()+" "+e.Key+" "+e.Detail)
+	}
+	assert.Equal(t, []string{
+		"register db *cx.testDB",
+		"register svc *cx.flakyChecker",
+		"state  starting",
+		"build_start db ",
+		"build_end db ",
+		"build_start svc ",
+		"get db ",
+		"build_end svc ",
+		"start db ",
+		"state  running",
+		"get missing ",
+		"health svc healthy",
+		"health svc unhealthy",
+		"state  stopping",
+		"stop db ",
+		"state  stopped",
+		"swap db *cx.testDB",
+	}, got)
+
+	events := c.Events()
+	assert.ErrorIs(t, events[10].Err, ErrComponentNotFound)
+	assert.EqualError(t, events[12].Err, "down")
+
+	rec := httptest.NewRecorder()
+	EventsHandler(c).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/cx/events?key=db&since=5", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body []struct {
+		Seq  uint64 `json:"seq"`
+		Kind string `json:"kind"`
+		Key  string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body, 4)
+	assert.Equal(t, uint64(7), body[0].Seq)
+	assert.Equal(t, "get", body[0].Kind)
+	assert.Equal(t, "swap", body[3].Kind)
+}
+
+func TestEventLog_Ring(t *testing.T) {
+	c := New(WithEventLog(2))
+	for _, k := range []string{"a", "b", "c"} {
+		require.NoError(t, Supply(c, k, 1))
+	}
+	events := c.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, uint64(2), events[0].Seq)
+	assert.Equal(t, "b", events[0].Key)
+	assert.Equal(t, "c", events[1].Key)
+}
@@ -22,6 +22,15 @@ var (
 	// StateNew or StateStopped.
 	ErrContainerNotIdle = errors.New("container is not idle")
 
+	// ErrContainerFrozen is returned when Provide/Supply is called on a
+	// frozen container (see Container.Freeze).
+	ErrContainerFrozen = errors.New("container is frozen")
+
+	// ErrComponentDisabled is returned when a component is disabled by an
+	// environment override, including by the build of a component that
+	// depends on it.
+	ErrComponentDisabled = errors.New("component disabled")
+
 	// ErrInvalidKey is returned when an empty key is provided.
 	ErrInvalidKey = errors.New("invalid key")
 )
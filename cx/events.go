@@ -0,0 +1,199 @@
+package cx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Event log
+// ---------------------------------------------------------------------------
+//
+// With WithEventLog the container records its operations in a fixed-size
+// in-memory ring buffer, so the sequence of events that led to a misbehaving
+// container can be reconstructed after the fact without verbose logging
+// being enabled all the time. Recording is cheap: lock-free Gets on a
+// running container are not recorded, and health results are recorded only
+// when they change, so a periodic health monitor does not flush the history.
+
+// EventKind identifies the container operation an [Event] describes.
+type EventKind int
+
+const (
+	EventRegister   EventKind = iota // Provide/Supply registered a component
+	EventSwap                        // Swap replaced a registration
+	EventState                       // The container changed state
+	EventBuildStart                  // A constructor is about to run
+	EventBuildEnd                    // A constructor returned
+	EventGet                         // Get outside the lock-free path, or a failed Get
+	EventStart                       // Starter.Start returned
+	EventWarmup                      // Warmable.Warmup returned
+	EventStop                        // Stopper.Stop returned (including rollbacks)
+	EventHealth                      // A component's health check result changed
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventRegister:
+		return "register"
+	case EventSwap:
+		return "swap"
+	case EventState:
+		return "state"
+	case EventBuildStart:
+		return "build_start"
+	case EventBuildEnd:
+		return "build_end"
+	case EventGet:
+		return "get"
+	case EventStart:
+		return "start"
+	case EventWarmup:
+		return "warmup"
+	case EventStop:
+		return "stop"
+	case EventHealth:
+		return "health"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is one recorded container operation.
+type Event struct {
+	// Seq numbers events from 1 in recording order. A gap between the
+	// first returned event and 1 means older events were overwritten.
+	Seq  uint64
+	Time time.Time
+	Kind EventKind
+	// Key is the component key, empty for container-level events.
+	Key string
+	// Detail carries kind-specific information: the registered type for
+	// register and swap, the new state for state, "healthy" or
+	// "unhealthy" for health.
+	Detail string
+	// Duration is the time spent in the operation for build_end, start,
+	// warmup and stop events.
+	Duration time.Duration
+	// Err is the error the operation failed with, if any.
+	Err error
+}
+
+// eventLog is a fixed-size ring buffer of events.
+type eventLog struct {
+	mu     sync.Mutex
+	buf    []Event
+	seq    uint64          // Seq of the most recent event
+	health map[string]bool // last recorded health result per key
+}
+
+// WithEventLog enables the event log, keeping the most recent size events
+// (see [Container.Events] and [EventsHandler]). size <= 0 disables it.
+func WithEventLog(size int) Option {
+	return func(c *Container) {
+		if size <= 0 {
+			c.events = nil
+			return
+		}
+		c.events = &eventLog{buf: make([]Event, size), health: make(map[string]bool)}
+	}
+}
+
+// record appends e to the event log, if enabled.
+func (c *Container) record(e Event) {
+	l := c.events
+	if l == nil {
+		return
+	}
+	e.Time = time.Now()
+	l.mu.Lock()
+	l.seq++
+	e.Seq = l.seq
+	l.buf[(l.seq-1)%uint64(len(l.buf))] = e
+	l.mu.Unlock()
+}
+
+// recordHealth records a health check result if it differs from the
+// previously recorded result for the same component.
+func (c *Container) recordHealth(h ComponentHealth) {
+	l := c.events
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	prev, seen := l.health[h.Key]
+	l.health[h.Key] = h.Healthy
+	l.mu.Unlock()
+	if seen && prev == h.Healthy {
+		return
+	}
+	detail := "healthy"
+	if !h.Healthy {
+		detail = "unhealthy"
+	}
+	c.record(Event{Kind: EventHealth, Key: h.Key, Detail: detail, Err: h.Error})
+}
+
+// typeName returns the name of T, including interface types.
+func typeName[T any]() string {
+	return fmt.Sprintf("%T", (*T)(nil))[1:]
+}
+
+// Events returns the recorded events, oldest first. It returns nil when the
+// event log is not enabled.
+func (c *Container) Events() []Event {
+	l := c.events
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := min(l.seq, uint64(len(l.buf)))
+	out := make([]Event, 0, n)
+	for s := l.seq - n + 1; s <= l.seq; s++ {
+		out = append(out, l.buf[(s-1)%uint64(len(l.buf))])
+	}
+	return out
+}
+
+// eventJSON is the wire format used by EventsHandler.
+type eventJSON struct {
+	Seq      uint64    `json:"seq"`
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Key      string    `json:"key,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	Duration string    `json:"duration,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// EventsHandler serves the container's event log as a JSON array, oldest
+// first. ?key= keeps only the events of one component and ?since= only the
+// events with a larger Seq, for polling. Mount it on an internal admin
+// router only; it exposes component names and error messages.
+func EventsHandler(c *Container) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		since, _ := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+		out := []eventJSON{}
+		for _, e := range c.Events() {
+			if e.Seq <= since || (key != "" && e.Key != key) {
+				continue
+			}
+			ej := eventJSON{Seq: e.Seq, Time: e.Time, Kind: e.Kind.String(), Key: e.Key, Detail: e.Detail}
+			if e.Duration > 0 {
+				ej.Duration = e.Duration.String()
+			}
+			if e.Err != nil {
+				ej.Error = e.Err.Error()
+			}
+			out = append(out, ej)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	})
+}
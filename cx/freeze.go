@@ -0,0 +1,97 @@
+package cx
+
+import "fmt"
+
+// ---------------------------------------------------------------------------
+// Freeze
+// ---------------------------------------------------------------------------
+//
+// A frozen container rejects Provide and Supply (and everything built on
+// them: ProvideN, SupplyKey, ProvideWhen) with ErrContainerFrozen. A
+// registration made after Start would otherwise be accepted once the
+// container is stopped and silently never built, or surface only at the
+// next restart. The first successful Start freezes the container unless it
+// was created with WithoutAutoFreeze.
+//
+// Freezing guards against accidents, not against intent: Swap replaces an
+// existing registration and ProvideLate / SupplyLate add a new one on a
+// frozen container. Both still require the container to be idle and take
+// effect at the next Start.
+
+// WithoutAutoFreeze keeps Start from freezing the container, so Provide and
+// Supply remain allowed whenever the container is idle.
+func WithoutAutoFreeze() Option {
+	return func(c *Container) { c.noAutoFreeze = true }
+}
+
+// Freeze makes Provide and Supply fail with [ErrContainerFrozen]. It may be
+// called in any state, e.g. at the end of main-time wiring before Start.
+// Freezing cannot be undone.
+func (c *Container) Freeze() {
+	c.mu.Lock()
+	c.frozen = true
+	c.mu.Unlock()
+}
+
+// Frozen reports whether the container has been frozen.
+func (c *Container) Frozen() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.frozen
+}
+
+// ProvideLate is like [Provide] but also registers on a frozen container.
+// It is the explicit opt-in for components added after the initial wiring,
+// e.g. plugins loaded between a Stop and the next Start.
+func ProvideLate[T any](c *Container, key string, ctor func(*Container) (T, error)) error {
+	return provide(c, key, ctor, true)
+}
+
+// SupplyLate is like [Supply] but also registers on a frozen container.
+func SupplyLate[T any](c *Container, key string, value T) error {
+	return ProvideLate(c, key, func(_ *Container) (T, error) {
+		return value, nil
+	})
+}
+
+// Swap replaces the constructor registered under key, keeping its position
+// in the registration order and its metrics. It is allowed on a frozen
+// container but, like registration, only while the container is idle; the
+// new constructor is invoked at the next Start. Typical uses are swapping a
+// component for a fake in tests, or for a reconfigured instance before a
+// Restart.
+func Swap[T any](c *Container, key string, ctor func(*Container) (T, error)) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty key", ErrInvalidKey)
+	}
+	if ctor == nil {
+		return fmt.Errorf("cx: nil constructor for %q", key)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != StateNew && c.state != StateStopped {
+		return fmt.Errorf("%w: current state is %s", ErrContainerNotIdle, c.state)
+	}
+	p, ok := c.providers[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrComponentNotFound, key)
+	}
+	var zero T
+	p.constructor = func(cont *Container) (any, error) {
+		return ctor(cont)
+	}
+	p.probe = zero
+	p.declared = nil
+	c.record(Event{Kind: EventSwap, Key: key, Detail: typeName[T]()})
+	return nil
+}
+
+// SwapValue is like [Swap] but replaces the registration with a
+// pre-constructed value.
+func SwapValue[T any](c *Container, key string, value T) error {
+	return Swap(c, key, func(_ *Container) (T, error) {
+		return value, nil
+	})
+}
@@ -0,0 +1,147 @@
+package cx
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Graph export
+// ---------------------------------------------------------------------------
+//
+// Dependency edges are recorded while constructors run, so the graph is only
+// complete after Start. Before Start every node is present but has no edges.
+
+// Graph is a serialisable snapshot of the dependency graph.
+type Graph struct {
+	// Nodes lists every registered component in registration order.
+	Nodes []GraphNode `json:"nodes"`
+	// Order is the computed start order (see ContainerMetrics.StartOrder).
+	Order []string `json:"order"`
+	// Missing lists unregistered keys requested by some constructor.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// GraphNode describes one component and its outgoing edges.
+type GraphNode struct {
+	Key   string `json:"key"`
+	State string `json:"state"`
+	// Type is the dynamic type of the built value, empty if not built.
+	Type string `json:"type,omitempty"`
+	// Deps are the registered keys this component pulled in via Get.
+	Deps []string `json:"deps,omitempty"`
+	// Missing are the unregistered keys this component asked for.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Graph returns a snapshot of the dependency graph.
+func (c *Container) Graph() Graph {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	g := Graph{
+		Nodes: make([]GraphNode, 0, len(c.keys)),
+		Order: slices.Clone(c.buildOrder),
+	}
+	for _, k := range c.keys {
+		p := c.providers[k]
+		n := GraphNode{
+			Key:     k,
+			State:   p.status.String(),
+			Deps:    slices.Clone(p.deps),
+			Missing: slices.Clone(p.missing),
+		}
+		if p.built {
+			n.Type = fmt.Sprintf("%T", p.value)
+		}
+		g.Nodes = append(g.Nodes, n)
+		for _, m := range p.missing {
+			if !slices.Contains(g.Missing, m) {
+				g.Missing = append(g.Missing, m)
+			}
+		}
+	}
+	if g.Order == nil {
+		g.Order = []string{}
+	}
+	return g
+}
+
+// WriteJSON writes the graph as indented JSON.
+func (g Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Edges point from a
+// component to its dependencies; node labels carry the start position.
+// Missing dependencies are drawn as dashed red nodes.
+//
+//	c.Graph().WriteDOT(f) // dot -Tsvg cx.dot -o cx.svg
+func (g Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	pos := make(map[string]int, len(g.Order))
+	for i, k := range g.Order {
+		pos[k] = i + 1
+	}
+
+	bw.WriteString("digraph cx {\n")
+	bw.WriteString("\trankdir=LR;\n")
+	bw.WriteString("\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		label := n.Key
+		if i, ok := pos[n.Key]; ok {
+			label = "#" + strconv.Itoa(i) + " " + label
+		}
+		if n.Type != "" {
+			label += "\n" + n.Type
+		}
+		bw.WriteString("\t" + dotQuote(n.Key) + " [label=" + dotQuote(label))
+		if n.State == ComponentFailed.String() {
+			bw.WriteString(", color=red")
+		}
+		bw.WriteString("];\n")
+	}
+	for _, m := range g.Missing {
+		bw.WriteString("\t" + dotQuote(m) + " [style=dashed, color=red, fontcolor=red];\n")
+	}
+	for _, n := range g.Nodes {
+		for _, d := range n.Deps {
+			bw.WriteString("\t" + dotQuote(n.Key) + " -> " + dotQuote(d) + ";\n")
+		}
+		for _, m := range n.Missing {
+			bw.WriteString("\t" + dotQuote(n.Key) + " -> " + dotQuote(m) + " [style=dashed, color=red];\n")
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// GraphHandler serves the container's dependency graph for debugging:
+// JSON by default, Graphviz DOT with ?format=dot. Mount it on an internal
+// admin router only; it exposes component names and types.
+func GraphHandler(c *Container) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := c.Graph()
+		if r.URL.Query().Get("format") == "dot" {
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			g.WriteDOT(w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		g.WriteJSON(w)
+	})
+}
+
+// dotQuote returns s as a DOT double-quoted string.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,237 @@
+package cx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Health monitor
+// ---------------------------------------------------------------------------
+//
+// With WithHealthInterval the container runs HealthCheck in the background
+// while it is running and caches the latest report, so probes are cheap and
+// do not hammer dependencies. The monitor starts when the container reaches
+// StateRunning and is stopped before the onStopping hooks run.
+
+// WithHealthInterval enables the background health monitor, which runs
+// [Container.HealthCheck] every d while the container is running. The latest
+// report is available via [Container.CachedHealth] and [HealthHandler].
+func WithHealthInterval(d time.Duration) Option {
+	return func(c *Container) { c.healthInterval = d }
+}
+
+// WithOnHealthChange registers a callback invoked by the background monitor
+// when a component transitions between healthy and unhealthy. Components are
+// assumed healthy before the first check, so a component that is unhealthy
+// on the first check triggers the callback. Callbacks run on the monitor
+// goroutine and should not block.
+func WithOnHealthChange(fn func(ComponentHealth)) Option {
+	return func(c *Container) { c.onHealthChange = append(c.onHealthChange, fn) }
+}
+
+// CachedHealth returns the report of the most recent background check and
+// whether one has completed since the container was started.
+func (c *Container) CachedHealth() (HealthReport, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.healthCache == nil {
+		return HealthReport{}, false
+	}
+	return *c.healthCache, true
+}
+
+// startHealthMonitorLocked launches the monitor goroutine. Caller holds c.mu.
+func (c *Container) startHealthMonitorLocked() {
+	if c.healthInterval <= 0 || c.healthStop != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.healthStop = func() {
+		cancel()
+		<-done
+	}
+	go func() {
+		defer close(done)
+		c.monitorHealth(ctx)
+	}()
+}
+
+// stopHealthMonitor stops the monitor and waits for an in-flight check to
+// return. The cached report is discarded.
+func (c *Container) stopHealthMonitor() {
+	c.mu.Lock()
+	stop := c.healthStop
+	c.healthStop = nil
+	c.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	c.mu.Lock()
+	c.healthCache = nil
+	c.mu.Unlock()
+}
+
+func (c *Container) monitorHealth(ctx context.Context) {
+	healthy := make(map[string]bool)
+	ticker := time.NewTicker(c.healthInterval)
+	defer ticker.Stop()
+	for {
+		report := c.HealthCheck(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		c.mu.Lock()
+		c.healthCache = &report
+		c.mu.Unlock()
+
+		for _, h := range report.Components {
+			prev, seen := healthy[h.Key]
+			if !seen {
+				prev = true
+			}
+			healthy[h.Key] = h.Healthy
+			if prev != h.Healthy {
+				for _, fn := range c.onHealthChange {
+					fn(h)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Health scoring
+// ---------------------------------------------------------------------------
+//
+// Besides the boolean verdict, every report carries a score in [0, 1]: the
+// weighted average of the checked components' scores. Load balancers can use
+// it (via ScoreHandler) to drain traffic gradually when a dependency degrades
+// instead of flipping the instance out of rotation.
+
+// WithHealthWeight sets the weight of the component registered under key in
+// [HealthReport.Score]. The default weight is 1; a weight of 0 excludes the
+// component from the score while still affecting [HealthReport.Healthy].
+func WithHealthWeight(key string, weight float64) Option {
+	return func(c *Container) {
+		if c.healthWeights == nil {
+			c.healthWeights = make(map[string]float64)
+		}
+		c.healthWeights[key] = max(weight, 0)
+	}
+}
+
+func (c *Container) healthWeight(key string) float64 {
+	if w, ok := c.healthWeights[key]; ok {
+		return w
+	}
+	return 1
+}
+
+func isHealthChecked(v any) bool {
+	switch v.(type) {
+	case HealthScorer, HealthChecker:
+		return true
+	}
+	return false
+}
+
+// checkHealth runs the component's check and returns its score.
+func checkHealth(ctx context.Context, v any) (float64, error) {
+	if s, ok := v.(HealthScorer); ok {
+		score, err := s.HealthScore(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return min(max(score, 0), 1), nil
+	}
+	if err := v.(HealthChecker).HealthCheck(ctx); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// ScoreHandler serves the container's aggregate health score for load
+// balancer health checks. It responds 200 when the report is healthy and the
+// score is at least minScore, and 503 otherwise. The score is exposed as
+//   - X-Health-Score: the score in [0, 1], e.g. "0.75"
+//   - X-Weight: the score as an integer percentage, e.g. "75"
+//   - the body: the percentage followed by "%", e.g. "75%", which is the
+//     format HAProxy agent checks use to set server weights
+//
+// Like [HealthHandler] it serves the cached report when the background
+// monitor is enabled.
+func ScoreHandler(c *Container, minScore float64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, ok := c.CachedHealth()
+		if !ok {
+			report = c.HealthCheck(r.Context())
+		}
+		percent := int(report.Score*100 + 0.5)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Health-Score", strconv.FormatFloat(report.Score, 'f', 2, 64))
+		w.Header().Set("X-Weight", strconv.Itoa(percent))
+		if !report.Healthy || report.Score < minScore {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprintf(w, "%d%%\n", percent)
+	})
+}
+
+// healthJSON is the wire format used by HealthHandler.
+type healthJSON struct {
+	Healthy    bool                  `json:"healthy"`
+	Score      float64               `json:"score"`
+	CheckedAt  time.Time             `json:"checked_at"`
+	Components []componentHealthJSON `json:"components"`
+}
+
+type componentHealthJSON struct {
+	Key     string  `json:"key"`
+	Healthy bool    `json:"healthy"`
+	Score   float64 `json:"score"`
+	Weight  float64 `json:"weight"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// HealthHandler serves the container's health report as JSON with status 200
+// when healthy and 503 otherwise. It serves the cached report when the
+// background monitor is enabled and has completed a check, and runs
+// [Container.HealthCheck] on demand otherwise.
+func HealthHandler(c *Container) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, ok := c.CachedHealth()
+		if !ok {
+			report = c.HealthCheck(r.Context())
+		}
+		out := healthJSON{
+			Healthy:    report.Healthy,
+			Score:      report.Score,
+			CheckedAt:  report.CheckedAt,
+			Components: make([]componentHealthJSON, 0, len(report.Components)),
+		}
+		for _, h := range report.Components {
+			ch := componentHealthJSON{Key: h.Key, Healthy: h.Healthy, Score: h.Score, Weight: h.Weight}
+			if h.Error != nil {
+				ch.Error = h.Error.Error()
+			}
+			out.Components = append(out.Components, ch)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(out)
+	})
+}
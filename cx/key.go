@@ -0,0 +1,183 @@
+package cx
+
+import "fmt"
+
+// ---------------------------------------------------------------------------
+// Typed keys
+// ---------------------------------------------------------------------------
+
+// Key is a typed component key. It carries the component type T alongside
+// the string key so that registration and retrieval through the Key-based
+// API are checked by the compiler rather than by a runtime type assertion.
+//
+// Keys are comparable values and are usually declared once as package-level
+// variables:
+//
+//	var ConfigKey = cx.NewKey[*Config]("config")
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a Key for components of type T registered under name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the underlying string key.
+func (k Key[T]) Name() string { return k.name }
+
+// String implements fmt.Stringer.
+func (k Key[T]) String() string { return k.name }
+
+// GetKey is like [Get] but takes a typed [Key].
+func GetKey[T any](c *Container, key Key[T]) (T, error) {
+	return Get[T](c, key.name)
+}
+
+// MustGetKey is like [GetKey] but panics if the component cannot be retrieved.
+func MustGetKey[T any](c *Container, key Key[T]) T {
+	return MustGet[T](c, key.name)
+}
+
+// SupplyKey is like [Supply] but takes a typed [Key].
+func SupplyKey[T any](c *Container, key Key[T], value T) error {
+	return Supply(c, key.name, value)
+}
+
+// ---------------------------------------------------------------------------
+// Constructor providers
+// ---------------------------------------------------------------------------
+//
+// ProvideN registers a plain constructor whose parameters are resolved from
+// the container by the given dependency keys. The container never sees the
+// constructor's signature through reflection: each dependency is fetched
+// with [Get] inside a generated wrapper, so dependency edges are recorded
+// and construction happens in topological order exactly as with [Provide].
+
+// declare records the dependency keys of a ProvideN registration, so that
+// [Container.Catalog] can report them before the constructor has run.
+func (c *Container) declare(key string, deps ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.providers[key]; ok {
+		p.declared = deps
+	}
+}
+
+// nilConstructorError mirrors the error [Provide] returns for a nil ctor;
+// the ProvideN wrappers are never nil themselves, so the check happens here.
+func nilConstructorError(key string) error {
+	return fmt.Errorf("cx: nil constructor for %q", key)
+}
+
+// Provide0 registers a constructor without dependencies under key.
+func Provide0[T any](c *Container, key Key[T], ctor func() (T, error)) error {
+	if ctor == nil {
+		return nilConstructorError(key.name)
+	}
+	return Provide(c, key.name, func(_ *Container) (T, error) {
+		return ctor()
+	})
+}
+
+// Provide1 registers a constructor whose single parameter is resolved from a.
+func Provide1[A, T any](c *Container, key Key[T], a Key[A], ctor func(A) (T, error)) error {
+	if ctor == nil {
+		return nilConstructorError(key.name)
+	}
+	err := Provide(c, key.name, func(c *Container) (T, error) {
+		var zero T
+		av, err := GetKey(c, a)
+		if err != nil {
+			return zero, err
+		}
+		return ctor(av)
+	})
+	if err == nil {
+		c.declare(key.name, a.name)
+	}
+	return err
+}
+
+// Provide2 registers a constructor whose parameters are resolved from a and b.
+func Provide2[A, B, T any](c *Container, key Key[T], a Key[A], b Key[B], ctor func(A, B) (T, error)) error {
+	if ctor == nil {
+		return nilConstructorError(key.name)
+	}
+	err := Provide(c, key.name, func(c *Container) (T, error) {
+		var zero T
+		av, err := GetKey(c, a)
+		if err != nil {
+			return zero, err
+		}
+		bv, err := GetKey(c, b)
+		if err != nil {
+			return zero, err
+		}
+		return ctor(av, bv)
+	})
+	if err == nil {
+		c.declare(key.name, a.name, b.name)
+	}
+	return err
+}
+
+// Provide3 registers a constructor whose parameters are resolved from a, b
+// and d.
+func Provide3[A, B, D, T any](c *Container, key Key[T], a Key[A], b Key[B], d Key[D], ctor func(A, B, D) (T, error)) error {
+	if ctor == nil {
+		return nilConstructorError(key.name)
+	}
+	err := Provide(c, key.name, func(c *Container) (T, error) {
+		var zero T
+		av, err := GetKey(c, a)
+		if err != nil {
+			return zero, err
+		}
+		bv, err := GetKey(c, b)
+		if err != nil {
+			return zero, err
+		}
+		dv, err := GetKey(c, d)
+		if err != nil {
+			return zero, err
+		}
+		return ctor(av, bv, dv)
+	})
+	if err == nil {
+		c.declare(key.name, a.name, b.name, d.name)
+	}
+	return err
+}
+
+// Provide4 registers a constructor whose parameters are resolved from a, b,
+// d and e.
+func Provide4[A, B, D, E, T any](c *Container, key Key[T], a Key[A], b Key[B], d Key[D], e Key[E], ctor func(A, B, D, E) (T, error)) error {
+	if ctor == nil {
+		return nilConstructorError(key.name)
+	}
+	err := Provide(c, key.name, func(c *Container) (T, error) {
+		var zero T
+		av, err := GetKey(c, a)
+		if err != nil {
+			return zero, err
+		}
+		bv, err := GetKey(c, b)
+		if err != nil {
+			return zero, err
+		}
+		dv, err := GetKey(c, d)
+		if err != nil {
+			return zero, err
+		}
+		ev, err := GetKey(c, e)
+		if err != nil {
+			return zero, err
+		}
+		return ctor(av, bv, dv, ev)
+	})
+	if err == nil {
+		c.declare(key.name, a.name, b.name, d.name, e.name)
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package cx
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kochabx/kit/log"
+)
+
+// ---------------------------------------------------------------------------
+// Environment overrides
+// ---------------------------------------------------------------------------
+//
+// Overrides let operations adjust a deployment without code changes: a
+// component can be moved earlier or later in the startup order, or disabled
+// altogether when it is known to be faulty. They are read from the
+// environment at the beginning of every Start, so a restart picks up new
+// values, and every applied override is logged.
+//
+// The variable name is the prefix followed by the component key, where "."
+// is written as "__" and any other character that is not a letter, digit or
+// underscore as "_". Keys are matched case-insensitively:
+//
+//	CX_ORDER_controller__payment=5   key "controller.payment" starts later
+//	CX_DISABLE_handler__legacy=true  key "handler.legacy" is not built
+
+const (
+	// OrderEnvPrefix prefixes the variables that set a component's startup
+	// order. Components are built and started in ascending order (default
+	// 0, ties keep registration order); dependencies are still built before
+	// the components that need them.
+	OrderEnvPrefix = "CX_ORDER_"
+
+	// DisableEnvPrefix prefixes the variables that disable a component
+	// (strconv.ParseBool syntax). A disabled component is neither built nor
+	// started, and Get on it returns [ErrComponentDisabled].
+	DisableEnvPrefix = "CX_DISABLE_"
+)
+
+// Override describes an environment override applied by Start.
+type Override struct {
+	Env   string // environment variable name
+	Key   string // component key it applies to
+	Value string // raw value
+}
+
+// WithEnvOverrides makes Start apply the order and disable overrides found
+// in the environment (see [OrderEnvPrefix] and [DisableEnvPrefix]). The
+// package-level container [C] has it enabled.
+func WithEnvOverrides() Option {
+	return func(c *Container) { c.envOverrides = true }
+}
+
+// Overrides returns the environment overrides applied by the most recent
+// Start, sorted by variable name.
+func (c *Container) Overrides() []Override {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.overrides)
+}
+
+// applyOverridesLocked resets the per-component overrides and, when enabled,
+// applies the ones found in the environment. A malformed value fails Start;
+// a variable naming an unregistered component is logged and ignored.
+// Caller holds c.mu.
+func (c *Container) applyOverridesLocked() error {
+	for _, p := range c.providers {
+		p.order = 0
+		p.disabled = false
+	}
+	c.overrides = nil
+	if !c.envOverrides {
+		return nil
+	}
+
+	env := os.Environ()
+	slices.Sort(env)
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		var (
+			suffix  string
+			isOrder bool
+		)
+		if s, ok := strings.CutPrefix(name, OrderEnvPrefix); ok {
+			suffix, isOrder = s, true
+		} else if s, ok := strings.CutPrefix(name, DisableEnvPrefix); ok {
+			suffix = s
+		} else {
+			continue
+		}
+
+		key, ok := c.envKeyLocked(suffix)
+		if !ok {
+			log.Warn().Str("env", name).Msg("cx: override for unknown component ignored")
+			continue
+		}
+		p := c.providers[key]
+		if isOrder {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("cx: invalid override %s=%q: %w", name, value, err)
+			}
+			p.order = n
+		} else {
+			b, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("cx: invalid override %s=%q: %w", name, value, err)
+			}
+			p.disabled = b
+			if b {
+				p.status = ComponentDisabled
+			}
+		}
+		c.overrides = append(c.overrides, Override{Env: name, Key: key, Value: value})
+		log.Info().Str("env", name).Str("component", key).Str("value", value).Msg("cx: override applied")
+	}
+	return nil
+}
+
+// envKeyLocked returns the registered key whose environment form matches
+// suffix. Caller holds c.mu.
+func (c *Container) envKeyLocked(suffix string) (string, bool) {
+	for _, k := range c.keys {
+		if strings.EqualFold(envKey(k), suffix) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// envKey encodes a component key for use in a variable name.
+func envKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch {
+		case r == '.':
+			b.WriteString("__")
+		case r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// buildKeysLocked returns the enabled keys in build order: ascending
+// override order, ties in registration order. Caller holds c.mu.
+func (c *Container) buildKeysLocked() []string {
+	keys := make([]string, 0, len(c.keys))
+	for _, k := range c.keys {
+		if !c.providers[k].disabled {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return cmp.Compare(c.providers[a].order, c.providers[b].order)
+	})
+	return keys
+}
@@ -0,0 +1,137 @@
+package cx
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Profiles
+// ---------------------------------------------------------------------------
+//
+// Profiles gate registration: ProvideWhen and SupplyWhen only register the
+// component when the profile expression matches the container's active
+// profiles, so environment-specific wiring does not need hand-rolled if
+// statements around Provide calls. The check happens at registration time,
+// which lets two conditional registrations share a key as long as their
+// profiles are mutually exclusive (e.g. "prod" and "!prod").
+
+// ProfilesEnv is the environment variable the package-level container [C]
+// reads its initial active profiles from (comma-separated), so that init()
+// registrations already see them.
+const ProfilesEnv = "CX_PROFILES"
+
+// WithProfiles sets the active profiles. Empty names are ignored.
+func WithProfiles(profiles ...string) Option {
+	return func(c *Container) { c.profiles = normalizeProfiles(profiles) }
+}
+
+// SetProfiles replaces the active profiles. Like registration, it is only
+// allowed while the container is idle (StateNew or StateStopped) and does not
+// affect components that were already registered.
+func (c *Container) SetProfiles(profiles ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != StateNew && c.state != StateStopped {
+		return fmt.Errorf("%w: current state is %s", ErrContainerNotIdle, c.state)
+	}
+	c.profiles = normalizeProfiles(profiles)
+	return nil
+}
+
+// Profiles returns the active profiles.
+func (c *Container) Profiles() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.profiles)
+}
+
+// ProfileActive reports whether expr matches the active profiles.
+//
+// expr is a comma-separated list of terms, any of which may match; a term
+// prefixed with "!" matches when that profile is not active. An empty expr
+// always matches.
+//
+//	"prod"         prod is active
+//	"prod,staging" prod or staging is active
+//	"!prod"        prod is not active
+func (c *Container) ProfileActive(expr string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.profileActiveLocked(expr)
+}
+
+func (c *Container) profileActiveLocked(expr string) bool {
+	if strings.TrimSpace(expr) == "" {
+		return true
+	}
+	for term := range strings.SplitSeq(expr, ",") {
+		term = strings.TrimSpace(term)
+		if name, neg := strings.CutPrefix(term, "!"); neg {
+			if !slices.Contains(c.profiles, strings.TrimSpace(name)) {
+				return true
+			}
+		} else if term != "" && slices.Contains(c.profiles, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProvideWhen is like [Provide] but only registers ctor when profile matches
+// the active profiles (see [Container.ProfileActive]). It reports whether the
+// component was registered; a skipped registration is not an error.
+func ProvideWhen[T any](c *Container, profile, key string, ctor func(*Container) (T, error)) (bool, error) {
+	if !c.ProfileActive(profile) {
+		return false, nil
+	}
+	if err := Provide(c, key, ctor); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SupplyWhen is like [Supply] but only registers value when profile matches
+// the active profiles.
+func SupplyWhen[T any](c *Container, profile, key string, value T) (bool, error) {
+	return ProvideWhen(c, profile, key, func(_ *Container) (T, error) {
+		return value, nil
+	})
+}
+
+// MustProvideWhen is like [ProvideWhen] but panics if registration fails.
+func MustProvideWhen[T any](c *Container, profile, key string, ctor func(*Container) (T, error)) bool {
+	ok, err := ProvideWhen(c, profile, key, ctor)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// MustSupplyWhen is like [SupplyWhen] but panics if registration fails.
+func MustSupplyWhen[T any](c *Container, profile, key string, value T) bool {
+	ok, err := SupplyWhen(c, profile, key, value)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// profilesFromEnv returns the profiles listed in [ProfilesEnv].
+func profilesFromEnv() []string {
+	return strings.Split(os.Getenv(ProfilesEnv), ",")
+}
+
+// normalizeProfiles trims names, drops empties and duplicates.
+func normalizeProfiles(profiles []string) []string {
+	out := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		p = strings.TrimSpace(p)
+		if p != "" && !slices.Contains(out, p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
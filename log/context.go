@@ -0,0 +1,21 @@
+package log
+
+import "context"
+
+// loggerKey 日志记录器在 context 中的键
+type loggerKey struct{}
+
+// WithContext 返回携带 logger 的 context，通常由中间件注入带有请求 ID、trace_id 等关联字段的请求级日志记录器。
+func WithContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Ctx 返回 context 携带的日志记录器，未设置时返回全局日志记录器。
+func Ctx(ctx context.Context) *Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	return Global()
+}
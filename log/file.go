@@ -15,7 +15,7 @@ func NewFile(config writer.FileConfig, opts ...Option) (*Logger, error) {
 	if err != nil {
 		return nil, err
 	}
-	logger := newWithWriter(fileWriter, opts...)
+	logger := NewWriter(fileWriter, opts...)
 	logger.closer = closer
 	return logger, nil
 }
@@ -27,7 +27,7 @@ func NewMulti(config writer.FileConfig, opts ...Option) (*Logger, error) {
 		return nil, err
 	}
 	output := zerolog.MultiLevelWriter(fileWriter, writer.NewConsole())
-	logger := newWithWriter(output, opts...)
+	logger := NewWriter(output, opts...)
 	logger.closer = closer
 	return logger, nil
 }
@@ -13,6 +13,7 @@ import (
 type Logger struct {
 	zerolog.Logger
 	redactor *redact.Redactor
+	output   io.Writer // redacted output reused by Tee, nil for loggers built directly
 	closer   io.Closer
 }
 
@@ -21,6 +22,28 @@ func (l *Logger) Redactor() *redact.Redactor {
 	return l.redactor
 }
 
+// Child returns a logger whose context is extended by fn, for example to
+// attach request-scoped fields. The child shares the parent's output and
+// redactor; closing the parent releases them.
+func (l *Logger) Child(fn func(zerolog.Context) zerolog.Context) *Logger {
+	return &Logger{Logger: fn(l.With()).Logger(), redactor: l.redactor, output: l.output}
+}
+
+// Tee returns a logger that writes every entry both to l's output and to w,
+// keeping l's context fields, level and redaction. It is meant for capturing
+// a copy of a scope's logs, e.g. a task's output, without taking them away
+// from the normal log pipeline. A Logger constructed directly rather than by
+// this package has no known output and the result writes to w only.
+func (l *Logger) Tee(w io.Writer) *Logger {
+	if l.redactor != nil {
+		w = redact.NewWriter(w, l.redactor)
+	}
+	if l.output != nil {
+		w = zerolog.MultiLevelWriter(l.output, w)
+	}
+	return &Logger{Logger: l.Output(w), redactor: l.redactor, output: w}
+}
+
 // Close releases resources owned by the logger.
 func (l *Logger) Close() error {
 	if l.closer == nil {
@@ -29,7 +52,8 @@ func (l *Logger) Close() error {
 	return l.closer.Close()
 }
 
-func newWithWriter(w io.Writer, opts ...Option) *Logger {
+// NewWriter creates a logger writing JSON lines to w.
+func NewWriter(w io.Writer, opts ...Option) *Logger {
 	config := loggerOptions{}
 	for _, opt := range opts {
 		opt(&config)
@@ -49,10 +73,10 @@ func newWithWriter(w io.Writer, opts ...Option) *Logger {
 		zlogger = zlogger.With().CallerWithSkipFrameCount(skip).Logger()
 	}
 
-	return &Logger{Logger: zlogger, redactor: config.redactor}
+	return &Logger{Logger: zlogger, redactor: config.redactor, output: output}
 }
 
 // New creates a console logger.
 func New(opts ...Option) *Logger {
-	return newWithWriter(writer.NewConsole(), opts...)
+	return NewWriter(writer.NewConsole(), opts...)
 }
@@ -1,8 +1,11 @@
 package log
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -69,6 +72,51 @@ func TestGlobalLog(t *testing.T) {
 	Error().Err(kiterrors.New(500, "test global error")).Msg("test global error log")
 }
 
+func TestContextLogger(t *testing.T) {
+	if Ctx(context.Background()) != Global() {
+		t.Fatal("Ctx without logger should return the global logger")
+	}
+
+	var buf bytes.Buffer
+	parent := NewWriter(&buf)
+	child := parent.Child(func(c zerolog.Context) zerolog.Context {
+		return c.Str("request_id", "req-1")
+	})
+	ctx := WithContext(context.Background(), child)
+	Ctx(ctx).Info().Msg("hello")
+
+	if got := buf.String(); !strings.Contains(got, `"request_id":"req-1"`) || !strings.Contains(got, `"message":"hello"`) {
+		t.Fatalf("unexpected output: %s", got)
+	}
+	if child.Redactor() != parent.Redactor() {
+		t.Fatal("child should share the parent's redactor")
+	}
+}
+
+func TestLoggerTee(t *testing.T) {
+	var base, captured bytes.Buffer
+	r, err := redact.New(redact.Field("password", redact.Replace("******")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := NewWriter(&base, WithLevel(zerolog.InfoLevel), WithRedactor(r))
+	tee := parent.Child(func(c zerolog.Context) zerolog.Context {
+		return c.Str("task_id", "t-1")
+	}).Tee(&captured)
+
+	tee.Debug().Msg("below level")
+	tee.Info().Str("password", "hunter2").Msg("hello")
+
+	for name, got := range map[string]string{"base": base.String(), "captured": captured.String()} {
+		if !strings.Contains(got, `"task_id":"t-1"`) || !strings.Contains(got, `"message":"hello"`) {
+			t.Fatalf("%s output missing entry: %s", name, got)
+		}
+		if strings.Contains(got, "hunter2") || strings.Contains(got, "below level") {
+			t.Fatalf("%s output should be redacted and levelled: %s", name, got)
+		}
+	}
+}
+
 func TestFileLog(t *testing.T) {
 	config := writer.FileConfig{
 		Path:       filepath.Join(t.TempDir(), "test.log"),
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"unsafe"
@@ -15,9 +16,60 @@ type contentMatcher struct {
 	mask Mask
 }
 
+type keyMatcher struct {
+	rx   *regexp.Regexp
+	mask Mask
+}
+
+// maxKeyCache bounds the per-plan cache of pattern lookups so that logs with
+// unbounded key sets (user-supplied maps) cannot grow it without limit.
+const maxKeyCache = 4096
+
 type plan struct {
 	fields  map[string]Mask
+	keys    []keyMatcher
 	content []contentMatcher
+
+	keyCache     sync.Map // key name -> keyLookup
+	keyCacheSize atomic.Int32
+}
+
+type keyLookup struct {
+	mask Mask
+	ok   bool
+}
+
+func (p *plan) empty() bool {
+	return len(p.fields) == 0 && len(p.keys) == 0 && len(p.content) == 0
+}
+
+// lookup resolves the mask for a JSON key. Exact names win over patterns and
+// the first matching pattern wins.
+func (p *plan) lookup(name string) (Mask, bool) {
+	if mask, ok := p.fields[name]; ok {
+		return mask, true
+	}
+	if len(p.keys) == 0 {
+		return nil, false
+	}
+	if cached, ok := p.keyCache.Load(name); ok {
+		result := cached.(keyLookup)
+		return result.mask, result.ok
+	}
+	var result keyLookup
+	for _, matcher := range p.keys {
+		if matcher.rx.MatchString(name) {
+			result = keyLookup{mask: matcher.mask, ok: true}
+			break
+		}
+	}
+	if p.keyCacheSize.Load() < maxKeyCache {
+		// name may alias the log line buffer.
+		if _, loaded := p.keyCache.LoadOrStore(strings.Clone(name), result); !loaded {
+			p.keyCacheSize.Add(1)
+		}
+	}
+	return result.mask, result.ok
 }
 
 type ruleEntry struct {
@@ -67,6 +119,17 @@ func compile(entries []ruleEntry) (*plan, error) {
 			if entry.enabled {
 				compiled.fields[rule.name] = rule.mask
 			}
+		case keyRule:
+			if rule.pattern == "" {
+				return nil, fmt.Errorf("redact: rule %q has an empty pattern", rule.name)
+			}
+			rx, err := regexp.Compile(rule.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("redact: compile rule %q: %w", rule.name, err)
+			}
+			if entry.enabled {
+				compiled.keys = append(compiled.keys, keyMatcher{rx: rx, mask: rule.mask})
+			}
 		case contentRule:
 			if rule.pattern == "" {
 				return nil, fmt.Errorf("redact: rule %q has an empty pattern", rule.name)
@@ -181,7 +244,7 @@ func (r *Redactor) HasRules() bool {
 		return false
 	}
 	compiled := r.current.Load()
-	return compiled != nil && (len(compiled.fields) != 0 || len(compiled.content) != 0)
+	return compiled != nil && !compiled.empty()
 }
 
 // Append appends redacted src to dst. When src is unchanged, it returns dst
@@ -192,11 +255,11 @@ func (r *Redactor) Append(dst, src []byte) ([]byte, bool) {
 	}
 
 	compiled := r.current.Load()
-	if compiled == nil || (len(compiled.fields) == 0 && len(compiled.content) == 0) {
+	if compiled == nil || compiled.empty() {
 		return dst, false
 	}
 	base := len(dst)
-	result, fieldChanged := redactFields(compiled.fields, dst, src)
+	result, fieldChanged := redactFields(compiled, dst, src)
 	input := src
 	if fieldChanged {
 		input = result[base:]
@@ -230,8 +293,8 @@ func (r *Redactor) RedactString(src string) string {
 	return string(result)
 }
 
-func redactFields(fields map[string]Mask, dst, src []byte) ([]byte, bool) {
-	if len(fields) == 0 {
+func redactFields(compiled *plan, dst, src []byte) ([]byte, bool) {
+	if len(compiled.fields) == 0 && len(compiled.keys) == 0 {
 		return dst, false
 	}
 	out := dst
@@ -256,14 +319,20 @@ func redactFields(fields map[string]Mask, dst, src []byte) ([]byte, bool) {
 			i = end + 1
 			continue
 		}
-		mask, exists := fields[name]
+		mask, exists := compiled.lookup(name)
 		valueStart := skipSpace(src, colon+1)
 		valueEnd := jsonValueEnd(src, valueStart)
 		if valueEnd < 0 {
 			break
 		}
 		if !exists {
-			i = valueEnd
+			// Descend into nested objects and arrays so their fields are
+			// redacted as well.
+			if src[valueStart] == '{' || src[valueStart] == '[' {
+				i = valueStart + 1
+			} else {
+				i = valueEnd
+			}
 			continue
 		}
 
@@ -13,6 +13,7 @@ type ruleKind uint8
 const (
 	fieldRule ruleKind = iota
 	contentRule
+	keyRule
 )
 
 // Field masks a JSON field by its exact name.
@@ -20,6 +21,13 @@ func Field(name string, mask Mask) Rule {
 	return Rule{kind: fieldRule, name: name, mask: mask}
 }
 
+// FieldPattern masks every JSON field whose name matches a regular
+// expression, e.g. `(?i)token` for access_token and X-Csrf-Token alike.
+// Exact Field rules take precedence over patterns.
+func FieldPattern(name, pattern string, mask Mask) Rule {
+	return Rule{kind: keyRule, name: name, pattern: pattern, mask: mask}
+}
+
 // Content masks text matched by a regular expression.
 func Content(name, pattern string, mask Mask) Rule {
 	return Rule{kind: contentRule, name: name, pattern: pattern, mask: mask}
@@ -0,0 +1,97 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Masked is the placeholder rendered in place of secrets.
+const Masked = "******"
+
+// SensitiveKeyPattern matches field, header and query parameter names that
+// carry credentials. It is intentionally broad: masking a harmless value is
+// cheaper than leaking a credential into aggregated logs.
+const SensitiveKeyPattern = `(?i)passw(or)?d|passphrase|secret|token|credential|authorization|cookie|api[-_]?key|private[-_]?key`
+
+// CardPattern matches digit runs shaped like payment card numbers from the
+// major issuers. Pair it with Card, which only masks Luhn-valid matches. It is
+// not part of BuiltinRules because scanning every log line for digit runs
+// roughly doubles the redaction cost.
+const CardPattern = `\b(?:4\d{3}|5[1-5]\d{2}|2[2-7]\d{2}|3[47]\d{2}|6(?:011|2\d{2}|5\d{2}))(?:[ -]?\d){9,15}\b`
+
+var (
+	sensitiveKey = regexp.MustCompile(SensitiveKeyPattern)
+	sensitive, _ = New(
+		FieldPattern("sensitive-keys", SensitiveKeyPattern, Replace(Masked)),
+		Content("card-content", CardPattern, Card()),
+	)
+)
+
+// IsSensitiveKey reports whether a field, header or parameter name matches
+// SensitiveKeyPattern.
+func IsSensitiveKey(name string) bool {
+	return sensitiveKey.MatchString(name)
+}
+
+// Header returns a copy of h with the values of sensitive headers masked. h is
+// returned as is when nothing needs masking.
+func Header(h http.Header) http.Header {
+	var masked http.Header
+	for name, values := range h {
+		if !IsSensitiveKey(name) {
+			continue
+		}
+		if masked == nil {
+			masked = h.Clone()
+		}
+		replaced := make([]string, len(values))
+		for i := range replaced {
+			replaced[i] = Masked
+		}
+		masked[name] = replaced
+	}
+	if masked == nil {
+		return h
+	}
+	return masked
+}
+
+// Query masks the values of sensitive parameters in a raw query string while
+// preserving parameter order and the encoding of everything else.
+func Query(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	var b strings.Builder
+	changed := false
+	for i, pair := range strings.Split(raw, "&") {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		key, _, hasValue := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); err == nil && hasValue && IsSensitiveKey(name) {
+			b.WriteString(key)
+			b.WriteByte('=')
+			b.WriteString(Masked)
+			changed = true
+			continue
+		}
+		b.WriteString(pair)
+	}
+	if !changed {
+		return raw
+	}
+	return b.String()
+}
+
+// JSON masks sensitive fields and card numbers in a JSON document, for
+// payloads that are logged as opaque bytes and so bypass the logger's
+// Redactor field rules.
+func JSON(src []byte) []byte {
+	if result, changed := sensitive.Append(nil, src); changed {
+		return result
+	}
+	return src
+}
@@ -2,7 +2,10 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"sync"
 	"testing"
@@ -131,7 +134,7 @@ func TestLoggerOutputsRedactedLog(t *testing.T) {
 	}
 
 	var output bytes.Buffer
-	logger := newWithWriter(&output, WithRedactor(r))
+	logger := NewWriter(&output, WithRedactor(r))
 	logger.Info().
 		Str("phone", "13812345678").
 		Str("password", "secret123").
@@ -208,3 +211,99 @@ func TestRedactorConcurrentUpdates(t *testing.T) {
 	}
 	workers.Wait()
 }
+
+func TestRedactorFieldPattern(t *testing.T) {
+	r, err := redact.New(
+		redact.Field("session_token", redact.KeepEdges(2, 2)),
+		redact.FieldPattern("credentials", `(?i)token|passw(or)?d`, redact.Replace("******")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := r.RedactString(`{"access_token":"abc","Password":"pw","session_token":"abcdef","auth":{"refresh_token":"xyz"},"user":"alice"}`)
+	want := `{"access_token":"******","Password":"******","session_token":"ab****ef","auth":{"refresh_token":"******"},"user":"alice"}`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if _, err := redact.New(redact.FieldPattern("invalid", "[", redact.Replace("x"))); err == nil {
+		t.Fatal("expected validation error")
+	}
+}
+
+func TestCardMask(t *testing.T) {
+	r, err := redact.New(redact.Content("card", redact.CardPattern, redact.Card()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"paid with 4111 1111 1111 1111": "paid with 4111****1111",
+		"card 5500-0000-0000-0004":      "card 5500****0004",
+		"order 4111111111111112":        "order 4111111111111112", // Luhn 校验失败
+		"id 1234567890123456":           "id 1234567890123456",
+	}
+	for input, want := range tests {
+		if got := r.RedactString(input); got != want {
+			t.Errorf("RedactString(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSensitiveHelpers(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Authorization": true,
+		"X-Api-Key":     true,
+		"refresh_token": true,
+		"db_password":   true,
+		"Content-Type":  false,
+		"user":          false,
+	} {
+		if got := redact.IsSensitiveKey(name); got != want {
+			t.Errorf("IsSensitiveKey(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	h := http.Header{"Authorization": {"Bearer x"}, "Accept": {"*/*"}}
+	masked := redact.Header(h)
+	if masked.Get("Authorization") != redact.Masked || masked.Get("Accept") != "*/*" {
+		t.Fatalf("unexpected header: %v", masked)
+	}
+	if h.Get("Authorization") != "Bearer x" {
+		t.Fatal("Header must not modify its input")
+	}
+
+	if got := redact.Query("a=1&access_token=x%20y&b"); got != "a=1&access_token=******&b" {
+		t.Fatalf("Query = %q", got)
+	}
+	if got := string(redact.JSON([]byte(`{"password":"pw","n":1}`))); got != `{"password":"******","n":1}` {
+		t.Fatalf("JSON = %s", got)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	s := Secret("p@ssw0rd")
+	for _, got := range []string{
+		s.String(),
+		fmt.Sprintf("%v|%+v|%#v|%s|%q|%x", s, s, s, s, s, s),
+	} {
+		if strings.Contains(got, "p@ssw0rd") || strings.Contains(got, "7040") {
+			t.Fatalf("secret leaked: %s", got)
+		}
+	}
+	b, err := json.Marshal(struct{ Password SecretValue[string] }{s})
+	if err != nil || string(b) != `{"Password":"******"}` {
+		t.Fatalf("json = %s, err = %v", b, err)
+	}
+	if s.Value() != "p@ssw0rd" {
+		t.Fatal("Value should return the wrapped value")
+	}
+	var cfg struct{ DSN SecretValue[string] }
+	if err := json.Unmarshal([]byte(`{"DSN":"mysql://root:pw@db"}`), &cfg); err != nil || cfg.DSN.Value() != "mysql://root:pw@db" {
+		t.Fatalf("unmarshal = %q, err = %v", cfg.DSN.Value(), err)
+	}
+
+	var output bytes.Buffer
+	NewWriter(&output).Info().Any("password", s).Stringer("token", Secret("tok")).Msg("login")
+	if got := output.String(); strings.Contains(got, "p@ssw0rd") || strings.Contains(got, `"tok"`) {
+		t.Fatalf("secret leaked in log: %s", got)
+	}
+}
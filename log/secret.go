@@ -0,0 +1,58 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/kochabx/kit/log/redact"
+)
+
+// SecretValue 包装敏感值，任何格式化、JSON/文本序列化与 slog 输出均渲染为掩码，
+// 真实值只能通过 Value 显式取出
+type SecretValue[T any] struct {
+	value T
+}
+
+// Secret 包装敏感值，用于日志字段或会被日志间接输出的结构体字段：
+//
+//	log.Info().Any("password", log.Secret(pwd)).Msg("login")
+//	log.Info().Stringer("token", log.Secret(token)).Msg("refresh")
+func Secret[T any](v T) SecretValue[T] {
+	return SecretValue[T]{value: v}
+}
+
+// Value 返回真实值
+func (s SecretValue[T]) Value() T { return s.value }
+
+// String 实现 fmt.Stringer
+func (s SecretValue[T]) String() string { return redact.Masked }
+
+// GoString 实现 fmt.GoStringer，避免 %#v 输出真实值
+func (s SecretValue[T]) GoString() string { return redact.Masked }
+
+// Format 实现 fmt.Formatter，所有动词均输出掩码
+func (s SecretValue[T]) Format(f fmt.State, verb rune) {
+	_, _ = f.Write([]byte(redact.Masked))
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (s SecretValue[T]) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(redact.Masked)), nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，便于在配置结构体中直接使用
+func (s *SecretValue[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &s.value)
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (s SecretValue[T]) MarshalText() ([]byte, error) {
+	return []byte(redact.Masked), nil
+}
+
+// LogValue 实现 slog.LogValuer
+func (s SecretValue[T]) LogValue() slog.Value {
+	return slog.StringValue(redact.Masked)
+}
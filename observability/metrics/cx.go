@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kochabx/kit/cx"
+)
+
+// containerCollector exports per-component lifecycle metrics of a cx.Container.
+// Values are read from Container.Metrics on every scrape.
+type containerCollector struct {
+	container *cx.Container
+
+	components     *prometheus.Desc
+	buildDuration  *prometheus.Desc
+	startDuration  *prometheus.Desc
+	warmupDuration *prometheus.Desc
+	stopDuration   *prometheus.Desc
+	startFailures  *prometheus.Desc
+	warmupFailures *prometheus.Desc
+	stopFailures   *prometheus.Desc
+}
+
+// NewContainerCollector returns a collector exposing cx container metrics:
+//
+//	cx_components                          registered component count
+//	cx_component_build_duration_seconds    constructor time of the last Start
+//	cx_component_start_duration_seconds    Starter.Start time of the last Start
+//	cx_component_warmup_duration_seconds   Warmable.Warmup time of the last Start
+//	cx_component_stop_duration_seconds     Stopper.Stop time of the last Stop
+//	cx_component_start_failures_total      constructor / Start failures
+//	cx_component_warmup_failures_total     Warmup failures
+//	cx_component_stop_failures_total       Stop failures
+func NewContainerCollector(c *cx.Container) prometheus.Collector {
+	labels := []string{"component"}
+	return &containerCollector{
+		container:      c,
+		components:     prometheus.NewDesc("cx_components", "Number of registered components.", nil, nil),
+		buildDuration:  prometheus.NewDesc("cx_component_build_duration_seconds", "Constructor duration of the most recent Start, excluding dependencies.", labels, nil),
+		startDuration:  prometheus.NewDesc("cx_component_start_duration_seconds", "Starter.Start duration of the most recent Start.", labels, nil),
+		warmupDuration: prometheus.NewDesc("cx_component_warmup_duration_seconds", "Warmable.Warmup duration of the most recent Start.", labels, nil),
+		stopDuration:   prometheus.NewDesc("cx_component_stop_duration_seconds", "Stopper.Stop duration of the most recent Stop.", labels, nil),
+		startFailures:  prometheus.NewDesc("cx_component_start_failures_total", "Total constructor and Start failures.", labels, nil),
+		warmupFailures: prometheus.NewDesc("cx_component_warmup_failures_total", "Total Warmup failures, including timeouts.", labels, nil),
+		stopFailures:   prometheus.NewDesc("cx_component_stop_failures_total", "Total Stop failures.", labels, nil),
+	}
+}
+
+// WithContainerCollector registers a collector for the given cx container.
+func WithContainerCollector(c *cx.Container) Option {
+	return func(p *Prometheus) {
+		if c != nil {
+			p.collectors = append(p.collectors, NewContainerCollector(c))
+		}
+	}
+}
+
+func (cc *containerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.components
+	ch <- cc.buildDuration
+	ch <- cc.startDuration
+	ch <- cc.warmupDuration
+	ch <- cc.stopDuration
+	ch <- cc.startFailures
+	ch <- cc.warmupFailures
+	ch <- cc.stopFailures
+}
+
+func (cc *containerCollector) Collect(ch chan<- prometheus.Metric) {
+	m := cc.container.Metrics()
+	ch <- prometheus.MustNewConstMetric(cc.components, prometheus.GaugeValue, float64(m.ComponentCount))
+	for _, comp := range m.Components {
+		ch <- prometheus.MustNewConstMetric(cc.buildDuration, prometheus.GaugeValue, comp.BuildDuration.Seconds(), comp.Key)
+		ch <- prometheus.MustNewConstMetric(cc.startDuration, prometheus.GaugeValue, comp.StartDuration.Seconds(), comp.Key)
+		ch <- prometheus.MustNewConstMetric(cc.warmupDuration, prometheus.GaugeValue, comp.WarmupDuration.Seconds(), comp.Key)
+		ch <- prometheus.MustNewConstMetric(cc.stopDuration, prometheus.GaugeValue, comp.StopDuration.Seconds(), comp.Key)
+		ch <- prometheus.MustNewConstMetric(cc.startFailures, prometheus.CounterValue, float64(comp.StartFailures), comp.Key)
+		ch <- prometheus.MustNewConstMetric(cc.warmupFailures, prometheus.CounterValue, float64(comp.WarmupFailures), comp.Key)
+		ch <- prometheus.MustNewConstMetric(cc.stopFailures, prometheus.CounterValue, float64(comp.StopFailures), comp.Key)
+	}
+}
@@ -1,11 +1,16 @@
 package metrics
 
 import (
+	"context"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kochabx/kit/core/wsx"
+	"github.com/kochabx/kit/cx"
 )
 
 func TestNew_WithCollectors(t *testing.T) {
@@ -79,3 +84,48 @@ func TestWithRegistryAndCollectorsIsOrderIndependent(t *testing.T) {
 		})
 	}
 }
+
+func TestWithContainerCollector(t *testing.T) {
+	c := cx.New()
+	cx.MustSupply(c, "db", "dsn")
+	require.NoError(t, c.Start(context.Background()))
+
+	p := New(WithContainerCollector(c))
+	metricFamilies, err := p.Registry().Gather()
+	require.NoError(t, err)
+
+	names := make(map[string]struct{}, len(metricFamilies))
+	for _, mf := range metricFamilies {
+		names[mf.GetName()] = struct{}{}
+	}
+
+	assert.Contains(t, names, "cx_components")
+	assert.Contains(t, names, "cx_component_build_duration_seconds")
+	assert.Contains(t, names, "cx_component_start_failures_total")
+	assert.Contains(t, names, "cx_component_warmup_duration_seconds")
+}
+
+func TestWithWSClientCollector(t *testing.T) {
+	client := wsx.New()
+	defer client.Close()
+
+	p := New(WithWSClientCollector("feed", client))
+	metricFamilies, err := p.Registry().Gather()
+	require.NoError(t, err)
+
+	families := make(map[string]*dto.MetricFamily, len(metricFamilies))
+	for _, mf := range metricFamilies {
+		families[mf.GetName()] = mf
+	}
+
+	require.Contains(t, families, "wsx_client_connected")
+	m := families["wsx_client_connected"].GetMetric()[0]
+	assert.Equal(t, 0.0, m.GetGauge().GetValue())
+	assert.Equal(t, "client", m.GetLabel()[0].GetName())
+	assert.Equal(t, "feed", m.GetLabel()[0].GetValue())
+	assert.Contains(t, families, "wsx_client_rtt_seconds")
+	assert.Contains(t, families, "wsx_client_reconnects_total")
+	assert.Contains(t, families, "wsx_client_sent_bytes_total")
+	assert.Contains(t, families, "wsx_client_events_dropped_total")
+	assert.Contains(t, families, "wsx_client_event_handler_panics_total")
+}
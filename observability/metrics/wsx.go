@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kochabx/kit/core/wsx"
+)
+
+// wsClientCollector exports the link health statistics of a wsx client.
+// Values are read from Clienter.Stats on every scrape.
+type wsClientCollector struct {
+	client wsx.Clienter
+
+	connected         *prometheus.Desc
+	rtt               *prometheus.Desc
+	smoothedRTT       *prometheus.Desc
+	lastPong          *prometheus.Desc
+	lastMessage       *prometheus.Desc
+	reconnectAttempts *prometheus.Desc
+	reconnects        *prometheus.Desc
+	messagesIn        *prometheus.Desc
+	messagesOut       *prometheus.Desc
+	bytesIn           *prometheus.Desc
+	bytesOut          *prometheus.Desc
+	eventWorkersBusy  *prometheus.Desc
+	eventsQueued      *prometheus.Desc
+	eventsDropped     *prometheus.Desc
+	handlerPanics     *prometheus.Desc
+}
+
+// NewWSClientCollector returns a collector exposing the health statistics of
+// a wsx client, labelled with client=name:
+//
+//	wsx_client_connected                         1 when connected
+//	wsx_client_rtt_seconds                       last ping/pong round trip
+//	wsx_client_smoothed_rtt_seconds              smoothed round trip
+//	wsx_client_last_pong_timestamp_seconds       last pong received, 0 if none
+//	wsx_client_last_message_timestamp_seconds    last message received, 0 if none
+//	wsx_client_reconnect_attempts_total          automatic reconnect attempts
+//	wsx_client_reconnects_total                  successful reconnects
+//	wsx_client_messages_received_total           messages received
+//	wsx_client_messages_sent_total               messages sent
+//	wsx_client_received_bytes_total              payload bytes received
+//	wsx_client_sent_bytes_total                  payload bytes sent
+//	wsx_client_event_workers_busy                goroutines running event handlers
+//	wsx_client_events_queued                     events waiting for a handler goroutine
+//	wsx_client_events_dropped_total              events dropped because their queue was full
+//	wsx_client_event_handler_panics_total        event handler panics, recovered and logged
+//
+// Alert on a degraded link with e.g. time() - wsx_client_last_pong_timestamp_seconds,
+// and on saturated event handlers with rate(wsx_client_events_dropped_total[5m]) > 0.
+func NewWSClientCollector(name string, c wsx.Clienter) prometheus.Collector {
+	labels := prometheus.Labels{"client": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc("wsx_client_"+metric, help, nil, labels)
+	}
+	return &wsClientCollector{
+		client:            c,
+		connected:         desc("connected", "Whether the client is connected (1) or not (0)."),
+		rtt:               desc("rtt_seconds", "Round trip time of the most recent ping/pong."),
+		smoothedRTT:       desc("smoothed_rtt_seconds", "Exponentially weighted moving average of the ping/pong round trip time."),
+		lastPong:          desc("last_pong_timestamp_seconds", "Unix time of the most recent pong, 0 if none."),
+		lastMessage:       desc("last_message_timestamp_seconds", "Unix time of the most recent message received, 0 if none."),
+		reconnectAttempts: desc("reconnect_attempts_total", "Total automatic reconnect attempts."),
+		reconnects:        desc("reconnects_total", "Total successful reconnects."),
+		messagesIn:        desc("messages_received_total", "Total messages received."),
+		messagesOut:       desc("messages_sent_total", "Total messages sent."),
+		bytesIn:           desc("received_bytes_total", "Total message payload bytes received."),
+		bytesOut:          desc("sent_bytes_total", "Total message payload bytes sent."),
+		eventWorkersBusy:  desc("event_workers_busy", "Number of goroutines currently running event handlers."),
+		eventsQueued:      desc("events_queued", "Number of events waiting to be handled."),
+		eventsDropped:     desc("events_dropped_total", "Total events dropped because their queue was full."),
+		handlerPanics:     desc("event_handler_panics_total", "Total event handler panics, recovered and logged."),
+	}
+}
+
+// WithWSClientCollector registers a collector for the given wsx client.
+func WithWSClientCollector(name string, c wsx.Clienter) Option {
+	return func(p *Prometheus) {
+		if c != nil {
+			p.collectors = append(p.collectors, NewWSClientCollector(name, c))
+		}
+	}
+}
+
+func (wc *wsClientCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- wc.connected
+	ch <- wc.rtt
+	ch <- wc.smoothedRTT
+	ch <- wc.lastPong
+	ch <- wc.lastMessage
+	ch <- wc.reconnectAttempts
+	ch <- wc.reconnects
+	ch <- wc.messagesIn
+	ch <- wc.messagesOut
+	ch <- wc.bytesIn
+	ch <- wc.bytesOut
+	ch <- wc.eventWorkersBusy
+	ch <- wc.eventsQueued
+	ch <- wc.eventsDropped
+	ch <- wc.handlerPanics
+}
+
+func (wc *wsClientCollector) Collect(ch chan<- prometheus.Metric) {
+	s := wc.client.Stats()
+	connected := 0.0
+	if s.Connected {
+		connected = 1
+	}
+	ch <- prometheus.MustNewConstMetric(wc.connected, prometheus.GaugeValue, connected)
+	ch <- prometheus.MustNewConstMetric(wc.rtt, prometheus.GaugeValue, s.RTT.Seconds())
+	ch <- prometheus.MustNewConstMetric(wc.smoothedRTT, prometheus.GaugeValue, s.SmoothedRTT.Seconds())
+	ch <- prometheus.MustNewConstMetric(wc.lastPong, prometheus.GaugeValue, unixSeconds(s.LastPongAt))
+	ch <- prometheus.MustNewConstMetric(wc.lastMessage, prometheus.GaugeValue, unixSeconds(s.LastMessageAt))
+	ch <- prometheus.MustNewConstMetric(wc.reconnectAttempts, prometheus.CounterValue, float64(s.ReconnectAttempts))
+	ch <- prometheus.MustNewConstMetric(wc.reconnects, prometheus.CounterValue, float64(s.Reconnects))
+	ch <- prometheus.MustNewConstMetric(wc.messagesIn, prometheus.CounterValue, float64(s.MessagesIn))
+	ch <- prometheus.MustNewConstMetric(wc.messagesOut, prometheus.CounterValue, float64(s.MessagesOut))
+	ch <- prometheus.MustNewConstMetric(wc.bytesIn, prometheus.CounterValue, float64(s.BytesIn))
+	ch <- prometheus.MustNewConstMetric(wc.bytesOut, prometheus.CounterValue, float64(s.BytesOut))
+	ch <- prometheus.MustNewConstMetric(wc.eventWorkersBusy, prometheus.GaugeValue, float64(s.EventWorkersBusy))
+	ch <- prometheus.MustNewConstMetric(wc.eventsQueued, prometheus.GaugeValue, float64(s.EventsQueued))
+	ch <- prometheus.MustNewConstMetric(wc.eventsDropped, prometheus.CounterValue, float64(s.EventsDropped))
+	ch <- prometheus.MustNewConstMetric(wc.handlerPanics, prometheus.CounterValue, float64(s.EventHandlerPanics))
+}
+
+// unixSeconds converts t to fractional Unix seconds, 0 for the zero time.
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / float64(time.Second)
+}
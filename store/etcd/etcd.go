@@ -3,8 +3,11 @@ package etcd
 import (
 	"context"
 	"errors"
+	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/kochabx/kit/log"
 )
 
 var (
@@ -16,6 +19,12 @@ var (
 type Etcd struct {
 	Client *clientv3.Client
 	config *Config
+
+	// 可观测性
+	metrics       *metrics
+	logger        *log.Logger
+	slowThreshold time.Duration
+	onStateChange []func(StateEvent)
 }
 
 // Option Etcd 配置选项函数类型
@@ -56,11 +65,13 @@ func (e *Etcd) connect() error {
 		MaxCallRecvMsgSize:   e.config.MaxRecvMsgSize,
 		RejectOldCluster:     e.config.RejectOldCluster,
 		PermitWithoutStream:  e.config.PermitWithoutStream,
+		DialOptions:          e.dialOptions(),
 	})
 	if err != nil {
 		return ErrConnectionFailed
 	}
 	e.Client = client
+	e.watchState(client)
 	return nil
 }
 
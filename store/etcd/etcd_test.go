@@ -1,14 +1,23 @@
 package etcd
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+
+	"github.com/kochabx/kit/log"
 )
 
 // getTestEndpoint 获取测试用的 etcd 端点
@@ -313,3 +322,80 @@ func TestEtcd_MultipleEndpoints_Integration(t *testing.T) {
 	err = client.Ping(context.Background())
 	assert.NoError(t, err)
 }
+
+func TestEtcd_UnaryInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	reg := prometheus.NewRegistry()
+	e, err := New(&Config{},
+		WithMetrics(reg),
+		WithLogger(&log.Logger{Logger: zerolog.New(&buf)}),
+		WithSlowThreshold(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ok := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return nil }
+	slow := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}
+	fail := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "no leader")
+	}
+
+	ctx := context.Background()
+	assert.NoError(t, e.unaryInterceptor(ctx, "/etcdserverpb.KV/Range", nil, nil, nil, ok))
+	assert.NoError(t, e.unaryInterceptor(ctx, "/etcdserverpb.KV/Range", nil, nil, nil, slow))
+	assert.Error(t, e.unaryInterceptor(ctx, "/etcdserverpb.KV/Put", nil, nil, nil, fail))
+
+	counts := map[string]float64{}
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() != "etcd_client_requests_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			var method, code string
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "method":
+					method = l.GetValue()
+				case "code":
+					code = l.GetValue()
+				}
+			}
+			counts[method+" "+code] = m.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{"KV/Range OK": 2, "KV/Put Unavailable": 1}, counts)
+
+	out := buf.String()
+	assert.Contains(t, out, "slow etcd request detected")
+	assert.Contains(t, out, "etcd request failed")
+	assert.Contains(t, out, "no leader")
+}
+
+func TestEtcd_StateChangeEvents(t *testing.T) {
+	events := make(chan StateEvent, 16)
+	e, err := New(&Config{Endpoints: []string{"127.0.0.1:1"}, DialTimeout: 100 * time.Millisecond},
+		WithOnStateChange(func(ev StateEvent) { events <- ev }),
+	)
+	require.NoError(t, err)
+	require.NoError(t, e.connect())
+	defer e.Close()
+
+	// 触发拨号，连接不上的端点最终进入 TransientFailure
+	e.Client.ActiveConnection().Connect()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			assert.NotEqual(t, ev.From, ev.To)
+			if ev.To == connectivity.TransientFailure {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected a transient_failure state event")
+		}
+	}
+}
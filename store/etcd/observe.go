@@ -0,0 +1,205 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+
+	"github.com/kochabx/kit/log"
+)
+
+// StateEvent 连接状态变化事件
+type StateEvent struct {
+	From connectivity.State
+	To   connectivity.State
+	At   time.Time
+}
+
+// WithMetrics 启用 Prometheus 指标，按操作记录请求数、错误码与耗时，以及连接状态
+// registerer 为 nil 时注册到 prometheus.DefaultRegisterer
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(e *Etcd) {
+		if registerer == nil {
+			registerer = prometheus.DefaultRegisterer
+		}
+		e.metrics = newMetrics(registerer)
+	}
+}
+
+// WithLogger 启用请求日志：失败的请求记录为警告，其余记录为调试日志
+func WithLogger(logger *log.Logger) Option {
+	return func(e *Etcd) {
+		e.logger = logger
+	}
+}
+
+// WithSlowThreshold 设置慢请求阈值，超过阈值的请求记录为警告，需配合 WithLogger 使用
+func WithSlowThreshold(d time.Duration) Option {
+	return func(e *Etcd) {
+		e.slowThreshold = d
+	}
+}
+
+// WithOnStateChange 注册连接状态变化回调，回调在独立协程中顺序执行，不应阻塞
+func WithOnStateChange(fn func(StateEvent)) Option {
+	return func(e *Etcd) {
+		e.onStateChange = append(e.onStateChange, fn)
+	}
+}
+
+// metrics etcd 客户端指标
+type metrics struct {
+	requests        *prometheus.CounterVec   // 请求总数（按操作、gRPC 状态码）
+	requestDuration *prometheus.HistogramVec // 请求耗时
+	streams         *prometheus.CounterVec   // 流式调用（Watch、KeepAlive）建立次数
+	connState       prometheus.Gauge         // 当前连接状态（gRPC connectivity.State）
+}
+
+func newMetrics(registerer prometheus.Registerer) *metrics {
+	factory := promauto.With(registerer)
+	return &metrics{
+		requests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "etcd_client",
+				Name:      "requests_total",
+				Help:      "Total number of etcd requests",
+			},
+			[]string{"method", "code"},
+		),
+		requestDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "etcd_client",
+				Name:      "request_duration_seconds",
+				Help:      "Etcd request duration in seconds",
+				Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+			},
+			[]string{"method"},
+		),
+		streams: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "etcd_client",
+				Name:      "streams_total",
+				Help:      "Total number of etcd streams opened",
+			},
+			[]string{"method", "code"},
+		),
+		connState: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "etcd_client",
+				Name:      "connection_state",
+				Help:      "Connection state (0=idle, 1=connecting, 2=ready, 3=transient_failure, 4=shutdown)",
+			},
+		),
+	}
+}
+
+// observed 是否启用了任一观测能力
+func (e *Etcd) observed() bool {
+	return e.metrics != nil || e.logger != nil
+}
+
+// dialOptions 构建观测用的 gRPC 拦截器
+func (e *Etcd) dialOptions() []grpc.DialOption {
+	if !e.observed() {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(e.unaryInterceptor),
+		grpc.WithChainStreamInterceptor(e.streamInterceptor),
+	}
+}
+
+// unaryInterceptor 记录一元调用的耗时、错误与慢请求
+func (e *Etcd) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	duration := time.Since(start)
+	name := methodName(method)
+
+	if e.metrics != nil {
+		e.metrics.requests.WithLabelValues(name, status.Code(err).String()).Inc()
+		e.metrics.requestDuration.WithLabelValues(name).Observe(duration.Seconds())
+	}
+
+	if e.logger != nil {
+		switch {
+		case e.slowThreshold > 0 && duration > e.slowThreshold:
+			e.logger.Warn().Str("method", name).Dur("duration", duration).Dur("threshold", e.slowThreshold).Err(err).Msg("slow etcd request detected")
+		case err != nil:
+			e.logger.Warn().Str("method", name).Dur("duration", duration).Err(err).Msg("etcd request failed")
+		default:
+			e.logger.Debug().Str("method", name).Dur("duration", duration).Msg("etcd request success")
+		}
+	}
+	return err
+}
+
+// streamInterceptor 记录流式调用的建立结果；流本身长期存在，不统计耗时
+func (e *Etcd) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	name := methodName(method)
+
+	if e.metrics != nil {
+		e.metrics.streams.WithLabelValues(name, status.Code(err).String()).Inc()
+	}
+	if e.logger != nil {
+		if err != nil {
+			e.logger.Warn().Str("method", name).Err(err).Msg("etcd stream failed")
+		} else {
+			e.logger.Debug().Str("method", name).Msg("etcd stream opened")
+		}
+	}
+	return cs, err
+}
+
+// watchState 监听连接状态变化，直到客户端关闭
+func (e *Etcd) watchState(client *clientv3.Client) {
+	if e.metrics == nil && e.logger == nil && len(e.onStateChange) == 0 {
+		return
+	}
+	conn := client.ActiveConnection()
+	if conn == nil {
+		return
+	}
+	ctx := client.Ctx()
+	state := conn.GetState()
+	e.recordState(state)
+
+	go func() {
+		for conn.WaitForStateChange(ctx, state) {
+			next := conn.GetState()
+			ev := StateEvent{From: state, To: next, At: time.Now()}
+			state = next
+
+			e.recordState(next)
+			if e.logger != nil {
+				e.logger.Info().Str("from", ev.From.String()).Str("to", ev.To.String()).Msg("etcd connection state changed")
+			}
+			for _, fn := range e.onStateChange {
+				fn(ev)
+			}
+		}
+	}()
+}
+
+func (e *Etcd) recordState(state connectivity.State) {
+	if e.metrics != nil {
+		e.metrics.connState.Set(float64(state))
+	}
+}
+
+// methodName 将 "/etcdserverpb.KV/Range" 简化为 "KV/Range"
+func methodName(fullMethod string) string {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -7,24 +7,39 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	"github.com/kochabx/kit/log"
 	"github.com/kochabx/kit/transport"
 )
 
-var _ transport.Server = (*Server)(nil)
+var (
+	_ transport.Server = (*Server)(nil)
+	_ transport.Failer = (*Server)(nil)
+)
 
 const (
 	defaultName = "grpc"
 	defaultAddr = ":50051"
 )
 
-// Server is the gRPC server wrapper.
+// Server is the gRPC server wrapper. It implements transport.Server, so it
+// plugs into app.WithServer and follows the same lifecycle as the HTTP
+// transports: Start listens and serves in the background, Stop drains
+// in-flight RPCs until the context expires and then closes the remaining
+// connections.
+//
+// A *grpc.Server cannot serve again once stopped; use app.ReplaceServer with
+// a new instance instead of restarting it.
 type Server struct {
-	srv  *grpc.Server
-	addr string
-	name string
-	lis  net.Listener
+	srv    *grpc.Server
+	health *health.Server
+	addr   string
+	name   string
+	lis    net.Listener
+	failed chan error // receives the serve error of the current run
 }
 
 // config holds the builder state for NewServer.
@@ -35,6 +50,8 @@ type config struct {
 	unaryInterceptors  []grpc.UnaryServerInterceptor
 	streamInterceptors []grpc.StreamServerInterceptor
 	serverOptions      []grpc.ServerOption
+	reflection         bool
+	health             bool
 }
 
 // Option configures a Server.
@@ -83,6 +100,20 @@ func WithServerOption(opts ...grpc.ServerOption) Option {
 	}
 }
 
+// WithReflection registers the server reflection service, so tools such as
+// grpcurl can list and call services without the proto files.
+func WithReflection() Option {
+	return func(c *config) { c.reflection = true }
+}
+
+// WithHealth registers the standard grpc.health.v1 health service. The
+// overall status ("") is SERVING while the server runs; Stop marks every
+// service NOT_SERVING before draining so load balancers stop routing to it.
+// Per-service statuses can be set through Health.
+func WithHealth() Option {
+	return func(c *config) { c.health = true }
+}
+
 // NewServer creates a gRPC server with the provided options.
 // Register service implementations on Srv() before calling Run.
 func NewServer(opts ...Option) *Server {
@@ -107,16 +138,29 @@ func NewServer(opts ...Option) *Server {
 		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(cfg.tlsConfig)))
 	}
 
-	return &Server{
+	s := &Server{
 		srv:  grpc.NewServer(serverOpts...),
 		addr: cfg.addr,
 		name: cfg.name,
 	}
+	if cfg.reflection {
+		reflection.Register(s.srv)
+	}
+	if cfg.health {
+		s.health = health.NewServer()
+		s.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		healthpb.RegisterHealthServer(s.srv, s.health)
+	}
+	return s
 }
 
 // Srv returns the underlying *grpc.Server for service registration.
 func (s *Server) Srv() *grpc.Server { return s.srv }
 
+// Health returns the health service registered by WithHealth, or nil when
+// it is disabled. Use SetServingStatus to report individual services.
+func (s *Server) Health() *health.Server { return s.health }
+
 // Start implements cx.Starter, starting the gRPC server in the background and returning immediately.
 func (s *Server) Start(_ context.Context) error {
 	lis, err := net.Listen("tcp", s.addr)
@@ -124,13 +168,31 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 	s.lis = lis
-	go s.srv.Serve(lis)
+	failed := make(chan error, 1)
+	s.failed = failed
+	go func() {
+		// Serve returns nil after Stop / GracefulStop.
+		if err := s.srv.Serve(lis); err != nil {
+			log.Error().Err(err).Msgf("%s server stopped serving", s.name)
+			failed <- err
+		}
+	}()
+	if s.health != nil {
+		s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	}
 	log.Info().Msgf("%s server listening on %s", s.name, s.addr)
 	return nil
 }
 
+// Failed implements transport.Failer. The channel belongs to the most recent
+// Start; it is nil before the first Start.
+func (s *Server) Failed() <-chan error { return s.failed }
+
 // Stop gracefully stops the gRPC server and waits for the background goroutine to exit.
 func (s *Server) Stop(ctx context.Context) error {
+	if s.health != nil {
+		s.health.Shutdown()
+	}
 	stopped := make(chan struct{})
 	go func() {
 		s.srv.GracefulStop()
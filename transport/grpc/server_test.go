@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+)
+
+func TestNewServer_Defaults(t *testing.T) {
+	s := NewServer()
+	assert.Equal(t, defaultAddr, s.addr)
+	assert.Equal(t, defaultName, s.name)
+	assert.Nil(t, s.Health())
+	assert.Empty(t, s.Srv().GetServiceInfo())
+}
+
+func TestNewServer_InvalidAddrFallback(t *testing.T) {
+	s := NewServer(WithAddr("not-valid"))
+	assert.Equal(t, defaultAddr, s.addr)
+}
+
+func TestServer_HealthAndReflection(t *testing.T) {
+	var calls atomic.Int64
+	s := NewServer(
+		WithAddr("127.0.0.1:19091"),
+		WithHealth(),
+		WithReflection(),
+		WithUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			calls.Add(1)
+			return handler(ctx, req)
+		}),
+	)
+	require.NotNil(t, s.Health())
+	s.Health().SetServingStatus("user.v1.UserService", healthpb.HealthCheckResponse_SERVING)
+
+	ctx := context.Background()
+	require.NoError(t, s.Start(ctx))
+
+	conn, err := grpc.NewClient("127.0.0.1:19091", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	hc := healthpb.NewHealthClient(conn)
+	resp, err := hc.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	resp, err = hc.Check(ctx, &healthpb.HealthCheckRequest{Service: "user.v1.UserService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Equal(t, int64(2), calls.Load())
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	info, err := stream.Recv()
+	require.NoError(t, err)
+	var services []string
+	for _, svc := range info.GetListServicesResponse().GetService() {
+		services = append(services, svc.GetName())
+	}
+	assert.Contains(t, services, healthpb.Health_ServiceDesc.ServiceName)
+	require.NoError(t, stream.CloseSend())
+
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(stopCtx))
+
+	// Stop marks every service NOT_SERVING before draining.
+	resp, err = s.Health().Check(ctx, &healthpb.HealthCheckRequest{Service: "user.v1.UserService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
@@ -0,0 +1,211 @@
+// Package ginx wires gin route registration to a cx container.
+//
+// A controller is usually built from a handful of components and then
+// registers its handlers on a router. Instead of fetching each component by
+// string key inside the registration function, write a plain constructor and
+// let MountN resolve its parameters by typed key:
+//
+//	var (
+//		DBKey    = cx.NewKey[*DB]("db")
+//		CacheKey = cx.NewKey[*Cache]("cache")
+//	)
+//
+//	type UserController struct{ db *DB; cache *Cache }
+//
+//	func NewUserController(db *DB, cache *Cache) (*UserController, error) {
+//		return &UserController{db: db, cache: cache}, nil
+//	}
+//
+//	func (u *UserController) RegisterRoutes(r gin.IRouter) {
+//		r.GET("/users/:id", u.get)
+//	}
+//
+//	ginx.Mount2(engine.Group("/api"), c, DBKey, CacheKey, NewUserController)
+//
+// Dependencies are fetched with cx.GetKey, so MountN must run after the
+// container has started, or inside a constructor during Start (e.g. the one
+// building the gin engine), where dependency edges are recorded exactly as
+// for cx.Provide. No reflection is involved.
+package ginx
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kochabx/kit/cx"
+)
+
+// Routes is implemented by controllers that register their own handlers.
+type Routes interface {
+	RegisterRoutes(r gin.IRouter)
+}
+
+// routesInterface is the catalog name of Routes.
+const routesInterface = "ginx.Routes"
+
+// Controllers registered in a container are reported as "ginx.Routes" in
+// its component catalog (see cx.Container.Catalog).
+func init() {
+	cx.RegisterCatalogInterface(routesInterface, func(v any) bool {
+		_, ok := v.(Routes)
+		return ok
+	})
+}
+
+// RoutesFunc adapts a function to Routes, so a constructor can return a
+// closure instead of declaring a controller type.
+type RoutesFunc func(r gin.IRouter)
+
+// RegisterRoutes calls f(r).
+func (f RoutesFunc) RegisterRoutes(r gin.IRouter) { f(r) }
+
+// mount registers the routes returned by a constructor.
+func mount[T Routes](r gin.IRouter, routes T, err error) error {
+	if err != nil {
+		return fmt.Errorf("ginx: construct routes: %w", err)
+	}
+	routes.RegisterRoutes(r)
+	return nil
+}
+
+// ErrNilConstructor is returned by MountN for a nil constructor.
+var ErrNilConstructor = errors.New("ginx: nil constructor")
+
+// Mount0 registers the routes returned by a constructor without dependencies.
+func Mount0[T Routes](r gin.IRouter, ctor func() (T, error)) error {
+	if ctor == nil {
+		return ErrNilConstructor
+	}
+	routes, err := ctor()
+	return mount(r, routes, err)
+}
+
+// Mount1 registers the routes returned by ctor, whose single parameter is
+// resolved from a.
+func Mount1[A any, T Routes](r gin.IRouter, c *cx.Container, a cx.Key[A], ctor func(A) (T, error)) error {
+	if ctor == nil {
+		return ErrNilConstructor
+	}
+	av, err := cx.GetKey(c, a)
+	if err != nil {
+		return err
+	}
+	routes, err := ctor(av)
+	return mount(r, routes, err)
+}
+
+// Mount2 registers the routes returned by ctor, whose parameters are
+// resolved from a and b.
+func Mount2[A, B any, T Routes](r gin.IRouter, c *cx.Container, a cx.Key[A], b cx.Key[B], ctor func(A, B) (T, error)) error {
+	if ctor == nil {
+		return ErrNilConstructor
+	}
+	av, err := cx.GetKey(c, a)
+	if err != nil {
+		return err
+	}
+	bv, err := cx.GetKey(c, b)
+	if err != nil {
+		return err
+	}
+	routes, err := ctor(av, bv)
+	return mount(r, routes, err)
+}
+
+// Mount3 registers the routes returned by ctor, whose parameters are
+// resolved from a, b and d.
+func Mount3[A, B, D any, T Routes](r gin.IRouter, c *cx.Container, a cx.Key[A], b cx.Key[B], d cx.Key[D], ctor func(A, B, D) (T, error)) error {
+	if ctor == nil {
+		return ErrNilConstructor
+	}
+	av, err := cx.GetKey(c, a)
+	if err != nil {
+		return err
+	}
+	bv, err := cx.GetKey(c, b)
+	if err != nil {
+		return err
+	}
+	dv, err := cx.GetKey(c, d)
+	if err != nil {
+		return err
+	}
+	routes, err := ctor(av, bv, dv)
+	return mount(r, routes, err)
+}
+
+// Mount4 registers the routes returned by ctor, whose parameters are
+// resolved from a, b, d and e.
+func Mount4[A, B, D, E any, T Routes](r gin.IRouter, c *cx.Container, a cx.Key[A], b cx.Key[B], d cx.Key[D], e cx.Key[E], ctor func(A, B, D, E) (T, error)) error {
+	if ctor == nil {
+		return ErrNilConstructor
+	}
+	av, err := cx.GetKey(c, a)
+	if err != nil {
+		return err
+	}
+	bv, err := cx.GetKey(c, b)
+	if err != nil {
+		return err
+	}
+	dv, err := cx.GetKey(c, d)
+	if err != nil {
+		return err
+	}
+	ev, err := cx.GetKey(c, e)
+	if err != nil {
+		return err
+	}
+	routes, err := ctor(av, bv, dv, ev)
+	return mount(r, routes, err)
+}
+
+// Group is the route group the controllers of a namespace are mounted on.
+type Group struct {
+	Prefix      string            // path prefix, e.g. "/admin"
+	Middlewares []gin.HandlerFunc // handlers run before every route of the group
+}
+
+// MountNamespaces registers every component of c that implements Routes,
+// grouped by the namespace of its key (the part before the first ".", see
+// cx.CatalogNamespace). The controllers of a namespace listed in groups are
+// mounted on r.Group(Prefix, Middlewares...); all others are mounted on r:
+//
+//	ginx.MountNamespaces(engine, c, map[string]ginx.Group{
+//		"admin": {Prefix: "/admin", Middlewares: []gin.HandlerFunc{authMiddleware}},
+//	})
+//
+// With the mapping above "admin.users" is served under /admin behind the
+// auth middleware while "public.home" is served at the root, and neither
+// controller hardcodes its prefix. Namespaces are mounted in name order and
+// controllers in registration order.
+//
+// Controllers are discovered through the component catalog and fetched with
+// cx.Get, so the same rules as for MountN apply: call it after the container
+// has started or inside a constructor during Start. Components registered
+// with an interface type are only discovered once built.
+func MountNamespaces(r gin.IRouter, c *cx.Container, groups map[string]Group) error {
+	for _, ns := range c.Catalog().Namespaces {
+		var router gin.IRouter
+		for _, comp := range ns.Components {
+			if !slices.Contains(comp.Interfaces, routesInterface) {
+				continue
+			}
+			routes, err := cx.Get[Routes](c, comp.Key)
+			if err != nil {
+				return err
+			}
+			if router == nil {
+				router = r
+				if g, ok := groups[ns.Name]; ok {
+					router = r.Group(g.Prefix, g.Middlewares...)
+				}
+			}
+			routes.RegisterRoutes(router)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package ginx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kochabx/kit/cx"
+)
+
+func init() { gin.SetMode(gin.TestMode) }
+
+var (
+	greetingKey = cx.NewKey[string]("greeting")
+	suffixKey   = cx.NewKey[string]("suffix")
+	engineKey   = cx.NewKey[*gin.Engine]("engine")
+)
+
+type helloController struct{ greeting, suffix string }
+
+func newHelloController(greeting, suffix string) (*helloController, error) {
+	return &helloController{greeting: greeting, suffix: suffix}, nil
+}
+
+func (h *helloController) RegisterRoutes(r gin.IRouter) {
+	r.GET("/hello", func(c *gin.Context) { c.String(http.StatusOK, h.greeting+h.suffix) })
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Code, w.Body.String()
+}
+
+func TestMount_AfterStart(t *testing.T) {
+	c := cx.New()
+	require.NoError(t, cx.SupplyKey(c, greetingKey, "hello"))
+	require.NoError(t, cx.SupplyKey(c, suffixKey, "!"))
+	require.NoError(t, c.Start(context.Background()))
+	t.Cleanup(func() { c.Stop(context.Background()) })
+
+	e := gin.New()
+	require.NoError(t, Mount2(e.Group("/api"), c, greetingKey, suffixKey, newHelloController))
+
+	code, body := get(t, e, "/api/hello")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "hello!", body)
+}
+
+func TestMount_InsideConstructor(t *testing.T) {
+	c := cx.New()
+	require.NoError(t, cx.Provide0(c, engineKey, func() (*gin.Engine, error) {
+		e := gin.New()
+		return e, Mount1(e, c, greetingKey, func(g string) (RoutesFunc, error) {
+			return func(r gin.IRouter) {
+				r.GET("/greet", func(ctx *gin.Context) { ctx.String(http.StatusOK, g) })
+			}, nil
+		})
+	}))
+	require.NoError(t, cx.Provide0(c, greetingKey, func() (string, error) { return "hi", nil }))
+	require.NoError(t, c.Start(context.Background()))
+	t.Cleanup(func() { c.Stop(context.Background()) })
+
+	code, body := get(t, cx.MustGetKey(c, engineKey), "/greet")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "hi", body)
+	// the dependency edge is recorded through Mount
+	assert.Equal(t, []string{"greeting"}, c.DependencyGraph()["engine"])
+}
+
+func TestMount_Errors(t *testing.T) {
+	c := cx.New()
+	require.NoError(t, c.Start(context.Background()))
+	t.Cleanup(func() { c.Stop(context.Background()) })
+	e := gin.New()
+
+	err := Mount1(e, c, greetingKey, newHelloControllerNoSuffix)
+	assert.ErrorIs(t, err, cx.ErrComponentNotFound)
+
+	boom := errors.New("boom")
+	err = Mount0(e, func() (RoutesFunc, error) { return nil, boom })
+	assert.ErrorIs(t, err, boom)
+
+	var nilCtor func() (RoutesFunc, error)
+	assert.ErrorIs(t, Mount0(e, nilCtor), ErrNilConstructor)
+	assert.Empty(t, e.Routes())
+}
+
+func newHelloControllerNoSuffix(greeting string) (*helloController, error) {
+	return newHelloController(greeting, "")
+}
+
+func TestCatalog_ReportsRoutes(t *testing.T) {
+	c := cx.New()
+	require.NoError(t, cx.Provide0(c, cx.NewKey[*helloController]("controller.hello"), func() (*helloController, error) {
+		return newHelloController("hi", "!")
+	}))
+
+	comps := c.Catalog().Namespaces[0].Components
+	require.Len(t, comps, 1)
+	assert.Equal(t, []string{"ginx.Routes"}, comps[0].Interfaces)
+}
+
+// pathController serves its own path, so tests can tell where it is mounted.
+type pathController string
+
+func (p pathController) RegisterRoutes(r gin.IRouter) {
+	r.GET(string(p), func(c *gin.Context) { c.String(http.StatusOK, string(p)) })
+}
+
+func requireToken(c *gin.Context) {
+	if c.GetHeader("X-Token") == "" {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+func TestMountNamespaces(t *testing.T) {
+	c := cx.New()
+	require.NoError(t, cx.Supply(c, "admin.users", pathController("/users")))
+	require.NoError(t, cx.Supply(c, "public.home", pathController("/home")))
+	require.NoError(t, cx.Supply(c, "health", pathController("/health")))
+	require.NoError(t, cx.SupplyKey(c, greetingKey, "not a controller"))
+	require.NoError(t, c.Start(context.Background()))
+	t.Cleanup(func() { c.Stop(context.Background()) })
+
+	e := gin.New()
+	require.NoError(t, MountNamespaces(e, c, map[string]Group{
+		"admin": {Prefix: "/admin", Middlewares: []gin.HandlerFunc{requireToken}},
+	}))
+
+	code, _ := get(t, e, "/admin/users")
+	assert.Equal(t, http.StatusUnauthorized, code, "admin routes run the group middleware")
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	req.Header.Set("X-Token", "t")
+	e.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	code, body := get(t, e, "/home")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "/home", body)
+	code, _ = get(t, e, "/health")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = get(t, e, "/users")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestMountNamespaces_InsideConstructor(t *testing.T) {
+	c := cx.New()
+	require.NoError(t, cx.Provide0(c, engineKey, func() (*gin.Engine, error) {
+		e := gin.New()
+		return e, MountNamespaces(e, c, map[string]Group{"admin": {Prefix: "/admin"}})
+	}))
+	require.NoError(t, cx.Provide0(c, cx.NewKey[pathController]("admin.users"), func() (pathController, error) {
+		return "/users", nil
+	}))
+	require.NoError(t, c.Start(context.Background()))
+	t.Cleanup(func() { c.Stop(context.Background()) })
+
+	code, _ := get(t, cx.MustGetKey(c, engineKey), "/admin/users")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"admin.users"}, c.DependencyGraph()["engine"])
+}
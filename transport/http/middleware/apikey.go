@@ -0,0 +1,258 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kochabx/kit/core/rate"
+	"github.com/kochabx/kit/errors"
+	"github.com/kochabx/kit/log"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+const headerAPIKey = "X-API-Key" // 默认 API Key 请求头
+
+var (
+	ErrAPIKeyMissing       = errors.Unauthorized("api key missing")
+	ErrAPIKeyInvalid       = errors.Unauthorized("api key invalid")
+	ErrAPIKeyExpired       = errors.Unauthorized("api key expired")
+	ErrAPIKeyScope         = errors.Forbidden("api key scope insufficient")
+	ErrKeyStoreUnavailable = errors.ServiceUnavailable("api key store unavailable")
+)
+
+// APIKey API Key 记录，实现 Claims，认证成功后写入 context，
+// 可通过 GetClaims[*APIKey] / GetAPIKey 获取，也可直接用于 RoleBasedChecker 与 RequireScopes。
+type APIKey struct {
+	ID        string       // Key 标识，用于日志、审计与限流，不包含密钥本身
+	Hash      string       // 密钥的哈希 (HashAPIKey)，存储中不保存明文
+	Subject   string       // 所属主体（用户、服务或租户）
+	Scopes    []string     // 授权范围，支持通配："*" 与 "orders:*"
+	Roles     []string     // 角色，供 RoleBasedChecker 使用
+	ExpiresAt time.Time    // 过期时间，零值表示永不过期
+	Limiter   rate.Limiter // 该 Key 的限流器，为 nil 时使用 APIKeyAuthConfig.Limiter
+}
+
+var _ Claims = (*APIKey)(nil)
+
+func (k *APIKey) GetExpirationTime() (*jwt.NumericDate, error) {
+	if k.ExpiresAt.IsZero() {
+		return nil, nil
+	}
+	return jwt.NewNumericDate(k.ExpiresAt), nil
+}
+func (k *APIKey) GetIssuedAt() (*jwt.NumericDate, error)  { return nil, nil }
+func (k *APIKey) GetNotBefore() (*jwt.NumericDate, error) { return nil, nil }
+func (k *APIKey) GetIssuer() (string, error)              { return "", nil }
+func (k *APIKey) GetSubject() (string, error)             { return k.Subject, nil }
+func (k *APIKey) GetAudience() (jwt.ClaimStrings, error)  { return nil, nil }
+
+// GetRoles 返回角色，供 RoleBasedChecker 使用
+func (k *APIKey) GetRoles() []string { return k.Roles }
+
+// GetScopes 返回授权范围，供 RequireScopes 使用
+func (k *APIKey) GetScopes() []string { return k.Scopes }
+
+// HasScope 是否拥有 scope，规则同 HasPermissions
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.ContainsFunc(k.Scopes, func(g string) bool { return grantsPermission(g, scope) })
+}
+
+// KeyStore API Key 存储接口，按密钥哈希查找，存储中只需保存哈希
+type KeyStore interface {
+	// Lookup 按 HashAPIKey 计算的哈希查找 Key，不存在时返回 (nil, nil)
+	Lookup(ctx context.Context, hash string) (*APIKey, error)
+}
+
+// KeyStoreFunc 函数适配器
+type KeyStoreFunc func(ctx context.Context, hash string) (*APIKey, error)
+
+func (f KeyStoreFunc) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	return f(ctx, hash)
+}
+
+// MemoryKeyStore 内存 KeyStore，适用于配置文件下发的少量 Key 与测试
+type MemoryKeyStore struct {
+	keys map[string]*APIKey
+}
+
+// NewMemoryKeyStore 创建内存 KeyStore，按 APIKey.Hash 建立索引
+func NewMemoryKeyStore(keys ...*APIKey) *MemoryKeyStore {
+	s := &MemoryKeyStore{keys: make(map[string]*APIKey, len(keys))}
+	for _, k := range keys {
+		s.keys[k.Hash] = k
+	}
+	return s
+}
+
+// Lookup 实现 KeyStore 接口
+func (s *MemoryKeyStore) Lookup(_ context.Context, hash string) (*APIKey, error) {
+	return s.keys[hash], nil
+}
+
+// HashAPIKey 返回密钥的 SHA-256 十六进制哈希。GenerateAPIKey 生成的密钥熵足够高，
+// 无需加盐或慢哈希，认证时可按哈希直接查找
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey 生成带前缀的随机密钥 (256 位) 及其哈希：密钥只展示给调用方一次，存储中保存哈希。
+// 前缀便于识别密钥用途及被密钥扫描工具检出，如 "sk_live_"
+func GenerateAPIKey(prefix string) (key, hash string, err error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", err
+	}
+	key = prefix + base64.RawURLEncoding.EncodeToString(b[:])
+	return key, HashAPIKey(key), nil
+}
+
+// APIKeyAuthConfig API Key 认证中间件配置
+type APIKeyAuthConfig struct {
+	Skip           SkipConfig                                        // 跳过配置
+	Store          KeyStore                                          // Key 存储（必需）
+	Extractor      TokenExtractor                                    // 密钥提取器，默认从 X-API-Key 请求头提取
+	Scopes         []string                                          // 要求拥有的全部授权范围，为空时不校验；路由级要求使用 RequireScopes
+	Limiter        rate.Limiter                                      // 默认的按 Key 限流器，为 nil 且 Key 未设置 Limiter 时不限流
+	ContextKey     string                                            // 上下文键，默认 "claims"
+	SuccessHandler func(http.ResponseWriter, *http.Request, *APIKey) // 成功回调
+	ErrorHandler   func(http.ResponseWriter, *http.Request, error)   // 错误处理，默认按错误码返回 401 / 403，被限流时返回 HTTP 429
+	Logger         *log.Logger                                       // 自定义日志记录器
+}
+
+// APIKeyAuth 创建 API Key 认证中间件：提取密钥并按哈希从 Store 查找，校验过期时间与授权范围，
+// 按 Key 限流后以 *APIKey 写入 context，下游可继续使用 GetClaims 与 Permission 中间件。
+//
+// 限流 key 为 "ratelimit:apikey:<ID>"，响应中输出 X-RateLimit-* 响应头；限流器出错时放行并记录日志。
+// 通过 Query 传递密钥 (QueryExtractor) 会使密钥出现在访问日志与代理日志中，仅在无法设置请求头时使用。
+func APIKeyAuth(cfg APIKeyAuthConfig) func(http.Handler) http.Handler {
+	if cfg.Store == nil {
+		panic("middleware: APIKeyAuth requires a KeyStore")
+	}
+	if cfg.Extractor == nil {
+		cfg.Extractor = HeaderExtractor(headerAPIKey)
+	}
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = contextKey
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if err == ErrRateLimited {
+				// 与 RateLimit 一致使用真实的 429 状态码，客户端据此遵循 Retry-After
+				writeStatusError(w, r, err)
+				return
+			}
+			if e, ok := errors.From(err); ok {
+				kithttp.Fail(w, e.Code(), err)
+				return
+			}
+			kithttp.Fail(w, http.StatusUnauthorized, err)
+		}
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			secret, err := cfg.Extractor(r)
+			if err != nil {
+				if err == ErrTokenMissing {
+					err = ErrAPIKeyMissing
+				}
+				cfg.ErrorHandler(w, r, err)
+				return
+			}
+
+			key, err := cfg.Store.Lookup(r.Context(), HashAPIKey(secret))
+			if err != nil {
+				cfg.Logger.Error().Err(err).Str("path", r.URL.Path).Msg("apikey: store lookup failed")
+				cfg.ErrorHandler(w, r, ErrKeyStoreUnavailable)
+				return
+			}
+			if key == nil {
+				cfg.ErrorHandler(w, r, ErrAPIKeyInvalid)
+				return
+			}
+			if !key.ExpiresAt.IsZero() && !time.Now().Before(key.ExpiresAt) {
+				cfg.ErrorHandler(w, r, ErrAPIKeyExpired)
+				return
+			}
+			for _, scope := range cfg.Scopes {
+				if !key.HasScope(scope) {
+					cfg.ErrorHandler(w, r, ErrAPIKeyScope)
+					return
+				}
+			}
+
+			limiter := key.Limiter
+			if limiter == nil {
+				limiter = cfg.Limiter
+			}
+			if limiter != nil {
+				res, err := limiter.Allow(r.Context(), "ratelimit:apikey:"+key.ID, 1)
+				if err != nil {
+					cfg.Logger.Error().Err(err).Str("key_id", key.ID).Msg("apikey: limiter failed")
+				} else {
+					res.SetHeaders(w.Header())
+					if !res.Allowed {
+						cfg.ErrorHandler(w, r, ErrRateLimited)
+						return
+					}
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), cfg.ContextKey, key)
+			r = r.WithContext(ctx)
+			setRequestLogValue(ctx, cfg.ContextKey, key)
+
+			if cfg.SuccessHandler != nil {
+				cfg.SuccessHandler(w, r, key)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetAPIKey 从 Context 获取 APIKeyAuth 写入的 Key
+func GetAPIKey(ctx context.Context, key ...string) (*APIKey, bool) {
+	return GetClaims[*APIKey](ctx, key...)
+}
+
+// RequireScopes 创建要求拥有全部 scopes 的权限检查器，授权范围取自 claims 的 GetScopes()，
+// 与 Permission 中间件配合用于路由级的授权范围要求：
+//
+//	r.With(middleware.RequirePermission(middleware.RequireScopes("orders:write"))).Post("/orders", create)
+func RequireScopes(scopes ...string) PermissionChecker {
+	return PermissionCheckerFunc(func(ctx context.Context, r *http.Request) error {
+		claims := ctx.Value(contextKey)
+		if claims == nil {
+			return ErrUnauthorized
+		}
+		sv, ok := claims.(interface{ GetScopes() []string })
+		if !ok {
+			return ErrAPIKeyScope
+		}
+		granted := sv.GetScopes()
+		for _, scope := range scopes {
+			if !slices.ContainsFunc(granted, func(g string) bool { return grantsPermission(g, scope) }) {
+				return ErrAPIKeyScope
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kochabx/kit/log"
+)
+
+// ============================================================================
+// APIKeyAuth 中间件测试
+// ============================================================================
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, hash, err := GenerateAPIKey("sk_test_")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(key, "sk_test_") || len(key) != len("sk_test_")+43 {
+		t.Errorf("key = %q", key)
+	}
+	if hash != HashAPIKey(key) || len(hash) != 64 {
+		t.Errorf("hash = %q, want sha256 of key", hash)
+	}
+	other, _, _ := GenerateAPIKey("sk_test_")
+	if other == key {
+		t.Error("generated keys should be random")
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	limiter := &quotaLimiter{limit: 1, used: make(map[string]int64)}
+	store := NewMemoryKeyStore(
+		&APIKey{ID: "k1", Hash: HashAPIKey("secret-1"), Subject: "svc-a", Scopes: []string{"orders:*"}, Roles: []string{"reader"}},
+		&APIKey{ID: "k2", Hash: HashAPIKey("secret-2"), Subject: "svc-b", Scopes: []string{"users:read"}},
+		&APIKey{ID: "k3", Hash: HashAPIKey("secret-3"), Subject: "svc-c", Scopes: []string{"*"}, ExpiresAt: time.Now().Add(-time.Minute)},
+		&APIKey{ID: "k4", Hash: HashAPIKey("secret-4"), Subject: "svc-d", Scopes: []string{"*"}, Limiter: limiter},
+	)
+
+	var got *APIKey
+	handler := APIKeyAuth(APIKeyAuthConfig{
+		Store:  store,
+		Scopes: []string{"orders:read"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetAPIKey(r.Context())
+		// 与 JWT Auth 共用 GetClaims
+		claims, ok := GetClaims[Claims](r.Context())
+		if !ok {
+			t.Error("claims should be available via GetClaims")
+		} else if sub, _ := claims.GetSubject(); sub != got.Subject {
+			t.Errorf("subject = %q", sub)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	withKey := func(key string) func(*http.Request) {
+		return func(r *http.Request) { r.Header.Set("X-API-Key", key) }
+	}
+
+	w := do(handler, http.MethodGet, "/orders", withKey("secret-1"))
+	if w.Code != http.StatusOK || got == nil || got.ID != "k1" {
+		t.Fatalf("valid key: code=%d key=%+v body=%s", w.Code, got, w.Body.String())
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"missing", "", `"code":401,"msg":"api key missing"`},
+		{"unknown", "nope", `"code":401,"msg":"api key invalid"`},
+		{"scope", "secret-2", `"code":403,"msg":"api key scope insufficient"`},
+		{"expired", "secret-3", `"code":401,"msg":"api key expired"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			w := do(handler, http.MethodGet, "/orders", withKey(tt.key))
+			if got != nil || !containsString(w.Body.String(), tt.want) {
+				t.Errorf("body = %s, want %q", w.Body.String(), tt.want)
+			}
+		})
+	}
+
+	// 按 Key 限流，被限流时返回真实的 429
+	if w := do(handler, http.MethodGet, "/orders", withKey("secret-4")); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("first request: code=%d headers=%v", w.Code, w.Header())
+	}
+	if w := do(handler, http.MethodGet, "/orders", withKey("secret-4")); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second request should be limited, got %d", w.Code)
+	}
+	if limiter.used["ratelimit:apikey:k4"] != 1 {
+		t.Errorf("limiter usage = %v", limiter.used)
+	}
+}
+
+func TestAPIKeyAuth_StoreErrorAndQuery(t *testing.T) {
+	calls := 0
+	store := KeyStoreFunc(func(ctx context.Context, hash string) (*APIKey, error) {
+		calls++
+		if hash == HashAPIKey("down") {
+			return nil, errors.New("db down")
+		}
+		return &APIKey{ID: "q", Subject: "svc"}, nil
+	})
+	handler := APIKeyAuth(APIKeyAuthConfig{
+		Store:     store,
+		Extractor: ChainExtractor(HeaderExtractor("X-API-Key"), QueryExtractor("api_key")),
+		Logger:    &log.Logger{Logger: zerolog.Nop()},
+	})(okHandler)
+
+	if w := do(handler, http.MethodGet, "/?api_key=abc", nil); w.Code != http.StatusOK {
+		t.Errorf("query key: code=%d body=%s", w.Code, w.Body.String())
+	}
+	w := do(handler, http.MethodGet, "/?api_key=down", nil)
+	if !containsString(w.Body.String(), `"code":503`) {
+		t.Errorf("store error: body=%s", w.Body.String())
+	}
+	if calls != 2 {
+		t.Errorf("store calls = %d", calls)
+	}
+}
+
+func TestRequireScopes(t *testing.T) {
+	key := &APIKey{ID: "k", Scopes: []string{"orders:*", "users:read"}}
+	ctx := context.WithValue(context.Background(), contextKey, key)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	if err := RequireScopes("orders:write", "users:read").Check(ctx, r); err != nil {
+		t.Errorf("expected scopes granted, got %v", err)
+	}
+	if err := RequireScopes("users:write").Check(ctx, r); err != ErrAPIKeyScope {
+		t.Errorf("expected ErrAPIKeyScope, got %v", err)
+	}
+	if err := RequireScopes("orders:read").Check(context.Background(), r); err != ErrUnauthorized {
+		t.Errorf("expected ErrUnauthorized without claims, got %v", err)
+	}
+}
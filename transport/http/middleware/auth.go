@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,6 +21,7 @@ var (
 	ErrTokenMissing     = errors.Unauthorized("token missing")
 	ErrTokenInvalid     = errors.Unauthorized("token invalid")
 	ErrAuthenticatorNil = errors.Unauthorized("authenticator missing")
+	ErrTokenAudience    = errors.Unauthorized("token audience mismatch")
 )
 
 // Claims JWT Claims 类型约束
@@ -103,6 +105,7 @@ type AuthConfig[T Claims] struct {
 	Authenticator  Authenticator[T]                                // 认证器（必需）
 	Extractor      TokenExtractor                                  // Token 提取器，默认 BearerExtractor
 	ContextKey     string                                          // 上下文键，默认 "claims"
+	Audience       string                                          // 要求的受众，为空时不校验；用于将路由组绑定到特定服务
 	SuccessHandler func(http.ResponseWriter, *http.Request, T)     // 成功回调
 	ErrorHandler   func(http.ResponseWriter, *http.Request, error) // 错误处理，默认返回 401
 }
@@ -147,8 +150,17 @@ func Auth[T Claims](cfg AuthConfig[T]) func(http.Handler) http.Handler {
 				return
 			}
 
+			if cfg.Audience != "" {
+				aud, err := claims.GetAudience()
+				if err != nil || !slices.Contains(aud, cfg.Audience) {
+					cfg.ErrorHandler(w, r, ErrTokenAudience)
+					return
+				}
+			}
+
 			ctx := context.WithValue(r.Context(), cfg.ContextKey, claims)
 			r = r.WithContext(ctx)
+			setRequestLogValue(ctx, cfg.ContextKey, claims)
 
 			if cfg.SuccessHandler != nil {
 				cfg.SuccessHandler(w, r, claims)
@@ -471,3 +471,38 @@ func TestAuthenticatorFunc(t *testing.T) {
 		t.Errorf("UserID = %d, want %d", claims.UserID, 999)
 	}
 }
+
+func TestAuth_Audience(t *testing.T) {
+	claims := &TestClaims{UserID: 1}
+	claims.Audience = jwt.ClaimStrings{"orders", "billing"}
+	auth := &mockAuthenticator{claims: claims}
+
+	tests := []struct {
+		audience string
+		allowed  bool
+	}{
+		{"", true},
+		{"orders", true},
+		{"billing", true},
+		{"admin", false},
+	}
+	for _, tt := range tests {
+		handler := setupHandler(Auth(AuthConfig[*TestClaims]{
+			Authenticator: auth,
+			Audience:      tt.audience,
+		}))
+
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		denied := containsString(w.Body.String(), `"code":401`)
+		if denied == tt.allowed {
+			t.Errorf("audience %q: allowed = %v, want %v, body: %s", tt.audience, !denied, tt.allowed, w.Body.String())
+		}
+		if denied && !containsString(w.Body.String(), "token audience mismatch") {
+			t.Errorf("audience %q: unexpected body %s", tt.audience, w.Body.String())
+		}
+	}
+}
@@ -2,16 +2,17 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 )
 
-// CorsConfig CORS 中间件配置
+// CorsConfig CORS 中间件配置，AllowOrigins、AllowMethods、AllowHeaders、MaxAge 为零值时使用 DefaultCorsConfig 中的默认值
 type CorsConfig struct {
 	Skip             SkipConfig // 跳过配置
-	AllowCredentials bool       // 是否允许携带凭证
-	MaxAge           int        // 预检请求缓存时间（秒）
-	AllowOrigins     []string   // 允许的源，支持 "*" 通配符
+	AllowCredentials bool       // 是否允许携带凭证，不能与 "*" 来源同时使用
+	MaxAge           int        // 预检请求缓存时间（秒），< 0 表示不缓存
+	AllowOrigins     []string   // 允许的源，"*" 表示全部，"*.example.com" 匹配子域名
 	AllowMethods     []string   // 允许的 HTTP 方法
 	AllowHeaders     []string   // 允许的请求头
 	ExposeHeaders    []string   // 暴露给客户端的响应头
@@ -29,19 +30,46 @@ func DefaultCorsConfig() CorsConfig {
 	}
 }
 
-// Cors 创建 CORS 中间件
+// Cors 创建 CORS 中间件：
+//   - 简单请求与实际请求：来源允许时输出 Access-Control-Allow-Origin，
+//     按需输出 Access-Control-Allow-Credentials 与 Access-Control-Expose-Headers
+//   - 预检请求（OPTIONS 且带 Access-Control-Request-Method）：来源允许时输出允许的方法、请求头与缓存时间并返回 204，
+//     否则返回 403；不进入后续处理
+//
+// 来源不允许的实际请求照常处理，但不输出 CORS 响应头，由浏览器拦截响应。
+// AllowCredentials 为 true 时须显式设置 AllowOrigins 且不能包含 "*"，否则 panic，避免任意来源携带凭证访问。
 func Cors(cfgs ...CorsConfig) func(http.Handler) http.Handler {
 	cfg := DefaultCorsConfig()
 	if len(cfgs) > 0 {
 		cfg = cfgs[0]
 	}
+	defaults := DefaultCorsConfig()
+	if len(cfg.AllowOrigins) == 0 {
+		// 携带凭证时必须显式列出来源，不能回退为默认的 "*"
+		if cfg.AllowCredentials {
+			panic("middleware: Cors AllowCredentials requires explicit AllowOrigins")
+		}
+		cfg.AllowOrigins = defaults.AllowOrigins
+	}
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = defaults.AllowMethods
+	}
+	if len(cfg.AllowHeaders) == 0 {
+		cfg.AllowHeaders = defaults.AllowHeaders
+	}
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = defaults.MaxAge
+	}
+
+	allowAllOrigins := slices.Contains(cfg.AllowOrigins, "*")
+	if allowAllOrigins && cfg.AllowCredentials {
+		panic("middleware: Cors AllowCredentials cannot be used with AllowOrigins \"*\"")
+	}
 
-	allowAllOrigins := len(cfg.AllowOrigins) == 1 && cfg.AllowOrigins[0] == "*"
 	methodsHeader := strings.Join(cfg.AllowMethods, ", ")
 	headersHeader := strings.Join(cfg.AllowHeaders, ", ")
 	exposeHeader := strings.Join(cfg.ExposeHeaders, ", ")
-	maxAgeHeader := strconv.Itoa(cfg.MaxAge)
-	credentialsHeader := strconv.FormatBool(cfg.AllowCredentials)
+	maxAgeHeader := strconv.Itoa(max(cfg.MaxAge, 0))
 
 	matcher := NewPathMatcher(cfg.Skip.Paths)
 
@@ -58,49 +86,67 @@ func Cors(cfgs ...CorsConfig) func(http.Handler) http.Handler {
 				return
 			}
 
-			allowed := allowAllOrigins || isOriginAllowed(origin, cfg.AllowOrigins)
-			if !allowed {
+			header := w.Header()
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !allowAllOrigins {
+				// 响应随 Origin 变化，避免缓存将某一来源的响应返回给其他来源
+				header.Add("Vary", "Origin")
+			}
+			if preflight {
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if !allowAllOrigins && !isOriginAllowed(origin, cfg.AllowOrigins) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			header := w.Header()
-			if allowAllOrigins && !cfg.AllowCredentials {
+			if allowAllOrigins {
 				header.Set("Access-Control-Allow-Origin", "*")
 			} else {
 				header.Set("Access-Control-Allow-Origin", origin)
-				header.Set("Vary", "Origin")
 			}
-
-			header.Set("Access-Control-Allow-Methods", methodsHeader)
-			header.Set("Access-Control-Allow-Headers", headersHeader)
-			header.Set("Access-Control-Allow-Credentials", credentialsHeader)
-
-			if exposeHeader != "" {
-				header.Set("Access-Control-Expose-Headers", exposeHeader)
+			if cfg.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
 			}
-			header.Set("Access-Control-Max-Age", maxAgeHeader)
 
-			if r.Method == http.MethodOptions {
+			if preflight {
+				header.Set("Access-Control-Allow-Methods", methodsHeader)
+				header.Set("Access-Control-Allow-Headers", headersHeader)
+				header.Set("Access-Control-Max-Age", maxAgeHeader)
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
 
+			if exposeHeader != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeader)
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// isOriginAllowed 检查源是否在允许列表中
+// isOriginAllowed 检查源是否在允许列表中，忽略大小写
 func isOriginAllowed(origin string, allowedOrigins []string) bool {
 	for _, allowed := range allowedOrigins {
-		if allowed == origin {
+		if strings.EqualFold(allowed, origin) {
 			return true
 		}
-		// 支持通配符匹配，如 "*.example.com"
-		if strings.HasPrefix(allowed, "*.") {
-			suffix := allowed[1:] // ".example.com"
-			if strings.HasSuffix(origin, suffix) {
+		// 支持通配符匹配，如 "*.example.com"；不限定协议与端口
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			host := origin
+			if _, rest, ok := strings.Cut(origin, "://"); ok {
+				host = rest
+			}
+			if h, _, ok := strings.Cut(host, ":"); ok {
+				host = h
+			}
+			if len(host) > len(suffix)+1 && strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(suffix)) {
 				return true
 			}
 		}
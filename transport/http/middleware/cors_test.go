@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -116,6 +117,139 @@ func TestCors_WildcardSubdomain(t *testing.T) {
 	}
 }
 
+func TestCors_Credentials(t *testing.T) {
+	cfg := CorsConfig{
+		AllowOrigins:     []string{"https://example.com"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Total-Count"},
+	}
+	handler := Cors(cfg)(okHandler)
+
+	w := do(handler, http.MethodGet, "/test", func(r *http.Request) {
+		r.Header.Set("Origin", "https://example.com")
+	})
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("Allow-Origin = %q, want reflected origin", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allow-Credentials = %q, want %q", got, "true")
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+		t.Errorf("Expose-Headers = %q, want %q", got, "X-Total-Count")
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want %q", got, "Origin")
+	}
+	// 预检专用响应头不出现在实际请求中
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "" {
+		t.Errorf("Allow-Methods should only be set on preflight, got %q", got)
+	}
+
+	// 不允许携带凭证时不输出 Allow-Credentials
+	w = do(Cors()(okHandler), http.MethodGet, "/test", func(r *http.Request) {
+		r.Header.Set("Origin", "https://example.com")
+	})
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Allow-Credentials = %q, want empty", got)
+	}
+}
+
+func TestCors_CredentialsWithWildcardPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for AllowCredentials with \"*\" origin")
+		}
+	}()
+	Cors(CorsConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+func TestCors_CredentialsWithoutOriginsPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for AllowCredentials without AllowOrigins")
+		}
+	}()
+	Cors(CorsConfig{AllowCredentials: true})
+}
+
+func TestCors_PreflightDefaults(t *testing.T) {
+	// 仅配置来源，其余字段使用默认值
+	handler := Cors(CorsConfig{AllowOrigins: []string{"https://example.com"}})(okHandler)
+
+	t.Run("allowed", func(t *testing.T) {
+		w := do(handler, http.MethodOptions, "/test", func(r *http.Request) {
+			r.Header.Set("Origin", "https://EXAMPLE.com")
+			r.Header.Set("Access-Control-Request-Method", "PUT")
+		})
+		if w.Code != http.StatusNoContent {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusNoContent)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, DELETE, PATCH, OPTIONS" {
+			t.Errorf("Allow-Methods = %q, want defaults", got)
+		}
+		if got := w.Header().Get("Access-Control-Max-Age"); got != "43200" {
+			t.Errorf("Max-Age = %q, want %q", got, "43200")
+		}
+		vary := strings.Join(w.Header().Values("Vary"), ", ")
+		if !containsString(vary, "Origin") || !containsString(vary, "Access-Control-Request-Method") {
+			t.Errorf("Vary = %q, want Origin and Access-Control-Request-Method", vary)
+		}
+	})
+
+	t.Run("disallowed", func(t *testing.T) {
+		w := do(handler, http.MethodOptions, "/test", func(r *http.Request) {
+			r.Header.Set("Origin", "https://evil.com")
+			r.Header.Set("Access-Control-Request-Method", "PUT")
+		})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Allow-Origin = %q, want empty", got)
+		}
+	})
+
+	t.Run("plain options", func(t *testing.T) {
+		// 不带 Access-Control-Request-Method 的 OPTIONS 不是预检，交给后续处理
+		w := do(handler, http.MethodOptions, "/test", func(r *http.Request) {
+			r.Header.Set("Origin", "https://example.com")
+		})
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		h := Cors(CorsConfig{MaxAge: -1})(okHandler)
+		w := do(h, http.MethodOptions, "/test", func(r *http.Request) {
+			r.Header.Set("Origin", "https://example.com")
+			r.Header.Set("Access-Control-Request-Method", "GET")
+		})
+		if got := w.Header().Get("Access-Control-Max-Age"); got != "0" {
+			t.Errorf("Max-Age = %q, want %q", got, "0")
+		}
+	})
+}
+
+func TestCors_WildcardSubdomainBoundary(t *testing.T) {
+	allowed := []string{"*.example.com"}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"http://a.b.example.com:8080", true},
+		{"https://example.com", false},
+		{"https://evilexample.com", false},
+		{"https://example.com.evil.com", false},
+	}
+	for _, tt := range tests {
+		if got := isOriginAllowed(tt.origin, allowed); got != tt.want {
+			t.Errorf("isOriginAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
 // ============================================================================
 // Gin 集成测试 — 同一中间件通过 gin.WrapH 在 Gin 框架下运行
 // ============================================================================
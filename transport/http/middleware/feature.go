@@ -0,0 +1,300 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kochabx/kit/errors"
+	"github.com/kochabx/kit/log"
+)
+
+const (
+	defaultFlagTenantHeader = "X-Tenant-ID" // 默认租户 Header
+	defaultFlagEnvPrefix    = "FEATURE_"    // EnvFlagProvider 默认环境变量前缀
+)
+
+var ErrFlagProviderNil = errors.Internal("flag provider missing")
+
+// flagSetKey FlagSet 在 context 中的键
+type flagSetKey struct{}
+
+// FlagSubject 旗标评估主体
+type FlagSubject struct {
+	UserID   string
+	TenantID string
+}
+
+// FlagProvider 旗标提供者接口，返回主体可见的全部旗标。
+// 接入 OpenFeature 等第三方 SDK 时，用 FlagProviderFunc 包装其客户端即可。
+type FlagProvider interface {
+	Evaluate(ctx context.Context, subject FlagSubject) (map[string]bool, error)
+}
+
+// FlagProviderFunc 旗标提供者函数适配器
+type FlagProviderFunc func(ctx context.Context, subject FlagSubject) (map[string]bool, error)
+
+func (f FlagProviderFunc) Evaluate(ctx context.Context, subject FlagSubject) (map[string]bool, error) {
+	return f(ctx, subject)
+}
+
+// FlagExposure 旗标曝光事件：请求内首次读取某个旗标时触发
+type FlagExposure struct {
+	Flag    string
+	Enabled bool
+	Subject FlagSubject
+}
+
+// FlagSet 请求级旗标集合，并发安全
+type FlagSet struct {
+	flags    map[string]bool
+	subject  FlagSubject
+	onExpose func(FlagExposure)
+
+	mu      sync.Mutex
+	exposed map[string]struct{}
+}
+
+// NewFlagSet 创建旗标集合，主要用于测试或在中间件之外手动注入
+func NewFlagSet(flags map[string]bool) *FlagSet {
+	return &FlagSet{flags: flags}
+}
+
+// IsEnabled 返回旗标是否开启，未知旗标视为关闭
+func (fs *FlagSet) IsEnabled(name string) bool {
+	if fs == nil {
+		return false
+	}
+	enabled := fs.flags[name]
+	fs.expose(name, enabled)
+	return enabled
+}
+
+// Flags 返回全部旗标的副本，不触发曝光事件
+func (fs *FlagSet) Flags() map[string]bool {
+	if fs == nil {
+		return nil
+	}
+	out := make(map[string]bool, len(fs.flags))
+	for k, v := range fs.flags {
+		out[k] = v
+	}
+	return out
+}
+
+// expose 每个旗标在一次请求内只上报一次曝光
+func (fs *FlagSet) expose(name string, enabled bool) {
+	if fs.onExpose == nil {
+		return
+	}
+	fs.mu.Lock()
+	if _, ok := fs.exposed[name]; ok {
+		fs.mu.Unlock()
+		return
+	}
+	if fs.exposed == nil {
+		fs.exposed = make(map[string]struct{})
+	}
+	fs.exposed[name] = struct{}{}
+	fs.mu.Unlock()
+
+	fs.onExpose(FlagExposure{Flag: name, Enabled: enabled, Subject: fs.subject})
+}
+
+// WithFlagSet 将 FlagSet 写入 context
+func WithFlagSet(ctx context.Context, fs *FlagSet) context.Context {
+	return context.WithValue(ctx, flagSetKey{}, fs)
+}
+
+// GetFlagSet 从 context 获取 FlagSet
+func GetFlagSet(ctx context.Context) (*FlagSet, bool) {
+	fs, ok := ctx.Value(flagSetKey{}).(*FlagSet)
+	return fs, ok
+}
+
+// IsEnabled 返回 context 中旗标是否开启；未经过 FeatureFlag 中间件时恒为 false
+func IsEnabled(ctx context.Context, name string) bool {
+	fs, _ := GetFlagSet(ctx)
+	return fs.IsEnabled(name)
+}
+
+// FeatureFlagConfig 特性旗标中间件配置
+type FeatureFlagConfig struct {
+	Skip           SkipConfig                                                   // 跳过配置
+	Provider       FlagProvider                                                 // 旗标提供者（必需）
+	SubjectFunc    func(r *http.Request) FlagSubject                            // 评估主体提取，默认从 claims 取用户、从 X-Tenant-ID 取租户
+	OverrideHeader string                                                       // 旗标覆盖 Header（如 "X-Feature-Flags"），为空时禁用；客户端可借此改写旗标，仅建议在测试环境开启
+	OnExposure     func(ctx context.Context, e FlagExposure)                    // 曝光事件回调
+	ErrorHandler   func(w http.ResponseWriter, r *http.Request, err error) bool // 提供者出错时调用，返回 true 继续处理（旗标全关），默认记录日志并继续
+	Logger         *log.Logger                                                  // 自定义日志记录器
+}
+
+// FeatureFlag 创建特性旗标中间件：按请求评估旗标并以 FlagSet 注入 context，
+// 业务代码通过 IsEnabled(ctx, "new-checkout") 判断，无需关心旗标来源。
+func FeatureFlag(cfg FeatureFlagConfig) func(http.Handler) http.Handler {
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+	if cfg.SubjectFunc == nil {
+		cfg.SubjectFunc = SubjectFromClaims(contextKey, defaultFlagTenantHeader)
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) bool {
+			cfg.Logger.Warn().Err(err).
+				Str("path", r.URL.Path).
+				Msg("feature flag: evaluate failed, all flags disabled")
+			return true
+		}
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			subject := cfg.SubjectFunc(r)
+
+			var flags map[string]bool
+			var err error
+			if cfg.Provider == nil {
+				err = ErrFlagProviderNil
+			} else {
+				flags, err = cfg.Provider.Evaluate(r.Context(), subject)
+			}
+			if err != nil {
+				if !cfg.ErrorHandler(w, r, err) {
+					return
+				}
+				flags = nil
+			}
+			if flags == nil {
+				flags = make(map[string]bool)
+			}
+			if cfg.OverrideHeader != "" {
+				applyFlagOverrides(flags, r.Header.Get(cfg.OverrideHeader))
+			}
+
+			fs := &FlagSet{flags: flags, subject: subject}
+			if cfg.OnExposure != nil {
+				ctx := r.Context()
+				fs.onExpose = func(e FlagExposure) { cfg.OnExposure(ctx, e) }
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithFlagSet(r.Context(), fs)))
+		})
+	}
+}
+
+// SubjectFromClaims 返回评估主体提取函数：用户 ID 取自 claims 的 GetSubject()，
+// 租户 ID 优先取 claims 的 GetTenantID()，否则取 tenantHeader。
+func SubjectFromClaims(claimsKey, tenantHeader string) func(r *http.Request) FlagSubject {
+	if claimsKey == "" {
+		claimsKey = contextKey
+	}
+	return func(r *http.Request) FlagSubject {
+		var s FlagSubject
+		claims := r.Context().Value(claimsKey)
+		s.UserID = claimsSubject(claims)
+		if c, ok := claims.(interface{ GetTenantID() string }); ok {
+			s.TenantID = c.GetTenantID()
+		}
+		if s.TenantID == "" && tenantHeader != "" {
+			s.TenantID = r.Header.Get(tenantHeader)
+		}
+		return s
+	}
+}
+
+// claimsSubject 返回 claims 的 GetSubject()，支持 jwt.Claims 与返回 string 的自定义 claims
+func claimsSubject(claims any) string {
+	if c, ok := claims.(interface{ GetSubject() (string, error) }); ok {
+		sub, _ := c.GetSubject()
+		return sub
+	}
+	if c, ok := claims.(interface{ GetSubject() string }); ok {
+		return c.GetSubject()
+	}
+	return ""
+}
+
+// applyFlagOverrides 解析覆盖 Header："a,b" 开启 a、b，"-c" 关闭 c
+func applyFlagOverrides(flags map[string]bool, header string) {
+	for item := range strings.SplitSeq(header, ",") {
+		item = strings.TrimSpace(item)
+		if name, ok := strings.CutPrefix(item, "-"); ok {
+			if name != "" {
+				flags[name] = false
+			}
+		} else if item != "" {
+			flags[item] = true
+		}
+	}
+}
+
+// parseFlagRule 解析旗标规则：
+//   - "true" / "1" / "on"：全部开启
+//   - "false" / "0" / "off" / 空：全部关闭
+//   - 其他：逗号分隔的用户或租户 ID 白名单
+func parseFlagRule(rule string, subject FlagSubject) bool {
+	rule = strings.TrimSpace(rule)
+	switch strings.ToLower(rule) {
+	case "true", "1", "on":
+		return true
+	case "", "false", "0", "off":
+		return false
+	}
+	ids := strings.Split(rule, ",")
+	for i := range ids {
+		ids[i] = strings.TrimSpace(ids[i])
+	}
+	return (subject.UserID != "" && slices.Contains(ids, subject.UserID)) ||
+		(subject.TenantID != "" && slices.Contains(ids, subject.TenantID))
+}
+
+// EnvFlagProvider 从环境变量读取旗标：FEATURE_NEW_CHECKOUT=true 对应旗标 "new-checkout"。
+// 变量名去掉前缀后转小写，下划线替换为连字符；值的语法见 parseFlagRule。
+// prefix 为空时使用 "FEATURE_"。环境变量在每次评估时读取。
+func EnvFlagProvider(prefix string) FlagProvider {
+	if prefix == "" {
+		prefix = defaultFlagEnvPrefix
+	}
+	return FlagProviderFunc(func(_ context.Context, subject FlagSubject) (map[string]bool, error) {
+		flags := make(map[string]bool)
+		for _, kv := range os.Environ() {
+			k, v, _ := strings.Cut(kv, "=")
+			name, ok := strings.CutPrefix(k, prefix)
+			if !ok || name == "" {
+				continue
+			}
+			name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+			flags[name] = parseFlagRule(v, subject)
+		}
+		return flags, nil
+	})
+}
+
+// RedisFlagProvider 从 Redis Hash 读取旗标：字段为旗标名，值的语法见 parseFlagRule。
+//
+//	HSET feature:flags new-checkout "tenant-a,tenant-b" dark-mode true
+func RedisFlagProvider(client redis.UniversalClient, key string) FlagProvider {
+	return FlagProviderFunc(func(ctx context.Context, subject FlagSubject) (map[string]bool, error) {
+		rules, err := client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		flags := make(map[string]bool, len(rules))
+		for name, rule := range rules {
+			flags[name] = parseFlagRule(rule, subject)
+		}
+		return flags, nil
+	})
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// ============================================================================
+// FeatureFlag 中间件测试
+// ============================================================================
+
+func staticFlags(flags map[string]bool) FlagProvider {
+	return FlagProviderFunc(func(ctx context.Context, subject FlagSubject) (map[string]bool, error) {
+		out := make(map[string]bool, len(flags))
+		for k, v := range flags {
+			out[k] = v
+		}
+		return out, nil
+	})
+}
+
+func TestFeatureFlag_IsEnabled(t *testing.T) {
+	var got bool
+	mw := FeatureFlag(FeatureFlagConfig{Provider: staticFlags(map[string]bool{"new-checkout": true})})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = IsEnabled(r.Context(), "new-checkout")
+		if IsEnabled(r.Context(), "unknown") {
+			t.Error("unknown flag should be disabled")
+		}
+	}))
+
+	do(h, http.MethodGet, "/", nil)
+	if !got {
+		t.Error("new-checkout should be enabled")
+	}
+}
+
+func TestFeatureFlag_SubjectFromClaims(t *testing.T) {
+	var subject FlagSubject
+	provider := FlagProviderFunc(func(ctx context.Context, s FlagSubject) (map[string]bool, error) {
+		subject = s
+		return nil, nil
+	})
+	mw := FeatureFlag(FeatureFlagConfig{Provider: provider})
+
+	do(mw(okHandler), http.MethodGet, "/", func(r *http.Request) {
+		*r = *r.WithContext(context.WithValue(r.Context(), contextKey, &permClaims{subject: "u1"}))
+		r.Header.Set("X-Tenant-ID", "t1")
+	})
+
+	if subject.UserID != "u1" || subject.TenantID != "t1" {
+		t.Errorf("subject = %+v, want u1/t1", subject)
+	}
+}
+
+func TestFeatureFlag_ExposureOncePerFlag(t *testing.T) {
+	var events []FlagExposure
+	mw := FeatureFlag(FeatureFlagConfig{
+		Provider:   staticFlags(map[string]bool{"a": true}),
+		OnExposure: func(ctx context.Context, e FlagExposure) { events = append(events, e) },
+	})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		IsEnabled(r.Context(), "a")
+		IsEnabled(r.Context(), "a")
+		IsEnabled(r.Context(), "b")
+	}))
+
+	do(h, http.MethodGet, "/", nil)
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want 2", len(events))
+	}
+	if events[0].Flag != "a" || !events[0].Enabled || events[1].Flag != "b" || events[1].Enabled {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestFeatureFlag_OverrideHeader(t *testing.T) {
+	var a, b bool
+	mw := FeatureFlag(FeatureFlagConfig{
+		Provider:       staticFlags(map[string]bool{"a": true}),
+		OverrideHeader: "X-Feature-Flags",
+	})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a, b = IsEnabled(r.Context(), "a"), IsEnabled(r.Context(), "b")
+	}))
+
+	do(h, http.MethodGet, "/", func(r *http.Request) {
+		r.Header.Set("X-Feature-Flags", "-a, b")
+	})
+	if a || !b {
+		t.Errorf("a=%v b=%v, want a=false b=true", a, b)
+	}
+}
+
+func TestFeatureFlag_ProviderErrorFailsOpen(t *testing.T) {
+	reached := false
+	provider := FlagProviderFunc(func(ctx context.Context, s FlagSubject) (map[string]bool, error) {
+		return nil, errors.New("redis down")
+	})
+	mw := FeatureFlag(FeatureFlagConfig{Provider: provider})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		if IsEnabled(r.Context(), "a") {
+			t.Error("flags should be disabled on provider error")
+		}
+	}))
+
+	do(h, http.MethodGet, "/", nil)
+	if !reached {
+		t.Error("handler should be reached when provider fails")
+	}
+}
+
+func TestEnvFlagProvider(t *testing.T) {
+	t.Setenv("FEATURE_NEW_CHECKOUT", "true")
+	t.Setenv("FEATURE_BETA", "tenant-a, tenant-b")
+
+	flags, err := EnvFlagProvider("").Evaluate(context.Background(), FlagSubject{TenantID: "tenant-b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flags["new-checkout"] || !flags["beta"] {
+		t.Errorf("flags = %v", flags)
+	}
+
+	flags, _ = EnvFlagProvider("").Evaluate(context.Background(), FlagSubject{TenantID: "tenant-c"})
+	if flags["beta"] {
+		t.Error("beta should be disabled for tenant-c")
+	}
+}
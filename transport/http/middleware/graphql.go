@@ -0,0 +1,721 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/kochabx/kit/core/rate"
+	"github.com/kochabx/kit/errors"
+	"github.com/kochabx/kit/log"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+const (
+	defaultGraphQLMaxBodySize = 1 << 20     // 默认请求体上限 1MiB
+	anonymousOperation        = "anonymous" // 匿名操作在指标与限流 key 中的名称
+)
+
+var (
+	ErrGraphQLBadRequest   = errors.BadRequest("invalid graphql request")
+	ErrGraphQLTooDeep      = errors.BadRequest("graphql query too deep")
+	ErrGraphQLTooComplex   = errors.BadRequest("graphql query too complex")
+	ErrGraphQLNotAllowed   = errors.Forbidden("graphql query not allowed")
+	ErrGraphQLRateLimited  = errors.TooManyRequests("graphql rate limit exceeded")
+	ErrGraphQLQueryMissing = errors.BadRequest("graphql query missing")
+)
+
+// graphQLOperationKey GraphQLOperation 在 context 中的键
+type graphQLOperationKey struct{}
+
+// GraphQLOperation 解析后的 GraphQL 操作
+type GraphQLOperation struct {
+	Name       string // operationName，匿名操作为空
+	Type       string // query / mutation / subscription
+	Query      string // 查询文本（持久化查询已还原）
+	Hash       string // 查询文本的 sha256（十六进制）
+	Depth      int    // 选择集最大嵌套深度，片段按展开计算
+	Complexity int    // 字段总数，片段按展开计算
+}
+
+// label 返回用于指标与限流的操作名
+func (op *GraphQLOperation) label() string {
+	if op.Name == "" {
+		return anonymousOperation
+	}
+	return op.Name
+}
+
+// GetGraphQLOperation 从 Context 获取当前请求的 GraphQL 操作
+func GetGraphQLOperation(ctx context.Context) (*GraphQLOperation, bool) {
+	op, ok := ctx.Value(graphQLOperationKey{}).(*GraphQLOperation)
+	return op, ok
+}
+
+// GraphQLConfig GraphQL 中间件配置
+type GraphQLConfig struct {
+	Skip          SkipConfig // 跳过配置
+	MaxBodySize   int64      // 请求体上限，默认 1MiB
+	MaxDepth      int        // 最大嵌套深度，0 表示不限制
+	MaxComplexity int        // 最大字段数，0 表示不限制
+
+	// PersistedQueries 持久化查询白名单 (sha256 十六进制 → 查询文本)。
+	// 非 nil 时只放行白名单内的查询；请求可只携带 extensions.persistedQuery.sha256Hash，
+	// 中间件会将查询文本还原到请求体中再交给下游。
+	PersistedQueries map[string]string
+
+	Limiter  rate.Limiter                                       // 按操作限流，为 nil 时不限流
+	LimitKey func(r *http.Request, op *GraphQLOperation) string // 限流 key，默认 "graphql:<操作名>"
+
+	OperationRoles map[string][]string       // 操作名 → 允许的角色
+	MutationRoles  []string                  // 执行任意 mutation 所需的角色
+	ClaimsKey      string                    // 从 context 获取 claims 的 key，默认 "claims"
+	RolesGetter    func(claims any) []string // 从 claims 获取角色，默认使用 GetRoles()
+
+	Registerer   prometheus.Registerer                           // 不为 nil 时按操作记录指标
+	ErrorHandler func(http.ResponseWriter, *http.Request, error) // 错误处理函数
+	Logger       *log.Logger                                     // 自定义日志记录器
+}
+
+// graphQLMetrics GraphQL 指标
+type graphQLMetrics struct {
+	operations *prometheus.CounterVec   // 操作总数（按操作名、类型、结果）
+	duration   *prometheus.HistogramVec // 放行操作的处理耗时
+}
+
+func newGraphQLMetrics(registerer prometheus.Registerer) *graphQLMetrics {
+	factory := promauto.With(registerer)
+	return &graphQLMetrics{
+		operations: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "graphql",
+				Name:      "operations_total",
+				Help:      "Total number of GraphQL operations",
+			},
+			[]string{"operation", "type", "outcome"},
+		),
+		duration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "graphql",
+				Name:      "operation_duration_seconds",
+				Help:      "GraphQL operation duration in seconds",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"operation", "type"},
+		),
+	}
+}
+
+// graphQLRequest GraphQL over HTTP 请求体
+type graphQLRequest struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	Extensions    struct {
+		PersistedQuery *struct {
+			Version    int    `json:"version"`
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery,omitempty"`
+	} `json:"extensions"`
+}
+
+// GraphQL 创建 GraphQL 感知的中间件，放在 gqlgen 等 GraphQL handler 之前，
+// 使 GraphQL 请求同样受到 HTTP 层的保护：
+//   - 持久化查询白名单（兼容 Apollo APQ 的 extensions.persistedQuery）
+//   - 深度 / 复杂度限制，片段按展开计算
+//   - 按操作名的角色校验，claims 来自 Auth 中间件
+//   - 按操作名的限流与指标
+//
+// 支持 GET (query 参数)、POST application/json 与 POST application/graphql，
+// 不支持批量请求与 multipart 上传。解析后的操作可通过 GetGraphQLOperation 获取。
+func GraphQL(cfg GraphQLConfig) func(http.Handler) http.Handler {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultGraphQLMaxBodySize
+	}
+	if cfg.LimitKey == nil {
+		cfg.LimitKey = func(r *http.Request, op *GraphQLOperation) string {
+			return "graphql:" + op.label()
+		}
+	}
+	if cfg.ClaimsKey == "" {
+		cfg.ClaimsKey = contextKey
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if e, ok := errors.From(err); ok {
+				kithttp.Fail(w, e.Code(), err)
+				return
+			}
+			kithttp.Fail(w, http.StatusInternalServerError, err)
+		}
+	}
+
+	var metrics *graphQLMetrics
+	if cfg.Registerer != nil {
+		metrics = newGraphQLMetrics(cfg.Registerer)
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			op, r, outcome, err := cfg.inspect(w, r)
+			if err != nil {
+				if metrics != nil {
+					name, typ := anonymousOperation, ""
+					if op != nil {
+						name, typ = op.label(), op.Type
+					}
+					metrics.operations.WithLabelValues(name, typ, outcome).Inc()
+				}
+				cfg.Logger.Warn().Err(err).
+					Str("path", r.URL.Path).
+					Str("outcome", outcome).
+					Msg("graphql: request rejected")
+				cfg.ErrorHandler(w, r, err)
+				return
+			}
+
+			start := time.Now()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), graphQLOperationKey{}, op)))
+			if metrics != nil {
+				metrics.operations.WithLabelValues(op.label(), op.Type, "ok").Inc()
+				metrics.duration.WithLabelValues(op.label(), op.Type).Observe(time.Since(start).Seconds())
+			}
+		})
+	}
+}
+
+// inspect 解析并校验请求，返回可能被改写 (还原持久化查询) 的请求与拒绝原因
+func (cfg *GraphQLConfig) inspect(w http.ResponseWriter, r *http.Request) (*GraphQLOperation, *http.Request, string, error) {
+	req, err := readGraphQLRequest(w, r, cfg.MaxBodySize)
+	if err != nil {
+		return nil, r, "bad_request", err
+	}
+
+	// 持久化查询：还原或校验查询文本
+	if cfg.PersistedQueries != nil {
+		hash := hashQuery(req.Query)
+		if pq := req.Extensions.PersistedQuery; pq != nil && req.Query == "" {
+			hash = pq.SHA256Hash
+		}
+		query, ok := cfg.PersistedQueries[hash]
+		if !ok {
+			return nil, r, "not_allowed", ErrGraphQLNotAllowed
+		}
+		if req.Query == "" {
+			req.Query = query
+			if r, err = rewriteGraphQLRequest(r, req); err != nil {
+				return nil, r, "bad_request", err
+			}
+		}
+	}
+	if req.Query == "" {
+		return nil, r, "bad_request", ErrGraphQLQueryMissing
+	}
+
+	// 超出限制时分析立即停止，不会完整展开片段
+	op, err := analyzeGraphQL(req.Query, req.OperationName, cfg.MaxDepth, cfg.MaxComplexity)
+	switch {
+	case err == ErrGraphQLTooDeep:
+		op.Hash = hashQuery(req.Query)
+		return op, r, "too_deep", ErrGraphQLTooDeep.With("max_depth", strconv.Itoa(cfg.MaxDepth))
+	case err == ErrGraphQLTooComplex:
+		op.Hash = hashQuery(req.Query)
+		return op, r, "too_complex", ErrGraphQLTooComplex.With("max_complexity", strconv.Itoa(cfg.MaxComplexity))
+	case err != nil:
+		return nil, r, "bad_request", errors.Wrap(err, http.StatusBadRequest, ErrGraphQLBadRequest.Message())
+	}
+	op.Hash = hashQuery(req.Query)
+
+	if err := cfg.authorize(r.Context(), op); err != nil {
+		return op, r, "forbidden", err
+	}
+
+	if cfg.Limiter != nil {
+		res, err := cfg.Limiter.Allow(r.Context(), cfg.LimitKey(r, op), 1)
+		switch {
+		case err != nil:
+			// 限流器故障时放行，避免 Redis 抖动导致整体不可用
+			cfg.Logger.Error().Err(err).Str("operation", op.label()).Msg("graphql: rate limiter failed")
+		case !res.Allowed:
+			res.SetHeaders(w.Header())
+			return op, r, "rate_limited", ErrGraphQLRateLimited
+		}
+	}
+
+	return op, r, "", nil
+}
+
+// authorize 按操作名与操作类型校验角色
+func (cfg *GraphQLConfig) authorize(ctx context.Context, op *GraphQLOperation) error {
+	var required [][]string
+	if roles, ok := cfg.OperationRoles[op.Name]; ok && op.Name != "" {
+		required = append(required, roles)
+	}
+	if op.Type == "mutation" && len(cfg.MutationRoles) > 0 {
+		required = append(required, cfg.MutationRoles)
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	claims := ctx.Value(cfg.ClaimsKey)
+	if claims == nil {
+		return ErrUnauthorized
+	}
+	var roles []string
+	if cfg.RolesGetter != nil {
+		roles = cfg.RolesGetter(claims)
+	} else if rv, ok := claims.(interface{ GetRoles() []string }); ok {
+		roles = rv.GetRoles()
+	}
+	for _, allowed := range required {
+		if !hasIntersection(allowed, roles) {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+// readGraphQLRequest 读取 GET / POST 请求中的 GraphQL 参数，POST 请求体会被还原供下游读取
+func readGraphQLRequest(w http.ResponseWriter, r *http.Request, maxBodySize int64) (*graphQLRequest, error) {
+	req := new(graphQLRequest)
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if ext := q.Get("extensions"); ext != "" {
+			if err := json.Unmarshal([]byte(ext), &req.Extensions); err != nil {
+				return nil, ErrGraphQLBadRequest
+			}
+		}
+		return req, nil
+	case http.MethodPost:
+	default:
+		return nil, ErrGraphQLBadRequest
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return nil, ErrGraphQLBadRequest
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/graphql":
+		req.Query = string(body)
+		req.OperationName = r.URL.Query().Get("operationName")
+	case "application/json", "":
+		if err := json.Unmarshal(body, req); err != nil {
+			return nil, ErrGraphQLBadRequest
+		}
+	default:
+		return nil, ErrGraphQLBadRequest
+	}
+	return req, nil
+}
+
+// rewriteGraphQLRequest 将还原后的查询写回请求，供下游 GraphQL handler 使用
+func rewriteGraphQLRequest(r *http.Request, req *graphQLRequest) (*http.Request, error) {
+	if r.Method == http.MethodGet {
+		r = r.Clone(r.Context())
+		q := r.URL.Query()
+		q.Set("query", req.Query)
+		r.URL.RawQuery = q.Encode()
+		return r, nil
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return r, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r, nil
+}
+
+func hashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// ---------------------------------------------------------------------------
+// 查询分析
+// ---------------------------------------------------------------------------
+
+// gqlToken GraphQL 词法单元
+type gqlToken struct {
+	punct bool   // 是否为标点符号
+	val   string // 名称、标点或字面量
+}
+
+// gqlDefinition 文档中的顶层定义
+type gqlDefinition struct {
+	kind string // query / mutation / subscription / fragment
+	name string
+	sel  int // 选择集 '{' 在 token 中的下标
+}
+
+// gqlAnalyzer 计算操作的深度与复杂度
+type gqlAnalyzer struct {
+	toks          []gqlToken
+	fragments     map[string]int // 片段名 → 选择集下标
+	memo          map[string][2]int
+	visiting      map[string]bool
+	maxDepth      int // 超出时返回 ErrGraphQLTooDeep，0 表示不限制
+	maxComplexity int // 超出时返回 ErrGraphQLTooComplex，0 表示不限制
+}
+
+// analyzeGraphQL 解析查询文本，选出 operationName 对应的操作并计算其深度与复杂度。
+// 深度或复杂度超出限制 (<=0 表示不限制) 时立即停止，返回 ErrGraphQLTooDeep / ErrGraphQLTooComplex
+// 及只含 Name 与 Type 的操作，避免相互引用的片段被指数级展开。
+func analyzeGraphQL(query, operationName string, maxDepth, maxComplexity int) (*GraphQLOperation, error) {
+	toks, err := lexGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := splitGraphQL(toks)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &gqlAnalyzer{
+		toks:          toks,
+		fragments:     make(map[string]int),
+		memo:          make(map[string][2]int),
+		visiting:      make(map[string]bool),
+		maxDepth:      maxDepth,
+		maxComplexity: maxComplexity,
+	}
+	var selected *gqlDefinition
+	var operations int
+	for i := range defs {
+		d := &defs[i]
+		if d.kind == "fragment" {
+			a.fragments[d.name] = d.sel
+			continue
+		}
+		operations++
+		if operationName == "" || d.name == operationName {
+			selected = d
+		}
+	}
+	switch {
+	case selected == nil && operationName != "":
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	case selected == nil:
+		return nil, fmt.Errorf("no operation")
+	case operationName == "" && operations > 1:
+		return nil, fmt.Errorf("operationName is required for documents with multiple operations")
+	}
+
+	depth, complexity, _, err := a.selectionSet(selected.sel, 0)
+	if err == ErrGraphQLTooDeep || err == ErrGraphQLTooComplex {
+		return &GraphQLOperation{Name: selected.name, Type: selected.kind, Query: query}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLOperation{
+		Name:       selected.name,
+		Type:       selected.kind,
+		Query:      query,
+		Depth:      depth,
+		Complexity: complexity,
+	}, nil
+}
+
+// lexGraphQL 将查询文本切分为 token，忽略空白、逗号与注释
+func lexGraphQL(s string) ([]gqlToken, error) {
+	var toks []gqlToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '.':
+			if i+2 >= len(s) || s[i+1] != '.' || s[i+2] != '.' {
+				return nil, fmt.Errorf("unexpected '.' at %d", i)
+			}
+			toks = append(toks, gqlToken{punct: true, val: "..."})
+			i += 3
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			toks = append(toks, gqlToken{punct: true, val: string(c)})
+			i++
+		case c == '"':
+			end, err := scanGraphQLString(s, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, gqlToken{val: s[i:end]})
+			i = end
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(s) && (s[i] == '_' || isLetter(s[i]) || isDigit(s[i])) {
+				i++
+			}
+			toks = append(toks, gqlToken{val: s[start:i]})
+		case c == '-' || isDigit(c):
+			// 数字字面量只出现在参数中，不参与分析，宽松地整体跳过
+			start := i
+			for i++; i < len(s) && (isDigit(s[i]) || s[i] == '.' || s[i] == 'e' || s[i] == 'E' || s[i] == '+' || s[i] == '-'); i++ {
+			}
+			toks = append(toks, gqlToken{val: s[start:i]})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return toks, nil
+}
+
+// scanGraphQLString 返回从 start 开始的字符串 (含块字符串) 结束后的下标
+func scanGraphQLString(s string, start int) (int, error) {
+	if len(s)-start >= 3 && s[start:start+3] == `"""` {
+		for i := start + 3; i+2 < len(s); i++ {
+			if s[i] == '\\' && i+3 < len(s) && s[i+1:i+4] == `"""` {
+				i += 3
+				continue
+			}
+			if s[i:i+3] == `"""` {
+				return i + 3, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated block string")
+	}
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		case '\n':
+			return 0, fmt.Errorf("unterminated string")
+		}
+	}
+	return 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+// splitGraphQL 识别顶层的操作与片段定义
+func splitGraphQL(toks []gqlToken) ([]gqlDefinition, error) {
+	var defs []gqlDefinition
+	for i := 0; i < len(toks); {
+		t := toks[i]
+		var d gqlDefinition
+		switch {
+		case t.punct && t.val == "{":
+			d.kind = "query"
+		case !t.punct && (t.val == "query" || t.val == "mutation" || t.val == "subscription" || t.val == "fragment"):
+			d.kind = t.val
+			i++
+			if i < len(toks) && !toks[i].punct {
+				d.name = toks[i].val
+			}
+			// 跳过变量定义、类型条件与指令，直到选择集
+			for depth := 0; i < len(toks) && (depth > 0 || !toks[i].punct || toks[i].val != "{"); i++ {
+				switch toks[i].val {
+				case "(":
+					depth++
+				case ")":
+					depth--
+				}
+			}
+			if i == len(toks) {
+				return nil, fmt.Errorf("%s %s: missing selection set", d.kind, d.name)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", t.val)
+		}
+		if d.kind == "fragment" && d.name == "" {
+			return nil, fmt.Errorf("fragment without name")
+		}
+		d.sel = i
+		end, err := skipBalanced(toks, i, "{", "}")
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, d)
+		i = end
+	}
+	return defs, nil
+}
+
+// skipBalanced 返回从 toks[i] (open) 开始、与之匹配的 close 之后的下标
+func skipBalanced(toks []gqlToken, i int, open, close string) (int, error) {
+	depth := 0
+	for ; i < len(toks); i++ {
+		if !toks[i].punct {
+			continue
+		}
+		switch toks[i].val {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced %q", open)
+}
+
+// selectionSet 计算 toks[i] 处选择集的深度与字段数，返回选择集结束后的下标。
+// level 为选择集所在的嵌套层级，用于提前判断深度是否超限；片段按 level 0 计算以便缓存，
+// 由展开处加上当前层级再判断。
+func (a *gqlAnalyzer) selectionSet(i, level int) (depth, complexity, end int, err error) {
+	toks := a.toks
+	i++ // '{'
+	for i < len(toks) && !(toks[i].punct && toks[i].val == "}") {
+		if err := a.check(level+depth, complexity); err != nil {
+			return 0, 0, 0, err
+		}
+		t := toks[i]
+		switch {
+		case t.punct && t.val == "...":
+			i++
+			if i < len(toks) && !toks[i].punct && toks[i].val != "on" {
+				// 片段展开：与当前层级同深度
+				d, c, err := a.fragment(toks[i].val)
+				if err != nil {
+					return 0, 0, 0, err
+				}
+				depth, complexity = max(depth, d), addComplexity(complexity, c)
+				i = a.skipDirectives(i + 1)
+				continue
+			}
+			// 内联片段
+			if i < len(toks) && toks[i].val == "on" {
+				i += 2
+			}
+			i = a.skipDirectives(i)
+			if i >= len(toks) || toks[i].val != "{" {
+				return 0, 0, 0, fmt.Errorf("inline fragment without selection set")
+			}
+			d, c, next, err := a.selectionSet(i, level)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			depth, complexity, i = max(depth, d), addComplexity(complexity, c), next
+		case !t.punct:
+			// 字段：[alias:] name [(args)] [@directives] [{...}]
+			i++
+			if i+1 < len(toks) && toks[i].val == ":" {
+				i += 2
+			}
+			if i < len(toks) && toks[i].val == "(" {
+				if i, err = skipBalanced(toks, i, "(", ")"); err != nil {
+					return 0, 0, 0, err
+				}
+			}
+			i = a.skipDirectives(i)
+			complexity++
+			if i < len(toks) && toks[i].val == "{" {
+				d, c, next, err := a.selectionSet(i, level+1)
+				if err != nil {
+					return 0, 0, 0, err
+				}
+				depth, complexity, i = max(depth, d+1), addComplexity(complexity, c), next
+			} else {
+				depth = max(depth, 1)
+			}
+		default:
+			return 0, 0, 0, fmt.Errorf("unexpected %q in selection set", t.val)
+		}
+	}
+	if i >= len(toks) {
+		return 0, 0, 0, fmt.Errorf("unterminated selection set")
+	}
+	if err := a.check(level+depth, complexity); err != nil {
+		return 0, 0, 0, err
+	}
+	return depth, complexity, i + 1, nil
+}
+
+// check 判断已累计的深度与字段数是否超出限制
+func (a *gqlAnalyzer) check(depth, complexity int) error {
+	if a.maxDepth > 0 && depth > a.maxDepth {
+		return ErrGraphQLTooDeep
+	}
+	if a.maxComplexity > 0 && complexity > a.maxComplexity {
+		return ErrGraphQLTooComplex
+	}
+	return nil
+}
+
+// addComplexity 累加字段数，溢出时取 math.MaxInt
+func addComplexity(a, b int) int {
+	if b > math.MaxInt-a {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// fragment 计算具名片段的深度与字段数，检测循环引用
+func (a *gqlAnalyzer) fragment(name string) (int, int, error) {
+	if m, ok := a.memo[name]; ok {
+		return m[0], m[1], nil
+	}
+	sel, ok := a.fragments[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown fragment %q", name)
+	}
+	if a.visiting[name] {
+		return 0, 0, fmt.Errorf("fragment cycle through %q", name)
+	}
+	a.visiting[name] = true
+	d, c, _, err := a.selectionSet(sel, 0)
+	a.visiting[name] = false
+	if err != nil {
+		return 0, 0, err
+	}
+	a.memo[name] = [2]int{d, c}
+	return d, c, nil
+}
+
+// skipDirectives 跳过 toks[i] 开始的指令列表
+func (a *gqlAnalyzer) skipDirectives(i int) int {
+	toks := a.toks
+	for i+1 < len(toks) && toks[i].punct && toks[i].val == "@" {
+		i += 2
+		if i < len(toks) && toks[i].val == "(" {
+			next, err := skipBalanced(toks, i, "(", ")")
+			if err != nil {
+				return len(toks)
+			}
+			i = next
+		}
+	}
+	return i
+}
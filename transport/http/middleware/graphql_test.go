@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kochabx/kit/core/rate"
+)
+
+// ============================================================================
+// GraphQL 中间件测试
+// ============================================================================
+
+func postGraphQL(handler http.Handler, body map[string]any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(data)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnalyzeGraphQL(t *testing.T) {
+	query := `
+		# 注释中的 { 不计入
+		query GetUser($id: ID!) {
+			user(id: $id, filter: {name: "a{b}"}) @include(if: true) {
+				id
+				alias: name
+				...Friends
+				... on Admin { level }
+			}
+		}
+		fragment Friends on User {
+			friends(first: 10) { id name }
+		}
+		mutation Other { x }
+	`
+	op, err := analyzeGraphQL(query, "GetUser", 0, 0)
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if op.Name != "GetUser" || op.Type != "query" {
+		t.Errorf("op = %s %s", op.Type, op.Name)
+	}
+	// user → friends → id
+	if op.Depth != 3 {
+		t.Errorf("depth = %d, want 3", op.Depth)
+	}
+	// user, id, name, friends, friends.id, friends.name, level
+	if op.Complexity != 7 {
+		t.Errorf("complexity = %d, want 7", op.Complexity)
+	}
+
+	if _, err := analyzeGraphQL(query, "", 0, 0); err == nil {
+		t.Error("expected error for multiple operations without operationName")
+	}
+	if _, err := analyzeGraphQL(`{ a ...A } fragment A on T { ...B } fragment B on T { ...A }`, "", 0, 0); err == nil {
+		t.Error("expected fragment cycle error")
+	}
+	if _, err := analyzeGraphQL(`{ a { b }`, "", 0, 0); err == nil {
+		t.Error("expected unbalanced error")
+	}
+}
+
+func TestGraphQL_DepthAndComplexity(t *testing.T) {
+	mw := GraphQL(GraphQLConfig{MaxDepth: 2, MaxComplexity: 3})
+
+	w := postGraphQL(mw(okHandler), map[string]any{"query": `{ a { b } }`})
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Errorf("shallow query should pass, got: %s", w.Body.String())
+	}
+
+	w = postGraphQL(mw(okHandler), map[string]any{"query": `{ a { b { c } } }`})
+	if !containsString(w.Body.String(), `"code":400`) {
+		t.Errorf("deep query should be rejected, got: %s", w.Body.String())
+	}
+
+	w = postGraphQL(mw(okHandler), map[string]any{"query": `{ a b c d }`})
+	if !containsString(w.Body.String(), `"code":400`) {
+		t.Errorf("complex query should be rejected, got: %s", w.Body.String())
+	}
+}
+
+// doublingFragments 构造 n 个逐级翻倍的片段，展开后字段数约为 2^(n+1)，深度为 n+1
+func doublingFragments(n int) string {
+	var b strings.Builder
+	b.WriteString("{ ...F0 }\n")
+	for i := range n {
+		fmt.Fprintf(&b, "fragment F%d on T { a: x { ...F%d } b: y { ...F%d } }\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "fragment F%d on T { z }\n", n)
+	return b.String()
+}
+
+func TestGraphQL_DoublingFragments(t *testing.T) {
+	query := doublingFragments(70)
+
+	// 不限制时字段数饱和而不溢出为负数
+	op, err := analyzeGraphQL(query, "", 0, 0)
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if op.Complexity != math.MaxInt || op.Depth != 71 {
+		t.Errorf("complexity = %d, depth = %d", op.Complexity, op.Depth)
+	}
+
+	if _, err := analyzeGraphQL(query, "", 0, 1000); err != ErrGraphQLTooComplex {
+		t.Errorf("expected ErrGraphQLTooComplex, got %v", err)
+	}
+	if _, err := analyzeGraphQL(query, "", 10, 0); err != ErrGraphQLTooDeep {
+		t.Errorf("expected ErrGraphQLTooDeep, got %v", err)
+	}
+
+	mw := GraphQL(GraphQLConfig{MaxDepth: 100, MaxComplexity: 1000})
+	w := postGraphQL(mw(okHandler), map[string]any{"query": query})
+	if !containsString(w.Body.String(), "too complex") {
+		t.Errorf("doubling fragments should be rejected, got: %s", w.Body.String())
+	}
+}
+
+func TestGraphQL_PersistedQueries(t *testing.T) {
+	query := `query Me { me { id } }`
+	hash := hashQuery(query)
+
+	var got graphQLRequest
+	var op *GraphQLOperation
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		op, _ = GetGraphQLOperation(r.Context())
+		okHandler(w, r)
+	})
+	mw := GraphQL(GraphQLConfig{PersistedQueries: map[string]string{hash: query}})
+
+	// 只携带哈希：查询被还原给下游
+	w := postGraphQL(mw(next), map[string]any{
+		"extensions": map[string]any{"persistedQuery": map[string]any{"version": 1, "sha256Hash": hash}},
+	})
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Fatalf("persisted query should pass, got: %s", w.Body.String())
+	}
+	if got.Query != query || op == nil || op.Name != "Me" || op.Hash != hash {
+		t.Errorf("downstream got query %q, op %+v", got.Query, op)
+	}
+
+	// 白名单外的查询被拒绝
+	w = postGraphQL(mw(next), map[string]any{"query": `{ users { password } }`})
+	if !containsString(w.Body.String(), `"code":403`) {
+		t.Errorf("unknown query should be rejected, got: %s", w.Body.String())
+	}
+}
+
+func TestGraphQL_Roles(t *testing.T) {
+	mw := GraphQL(GraphQLConfig{
+		OperationRoles: map[string][]string{"DeleteUser": {"admin"}},
+		MutationRoles:  []string{"editor", "admin"},
+	})
+	handler := func(roles ...string) http.Handler {
+		return withContextValue(contextKey, &permClaims{subject: "u1", roles: roles})(mw(okHandler))
+	}
+
+	w := postGraphQL(handler("editor"), map[string]any{"query": `mutation DeleteUser { deleteUser(id: 1) }`})
+	if !containsString(w.Body.String(), `"code":403`) {
+		t.Errorf("editor should not delete users, got: %s", w.Body.String())
+	}
+	w = postGraphQL(handler("admin"), map[string]any{"query": `mutation DeleteUser { deleteUser(id: 1) }`})
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Errorf("admin should delete users, got: %s", w.Body.String())
+	}
+	w = postGraphQL(mw(okHandler), map[string]any{"query": `mutation Rename { rename }`})
+	if !containsString(w.Body.String(), `"code":401`) {
+		t.Errorf("mutation without claims should be unauthorized, got: %s", w.Body.String())
+	}
+	w = postGraphQL(mw(okHandler), map[string]any{"query": `query List { users { id } }`})
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Errorf("unprotected query should pass, got: %s", w.Body.String())
+	}
+}
+
+// countingLimiter 每个 key 只放行 limit 次
+type countingLimiter struct {
+	limit int
+	seen  map[string]int
+}
+
+func (l *countingLimiter) Allow(_ context.Context, key string, _ int) (rate.Result, error) {
+	l.seen[key]++
+	if l.seen[key] > l.limit {
+		return rate.Result{RetryAfter: 2 * time.Second}, nil
+	}
+	return rate.Result{Allowed: true}, nil
+}
+
+func TestGraphQL_RateLimitAndMetrics(t *testing.T) {
+	limiter := &countingLimiter{limit: 1, seen: make(map[string]int)}
+	reg := prometheus.NewRegistry()
+	mw := GraphQL(GraphQLConfig{Limiter: limiter, Registerer: reg})
+
+	postGraphQL(mw(okHandler), map[string]any{"query": `query A { a }`})
+	postGraphQL(mw(okHandler), map[string]any{"query": `query B { b }`})
+	w := postGraphQL(mw(okHandler), map[string]any{"query": `query A { a }`})
+	if !containsString(w.Body.String(), `"code":429`) || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("second A should be limited, got: %s (Retry-After %q)", w.Body.String(), w.Header().Get("Retry-After"))
+	}
+	if limiter.seen["graphql:A"] != 2 || limiter.seen["graphql:B"] != 1 {
+		t.Errorf("limit keys = %v", limiter.seen)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	counts := make(map[string]float64)
+	for _, f := range families {
+		if f.GetName() != "graphql_operations_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			var op, outcome string
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "operation":
+					op = l.GetValue()
+				case "outcome":
+					outcome = l.GetValue()
+				}
+			}
+			counts[op+"/"+outcome] = m.GetCounter().GetValue()
+		}
+	}
+	if counts["A/ok"] != 1 || counts["B/ok"] != 1 || counts["A/rate_limited"] != 1 {
+		t.Errorf("operation counts = %v", counts)
+	}
+}
+
+func TestGraphQL_GetAndBadRequest(t *testing.T) {
+	mw := GraphQL(GraphQLConfig{MaxDepth: 1})
+
+	w := do(mw(okHandler), http.MethodGet, "/graphql?query=%7B+a+%7D", nil)
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Errorf("GET query should pass, got: %s", w.Body.String())
+	}
+	w = do(mw(okHandler), http.MethodGet, "/graphql?query=%7B+a+%7B+b+%7D+%7D", nil)
+	if !containsString(w.Body.String(), `"code":400`) {
+		t.Errorf("deep GET query should be rejected, got: %s", w.Body.String())
+	}
+	w = do(mw(okHandler), http.MethodPost, "/graphql", func(r *http.Request) {
+		r.Body = io.NopCloser(strings.NewReader(`[{"query":"{ a }"}]`))
+		r.Header.Set("Content-Type", "application/json")
+	})
+	if !containsString(w.Body.String(), `"code":400`) {
+		t.Errorf("batched request should be rejected, got: %s", w.Body.String())
+	}
+}
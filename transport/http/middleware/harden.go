@@ -0,0 +1,326 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kochabx/kit/core/breaker"
+	"github.com/kochabx/kit/errors"
+	"github.com/kochabx/kit/log"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+var (
+	ErrBodyTooLarge   = errors.New(http.StatusRequestEntityTooLarge, "request body too large")
+	ErrHandlerTimeout = errors.ServiceUnavailable("request timeout")
+	ErrCircuitOpen    = errors.ServiceUnavailable("service temporarily unavailable")
+)
+
+// HardenConfig 加固中间件组配置，零值字段对应的保护不启用
+type HardenConfig struct {
+	Skip           SkipConfig                                              // 跳过配置
+	MaxBodySize    int64                                                   // 请求体上限（字节），<= 0 不限制
+	Timeout        time.Duration                                           // handler 超时，<= 0 不限制
+	CircuitBreaker *CircuitBreakerConfig                                   // 按路由熔断，为 nil 不启用
+	ErrorHandler   func(w http.ResponseWriter, r *http.Request, err error) // 拒绝请求时的处理函数，默认按错误码返回真实状态码与标准错误响应体
+	Logger         *log.Logger                                             // 自定义日志记录器
+}
+
+// CircuitBreakerConfig 按路由熔断配置
+type CircuitBreakerConfig struct {
+	Skip         SkipConfig                                              // 跳过配置
+	MaxFailures  int                                                     // 连续失败多少次后熔断，默认 5
+	OpenTimeout  time.Duration                                           // 熔断持续时间，之后进入半开状态放行请求试探恢复，默认 30s
+	KeyFunc      func(r *http.Request) string                            // 路由 key，默认 "<方法> <路由模式>"，无路由模式时只按方法区分
+	IsFailure    func(status int) bool                                   // 按响应状态码判断失败，默认 status >= 500；handler panic 始终视为失败
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error) // 熔断时的处理函数，默认返回 HTTP 503
+	Logger       *log.Logger                                             // 自定义日志记录器
+}
+
+// Harden 创建加固中间件组，按顺序应用：
+//   - CircuitBreaker：路由熔断时直接返回 503，不再进入后续处理
+//   - MaxBodySize：请求体超过上限返回 413
+//   - Timeout：handler 超时返回 503
+//
+// 超时的请求同样计为熔断失败，持续超时的路由会被熔断，避免堆积的请求拖垮服务。
+// 流式响应（SSE、WebSocket）应通过 Skip 跳过，见 HandlerTimeout。
+func Harden(cfg HardenConfig) func(http.Handler) http.Handler {
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = writeStatusError
+	}
+
+	var mws []func(http.Handler) http.Handler
+	if cfg.CircuitBreaker != nil {
+		cb := *cfg.CircuitBreaker
+		if cb.ErrorHandler == nil {
+			cb.ErrorHandler = cfg.ErrorHandler
+		}
+		if cb.Logger == nil {
+			cb.Logger = cfg.Logger
+		}
+		mws = append(mws, CircuitBreaker(cb))
+	}
+	if cfg.MaxBodySize > 0 {
+		mws = append(mws, maxBodySize(cfg.MaxBodySize, cfg.ErrorHandler))
+	}
+	if cfg.Timeout > 0 {
+		mws = append(mws, handlerTimeout(cfg.Timeout, cfg.ErrorHandler))
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		hardened := next
+		for i := len(mws) - 1; i >= 0; i-- {
+			hardened = mws[i](hardened)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			hardened.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MaxBodySize 创建请求体大小限制中间件：Content-Length 超过 n 时直接返回 HTTP 413，
+// 否则以 http.MaxBytesReader 包装请求体，读取超过 n 字节时返回 *http.MaxBytesError
+func MaxBodySize(n int64) func(http.Handler) http.Handler {
+	if n <= 0 {
+		panic("middleware: MaxBodySize requires a positive limit")
+	}
+	return maxBodySize(n, writeStatusError)
+}
+
+func maxBodySize(n int64, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				onError(w, r, ErrBodyTooLarge)
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HandlerTimeout 创建 handler 超时中间件：handler 在独立的 goroutine 中执行，context 带有截止时间，
+// 超过 d 仍未返回时立即返回 HTTP 503，handler 之后的写入返回 http.ErrHandlerTimeout。
+//
+// 响应在 handler 返回前缓存在内存中，因此不支持 Flush 与 Hijack，流式响应应跳过该中间件。
+// handler 中的 panic 会在调用方的 goroutine 中重新抛出，可由外层的 Recovery 处理。
+// Gin 中须通过 gin.WrapH 包装整个处理链使用，AdaptToGin 下 handler 直接写入 gin.Context.Writer，无法缓存。
+func HandlerTimeout(d time.Duration) func(http.Handler) http.Handler {
+	if d <= 0 {
+		panic("middleware: HandlerTimeout requires a positive timeout")
+	}
+	return handlerTimeout(d, writeStatusError)
+}
+
+func handlerTimeout(d time.Duration, onError func(http.ResponseWriter, *http.Request, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+						return
+					}
+					close(done)
+				}()
+				next.ServeHTTP(tw, r)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				maps.Copy(w.Header(), tw.header)
+				if !tw.wroteHeader {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				_, _ = w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				onError(w, r, ErrHandlerTimeout)
+			}
+		})
+	}
+}
+
+// timeoutWriter 缓存 handler 的响应，超时后拒绝写入
+type timeoutWriter struct {
+	header http.Header
+	buf    bytes.Buffer
+
+	mu          sync.Mutex
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// CircuitBreaker 创建按路由熔断中间件：每个路由 key 独立的 breaker.Breaker（与 scheduler 的熔断器逻辑相同），
+// 连续 MaxFailures 次失败后熔断 OpenTimeout，期间直接返回 HTTP 503；之后进入半开状态，
+// 放行的请求成功即恢复，失败则重新熔断。
+//
+// 默认 key 使用 Go 1.22 ServeMux 的路由模式（r.Pattern，仅当中间件包装在 mux 注册的 handler 上时可用），
+// 通过 AdaptToGin 使用时为 Gin 的路由模板。没有路由模式（如未匹配的路由）时同一方法共用一个熔断器，
+// 不按请求路径区分，避免任意路径各自创建熔断器导致内存无上限。
+func CircuitBreaker(cfg CircuitBreakerConfig) func(http.Handler) http.Handler {
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = routeKey
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(status int) bool { return status >= http.StatusInternalServerError }
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = writeStatusError
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+
+	var (
+		mu       sync.Mutex
+		breakers = make(map[string]*breaker.Breaker)
+	)
+	get := func(key string) *breaker.Breaker {
+		mu.Lock()
+		defer mu.Unlock()
+		b, ok := breakers[key]
+		if !ok {
+			b = breaker.New(true, cfg.MaxFailures, cfg.OpenTimeout)
+			breakers[key] = b
+		}
+		return b
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := cfg.KeyFunc(r)
+			b := get(key)
+			if !b.Allow() {
+				cfg.ErrorHandler(w, r, ErrCircuitOpen)
+				return
+			}
+
+			sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				p := recover()
+				before := b.GetState()
+				if p != nil || cfg.IsFailure(sw.status) {
+					b.RecordFailure()
+				} else {
+					b.RecordSuccess()
+				}
+				if after := b.GetState(); after != before {
+					cfg.Logger.Warn().
+						Str("route", key).
+						Str("from", before.String()).
+						Str("to", after.String()).
+						Msg("circuit breaker state changed")
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// routeKey 返回 "<方法> <路由模式>"，无路由模式时只返回方法
+func routeKey(r *http.Request) string {
+	if r.Pattern != "" {
+		if strings.Contains(r.Pattern, " ") {
+			return r.Pattern // 模式已带方法，如 "GET /users/{id}"
+		}
+		return routeMethod(r.Method) + " " + r.Pattern
+	}
+	return routeMethod(r.Method)
+}
+
+// routeMethod 将非标准方法归为 "OTHER"，避免客户端以任意方法名创建熔断器
+func routeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// writeStatusError 按错误码写入真实的 HTTP 状态码与标准错误响应体，非结构化错误按 503 处理
+func writeStatusError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusServiceUnavailable
+	if e, ok := errors.From(err); ok {
+		status = e.Code()
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	kithttp.Fail(w, status, err)
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/kochabx/kit/log"
+)
+
+// ============================================================================
+// Harden 中间件测试
+// ============================================================================
+
+func TestMaxBodySize(t *testing.T) {
+	var readErr error
+	h := MaxBodySize(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	// Content-Length 超过上限直接拒绝
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("0123456789"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || !containsString(w.Body.String(), `"code":413`) {
+		t.Fatalf("expected 413, got %d %s", w.Code, w.Body.String())
+	}
+
+	// 未声明长度时读取超过上限返回 MaxBytesError
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("0123456789"))
+	req.ContentLength = -1
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Fatalf("expected MaxBytesError, got %v", readErr)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("01234567"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if readErr != nil {
+		t.Fatalf("body within limit: %v", readErr)
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	mw := HandlerTimeout(50 * time.Millisecond)
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fast" {
+			w.Header().Set("X-Handler", "fast")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("done"))
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		_, err := w.Write([]byte("late"))
+		lateWrite <- err
+	}))
+
+	w := do(h, http.MethodGet, "/fast", nil)
+	if w.Code != http.StatusCreated || w.Body.String() != "done" || w.Header().Get("X-Handler") != "fast" {
+		t.Fatalf("fast handler: %d %s %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	w = do(h, http.MethodGet, "/slow", nil)
+	if w.Code != http.StatusServiceUnavailable || !containsString(w.Body.String(), `"code":503`) {
+		t.Fatalf("expected 503, got %d %s", w.Code, w.Body.String())
+	}
+	if err := <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("write after timeout: %v", err)
+	}
+}
+
+func TestHandlerTimeout_PanicPropagates(t *testing.T) {
+	h := Recovery(RecoveryConfig{Logger: &log.Logger{Logger: zerolog.Nop()}})(HandlerTimeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	w := do(h, http.MethodGet, "/", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected Recovery to handle the panic, got %d", w.Code)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	mw := CircuitBreaker(CircuitBreakerConfig{
+		MaxFailures: 2,
+		OpenTimeout: 50 * time.Millisecond,
+		Logger:      &log.Logger{Logger: zerolog.Nop()},
+	})
+	h := http.NewServeMux()
+	h.Handle("/", mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() && r.URL.Path == "/flaky" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})))
+	h.Handle("/flaky", mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	do(h, http.MethodGet, "/flaky", nil)
+	do(h, http.MethodGet, "/flaky", nil)
+
+	// 连续失败后熔断，不再调用 handler
+	w := do(h, http.MethodGet, "/flaky", nil)
+	if w.Code != http.StatusServiceUnavailable || !containsString(w.Body.String(), `"code":503`) {
+		t.Fatalf("expected open circuit, got %d %s", w.Code, w.Body.String())
+	}
+	// 其他路由不受影响
+	if w := do(h, http.MethodGet, "/stable", nil); w.Code != http.StatusOK {
+		t.Fatalf("other route: %d", w.Code)
+	}
+
+	// 超时后半开，成功即恢复
+	time.Sleep(80 * time.Millisecond)
+	fail.Store(false)
+	if w := do(h, http.MethodGet, "/flaky", nil); w.Code != http.StatusOK {
+		t.Fatalf("half-open probe: %d", w.Code)
+	}
+	if w := do(h, http.MethodGet, "/flaky", nil); w.Code != http.StatusOK {
+		t.Fatalf("closed again: %d", w.Code)
+	}
+}
+
+func TestRouteKey(t *testing.T) {
+	mux := http.NewServeMux()
+	var key string
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) { key = routeKey(r) })
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) { key = routeKey(r) })
+
+	do(mux, http.MethodGet, "/users/42", nil)
+	if key != "GET /users/{id}" {
+		t.Errorf("key = %q", key)
+	}
+	do(mux, http.MethodPut, "/files/a/b", nil)
+	if key != "PUT /files/" {
+		t.Errorf("key = %q", key)
+	}
+	// 无路由模式时只按方法区分，不同路径不会各自创建熔断器
+	if k := routeKey(httptest.NewRequest(http.MethodPost, "/raw", nil)); k != "POST" {
+		t.Errorf("key = %q", k)
+	}
+	if k := routeKey(httptest.NewRequest("X-RANDOM-123", "/raw", nil)); k != "OTHER" {
+		t.Errorf("key = %q", k)
+	}
+}
+
+func TestCircuitBreaker_GinRouteKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var keys []string
+	r := gin.New()
+	r.Use(AdaptToGin(CircuitBreaker(CircuitBreakerConfig{
+		KeyFunc: func(r *http.Request) string {
+			keys = append(keys, routeKey(r))
+			return routeKey(r)
+		},
+		Logger: &log.Logger{Logger: zerolog.Nop()},
+	})))
+	r.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// 同一路由模板共用 key，未匹配的路由按方法共用一个 key
+	do(r, http.MethodGet, "/users/1", nil)
+	do(r, http.MethodGet, "/users/2", nil)
+	do(r, http.MethodGet, "/missing/a", nil)
+	do(r, http.MethodGet, "/missing/b", nil)
+
+	want := []string{"GET /users/:id", "GET /users/:id", "GET", "GET"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+}
+
+func TestHarden(t *testing.T) {
+	var calls atomic.Int32
+	mw := Harden(HardenConfig{
+		Skip:           SkipConfig{Paths: []string{"/events"}},
+		MaxBodySize:    4,
+		Timeout:        30 * time.Millisecond,
+		CircuitBreaker: &CircuitBreakerConfig{MaxFailures: 1, OpenTimeout: time.Minute},
+		Logger:         &log.Logger{Logger: zerolog.Nop()},
+	})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/slow" || r.URL.Path == "/events" {
+			time.Sleep(60 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("too large"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body limit: %d", w.Code)
+	}
+
+	// 超时计为熔断失败
+	if w := do(h, http.MethodGet, "/slow", nil); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("timeout: %d", w.Code)
+	}
+	before := calls.Load()
+	if w := do(h, http.MethodGet, "/slow", nil); w.Code != http.StatusServiceUnavailable || calls.Load() != before {
+		t.Fatalf("circuit should be open: code=%d calls=%d", w.Code, calls.Load()-before)
+	}
+
+	// 跳过的路径不受超时限制
+	if w := do(h, http.MethodGet, "/events", nil); w.Code != http.StatusOK {
+		t.Fatalf("skipped path: %d", w.Code)
+	}
+}
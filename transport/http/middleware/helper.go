@@ -124,6 +124,9 @@ func shouldSkip(r *http.Request, matcher *PathMatcher, skipFunc func(*http.Reque
 // 中间件内对 *http.Request 的任何修改（例如写入 context 的 Claims）会通过
 // c.Request = r 同步回 Gin 上下文，后续 Handler 可从 c.Request.Context() 中正常读取。
 //
+// 请求未设置 r.Pattern 时以 Gin 的路由模板（c.FullPath()）填充，
+// CircuitBreaker 等按路由区分的中间件据此识别路由。
+//
 // 示例：
 //
 //	r := gin.New()
@@ -132,12 +135,17 @@ func shouldSkip(r *http.Request, matcher *PathMatcher, skipFunc func(*http.Reque
 //	r.Use(AdaptToGin(Auth(authCfg)))
 func AdaptToGin(m func(http.Handler) http.Handler) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		req := c.Request
+		if req.Pattern == "" && c.FullPath() != "" {
+			req = req.WithContext(req.Context())
+			req.Pattern = c.FullPath()
+		}
 		nextCalled := false
 		m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			nextCalled = true
 			c.Request = r
 			c.Next()
-		})).ServeHTTP(c.Writer, c.Request)
+		})).ServeHTTP(c.Writer, req)
 		if !nextCalled {
 			c.Abort()
 		}
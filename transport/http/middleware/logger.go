@@ -1,20 +1,31 @@
 package middleware
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"math/rand/v2"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kochabx/kit/log"
+	"github.com/kochabx/kit/log/redact"
 )
 
-// LogFields 控制日志中各字段的记录开关
+// defaultMaxLogBodySize 记录的请求/响应体默认最大字节数
+const defaultMaxLogBodySize = 4096
+
+// LogFields 控制日志中各字段的记录开关。
+// 请求头、查询参数与请求/响应体中的凭证（Authorization、Cookie、token、password 等）
+// 及银行卡号在记录前统一脱敏
 type LogFields struct {
 	Header       bool // 是否记录请求头
 	RequestBody  bool // 是否记录请求体
@@ -28,13 +39,53 @@ type LoggerConfig struct {
 	Fields   LogFields                                          // 日志字段记录开关
 	Enricher func(*http.Request, *zerolog.Event) *zerolog.Event // 追加自定义日志字段
 	Logger   *log.Logger                                        // 自定义日志记录器
+
+	// BodySampleRate 记录请求/响应体的请求比例，取值 (0, 1]，默认 1 即全部记录；
+	// 仅在 Fields.RequestBody / Fields.ResponseBody 开启时生效，未抽中的请求不缓冲 Body
+	BodySampleRate float64
+	// MaxBodySize 记录的请求/响应体最大字节数，超出部分截断，默认 4096；不影响下游读取与客户端响应
+	MaxBodySize int
+	// ClaimsKey 读取 claims 的上下文键，默认 "claims"；claims 的 GetSubject() 记录为 user_id
+	ClaimsKey string
+}
+
+// requestLogKey requestLog 在 context 中的键
+type requestLogKey struct{}
+
+// requestLog 由 Logger 注入 context，供下游中间件回填日志字段。
+// Auth 写入的 claims 位于下游的新 context 中，Logger 经此读取
+type requestLog struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+// setRequestLogValue 在 Logger 注入的 requestLog 中记录 key 对应的值，未经 Logger 时为空操作
+func setRequestLogValue(ctx context.Context, key string, value any) {
+	rl, ok := ctx.Value(requestLogKey{}).(*requestLog)
+	if !ok {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.values == nil {
+		rl.values = make(map[string]any)
+	}
+	rl.values[key] = value
+}
+
+func (rl *requestLog) value(key string) any {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.values[key]
 }
 
-// statusResponseWriter 包装 http.ResponseWriter 以捕获状态码和响应体
+// statusResponseWriter 包装 http.ResponseWriter 以捕获状态码、响应大小和响应体
 type statusResponseWriter struct {
 	http.ResponseWriter
-	status int
-	body   *bytes.Buffer
+	status  int
+	size    int64
+	body    *bytes.Buffer
+	maxBody int
 }
 
 func (w *statusResponseWriter) WriteHeader(status int) {
@@ -44,9 +95,13 @@ func (w *statusResponseWriter) WriteHeader(status int) {
 
 func (w *statusResponseWriter) Write(b []byte) (int, error) {
 	if w.body != nil {
-		w.body.Write(b)
+		if rest := w.maxBody - w.body.Len(); rest > 0 {
+			w.body.Write(b[:min(len(b), rest)])
+		}
 	}
-	return w.ResponseWriter.Write(b)
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
 }
 
 func (w *statusResponseWriter) Flush() {
@@ -55,6 +110,32 @@ func (w *statusResponseWriter) Flush() {
 	}
 }
 
+// Hijack 支持 WebSocket 等协议升级
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("middleware: %T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// countingReader 统计下游读取的请求体字节数
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
 // clientIP 从请求中提取客户端 IP，优先读取 X-Real-IP / X-Forwarded-For
 func clientIP(r *http.Request) string {
 	if ip := r.Header.Get("X-Real-IP"); ip != "" {
@@ -72,7 +153,8 @@ func clientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
-// Logger 创建请求日志中间件
+// Logger 创建请求日志中间件，记录状态码、耗时、请求/响应大小、请求 ID、用户 ID 等访问日志字段，
+// 5xx 记为 Error，4xx 记为 Warn。需注册在 Auth 之前才能记录经 Auth 认证的用户
 func Logger(cfgs ...LoggerConfig) func(http.Handler) http.Handler {
 	cfg := LoggerConfig{}
 	if len(cfgs) > 0 {
@@ -82,6 +164,15 @@ func Logger(cfgs ...LoggerConfig) func(http.Handler) http.Handler {
 	if cfg.Logger == nil {
 		cfg.Logger = log.Global()
 	}
+	if cfg.BodySampleRate <= 0 || cfg.BodySampleRate > 1 {
+		cfg.BodySampleRate = 1
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultMaxLogBodySize
+	}
+	if cfg.ClaimsKey == "" {
+		cfg.ClaimsKey = contextKey
+	}
 
 	matcher := NewPathMatcher(cfg.Skip.Paths)
 
@@ -93,24 +184,38 @@ func Logger(cfgs ...LoggerConfig) func(http.Handler) http.Handler {
 			}
 
 			start := time.Now()
+			captureBody := cfg.BodySampleRate >= 1 || rand.Float64() < cfg.BodySampleRate
 
 			var requestBody []byte
-			if cfg.Fields.RequestBody {
-				body, err := io.ReadAll(r.Body)
+			if cfg.Fields.RequestBody && captureBody && r.Body != nil {
+				// 只读取前 MaxBodySize 字节，其余部分留给下游
+				head, err := io.ReadAll(io.LimitReader(r.Body, int64(cfg.MaxBodySize)))
 				if err == nil {
-					requestBody = body
-					r.Body = io.NopCloser(bytes.NewReader(body))
+					requestBody = head
 				}
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+			}
+			var bodyIn *countingReader
+			if r.Body != nil && r.Body != http.NoBody {
+				bodyIn = &countingReader{ReadCloser: r.Body}
+				r.Body = bodyIn
 			}
 
 			rw := &statusResponseWriter{
 				ResponseWriter: w,
 				status:         http.StatusOK,
+				maxBody:        cfg.MaxBodySize,
 			}
-			if cfg.Fields.ResponseBody {
+			if cfg.Fields.ResponseBody && captureBody {
 				rw.body = bytes.NewBuffer(nil)
 			}
 
+			rl := &requestLog{}
+			r = r.WithContext(context.WithValue(r.Context(), requestLogKey{}, rl))
+
 			next.ServeHTTP(rw, r)
 
 			var event *zerolog.Event
@@ -127,16 +232,30 @@ func Logger(cfgs ...LoggerConfig) func(http.Handler) http.Handler {
 				Str("method", r.Method).
 				Str("path", r.URL.Path).
 				Dur("duration", time.Since(start)).
-				Str("client_ip", clientIP(r))
+				Str("client_ip", clientIP(r)).
+				Int64("bytes_out", rw.size)
+
+			if bodyIn != nil {
+				// 下游未读完请求体时以 Content-Length 为准
+				event = event.Int64("bytes_in", max(bodyIn.n, r.ContentLength))
+			}
 
 			if query := r.URL.RawQuery; query != "" {
-				event = event.Str("query", query)
+				event = event.Str("query", redact.Query(query))
 			}
 
-			if requestID := r.Header.Get("X-Request-Id"); requestID != "" {
+			if requestID := requestIDOf(r); requestID != "" {
 				event = event.Str("request_id", requestID)
 			}
 
+			claims := r.Context().Value(cfg.ClaimsKey)
+			if claims == nil {
+				claims = rl.value(cfg.ClaimsKey)
+			}
+			if userID := claimsSubject(claims); userID != "" {
+				event = event.Str("user_id", userID)
+			}
+
 			if cfg.Fields.Trace {
 				span := trace.SpanFromContext(r.Context())
 				if sc := span.SpanContext(); sc.IsValid() {
@@ -147,15 +266,15 @@ func Logger(cfgs ...LoggerConfig) func(http.Handler) http.Handler {
 			}
 
 			if cfg.Fields.Header {
-				event = event.Any("headers", r.Header)
+				event = event.Any("headers", redact.Header(r.Header))
 			}
 
-			if cfg.Fields.RequestBody && len(requestBody) > 0 {
-				event = event.Bytes("request_body", requestBody)
+			if len(requestBody) > 0 {
+				event = event.Bytes("request_body", redact.JSON(requestBody))
 			}
 
-			if cfg.Fields.ResponseBody && rw.body != nil {
-				event = event.Bytes("response_body", rw.body.Bytes())
+			if rw.body != nil {
+				event = event.Bytes("response_body", redact.JSON(rw.body.Bytes()))
 			}
 
 			if cfg.Enricher != nil {
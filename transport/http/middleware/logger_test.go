@@ -1,11 +1,16 @@
 package middleware
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kochabx/kit/log"
 )
 
 // ============================================================================
@@ -96,6 +101,31 @@ func TestLogger_ResponseBodyCapture(t *testing.T) {
 	}
 }
 
+func TestLogger_MasksCredentials(t *testing.T) {
+	// 请求头、查询参数与请求体中的凭证不应出现在日志中
+	var output bytes.Buffer
+	mw := Logger(LoggerConfig{
+		Fields: LogFields{Header: true, RequestBody: true},
+		Logger: &log.Logger{Logger: zerolog.New(&output)},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login?user=alice&access_token=qs-secret",
+		strings.NewReader(`{"user":"alice","password":"body-secret"}`))
+	req.Header.Set("Authorization", "Bearer header-secret")
+	req.Header.Set("Cookie", "sid=cookie-secret")
+	mw(okHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	got := output.String()
+	for _, secret := range []string{"qs-secret", "body-secret", "header-secret", "cookie-secret"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("credential %q leaked: %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, "user=alice") || !strings.Contains(got, "/login") {
+		t.Errorf("non-sensitive fields should be kept: %s", got)
+	}
+}
+
 func TestLogger_DefaultStatus200(t *testing.T) {
 	// 未调用 WriteHeader 时，状态码应默认为 200
 	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -135,6 +165,80 @@ func TestLogger_ClientIP_RemoteAddr(t *testing.T) {
 	}
 }
 
+func TestLogger_AccessLogFields(t *testing.T) {
+	// Auth 注册在 Logger 之后时，仍能记录认证用户；同时记录请求/响应大小与请求 ID
+	var output bytes.Buffer
+	claims := &TestClaims{}
+	claims.Subject = "user123"
+	auth := Auth(AuthConfig[*TestClaims]{Authenticator: &mockAuthenticator{claims: claims}})
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Write([]byte("hello"))
+	})
+	h := Logger(LoggerConfig{Logger: &log.Logger{Logger: zerolog.New(&output)}})(auth(inner))
+
+	req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Request-Id", "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := output.String()
+	for _, want := range []string{`"user_id":"user123"`, `"bytes_in":7`, `"bytes_out":5`, `"request_id":"req-1"`, `"status":200`, `"duration":`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log should contain %s: %s", want, got)
+		}
+	}
+}
+
+func TestLogger_BodyTruncated(t *testing.T) {
+	// 超过 MaxBodySize 的部分不记录，但下游与客户端拿到完整内容
+	var output bytes.Buffer
+	var downstreamBody string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		downstreamBody = string(b)
+		w.Write([]byte("0123456789"))
+	})
+	h := Logger(LoggerConfig{
+		Fields:      LogFields{RequestBody: true, ResponseBody: true},
+		MaxBodySize: 4,
+		Logger:      &log.Logger{Logger: zerolog.New(&output)},
+	})(inner)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abcdefgh"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if downstreamBody != "abcdefgh" || w.Body.String() != "0123456789" {
+		t.Errorf("downstream body = %q, client body = %q", downstreamBody, w.Body.String())
+	}
+	got := output.String()
+	if !strings.Contains(got, `"request_body":"abcd"`) || !strings.Contains(got, `"response_body":"0123"`) {
+		t.Errorf("bodies should be truncated to 4 bytes: %s", got)
+	}
+}
+
+func TestLogger_BodySampling(t *testing.T) {
+	// 未抽中的请求不记录 Body，其余字段照常记录
+	var output bytes.Buffer
+	h := Logger(LoggerConfig{
+		Fields:         LogFields{RequestBody: true, ResponseBody: true},
+		BodySampleRate: 1e-12,
+		Logger:         &log.Logger{Logger: zerolog.New(&output)},
+	})(okHandler)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := output.String()
+	if strings.Contains(got, "request_body") || strings.Contains(got, "response_body") {
+		t.Errorf("unsampled request should not log bodies: %s", got)
+	}
+	if !strings.Contains(got, `"bytes_in":7`) {
+		t.Errorf("bytes_in should still be logged: %s", got)
+	}
+}
+
 // ============================================================================
 // Gin 集成测试
 // ============================================================================
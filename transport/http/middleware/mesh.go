@@ -0,0 +1,322 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kochabx/kit/errors"
+	"github.com/kochabx/kit/log"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+const (
+	headerXFCC   = "X-Forwarded-Client-Cert" // Envoy / Istio 转发的客户端证书信息
+	spiffeScheme = "spiffe"
+)
+
+var (
+	ErrIdentityMissing   = errors.Unauthorized("identity missing")
+	ErrIdentityInvalid   = errors.Unauthorized("identity invalid")
+	ErrIdentityUntrusted = errors.Unauthorized("identity header from untrusted peer")
+	ErrIdentityForbidden = errors.Forbidden("identity not allowed")
+)
+
+// IdentitySource 身份来源
+type IdentitySource string
+
+const (
+	IdentitySourceCertificate IdentitySource = "certificate" // 本服务终止 mTLS，来自已验证的客户端证书
+	IdentitySourceHeader      IdentitySource = "header"      // 来自 sidecar 注入的 Header，仅信任 TrustedProxies 发来的请求
+)
+
+// Principal 服务网格中的调用方身份，实现 Claims，
+// 可通过 GetClaims[*Principal] 获取，也可直接用于 RoleBasedChecker。
+type Principal struct {
+	ID          string         // 身份标识，如 spiffe://cluster.local/ns/default/sa/orders
+	TrustDomain string         // SPIFFE 信任域，如 cluster.local；非 SPIFFE 身份为空
+	Path        string         // SPIFFE 路径，如 /ns/default/sa/orders
+	Source      IdentitySource // 身份来源
+	Roles       []string       // 由 MeshAuthConfig.Roles 映射得到的角色
+}
+
+var _ Claims = (*Principal)(nil)
+
+func (p *Principal) GetExpirationTime() (*jwt.NumericDate, error) { return nil, nil }
+func (p *Principal) GetIssuedAt() (*jwt.NumericDate, error)       { return nil, nil }
+func (p *Principal) GetNotBefore() (*jwt.NumericDate, error)      { return nil, nil }
+func (p *Principal) GetIssuer() (string, error)                   { return p.TrustDomain, nil }
+func (p *Principal) GetSubject() (string, error)                  { return p.ID, nil }
+func (p *Principal) GetAudience() (jwt.ClaimStrings, error)       { return nil, nil }
+
+// GetRoles 返回角色，供 RoleBasedChecker 使用
+func (p *Principal) GetRoles() []string { return p.Roles }
+
+// IsSPIFFE 是否为 SPIFFE 身份
+func (p *Principal) IsSPIFFE() bool { return p.TrustDomain != "" }
+
+// IdentityExtractor 从 HTTP 请求提取调用方身份
+type IdentityExtractor func(r *http.Request) (string, IdentitySource, error)
+
+// PeerCertificateIdentity 从已验证的客户端证书提取 SPIFFE ID（URI SAN），
+// 适用于服务自身终止 mTLS（tls.Config.ClientAuth 为 RequireAndVerifyClientCert）
+func PeerCertificateIdentity() IdentityExtractor {
+	return func(r *http.Request) (string, IdentitySource, error) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			return "", IdentitySourceCertificate, ErrIdentityMissing
+		}
+		if len(r.TLS.VerifiedChains) == 0 {
+			return "", IdentitySourceCertificate, ErrIdentityUntrusted
+		}
+		for _, u := range r.TLS.PeerCertificates[0].URIs {
+			if u.Scheme == spiffeScheme {
+				return u.String(), IdentitySourceCertificate, nil
+			}
+		}
+		return "", IdentitySourceCertificate, ErrIdentityMissing
+	}
+}
+
+// XFCCIdentity 从 X-Forwarded-Client-Cert 提取 SPIFFE ID（Envoy / Istio）。
+// 多跳转发时取最后一个元素，即与 sidecar 直接建立 mTLS 的调用方。
+func XFCCIdentity() IdentityExtractor {
+	return func(r *http.Request) (string, IdentitySource, error) {
+		header := r.Header.Get(headerXFCC)
+		if header == "" {
+			return "", IdentitySourceHeader, ErrIdentityMissing
+		}
+		elements := splitQuoted(header, ',')
+		var uri string
+		for _, pair := range splitQuoted(elements[len(elements)-1], ';') {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(k), "URI") {
+				continue
+			}
+			v = unquote(strings.TrimSpace(v))
+			if strings.HasPrefix(v, spiffeScheme+"://") {
+				return v, IdentitySourceHeader, nil
+			}
+			if uri == "" {
+				uri = v
+			}
+		}
+		if uri == "" {
+			return "", IdentitySourceHeader, ErrIdentityMissing
+		}
+		return uri, IdentitySourceHeader, nil
+	}
+}
+
+// HeaderIdentity 从指定 Header 提取身份，如 Linkerd 的 l5d-client-id
+func HeaderIdentity(header string) IdentityExtractor {
+	return func(r *http.Request) (string, IdentitySource, error) {
+		if id := r.Header.Get(header); id != "" {
+			return id, IdentitySourceHeader, nil
+		}
+		return "", IdentitySourceHeader, ErrIdentityMissing
+	}
+}
+
+// ChainIdentity 链式提取器，依次尝试直到成功
+func ChainIdentity(extractors ...IdentityExtractor) IdentityExtractor {
+	return func(r *http.Request) (string, IdentitySource, error) {
+		err := error(ErrIdentityMissing)
+		for _, extract := range extractors {
+			id, source, e := extract(r)
+			if e == nil {
+				return id, source, nil
+			}
+			if e != ErrIdentityMissing {
+				err = e
+			}
+		}
+		return "", "", err
+	}
+}
+
+// MeshAuthConfig 服务网格身份认证中间件配置
+type MeshAuthConfig struct {
+	Skip           SkipConfig                                           // 跳过配置
+	Extractor      IdentityExtractor                                    // 身份提取器，默认依次尝试客户端证书与 X-Forwarded-Client-Cert
+	TrustedProxies []string                                             // 允许注入身份 Header 的来源地址（CIDR），默认仅回环地址
+	TrustDomains   []string                                             // 允许的 SPIFFE 信任域，为空时不校验；设置后拒绝非 SPIFFE 身份
+	Allow          []string                                             // 身份白名单，支持精确 / 前缀 /** / Glob，为空时允许全部
+	Roles          map[string][]string                                  // 身份到角色的映射
+	ContextKey     string                                               // 上下文键，默认 "claims"
+	SuccessHandler func(http.ResponseWriter, *http.Request, *Principal) // 成功回调
+	ErrorHandler   func(http.ResponseWriter, *http.Request, error)      // 错误处理，默认按错误码返回 401 / 403
+}
+
+// MeshAuth 创建服务网格身份认证中间件。
+//
+// 东西向调用由网格完成 mTLS，无需再签发 JWT：中间件从客户端证书或 sidecar 注入的 Header
+// 中提取调用方身份，校验信任域与白名单后以 *Principal 写入 context，
+// 下游可继续使用 GetClaims 与 Permission 中间件。
+func MeshAuth(cfg MeshAuthConfig) func(http.Handler) http.Handler {
+	if cfg.Extractor == nil {
+		cfg.Extractor = ChainIdentity(PeerCertificateIdentity(), XFCCIdentity())
+	}
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = contextKey
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if e, ok := errors.From(err); ok {
+				kithttp.Fail(w, e.Code(), err)
+				return
+			}
+			kithttp.Fail(w, http.StatusUnauthorized, err)
+		}
+	}
+
+	trusted := parsePrefixes(cfg.TrustedProxies)
+	if len(cfg.TrustedProxies) == 0 {
+		trusted = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+	}
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+	var allow *PathMatcher
+	if len(cfg.Allow) > 0 {
+		allow = NewPathMatcher(cfg.Allow)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, source, err := cfg.Extractor(r)
+			if err != nil {
+				cfg.ErrorHandler(w, r, err)
+				return
+			}
+			if source == IdentitySourceHeader && !fromTrustedPeer(r, trusted) {
+				cfg.ErrorHandler(w, r, ErrIdentityUntrusted)
+				return
+			}
+
+			principal, err := parsePrincipal(id)
+			if err != nil {
+				cfg.ErrorHandler(w, r, err)
+				return
+			}
+			principal.Source = source
+
+			if len(cfg.TrustDomains) > 0 && !slices.Contains(cfg.TrustDomains, principal.TrustDomain) {
+				cfg.ErrorHandler(w, r, ErrIdentityForbidden)
+				return
+			}
+			if allow != nil && !allow.Match(principal.ID) {
+				cfg.ErrorHandler(w, r, ErrIdentityForbidden)
+				return
+			}
+			principal.Roles = cfg.Roles[principal.ID]
+
+			ctx := context.WithValue(r.Context(), cfg.ContextKey, principal)
+			r = r.WithContext(ctx)
+
+			if cfg.SuccessHandler != nil {
+				cfg.SuccessHandler(w, r, principal)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetPrincipal 从 Context 获取 MeshAuth 写入的调用方身份
+func GetPrincipal(ctx context.Context, key ...string) (*Principal, bool) {
+	return GetClaims[*Principal](ctx, key...)
+}
+
+// parsePrincipal 解析身份，spiffe:// 开头的按 SPIFFE ID 校验并拆分信任域与路径
+func parsePrincipal(id string) (*Principal, error) {
+	if !strings.HasPrefix(id, spiffeScheme+"://") {
+		return &Principal{ID: id}, nil
+	}
+	u, err := url.Parse(id)
+	if err != nil || u.Host == "" || u.User != nil || u.Port() != "" ||
+		u.RawQuery != "" || u.Fragment != "" || strings.ToLower(u.Host) != u.Host {
+		return nil, ErrIdentityInvalid
+	}
+	return &Principal{ID: id, TrustDomain: u.Host, Path: u.Path}, nil
+}
+
+// fromTrustedPeer 请求的直接来源地址是否在 trusted 中
+func fromTrustedPeer(r *http.Request, trusted []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePrefixes(cidrs []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, s := range cidrs {
+		if !strings.Contains(s, "/") {
+			if addr, err := netip.ParseAddr(s); err == nil {
+				prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+				continue
+			}
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			log.Warn().Str("cidr", s).Err(err).Msg("middleware: invalid trusted proxy ignored")
+			continue
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes
+}
+
+// splitQuoted 按 sep 切分，忽略双引号内的分隔符
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote 去除双引号并还原转义字符
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const (
+	ordersID  = "spiffe://cluster.local/ns/shop/sa/orders"
+	billingID = "spiffe://cluster.local/ns/shop/sa/billing"
+)
+
+func meshHandler(cfg MeshAuthConfig) http.Handler {
+	return MeshAuth(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := GetClaims[*Principal](r.Context())
+		if !ok {
+			http.Error(w, "principal not found", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(p.ID + "|" + p.TrustDomain + "|" + p.Path + "|" + string(p.Source)))
+	}))
+}
+
+func meshRequest(remote, xfcc string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remote
+	if xfcc != "" {
+		req.Header.Set("X-Forwarded-Client-Cert", xfcc)
+	}
+	return req
+}
+
+// meshCode 返回业务码：Fail 以 HTTP 200 写出 {"code":…}，成功时 body 为身份文本
+func meshCode(w *httptest.ResponseRecorder) int {
+	var resp struct {
+		Code int `json:"code"`
+	}
+	if json.Unmarshal(w.Body.Bytes(), &resp) != nil || resp.Code == 0 {
+		return w.Code
+	}
+	return resp.Code
+}
+
+func TestXFCCIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		wantID  string
+		wantErr bool
+	}{
+		{
+			name:   "istio",
+			header: `By=spiffe://cluster.local/ns/shop/sa/billing;Hash=abc;Subject="";URI=` + ordersID,
+			wantID: ordersID,
+		},
+		{
+			name:   "quoted subject with separators",
+			header: `Hash=abc;Subject="CN=a,O=b;c";URI="` + ordersID + `"`,
+			wantID: ordersID,
+		},
+		{
+			name:   "last element is the direct peer",
+			header: `URI=` + billingID + `,By=x;URI=` + ordersID,
+			wantID: ordersID,
+		},
+		{
+			name:   "spiffe uri preferred",
+			header: `URI=https://example.com;URI=` + ordersID,
+			wantID: ordersID,
+		},
+		{
+			name:    "no uri",
+			header:  `Hash=abc;DNS=orders.shop`,
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			wantErr: true,
+		},
+	}
+
+	extractor := XFCCIdentity()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, source, err := extractor(meshRequest("127.0.0.1:1234", tt.header))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got id %q", id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tt.wantID || source != IdentitySourceHeader {
+				t.Errorf("got %q (%s), want %q", id, source, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMeshAuth_Header(t *testing.T) {
+	h := meshHandler(MeshAuthConfig{})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, meshRequest("127.0.0.1:1234", "URI="+ordersID))
+	if meshCode(w) != http.StatusOK {
+		t.Fatalf("status = %d, body %s", meshCode(w), w.Body.String())
+	}
+	if want := ordersID + "|cluster.local|/ns/shop/sa/orders|header"; w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+
+	// 非回环地址注入的身份 Header 不被信任
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, meshRequest("10.0.0.8:1234", "URI="+ordersID))
+	if meshCode(w) != http.StatusUnauthorized {
+		t.Errorf("untrusted peer: status = %d, want 401", meshCode(w))
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, meshRequest("127.0.0.1:1234", ""))
+	if meshCode(w) != http.StatusUnauthorized {
+		t.Errorf("missing identity: status = %d, want 401", meshCode(w))
+	}
+}
+
+func TestMeshAuth_TrustedProxies(t *testing.T) {
+	h := meshHandler(MeshAuthConfig{
+		Extractor:      HeaderIdentity("l5d-client-id"),
+		TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"},
+	})
+
+	for remote, want := range map[string]int{
+		"10.1.2.3:80":          http.StatusOK,
+		"192.168.1.1:80":       http.StatusOK,
+		"[::ffff:10.0.0.1]:80": http.StatusOK,
+		"192.168.1.2:80":       http.StatusUnauthorized,
+		"127.0.0.1:80":         http.StatusUnauthorized,
+	} {
+		req := meshRequest(remote, "")
+		req.Header.Set("l5d-client-id", "web.default.serviceaccount.identity.linkerd.cluster.local")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if meshCode(w) != want {
+			t.Errorf("%s: status = %d, want %d", remote, meshCode(w), want)
+		}
+	}
+}
+
+func TestMeshAuth_Policy(t *testing.T) {
+	h := meshHandler(MeshAuthConfig{
+		TrustDomains: []string{"cluster.local"},
+		Allow:        []string{"spiffe://cluster.local/ns/shop/**"},
+	})
+
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{ordersID, http.StatusOK},
+		{"spiffe://cluster.local/ns/admin/sa/ops", http.StatusForbidden},
+		{"spiffe://other.domain/ns/shop/sa/orders", http.StatusForbidden},
+		{"orders.shop.svc", http.StatusForbidden},
+		{"spiffe://cluster.local:8080/ns/shop/sa/orders", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, meshRequest("127.0.0.1:1234", "URI="+tt.id))
+		if meshCode(w) != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.id, meshCode(w), tt.want)
+		}
+	}
+}
+
+func TestMeshAuth_PeerCertificate(t *testing.T) {
+	h := meshHandler(MeshAuthConfig{})
+	u, _ := url.Parse(ordersID)
+	cert := &x509.Certificate{URIs: []*url.URL{u}}
+
+	req := meshRequest("10.0.0.8:1234", "")
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if want := ordersID + "|cluster.local|/ns/shop/sa/orders|certificate"; w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+
+	// 未经验证的证书不可信
+	req.TLS.VerifiedChains = nil
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if meshCode(w) != http.StatusUnauthorized {
+		t.Errorf("unverified certificate: status = %d, want 401", meshCode(w))
+	}
+}
+
+func TestMeshAuth_RolesWithPermission(t *testing.T) {
+	mw := MeshAuth(MeshAuthConfig{
+		Roles: map[string][]string{ordersID: {"order-service"}},
+	})
+	perm := Permission(PermissionConfig{
+		Checker: RoleBasedChecker(RoleBasedConfig{AllowedRoles: []string{"order-service"}}),
+	})
+	h := mw(perm(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := GetPrincipal(r.Context())
+		w.Write([]byte(p.ID))
+	})))
+
+	for id, want := range map[string]int{ordersID: http.StatusOK, billingID: http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, meshRequest("127.0.0.1:1234", "URI="+id))
+		if meshCode(w) != want {
+			t.Errorf("%s: status = %d, want %d", id, meshCode(w), want)
+		}
+	}
+}
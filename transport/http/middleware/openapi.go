@@ -0,0 +1,764 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kochabx/kit/errors"
+	"github.com/kochabx/kit/log"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+const defaultOpenAPIMaxBodySize = 1 << 20 // 默认请求体上限 1MiB
+
+var (
+	ErrOpenAPIRouteNotFound     = errors.NotFound("route not defined in api spec")
+	ErrOpenAPIMethodNotAllowed  = errors.MethodNotAllowed("method not allowed by api spec")
+	ErrOpenAPIUnsupportedMedia  = errors.New(http.StatusUnsupportedMediaType, "unsupported content type")
+	ErrOpenAPIValidationFailed  = errors.BadRequest("request validation failed")
+	ErrOpenAPISpecInvalid       = stderrors.New("middleware: invalid openapi spec")
+	errOpenAPIUnsupportedFormat = stderrors.New("only openapi 3.x documents are supported")
+)
+
+// openAPIOperationKey OpenAPIOperation 在 context 中的键
+type openAPIOperationKey struct{}
+
+// OpenAPIOperation 请求匹配到的 OpenAPI 操作
+type OpenAPIOperation struct {
+	ID         string            // operationId，未声明时为空
+	Method     string            // 请求方法
+	Path       string            // 路径模板，如 "/users/{id}"
+	PathParams map[string]string // 路径参数 (已解码)
+}
+
+// label 返回用于指标与日志的操作名
+func (op *OpenAPIOperation) label() string {
+	if op.ID != "" {
+		return op.ID
+	}
+	return op.Method + " " + op.Path
+}
+
+// GetOpenAPIOperation 从 Context 获取当前请求匹配到的 OpenAPI 操作
+func GetOpenAPIOperation(ctx context.Context) (*OpenAPIOperation, bool) {
+	op, ok := ctx.Value(openAPIOperationKey{}).(*OpenAPIOperation)
+	return op, ok
+}
+
+// OpenAPIValidationError 请求不符合 OpenAPI 定义，Issues 为全部失败原因
+type OpenAPIValidationError struct {
+	Operation string
+	Issues    []ValidationIssue
+}
+
+// Error 实现 error 接口
+func (e *OpenAPIValidationError) Error() string {
+	parts := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		parts[i] = issue.In
+		if issue.Field != "" {
+			parts[i] += " " + issue.Field
+		}
+		parts[i] += ": " + issue.Message
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrOpenAPIValidationFailed.Message(), e.Operation, strings.Join(parts, "; "))
+}
+
+// Unwrap 使 errors.Is(err, ErrOpenAPIValidationFailed) 成立
+func (e *OpenAPIValidationError) Unwrap() error { return ErrOpenAPIValidationFailed }
+
+// OpenAPIConfig OpenAPI 请求校验中间件配置
+type OpenAPIConfig struct {
+	Skip SkipConfig   // 跳过配置
+	Spec *OpenAPISpec // 由 LoadOpenAPISpec 加载的文档，必填
+
+	// BasePath 路由前缀，匹配前从请求路径中去除。默认取 servers[0].url 的路径部分
+	BasePath string
+	// ReportOnly 只记录不拦截：校验失败时记录日志并调用 OnViolation，请求照常交给下游，
+	// 便于在启用强制校验前观察存量客户端的违规情况
+	ReportOnly bool
+	// AllowUnknownRoutes 放行文档中未定义的路径与方法，默认返回 404 / 405。
+	// 未声明的 OPTIONS 请求 (CORS 预检) 总是放行
+	AllowUnknownRoutes bool
+	MaxBodySize        int64 // 请求体上限，默认 1MiB
+
+	OnViolation  func(r *http.Request, err error)                // 校验失败时回调 (两种模式均调用)，可用于上报
+	Registerer   prometheus.Registerer                           // 不为 nil 时按操作记录指标
+	ErrorHandler func(http.ResponseWriter, *http.Request, error) // 错误处理函数
+	Logger       *log.Logger                                     // 自定义日志记录器
+}
+
+// openAPIMetrics OpenAPI 校验指标
+type openAPIMetrics struct {
+	requests *prometheus.CounterVec // 请求总数（按操作、结果）
+}
+
+func newOpenAPIMetrics(registerer prometheus.Registerer) *openAPIMetrics {
+	return &openAPIMetrics{
+		requests: promauto.With(registerer).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "openapi",
+				Name:      "requests_total",
+				Help:      "Total number of requests checked against the OpenAPI spec",
+			},
+			[]string{"operation", "outcome"},
+		),
+	}
+}
+
+// OpenAPI 创建按 OpenAPI 3 文档校验请求的中间件，集中保证 API 契约，
+// 不再依赖分散在各 handler 中的手写绑定代码：
+//   - 按路径模板与方法匹配操作，具体路径优先于模板路径
+//   - 校验 path / query / header / cookie 参数 (按 schema 将字符串转换为对应类型)
+//   - 校验请求体的 Content-Type，JSON 与 application/x-www-form-urlencoded 请求体按 schema 校验
+//
+// 校验失败时默认以标准响应结构返回 400，data 为全部失败原因 ([]ValidationIssue)。
+// 请求体会被还原供下游读取，匹配到的操作可通过 GetOpenAPIOperation 获取。
+func OpenAPI(cfg OpenAPIConfig) func(http.Handler) http.Handler {
+	if cfg.Spec == nil {
+		panic("middleware: OpenAPI requires a Spec")
+	}
+	if cfg.BasePath == "" {
+		cfg.BasePath = cfg.Spec.basePath
+	}
+	cfg.BasePath = strings.TrimRight(cfg.BasePath, "/")
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultOpenAPIMaxBodySize
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = defaultOpenAPIErrorHandler
+	}
+
+	var metrics *openAPIMetrics
+	if cfg.Registerer != nil {
+		metrics = newOpenAPIMetrics(cfg.Registerer)
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			op, outcome, err := cfg.check(w, r)
+			if metrics != nil {
+				name := "unknown"
+				if op != nil {
+					name = op.label()
+				}
+				metrics.requests.WithLabelValues(name, outcome).Inc()
+			}
+			if err != nil {
+				if cfg.OnViolation != nil {
+					cfg.OnViolation(r, err)
+				}
+				cfg.Logger.Warn().Err(err).
+					Str("method", r.Method).
+					Str("path", r.URL.Path).
+					Str("outcome", outcome).
+					Bool("report_only", cfg.ReportOnly).
+					Msg("openapi: request violates spec")
+				if !cfg.ReportOnly {
+					cfg.ErrorHandler(w, r, err)
+					return
+				}
+			}
+			if op != nil {
+				r = r.WithContext(context.WithValue(r.Context(), openAPIOperationKey{}, op))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// defaultOpenAPIErrorHandler 校验失败时以标准响应结构返回全部失败原因，其他错误按错误码返回
+func defaultOpenAPIErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *OpenAPIValidationError
+	if stderrors.As(err, &verr) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(&kithttp.Response[[]ValidationIssue]{
+			Code: ErrOpenAPIValidationFailed.Code(),
+			Msg:  ErrOpenAPIValidationFailed.Message(),
+			Data: verr.Issues,
+		})
+		return
+	}
+	if e, ok := errors.From(err); ok {
+		kithttp.Fail(w, e.Code(), err)
+		return
+	}
+	kithttp.Fail(w, http.StatusInternalServerError, err)
+}
+
+// check 匹配操作并校验请求，返回匹配到的操作 (未匹配时为 nil)、结果与失败原因
+func (cfg *OpenAPIConfig) check(w http.ResponseWriter, r *http.Request) (*OpenAPIOperation, string, error) {
+	reqPath := r.URL.EscapedPath()
+	if cfg.BasePath != "" {
+		rest, ok := strings.CutPrefix(reqPath, cfg.BasePath)
+		if !ok || (rest != "" && rest[0] != '/') {
+			return cfg.unknown(r, "not_found", ErrOpenAPIRouteNotFound)
+		}
+		reqPath = rest
+		if reqPath == "" {
+			reqPath = "/"
+		}
+	}
+
+	route, params := cfg.Spec.match(reqPath)
+	if route == nil {
+		return cfg.unknown(r, "not_found", ErrOpenAPIRouteNotFound)
+	}
+	operation, ok := route.operations[r.Method]
+	if !ok && r.Method == http.MethodHead {
+		operation, ok = route.operations[http.MethodGet]
+	}
+	if !ok {
+		return cfg.unknown(r, "method_not_allowed", ErrOpenAPIMethodNotAllowed)
+	}
+
+	op := &OpenAPIOperation{ID: operation.id, Method: r.Method, Path: route.template, PathParams: params}
+	v := &schemaValidator{spec: cfg.Spec}
+	cfg.checkParameters(v, r, operation, params)
+	if operation.body != nil {
+		if err := cfg.checkBody(v, w, r, operation.body); err != nil {
+			if err == ErrOpenAPIUnsupportedMedia {
+				return op, "unsupported_media_type", err
+			}
+			return op, "bad_request", err
+		}
+	}
+	if len(v.issues) > 0 {
+		return op, "invalid", &OpenAPIValidationError{Operation: op.label(), Issues: v.issues}
+	}
+	return op, "ok", nil
+}
+
+// unknown 处理文档中未定义的路径或方法
+func (cfg *OpenAPIConfig) unknown(r *http.Request, outcome string, err error) (*OpenAPIOperation, string, error) {
+	if cfg.AllowUnknownRoutes || r.Method == http.MethodOptions {
+		return nil, "unknown", nil
+	}
+	return nil, outcome, err
+}
+
+// checkParameters 校验 path / query / header / cookie 参数
+func (cfg *OpenAPIConfig) checkParameters(v *schemaValidator, r *http.Request, operation *openAPIOperation, pathParams map[string]string) {
+	query := r.URL.Query()
+	for _, p := range operation.parameters {
+		var raw []string
+		switch p.In {
+		case "path":
+			if val, ok := pathParams[p.Name]; ok {
+				raw = []string{val}
+			}
+		case "query":
+			raw = query[p.Name]
+		case "header":
+			raw = r.Header.Values(p.Name)
+		case "cookie":
+			if c, err := r.Cookie(p.Name); err == nil {
+				raw = []string{c.Value}
+			}
+		default:
+			continue
+		}
+
+		v.in = p.In
+		if len(raw) == 0 {
+			if p.Required || p.In == "path" {
+				v.addf(p.Name, "is required")
+			}
+			continue
+		}
+		if p.Schema == nil {
+			continue
+		}
+		v.validate(p.Schema, cfg.Spec.coerceParameter(p, raw), p.Name)
+	}
+}
+
+// checkBody 校验请求体，请求体被还原供下游读取。Content-Type 不被接受或请求体无法读取时返回错误
+func (cfg *OpenAPIConfig) checkBody(v *schemaValidator, w http.ResponseWriter, r *http.Request, body *openAPIRequestBody) error {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cfg.MaxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if stderrors.As(err, &maxErr) {
+			return ErrBodyTooLarge
+		}
+		return errors.Wrap(err, http.StatusBadRequest, "read request body failed")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	v.in = "body"
+	if len(data) == 0 {
+		if body.Required {
+			v.addf("", "request body is required")
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	media, ok := body.mediaType(mediaType)
+	if !ok {
+		return ErrOpenAPIUnsupportedMedia
+	}
+	if media == nil || media.Schema == nil {
+		return nil
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			v.addf("", "invalid JSON: %v", err)
+			return nil
+		}
+		if dec.More() {
+			v.addf("", "invalid JSON: unexpected data after top-level value")
+			return nil
+		}
+		v.validate(media.Schema, value, "")
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			v.addf("", "invalid form: %v", err)
+			return nil
+		}
+		v.validate(media.Schema, cfg.Spec.coerceForm(media.Schema, form), "")
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// 文档加载
+// ---------------------------------------------------------------------------
+
+// OpenAPISpec 解析后的 OpenAPI 3 文档，只保留请求校验所需的部分，并发安全
+type OpenAPISpec struct {
+	basePath string                    // servers[0].url 的路径部分
+	routes   []*openAPIRoute           // 路径模板
+	schemas  map[string]*openAPISchema // components.schemas
+}
+
+// openAPIRoute 一个路径模板及其下的操作
+type openAPIRoute struct {
+	template   string
+	re         *regexp.Regexp
+	names      []string                     // 路径参数名，按出现顺序
+	literals   int                          // 模板中非参数部分的长度，越大越具体
+	operations map[string]*openAPIOperation // 方法 → 操作
+}
+
+// openAPIOperation 一个操作的参数与请求体定义 ($ref 已解析)
+type openAPIOperation struct {
+	id         string
+	parameters []*openAPIParameter
+	body       *openAPIRequestBody
+}
+
+// openAPIDocument OpenAPI 文档中用于请求校验的部分
+type openAPIDocument struct {
+	OpenAPI string `yaml:"openapi"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths      map[string]*openAPIPathItem `yaml:"paths"`
+	Components struct {
+		Schemas       map[string]*openAPISchema      `yaml:"schemas"`
+		Parameters    map[string]*openAPIParameter   `yaml:"parameters"`
+		RequestBodies map[string]*openAPIRequestBody `yaml:"requestBodies"`
+	} `yaml:"components"`
+}
+
+// openAPIPathItem Path Item Object
+type openAPIPathItem struct {
+	Parameters []*openAPIParameter   `yaml:"parameters"`
+	Get        *openAPIOperationItem `yaml:"get"`
+	Put        *openAPIOperationItem `yaml:"put"`
+	Post       *openAPIOperationItem `yaml:"post"`
+	Delete     *openAPIOperationItem `yaml:"delete"`
+	Options    *openAPIOperationItem `yaml:"options"`
+	Head       *openAPIOperationItem `yaml:"head"`
+	Patch      *openAPIOperationItem `yaml:"patch"`
+	Trace      *openAPIOperationItem `yaml:"trace"`
+}
+
+// openAPIOperationItem Operation Object
+type openAPIOperationItem struct {
+	OperationID string              `yaml:"operationId"`
+	Parameters  []*openAPIParameter `yaml:"parameters"`
+	RequestBody *openAPIRequestBody `yaml:"requestBody"`
+}
+
+// openAPIParameter Parameter Object
+type openAPIParameter struct {
+	Ref      string         `yaml:"$ref"`
+	Name     string         `yaml:"name"`
+	In       string         `yaml:"in"`
+	Required bool           `yaml:"required"`
+	Style    string         `yaml:"style"`
+	Explode  *bool          `yaml:"explode"`
+	Schema   *openAPISchema `yaml:"schema"`
+}
+
+// openAPIRequestBody Request Body Object
+type openAPIRequestBody struct {
+	Ref      string                       `yaml:"$ref"`
+	Required bool                         `yaml:"required"`
+	Content  map[string]*openAPIMediaType `yaml:"content"`
+}
+
+// openAPIMediaType Media Type Object
+type openAPIMediaType struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+// mediaType 按 Content-Type 查找媒体类型定义，依次尝试精确匹配、"type/*" 与 "*/*"
+func (b *openAPIRequestBody) mediaType(mediaType string) (*openAPIMediaType, bool) {
+	if len(b.Content) == 0 {
+		return nil, true
+	}
+	if m, ok := b.Content[mediaType]; ok {
+		return m, true
+	}
+	if typ, _, ok := strings.Cut(mediaType, "/"); ok {
+		if m, ok := b.Content[typ+"/*"]; ok {
+			return m, true
+		}
+	}
+	m, ok := b.Content["*/*"]
+	return m, ok
+}
+
+// LoadOpenAPISpec 解析 OpenAPI 3.0 / 3.1 文档 (JSON 或 YAML)。
+// 只支持文档内的 $ref (#/components/...)，引用不存在或 pattern 无法编译时返回错误
+func LoadOpenAPISpec(data []byte) (*OpenAPISpec, error) {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOpenAPISpecInvalid, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: %v", ErrOpenAPISpecInvalid, errOpenAPIUnsupportedFormat)
+	}
+
+	spec := &OpenAPISpec{schemas: doc.Components.Schemas}
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			spec.basePath = strings.TrimRight(u.Path, "/")
+		}
+	}
+
+	l := &specLoader{doc: &doc, spec: spec, visited: make(map[*openAPISchema]bool)}
+	for template, item := range doc.Paths {
+		if item == nil {
+			continue
+		}
+		route, err := compileRoute(template)
+		if err != nil {
+			return nil, err
+		}
+		for method, opItem := range item.operations() {
+			op, err := l.operation(template, item.Parameters, opItem)
+			if err != nil {
+				return nil, err
+			}
+			route.operations[method] = op
+		}
+		spec.routes = append(spec.routes, route)
+	}
+	for name, s := range doc.Components.Schemas {
+		if err := l.schema(s, "#/components/schemas/"+name); err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
+
+// operations 返回路径下声明的操作
+func (item *openAPIPathItem) operations() map[string]*openAPIOperationItem {
+	ops := make(map[string]*openAPIOperationItem)
+	for method, op := range map[string]*openAPIOperationItem{
+		http.MethodGet: item.Get, http.MethodPut: item.Put, http.MethodPost: item.Post,
+		http.MethodDelete: item.Delete, http.MethodOptions: item.Options, http.MethodHead: item.Head,
+		http.MethodPatch: item.Patch, http.MethodTrace: item.Trace,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// compileRoute 将路径模板编译为正则，如 "/users/{id}" → ^/users/([^/]+)$
+func compileRoute(template string) (*openAPIRoute, error) {
+	route := &openAPIRoute{template: template, operations: make(map[string]*openAPIOperation)}
+	var b strings.Builder
+	b.WriteByte('^')
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated parameter in path %q", ErrOpenAPISpecInvalid, template)
+		}
+		b.WriteString(regexp.QuoteMeta(rest[:start]))
+		b.WriteString("([^/]+)")
+		route.literals += start
+		route.names = append(route.names, rest[start+1:start+end])
+		rest = rest[start+end+1:]
+	}
+	b.WriteString(regexp.QuoteMeta(rest))
+	b.WriteByte('$')
+	route.literals += len(rest)
+	route.re = regexp.MustCompile(b.String())
+	return route, nil
+}
+
+// match 返回与路径匹配的最具体的路由及解码后的路径参数
+func (s *OpenAPISpec) match(reqPath string) (*openAPIRoute, map[string]string) {
+	var best *openAPIRoute
+	var bestValues []string
+	for _, route := range s.routes {
+		values := route.re.FindStringSubmatch(reqPath)
+		if values == nil {
+			continue
+		}
+		if best == nil || route.literals > best.literals ||
+			(route.literals == best.literals && len(route.names) < len(best.names)) {
+			best, bestValues = route, values[1:]
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	params := make(map[string]string, len(best.names))
+	for i, name := range best.names {
+		val, err := url.PathUnescape(bestValues[i])
+		if err != nil {
+			val = bestValues[i]
+		}
+		params[name] = val
+	}
+	return best, params
+}
+
+// resolveSchema 解析 schema 的 $ref
+func (s *OpenAPISpec) resolveSchema(schema *openAPISchema) *openAPISchema {
+	for i := 0; schema != nil && schema.Ref != "" && i < maxSchemaDepth; i++ {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		if !ok {
+			return nil
+		}
+		schema = s.schemas[name]
+	}
+	return schema
+}
+
+// coerceParameter 按 schema 将参数的字符串值转换为 JSON 值
+func (s *OpenAPISpec) coerceParameter(p *openAPIParameter, raw []string) any {
+	schema := s.resolveSchema(p.Schema)
+	if schema == nil || !schema.hasType("array") {
+		return s.coerceScalar(schema, raw[0])
+	}
+
+	values := raw
+	explode := p.Explode == nil || *p.Explode
+	if p.In != "query" || (p.Style != "" && p.Style != "form") {
+		explode = false
+	}
+	if !explode {
+		sep := ","
+		switch p.Style {
+		case "spaceDelimited":
+			sep = " "
+		case "pipeDelimited":
+			sep = "|"
+		}
+		values = nil
+		for _, r := range raw {
+			values = append(values, strings.Split(r, sep)...)
+		}
+	}
+	items := s.resolveSchema(schema.Items)
+	out := make([]any, len(values))
+	for i, val := range values {
+		out[i] = s.coerceScalar(items, strings.TrimSpace(val))
+	}
+	return out
+}
+
+// coerceForm 按对象 schema 将表单转换为 JSON 对象
+func (s *OpenAPISpec) coerceForm(schema *openAPISchema, form url.Values) map[string]any {
+	schema = s.resolveSchema(schema)
+	out := make(map[string]any, len(form))
+	for name, raw := range form {
+		var prop *openAPISchema
+		if schema != nil {
+			prop = s.resolveSchema(schema.Properties[name])
+		}
+		if prop != nil && prop.hasType("array") {
+			items := s.resolveSchema(prop.Items)
+			values := make([]any, len(raw))
+			for i, val := range raw {
+				values[i] = s.coerceScalar(items, val)
+			}
+			out[name] = values
+			continue
+		}
+		out[name] = s.coerceScalar(prop, raw[0])
+	}
+	return out
+}
+
+// coerceScalar 按 schema 类型转换单个字符串值，无法转换时保留字符串，由类型校验报告
+func (s *OpenAPISpec) coerceScalar(schema *openAPISchema, raw string) any {
+	if schema == nil {
+		return raw
+	}
+	switch {
+	case schema.hasType("integer") || schema.hasType("number"):
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case schema.hasType("boolean"):
+		if b, err := strconv.ParseBool(raw); err == nil && (raw == "true" || raw == "false") {
+			return b
+		}
+	}
+	return raw
+}
+
+// specLoader 解析 $ref 并预处理 schema
+type specLoader struct {
+	doc     *openAPIDocument
+	spec    *OpenAPISpec
+	visited map[*openAPISchema]bool
+}
+
+// operation 合并路径级与操作级参数 (操作级覆盖同名同位置参数) 并解析引用
+func (l *specLoader) operation(template string, shared []*openAPIParameter, item *openAPIOperationItem) (*openAPIOperation, error) {
+	op := &openAPIOperation{id: item.OperationID}
+	index := make(map[string]int)
+	for _, list := range [][]*openAPIParameter{shared, item.Parameters} {
+		for _, p := range list {
+			p, err := l.parameter(p)
+			if err != nil {
+				return nil, err
+			}
+			key := p.In + ":" + p.Name
+			if p.In == "header" {
+				key = p.In + ":" + http.CanonicalHeaderKey(p.Name)
+			}
+			if i, ok := index[key]; ok {
+				op.parameters[i] = p
+				continue
+			}
+			index[key] = len(op.parameters)
+			op.parameters = append(op.parameters, p)
+		}
+	}
+
+	if body := item.RequestBody; body != nil {
+		if body.Ref != "" {
+			name, ok := strings.CutPrefix(body.Ref, "#/components/requestBodies/")
+			if !ok || l.doc.Components.RequestBodies[name] == nil {
+				return nil, fmt.Errorf("%w: unresolved $ref %q in %s", ErrOpenAPISpecInvalid, body.Ref, template)
+			}
+			body = l.doc.Components.RequestBodies[name]
+		}
+		for mediaType, m := range body.Content {
+			if m == nil {
+				continue
+			}
+			if err := l.schema(m.Schema, template+" "+mediaType); err != nil {
+				return nil, err
+			}
+		}
+		op.body = body
+	}
+	return op, nil
+}
+
+// parameter 解析参数引用并预处理其 schema
+func (l *specLoader) parameter(p *openAPIParameter) (*openAPIParameter, error) {
+	if p == nil {
+		return nil, fmt.Errorf("%w: empty parameter", ErrOpenAPISpecInvalid)
+	}
+	if p.Ref != "" {
+		name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+		resolved := l.doc.Components.Parameters[name]
+		if !ok || resolved == nil {
+			return nil, fmt.Errorf("%w: unresolved $ref %q", ErrOpenAPISpecInvalid, p.Ref)
+		}
+		p = resolved
+	}
+	if err := l.schema(p.Schema, "parameter "+p.Name); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// schema 检查引用是否存在并编译 pattern，where 用于错误信息
+func (l *specLoader) schema(s *openAPISchema, where string) error {
+	if s == nil || l.visited[s] {
+		return nil
+	}
+	l.visited[s] = true
+
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok || l.spec.schemas[name] == nil {
+			return fmt.Errorf("%w: unresolved $ref %q in %s", ErrOpenAPISpecInvalid, s.Ref, where)
+		}
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%w: invalid pattern %q in %s: %v", ErrOpenAPISpecInvalid, s.Pattern, where, err)
+		}
+		s.pattern = re
+	}
+
+	children := []*openAPISchema{s.Items, s.Not}
+	children = append(children, s.AllOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.OneOf...)
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.schema)
+	}
+	for _, child := range children {
+		if err := l.schema(child, where); err != nil {
+			return err
+		}
+	}
+	return nil
+}
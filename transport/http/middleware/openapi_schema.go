@@ -0,0 +1,415 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxSchemaDepth 校验时 schema 的最大嵌套层数，防止自引用的 allOf / $ref 无限递归
+const maxSchemaDepth = 64
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// openAPISchema OpenAPI 3.0 / 3.1 Schema Object 中用于请求校验的子集
+type openAPISchema struct {
+	Ref                  string                    `yaml:"$ref"`
+	Type                 schemaTypes               `yaml:"type"`
+	Nullable             bool                      `yaml:"nullable"`
+	Format               string                    `yaml:"format"`
+	Enum                 []any                     `yaml:"enum"`
+	Pattern              string                    `yaml:"pattern"`
+	MinLength            *int                      `yaml:"minLength"`
+	MaxLength            *int                      `yaml:"maxLength"`
+	Minimum              *float64                  `yaml:"minimum"`
+	Maximum              *float64                  `yaml:"maximum"`
+	ExclusiveMinimum     exclusiveBound            `yaml:"exclusiveMinimum"`
+	ExclusiveMaximum     exclusiveBound            `yaml:"exclusiveMaximum"`
+	MultipleOf           *float64                  `yaml:"multipleOf"`
+	Items                *openAPISchema            `yaml:"items"`
+	MinItems             *int                      `yaml:"minItems"`
+	MaxItems             *int                      `yaml:"maxItems"`
+	UniqueItems          bool                      `yaml:"uniqueItems"`
+	Properties           map[string]*openAPISchema `yaml:"properties"`
+	Required             []string                  `yaml:"required"`
+	AdditionalProperties *additionalProperties     `yaml:"additionalProperties"`
+	MinProperties        *int                      `yaml:"minProperties"`
+	MaxProperties        *int                      `yaml:"maxProperties"`
+	AllOf                []*openAPISchema          `yaml:"allOf"`
+	AnyOf                []*openAPISchema          `yaml:"anyOf"`
+	OneOf                []*openAPISchema          `yaml:"oneOf"`
+	Not                  *openAPISchema            `yaml:"not"`
+	ReadOnly             bool                      `yaml:"readOnly"`
+
+	pattern *regexp.Regexp // 加载时编译的 Pattern
+}
+
+// schemaTypes type 关键字，兼容 3.0 的字符串与 3.1 的数组写法
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaTypes{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// exclusiveBound exclusiveMinimum / exclusiveMaximum，兼容 3.0 的布尔值 (修饰 minimum / maximum)
+// 与 3.1 的数值写法
+type exclusiveBound struct {
+	flag  bool     // 3.0：minimum / maximum 不含边界
+	value *float64 // 3.1：不含边界的界限值
+}
+
+func (b *exclusiveBound) UnmarshalYAML(node *yaml.Node) error {
+	if node.Tag == "!!bool" {
+		return node.Decode(&b.flag)
+	}
+	return node.Decode(&b.value)
+}
+
+// additionalProperties 布尔值或 schema
+type additionalProperties struct {
+	allowed bool
+	schema  *openAPISchema
+}
+
+func (a *additionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Tag == "!!bool" {
+		return node.Decode(&a.allowed)
+	}
+	a.allowed = true
+	return node.Decode(&a.schema)
+}
+
+// hasType schema 是否声明了类型 t
+func (s *openAPISchema) hasType(t string) bool {
+	return slices.Contains(s.Type, t)
+}
+
+// ValidationIssue 一条请求校验失败原因
+type ValidationIssue struct {
+	In      string `json:"in"`              // path / query / header / cookie / body
+	Field   string `json:"field,omitempty"` // 参数名，请求体中为 JSON Pointer (根为空)
+	Message string `json:"message"`
+}
+
+// schemaValidator 按 schema 校验值并收集问题
+type schemaValidator struct {
+	spec   *OpenAPISpec
+	in     string
+	issues []ValidationIssue
+	depth  int
+}
+
+func (v *schemaValidator) addf(field, format string, args ...any) {
+	v.issues = append(v.issues, ValidationIssue{In: v.in, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid 在不记录问题的情况下判断值是否满足 schema，用于 anyOf / oneOf / not
+func (v *schemaValidator) valid(s *openAPISchema, value any, field string) bool {
+	sub := &schemaValidator{spec: v.spec, in: v.in, depth: v.depth}
+	sub.validate(s, value, field)
+	return len(sub.issues) == 0
+}
+
+// validate 按 schema 校验 value，value 为 JSON 解码结果 (数字为 json.Number)
+func (v *schemaValidator) validate(s *openAPISchema, value any, field string) {
+	s = v.spec.resolveSchema(s)
+	if s == nil {
+		return
+	}
+	if v.depth >= maxSchemaDepth {
+		v.addf(field, "schema nesting too deep")
+		return
+	}
+	v.depth++
+	defer func() { v.depth-- }()
+
+	for _, sub := range s.AllOf {
+		v.validate(sub, value, field)
+	}
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(sub *openAPISchema) bool { return v.valid(sub, value, field) }) {
+		v.addf(field, "must match at least one of the allowed schemas")
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if v.valid(sub, value, field) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.addf(field, "must match exactly one of the allowed schemas, matched %d", matched)
+		}
+	}
+	if s.Not != nil && v.valid(s.Not, value, field) {
+		v.addf(field, "must not match the disallowed schema")
+	}
+
+	if value == nil {
+		if len(s.Type) > 0 && !s.Nullable && !s.hasType("null") {
+			v.addf(field, "must not be null")
+		}
+		return
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return matchesType(t, value) }) {
+		v.addf(field, "must be of type %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, value) }) {
+		v.addf(field, "must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch val := value.(type) {
+	case string:
+		v.validateString(s, val, field)
+	case json.Number:
+		v.validateNumber(s, val, field)
+	case []any:
+		v.validateArray(s, val, field)
+	case map[string]any:
+		v.validateObject(s, val, field)
+	}
+}
+
+func (v *schemaValidator) validateString(s *openAPISchema, val, field string) {
+	n := utf8.RuneCountInString(val)
+	if s.MinLength != nil && n < *s.MinLength {
+		v.addf(field, "must be at least %d characters", *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		v.addf(field, "must be at most %d characters", *s.MaxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(val) {
+		v.addf(field, "must match pattern %s", s.Pattern)
+	}
+	if !validFormat(s.Format, val) {
+		v.addf(field, "must be a valid %s", s.Format)
+	}
+}
+
+func (v *schemaValidator) validateNumber(s *openAPISchema, val json.Number, field string) {
+	f, err := val.Float64()
+	if err != nil {
+		v.addf(field, "must be a number")
+		return
+	}
+	if s.Minimum != nil {
+		if s.ExclusiveMinimum.flag && f <= *s.Minimum {
+			v.addf(field, "must be greater than %v", *s.Minimum)
+		} else if f < *s.Minimum {
+			v.addf(field, "must be at least %v", *s.Minimum)
+		}
+	}
+	if s.Maximum != nil {
+		if s.ExclusiveMaximum.flag && f >= *s.Maximum {
+			v.addf(field, "must be less than %v", *s.Maximum)
+		} else if f > *s.Maximum {
+			v.addf(field, "must be at most %v", *s.Maximum)
+		}
+	}
+	if b := s.ExclusiveMinimum.value; b != nil && f <= *b {
+		v.addf(field, "must be greater than %v", *b)
+	}
+	if b := s.ExclusiveMaximum.value; b != nil && f >= *b {
+		v.addf(field, "must be less than %v", *b)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		if q := f / *s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.addf(field, "must be a multiple of %v", *s.MultipleOf)
+		}
+	}
+	if s.Format == "int32" && (f < math.MinInt32 || f > math.MaxInt32) {
+		v.addf(field, "must be a valid int32")
+	}
+}
+
+func (v *schemaValidator) validateArray(s *openAPISchema, val []any, field string) {
+	if s.MinItems != nil && len(val) < *s.MinItems {
+		v.addf(field, "must contain at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(val) > *s.MaxItems {
+		v.addf(field, "must contain at most %d items", *s.MaxItems)
+	}
+	if s.UniqueItems {
+		for i := 1; i < len(val); i++ {
+			if slices.ContainsFunc(val[:i], func(prev any) bool { return jsonEqual(prev, val[i]) }) {
+				v.addf(field, "must not contain duplicate items")
+				break
+			}
+		}
+	}
+	if s.Items != nil {
+		for i, item := range val {
+			v.validate(s.Items, item, field+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s *openAPISchema, val map[string]any, field string) {
+	if s.MinProperties != nil && len(val) < *s.MinProperties {
+		v.addf(field, "must contain at least %d properties", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && len(val) > *s.MaxProperties {
+		v.addf(field, "must contain at most %d properties", *s.MaxProperties)
+	}
+	for _, name := range s.Required {
+		if _, ok := val[name]; ok {
+			continue
+		}
+		// 只读属性由服务端生成，请求中不要求
+		if prop := v.spec.resolveSchema(s.Properties[name]); prop != nil && prop.ReadOnly {
+			continue
+		}
+		v.addf(field+"/"+escapePointer(name), "is required")
+	}
+
+	// 按属性名排序，使问题顺序稳定
+	names := make([]string, 0, len(val))
+	for name := range val {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		child := field + "/" + escapePointer(name)
+		if prop, ok := s.Properties[name]; ok {
+			v.validate(prop, val[name], child)
+			continue
+		}
+		switch ap := s.AdditionalProperties; {
+		case ap == nil:
+		case !ap.allowed:
+			v.addf(child, "is not allowed")
+		case ap.schema != nil:
+			v.validate(ap.schema, val[name], child)
+		}
+	}
+}
+
+// matchesType 值是否符合 JSON Schema 类型
+func matchesType(t string, value any) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := n.Int64(); err == nil {
+			return true
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f) && !math.IsInf(f, 0)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// validFormat 校验常见的字符串格式，未知格式视为通过
+func validFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "uuid":
+		return uuidPattern.MatchString(s)
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	}
+	return true
+}
+
+// jsonEqual 按 JSON 语义比较两个值，数字按数值比较
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+// normalizeJSON 将 YAML / JSON 解码结果中的数字统一为 float64
+func normalizeJSON(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = normalizeJSON(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = normalizeJSON(item)
+		}
+		return out
+	}
+	return v
+}
+
+func formatEnum(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		parts[i] = string(data)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// escapePointer 转义 JSON Pointer 中的 "~" 与 "/"
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,348 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ============================================================================
+// OpenAPI 请求校验中间件测试
+// ============================================================================
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer, minimum: 1, maximum: 100}
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items: {type: string, enum: [a, b, c]}
+        - $ref: '#/components/parameters/TraceID'
+    post:
+      operationId: createUser
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/NewUser'}
+          application/x-www-form-urlencoded:
+            schema: {$ref: '#/components/schemas/NewUser'}
+  /users/me:
+    get:
+      operationId: getMe
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema: {type: integer, format: int32}
+    get:
+      operationId: getUser
+    delete:
+      operationId: deleteUser
+components:
+  parameters:
+    TraceID:
+      name: X-Trace-Id
+      in: header
+      schema: {type: string, format: uuid}
+  schemas:
+    NewUser:
+      type: object
+      required: [id, name, email]
+      additionalProperties: false
+      properties:
+        id: {type: integer, readOnly: true}
+        name: {type: string, minLength: 2, pattern: '^[a-z]+$'}
+        email: {type: string, format: email}
+        age: {type: integer, minimum: 0, exclusiveMinimum: true}
+        role:
+          oneOf:
+            - {type: string, enum: [admin]}
+            - {type: string, enum: [member]}
+`
+
+func mustLoadOpenAPISpec(t *testing.T) *OpenAPISpec {
+	t.Helper()
+	spec, err := LoadOpenAPISpec([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	return spec
+}
+
+func serveOpenAPI(handler http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// decodeIssues 解析标准响应结构中的校验失败原因
+func decodeIssues(t *testing.T, w *httptest.ResponseRecorder) (int, []ValidationIssue) {
+	t.Helper()
+	var resp struct {
+		Code int               `json:"code"`
+		Data []ValidationIssue `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return resp.Code, resp.Data
+}
+
+func hasIssue(issues []ValidationIssue, in, field string) bool {
+	for _, issue := range issues {
+		if issue.In == in && issue.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoadOpenAPISpec_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"swagger 2": `swagger: "2.0"`,
+		"bad ref": `
+openapi: 3.1.0
+paths:
+  /a:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Missing'}
+`,
+		"bad pattern": `
+openapi: 3.1.0
+components:
+  schemas:
+    A: {type: string, pattern: '('}
+`,
+	}
+	for name, doc := range cases {
+		if _, err := LoadOpenAPISpec([]byte(doc)); !errors.Is(err, ErrOpenAPISpecInvalid) {
+			t.Errorf("%s: err = %v, want ErrOpenAPISpecInvalid", name, err)
+		}
+	}
+}
+
+func TestOpenAPI_Routing(t *testing.T) {
+	var got *OpenAPIOperation
+	handler := OpenAPI(OpenAPIConfig{Spec: mustLoadOpenAPISpec(t)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetOpenAPIOperation(r.Context())
+		okHandler(w, r)
+	}))
+
+	// 具体路径优先于模板路径
+	w := serveOpenAPI(handler, http.MethodGet, "/v1/users/me", "", "")
+	if !containsString(w.Body.String(), `"ok":true`) || got == nil || got.ID != "getMe" {
+		t.Fatalf("/users/me should match getMe, got %+v: %s", got, w.Body.String())
+	}
+
+	w = serveOpenAPI(handler, http.MethodGet, "/v1/users/42", "", "")
+	if got == nil || got.ID != "getUser" || got.Path != "/users/{id}" || got.PathParams["id"] != "42" {
+		t.Fatalf("unexpected operation %+v: %s", got, w.Body.String())
+	}
+
+	// HEAD 使用 GET 的定义
+	got = nil
+	serveOpenAPI(handler, http.MethodHead, "/v1/users/42", "", "")
+	if got == nil || got.ID != "getUser" {
+		t.Errorf("HEAD should fall back to GET, got %+v", got)
+	}
+
+	w = serveOpenAPI(handler, http.MethodGet, "/v1/orders", "", "")
+	if code, _ := decodeIssues(t, w); code != http.StatusNotFound {
+		t.Errorf("unknown path code = %d, want 404", code)
+	}
+	w = serveOpenAPI(handler, http.MethodPut, "/v1/users/42", "", "")
+	if code, _ := decodeIssues(t, w); code != http.StatusMethodNotAllowed {
+		t.Errorf("undeclared method code = %d, want 405", code)
+	}
+	// 未声明的 OPTIONS (CORS 预检) 放行
+	w = serveOpenAPI(handler, http.MethodOptions, "/v1/users/42", "", "")
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Errorf("OPTIONS should pass through, got %s", w.Body.String())
+	}
+
+	lenient := OpenAPI(OpenAPIConfig{Spec: mustLoadOpenAPISpec(t), AllowUnknownRoutes: true})(okHandler)
+	w = serveOpenAPI(lenient, http.MethodGet, "/v1/orders", "", "")
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Errorf("AllowUnknownRoutes should pass unknown path, got %s", w.Body.String())
+	}
+}
+
+func TestOpenAPI_Parameters(t *testing.T) {
+	handler := OpenAPI(OpenAPIConfig{Spec: mustLoadOpenAPISpec(t)})(okHandler)
+
+	w := serveOpenAPI(handler, http.MethodGet, "/v1/users?limit=10&tags=a&tags=b", "", "")
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Fatalf("valid query should pass, got %s", w.Body.String())
+	}
+
+	w = serveOpenAPI(handler, http.MethodGet, "/v1/users?limit=0&tags=a&tags=x", "", "")
+	code, issues := decodeIssues(t, w)
+	if code != http.StatusBadRequest {
+		t.Fatalf("code = %d, want 400", code)
+	}
+	if !hasIssue(issues, "query", "limit") || !hasIssue(issues, "query", "tags/1") {
+		t.Errorf("unexpected issues %+v", issues)
+	}
+
+	w = serveOpenAPI(handler, http.MethodGet, "/v1/users?limit=abc", "", "")
+	if _, issues := decodeIssues(t, w); !hasIssue(issues, "query", "limit") {
+		t.Errorf("non-numeric limit should fail, got %+v", issues)
+	}
+
+	// 路径参数类型与 int32 范围
+	w = serveOpenAPI(handler, http.MethodDelete, "/v1/users/99999999999", "", "")
+	if _, issues := decodeIssues(t, w); !hasIssue(issues, "path", "id") {
+		t.Errorf("out of range id should fail, got %+v", issues)
+	}
+
+	// $ref 引用的 header 参数
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set("X-Trace-Id", "not-a-uuid")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if _, issues := decodeIssues(t, w); !hasIssue(issues, "header", "X-Trace-Id") {
+		t.Errorf("invalid header should fail, got %+v", issues)
+	}
+}
+
+func TestOpenAPI_Body(t *testing.T) {
+	var body string
+	handler := OpenAPI(OpenAPIConfig{Spec: mustLoadOpenAPISpec(t)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		okHandler(w, r)
+	}))
+
+	// 只读属性 id 不要求出现在请求中，请求体被还原供下游读取
+	valid := `{"name":"alice","email":"alice@example.com","age":1,"role":"admin"}`
+	w := serveOpenAPI(handler, http.MethodPost, "/v1/users", "application/json; charset=utf-8", valid)
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Fatalf("valid body should pass, got %s", w.Body.String())
+	}
+	if body != valid {
+		t.Errorf("downstream body = %q", body)
+	}
+
+	w = serveOpenAPI(handler, http.MethodPost, "/v1/users", "application/json",
+		`{"name":"A","email":"bad","age":0,"role":"guest","extra":true}`)
+	code, issues := decodeIssues(t, w)
+	if code != http.StatusBadRequest {
+		t.Fatalf("code = %d, want 400", code)
+	}
+	for _, field := range []string{"/name", "/email", "/age", "/role", "/extra"} {
+		if !hasIssue(issues, "body", field) {
+			t.Errorf("missing issue for %s in %+v", field, issues)
+		}
+	}
+
+	w = serveOpenAPI(handler, http.MethodPost, "/v1/users", "application/json", `{"name":"alice"}`)
+	if _, issues := decodeIssues(t, w); !hasIssue(issues, "body", "/email") || hasIssue(issues, "body", "/id") {
+		t.Errorf("unexpected required issues %+v", issues)
+	}
+
+	w = serveOpenAPI(handler, http.MethodPost, "/v1/users", "application/json", "")
+	if _, issues := decodeIssues(t, w); !hasIssue(issues, "body", "") {
+		t.Errorf("missing body should fail, got %+v", issues)
+	}
+
+	w = serveOpenAPI(handler, http.MethodPost, "/v1/users", "application/json", `{"name":"alice"} {}`)
+	if _, issues := decodeIssues(t, w); !hasIssue(issues, "body", "") {
+		t.Errorf("trailing data should fail, got %+v", issues)
+	}
+
+	// 表单按属性 schema 转换类型
+	w = serveOpenAPI(handler, http.MethodPost, "/v1/users", "application/x-www-form-urlencoded",
+		"name=bob&email=bob@example.com&age=3")
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Errorf("valid form should pass, got %s", w.Body.String())
+	}
+
+	w = serveOpenAPI(handler, http.MethodPost, "/v1/users", "text/plain", "hello")
+	if code, _ := decodeIssues(t, w); code != http.StatusUnsupportedMediaType {
+		t.Errorf("unsupported content type code = %d, want 415", code)
+	}
+
+	small := OpenAPI(OpenAPIConfig{Spec: mustLoadOpenAPISpec(t), MaxBodySize: 8})(okHandler)
+	w = serveOpenAPI(small, http.MethodPost, "/v1/users", "application/json", valid)
+	if code, _ := decodeIssues(t, w); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body code = %d, want 413", code)
+	}
+}
+
+func TestOpenAPI_ReportOnly(t *testing.T) {
+	var violations []error
+	reg := prometheus.NewRegistry()
+	handler := OpenAPI(OpenAPIConfig{
+		Spec:       mustLoadOpenAPISpec(t),
+		ReportOnly: true,
+		Registerer: reg,
+		OnViolation: func(r *http.Request, err error) {
+			violations = append(violations, err)
+		},
+	})(okHandler)
+
+	// 校验失败仍交给下游处理
+	w := serveOpenAPI(handler, http.MethodGet, "/v1/users?limit=1000", "", "")
+	if !containsString(w.Body.String(), `"ok":true`) {
+		t.Fatalf("report-only should pass through, got %s", w.Body.String())
+	}
+	if len(violations) != 1 {
+		t.Fatalf("violations = %d, want 1", len(violations))
+	}
+	var verr *OpenAPIValidationError
+	if !errors.As(violations[0], &verr) || verr.Operation != "listUsers" || !errors.Is(verr, ErrOpenAPIValidationFailed) {
+		t.Errorf("unexpected violation %v", violations[0])
+	}
+
+	serveOpenAPI(handler, http.MethodGet, "/v1/users?limit=1", "", "")
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	counts := make(map[string]float64)
+	for _, f := range families {
+		if f.GetName() != "openapi_requests_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			var op, outcome string
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "operation":
+					op = l.GetValue()
+				case "outcome":
+					outcome = l.GetValue()
+				}
+			}
+			counts[op+"/"+outcome] = m.GetCounter().GetValue()
+		}
+	}
+	if counts["listUsers/invalid"] != 1 || counts["listUsers/ok"] != 1 {
+		t.Errorf("operation counts = %v", counts)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"slices"
+	"strings"
 
 	"github.com/kochabx/kit/errors"
 	"github.com/kochabx/kit/log"
@@ -183,6 +184,93 @@ func OwnerBasedChecker(cfg OwnerBasedConfig) PermissionChecker {
 	})
 }
 
+// RequireRoles 创建要求任一角色的路由级中间件，注册在 Auth 之后：
+//
+//	r.With(middleware.RequireRoles("admin")).Delete("/users/{id}", deleteUser)
+//
+// claims 取自 Auth 的默认上下文键，角色取自 claims 的 GetRoles()；
+// 需自定义 claims key 或角色提取方式时使用 Permission 与 RoleBasedChecker。
+func RequireRoles(roles ...string) func(http.Handler) http.Handler {
+	return RequirePermission(RoleBasedChecker(RoleBasedConfig{AllowedRoles: roles}))
+}
+
+// RequirePermission 创建以 checker 检查权限的路由级中间件，
+// 等价于 Permission(PermissionConfig{Checker: checker})，checker 为 nil 时 panic。
+func RequirePermission(checker PermissionChecker) func(http.Handler) http.Handler {
+	if checker == nil {
+		panic("middleware: RequirePermission requires a PermissionChecker")
+	}
+	return Permission(PermissionConfig{Checker: checker})
+}
+
+// PermissionResolver 权限解析器接口，返回请求主体拥有的权限，如按用户或角色从数据库、缓存加载
+type PermissionResolver interface {
+	Resolve(ctx context.Context, claims any) ([]string, error)
+}
+
+// PermissionResolverFunc 权限解析器函数适配器
+type PermissionResolverFunc func(ctx context.Context, claims any) ([]string, error)
+
+func (f PermissionResolverFunc) Resolve(ctx context.Context, claims any) ([]string, error) {
+	return f(ctx, claims)
+}
+
+// RolePermissions 返回按角色映射权限的解析器，角色取自 claims 的 GetRoles()
+func RolePermissions(m map[string][]string) PermissionResolver {
+	return PermissionResolverFunc(func(ctx context.Context, claims any) ([]string, error) {
+		rv, ok := claims.(interface{ GetRoles() []string })
+		if !ok {
+			return nil, nil
+		}
+		var perms []string
+		for _, role := range rv.GetRoles() {
+			perms = append(perms, m[role]...)
+		}
+		return perms, nil
+	})
+}
+
+// HasPermissions 创建要求拥有全部 perms 的权限检查器，权限由 resolver 解析。
+// 拥有的权限支持通配："*" 匹配所有权限，"order:*" 匹配 "order:" 开头的权限。
+//
+//	resolver := middleware.RolePermissions(map[string][]string{
+//		"admin":  {"*"},
+//		"editor": {"article:*"},
+//	})
+//	r.With(middleware.RequirePermission(middleware.HasPermissions(resolver, "article:publish"))).Post("/articles/{id}/publish", publish)
+func HasPermissions(resolver PermissionResolver, perms ...string) PermissionChecker {
+	if resolver == nil {
+		panic("middleware: HasPermissions requires a PermissionResolver")
+	}
+	return PermissionCheckerFunc(func(ctx context.Context, r *http.Request) error {
+		claims := ctx.Value(contextKey)
+		if claims == nil {
+			return ErrUnauthorized
+		}
+
+		granted, err := resolver.Resolve(ctx, claims)
+		if err != nil {
+			return err
+		}
+
+		for _, perm := range perms {
+			if !slices.ContainsFunc(granted, func(g string) bool { return grantsPermission(g, perm) }) {
+				return ErrForbidden
+			}
+		}
+		return nil
+	})
+}
+
+// grantsPermission 检查拥有的权限 granted 是否覆盖 want
+func grantsPermission(granted, want string) bool {
+	if granted == "*" || granted == want {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, "*")
+	return ok && strings.HasSuffix(prefix, ":") && strings.HasPrefix(want, prefix)
+}
+
 // hasIntersection 检查两个切片是否有交集
 func hasIntersection[T comparable](a, b []T) bool {
 	for _, item := range b {
@@ -277,3 +277,94 @@ func TestPermission_Gin_Deny(t *testing.T) {
 		t.Errorf("[Gin] should be forbidden, got: %s", w.Body.String())
 	}
 }
+
+// ============================================================================
+// RequireRoles / RequirePermission / HasPermissions 测试
+// ============================================================================
+
+func TestRequireRoles(t *testing.T) {
+	mw := RequireRoles("admin", "ops")
+
+	for _, tt := range []struct {
+		name   string
+		claims *permClaims
+		want   string
+	}{
+		{"match", &permClaims{roles: []string{"viewer", "ops"}}, `"ok":true`},
+		{"no match", &permClaims{roles: []string{"viewer"}}, `"code":403`},
+		{"no claims", nil, `"code":401`},
+	} {
+		h := mw(okHandler)
+		if tt.claims != nil {
+			h = withContextValue(contextKey, tt.claims)(h)
+		}
+		w := do(h, http.MethodGet, "/", nil)
+		if !containsString(w.Body.String(), tt.want) {
+			t.Errorf("%s: body should contain %s, got: %s", tt.name, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestRequirePermission_NilChecker(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("nil checker should panic")
+		}
+	}()
+	RequirePermission(nil)
+}
+
+func TestHasPermissions(t *testing.T) {
+	resolver := RolePermissions(map[string][]string{
+		"admin":  {"*"},
+		"editor": {"article:*", "comment:read"},
+		"viewer": {"article:read"},
+	})
+	mw := RequirePermission(HasPermissions(resolver, "article:publish", "comment:read"))
+
+	allowed := func(roles ...string) bool {
+		h := withContextValue(contextKey, &permClaims{roles: roles})(mw(okHandler))
+		return containsString(do(h, http.MethodPost, "/articles/1/publish", nil).Body.String(), `"ok":true`)
+	}
+	if !allowed("admin") || !allowed("editor") {
+		t.Error("admin and editor should be allowed")
+	}
+	// 需同时拥有全部权限
+	if allowed("viewer") || allowed() {
+		t.Error("viewer and roleless subject should be forbidden")
+	}
+
+	// 解析失败时透传错误
+	failing := RequirePermission(HasPermissions(PermissionResolverFunc(func(ctx context.Context, claims any) ([]string, error) {
+		return nil, ErrPermissionCheckerNil
+	}), "x"))
+	h := withContextValue(contextKey, &permClaims{})(failing(okHandler))
+	if w := do(h, http.MethodGet, "/", nil); !containsString(w.Body.String(), `"code":500`) {
+		t.Errorf("resolver error should be returned, got: %s", w.Body.String())
+	}
+}
+
+func TestGrantsPermission(t *testing.T) {
+	for _, tt := range []struct {
+		granted, want string
+		ok            bool
+	}{
+		{"*", "order:read", true},
+		{"order:read", "order:read", true},
+		{"order:*", "order:item:write", true},
+		{"order:*", "orders:read", false},
+		{"order*", "orders:read", false},
+		{"order:read", "order:write", false},
+	} {
+		if got := grantsPermission(tt.granted, tt.want); got != tt.ok {
+			t.Errorf("grantsPermission(%q, %q) = %v, want %v", tt.granted, tt.want, got, tt.ok)
+		}
+	}
+}
+
+func TestRequireRoles_Gin(t *testing.T) {
+	r := ginEngine(http.MethodGet, "/admin", RequireRoles("admin"), okHandler)
+	if w := do(r, http.MethodGet, "/admin", nil); !containsString(w.Body.String(), `"code":401`) {
+		t.Errorf("[Gin] anonymous request should be unauthorized, got: %s", w.Body.String())
+	}
+}
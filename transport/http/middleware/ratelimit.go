@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/kochabx/kit/core/rate"
+	"github.com/kochabx/kit/errors"
+	"github.com/kochabx/kit/log"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+var ErrRateLimited = errors.TooManyRequests("rate limit exceeded")
+
+// RateLimitConfig 限流中间件配置
+type RateLimitConfig struct {
+	Skip           SkipConfig                                              // 跳过配置
+	Limiter        rate.Limiter                                            // 默认限流器，为 nil 时只限制命中 Rules 的请求
+	KeyFunc        func(r *http.Request) string                            // 限流 key，默认 RateLimitByIP(TrustedProxies...)
+	TrustedProxies []string                                                // 允许通过 X-Real-IP / X-Forwarded-For 传递客户端 IP 的代理地址（CIDR），仅用于默认 KeyFunc
+	CostFunc       func(r *http.Request) int                               // 单次请求消耗的配额，默认 1
+	Rules          []RateLimitRule                                         // 按路由覆盖限流配置，按顺序匹配，第一条命中的生效
+	ErrorHandler   func(w http.ResponseWriter, r *http.Request, err error) // 被限流时的处理函数，默认返回 HTTP 429
+	Logger         *log.Logger                                             // 自定义日志记录器
+}
+
+// RateLimitRule 单条路由的限流配置，未设置的字段沿用 RateLimitConfig。
+//
+// 命中规则的请求使用 "<key>:<Name>" 作为限流 key，与默认配额及其他规则互不影响。
+type RateLimitRule struct {
+	Name     string                       // 规则名，默认为 Paths[0]
+	Paths    []string                     // 匹配的路径，语法同 SkipConfig.Paths
+	Methods  []string                     // 匹配的方法，为空时匹配所有方法
+	Limiter  rate.Limiter                 // 该路由的限流器
+	KeyFunc  func(r *http.Request) string // 该路由的限流 key
+	Cost     int                          // 单次请求消耗的配额，为 0 时沿用 CostFunc
+	Disabled bool                         // 该路由不限流
+}
+
+// rateLimitRoute 预编译的路由规则
+type rateLimitRoute struct {
+	RateLimitRule
+	matcher *PathMatcher
+}
+
+func (rt *rateLimitRoute) match(r *http.Request) bool {
+	if len(rt.Methods) > 0 && !slices.ContainsFunc(rt.Methods, func(m string) bool {
+		return strings.EqualFold(m, r.Method)
+	}) {
+		return false
+	}
+	return rt.matcher.Match(r.URL.Path)
+}
+
+// RateLimit 创建限流中间件：每个请求按 KeyFunc 取得 key 并向 Limiter 申请配额，
+// 并在响应中输出 X-RateLimit-Limit / X-RateLimit-Remaining / X-RateLimit-Reset，
+// 被拒绝时额外输出 Retry-After 并返回 HTTP 429。
+//
+// 登录、短信验证码等敏感接口可通过 Rules 设置更严格的配额。
+// 限流器出错时放行并记录日志，避免 Redis 抖动导致整体不可用。
+// Gin 中通过 AdaptToGin 使用。
+func RateLimit(cfg RateLimitConfig) func(http.Handler) http.Handler {
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = RateLimitByIP(cfg.TrustedProxies...)
+	}
+	if cfg.CostFunc == nil {
+		cfg.CostFunc = func(r *http.Request) int { return 1 }
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			// 与其他中间件不同，这里使用真实的 429 状态码，网关与客户端 SDK 据此识别限流并遵循 Retry-After
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusTooManyRequests)
+			kithttp.Fail(w, http.StatusTooManyRequests, err)
+		}
+	}
+
+	routes := make([]*rateLimitRoute, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		if rule.Name == "" && len(rule.Paths) > 0 {
+			rule.Name = rule.Paths[0]
+		}
+		if rule.Limiter == nil {
+			rule.Limiter = cfg.Limiter
+		}
+		if rule.Limiter == nil && !rule.Disabled {
+			panic("middleware: RateLimit rule " + rule.Name + " requires a Limiter")
+		}
+		if rule.KeyFunc == nil {
+			rule.KeyFunc = cfg.KeyFunc
+		}
+		routes = append(routes, &rateLimitRoute{RateLimitRule: rule, matcher: NewPathMatcher(rule.Paths)})
+	}
+	if cfg.Limiter == nil && len(routes) == 0 {
+		panic("middleware: RateLimit requires a Limiter")
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var (
+				limiter = cfg.Limiter
+				key     string
+				n       int
+			)
+			if i := slices.IndexFunc(routes, func(rt *rateLimitRoute) bool { return rt.match(r) }); i >= 0 {
+				rt := routes[i]
+				if rt.Disabled {
+					next.ServeHTTP(w, r)
+					return
+				}
+				limiter, key, n = rt.Limiter, rt.KeyFunc(r)+":"+rt.Name, rt.Cost
+			} else {
+				if limiter == nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+				key = cfg.KeyFunc(r)
+			}
+			if n <= 0 {
+				n = cfg.CostFunc(r)
+			}
+
+			res, err := limiter.Allow(r.Context(), key, n)
+			if err != nil {
+				cfg.Logger.Error().Err(err).Str("path", r.URL.Path).Msg("ratelimit: limiter failed")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res.SetHeaders(w.Header())
+			if !res.Allowed {
+				cfg.ErrorHandler(w, r, ErrRateLimited)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitByIP 返回按客户端 IP 限流的 KeyFunc。IP 默认取自 RemoteAddr；
+// 仅当直接来源地址属于 trustedProxies（CIDR 或 IP）时才读取 X-Real-IP 与 X-Forwarded-For，
+// 避免客户端伪造请求头绕过限流。
+func RateLimitByIP(trustedProxies ...string) func(r *http.Request) string {
+	trusted := parsePrefixes(trustedProxies)
+	return func(r *http.Request) string {
+		return "ratelimit:ip:" + realIP(r, trusted)
+	}
+}
+
+// RateLimitByHeader 返回按请求头限流的 KeyFunc，如按 API Key 或租户 ID；
+// 请求头为空时回退为按客户端 IP，trustedProxies 同 RateLimitByIP。
+func RateLimitByHeader(name string, trustedProxies ...string) func(r *http.Request) string {
+	prefix := "ratelimit:header:" + strings.ToLower(name) + ":"
+	byIP := RateLimitByIP(trustedProxies...)
+	return func(r *http.Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return prefix + v
+		}
+		return byIP(r)
+	}
+}
+
+// RateLimitBySubject 返回按用户限流的 KeyFunc：用户 ID 取自 claims 的 GetSubject()，
+// 未认证的请求回退为按客户端 IP，trustedProxies 同 RateLimitByIP。需注册在 Auth 之后。
+func RateLimitBySubject(claimsKey string, trustedProxies ...string) func(r *http.Request) string {
+	subject := SubjectFromClaims(claimsKey, "")
+	byIP := RateLimitByIP(trustedProxies...)
+	return func(r *http.Request) string {
+		if id := subject(r).UserID; id != "" {
+			return "ratelimit:user:" + id
+		}
+		return byIP(r)
+	}
+}
+
+// realIP 返回请求的客户端 IP：直接来源不在 trusted 中时使用 RemoteAddr；
+// 否则依次取 X-Real-IP 与 X-Forwarded-For 中从右往左第一个不属于 trusted 的地址
+func realIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !fromTrustedPeer(r, trusted) {
+		return host
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			return hop
+		}
+		if !slices.ContainsFunc(trusted, func(p netip.Prefix) bool { return p.Contains(addr.Unmap()) }) {
+			return hop
+		}
+	}
+	return host
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kochabx/kit/core/rate"
+)
+
+// ============================================================================
+// RateLimit 中间件测试
+// ============================================================================
+
+// quotaLimiter 每个 key 固定 limit 个配额，记录每次申请的数量
+type quotaLimiter struct {
+	limit int64
+	used  map[string]int64
+	err   error
+}
+
+func (l *quotaLimiter) Allow(_ context.Context, key string, n int) (rate.Result, error) {
+	if l.err != nil {
+		return rate.Result{}, l.err
+	}
+	res := rate.Result{Limit: l.limit, ResetAt: time.Now().Add(time.Minute)}
+	if l.used[key]+int64(n) > l.limit {
+		res.Remaining = l.limit - l.used[key]
+		res.RetryAfter = 1500 * time.Millisecond
+		return res, nil
+	}
+	l.used[key] += int64(n)
+	res.Allowed = true
+	res.Remaining = l.limit - l.used[key]
+	return res, nil
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	limiter := &quotaLimiter{limit: 2, used: make(map[string]int64)}
+	mw := RateLimit(RateLimitConfig{Limiter: limiter})
+	fromIP := func(ip string) func(*http.Request) {
+		return func(r *http.Request) { r.RemoteAddr = ip + ":1234" }
+	}
+
+	w := do(mw(okHandler), http.MethodGet, "/api", fromIP("10.0.0.1"))
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Errorf("first request: code=%d headers=%v", w.Code, w.Header())
+	}
+	if w.Header().Get("X-RateLimit-Reset") == "" || w.Header().Get("Retry-After") != "" {
+		t.Errorf("allowed request should carry reset but not Retry-After: %v", w.Header())
+	}
+
+	do(mw(okHandler), http.MethodGet, "/api", fromIP("10.0.0.1"))
+	w = do(mw(okHandler), http.MethodGet, "/api", fromIP("10.0.0.1"))
+	if w.Code != http.StatusTooManyRequests || !containsString(w.Body.String(), `"code":429`) || w.Header().Get("Retry-After") != "2" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("third request should be limited: body=%s headers=%v", w.Body.String(), w.Header())
+	}
+
+	// 不同 IP 配额独立
+	w = do(mw(okHandler), http.MethodGet, "/api", fromIP("10.0.0.2"))
+	if w.Code != http.StatusOK {
+		t.Errorf("other client should pass, got %d", w.Code)
+	}
+	if limiter.used["ratelimit:ip:10.0.0.1"] != 2 || limiter.used["ratelimit:ip:10.0.0.2"] != 1 {
+		t.Errorf("used = %v", limiter.used)
+	}
+}
+
+func TestRateLimit_SubjectCostAndSkip(t *testing.T) {
+	limiter := &quotaLimiter{limit: 5, used: make(map[string]int64)}
+	mw := RateLimit(RateLimitConfig{
+		Limiter:  limiter,
+		KeyFunc:  RateLimitBySubject(""),
+		CostFunc: func(r *http.Request) int { return 3 },
+		Skip:     SkipConfig{Paths: []string{"/health"}},
+	})
+	h := withContextValue(contextKey, &permClaims{subject: "alice"})(mw(okHandler))
+
+	if w := do(h, http.MethodGet, "/api", nil); w.Code != http.StatusOK {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	// 剩余 2 个配额不足以支付 3
+	if w := do(h, http.MethodGet, "/api", nil); !containsString(w.Body.String(), `"code":429`) {
+		t.Errorf("second request should be limited, got: %s", w.Body.String())
+	}
+	if w := do(h, http.MethodGet, "/health", nil); w.Code != http.StatusOK {
+		t.Errorf("skipped path should pass, got %d", w.Code)
+	}
+	if limiter.used["ratelimit:user:alice"] != 3 || len(limiter.used) != 1 {
+		t.Errorf("used = %v", limiter.used)
+	}
+
+	// 未认证请求按 IP 限流
+	do(mw(okHandler), http.MethodGet, "/api", nil)
+	if limiter.used["ratelimit:ip:192.0.2.1"] != 3 {
+		t.Errorf("anonymous request should fall back to IP, used = %v", limiter.used)
+	}
+}
+
+func TestRateLimit_TrustedProxies(t *testing.T) {
+	limiter := &quotaLimiter{limit: 100, used: make(map[string]int64)}
+	mw := RateLimit(RateLimitConfig{Limiter: limiter, TrustedProxies: []string{"10.0.0.0/8"}})
+
+	tests := []struct {
+		name   string
+		remote string
+		header map[string]string
+		want   string
+	}{
+		// 未经可信代理的请求忽略转发头，避免伪造 IP 绕过限流
+		{"untrusted real ip", "192.0.2.1:1234", map[string]string{"X-Real-IP": "1.1.1.1"}, "192.0.2.1"},
+		{"untrusted forwarded", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1"}, "192.0.2.1"},
+		{"trusted real ip", "10.0.0.1:1234", map[string]string{"X-Real-IP": "1.1.1.2"}, "1.1.1.2"},
+		// 取从右往左第一个不可信的地址，客户端自行添加的最左侧地址不生效
+		{"trusted forwarded", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "6.6.6.6, 1.1.1.3, 10.0.0.2"}, "1.1.1.3"},
+		{"trusted without header", "10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		do(mw(okHandler), http.MethodGet, "/api", func(r *http.Request) {
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+		})
+		if limiter.used["ratelimit:ip:"+tt.want] == 0 {
+			t.Errorf("%s: expected key for %s, used = %v", tt.name, tt.want, limiter.used)
+		}
+	}
+	if limiter.used["ratelimit:ip:1.1.1.1"] != 0 || limiter.used["ratelimit:ip:6.6.6.6"] != 0 {
+		t.Errorf("spoofed addresses should not be used, used = %v", limiter.used)
+	}
+
+	// RateLimitByHeader 回退为 IP 时同样默认忽略转发头
+	key := RateLimitByHeader("X-API-Key")
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1")
+	if got := key(r); got != "ratelimit:ip:192.0.2.1" {
+		t.Errorf("RateLimitByHeader fallback = %q", got)
+	}
+}
+
+func TestRateLimit_FailOpen(t *testing.T) {
+	mw := RateLimit(RateLimitConfig{Limiter: &quotaLimiter{err: errors.New("redis down")}})
+	w := do(mw(okHandler), http.MethodGet, "/api", nil)
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("limiter error should pass without headers, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimit_Rules(t *testing.T) {
+	global := &quotaLimiter{limit: 100, used: make(map[string]int64)}
+	login := &quotaLimiter{limit: 1, used: make(map[string]int64)}
+	mw := RateLimit(RateLimitConfig{
+		Limiter: global,
+		Rules: []RateLimitRule{
+			{Name: "login", Paths: []string{"/login"}, Methods: []string{http.MethodPost}, Limiter: login},
+			{Paths: []string{"/export/**"}, Cost: 10},
+			{Paths: []string{"/metrics"}, Disabled: true},
+		},
+	})
+
+	if w := do(mw(okHandler), http.MethodPost, "/login", nil); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("first login: code=%d headers=%v", w.Code, w.Header())
+	}
+	w := do(mw(okHandler), http.MethodPost, "/login", nil)
+	if !containsString(w.Body.String(), `"code":429`) || w.Header().Get("Retry-After") == "" {
+		t.Errorf("second login should be limited: %s %v", w.Body.String(), w.Header())
+	}
+	// GET /login 不命中规则，使用默认配额
+	if w := do(mw(okHandler), http.MethodGet, "/login", nil); w.Header().Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("GET /login should use default limiter, headers=%v", w.Header())
+	}
+	do(mw(okHandler), http.MethodGet, "/export/users", nil)
+	if w := do(mw(okHandler), http.MethodGet, "/metrics", nil); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("disabled route should not be limited, headers=%v", w.Header())
+	}
+
+	if login.used["ratelimit:ip:192.0.2.1:login"] != 1 {
+		t.Errorf("login used = %v", login.used)
+	}
+	want := map[string]int64{"ratelimit:ip:192.0.2.1": 1, "ratelimit:ip:192.0.2.1:/export/**": 10}
+	for k, v := range want {
+		if global.used[k] != v {
+			t.Errorf("global used[%q] = %d, want %d (all: %v)", k, global.used[k], v, global.used)
+		}
+	}
+}
+
+func TestRateLimit_RulesOnly(t *testing.T) {
+	limiter := &quotaLimiter{limit: 1, used: make(map[string]int64)}
+	mw := RateLimit(RateLimitConfig{
+		KeyFunc: RateLimitByHeader("X-API-Key"),
+		Rules:   []RateLimitRule{{Name: "sms", Paths: []string{"/sms"}, Limiter: limiter}},
+	})
+	withKey := func(r *http.Request) { r.Header.Set("X-API-Key", "k1") }
+
+	if w := do(mw(okHandler), http.MethodGet, "/other", withKey); w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("unmatched route should not be limited without a default limiter")
+	}
+	do(mw(okHandler), http.MethodGet, "/sms", withKey)
+	do(mw(okHandler), http.MethodGet, "/sms", nil)
+	if limiter.used["ratelimit:header:x-api-key:k1:sms"] != 1 || limiter.used["ratelimit:ip:192.0.2.1:sms"] != 1 {
+		t.Errorf("used = %v", limiter.used)
+	}
+}
+
+func TestRateLimit_Gin(t *testing.T) {
+	limiter := &quotaLimiter{limit: 1, used: make(map[string]int64)}
+	r := ginEngine(http.MethodGet, "/api", RateLimit(RateLimitConfig{Limiter: limiter}), okHandler)
+
+	do(r, http.MethodGet, "/api", nil)
+	w := do(r, http.MethodGet, "/api", nil)
+	if w.Code != http.StatusTooManyRequests || !containsString(w.Body.String(), `"code":429`) || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("gin: second request should be limited, got %d %s %v", w.Code, w.Body.String(), w.Header())
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"bufio"
 	"fmt"
 	"net"
 	"net/http"
@@ -9,16 +10,25 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/kochabx/kit/errors"
 	"github.com/kochabx/kit/log"
+	"github.com/kochabx/kit/log/redact"
+	kithttp "github.com/kochabx/kit/transport/http"
 )
 
+// ErrPanic panic 恢复后返回给客户端的错误，不暴露 panic 详情
+var ErrPanic = errors.Internal("internal server error")
+
 // RecoveryConfig Recovery 中间件配置
 type RecoveryConfig struct {
-	StackTrace bool        // 是否记录堆栈信息
-	Logger     *log.Logger // 自定义日志记录器
+	StackTrace   bool                                                    // 是否记录堆栈信息
+	Logger       *log.Logger                                             // 自定义日志记录器
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error) // 恢复后的响应，默认返回 HTTP 500 与标准错误响应体
 }
 
-// Recovery 创建 panic 恢复中间件
+// Recovery 创建 panic 恢复中间件：通过 Logger 记录 panic、脱敏后的请求与堆栈，
+// 并以标准响应格式 {"code":500,"msg":"internal server error"} 返回 HTTP 500。
+// 响应已开始写入时只记录日志；客户端断开（broken pipe）时仅记录 Warn 日志，不写响应
 func Recovery(cfgs ...RecoveryConfig) func(http.Handler) http.Handler {
 	cfg := RecoveryConfig{
 		StackTrace: true,
@@ -30,12 +40,20 @@ func Recovery(cfgs ...RecoveryConfig) func(http.Handler) http.Handler {
 	if cfg.Logger == nil {
 		cfg.Logger = log.Global()
 	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusInternalServerError)
+			kithttp.Fail(w, http.StatusInternalServerError, err)
+		}
+	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &recoveryResponseWriter{ResponseWriter: w}
 			defer func() {
 				if err := recover(); err != nil {
-					httpRequest, _ := httputil.DumpRequest(r, false)
+					httpRequest := dumpRequest(r)
 
 					if isBrokenPipe(err) {
 						cfg.Logger.Warn().
@@ -47,21 +65,84 @@ func Recovery(cfgs ...RecoveryConfig) func(http.Handler) http.Handler {
 
 					event := cfg.Logger.Error().
 						Str("error", fmt.Sprintf("%v", err)).
+						Str("method", r.Method).
+						Str("path", r.URL.Path).
+						Str("client_ip", clientIP(r)).
 						Bytes("request", httpRequest)
 
+					if requestID := requestIDOf(r); requestID != "" {
+						event = event.Str("request_id", requestID)
+					}
+
 					if cfg.StackTrace {
 						event = event.Bytes("stack", debug.Stack())
 					}
 
 					event.Msg("panic recovered")
-					w.WriteHeader(http.StatusInternalServerError)
+					if !rw.wroteHeader {
+						cfg.ErrorHandler(rw, r, ErrPanic)
+					}
 				}
 			}()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(rw, r)
 		})
 	}
 }
 
+// recoveryResponseWriter 记录响应是否已开始写入，已写入时 panic 后不再输出错误响应
+type recoveryResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoveryResponseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recoveryResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recoveryResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack 支持 WebSocket 等协议升级，升级后视为响应已写入
+func (w *recoveryResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("middleware: %T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	w.wroteHeader = true
+	return h.Hijack()
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *recoveryResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// dumpRequest 转储请求行与请求头，凭证类请求头与查询参数已脱敏
+func dumpRequest(r *http.Request) []byte {
+	masked := *r
+	masked.Header = redact.Header(r.Header)
+	if r.URL != nil {
+		u := *r.URL
+		u.RawQuery = redact.Query(u.RawQuery)
+		masked.URL = &u
+	}
+	if path, query, ok := strings.Cut(r.RequestURI, "?"); ok {
+		masked.RequestURI = path + "?" + redact.Query(query)
+	}
+	dump, _ := httputil.DumpRequest(&masked, false)
+	return dump
+}
+
 // isBrokenPipe 检查是否为断开的连接错误
 func isBrokenPipe(err any) bool {
 	if ne, ok := err.(*net.OpError); ok {
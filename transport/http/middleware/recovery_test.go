@@ -1,9 +1,15 @@
 package middleware
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kochabx/kit/log"
 )
 
 // ============================================================================
@@ -64,6 +70,29 @@ func TestRecovery_NoStackTrace(t *testing.T) {
 	}
 }
 
+func TestRecovery_MasksCredentials(t *testing.T) {
+	// 转储的请求中凭证类请求头与查询参数应已脱敏
+	var output bytes.Buffer
+	mw := Recovery(RecoveryConfig{Logger: &log.Logger{Logger: zerolog.New(&output)}})
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w := do(mw(inner), http.MethodGet, "/panic?token=qs-secret", func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer header-secret")
+	})
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	got := output.String()
+	if strings.Contains(got, "header-secret") || strings.Contains(got, "qs-secret") {
+		t.Errorf("credential leaked: %s", got)
+	}
+	if !strings.Contains(got, "/panic") {
+		t.Errorf("request line should be kept: %s", got)
+	}
+}
+
 func TestRecovery_PanicAfterWriteHeader(t *testing.T) {
 	// 写入状态头后 panic，不能再次写入（不崩溃即通过）
 	mw := Recovery()
@@ -81,6 +110,41 @@ func TestRecovery_PanicAfterWriteHeader(t *testing.T) {
 	do(mw(inner), http.MethodGet, "/", nil)
 }
 
+func TestRecovery_Envelope(t *testing.T) {
+	// panic 后返回标准错误响应体，日志包含请求 ID 与堆栈
+	var output bytes.Buffer
+	mw := Recovery(RecoveryConfig{StackTrace: true, Logger: &log.Logger{Logger: zerolog.New(&output)}})
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w := do(mw(inner), http.MethodGet, "/panic", func(r *http.Request) {
+		r.Header.Set("X-Request-Id", "req-9")
+	})
+	if w.Code != http.StatusInternalServerError || !containsString(w.Body.String(), `"code":500`) ||
+		containsString(w.Body.String(), "boom") {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	got := output.String()
+	if !strings.Contains(got, `"request_id":"req-9"`) || !strings.Contains(got, `"stack":`) || !strings.Contains(got, `"error":"boom"`) {
+		t.Errorf("log should contain request id, error and stack: %s", got)
+	}
+}
+
+func TestRecovery_NoEnvelopeAfterWrite(t *testing.T) {
+	// 响应已开始写入时不再追加错误响应体
+	mw := Recovery()
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("panic after write")
+	})
+
+	w := do(mw(inner), http.MethodGet, "/", nil)
+	if w.Body.String() != "partial" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "partial")
+	}
+}
+
 // ============================================================================
 // Gin 集成测试
 // ============================================================================
@@ -96,8 +160,8 @@ func TestRecovery_Gin(t *testing.T) {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("[Gin] panic should return 500, got %d", w.Code)
+	if w.Code != http.StatusInternalServerError || !containsString(w.Body.String(), `"code":500`) {
+		t.Errorf("[Gin] panic should return 500 with error body, got %d %s", w.Code, w.Body.String())
 	}
 }
 
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	kitctx "github.com/kochabx/kit/core/x/ctx"
+	"github.com/kochabx/kit/log"
+)
+
+const (
+	headerRequestID   = "X-Request-ID" // 默认请求 ID 请求头
+	maxRequestIDLen   = 128            // 上游请求 ID 的最大长度，超出时重新生成
+	headerTraceParent = "traceparent"  // W3C Trace Context 请求头
+)
+
+// traceContext W3C Trace Context 传播器 (traceparent / tracestate)
+var traceContext = propagation.TraceContext{}
+
+// RequestIDConfig 请求 ID 与 Trace Context 中间件配置
+type RequestIDConfig struct {
+	Skip      SkipConfig    // 跳过配置
+	Header    string        // 请求 ID 请求头，默认 "X-Request-ID"
+	Generator func() string // 请求 ID 生成函数，默认 32 位十六进制随机串
+	Logger    *log.Logger   // 派生请求级日志记录器的基础 Logger，默认全局 Logger
+}
+
+// RequestID 创建请求 ID 与 Trace Context 传播中间件：
+//   - 沿用上游的请求 ID（缺失或不合法时生成），写入响应头并通过 ctx.WithRequestID 存入 context
+//   - 解析 W3C traceparent / tracestate 请求头，缺失时生成新的 trace，存入 context 并写回响应头；
+//     已有 OpenTelemetry span 的 context 保持不变
+//   - 向 context 注入带 request_id、trace_id、span_id 字段的日志记录器，业务代码通过 log.Ctx(ctx) 获取
+//
+// httpx.Client 发起的出站请求会自动携带 context 中的请求 ID 与 traceparent。
+// 应注册在最外层，使 Logger、Recovery 等中间件记录同一请求 ID。
+func RequestID(cfgs ...RequestIDConfig) func(http.Handler) http.Handler {
+	cfg := RequestIDConfig{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+
+	if cfg.Header == "" {
+		cfg.Header = headerRequestID
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = newRequestID
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Global()
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := r.Header.Get(cfg.Header)
+			if !validRequestID(requestID) {
+				requestID = cfg.Generator()
+				r.Header.Set(cfg.Header, requestID)
+			}
+			w.Header().Set(cfg.Header, requestID)
+
+			ctx := kitctx.WithRequestID(r.Context(), requestID)
+			ctx = withTraceContext(ctx, r.Header)
+			sc := trace.SpanContextFromContext(ctx)
+			traceContext.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+			logger := cfg.Logger.Child(func(c zerolog.Context) zerolog.Context {
+				c = c.Str("request_id", requestID)
+				if sc.IsValid() {
+					c = c.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+				}
+				return c
+			})
+			ctx = log.WithContext(ctx, logger)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// withTraceContext 返回携带 span context 的 ctx：已有有效 span 时不变，
+// 否则使用请求头中的 traceparent，缺失或不合法时生成新的 trace
+func withTraceContext(ctx context.Context, header http.Header) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	if header.Get(headerTraceParent) != "" {
+		extracted := traceContext.Extract(ctx, propagation.HeaderCarrier(header))
+		if trace.SpanContextFromContext(extracted).IsValid() {
+			return extracted
+		}
+	}
+
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// GetRequestID 从 Context 获取 RequestID 中间件设置的请求 ID
+func GetRequestID(ctx context.Context) string {
+	return kitctx.RequestID(ctx)
+}
+
+// requestIDOf 返回请求 ID：优先取自 context（RequestID 中间件生成的 ID），其次取自请求头
+func requestIDOf(r *http.Request) string {
+	if id := kitctx.RequestID(r.Context()); id != "" {
+		return id
+	}
+	return r.Header.Get(headerRequestID)
+}
+
+// validRequestID 校验上游请求 ID：非空、不超过 128 字节且仅含可打印 ASCII 字符，防止日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成 32 位十六进制随机请求 ID
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
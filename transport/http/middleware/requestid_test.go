@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kochabx/kit/core/httpx"
+	"github.com/kochabx/kit/log"
+)
+
+// ============================================================================
+// RequestID 中间件测试
+// ============================================================================
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestRequestID_Generate(t *testing.T) {
+	var output bytes.Buffer
+	var ctxID, traceID string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = GetRequestID(r.Context())
+		traceID = trace.SpanContextFromContext(r.Context()).TraceID().String()
+		log.Ctx(r.Context()).Info().Msg("handled")
+	})
+	mw := RequestID(RequestIDConfig{Logger: &log.Logger{Logger: zerolog.New(&output)}})
+
+	w := do(mw(inner), http.MethodGet, "/", nil)
+
+	id := w.Header().Get("X-Request-ID")
+	if len(id) != 32 || ctxID != id {
+		t.Fatalf("generated id = %q, context id = %q", id, ctxID)
+	}
+	if tp := w.Header().Get("traceparent"); !strings.Contains(tp, traceID) || traceID == "00000000000000000000000000000000" {
+		t.Errorf("traceparent = %q, trace id = %q", tp, traceID)
+	}
+	got := output.String()
+	if !strings.Contains(got, `"request_id":"`+id+`"`) || !strings.Contains(got, `"trace_id":"`+traceID+`"`) {
+		t.Errorf("context logger should carry correlation fields: %s", got)
+	}
+}
+
+func TestRequestID_Propagate(t *testing.T) {
+	// 沿用上游的请求 ID 与 traceparent
+	var ctxID, traceID string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = GetRequestID(r.Context())
+		traceID = trace.SpanContextFromContext(r.Context()).TraceID().String()
+	})
+
+	w := do(RequestID()(inner), http.MethodGet, "/", func(r *http.Request) {
+		r.Header.Set("X-Request-ID", "upstream-1")
+		r.Header.Set("traceparent", testTraceParent)
+	})
+	if ctxID != "upstream-1" || w.Header().Get("X-Request-ID") != "upstream-1" {
+		t.Errorf("request id = %q, response header = %q", ctxID, w.Header().Get("X-Request-ID"))
+	}
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || w.Header().Get("traceparent") != testTraceParent {
+		t.Errorf("trace id = %q, traceparent = %q", traceID, w.Header().Get("traceparent"))
+	}
+}
+
+func TestRequestID_RejectInvalid(t *testing.T) {
+	// 不合法的上游请求 ID 与 traceparent 被替换
+	var ctxID string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = GetRequestID(r.Context())
+	})
+
+	w := do(RequestID(RequestIDConfig{Generator: func() string { return "fixed" }})(inner), http.MethodGet, "/", func(r *http.Request) {
+		r.Header.Set("X-Request-ID", "bad id\n")
+		r.Header.Set("traceparent", "00-invalid")
+	})
+	if ctxID != "fixed" {
+		t.Errorf("request id = %q, want generated", ctxID)
+	}
+	if tp := w.Header().Get("traceparent"); tp == "" || tp == "00-invalid" {
+		t.Errorf("traceparent = %q, want a new trace", tp)
+	}
+}
+
+func TestRequestID_AccessLog(t *testing.T) {
+	// Logger 注册在 RequestID 之内时，访问日志记录生成的请求 ID
+	var output bytes.Buffer
+	h := RequestID()(Logger(LoggerConfig{Logger: &log.Logger{Logger: zerolog.New(&output)}})(okHandler))
+
+	w := do(h, http.MethodGet, "/", nil)
+	if id := w.Header().Get("X-Request-ID"); !strings.Contains(output.String(), `"request_id":"`+id+`"`) {
+		t.Errorf("access log should contain request id %s: %s", id, output.String())
+	}
+}
+
+func TestRequestID_OutboundPropagation(t *testing.T) {
+	// 处理函数中通过 httpx 发起的出站请求自动携带请求 ID 与 traceparent
+	var gotID, gotTraceParent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get("X-Request-ID")
+		gotTraceParent = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+
+	client := httpx.New()
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := client.Get(r.Context(), downstream.URL)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	})
+
+	do(RequestID()(inner), http.MethodGet, "/", func(r *http.Request) {
+		r.Header.Set("X-Request-ID", "upstream-2")
+		r.Header.Set("traceparent", testTraceParent)
+	})
+	if gotID != "upstream-2" || !strings.HasPrefix(gotTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Errorf("downstream got X-Request-ID %q, traceparent %q", gotID, gotTraceParent)
+	}
+}
+
+func TestRequestID_KeepsExistingSpan(t *testing.T) {
+	// context 中已有 OpenTelemetry span 时不覆盖
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled,
+	})
+	var traceID trace.TraceID
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID = trace.SpanContextFromContext(r.Context()).TraceID()
+	})
+	withSpan := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(trace.ContextWithSpanContext(context.Background(), sc)))
+		})
+	}
+
+	do(withSpan(RequestID()(inner)), http.MethodGet, "/", func(r *http.Request) {
+		r.Header.Set("traceparent", testTraceParent)
+	})
+	if traceID != sc.TraceID() {
+		t.Errorf("trace id = %s, want %s", traceID, sc.TraceID())
+	}
+}
@@ -0,0 +1,312 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CSP 常用来源关键字
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+
+	// CSPNonceSource 每个请求随机生成的 nonce 来源，渲染为 'nonce-<base64>'，
+	// 页面模板通过 GetCSPNonce 取得同一个值写入 <script nonce="...">
+	CSPNonceSource = "'nonce'"
+)
+
+// cspNonceSize nonce 随机字节数
+const cspNonceSize = 16
+
+// cspNonceKey CSP nonce 在 context 中的键
+type cspNonceKey struct{}
+
+// GetCSPNonce 从 context 获取当前请求的 CSP nonce；CSP 未使用 CSPNonceSource 时返回 false
+func GetCSPNonce(ctx context.Context) (string, bool) {
+	nonce, ok := ctx.Value(cspNonceKey{}).(string)
+	return nonce, ok
+}
+
+// cspDirective 一条 CSP 指令
+type cspDirective struct {
+	name    string
+	sources []string
+}
+
+// CSP Content-Security-Policy 构建器，指令按首次设置的顺序输出：
+//
+//	csp := middleware.NewCSP().
+//		Set("default-src", middleware.CSPSelf).
+//		Set("script-src", middleware.CSPSelf, middleware.CSPNonceSource).
+//		Set("upgrade-insecure-requests")
+type CSP struct {
+	directives []cspDirective
+}
+
+// NewCSP 创建空的 CSP 构建器
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// Set 设置指令及其来源，已存在的同名指令被替换；无来源的指令（如 upgrade-insecure-requests）只输出名称
+func (c *CSP) Set(name string, sources ...string) *CSP {
+	d := cspDirective{name: name, sources: append([]string(nil), sources...)}
+	for i := range c.directives {
+		if c.directives[i].name == name {
+			c.directives[i] = d
+			return c
+		}
+	}
+	c.directives = append(c.directives, d)
+	return c
+}
+
+// Add 为指令追加来源，指令不存在时创建
+func (c *CSP) Add(name string, sources ...string) *CSP {
+	for i := range c.directives {
+		if c.directives[i].name == name {
+			c.directives[i].sources = append(c.directives[i].sources, sources...)
+			return c
+		}
+	}
+	return c.Set(name, sources...)
+}
+
+// Remove 删除指令
+func (c *CSP) Remove(name string) *CSP {
+	for i := range c.directives {
+		if c.directives[i].name == name {
+			c.directives = append(c.directives[:i], c.directives[i+1:]...)
+			break
+		}
+	}
+	return c
+}
+
+// Clone 复制构建器，用于在预设基础上为个别路由调整策略
+func (c *CSP) Clone() *CSP {
+	if c == nil {
+		return nil
+	}
+	clone := &CSP{directives: make([]cspDirective, len(c.directives))}
+	for i, d := range c.directives {
+		clone.directives[i] = cspDirective{name: d.name, sources: append([]string(nil), d.sources...)}
+	}
+	return clone
+}
+
+// String 渲染策略，CSPNonceSource 保持原样
+func (c *CSP) String() string {
+	if c == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(c.directives))
+	for _, d := range c.directives {
+		if len(d.sources) == 0 {
+			parts = append(parts, d.name)
+			continue
+		}
+		parts = append(parts, d.name+" "+strings.Join(d.sources, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SecureConfig 安全响应头中间件配置，字段为零值时不输出对应响应头
+type SecureConfig struct {
+	Skip SkipConfig // 跳过配置
+
+	HSTSMaxAge            int  // Strict-Transport-Security max-age（秒），0 表示不输出
+	HSTSIncludeSubdomains bool // HSTS 是否包含子域名
+	HSTSPreload           bool // HSTS 是否声明 preload
+
+	ContentTypeNosniff bool   // 是否输出 X-Content-Type-Options: nosniff
+	FrameOptions       string // X-Frame-Options，如 "DENY"、"SAMEORIGIN"
+	ReferrerPolicy     string // Referrer-Policy，如 "no-referrer"
+
+	CSP           *CSP   // Content-Security-Policy，nil 表示不输出
+	CSPReportOnly bool   // 以 Content-Security-Policy-Report-Only 输出，只上报不拦截，便于灰度新策略
+	CSPReportURI  string // 违规上报地址，追加为 report-uri 指令
+
+	PermissionsPolicy string // Permissions-Policy，如 "camera=(), microphone=()"
+
+	Routes []SecureRoute // 按路径覆盖的配置，按顺序匹配，首个命中的配置整体替换当前配置
+}
+
+// SecureRoute 按路径覆盖的安全响应头配置
+type SecureRoute struct {
+	Paths  []string     // 路径，规则同 SkipConfig.Paths
+	Config SecureConfig // 命中时使用的配置，其 Routes 被忽略
+}
+
+// APISecureConfig 返回 JSON API 预设：禁止页面渲染与嵌入，不发送 Referer
+func APISecureConfig() SecureConfig {
+	return SecureConfig{
+		HSTSMaxAge:            31536000,
+		HSTSIncludeSubdomains: true,
+		ContentTypeNosniff:    true,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		CSP: NewCSP().
+			Set("default-src", CSPNone).
+			Set("frame-ancestors", CSPNone),
+	}
+}
+
+// WebSecureConfig 返回网页预设：资源仅限同源，脚本与样式需携带 nonce，
+// 页面通过 GetCSPNonce 取得 nonce
+func WebSecureConfig() SecureConfig {
+	return SecureConfig{
+		HSTSMaxAge:            31536000,
+		HSTSIncludeSubdomains: true,
+		ContentTypeNosniff:    true,
+		FrameOptions:          "SAMEORIGIN",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		CSP: NewCSP().
+			Set("default-src", CSPSelf).
+			Set("script-src", CSPSelf, CSPNonceSource).
+			Set("style-src", CSPSelf, CSPNonceSource).
+			Set("img-src", CSPSelf, "data:").
+			Set("object-src", CSPNone).
+			Set("base-uri", CSPSelf).
+			Set("form-action", CSPSelf).
+			Set("frame-ancestors", CSPSelf),
+		PermissionsPolicy: "camera=(), microphone=(), geolocation=(), payment=()",
+	}
+}
+
+// secureHeaders 预先渲染的响应头
+type secureHeaders struct {
+	static    [][2]string // 与请求无关的响应头
+	cspHeader string      // CSP 响应头名称
+	csp       string      // CSP 值，nonce 处为占位符
+	nonce     bool        // CSP 是否使用 nonce
+}
+
+// cspNoncePlaceholder 渲染后 CSP 中 nonce 的占位符
+const cspNoncePlaceholder = "'nonce-\x00'"
+
+// compileSecureHeaders 预先渲染配置中的响应头
+func compileSecureHeaders(cfg SecureConfig) *secureHeaders {
+	h := &secureHeaders{}
+	if cfg.HSTSMaxAge > 0 {
+		v := "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+		if cfg.HSTSIncludeSubdomains {
+			v += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			v += "; preload"
+		}
+		h.static = append(h.static, [2]string{"Strict-Transport-Security", v})
+	}
+	if cfg.ContentTypeNosniff {
+		h.static = append(h.static, [2]string{"X-Content-Type-Options", "nosniff"})
+	}
+	if cfg.FrameOptions != "" {
+		h.static = append(h.static, [2]string{"X-Frame-Options", cfg.FrameOptions})
+	}
+	if cfg.ReferrerPolicy != "" {
+		h.static = append(h.static, [2]string{"Referrer-Policy", cfg.ReferrerPolicy})
+	}
+	if cfg.PermissionsPolicy != "" {
+		h.static = append(h.static, [2]string{"Permissions-Policy", cfg.PermissionsPolicy})
+	}
+
+	if cfg.CSP != nil {
+		csp := cfg.CSP.Clone()
+		if cfg.CSPReportURI != "" {
+			csp.Set("report-uri", cfg.CSPReportURI)
+		}
+		for _, d := range csp.directives {
+			for i, src := range d.sources {
+				if src == CSPNonceSource {
+					d.sources[i] = cspNoncePlaceholder
+					h.nonce = true
+				}
+			}
+		}
+		h.csp = csp.String()
+		h.cspHeader = "Content-Security-Policy"
+		if cfg.CSPReportOnly {
+			h.cspHeader = "Content-Security-Policy-Report-Only"
+		}
+		if !h.nonce && h.csp != "" {
+			h.static = append(h.static, [2]string{h.cspHeader, h.csp})
+		}
+	}
+	return h
+}
+
+// SecureHeaders 创建安全响应头中间件，默认使用 APISecureConfig。
+// 响应头在调用后续 handler 前写入，handler 仍可覆盖个别响应头
+func SecureHeaders(cfgs ...SecureConfig) func(http.Handler) http.Handler {
+	cfg := APISecureConfig()
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+
+	matcher := NewPathMatcher(cfg.Skip.Paths)
+	headers := compileSecureHeaders(cfg)
+
+	type route struct {
+		matcher     *PathMatcher
+		skipMatcher *PathMatcher
+		skipFunc    func(*http.Request) bool
+		headers     *secureHeaders
+	}
+	routes := make([]route, len(cfg.Routes))
+	for i, r := range cfg.Routes {
+		routes[i] = route{
+			matcher:     NewPathMatcher(r.Paths),
+			skipMatcher: NewPathMatcher(r.Config.Skip.Paths),
+			skipFunc:    r.Config.Skip.Func,
+			headers:     compileSecureHeaders(r.Config),
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkip(r, matcher, cfg.Skip.Func) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := headers
+			for i := range routes {
+				if routes[i].matcher.Match(r.URL.Path) {
+					if shouldSkip(r, routes[i].skipMatcher, routes[i].skipFunc) {
+						next.ServeHTTP(w, r)
+						return
+					}
+					h = routes[i].headers
+					break
+				}
+			}
+
+			header := w.Header()
+			for _, kv := range h.static {
+				header.Set(kv[0], kv[1])
+			}
+			if h.nonce {
+				nonce := newCSPNonce()
+				header.Set(h.cspHeader, strings.ReplaceAll(h.csp, cspNoncePlaceholder, "'nonce-"+nonce+"'"))
+				r = r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, nonce))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newCSPNonce 生成 base64 编码的随机 nonce
+func newCSPNonce() string {
+	b := make([]byte, cspNonceSize)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
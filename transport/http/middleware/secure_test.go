@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// SecureHeaders 中间件测试
+// ============================================================================
+
+func TestSecureHeaders_APIPreset(t *testing.T) {
+	w := do(SecureHeaders()(okHandler), http.MethodGet, "/api/users", nil)
+
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "no-referrer",
+		"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+	}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if got := w.Header().Get("Permissions-Policy"); got != "" {
+		t.Errorf("Permissions-Policy = %q, want empty", got)
+	}
+}
+
+func TestSecureHeaders_Nonce(t *testing.T) {
+	var nonce string
+	h := SecureHeaders(WebSecureConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, _ = GetCSPNonce(r.Context())
+	}))
+
+	w := do(h, http.MethodGet, "/", nil)
+	if nonce == "" {
+		t.Fatal("nonce missing from context")
+	}
+	csp := w.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "script-src 'self' 'nonce-"+nonce+"'") {
+		t.Errorf("CSP %q does not carry nonce %q", csp, nonce)
+	}
+	if got := w.Header().Get("Permissions-Policy"); got == "" {
+		t.Error("Permissions-Policy missing")
+	}
+
+	// 每个请求使用新的 nonce
+	first := nonce
+	do(h, http.MethodGet, "/", nil)
+	if nonce == first {
+		t.Error("nonce reused across requests")
+	}
+}
+
+func TestSecureHeaders_Routes(t *testing.T) {
+	docs := WebSecureConfig()
+	docs.CSP = docs.CSP.Clone().Add("script-src", "https://cdn.example.com").Remove("style-src")
+	docs.CSPReportOnly = true
+	docs.CSPReportURI = "/csp-report"
+
+	cfg := APISecureConfig()
+	cfg.Skip.Paths = []string{"/health"}
+	cfg.Routes = []SecureRoute{{Paths: []string{"/docs/**"}, Config: docs}}
+	h := SecureHeaders(cfg)(okHandler)
+
+	w := do(h, http.MethodGet, "/docs/index.html", nil)
+	if got := w.Header().Get("X-Frame-Options"); got != "SAMEORIGIN" {
+		t.Errorf("X-Frame-Options = %q, want SAMEORIGIN", got)
+	}
+	if got := w.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("report-only route sent enforcing CSP %q", got)
+	}
+	csp := w.Header().Get("Content-Security-Policy-Report-Only")
+	if !strings.Contains(csp, "https://cdn.example.com") || strings.Contains(csp, "style-src") || !strings.HasSuffix(csp, "report-uri /csp-report") {
+		t.Errorf("unexpected report-only CSP %q", csp)
+	}
+	// 覆盖在副本上进行，预设不受影响
+	if strings.Contains(WebSecureConfig().CSP.String(), "cdn.example.com") {
+		t.Error("preset modified by override")
+	}
+
+	w = do(h, http.MethodGet, "/api/orders", nil)
+	if got := w.Header().Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("X-Frame-Options = %q, want DENY", got)
+	}
+
+	w = do(h, http.MethodGet, "/health", nil)
+	if got := w.Header().Get("X-Content-Type-Options"); got != "" {
+		t.Errorf("skipped path got X-Content-Type-Options %q", got)
+	}
+}
+
+func TestCSP_Builder(t *testing.T) {
+	csp := NewCSP().
+		Set("default-src", CSPSelf).
+		Set("img-src", CSPSelf).
+		Add("img-src", "data:").
+		Set("upgrade-insecure-requests").
+		Set("default-src", CSPNone)
+
+	want := "default-src 'none'; img-src 'self' data:; upgrade-insecure-requests"
+	if got := csp.String(); got != want {
+		t.Errorf("CSP = %q, want %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"net"
 	"net/http"
 	"strings"
@@ -16,7 +17,10 @@ import (
 	"github.com/kochabx/kit/transport"
 )
 
-var _ transport.Server = (*Server)(nil)
+var (
+	_ transport.Server = (*Server)(nil)
+	_ transport.Failer = (*Server)(nil)
+)
 
 const (
 	defaultName         = "http"
@@ -33,6 +37,7 @@ type Server struct {
 	name        string
 	tlsCertFile string
 	tlsKeyFile  string
+	failed      chan error // receives the serve error of the current run
 }
 
 // config holds the builder state for NewServer.
@@ -182,18 +187,42 @@ func (s *Server) Start(_ context.Context) error {
 	if err != nil {
 		return err
 	}
-	if s.tlsCertFile != "" {
-		go s.srv.ServeTLS(lis, s.tlsCertFile, s.tlsKeyFile)
-	} else {
-		go s.srv.Serve(lis)
-	}
+	srv, failed := s.srv, make(chan error, 1)
+	s.failed = failed
+	go func() {
+		var err error
+		if s.tlsCertFile != "" {
+			err = srv.ServeTLS(lis, s.tlsCertFile, s.tlsKeyFile)
+		} else {
+			err = srv.Serve(lis)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msgf("%s server stopped serving", s.name)
+			failed <- err
+		}
+	}()
 	log.Info().Msgf("%s server listening on %s", s.name, s.srv.Addr)
 	return nil
 }
 
-// Stop gracefully stops the server.
+// Failed implements transport.Failer. The channel belongs to the most recent
+// Start; it is nil before the first Start.
+func (s *Server) Failed() <-chan error { return s.failed }
+
+// Stop gracefully stops the server. A stopped Server can be started again.
 func (s *Server) Stop(ctx context.Context) error {
-	return s.srv.Shutdown(ctx)
+	err := s.srv.Shutdown(ctx)
+	// An http.Server cannot serve again after Shutdown; swap in a fresh one
+	// with the same settings so Start works after Stop (e.g. app.RestartServer).
+	s.srv = &http.Server{
+		Addr:         s.srv.Addr,
+		Handler:      s.srv.Handler,
+		ReadTimeout:  s.srv.ReadTimeout,
+		WriteTimeout: s.srv.WriteTimeout,
+		IdleTimeout:  s.srv.IdleTimeout,
+		TLSConfig:    s.srv.TLSConfig,
+	}
+	return err
 }
 
 // buildHandler wraps userHandler with a net/http.ServeMux for built-in endpoints.
@@ -148,6 +148,32 @@ func TestServer_Stop(t *testing.T) {
 	defer cancel()
 
 	require.NoError(t, s.Stop(ctx))
+
+	// a graceful stop is not reported as a failure
+	select {
+	case err := <-s.Failed():
+		t.Fatalf("unexpected failure after Stop: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestServer_RestartAfterStop(t *testing.T) {
+	s := NewServer(http.NotFoundHandler(), WithAddr(":19081"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for range 2 {
+		require.NoError(t, s.Start(ctx))
+		require.Eventually(t, func() bool {
+			resp, err := http.Get("http://127.0.0.1:19081/")
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return resp.StatusCode == http.StatusNotFound
+		}, 2*time.Second, 20*time.Millisecond)
+		require.NoError(t, s.Stop(ctx))
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -0,0 +1,348 @@
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+
+	"github.com/kochabx/kit/log"
+	"github.com/kochabx/kit/transport"
+)
+
+var (
+	_ transport.Server = (*Server)(nil)
+	_ transport.Failer = (*Server)(nil)
+)
+
+const (
+	defaultName      = "quic"
+	defaultHTTP3Name = "http3"
+	defaultAddr      = ":4433"
+	shutdownMessage  = "server shutdown"
+)
+
+// ErrNoTLSConfig is returned by Start when neither WithTLSConfig nor WithTLS
+// was given; QUIC always runs over TLS 1.3.
+var ErrNoTLSConfig = errors.New("quic: TLS config is required")
+
+// ErrNoNextProtos is returned by Start for a raw QUIC server whose TLS config
+// does not list an ALPN protocol; QUIC requires one to be negotiated.
+var ErrNoNextProtos = errors.New("quic: TLS config must set NextProtos")
+
+// ConnHandler serves a raw QUIC connection. ServeConn runs in its own
+// goroutine per connection and owns conn until it returns; the Server closes
+// the connection afterwards.
+//
+// ctx is cancelled when Stop starts draining: handlers should finish the
+// streams they are processing and return. Connections whose handler has not
+// returned when the Stop context expires are closed by the Server.
+type ConnHandler interface {
+	ServeConn(ctx context.Context, conn *quic.Conn) error
+}
+
+// ConnHandlerFunc adapts a function to ConnHandler.
+type ConnHandlerFunc func(ctx context.Context, conn *quic.Conn) error
+
+// ServeConn implements ConnHandler.
+func (f ConnHandlerFunc) ServeConn(ctx context.Context, conn *quic.Conn) error {
+	return f(ctx, conn)
+}
+
+// Server serves raw QUIC connections (NewServer) or HTTP/3 (NewHTTP3Server)
+// on a UDP socket. It implements transport.Server, so it plugs into
+// app.WithServer and follows the same lifecycle as the HTTP and gRPC
+// transports: Start listens and serves in the background, Stop stops
+// accepting, drains open connections until the context expires and then
+// closes the remaining ones. A stopped Server can be started again.
+type Server struct {
+	cfg     config
+	handler ConnHandler  // raw QUIC mode
+	httpH   http.Handler // HTTP/3 mode
+
+	mu     sync.Mutex
+	pconn  net.PacketConn
+	tr     *quic.Transport
+	ln     *quic.Listener
+	h3     *http3.Server
+	conns  map[*quic.Conn]struct{}
+	wg     sync.WaitGroup
+	drain  context.CancelFunc
+	failed chan error // receives the serve error of the current run
+}
+
+// config holds the builder state for NewServer and NewHTTP3Server.
+type config struct {
+	addr        string
+	name        string
+	tlsCertFile string
+	tlsKeyFile  string
+	tlsConfig   *tls.Config
+	quicConfig  *quic.Config
+	connIDLen   int
+	connIDGen   quic.ConnectionIDGenerator
+	resetKey    *quic.StatelessResetKey
+}
+
+// Option configures a Server.
+type Option func(*config)
+
+// WithAddr sets the UDP address the server listens on (e.g. ":4433").
+func WithAddr(addr string) Option {
+	return func(c *config) { c.addr = addr }
+}
+
+// WithName sets the server name, used in log output.
+func WithName(name string) Option {
+	return func(c *config) { c.name = name }
+}
+
+// WithTLS loads the certificate and private key files on Start.
+func WithTLS(certFile, keyFile string) Option {
+	return func(c *config) {
+		c.tlsCertFile = certFile
+		c.tlsKeyFile = keyFile
+	}
+}
+
+// WithTLSConfig sets the *tls.Config used for the QUIC handshake. For a raw
+// QUIC server it must list the application protocol in NextProtos; for
+// HTTP/3 "h3" is added automatically.
+func WithTLSConfig(tlsCfg *tls.Config) Option {
+	return func(c *config) { c.tlsConfig = tlsCfg }
+}
+
+// WithQUICConfig sets the quic-go connection configuration (idle timeout,
+// stream limits, flow control windows, datagrams, keep-alive).
+func WithQUICConfig(quicCfg *quic.Config) Option {
+	return func(c *config) { c.quicConfig = quicCfg }
+}
+
+// WithConnectionIDLength sets the length in bytes of the connection IDs the
+// server issues, between 1 and 20 (4 by default).
+func WithConnectionIDLength(n int) Option {
+	return func(c *config) { c.connIDLen = n }
+}
+
+// WithConnectionIDGenerator lets the application generate connection IDs,
+// e.g. to encode the server instance for QUIC-aware load balancers that route
+// by connection ID, so connections survive client address migration.
+func WithConnectionIDGenerator(gen quic.ConnectionIDGenerator) Option {
+	return func(c *config) { c.connIDGen = gen }
+}
+
+// WithStatelessResetKey enables stateless resets (RFC 9000, section 10.3).
+// Instances behind the same address should share the key, so a restarted
+// instance can reset connections its predecessor left behind and clients
+// reconnect immediately instead of waiting for the idle timeout.
+func WithStatelessResetKey(key *quic.StatelessResetKey) Option {
+	return func(c *config) { c.resetKey = key }
+}
+
+func newServer(name string, opts []Option) *Server {
+	cfg := config{addr: defaultAddr, name: name}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if !transport.ValidAddress(cfg.addr) {
+		log.Warn().Msgf("invalid address %q, falling back to %s", cfg.addr, defaultAddr)
+		cfg.addr = defaultAddr
+	}
+	return &Server{cfg: cfg}
+}
+
+// NewServer creates a raw QUIC server that passes every accepted connection
+// to handler:
+//
+//	srv := quic.NewServer(quic.ConnHandlerFunc(ingest),
+//		quic.WithAddr(":4433"),
+//		quic.WithTLSConfig(&tls.Config{Certificates: certs, NextProtos: []string{"telemetry/1"}}),
+//	)
+//	app.New(app.WithServer(srv))
+func NewServer(handler ConnHandler, opts ...Option) *Server {
+	s := newServer(defaultName, opts)
+	s.handler = handler
+	return s
+}
+
+// NewHTTP3Server creates an HTTP/3 server for handler. Any http.Handler
+// (gin, chi, stdlib mux, etc.) can be passed, typically the same one served
+// over TCP by transport/http.
+func NewHTTP3Server(handler http.Handler, opts ...Option) *Server {
+	s := newServer(defaultHTTP3Name, opts)
+	s.httpH = handler
+	return s
+}
+
+// Start implements cx.Starter, starting the server in the background and returning immediately.
+func (s *Server) Start(_ context.Context) error {
+	tlsCfg, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	pconn, err := net.ListenPacket("udp", s.cfg.addr)
+	if err != nil {
+		return err
+	}
+	tr := &quic.Transport{
+		Conn:                  pconn,
+		ConnectionIDLength:    s.cfg.connIDLen,
+		ConnectionIDGenerator: s.cfg.connIDGen,
+		StatelessResetKey:     s.cfg.resetKey,
+	}
+	ln, err := tr.Listen(tlsCfg, s.cfg.quicConfig)
+	if err != nil {
+		_ = tr.Close()
+		_ = pconn.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.pconn, s.tr, s.ln = pconn, tr, ln
+	failed := make(chan error, 1)
+	s.failed = failed
+	if s.httpH != nil {
+		s.h3 = &http3.Server{
+			Addr:       s.cfg.addr,
+			TLSConfig:  tlsCfg,
+			QUICConfig: s.cfg.quicConfig,
+			Handler:    s.httpH,
+		}
+		h3 := s.h3
+		s.mu.Unlock()
+		go func() {
+			// ServeListener returns http.ErrServerClosed after Shutdown.
+			if err := h3.ServeListener(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error().Err(err).Msgf("%s server stopped serving", s.cfg.name)
+				failed <- err
+			}
+		}()
+	} else {
+		ctx, drain := context.WithCancel(context.Background())
+		s.conns = make(map[*quic.Conn]struct{})
+		s.drain = drain
+		s.mu.Unlock()
+		go s.accept(ctx, ln, failed)
+	}
+
+	log.Info().Msgf("%s server listening on %s", s.cfg.name, s.cfg.addr)
+	return nil
+}
+
+// tlsConfig returns the TLS config for the QUIC listener.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	tlsCfg := s.cfg.tlsConfig
+	if s.cfg.tlsCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.cfg.tlsCertFile, s.cfg.tlsKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if tlsCfg == nil {
+			tlsCfg = &tls.Config{}
+		} else {
+			tlsCfg = tlsCfg.Clone()
+		}
+		tlsCfg.Certificates = append(tlsCfg.Certificates, cert)
+	}
+	if tlsCfg == nil {
+		return nil, ErrNoTLSConfig
+	}
+	if s.httpH != nil {
+		return http3.ConfigureTLSConfig(tlsCfg), nil
+	}
+	if len(tlsCfg.NextProtos) == 0 {
+		return nil, ErrNoNextProtos
+	}
+	return tlsCfg, nil
+}
+
+// accept runs the raw QUIC accept loop until the listener is closed.
+func (s *Server) accept(ctx context.Context, ln *quic.Listener, failed chan<- error) {
+	for {
+		conn, err := ln.Accept(context.Background())
+		if err != nil {
+			// Accept returns quic.ErrServerClosed after Stop.
+			if !errors.Is(err, quic.ErrServerClosed) {
+				log.Error().Err(err).Msgf("%s server stopped serving", s.cfg.name)
+				failed <- err
+			}
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				s.wg.Done()
+			}()
+			if err := s.handler.ServeConn(ctx, conn); err != nil {
+				log.Warn().Err(err).Str("remote", conn.RemoteAddr().String()).Msgf("%s connection handler failed", s.cfg.name)
+			}
+			_ = conn.CloseWithError(0, "")
+		}()
+	}
+}
+
+// Failed implements transport.Failer. The channel belongs to the most recent
+// Start; it is nil before the first Start.
+func (s *Server) Failed() <-chan error { return s.failed }
+
+// Stop gracefully stops the server. It stops accepting connections, then
+// drains the open ones until ctx expires: HTTP/3 clients receive a GOAWAY and
+// in-flight requests complete, raw QUIC handlers see their context cancelled.
+// Connections still open when ctx expires are closed.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	pconn, tr, ln, h3, drain := s.pconn, s.tr, s.ln, s.h3, s.drain
+	s.pconn, s.tr, s.ln, s.h3, s.drain = nil, nil, nil, nil, nil
+	s.mu.Unlock()
+	if tr == nil {
+		return nil
+	}
+
+	var err error
+	if h3 != nil {
+		err = h3.Shutdown(ctx)
+		_ = ln.Close()
+	} else {
+		_ = ln.Close()
+		drain()
+		err = s.wait(ctx)
+	}
+	return errors.Join(err, tr.Close(), pconn.Close())
+}
+
+// wait waits for the raw QUIC handlers to return, closing the remaining
+// connections when ctx expires.
+func (s *Server) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.CloseWithError(0, shutdownMessage)
+	}
+	s.mu.Unlock()
+	return ctx.Err()
+}
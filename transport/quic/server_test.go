@@ -0,0 +1,219 @@
+package quic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProto = "kit-test"
+
+// selfSignedCert returns a certificate for 127.0.0.1 valid for one hour.
+func selfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func clientTLS(protos ...string) *tls.Config {
+	return &tls.Config{InsecureSkipVerify: true, NextProtos: protos} //nolint:gosec // test server uses a self-signed certificate
+}
+
+// echo serves bidirectional streams, echoing each one back to the client.
+func echo(ctx context.Context, conn *quic.Conn) error {
+	for {
+		stream, err := conn.AcceptStream(ctx)
+		if err != nil {
+			return nil
+		}
+		go func() {
+			defer stream.Close()
+			_, _ = io.Copy(stream, stream)
+		}()
+	}
+}
+
+func TestNewServer_Defaults(t *testing.T) {
+	s := NewServer(ConnHandlerFunc(echo))
+	assert.Equal(t, defaultAddr, s.cfg.addr)
+	assert.Equal(t, defaultName, s.cfg.name)
+
+	h := NewHTTP3Server(http.NotFoundHandler(), WithAddr("not-valid"))
+	assert.Equal(t, defaultAddr, h.cfg.addr)
+	assert.Equal(t, defaultHTTP3Name, h.cfg.name)
+}
+
+func TestServer_StartRequiresTLS(t *testing.T) {
+	ctx := context.Background()
+	assert.ErrorIs(t, NewServer(ConnHandlerFunc(echo), WithAddr("127.0.0.1:19441")).Start(ctx), ErrNoTLSConfig)
+
+	cert := selfSignedCert(t)
+	s := NewServer(ConnHandlerFunc(echo),
+		WithAddr("127.0.0.1:19441"),
+		WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}}),
+	)
+	assert.ErrorIs(t, s.Start(ctx), ErrNoNextProtos)
+	assert.NoError(t, s.Stop(ctx))
+}
+
+func TestServer_RawQUIC(t *testing.T) {
+	cert := selfSignedCert(t)
+	s := NewServer(ConnHandlerFunc(echo),
+		WithAddr("127.0.0.1:19442"),
+		WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{testProto}}),
+		WithConnectionIDLength(8),
+		WithStatelessResetKey(&quic.StatelessResetKey{1}),
+	)
+	ctx := context.Background()
+
+	// Stop followed by Start serves again on the same address.
+	for range 2 {
+		require.NoError(t, s.Start(ctx))
+
+		dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		conn, err := quic.DialAddr(dialCtx, "127.0.0.1:19442", clientTLS(testProto), nil)
+		require.NoError(t, err)
+		stream, err := conn.OpenStreamSync(dialCtx)
+		require.NoError(t, err)
+		_, err = stream.Write([]byte("ping"))
+		require.NoError(t, err)
+		require.NoError(t, stream.Close())
+		got, err := io.ReadAll(stream)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(got))
+		_ = conn.CloseWithError(0, "")
+		cancel()
+
+		stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+		require.NoError(t, s.Stop(stopCtx))
+		cancel()
+	}
+}
+
+func TestServer_RawQUICDrain(t *testing.T) {
+	cert := selfSignedCert(t)
+	drained := make(chan struct{})
+	s := NewServer(ConnHandlerFunc(func(ctx context.Context, conn *quic.Conn) error {
+		<-ctx.Done() // Stop cancels ctx before closing connections
+		close(drained)
+		return nil
+	}),
+		WithAddr("127.0.0.1:19443"),
+		WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{testProto}}),
+	)
+	ctx := context.Background()
+	require.NoError(t, s.Start(ctx))
+
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	conn, err := quic.DialAddr(dialCtx, "127.0.0.1:19443", clientTLS(testProto), nil)
+	require.NoError(t, err)
+
+	// Wait until the server has accepted the connection.
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.conns) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stopCtx, stopCancel := context.WithTimeout(ctx, time.Second)
+	defer stopCancel()
+	require.NoError(t, s.Stop(stopCtx))
+	<-drained
+
+	// The handler returned, so the server closed the connection.
+	select {
+	case <-conn.Context().Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection not closed after drain")
+	}
+}
+
+func TestServer_RawQUICForceClose(t *testing.T) {
+	cert := selfSignedCert(t)
+	s := NewServer(ConnHandlerFunc(func(_ context.Context, conn *quic.Conn) error {
+		<-conn.Context().Done() // ignores the drain signal
+		return nil
+	}),
+		WithAddr("127.0.0.1:19444"),
+		WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{testProto}}),
+	)
+	ctx := context.Background()
+	require.NoError(t, s.Start(ctx))
+
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	conn, err := quic.DialAddr(dialCtx, "127.0.0.1:19444", clientTLS(testProto), nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.conns) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stopCtx, stopCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer stopCancel()
+	assert.ErrorIs(t, s.Stop(stopCtx), context.DeadlineExceeded)
+
+	select {
+	case <-conn.Context().Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection not closed after stop deadline")
+	}
+}
+
+func TestServer_HTTP3(t *testing.T) {
+	cert := selfSignedCert(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	s := NewHTTP3Server(mux,
+		WithAddr("127.0.0.1:19445"),
+		WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}}),
+	)
+	ctx := context.Background()
+
+	for range 2 {
+		require.NoError(t, s.Start(ctx))
+
+		tr := &http3.Transport{TLSClientConfig: clientTLS()}
+		client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+		resp, err := client.Get("https://127.0.0.1:19445/ping")
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "HTTP/3.0", string(body))
+		_ = tr.Close()
+
+		stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+		require.NoError(t, s.Stop(stopCtx))
+		cancel()
+	}
+}
@@ -0,0 +1,407 @@
+package sse
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kochabx/kit/log"
+)
+
+const (
+	defaultReplaySize   = 100
+	defaultClientBuffer = 64
+	defaultHeartbeat    = 15 * time.Second
+	defaultStreamParam  = "stream"
+)
+
+// ErrNoStreams is returned by the default stream resolver when the request
+// names no stream.
+var ErrNoStreams = errors.New("sse: no stream requested")
+
+// brokerConfig holds the builder state for NewBroker.
+type brokerConfig struct {
+	replaySize   int
+	clientBuffer int
+	heartbeat    time.Duration
+	retry        time.Duration
+	streams      func(*http.Request) ([]string, error)
+	onConnect    func(r *http.Request, streams []string)
+	onDisconnect func(r *http.Request, streams []string)
+}
+
+// BrokerOption configures a Broker.
+type BrokerOption func(*brokerConfig)
+
+// WithReplaySize sets how many recent events each stream keeps for clients
+// that reconnect with Last-Event-ID, 100 by default. Zero disables replay.
+func WithReplaySize(n int) BrokerOption {
+	return func(c *brokerConfig) {
+		if n >= 0 {
+			c.replaySize = n
+		}
+	}
+}
+
+// WithClientBuffer sets the per-client queue length. A client whose queue
+// fills up is disconnected as a slow consumer and resumes through replay
+// when it reconnects.
+func WithClientBuffer(n int) BrokerOption {
+	return func(c *brokerConfig) {
+		if n > 0 {
+			c.clientBuffer = n
+		}
+	}
+}
+
+// WithHeartbeat sets the interval of the comment lines sent to keep idle
+// connections open through proxies, 15s by default. A non-positive interval
+// disables heartbeats.
+func WithHeartbeat(d time.Duration) BrokerOption {
+	return func(c *brokerConfig) { c.heartbeat = d }
+}
+
+// WithRetry sends a retry field when a client connects, telling it how long
+// to wait before reconnecting.
+func WithRetry(d time.Duration) BrokerOption {
+	return func(c *brokerConfig) { c.retry = d }
+}
+
+// WithStreams sets the function that maps a request to the streams it
+// subscribes to; this is also the place for authorization. An error rejects
+// the request with 403 and the error text, except ErrNoStreams and an empty
+// result, which are answered with 400. By default the streams are taken
+// from the repeated "stream" query parameter.
+func WithStreams(fn func(*http.Request) ([]string, error)) BrokerOption {
+	return func(c *brokerConfig) { c.streams = fn }
+}
+
+// WithOnConnect registers a hook that runs after a client has subscribed.
+func WithOnConnect(fn func(r *http.Request, streams []string)) BrokerOption {
+	return func(c *brokerConfig) { c.onConnect = fn }
+}
+
+// WithOnDisconnect registers a hook that runs after a client has been
+// unsubscribed.
+func WithOnDisconnect(fn func(r *http.Request, streams []string)) BrokerOption {
+	return func(c *brokerConfig) { c.onDisconnect = fn }
+}
+
+// queryStreams is the default stream resolver.
+func queryStreams(r *http.Request) ([]string, error) {
+	streams := r.URL.Query()[defaultStreamParam]
+	if len(streams) == 0 {
+		return nil, ErrNoStreams
+	}
+	return streams, nil
+}
+
+// Broker fans published events out to the clients subscribed to each named
+// stream. A Broker is an http.Handler and can be mounted on any router, e.g.
+// gin via gin.WrapH(broker), or served on its own port by Server.
+//
+// Event IDs are assigned by the Broker from a sequence shared by all
+// streams, so a single Last-Event-ID resumes every stream a client
+// subscribes to. IDs carry the Broker's start time: after a restart the
+// history is gone, and clients presenting an old ID receive ResetEvent.
+type Broker struct {
+	cfg   brokerConfig
+	epoch string
+
+	mu      sync.Mutex
+	seq     uint64
+	streams map[string]*stream
+	clients map[*client]struct{}
+	closed  bool
+	wg      sync.WaitGroup // one per connected client
+}
+
+// stream is the history and subscribers of one named stream.
+type stream struct {
+	history []record
+	evicted uint64 // sequence of the newest event dropped from history
+	subs    map[*client]struct{}
+}
+
+type record struct {
+	seq   uint64
+	event Event
+}
+
+// client is one connected event stream.
+type client struct {
+	ch       chan Event
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func (c *client) close() { c.doneOnce.Do(func() { close(c.done) }) }
+
+// NewBroker creates a Broker.
+func NewBroker(opts ...BrokerOption) *Broker {
+	cfg := brokerConfig{
+		replaySize:   defaultReplaySize,
+		clientBuffer: defaultClientBuffer,
+		heartbeat:    defaultHeartbeat,
+		streams:      queryStreams,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Broker{
+		cfg:     cfg,
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		streams: make(map[string]*stream),
+		clients: make(map[*client]struct{}),
+	}
+}
+
+// Publish assigns the next ID to event, records it in the stream's history
+// and queues it on every subscriber. It returns the ID. Subscribers whose
+// queue is full are disconnected rather than delaying the others.
+func (b *Broker) Publish(name string, event Event) string {
+	b.mu.Lock()
+	b.seq++
+	event.ID = b.epoch + "-" + strconv.FormatUint(b.seq, 10)
+	s := b.streamLocked(name)
+	if b.cfg.replaySize > 0 {
+		s.history = append(s.history, record{seq: b.seq, event: event})
+		if over := len(s.history) - b.cfg.replaySize; over > 0 {
+			s.evicted = s.history[over-1].seq
+			s.history = append(s.history[:0:0], s.history[over:]...)
+		}
+	} else {
+		s.evicted = b.seq
+	}
+	subs := make([]*client, 0, len(s.subs))
+	for c := range s.subs {
+		subs = append(subs, c)
+	}
+	b.mu.Unlock()
+
+	for _, c := range subs {
+		select {
+		case c.ch <- event:
+		default:
+			log.Warn().Str("stream", name).Msg("sse: slow consumer disconnected")
+			c.close()
+		}
+	}
+	return event.ID
+}
+
+// Len returns the number of connected clients.
+func (b *Broker) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients)
+}
+
+// StreamLen returns the number of clients subscribed to stream.
+func (b *Broker) StreamLen(name string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, ok := b.streams[name]; ok {
+		return len(s.subs)
+	}
+	return 0
+}
+
+// ServeHTTP subscribes the request to its streams, replays the events after
+// Last-Event-ID and streams live events until the client goes away. After
+// Shutdown new requests are rejected with 503.
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	names, err := b.cfg.streams(r)
+	if err != nil && !errors.Is(err, ErrNoStreams) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if len(names) == 0 {
+		http.Error(w, ErrNoStreams.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c := &client{ch: make(chan Event, b.cfg.clientBuffer), done: make(chan struct{})}
+	replay, reset, ok := b.subscribe(c, names, r.Header.Get("Last-Event-ID"))
+	if !ok {
+		http.Error(w, "sse: server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer b.wg.Done()
+	if b.cfg.onDisconnect != nil {
+		defer b.cfg.onDisconnect(r, names)
+	}
+	defer b.unsubscribe(c, names)
+	if b.cfg.onConnect != nil {
+		b.cfg.onConnect(r, names)
+	}
+
+	rc := http.NewResponseController(w)
+	// Streams outlive any write timeout of the hosting server.
+	_ = rc.SetWriteDeadline(time.Time{})
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if b.cfg.retry > 0 {
+		if _, err := w.Write([]byte("retry: " + strconv.FormatInt(b.cfg.retry.Milliseconds(), 10) + "\n\n")); err != nil {
+			return
+		}
+	}
+	if reset {
+		if _, err := (Event{Event: ResetEvent}).WriteTo(w); err != nil {
+			return
+		}
+	}
+	for _, e := range replay {
+		if _, err := e.WriteTo(w); err != nil {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	var heartbeat <-chan time.Time
+	if b.cfg.heartbeat > 0 {
+		t := time.NewTicker(b.cfg.heartbeat)
+		defer t.Stop()
+		heartbeat = t.C
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.done:
+			return
+		case e := <-c.ch:
+			if _, err := e.WriteTo(w); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if _, err := w.Write([]byte(": ping\n\n")); err != nil {
+				return
+			}
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// Shutdown stops accepting clients, ends every open stream and waits for
+// the handlers to return or for ctx to be done. EventSource clients
+// reconnect on their own and resume through Last-Event-ID.
+func (b *Broker) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	for c := range b.clients {
+		c.close()
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reopen accepts clients again after Shutdown, so a Server can restart.
+func (b *Broker) reopen() {
+	b.mu.Lock()
+	b.closed = false
+	b.mu.Unlock()
+}
+
+// subscribe registers c on names and returns the events to replay after
+// lastID, in publication order. Registration and the history snapshot
+// happen under one lock, so no event is missed or delivered twice.
+func (b *Broker) subscribe(c *client, names []string, lastID string) (replay []Event, reset, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, false, false
+	}
+	b.clients[c] = struct{}{}
+	b.wg.Add(1)
+	for _, name := range names {
+		b.streamLocked(name).subs[c] = struct{}{}
+	}
+
+	if lastID == "" {
+		return nil, false, true
+	}
+	last, known := b.parseIDLocked(lastID)
+	if !known {
+		return nil, true, true
+	}
+	var records []record
+	for _, name := range names {
+		s := b.streams[name]
+		if s.evicted > last {
+			return nil, true, true
+		}
+		for _, rec := range s.history {
+			if rec.seq > last {
+				records = append(records, rec)
+			}
+		}
+	}
+	// Merge the streams back into publication order.
+	slices.SortFunc(records, func(x, y record) int { return cmp.Compare(x.seq, y.seq) })
+	replay = make([]Event, len(records))
+	for i, rec := range records {
+		replay[i] = rec.event
+	}
+	return replay, false, true
+}
+
+func (b *Broker) unsubscribe(c *client, names []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.clients, c)
+	for _, name := range names {
+		if s, ok := b.streams[name]; ok {
+			delete(s.subs, c)
+		}
+	}
+}
+
+// streamLocked returns the named stream, creating it on first use.
+func (b *Broker) streamLocked(name string) *stream {
+	s, ok := b.streams[name]
+	if !ok {
+		s = &stream{subs: make(map[*client]struct{})}
+		b.streams[name] = s
+	}
+	return s
+}
+
+// parseIDLocked returns the sequence of an ID issued by this Broker.
+func (b *Broker) parseIDLocked(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil || n > b.seq {
+		return 0, false
+	}
+	return n, true
+}
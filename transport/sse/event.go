@@ -0,0 +1,69 @@
+// Package sse provides a Server-Sent Events transport for one-way push: a
+// Broker that serves named event streams with Last-Event-ID replay, and a
+// Server that serves a Broker on its own port as a transport.Server.
+//
+// Browsers consume the streams with the built-in EventSource, which
+// reconnects automatically and sends the ID of the last event it received:
+//
+//	const es = new EventSource("/events?stream=orders&stream=alerts");
+//	es.addEventListener("order.created", e => render(JSON.parse(e.data)));
+package sse
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ResetEvent is the event name sent when a reconnecting client's
+// Last-Event-ID can no longer be replayed, because the events after it have
+// been evicted from the history or the ID belongs to a previous Broker
+// (e.g. before a restart). The client should reload its state; live events
+// follow.
+const ResetEvent = "reset"
+
+// Event is a single server-sent event.
+type Event struct {
+	// ID is assigned by Broker.Publish; clients send it back as
+	// Last-Event-ID when they reconnect.
+	ID string
+	// Event is the event name; empty means the default "message" event.
+	Event string
+	// Data is the payload. Multi-line data is sent as multiple data lines
+	// and reassembled by the client.
+	Data []byte
+	// Retry, when positive, tells the client how long to wait before
+	// reconnecting.
+	Retry time.Duration
+}
+
+// WriteTo writes e in the text/event-stream format.
+func (e Event) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: ")
+		buf.WriteString(e.ID)
+		buf.WriteByte('\n')
+	}
+	if e.Event != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(e.Event)
+		buf.WriteByte('\n')
+	}
+	if e.Retry > 0 {
+		buf.WriteString("retry: ")
+		buf.WriteString(strconv.FormatInt(e.Retry.Milliseconds(), 10))
+		buf.WriteByte('\n')
+	}
+	// CR, LF and CRLF all end a line in the event stream.
+	data := bytes.ReplaceAll(e.Data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	for line := range bytes.SplitSeq(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.WriteTo(w)
+}
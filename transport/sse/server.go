@@ -0,0 +1,100 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/kochabx/kit/transport"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+var (
+	_ transport.Server = (*Server)(nil)
+	_ transport.Failer = (*Server)(nil)
+)
+
+const (
+	defaultName = "sse"
+	defaultAddr = ":8082"
+	defaultPath = "/events"
+)
+
+// Server serves a Broker on a dedicated listener. Stop ends every open
+// stream before shutting the listener down, which http.Server.Shutdown alone
+// does not do for long-lived responses.
+type Server struct {
+	broker *Broker
+	srv    *kithttp.Server
+}
+
+// config holds the builder state for NewServer.
+type config struct {
+	addr string
+	name string
+	path string
+}
+
+// Option configures a Server.
+type Option func(*config)
+
+// WithAddr sets the TCP address the server listens on (e.g. ":8082").
+func WithAddr(addr string) Option {
+	return func(c *config) { c.addr = addr }
+}
+
+// WithName sets the server name, used in log output.
+func WithName(name string) Option {
+	return func(c *config) { c.name = name }
+}
+
+// WithPath sets the path the Broker is mounted on, "/events" by default.
+func WithPath(path string) Option {
+	return func(c *config) { c.path = path }
+}
+
+// NewServer creates a Server for broker:
+//
+//	broker := sse.NewBroker(sse.WithReplaySize(500))
+//	srv := sse.NewServer(broker, sse.WithAddr(":8082"))
+//	app.New(app.WithServer(srv))
+//
+//	broker.Publish("orders", sse.Event{Event: "order.created", Data: payload})
+//
+// Read and write timeouts of the underlying HTTP server are disabled; the
+// Broker's heartbeat keeps idle streams alive instead.
+func NewServer(broker *Broker, opts ...Option) *Server {
+	cfg := config{addr: defaultAddr, name: defaultName, path: defaultPath}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.path, broker)
+	return &Server{
+		broker: broker,
+		srv: kithttp.NewServer(mux,
+			kithttp.WithAddr(cfg.addr),
+			kithttp.WithName(cfg.name),
+			kithttp.WithTimeout(0, 0, 0),
+		),
+	}
+}
+
+// Broker returns the Broker served by s.
+func (s *Server) Broker() *Broker { return s.broker }
+
+// Start implements cx.Starter, starting the server in the background and
+// returning immediately. A stopped Server can be started again.
+func (s *Server) Start(ctx context.Context) error {
+	s.broker.reopen()
+	return s.srv.Start(ctx)
+}
+
+// Failed implements transport.Failer.
+func (s *Server) Failed() <-chan error { return s.srv.Failed() }
+
+// Stop ends all streams (see Broker.Shutdown), then stops the listener.
+func (s *Server) Stop(ctx context.Context) error {
+	return errors.Join(s.broker.Shutdown(ctx), s.srv.Stop(ctx))
+}
@@ -0,0 +1,208 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openStream opens an event stream and returns a reader for its events.
+func openStream(t *testing.T, url, lastID string) (*http.Response, func() Event) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cancel()
+		resp.Body.Close()
+	})
+
+	events := make(chan Event, 16)
+	go func() {
+		defer close(events)
+		br := bufio.NewReader(resp.Body)
+		var e Event
+		var data [][]byte
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "":
+				if data != nil {
+					e.Data = bytes.Join(data, []byte("\n"))
+					events <- e
+				}
+				e, data = Event{}, nil
+			case "id":
+				e.ID = value
+			case "event":
+				e.Event = value
+			case "data":
+				data = append(data, []byte(value))
+			}
+		}
+	}()
+	return resp, func() Event {
+		t.Helper()
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return Event{}
+			}
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for event")
+			return Event{}
+		}
+	}
+}
+
+func TestEvent_WriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := Event{ID: "1", Event: "update", Data: []byte("a\r\nb\rc"), Retry: 3 * time.Second}.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "id: 1\nevent: update\nretry: 3000\ndata: a\ndata: b\ndata: c\n\n", buf.String())
+}
+
+func TestBroker_PublishAndStreams(t *testing.T) {
+	broker := NewBroker()
+	srv := httptest.NewServer(broker)
+	t.Cleanup(srv.Close)
+
+	resp, nextOrders := openStream(t, srv.URL+"?stream=orders", "")
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	_, nextBoth := openStream(t, srv.URL+"?stream=orders&stream=alerts", "")
+	require.Eventually(t, func() bool { return broker.StreamLen("orders") == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, broker.StreamLen("alerts"))
+	assert.Equal(t, 2, broker.Len())
+
+	id := broker.Publish("alerts", Event{Event: "alert", Data: []byte("disk\nfull")})
+	broker.Publish("orders", Event{Event: "order.created", Data: []byte(`{"id":1}`)})
+
+	e := nextBoth()
+	assert.Equal(t, id, e.ID)
+	assert.Equal(t, "alert", e.Event)
+	assert.Equal(t, "disk\nfull", string(e.Data))
+	assert.Equal(t, "order.created", nextBoth().Event)
+	assert.Equal(t, `{"id":1}`, string(nextOrders().Data))
+}
+
+func TestBroker_Replay(t *testing.T) {
+	broker := NewBroker()
+	srv := httptest.NewServer(broker)
+	t.Cleanup(srv.Close)
+	url := srv.URL + "?stream=a&stream=b"
+
+	resp, next := openStream(t, url, "")
+	require.Eventually(t, func() bool { return broker.Len() == 1 }, time.Second, 5*time.Millisecond)
+	broker.Publish("a", Event{Data: []byte("1")})
+	last := next()
+	resp.Body.Close()
+	require.Eventually(t, func() bool { return broker.Len() == 0 }, time.Second, 5*time.Millisecond)
+
+	// Missed while disconnected, across both streams and interleaved with
+	// a stream the client does not follow.
+	broker.Publish("b", Event{Data: []byte("2")})
+	broker.Publish("c", Event{Data: []byte("x")})
+	broker.Publish("a", Event{Data: []byte("3")})
+
+	_, next = openStream(t, url, last.ID)
+	assert.Equal(t, "2", string(next().Data))
+	assert.Equal(t, "3", string(next().Data))
+	require.Eventually(t, func() bool { return broker.Len() == 1 }, time.Second, 5*time.Millisecond)
+	broker.Publish("b", Event{Data: []byte("4")})
+	assert.Equal(t, "4", string(next().Data))
+}
+
+func TestBroker_Reset(t *testing.T) {
+	broker := NewBroker(WithReplaySize(2))
+	srv := httptest.NewServer(broker)
+	t.Cleanup(srv.Close)
+	url := srv.URL + "?stream=a"
+
+	first := broker.Publish("a", Event{Data: []byte("1")})
+	for range 3 {
+		broker.Publish("a", Event{Data: []byte("n")})
+	}
+
+	// The events after first have been evicted.
+	_, next := openStream(t, url, first)
+	assert.Equal(t, ResetEvent, next().Event)
+
+	// An ID from a previous Broker.
+	_, next = openStream(t, url, "old-3")
+	assert.Equal(t, ResetEvent, next().Event)
+	require.Eventually(t, func() bool { return broker.StreamLen("a") == 2 }, time.Second, 5*time.Millisecond)
+	broker.Publish("a", Event{Data: []byte("live")})
+	assert.Equal(t, "live", string(next().Data))
+}
+
+func TestBroker_Rejected(t *testing.T) {
+	errDenied := errors.New("not allowed")
+	broker := NewBroker(WithStreams(func(r *http.Request) ([]string, error) {
+		if r.Header.Get("Authorization") == "" {
+			return nil, errDenied
+		}
+		return queryStreams(r)
+	}))
+	srv := httptest.NewServer(broker)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "?stream=a")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, string(body), errDenied.Error())
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Authorization", "Bearer x")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_StopEndsStreams(t *testing.T) {
+	disconnected := make(chan []string, 1)
+	broker := NewBroker(WithOnDisconnect(func(_ *http.Request, streams []string) { disconnected <- streams }))
+	s := NewServer(broker, WithAddr("127.0.0.1:19082"))
+	require.NoError(t, s.Start(context.Background()))
+
+	resp, next := openStream(t, "http://127.0.0.1:19082/events?stream=a", "")
+	require.Eventually(t, func() bool { return broker.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Stop(ctx))
+	assert.Equal(t, Event{}, next(), "stream should end")
+	assert.Equal(t, []string{"a"}, <-disconnected)
+	resp.Body.Close()
+
+	// A stopped Server can be started again.
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	_, next = openStream(t, "http://127.0.0.1:19082/events?stream=a", "")
+	require.Eventually(t, func() bool { return broker.Len() == 1 }, time.Second, 5*time.Millisecond)
+	broker.Publish("a", Event{Data: []byte("again")})
+	assert.Equal(t, "again", string(next().Data))
+}
@@ -20,6 +20,15 @@ type Server interface {
 	Stop(ctx context.Context) error
 }
 
+// Failer is optionally implemented by servers whose background serve loop
+// can fail after Start has returned, e.g. when the listener is closed by the
+// operating system. Failed returns a channel for the current run that
+// receives at most one such error; a graceful Stop is never reported.
+// After a failure the serve loop has exited and Start may be called again.
+type Failer interface {
+	Failed() <-chan error
+}
+
 // ValidAddress checks whether addr is a syntactically valid host:port pair
 // with a port in [1, 65535]. Host validity is left to net.Listen at runtime.
 func ValidAddress(addr string) bool {
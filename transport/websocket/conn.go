@@ -0,0 +1,247 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+
+	"github.com/kochabx/kit/core/wsx"
+)
+
+var (
+	// ErrConnClosed is returned by Send on a closed connection.
+	ErrConnClosed = errors.New("websocket: connection closed")
+	// ErrSendQueueFull is returned by Send when the connection's send queue
+	// is full. The connection is closed, as the peer cannot keep up.
+	ErrSendQueueFull = errors.New("websocket: send queue full")
+)
+
+// closeGrace bounds how long a closing connection waits for the peer's
+// close frame before the underlying network connection is torn down.
+const closeGrace = time.Second
+
+// Conn is a server-side WebSocket connection managed by a Hub. Send and the
+// room / value helpers are safe for concurrent use.
+type Conn struct {
+	id  string
+	hub *Hub
+	ws  *gws.Conn
+	req *http.Request
+
+	send   chan wsx.Message
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	rpc *wsx.Correlator
+
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+	writeDone chan struct{}
+
+	mu     sync.Mutex
+	rooms  map[string]struct{}
+	values map[string]any
+}
+
+// ID returns the connection's unique identifier within its Hub.
+func (c *Conn) ID() string { return c.id }
+
+// Request returns the HTTP request that was upgraded to this connection.
+func (c *Conn) Request() *http.Request { return c.req }
+
+// ResumeToken returns the resume token the client sent during the upgrade
+// (wsx.ResumeTokenHeader), or "" for a new session.
+func (c *Conn) ResumeToken() string { return c.req.Header.Get(wsx.ResumeTokenHeader) }
+
+// Context returns a context that is cancelled when the connection closes.
+// It carries the values of the upgrade request's context.
+func (c *Conn) Context() context.Context { return c.ctx }
+
+// Send queues a message for delivery without blocking. If the send queue is
+// full the connection is closed and ErrSendQueueFull is returned.
+func (c *Conn) Send(messageType wsx.MessageType, data []byte) error {
+	if c.ctx.Err() != nil {
+		return ErrConnClosed
+	}
+	select {
+	case c.send <- wsx.Message{Type: messageType, Data: data}:
+		return nil
+	case <-c.ctx.Done():
+		return ErrConnClosed
+	default:
+		c.closeWith(gws.CloseTryAgainLater, "send queue full")
+		return ErrSendQueueFull
+	}
+}
+
+// SendText queues a text message.
+func (c *Conn) SendText(text string) error { return c.Send(wsx.TextMessage, []byte(text)) }
+
+// SendBinary queues a binary message.
+func (c *Conn) SendBinary(data []byte) error { return c.Send(wsx.BinaryMessage, data) }
+
+// SendRequest sends a request to the peer and waits for its response; it is
+// safe for concurrent use. The peer answers through its request handler
+// (wsx.WithRequestHandler on the client). If ctx has no deadline the Hub's
+// request timeout applies. A remote failure is returned as *wsx.RemoteError.
+func (c *Conn) SendRequest(ctx context.Context, payload []byte) ([]byte, error) {
+	resp, err := c.rpc.Request(ctx, payload, c.sendText)
+	if errors.Is(err, wsx.ErrNotConnected) {
+		return nil, ErrConnClosed
+	}
+	return resp, err
+}
+
+func (c *Conn) sendText(data []byte) error { return c.Send(wsx.TextMessage, data) }
+
+// handleRequest adapts the Hub's request handler to wsx.RequestHandler.
+func (c *Conn) handleRequest(ctx context.Context, payload []byte) ([]byte, error) {
+	if c.hub.cfg.onRequest == nil {
+		return nil, errors.New("no request handler")
+	}
+	return c.hub.cfg.onRequest(ctx, c, payload)
+}
+
+// Close flushes queued messages and closes the connection with a normal
+// closure frame. It does not wait for the connection to be torn down.
+func (c *Conn) Close() error {
+	c.closeWith(gws.CloseNormalClosure, "")
+	return nil
+}
+
+// Join adds the connection to room. Joining after the connection has been
+// removed from its Hub is a no-op.
+func (c *Conn) Join(room string) { c.hub.join(c, room) }
+
+// Leave removes the connection from room.
+func (c *Conn) Leave(room string) { c.hub.leave(c, room) }
+
+// Rooms returns the rooms the connection has joined.
+func (c *Conn) Rooms() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for r := range c.rooms {
+		rooms = append(rooms, r)
+	}
+	return rooms
+}
+
+// Set stores a value on the connection, e.g. the authenticated user.
+func (c *Conn) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]any)
+	}
+	c.values[key] = value
+}
+
+// Get returns a value stored with Set.
+func (c *Conn) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// closeWith records the close frame to send and signals the write loop.
+// Only the first call takes effect.
+func (c *Conn) closeWith(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode, c.closeText = code, text
+		c.cancel()
+	})
+}
+
+// readLoop delivers inbound messages until the connection fails or closes.
+func (c *Conn) readLoop() error {
+	cfg := &c.hub.cfg
+	c.ws.SetReadLimit(cfg.maxMessageSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(cfg.pongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(cfg.pongWait))
+	})
+
+	for {
+		typ, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if typ == gws.TextMessage && c.rpc.Handle(c.ctx, data, c.handleRequest, c.sendText) {
+			continue
+		}
+		msg := wsx.Message{Type: wsx.MessageType(typ), Data: data}
+		if c.handleTopic(msg) {
+			continue
+		}
+		if cfg.onMessage != nil {
+			cfg.onMessage(c, msg)
+		}
+	}
+}
+
+// writeLoop owns all data writes to the connection. On close it flushes the
+// queued messages within one write timeout, sends the close frame and gives
+// the peer closeGrace to answer before the read loop is unblocked.
+func (c *Conn) writeLoop() {
+	defer close(c.writeDone)
+	cfg := &c.hub.cfg
+
+	var ping <-chan time.Time
+	if cfg.pingInterval > 0 {
+		t := time.NewTicker(cfg.pingInterval)
+		defer t.Stop()
+		ping = t.C
+	}
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(cfg.writeTimeout))
+			if err := c.ws.WriteMessage(int(msg.Type), msg.Data); err != nil {
+				c.closeWith(gws.CloseAbnormalClosure, "")
+				_ = c.ws.Close()
+				return
+			}
+		case <-ping:
+			if err := c.ws.WriteControl(gws.PingMessage, nil, time.Now().Add(cfg.writeTimeout)); err != nil {
+				c.closeWith(gws.CloseAbnormalClosure, "")
+				_ = c.ws.Close()
+				return
+			}
+		case <-c.ctx.Done():
+			c.flush()
+			return
+		}
+	}
+}
+
+func (c *Conn) flush() {
+	deadline := time.Now().Add(c.hub.cfg.writeTimeout)
+	if c.closeCode != gws.CloseTryAgainLater && c.closeCode != gws.CloseAbnormalClosure {
+		_ = c.ws.SetWriteDeadline(deadline)
+	drain:
+		for {
+			select {
+			case msg := <-c.send:
+				if err := c.ws.WriteMessage(int(msg.Type), msg.Data); err != nil {
+					break drain
+				}
+			default:
+				break drain
+			}
+		}
+	}
+	frame := gws.FormatCloseMessage(c.closeCode, c.closeText)
+	if err := c.ws.WriteControl(gws.CloseMessage, frame, deadline); err != nil {
+		_ = c.ws.Close()
+		return
+	}
+	_ = c.ws.SetReadDeadline(time.Now().Add(closeGrace))
+}
@@ -0,0 +1,420 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	gws "github.com/gorilla/websocket"
+
+	"github.com/kochabx/kit/core/wsx"
+	"github.com/kochabx/kit/log"
+)
+
+const (
+	defaultSendQueueSize  = 256
+	defaultMaxMessageSize = 1 << 20
+	defaultWriteTimeout   = 10 * time.Second
+	defaultPongWait       = 60 * time.Second
+	defaultPingInterval   = 54 * time.Second
+	defaultBufferSize     = 4096
+)
+
+// hubConfig holds the builder state for NewHub.
+type hubConfig struct {
+	sendQueueSize   int
+	maxMessageSize  int64
+	writeTimeout    time.Duration
+	pongWait        time.Duration
+	pingInterval    time.Duration
+	readBufferSize  int
+	writeBufferSize int
+	compression     bool
+	checkOrigin     func(*http.Request) bool
+
+	requestTimeout time.Duration
+	topicCodec     wsx.TopicCodec
+	topics         map[string]topicHandler
+
+	onConnect    func(*Conn) error
+	onResume     func(*Conn, string) error
+	onMessage    func(*Conn, wsx.Message)
+	onRequest    func(context.Context, *Conn, []byte) ([]byte, error)
+	onDisconnect func(*Conn, error)
+}
+
+// HubOption configures a Hub.
+type HubOption func(*hubConfig)
+
+func defaultHubConfig() hubConfig {
+	return hubConfig{
+		sendQueueSize:   defaultSendQueueSize,
+		maxMessageSize:  defaultMaxMessageSize,
+		writeTimeout:    defaultWriteTimeout,
+		pongWait:        defaultPongWait,
+		pingInterval:    defaultPingInterval,
+		readBufferSize:  defaultBufferSize,
+		writeBufferSize: defaultBufferSize,
+		topicCodec:      wsx.JSONTopicCodec(),
+	}
+}
+
+// WithSendQueueSize sets the per-connection send queue length. A connection
+// whose queue fills up is closed as a slow consumer.
+func WithSendQueueSize(n int) HubOption {
+	return func(c *hubConfig) {
+		if n > 0 {
+			c.sendQueueSize = n
+		}
+	}
+}
+
+// WithMaxMessageSize limits the size of inbound messages in bytes.
+func WithMaxMessageSize(n int64) HubOption {
+	return func(c *hubConfig) { c.maxMessageSize = n }
+}
+
+// WithWriteTimeout bounds each write to a connection.
+func WithWriteTimeout(d time.Duration) HubOption {
+	return func(c *hubConfig) { c.writeTimeout = d }
+}
+
+// WithKeepalive sets the ping interval and how long to wait for any inbound
+// frame (including pongs) before the connection is considered dead. The
+// interval should be shorter than pongWait; a non-positive interval disables
+// pings.
+func WithKeepalive(pingInterval, pongWait time.Duration) HubOption {
+	return func(c *hubConfig) {
+		c.pingInterval = pingInterval
+		c.pongWait = pongWait
+	}
+}
+
+// WithBufferSizes sets the upgrader's read and write buffer sizes.
+func WithBufferSizes(read, write int) HubOption {
+	return func(c *hubConfig) {
+		c.readBufferSize = read
+		c.writeBufferSize = write
+	}
+}
+
+// WithCompression negotiates per-message compression with clients that
+// support it.
+func WithCompression() HubOption {
+	return func(c *hubConfig) { c.compression = true }
+}
+
+// WithCheckOrigin sets the origin check used during the upgrade. By default
+// only same-origin requests (or requests without an Origin header) are
+// accepted.
+func WithCheckOrigin(fn func(*http.Request) bool) HubOption {
+	return func(c *hubConfig) { c.checkOrigin = fn }
+}
+
+// WithOnConnect registers a hook that runs after the upgrade, before any
+// inbound message is read. Returning an error closes the connection with a
+// policy-violation frame carrying the error text, e.g. for failed auth.
+func WithOnConnect(fn func(*Conn) error) HubOption {
+	return func(c *hubConfig) { c.onConnect = fn }
+}
+
+// WithOnResume registers a hook for clients that reconnect with a resume
+// token (see wsx.WithResumeToken), e.g. to restore subscriptions or room
+// membership of the previous session. It runs before the OnConnect hook.
+// Returning an error closes the connection like an OnConnect error; return
+// nil for unknown or expired tokens to treat the connection as a new session.
+func WithOnResume(fn func(c *Conn, token string) error) HubOption {
+	return func(c *hubConfig) { c.onResume = fn }
+}
+
+// WithOnMessage registers the inbound message handler. It runs on the
+// connection's read goroutine, so messages of one connection are handled in
+// order and a slow handler delays only that connection.
+func WithOnMessage(fn func(*Conn, wsx.Message)) HubOption {
+	return func(c *hubConfig) { c.onMessage = fn }
+}
+
+// WithOnRequest registers the handler for requests the peer sends with
+// SendRequest (see wsx.Client.SendRequest). Each request runs on its own
+// goroutine; ctx is cancelled when the connection closes. A returned error
+// is delivered to the requester as a *wsx.RemoteError. Without a handler,
+// requests are answered with an error.
+func WithOnRequest(fn func(ctx context.Context, c *Conn, payload []byte) ([]byte, error)) HubOption {
+	return func(c *hubConfig) { c.onRequest = fn }
+}
+
+// WithRequestTimeout sets the timeout Conn.SendRequest applies when its
+// context has no deadline, wsx.DefaultRequestTimeout by default.
+func WithRequestTimeout(d time.Duration) HubOption {
+	return func(c *hubConfig) { c.requestTimeout = d }
+}
+
+// WithOnDisconnect registers a hook that runs after the connection has been
+// removed from the Hub and all its rooms. err is the read error that ended
+// the connection; it is a *websocket.CloseError when the peer closed it.
+func WithOnDisconnect(fn func(*Conn, error)) HubOption {
+	return func(c *hubConfig) { c.onDisconnect = fn }
+}
+
+// Hub upgrades HTTP requests to WebSocket connections and tracks them for
+// broadcast and room delivery. A Hub is an http.Handler and can be mounted on
+// any router, e.g. gin via gin.WrapH(hub), or served on its own port by
+// Server.
+type Hub struct {
+	cfg      hubConfig
+	upgrader gws.Upgrader
+
+	mu     sync.RWMutex
+	conns  map[string]*Conn
+	rooms  map[string]map[string]*Conn
+	closed bool
+	wg     sync.WaitGroup // one per live connection
+}
+
+// NewHub creates a Hub.
+func NewHub(opts ...HubOption) *Hub {
+	cfg := defaultHubConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Hub{
+		cfg: cfg,
+		upgrader: gws.Upgrader{
+			ReadBufferSize:    cfg.readBufferSize,
+			WriteBufferSize:   cfg.writeBufferSize,
+			EnableCompression: cfg.compression,
+			CheckOrigin:       cfg.checkOrigin,
+		},
+		conns: make(map[string]*Conn),
+		rooms: make(map[string]map[string]*Conn),
+	}
+}
+
+// ServeHTTP upgrades the request and serves the connection until it closes.
+// After Shutdown new requests are rejected with 503.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		http.Error(w, "websocket: server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written the error response.
+		log.Debug().Err(err).Msg("websocket upgrade failed")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	c := &Conn{
+		id:        uuid.NewString(),
+		hub:       h,
+		ws:        ws,
+		req:       r,
+		send:      make(chan wsx.Message, h.cfg.sendQueueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+		writeDone: make(chan struct{}),
+		rpc:       wsx.NewCorrelator(h.cfg.requestTimeout),
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		cancel()
+		_ = ws.WriteControl(gws.CloseMessage, gws.FormatCloseMessage(gws.CloseGoingAway, "server shutting down"), time.Now().Add(h.cfg.writeTimeout))
+		_ = ws.Close()
+		return
+	}
+	h.conns[c.id] = c
+	h.wg.Add(1)
+	h.mu.Unlock()
+	defer h.wg.Done()
+
+	go c.writeLoop()
+
+	var readErr error
+	if token := c.ResumeToken(); token != "" && h.cfg.onResume != nil {
+		if err := h.cfg.onResume(c, token); err != nil {
+			c.closeWith(gws.ClosePolicyViolation, err.Error())
+			readErr = err
+		}
+	}
+	if readErr == nil && h.cfg.onConnect != nil {
+		if err := h.cfg.onConnect(c); err != nil {
+			c.closeWith(gws.ClosePolicyViolation, err.Error())
+			readErr = err
+		}
+	}
+	if readErr == nil {
+		readErr = c.readLoop()
+	}
+
+	// Read side is done: let the write loop send its close frame, then tear
+	// down the network connection.
+	code := gws.CloseNormalClosure
+	if ce := (*gws.CloseError)(nil); errors.As(readErr, &ce) {
+		code = ce.Code
+	}
+	c.closeWith(code, "")
+	c.rpc.Fail()
+	<-c.writeDone
+	_ = ws.Close()
+
+	h.remove(c)
+	if h.cfg.onDisconnect != nil {
+		h.cfg.onDisconnect(c, readErr)
+	}
+}
+
+// Conn returns the live connection with the given ID.
+func (h *Hub) Conn(id string) (*Conn, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	c, ok := h.conns[id]
+	return c, ok
+}
+
+// Len returns the number of live connections.
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// RoomLen returns the number of connections in room.
+func (h *Hub) RoomLen(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Broadcast queues a message on every live connection and returns the
+// number of connections it was queued on. Slow consumers are closed rather
+// than delaying the others.
+func (h *Hub) Broadcast(messageType wsx.MessageType, data []byte) int {
+	h.mu.RLock()
+	targets := make([]*Conn, 0, len(h.conns))
+	for _, c := range h.conns {
+		targets = append(targets, c)
+	}
+	h.mu.RUnlock()
+	return sendAll(targets, messageType, data)
+}
+
+// BroadcastRoom is like Broadcast but only targets the connections in room.
+func (h *Hub) BroadcastRoom(room string, messageType wsx.MessageType, data []byte) int {
+	h.mu.RLock()
+	members := h.rooms[room]
+	targets := make([]*Conn, 0, len(members))
+	for _, c := range members {
+		targets = append(targets, c)
+	}
+	h.mu.RUnlock()
+	return sendAll(targets, messageType, data)
+}
+
+func sendAll(targets []*Conn, messageType wsx.MessageType, data []byte) int {
+	n := 0
+	for _, c := range targets {
+		if c.Send(messageType, data) == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Shutdown stops accepting connections, closes every live connection with a
+// going-away frame after flushing its queue, and waits for them to finish or
+// for ctx to be done, in which case the remaining connections are torn down
+// and ctx.Err() is returned.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*Conn, 0, len(h.conns))
+	for _, c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.closeWith(gws.CloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		for _, c := range conns {
+			_ = c.ws.Close()
+		}
+		return ctx.Err()
+	}
+}
+
+// reopen accepts connections again after Shutdown, so a Server can restart.
+func (h *Hub) reopen() {
+	h.mu.Lock()
+	h.closed = false
+	h.mu.Unlock()
+}
+
+func (h *Hub) join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conns[c.id] != c {
+		return
+	}
+	members, ok := h.rooms[room]
+	if !ok {
+		members = make(map[string]*Conn)
+		h.rooms[room] = members
+	}
+	members[c.id] = c
+
+	c.mu.Lock()
+	if c.rooms == nil {
+		c.rooms = make(map[string]struct{})
+	}
+	c.rooms[room] = struct{}{}
+	c.mu.Unlock()
+}
+
+func (h *Hub) leave(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leaveLocked(c, room)
+}
+
+func (h *Hub) leaveLocked(c *Conn, room string) {
+	if members, ok := h.rooms[room]; ok {
+		delete(members, c.id)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+	c.mu.Lock()
+	delete(c.rooms, room)
+	c.mu.Unlock()
+}
+
+// remove unregisters c from the Hub and all its rooms.
+func (h *Hub) remove(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, room := range c.Rooms() {
+		h.leaveLocked(c, room)
+	}
+	delete(h.conns, c.id)
+}
@@ -0,0 +1,101 @@
+// Package websocket provides a server-side WebSocket transport: a Hub that
+// upgrades requests and manages connections, broadcast and rooms, and a
+// Server that serves a Hub on its own port as a transport.Server.
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/kochabx/kit/transport"
+	kithttp "github.com/kochabx/kit/transport/http"
+)
+
+var (
+	_ transport.Server = (*Server)(nil)
+	_ transport.Failer = (*Server)(nil)
+)
+
+const (
+	defaultName = "websocket"
+	defaultAddr = ":8081"
+	defaultPath = "/ws"
+)
+
+// Server serves a Hub on a dedicated listener. Stop closes every connection
+// gracefully before shutting the listener down, which http.Server.Shutdown
+// alone does not do for hijacked connections.
+type Server struct {
+	hub *Hub
+	srv *kithttp.Server
+}
+
+// config holds the builder state for NewServer.
+type config struct {
+	addr string
+	name string
+	path string
+}
+
+// Option configures a Server.
+type Option func(*config)
+
+// WithAddr sets the TCP address the server listens on (e.g. ":8081").
+func WithAddr(addr string) Option {
+	return func(c *config) { c.addr = addr }
+}
+
+// WithName sets the server name, used in log output.
+func WithName(name string) Option {
+	return func(c *config) { c.name = name }
+}
+
+// WithPath sets the path the Hub is mounted on, "/ws" by default.
+func WithPath(path string) Option {
+	return func(c *config) { c.path = path }
+}
+
+// NewServer creates a Server for hub:
+//
+//	hub := websocket.NewHub(websocket.WithOnMessage(onMessage))
+//	srv := websocket.NewServer(hub, websocket.WithAddr(":8081"))
+//	app.New(app.WithServer(srv))
+//
+// Read and write timeouts of the underlying HTTP server are disabled; the
+// Hub's keepalive detects dead connections instead.
+func NewServer(hub *Hub, opts ...Option) *Server {
+	cfg := config{addr: defaultAddr, name: defaultName, path: defaultPath}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.path, hub)
+	return &Server{
+		hub: hub,
+		srv: kithttp.NewServer(mux,
+			kithttp.WithAddr(cfg.addr),
+			kithttp.WithName(cfg.name),
+			kithttp.WithTimeout(0, 0, 0),
+		),
+	}
+}
+
+// Hub returns the Hub served by s.
+func (s *Server) Hub() *Hub { return s.hub }
+
+// Start implements cx.Starter, starting the server in the background and
+// returning immediately. A stopped Server can be started again.
+func (s *Server) Start(ctx context.Context) error {
+	s.hub.reopen()
+	return s.srv.Start(ctx)
+}
+
+// Failed implements transport.Failer.
+func (s *Server) Failed() <-chan error { return s.srv.Failed() }
+
+// Stop closes all connections (see Hub.Shutdown), then stops the listener.
+func (s *Server) Stop(ctx context.Context) error {
+	return errors.Join(s.hub.Shutdown(ctx), s.srv.Stop(ctx))
+}
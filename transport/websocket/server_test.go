@@ -0,0 +1,321 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kochabx/kit/core/wsx"
+)
+
+func dial(t *testing.T, url string) *gws.Conn {
+	t.Helper()
+	ws, _, err := gws.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws
+}
+
+func readText(t *testing.T, ws *gws.Conn) string {
+	t.Helper()
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	typ, data, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, gws.TextMessage, typ)
+	return string(data)
+}
+
+func TestHub_BroadcastAndRooms(t *testing.T) {
+	connected := make(chan *Conn, 2)
+	hub := NewHub(
+		WithOnConnect(func(c *Conn) error {
+			if c.Request().URL.Query().Get("room") == "a" {
+				c.Join("a")
+			}
+			connected <- c
+			return nil
+		}),
+		WithOnMessage(func(c *Conn, msg wsx.Message) {
+			_ = c.Send(msg.Type, append([]byte("echo:"), msg.Data...))
+		}),
+	)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	inRoom := dial(t, srv.URL+"/?room=a")
+	<-connected
+	other := dial(t, srv.URL)
+	<-connected
+	assert.Equal(t, 2, hub.Len())
+	assert.Equal(t, 1, hub.RoomLen("a"))
+
+	require.NoError(t, other.WriteMessage(gws.TextMessage, []byte("hi")))
+	assert.Equal(t, "echo:hi", readText(t, other))
+
+	assert.Equal(t, 1, hub.BroadcastRoom("a", wsx.TextMessage, []byte("room")))
+	assert.Equal(t, "room", readText(t, inRoom))
+
+	assert.Equal(t, 2, hub.Broadcast(wsx.TextMessage, []byte("all")))
+	assert.Equal(t, "all", readText(t, inRoom))
+	assert.Equal(t, "all", readText(t, other))
+}
+
+func TestHub_OnConnectReject(t *testing.T) {
+	disconnected := make(chan error, 1)
+	hub := NewHub(
+		WithOnConnect(func(*Conn) error { return errors.New("unauthorized") }),
+		WithOnDisconnect(func(_ *Conn, err error) { disconnected <- err }),
+	)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	ws := dial(t, srv.URL)
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := ws.ReadMessage()
+	var ce *gws.CloseError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, gws.ClosePolicyViolation, ce.Code)
+	assert.Equal(t, "unauthorized", ce.Text)
+
+	select {
+	case err := <-disconnected:
+		assert.EqualError(t, err, "unauthorized")
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnDisconnect not called")
+	}
+	assert.Equal(t, 0, hub.Len())
+}
+
+func TestHub_SlowConsumerClosed(t *testing.T) {
+	connected := make(chan *Conn, 1)
+	hub := NewHub(
+		WithSendQueueSize(1),
+		WithOnConnect(func(c *Conn) error {
+			connected <- c
+			return nil
+		}),
+	)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	dial(t, srv.URL)
+	c := <-connected
+
+	var err error
+	for range 1000 {
+		if err = c.SendBinary(make([]byte, 64<<10)); err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, ErrSendQueueFull)
+	assert.ErrorIs(t, c.SendText("late"), ErrConnClosed)
+}
+
+func TestServer_StopClosesConnections(t *testing.T) {
+	hub := NewHub()
+	s := NewServer(hub, WithAddr("127.0.0.1:18995"), WithPath("/stream"))
+	require.NoError(t, s.Start(context.Background()))
+
+	ws := dial(t, "http://127.0.0.1:18995/stream")
+	require.Eventually(t, func() bool { return hub.Len() == 1 }, 2*time.Second, 10*time.Millisecond)
+	hub.Broadcast(wsx.TextMessage, []byte("bye"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop(ctx) }()
+
+	// Queued messages are flushed before the going-away frame.
+	assert.Equal(t, "bye", readText(t, ws))
+	_, _, err := ws.ReadMessage()
+	var ce *gws.CloseError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, gws.CloseGoingAway, ce.Code)
+	require.NoError(t, <-stopped)
+	assert.Equal(t, 0, hub.Len())
+
+	// A stopped server accepts connections again after Start.
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+	resp, err := http.Get("http://127.0.0.1:18995/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode) // not an upgrade request, but not rejected as shut down
+}
+
+func TestHub_RequestResponse(t *testing.T) {
+	connected := make(chan *Conn, 1)
+	hub := NewHub(
+		WithOnConnect(func(c *Conn) error {
+			connected <- c
+			return nil
+		}),
+		WithOnRequest(func(_ context.Context, _ *Conn, payload []byte) ([]byte, error) {
+			if string(payload) == "fail" {
+				return nil, errors.New("bad request")
+			}
+			return append([]byte("re:"), payload...), nil
+		}),
+	)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	client := wsx.New(
+		wsx.WithReconnect(wsx.ReconnectConfig{}),
+		wsx.WithRequestHandler(func(ctx context.Context, payload []byte) ([]byte, error) {
+			if string(payload) == "block" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return append([]byte("client:"), payload...), nil
+		}),
+	)
+	defer client.Close()
+	require.NoError(t, client.Connect(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")))
+	conn := <-connected
+
+	// Concurrent client → server requests are matched to their own responses.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errs := make(chan error, 20)
+	for i := range 20 {
+		go func() {
+			want := fmt.Sprintf("req-%d", i)
+			resp, err := client.SendRequest(ctx, []byte(want))
+			if err == nil && string(resp) != "re:"+want {
+				err = fmt.Errorf("got %q for %q", resp, want)
+			}
+			errs <- err
+		}()
+	}
+	for range 20 {
+		require.NoError(t, <-errs)
+	}
+
+	_, err := client.SendRequest(ctx, []byte("fail"))
+	var remote *wsx.RemoteError
+	require.ErrorAs(t, err, &remote)
+	assert.Equal(t, "bad request", remote.Message)
+
+	// Server → client.
+	resp, err := conn.SendRequest(ctx, []byte("ping"))
+	require.NoError(t, err)
+	assert.Equal(t, "client:ping", string(resp))
+
+	// Pending requests fail when the connection closes.
+	pending := make(chan error, 1)
+	go func() {
+		_, err := conn.SendRequest(context.Background(), []byte("block"))
+		pending <- err
+	}()
+	require.NoError(t, client.Disconnect())
+	select {
+	case err := <-pending:
+		assert.ErrorIs(t, err, ErrConnClosed)
+	case <-time.After(3 * time.Second):
+		t.Fatal("pending request not failed on close")
+	}
+}
+
+func TestHub_Topics(t *testing.T) {
+	type chat struct {
+		Room string `json:"room"`
+		Text string `json:"text"`
+	}
+
+	messages := make(chan wsx.Message, 1)
+	hub := NewHub(
+		OnTopic("chat.join", func(c *Conn, room string) { c.Join(room) }),
+		OnTopic("chat.send", func(c *Conn, m chat) {
+			_, _ = c.hub.BroadcastRoomTopic(m.Room, "chat.message", m)
+		}),
+		WithOnMessage(func(_ *Conn, msg wsx.Message) { messages <- msg }),
+	)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	client := wsx.New(wsx.WithReconnect(wsx.ReconnectConfig{}))
+	defer client.Close()
+	received := make(chan chat, 1)
+	wsx.OnTopic(client, "chat.message", func(m chat) { received <- m })
+	require.NoError(t, client.Connect(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")))
+
+	require.NoError(t, client.SendTopic("chat.join", "lobby"))
+	require.Eventually(t, func() bool { return hub.RoomLen("lobby") == 1 }, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, client.SendTopic("chat.send", chat{Room: "lobby", Text: "hi"}))
+	select {
+	case m := <-received:
+		assert.Equal(t, chat{Room: "lobby", Text: "hi"}, m)
+	case <-time.After(2 * time.Second):
+		t.Fatal("topic message not received")
+	}
+
+	// Undecodable payloads are dropped; unregistered topics reach OnMessage.
+	require.NoError(t, client.SendTopic("chat.send", 42))
+	require.NoError(t, client.SendTopic("other", 1))
+	select {
+	case msg := <-messages:
+		assert.Equal(t, `{"topic":"other","data":1}`, string(msg.Data))
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnMessage not called")
+	}
+	assert.Empty(t, received)
+}
+
+func TestHub_Resume(t *testing.T) {
+	resumed := make(chan string, 1)
+	hub := NewHub(
+		WithOnResume(func(c *Conn, token string) error {
+			if token == "revoked" {
+				return errors.New("session revoked")
+			}
+			c.Join("room-of-" + token)
+			resumed <- token
+			return nil
+		}),
+		WithOnConnect(func(c *Conn) error {
+			// The resume hook runs first, so restored state is visible here.
+			if c.ResumeToken() != "" && len(c.Rooms()) == 0 {
+				return errors.New("state not restored")
+			}
+			return nil
+		}),
+	)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	header := http.Header{wsx.ResumeTokenHeader: []string{"s1"}}
+	ws, _, err := gws.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	defer ws.Close()
+	assert.Equal(t, "s1", <-resumed)
+	require.Eventually(t, func() bool { return hub.RoomLen("room-of-s1") == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	header.Set(wsx.ResumeTokenHeader, "revoked")
+	rejected, _, err := gws.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	defer rejected.Close()
+	_ = rejected.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = rejected.ReadMessage()
+	var ce *gws.CloseError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, "session revoked", ce.Text)
+
+	// A new session carries no token and skips the hook.
+	dial(t, srv.URL)
+	select {
+	case token := <-resumed:
+		t.Fatalf("unexpected resume with %q", token)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
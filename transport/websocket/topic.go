@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"fmt"
+
+	"github.com/kochabx/kit/core/wsx"
+	"github.com/kochabx/kit/log"
+)
+
+// topicHandler decodes a topic payload with codec and calls a typed handler.
+type topicHandler func(codec wsx.TopicCodec, c *Conn, payload []byte) error
+
+// WithTopicCodec sets the codec for topic messages (OnTopic, SendTopic and
+// the BroadcastTopic helpers), wsx.JSONTopicCodec by default. Clients must
+// use the same codec (wsx.WithTopicCodec).
+func WithTopicCodec(codec wsx.TopicCodec) HubOption {
+	return func(c *hubConfig) {
+		if codec != nil {
+			c.topicCodec = codec
+		}
+	}
+}
+
+// OnTopic registers a typed handler for topic. The payload is decoded into
+// a T with the Hub's topic codec before fn is called:
+//
+//	hub := websocket.NewHub(
+//		websocket.OnTopic("chat.message", func(c *websocket.Conn, m ChatMessage) { … }),
+//	)
+//
+// Like WithOnMessage handlers, fn runs on the connection's read goroutine.
+// Messages for a registered topic do not reach the OnMessage handler;
+// messages that fail to decode are logged and dropped. Messages for other
+// topics are delivered to OnMessage unchanged.
+func OnTopic[T any](topic string, fn func(*Conn, T)) HubOption {
+	return func(c *hubConfig) {
+		if c.topics == nil {
+			c.topics = make(map[string]topicHandler)
+		}
+		c.topics[topic] = func(codec wsx.TopicCodec, conn *Conn, payload []byte) error {
+			var v T
+			if err := codec.Unmarshal(payload, &v); err != nil {
+				return fmt.Errorf("websocket: unmarshal topic %s: %w", topic, err)
+			}
+			fn(conn, v)
+			return nil
+		}
+	}
+}
+
+// SendTopic encodes v with the Hub's topic codec and queues it as a topic
+// message, see Send.
+func (c *Conn) SendTopic(topic string, v any) error {
+	msg, err := c.hub.cfg.topicCodec.Encode(topic, v)
+	if err != nil {
+		return err
+	}
+	return c.Send(msg.Type, msg.Data)
+}
+
+// BroadcastTopic encodes v once and broadcasts it as a topic message, see
+// Broadcast.
+func (h *Hub) BroadcastTopic(topic string, v any) (int, error) {
+	msg, err := h.cfg.topicCodec.Encode(topic, v)
+	if err != nil {
+		return 0, err
+	}
+	return h.Broadcast(msg.Type, msg.Data), nil
+}
+
+// BroadcastRoomTopic is like BroadcastTopic but only targets the
+// connections in room.
+func (h *Hub) BroadcastRoomTopic(room, topic string, v any) (int, error) {
+	msg, err := h.cfg.topicCodec.Encode(topic, v)
+	if err != nil {
+		return 0, err
+	}
+	return h.BroadcastRoom(room, msg.Type, msg.Data), nil
+}
+
+// handleTopic dispatches msg to its topic handler and reports whether one
+// was registered.
+func (c *Conn) handleTopic(msg wsx.Message) bool {
+	cfg := &c.hub.cfg
+	if len(cfg.topics) == 0 {
+		return false
+	}
+	topic, payload, ok := cfg.topicCodec.Decode(msg)
+	if !ok {
+		return false
+	}
+	h, ok := cfg.topics[topic]
+	if !ok {
+		return false
+	}
+	if err := h(cfg.topicCodec, c, payload); err != nil {
+		log.Warn().Str("conn", c.id).Err(err).Msg("websocket topic message dropped")
+	}
+	return true
+}